@@ -185,16 +185,27 @@ func RunMigrations(db *gorm.DB) error {
 		&utils.Config{},
 		&sipPersist.SIPUser{},
 		&sipPersist.SIPCall{},
+		&sipPersist.SIPMessage{},
 		&models.ACDPoolTarget{},
 		&models.SIPACDTransferOffer{},
+		&models.ACDWorkStateEvent{},
 		&models.SIPCampaign{},
 		&models.SIPCampaignContact{},
 		&models.SIPCallAttempt{},
 		&models.SIPScriptRun{},
 		&models.SIPCampaignEvent{},
+		&models.SIPClickToCall{},
+		&models.SIPClickToCallEvent{},
 		&models.SIPScriptTemplate{},
+		&models.SIPRecordingPause{},
+		&models.SIPDispositionCode{},
+		&models.RecordingDataKey{},
+		&models.RetentionPurgeLog{},
 		&models.Trunk{},
 		&models.TrunkNumber{},
+		&models.TrunkGroup{},
+		&models.TrunkGroupMember{},
+		&models.TrunkRoute{},
 		&models.Tenant{},
 		&models.TenantGroup{},
 		&models.TenantUser{},
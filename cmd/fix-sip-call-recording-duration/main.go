@@ -75,7 +75,7 @@ func main() {
 			log.Printf("id=%d call_id=%s fetch: %v", row.ID, row.CallID, err)
 			continue
 		}
-		durSec := sipPersist.WAVDurationSec(wav)
+		durSec := sipPersist.RecordingDurationSec(wav)
 		if durSec <= 0 {
 			failed++
 			log.Printf("id=%d call_id=%s parse wav duration failed (bytes=%d)", row.ID, row.CallID, len(wav))
@@ -109,6 +109,8 @@ func main() {
 	app := NewLingEchoApp(db)
 	sipUserCleaner := tasks.NewSIPUserOnlineCleaner(db, time.Duration(utils.GetIntEnv("SIP_USER_ONLINE_SWEEP_SECONDS"))*time.Second)
 	sipUserCleaner.Start()
+	retentionPurger := tasks.NewRetentionPurger(db)
+	retentionPurger.Start()
 	if config.GlobalConfig.Features.BackupEnabled {
 		backup.StartBackupScheduler(db)
 	}
@@ -206,6 +208,14 @@ func main() {
 	}
 	sipEmbedded = se
 	app.handlers.SetCampaignService(sipEmbedded.CampaignService())
+	app.handlers.SetCallSessionLookup(sipEmbedded.CallSession)
+	app.handlers.SetTrunkRegistrationHooks(sipEmbedded.TrunkRegistrations, sipEmbedded.ReloadTrunkRegistrations)
+	app.handlers.SetTrunkRoutingHooks(sipEmbedded.TrunkHealths, sipEmbedded.TrunkStats, sipEmbedded.ReloadTrunkHealth)
+	app.handlers.SetSIPWebSocketHandler(sipEmbedded.SIPWebSocketHandler())
+	app.handlers.SetChatService(sipEmbedded.ChatService())
+	app.handlers.SetClickToCallService(sipEmbedded.ClickToCallService())
+	app.handlers.SetACWService(sipEmbedded.ACWService())
+	app.handlers.SetWallboardService(sipEmbedded.WallboardService())
 	logger.Info("embedded SIP stack started",
 		zap.String("sip_host", *sipHost),
 		zap.Int("sip_port", *sipPort),
@@ -241,6 +251,7 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 		defer cancel()
 		sipUserCleaner.Stop()
+		retentionPurger.Stop()
 		if sipEmbedded != nil {
 			sipEmbedded.Shutdown(ctx)
 		}
@@ -0,0 +1,182 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+
+	"github.com/LinByte/VoiceServer/pkg/voice/vad"
+)
+
+// clip is one training sequence: a feature vector and a 0/1 speech
+// label per 20 ms frame.
+type clip struct {
+	x [][]float64
+	y []float64
+}
+
+// Speech labelling: a clean frame is speech when it is within
+// labelRangeDB of the loudest frame of its clip and above labelFloor.
+const (
+	labelRangeDB = 35
+	labelFloor   = 5 // RMS after gain, int16 units
+)
+
+type generator struct {
+	rng    *rand.Rand
+	rate   int
+	speech [][]float64
+	noise  [][]float64
+}
+
+// clip renders one mixture. A quarter are noise only; the rest are an
+// excerpt of speech with noise before, under and after it.
+func (g *generator) clip() clip {
+	frame := g.rate * vad.FrameDuration / 1000
+	var clean []float64
+	if g.rng.Float64() >= 0.25 && len(g.speech) > 0 {
+		src := g.speech[g.rng.Intn(len(g.speech))]
+		n := min(len(src), g.seconds(1, 3))
+		start := g.rng.Intn(len(src) - n + 1)
+		gain := math.Exp(g.uniform(math.Log(0.05), 0))
+		clean = append(clean, make([]float64, g.seconds(0.2, 1))...)
+		for _, s := range src[start : start+n] {
+			clean = append(clean, s*gain)
+		}
+		clean = append(clean, make([]float64, g.seconds(0.2, 1))...)
+	} else {
+		clean = make([]float64, g.seconds(2, 3))
+	}
+	frames := len(clean) / frame
+	clean = clean[:frames*frame]
+
+	labels := make([]float64, frames)
+	energy := make([]float64, frames)
+	peak := 0.0
+	var speechSq float64
+	for f := range labels {
+		var sq float64
+		for _, s := range clean[f*frame : (f+1)*frame] {
+			sq += s * s
+		}
+		energy[f] = math.Sqrt(sq / float64(frame))
+		peak = math.Max(peak, energy[f])
+	}
+	for f, e := range energy {
+		if e >= labelFloor && e >= peak*math.Pow(10, -labelRangeDB/20.0) {
+			labels[f] = 1
+		}
+	}
+	// One frame of dilation: onsets and releases belong to the word.
+	dilated := make([]float64, frames)
+	speechFrames := 0
+	for f := range labels {
+		if labels[f] == 1 || (f > 0 && labels[f-1] == 1) || (f+1 < frames && labels[f+1] == 1) {
+			dilated[f] = 1
+		}
+		if labels[f] == 1 {
+			speechFrames++
+			for _, s := range clean[f*frame : (f+1)*frame] {
+				speechSq += s * s
+			}
+		}
+	}
+
+	var noiseRMS float64
+	if speechFrames > 0 {
+		speechRMS := math.Sqrt(speechSq / float64(speechFrames*frame))
+		noiseRMS = speechRMS / math.Pow(10, g.uniform(0, 30)/20)
+	} else {
+		noiseRMS = math.Exp(g.uniform(math.Log(3), math.Log(5000)))
+	}
+	noise := g.noiseSignal(len(clean))
+	pcm := make([]byte, 2*len(clean))
+	for i, s := range clean {
+		v := math.Max(math.MinInt16, math.Min(math.MaxInt16, s+noise[i]*noiseRMS))
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(v)))
+	}
+
+	feats := vad.Features(pcm, g.rate)
+	c := clip{x: make([][]float64, len(feats)), y: dilated[:len(feats)]}
+	for i, f := range feats {
+		c.x[i] = append([]float64(nil), f[:]...)
+	}
+	return c
+}
+
+// noiseSignal returns n samples of unit-RMS noise of a random kind.
+func (g *generator) noiseSignal(n int) []float64 {
+	out := make([]float64, n)
+	rate := float64(g.rate)
+	switch k := g.rng.Intn(7); {
+	case k == 0: // white
+		for i := range out {
+			out[i] = g.rng.NormFloat64()
+		}
+	case k == 1: // pink (Paul Kellet's economy filter)
+		var b0, b1, b2 float64
+		for i := range out {
+			w := g.rng.NormFloat64()
+			b0 = 0.99765*b0 + w*0.0990460
+			b1 = 0.96300*b1 + w*0.2965164
+			b2 = 0.57000*b2 + w*1.0526913
+			out[i] = b0 + b1 + b2 + w*0.1848
+		}
+	case k == 2: // brown
+		var b float64
+		for i := range out {
+			b = 0.995*b + g.rng.NormFloat64()
+			out[i] = b
+		}
+	case k == 3: // mains hum with harmonics
+		f0 := 50.0
+		if g.rng.Intn(2) == 1 {
+			f0 = 60
+		}
+		for h := 1; h <= 7; h++ {
+			ph := g.uniform(0, 2*math.Pi)
+			for i := range out {
+				out[i] += math.Sin(2*math.Pi*f0*float64(h)*float64(i)/rate+ph) / float64(h)
+			}
+		}
+	case k == 4: // dual tone: DTMF, dial tone, busy tone
+		f1, f2 := g.uniform(300, 1000), g.uniform(900, 1700)
+		on := g.seconds(0.05, 1)
+		off := g.seconds(0, 0.5)
+		for i := range out {
+			if (on+off) > 0 && i%(on+off) >= on {
+				continue
+			}
+			t := float64(i) / rate
+			out[i] = math.Sin(2*math.Pi*f1*t) + math.Sin(2*math.Pi*f2*t)
+		}
+	case k == 5 && len(g.noise) > 0: // recorded non-speech (ringback)
+		src := g.noise[g.rng.Intn(len(g.noise))]
+		start := g.rng.Intn(len(src))
+		for i := range out {
+			out[i] = src[(start+i)%len(src)]
+		}
+	default: // near-digital silence / comfort noise
+		for i := range out {
+			out[i] = g.rng.NormFloat64() * 0.05
+		}
+		return out
+	}
+	var sq float64
+	for _, v := range out {
+		sq += v * v
+	}
+	if rms := math.Sqrt(sq / float64(max(n, 1))); rms > 0 {
+		for i := range out {
+			out[i] /= rms
+		}
+	}
+	return out
+}
+
+func (g *generator) uniform(lo, hi float64) float64 { return lo + (hi-lo)*g.rng.Float64() }
+
+func (g *generator) seconds(lo, hi float64) int { return int(g.uniform(lo, hi) * float64(g.rate)) }
@@ -0,0 +1,259 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/LinByte/VoiceServer/pkg/voice/vad"
+)
+
+// net holds trainable GRU weights laid out like vad.GRUModel, whose
+// equations forward mirrors exactly.
+type net struct {
+	h                      int
+	wz, uz, wr, ur, wh, uh [][]float64
+	bz, br, bh, wo         []float64
+	bo                     []float64 // one entry
+}
+
+func newNet(hidden int, rng *rand.Rand) *net {
+	n := zeroNet(hidden)
+	initRows := func(m [][]float64, fanIn int) {
+		lim := math.Sqrt(6 / float64(fanIn+hidden))
+		for _, row := range m {
+			for i := range row {
+				row[i] = (2*rng.Float64() - 1) * lim
+			}
+		}
+	}
+	initRows(n.wz, vad.FeatureSize)
+	initRows(n.wr, vad.FeatureSize)
+	initRows(n.wh, vad.FeatureSize)
+	initRows(n.uz, hidden)
+	initRows(n.ur, hidden)
+	initRows(n.uh, hidden)
+	initRows([][]float64{n.wo}, hidden)
+	return n
+}
+
+func zeroNet(hidden int) *net {
+	mat := func(cols int) [][]float64 {
+		m := make([][]float64, hidden)
+		for i := range m {
+			m[i] = make([]float64, cols)
+		}
+		return m
+	}
+	return &net{
+		h:  hidden,
+		wz: mat(vad.FeatureSize), wr: mat(vad.FeatureSize), wh: mat(vad.FeatureSize),
+		uz: mat(hidden), ur: mat(hidden), uh: mat(hidden),
+		bz: make([]float64, hidden), br: make([]float64, hidden), bh: make([]float64, hidden),
+		wo: make([]float64, hidden), bo: make([]float64, 1),
+	}
+}
+
+// params lists every weight slice in a fixed order, so nets of the
+// same size can be walked in lockstep.
+func (n *net) params() [][]float64 {
+	var out [][]float64
+	for _, m := range [][][]float64{n.wz, n.uz, n.wr, n.ur, n.wh, n.uh} {
+		out = append(out, m...)
+	}
+	return append(out, n.bz, n.br, n.bh, n.wo, n.bo)
+}
+
+// trace is one forward pass, kept for backpropagation.
+type trace struct {
+	hPrev, z, r, c, h [][]float64
+	p                 []float64
+}
+
+func (n *net) forward(xs [][]float64) trace {
+	T := len(xs)
+	tr := trace{
+		hPrev: make([][]float64, T), z: make([][]float64, T), r: make([][]float64, T),
+		c: make([][]float64, T), h: make([][]float64, T), p: make([]float64, T),
+	}
+	h := make([]float64, n.h)
+	for t, x := range xs {
+		z, r, c := make([]float64, n.h), make([]float64, n.h), make([]float64, n.h)
+		rh := make([]float64, n.h)
+		for j := 0; j < n.h; j++ {
+			r[j] = sigmoid(dot(n.wr[j], x) + dot(n.ur[j], h) + n.br[j])
+			rh[j] = r[j] * h[j]
+		}
+		for j := 0; j < n.h; j++ {
+			c[j] = math.Tanh(dot(n.wh[j], x) + dot(n.uh[j], rh) + n.bh[j])
+			z[j] = sigmoid(dot(n.wz[j], x) + dot(n.uz[j], h) + n.bz[j])
+		}
+		next := make([]float64, n.h)
+		for j := range next {
+			next[j] = (1-z[j])*h[j] + z[j]*c[j]
+		}
+		tr.hPrev[t], tr.z[t], tr.r[t], tr.c[t], tr.h[t] = h, z, r, c, next
+		tr.p[t] = sigmoid(dot(n.wo, next) + n.bo[0])
+		h = next
+	}
+	return tr
+}
+
+// backward adds the cross-entropy gradient of one clip to g and
+// returns its summed loss.
+func (n *net) backward(c clip, g *net) float64 {
+	tr := n.forward(c.x)
+	H := n.h
+	dh := make([]float64, H)
+	az, ar, ac := make([]float64, H), make([]float64, H), make([]float64, H)
+	drh, rh := make([]float64, H), make([]float64, H)
+	var loss float64
+	for t := len(c.x) - 1; t >= 0; t-- {
+		p, y := tr.p[t], c.y[t]
+		loss -= y*math.Log(p+1e-9) + (1-y)*math.Log(1-p+1e-9)
+		dy := p - y
+		x, hp, z, r, cc, h := c.x[t], tr.hPrev[t], tr.z[t], tr.r[t], tr.c[t], tr.h[t]
+		for j := 0; j < H; j++ {
+			g.wo[j] += dy * h[j]
+			dh[j] += dy * n.wo[j]
+		}
+		g.bo[0] += dy
+
+		dhPrev := make([]float64, H)
+		for j := 0; j < H; j++ {
+			az[j] = dh[j] * (cc[j] - hp[j]) * z[j] * (1 - z[j])
+			ac[j] = dh[j] * z[j] * (1 - cc[j]*cc[j])
+			dhPrev[j] += dh[j] * (1 - z[j])
+			rh[j] = r[j] * hp[j]
+		}
+		for i := range drh {
+			drh[i] = 0
+		}
+		for j := 0; j < H; j++ {
+			addOuter(g.wz[j], az[j], x)
+			addOuter(g.uz[j], az[j], hp)
+			g.bz[j] += az[j]
+			addOuter(g.wh[j], ac[j], x)
+			addOuter(g.uh[j], ac[j], rh)
+			g.bh[j] += ac[j]
+			for k := 0; k < H; k++ {
+				dhPrev[k] += n.uz[j][k] * az[j]
+				drh[k] += n.uh[j][k] * ac[j]
+			}
+		}
+		for k := 0; k < H; k++ {
+			dhPrev[k] += drh[k] * r[k]
+			ar[k] = drh[k] * hp[k] * r[k] * (1 - r[k])
+		}
+		for j := 0; j < H; j++ {
+			addOuter(g.wr[j], ar[j], x)
+			addOuter(g.ur[j], ar[j], hp)
+			g.br[j] += ar[j]
+			for k := 0; k < H; k++ {
+				dhPrev[k] += n.ur[j][k] * ar[j]
+			}
+		}
+		copy(dh, dhPrev)
+	}
+	return loss
+}
+
+// adam is the optimiser state for one net.
+type adam struct {
+	n, m, v *net
+	lr      float64
+	t       int
+}
+
+func newAdam(n *net, lr float64) *adam {
+	return &adam{n: n, m: zeroNet(n.h), v: zeroNet(n.h), lr: lr}
+}
+
+// step applies one update from a batch of clips and returns the
+// batch's mean per-frame loss times its clip count.
+func (a *adam) step(batch []clip) float64 {
+	g := zeroNet(a.n.h)
+	var loss float64
+	frames := 0
+	for _, c := range batch {
+		loss += a.n.backward(c, g)
+		frames += len(c.x)
+	}
+	if frames == 0 {
+		return 0
+	}
+	scale := 1 / float64(frames)
+	var norm float64
+	for _, p := range g.params() {
+		for i := range p {
+			p[i] *= scale
+			norm += p[i] * p[i]
+		}
+	}
+	if norm = math.Sqrt(norm); norm > 1 {
+		for _, p := range g.params() {
+			for i := range p {
+				p[i] /= norm
+			}
+		}
+	}
+	a.t++
+	const b1, b2 = 0.9, 0.999
+	c1, c2 := 1-math.Pow(b1, float64(a.t)), 1-math.Pow(b2, float64(a.t))
+	ps, gs, ms, vs := a.n.params(), g.params(), a.m.params(), a.v.params()
+	for k := range ps {
+		for i := range ps[k] {
+			ms[k][i] = b1*ms[k][i] + (1-b1)*gs[k][i]
+			vs[k][i] = b2*vs[k][i] + (1-b2)*gs[k][i]*gs[k][i]
+			ps[k][i] -= a.lr * (ms[k][i] / c1) / (math.Sqrt(vs[k][i]/c2) + 1e-8)
+		}
+	}
+	return loss / float64(frames) * float64(len(batch))
+}
+
+// model exports the weights with the normalisation they were trained on.
+func (n *net) model(mean, std []float64) *vad.GRUModel {
+	mat := func(m [][]float64) [][]float64 {
+		out := make([][]float64, len(m))
+		for i, row := range m {
+			out[i] = vec(row)
+		}
+		return out
+	}
+	return &vad.GRUModel{
+		Version: 1,
+		Hidden:  n.h,
+		Mean:    mean,
+		Std:     std,
+		Wz:      mat(n.wz), Uz: mat(n.uz), Bz: vec(n.bz),
+		Wr: mat(n.wr), Ur: mat(n.ur), Br: vec(n.br),
+		Wh: mat(n.wh), Uh: mat(n.uh), Bh: vec(n.bh),
+		Wo: vec(n.wo), Bo: round(n.bo[0]),
+	}
+}
+
+func vec(v []float64) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = round(x)
+	}
+	return out
+}
+
+func addOuter(dst []float64, a float64, x []float64) {
+	for i, v := range x {
+		dst[i] += a * v
+	}
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
@@ -0,0 +1,204 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// vad-train fits the GRU speech classifier behind SIP_VAD_ENGINE=neural
+// and writes it as a vad.GRUModel JSON file.
+//
+// Training clips are built from clean speech WAVs mixed with noise at
+// random SNRs: white / pink / brown noise, mains hum, dual tones (DTMF,
+// dial tone) and excerpts of the -noise WAVs (ringback). Frame labels
+// come from the clean speech energy, so the model learns to ignore
+// whatever is mixed in. Every clip is rendered at 8 kHz and 16 kHz and
+// run through vad.Features, the same analysis the detector serves.
+//
+// Usage (from repo root; the defaults reproduce models/vad_gru.json):
+//
+//	go run ./cmd/vad-train
+//	go run ./cmd/vad-train -speech a.wav,b.wav -holdout c.wav -out /tmp/vad.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+
+	sipConversation "github.com/LinByte/VoiceServer/pkg/sip/conversation"
+	"github.com/LinByte/VoiceServer/pkg/voice/vad"
+)
+
+const (
+	defaultSpeech = "scripts/welcome.wav,scripts/config_error.wav,scripts/QuotaWarning.wav," +
+		"scripts/transfer_confirm_execute.wav,scripts/transfer_confirm_normal.wav"
+	defaultHoldout = "scripts/not_bind.wav"
+	defaultNoise   = "scripts/ringing.wav"
+)
+
+var rates = []int{8000, 16000}
+
+func main() {
+	speechFlag := flag.String("speech", defaultSpeech, "comma-separated clean speech WAVs to train on")
+	holdoutFlag := flag.String("holdout", defaultHoldout, "comma-separated speech WAVs kept out of training for evaluation")
+	noiseFlag := flag.String("noise", defaultNoise, "comma-separated non-speech WAVs mixed in as noise")
+	out := flag.String("out", "models/vad_gru.json", "model file to write")
+	hidden := flag.Int("hidden", 16, "GRU hidden units")
+	clips := flag.Int("clips", 600, "training clips per sample rate")
+	epochs := flag.Int("epochs", 40, "passes over the training clips")
+	batch := flag.Int("batch", 16, "clips per gradient step")
+	lr := flag.Float64("lr", 0.005, "Adam learning rate")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(*seed))
+	train, err := loadSet(*speechFlag)
+	if err != nil {
+		log.Fatalf("speech: %v", err)
+	}
+	holdout, err := loadSet(*holdoutFlag)
+	if err != nil {
+		log.Fatalf("holdout: %v", err)
+	}
+	noise, err := loadSet(*noiseFlag)
+	if err != nil {
+		log.Fatalf("noise: %v", err)
+	}
+
+	var trainClips, evalClips []clip
+	for _, rate := range rates {
+		g := &generator{rng: rng, rate: rate, speech: train[rate], noise: noise[rate]}
+		for i := 0; i < *clips; i++ {
+			trainClips = append(trainClips, g.clip())
+		}
+		if len(holdout[rate]) > 0 {
+			g.speech = holdout[rate]
+			for i := 0; i < *clips/5; i++ {
+				evalClips = append(evalClips, g.clip())
+			}
+		}
+	}
+	log.Printf("clips: %d train, %d eval", len(trainClips), len(evalClips))
+
+	mean, std := featureStats(trainClips)
+	normalize(trainClips, mean, std)
+	normalize(evalClips, mean, std)
+
+	n := newNet(*hidden, rng)
+	opt := newAdam(n, *lr)
+	for ep := 1; ep <= *epochs; ep++ {
+		rng.Shuffle(len(trainClips), func(i, j int) { trainClips[i], trainClips[j] = trainClips[j], trainClips[i] })
+		var loss float64
+		for i := 0; i < len(trainClips); i += *batch {
+			end := min(i+*batch, len(trainClips))
+			loss += opt.step(trainClips[i:end])
+		}
+		if ep%5 == 0 || ep == *epochs {
+			log.Printf("epoch %d: train loss %.4f, %s", ep, loss/float64(len(trainClips)), evaluate(n, evalClips))
+		}
+	}
+
+	m := n.model(mean, std)
+	data, err := json.Marshal(m)
+	if err != nil {
+		log.Fatalf("encode: %v", err)
+	}
+	if _, err := vad.ParseGRUModel(data); err != nil {
+		log.Fatalf("self-check: %v", err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatalf("write: %v", err)
+	}
+	fmt.Printf("wrote %s (%d bytes)\n", *out, len(data)+1)
+}
+
+// loadSet reads every WAV in list once per training rate.
+func loadSet(list string) (map[int][][]float64, error) {
+	set := map[int][][]float64{}
+	for _, path := range strings.Split(list, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		for _, rate := range rates {
+			pcm, err := sipConversation.LoadWAVAsPCM16Mono(path, rate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			samples := make([]float64, len(pcm)/2)
+			for i := range samples {
+				samples[i] = float64(int16(uint16(pcm[2*i]) | uint16(pcm[2*i+1])<<8))
+			}
+			set[rate] = append(set[rate], samples)
+		}
+	}
+	return set, nil
+}
+
+// evaluate reports frame accuracy at p ≥ 0.5 and the false-positive
+// rate on non-speech frames.
+func evaluate(n *net, clips []clip) string {
+	if len(clips) == 0 {
+		return "no holdout"
+	}
+	var right, total, fp, neg int
+	for _, c := range clips {
+		for t, p := range n.forward(c.x).p {
+			speech := p >= 0.5
+			if speech == (c.y[t] == 1) {
+				right++
+			}
+			if c.y[t] == 0 {
+				neg++
+				if speech {
+					fp++
+				}
+			}
+			total++
+		}
+	}
+	return fmt.Sprintf("holdout accuracy %.3f, false positives %.3f", float64(right)/float64(total), float64(fp)/float64(max(neg, 1)))
+}
+
+func featureStats(clips []clip) (mean, std []float64) {
+	mean = make([]float64, vad.FeatureSize)
+	std = make([]float64, vad.FeatureSize)
+	var n float64
+	for _, c := range clips {
+		for _, x := range c.x {
+			for i, v := range x {
+				mean[i] += v
+			}
+			n++
+		}
+	}
+	for i := range mean {
+		mean[i] /= n
+	}
+	for _, c := range clips {
+		for _, x := range c.x {
+			for i, v := range x {
+				std[i] += (v - mean[i]) * (v - mean[i])
+			}
+		}
+	}
+	for i := range std {
+		std[i] = math.Sqrt(std[i]/n) + 1e-6
+		mean[i], std[i] = round(mean[i]), round(std[i])
+	}
+	return mean, std
+}
+
+func normalize(clips []clip, mean, std []float64) {
+	for _, c := range clips {
+		for _, x := range c.x {
+			for i := range x {
+				x[i] = (x[i] - mean[i]) / std[i]
+			}
+		}
+	}
+}
+
+// round keeps five decimals so the shipped JSON stays small.
+func round(v float64) float64 { return math.Round(v*1e5) / 1e5 }
@@ -35,6 +35,7 @@ require (
 	github.com/pion/dtls/v2 v2.2.12
 	github.com/pion/rtcp v1.2.14
 	github.com/pion/srtp/v2 v2.0.20
+	github.com/pion/stun v0.6.1
 	github.com/pion/webrtc/v3 v3.3.6
 	github.com/pquerna/otp v1.5.0
 	github.com/qiniu/go-sdk/v7 v7.26.10
@@ -48,6 +49,7 @@ require (
 	github.com/tencentyun/cos-go-sdk-v5 v0.7.73
 	go.uber.org/zap v1.27.0
 	golang.org/x/crypto v0.48.0
+	golang.org/x/net v0.50.0
 	golang.org/x/sys v0.44.0
 	golang.org/x/time v0.14.0
 	gorm.io/datatypes v1.2.7
@@ -147,7 +149,6 @@ require (
 	github.com/pion/rtp v1.8.25 // indirect
 	github.com/pion/sctp v1.8.19 // indirect
 	github.com/pion/sdp/v3 v3.0.9 // indirect
-	github.com/pion/stun v0.6.1 // indirect
 	github.com/pion/transport/v2 v2.2.10 // indirect
 	github.com/pion/turn/v2 v2.1.6 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
@@ -172,7 +173,6 @@ require (
 	go.uber.org/multierr v1.10.0 // indirect
 	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/arch v0.23.0 // indirect
-	golang.org/x/net v0.50.0 // indirect
 	golang.org/x/oauth2 v0.32.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/text v0.34.0 // indirect
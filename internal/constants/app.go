@@ -33,6 +33,7 @@ const (
 	CredentialAPISecret           = "X-API-SECRET"
 	LingechoWebSeatPathPrefix     = "lingecho/webseat/v1"
 	LingechoVoiceDialogPathPrefix = "lingecho/voice-dialog/v1"
+	SIPWebSocketPath              = "sip/ws"
 
 	KEYVoiceCloneXunfeiConfig     = "VOICE_CLONE_XUNFEI_CONFIG"
 	KEYVoiceCloneVolcengineConfig = "VOICE_CLONE_VOLCENGINE_CONFIG"
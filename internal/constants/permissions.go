@@ -12,6 +12,7 @@ const (
 // Built-in permission catalog codes (global RBAC).
 const (
 	PermAPISIPCallsRead      = "api.sip.calls.read"
+	PermAPISIPCallsWrite     = "api.sip.calls.write"
 	PermAPISIPACDRead        = "api.sip.acd.read"
 	PermAPISIPACDWrite       = "api.sip.acd.write"
 	PermAPISIPScriptsRead    = "api.sip.scripts.read"
@@ -32,3 +32,21 @@ const (
 
 // WebSeatStaleAfter is the max age of web-seat heartbeat for "online" eligibility.
 const WebSeatStaleAfter = 90 * time.Second
+
+// After-call work end reasons (sip_calls.acw_end_reason).
+const (
+	ACWEndWrappedUp = "wrapped_up" // agent submitted a disposition
+	ACWEndExpired   = "expired"    // ACW timer ran out
+	ACWEndCancelled = "cancelled"  // work_state left acw by another path (break, offline, admin)
+)
+
+// ACD break reasons (acd_pool_targets.work_state_reason, acd_work_state_events.reason).
+const (
+	ACDBreakReasonRest       = "rest"
+	ACDBreakReasonMeal       = "meal"
+	ACDBreakReasonMeeting    = "meeting"
+	ACDBreakReasonTraining   = "training"
+	ACDBreakReasonCoaching   = "coaching"
+	ACDBreakReasonBackoffice = "backoffice" // non-phone work (email, tickets)
+	ACDBreakReasonOther      = "other"
+)
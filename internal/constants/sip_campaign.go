@@ -15,3 +15,23 @@ const (
 	SIPCampaignContactExhausted  = "exhausted"
 	SIPCampaignContactSuppressed = "suppressed"
 )
+
+// Click-to-call (API-originated call) modes and statuses.
+const (
+	SIPClickToCallModeAI    = "ai"
+	SIPClickToCallModeAgent = "agent"
+
+	// Origin: the integrator API, or a web-seat agent dialing from the browser.
+	SIPClickToCallOriginAPI     = "api"
+	SIPClickToCallOriginWebSeat = "web_seat"
+
+	SIPClickToCallQueued          = "queued"
+	SIPClickToCallAgentRinging    = "agent_ringing"
+	SIPClickToCallAgentAnswered   = "agent_answered"
+	SIPClickToCallCustomerRinging = "customer_ringing"
+	SIPClickToCallAnswered        = "answered"
+	SIPClickToCallBridged         = "bridged"
+	SIPClickToCallCompleted       = "completed"
+	SIPClickToCallFailed          = "failed"
+	SIPClickToCallCanceled        = "canceled"
+)
@@ -26,6 +26,9 @@ const (
 	SIPScriptStepLLMReply  = "llm_reply"
 	SIPScriptStepCondition = "condition"
 	SIPScriptStepEnd       = "end"
+	// Recording control (PCI): pause / resume both legs of the call recording.
+	SIPScriptStepRecordPause  = "record_pause"
+	SIPScriptStepRecordResume = "record_resume"
 )
 
 // SIP hybrid script runtime event results.
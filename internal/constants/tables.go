@@ -5,16 +5,27 @@ package constants
 const (
 	SIPUserTableName              = "sip_users"
 	SIPCallTableName              = "sip_calls"
+	SIPMessageTableName           = "sip_messages"
 	SIPCampaignTableName          = "sip_campaigns"
 	SIPCampaignContactTableName   = "sip_campaign_contacts"
 	SIPCallAttemptTableName       = "sip_call_attempts"
 	SIPScriptRunTableName         = "sip_script_runs"
 	SIPCampaignEventTableName     = "sip_campaign_events"
+	SIPClickToCallTableName       = "sip_click_to_calls"
+	SIPClickToCallEventTableName  = "sip_click_to_call_events"
 	SIPScriptTemplateTableName    = "sip_script_templates"
 	ACDPoolTargetTableName        = "acd_pool_targets"
 	SIPACDTransferOfferTableName  = "sip_acd_transfer_offers"
+	ACDWorkStateEventTableName    = "acd_work_state_events"
+	SIPRecordingPauseTableName    = "sip_recording_pauses"
+	SIPDispositionCodeTableName   = "sip_disposition_codes"
+	RecordingDataKeyTableName     = "recording_data_keys"
+	RetentionPurgeLogTableName    = "retention_purge_logs"
 	SIPTrunkTableName             = "sip_trunks"
 	SIPTrunkNumberTableName       = "sip_trunk_numbers"
+	SIPTrunkGroupTableName        = "sip_trunk_groups"
+	SIPTrunkGroupMemberTableName  = "sip_trunk_group_members"
+	SIPTrunkRouteTableName        = "sip_trunk_routes"
 	TenantTableName               = "tenants"
 	TenantGroupTableName          = "tenant_groups"
 	TenantUserTableName           = "tenant_users"
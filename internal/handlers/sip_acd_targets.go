@@ -35,6 +35,8 @@ type acdPoolTargetWriteReq struct {
 	Weight               int    `json:"weight"`
 	SortOrder            int    `json:"sortOrder"`
 	WorkState            string `json:"workState"`
+	// BreakReason optional reason code when workState = break (rest, meal, meeting, training, coaching, backoffice, other).
+	BreakReason string `json:"breakReason"`
 	// ShiftSchedule JSON: e.g. [{"weekdays":[1,2,3,4,5],"start":"09:00","end":"18:00"}] (weekdays 0=Sun .. 6=Sat). Empty = 24/7.
 	ShiftSchedule string `json:"shiftSchedule"`
 	// Remark plain-text admin note (BaseModel.Remark, max 128 chars).
@@ -67,11 +69,32 @@ func normalizeACDPoolTargetWriteMeta(remark string, metaRaw json.RawMessage) (st
 	return nRemark, nMeta, nil
 }
 
-func (h *Handlers) reconcileACDPoolTargetShiftAfterSave(c *gin.Context, id uint) models.ACDPoolTarget {
+// reconcileACDPoolTargetShiftAfterSave applies the shift schedule after an admin save and
+// records / reports a work_state change made by the save itself (zero prev = new row).
+func (h *Handlers) reconcileACDPoolTargetShiftAfterSave(c *gin.Context, id uint, prev models.ACDPoolTarget) models.ACDPoolTarget {
 	row, err := models.ReloadACDPoolTargetByID(h.db, id)
 	if err != nil || row.ID == 0 {
 		return row
 	}
+	if row.WorkState != prev.WorkState || row.WorkStateReason != prev.WorkStateReason {
+		prevState := ""
+		if prev.ID > 0 {
+			prevState = models.NormalizeACDWorkState(prev.WorkState)
+		}
+		_ = models.AppendACDWorkStateEvent(c.Request.Context(), h.db, models.ACDWorkStateEvent{
+			TenantID:    row.TenantID,
+			ACDTargetID: row.ID,
+			PrevState:   prevState,
+			PrevReason:  prev.WorkStateReason,
+			WorkState:   row.WorkState,
+			Reason:      row.WorkStateReason,
+			Source:      middleware.AuditOperator(c),
+			At:          time.Now(),
+		})
+	}
+	if row.WorkState != prev.WorkState {
+		models.NotifyACDWorkStateChanged(row.ID, row.WorkState)
+	}
 	_, _ = models.ApplyACDPoolTargetShiftWorkState(c.Request.Context(), h.db, &row, time.Now(), "acd-shift")
 	row, _ = models.ReloadACDPoolTargetByID(h.db, id)
 	return row
@@ -203,6 +226,11 @@ func (h *Handlers) createACDPoolTarget(c *gin.Context) {
 		response.Fail(c, "workState 仅允许 available/offline/break", nil)
 		return
 	}
+	breakReason, err := models.NormalizeACDBreakReason(ws, req.BreakReason)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
 	nRemark, nMeta, err := normalizeACDPoolTargetWriteMeta(req.Remark, req.MetaData)
 	if err != nil {
 		response.Fail(c, err.Error(), nil)
@@ -242,6 +270,7 @@ func (h *Handlers) createACDPoolTarget(c *gin.Context) {
 		nMeta,
 	)
 	row.TenantID = tid
+	row.WorkStateReason = breakReason
 	op := middleware.AuditOperator(c)
 	if op != "" {
 		row.SetCreateInfo(op)
@@ -267,6 +296,9 @@ func (h *Handlers) createACDPoolTarget(c *gin.Context) {
 				nRemark,
 				nMeta,
 			)
+			if breakReason != "" {
+				updates["work_state_reason"] = breakReason
+			}
 			if err := h.db.WithContext(ctx).Model(&models.ACDPoolTarget{}).Where("id = ?", keep.ID).Updates(updates).Error; err != nil {
 				response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
 				return
@@ -281,7 +313,7 @@ func (h *Handlers) createACDPoolTarget(c *gin.Context) {
 				}
 				_, _ = models.SoftDeleteACDPoolTargetsByIDs(ctx, h.db, dupIDs, op)
 			}
-			updated := h.reconcileACDPoolTargetShiftAfterSave(c, keep.ID)
+			updated := h.reconcileACDPoolTargetShiftAfterSave(c, keep.ID, keep)
 			response.Success(c, "success", updated)
 			return
 		}
@@ -290,7 +322,7 @@ func (h *Handlers) createACDPoolTarget(c *gin.Context) {
 		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
 		return
 	}
-	created := h.reconcileACDPoolTargetShiftAfterSave(c, row.ID)
+	created := h.reconcileACDPoolTargetShiftAfterSave(c, row.ID, models.ACDPoolTarget{})
 	response.Success(c, "success", created)
 }
 
@@ -327,6 +359,11 @@ func (h *Handlers) updateACDPoolTarget(c *gin.Context) {
 		response.Fail(c, "workState 仅允许 available/offline/break", nil)
 		return
 	}
+	breakReason, err := models.NormalizeACDBreakReason(ws, req.BreakReason)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
 	nRemark, nMeta, err := normalizeACDPoolTargetWriteMeta(req.Remark, req.MetaData)
 	if err != nil {
 		response.Fail(c, err.Error(), nil)
@@ -357,6 +394,9 @@ func (h *Handlers) updateACDPoolTarget(c *gin.Context) {
 		nRemark,
 		nMeta,
 	)
+	if breakReason != "" {
+		updates["work_state_reason"] = breakReason
+	}
 	if err := h.db.Model(&row).Updates(updates).Error; err != nil {
 		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
 		return
@@ -364,7 +404,7 @@ func (h *Handlers) updateACDPoolTarget(c *gin.Context) {
 	if rt == constants.ACDPoolRouteTypeWeb && ws == constants.ACDWorkStateOffline {
 		_ = models.ClearACDPoolTargetWebSeatLastSeen(h.db, id)
 	}
-	updated := h.reconcileACDPoolTargetShiftAfterSave(c, id)
+	updated := h.reconcileACDPoolTargetShiftAfterSave(c, id, row)
 	response.Success(c, "success", updated)
 }
 
@@ -393,6 +433,11 @@ func parseWebSeatHeartbeatTargetID(c *gin.Context) (uint, error) {
 	if err := c.ShouldBindJSON(&raw); err != nil {
 		return 0, err
 	}
+	return webSeatTargetIDFromBody(raw)
+}
+
+// webSeatTargetIDFromBody reads targetId as a JSON number or a numeric string.
+func webSeatTargetIDFromBody(raw map[string]json.RawMessage) (uint, error) {
 	v, ok := raw["targetId"]
 	if !ok || len(v) == 0 {
 		return 0, errWebSeatHeartbeatNeedTargetID
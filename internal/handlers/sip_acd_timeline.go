@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type acdWorkStateReq struct {
+	WorkState   string `json:"workState"`
+	BreakReason string `json:"breakReason"`
+}
+
+// acdTimelineMaxDays bounds GET /acd-pool/:id/timeline ranges.
+const acdTimelineMaxDays = 31
+
+// setACDPoolTargetWorkState is the agent's own state switch (available / break with
+// reason / offline). Seats on a call or in after-call work cannot switch to available.
+func (h *Handlers) setACDPoolTargetWorkState(c *gin.Context) {
+	tid := middleware.CurrentTenantID(c)
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req acdWorkStateReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	op := middleware.AuditOperator(c)
+	if op == "" {
+		response.Fail(c, "unauthorized", nil)
+		return
+	}
+	row, err := models.GetActiveACDPoolTargetByID(h.db, id)
+	if err != nil || row.TenantID != tid {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if row.RouteType == constants.ACDPoolRouteTypeWeb && !models.WebSeatActorMayTouchRow(row, op) {
+		response.Fail(c, "forbidden", nil)
+		return
+	}
+	ws := models.NormalizeACDWorkState(req.WorkState)
+	if ws != constants.ACDWorkStateAvailable && ws != constants.ACDWorkStateOffline && ws != constants.ACDWorkStateBreak {
+		response.Fail(c, "workState 仅允许 available/offline/break", nil)
+		return
+	}
+	reason, err := models.NormalizeACDBreakReason(ws, req.BreakReason)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	switch models.NormalizeACDWorkState(row.WorkState) {
+	case constants.ACDWorkStateRinging, constants.ACDWorkStateBusy:
+		response.Fail(c, "坐席通话中，无法切换状态", nil)
+		return
+	}
+	now := time.Now()
+	if ws == constants.ACDWorkStateAvailable {
+		if _, inACW := h.acwSvc.Current(tid, id); inACW {
+			response.Fail(c, "坐席处于话后处理，请先提交小结", nil)
+			return
+		}
+		if !models.ACDFitsShiftSchedule(row.ShiftScheduleJSON, now, models.ACDShiftTimeLocation()) {
+			response.Fail(c, "当前不在坐席班次时间内", nil)
+			return
+		}
+		if row.RouteType == constants.ACDPoolRouteTypeWeb {
+			if err := models.UpdateACDPoolTargetWebSeatHeartbeat(h.db, id, op, now); err != nil {
+				response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+	if err := models.UpdateACDPoolTargetWorkStateWithReason(c.Request.Context(), h.db, id, ws, reason, op); err != nil {
+		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+		return
+	}
+	row, _ = models.ReloadACDPoolTargetByID(h.db, id)
+	response.Success(c, "success", row)
+}
+
+// getACDPoolTargetTimeline returns one seat's work-state segments with seconds per
+// state and break reason, occupancy and adherence to its shift schedule.
+// Query: startAt / endAt dates (yyyy-MM-dd, inclusive; default last 7 days).
+func (h *Handlers) getACDPoolTargetTimeline(c *gin.Context) {
+	tid := middleware.CurrentTenantID(c)
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	from, to, ok := parseReportDateRange(c, acdTimelineMaxDays)
+	if !ok {
+		return
+	}
+	row, err := models.GetActiveACDPoolTargetByID(h.db, id)
+	if err != nil || (middleware.AuthPlatformAdminID(c) == 0 && row.TenantID != tid) {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	tl, err := models.BuildACDTimeline(c.Request.Context(), h.db, row, from, to, time.Now())
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", tl)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// agentReportMaxDays bounds GET /acd-wallboard/reports/agents ranges.
+const agentReportMaxDays = 92
+
+// getACDWallboard returns one wallboard snapshot (seats, today's metrics, per-number queues).
+func (h *Handlers) getACDWallboard(c *gin.Context) {
+	if h.wallboardSvc == nil {
+		response.Fail(c, "wallboard service unavailable", nil)
+		return
+	}
+	wb, err := h.wallboardSvc.Snapshot(c.Request.Context(), reportTenantID(c))
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", wb)
+}
+
+// streamACDWallboard pushes wallboard snapshots over Server-Sent Events (SSE):
+// on every seat work_state change (at most once per second) and periodically
+// (SIP_WALLBOARD_INTERVAL_SECONDS, default 5). Events: snapshot, error.
+func (h *Handlers) streamACDWallboard(c *gin.Context) {
+	if h.wallboardSvc == nil {
+		response.Fail(c, "wallboard service unavailable", nil)
+		return
+	}
+	tid := reportTenantID(c)
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		response.AbortWithStatusJSON(c, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream; charset=utf-8")
+	c.Header("Cache-Control", "no-cache, no-transform")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writeSSE := func(event string, payload any) {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return
+		}
+		_, _ = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, b)
+		flusher.Flush()
+	}
+
+	ctx := c.Request.Context()
+	var last time.Time
+	send := func() {
+		last = time.Now()
+		wb, err := h.wallboardSvc.Snapshot(ctx, tid)
+		if err != nil {
+			writeSSE("error", gin.H{"msg": err.Error()})
+			return
+		}
+		writeSSE("snapshot", wb)
+	}
+
+	changes, cancel := h.wallboardSvc.Subscribe()
+	defer cancel()
+	send()
+
+	periodic := time.NewTicker(h.wallboardSvc.Interval())
+	defer periodic.Stop()
+	gap := h.wallboardSvc.MinGap()
+	flush := time.NewTimer(gap)
+	flush.Stop()
+	dirty := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+			if dirty {
+				continue
+			}
+			if wait := gap - time.Since(last); wait > 0 {
+				dirty = true
+				flush.Reset(wait)
+				continue
+			}
+			send()
+		case <-flush.C:
+			dirty = false
+			send()
+		case <-periodic.C:
+			if !dirty {
+				send()
+			}
+		}
+	}
+}
+
+// getACDAgentDailyReport returns per-seat, per-day metrics: calls handled,
+// transfers offered / received / missed, talk / ACW / handle times and seconds
+// per work state. Query: startAt / endAt dates (yyyy-MM-dd, inclusive; default
+// last 7 days), targetId (optional).
+func (h *Handlers) getACDAgentDailyReport(c *gin.Context) {
+	if h.wallboardSvc == nil {
+		response.Fail(c, "wallboard service unavailable", nil)
+		return
+	}
+	targetID, ok := parseReportTargetID(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseReportDateRange(c, agentReportMaxDays)
+	if !ok {
+		return
+	}
+	list, err := h.wallboardSvc.AgentDailyReport(c.Request.Context(), reportTenantID(c), targetID, from, to)
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", gin.H{"startAt": from, "endAt": to, "list": list})
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/internal/sipserver"
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type sipDispositionCodeWriteReq struct {
+	Code      string `json:"code"`
+	Label     string `json:"label"`
+	SortOrder int    `json:"sortOrder"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+type wrapUpReq struct {
+	CallID          string `json:"callId"`
+	DispositionCode string `json:"dispositionCode"`
+	Notes           string `json:"notes"`
+}
+
+// dispositionStatsMaxDays bounds GET /acd-dispositions/stats ranges.
+const dispositionStatsMaxDays = 92
+
+func (h *Handlers) listSIPDispositionCodes(c *gin.Context) {
+	tid := middleware.CurrentTenantID(c)
+	list, err := models.ListSIPDispositionCodes(c.Request.Context(), h.db, tid, c.Query("enabled") == "true")
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", list)
+}
+
+func (h *Handlers) createSIPDispositionCode(c *gin.Context) {
+	tid := middleware.CurrentTenantID(c)
+	var req sipDispositionCodeWriteReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	code, err := models.NormalizeSIPDispositionCode(req.Code)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		label = code
+	}
+	taken, err := models.SIPDispositionCodeTaken(h.db, tid, code, 0)
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	if taken {
+		response.Fail(c, models.ErrDispositionCodeExists.Error(), nil)
+		return
+	}
+	row := models.SIPDispositionCode{TenantID: tid, Code: code, Label: label, SortOrder: req.SortOrder, Enabled: true}
+	if req.Enabled != nil {
+		row.Enabled = *req.Enabled
+	}
+	if op := middleware.AuditOperator(c); op != "" {
+		row.SetCreateInfo(op)
+	}
+	if ginutil.WriteInternalError(c, h.db.Create(&row).Error) {
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) updateSIPDispositionCode(c *gin.Context) {
+	tid := middleware.CurrentTenantID(c)
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req sipDispositionCodeWriteReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	row, err := models.GetSIPDispositionCodeForTenant(h.db, id, tid)
+	if ginutil.WriteGORMError(c, err, "not found") {
+		return
+	}
+	code, err := models.NormalizeSIPDispositionCode(req.Code)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	taken, err := models.SIPDispositionCodeTaken(h.db, tid, code, row.ID)
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	if taken {
+		response.Fail(c, models.ErrDispositionCodeExists.Error(), nil)
+		return
+	}
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		label = code
+	}
+	updates := map[string]interface{}{
+		"code":       code,
+		"label":      label,
+		"sort_order": req.SortOrder,
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if op := middleware.AuditOperator(c); op != "" {
+		updates["update_by"] = op
+	}
+	if ginutil.WriteInternalError(c, h.db.Model(&row).Updates(updates).Error) {
+		return
+	}
+	row, _ = models.GetSIPDispositionCodeForTenant(h.db, id, tid)
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) deleteSIPDispositionCode(c *gin.Context) {
+	tid := middleware.CurrentTenantID(c)
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	n, err := models.SoftDeleteSIPDispositionCodeForTenant(h.db, id, tid, middleware.AuditOperator(c))
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	if n == 0 {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	response.Success(c, "success", gin.H{"id": id})
+}
+
+// reportTenantID is the caller's tenant; platform admins may pass tenantId (default all tenants).
+func reportTenantID(c *gin.Context) uint {
+	tid := middleware.CurrentTenantID(c)
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		tid = 0
+		if s := strings.TrimSpace(c.Query("tenantId")); s != "" {
+			if v, err := utils.ParseID(s); err == nil {
+				tid = v
+			}
+		}
+	}
+	return tid
+}
+
+// parseReportDateRange reads startAt / endAt dates (yyyy-MM-dd, inclusive) as
+// [from, to) local midnights; the default is the last 7 days. It writes the
+// error response and returns false on bad input or a range over maxDays.
+func parseReportDateRange(c *gin.Context, maxDays int) (from, to time.Time, ok bool) {
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	from, to = today.AddDate(0, 0, -6), today.AddDate(0, 0, 1)
+	if s := strings.TrimSpace(c.Query("startAt")); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			response.Fail(c, "invalid startAt", nil)
+			return from, to, false
+		}
+		from = t
+	}
+	if s := strings.TrimSpace(c.Query("endAt")); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			response.Fail(c, "invalid endAt", nil)
+			return from, to, false
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	if !to.After(from) || to.Sub(from) > time.Duration(maxDays)*24*time.Hour {
+		response.Fail(c, "invalid date range", nil)
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// parseReportTargetID reads the optional targetId filter (0 = all seats).
+func parseReportTargetID(c *gin.Context) (uint, bool) {
+	s := strings.TrimSpace(c.Query("targetId"))
+	if s == "" {
+		return 0, true
+	}
+	v, err := utils.ParseID(s)
+	if err != nil {
+		response.Fail(c, "invalid targetId", nil)
+		return 0, false
+	}
+	return v, true
+}
+
+// getSIPDispositionStats reports wrapped-up calls per agent and disposition code.
+// startAt / endAt are dates (yyyy-MM-dd, inclusive); the default is the last 7 days.
+func (h *Handlers) getSIPDispositionStats(c *gin.Context) {
+	tid := reportTenantID(c)
+	targetID, ok := parseReportTargetID(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseReportDateRange(c, dispositionStatsMaxDays)
+	if !ok {
+		return
+	}
+	list, err := persist.SIPCallDispositionStats(c.Request.Context(), h.db, tid, targetID, from, to)
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", gin.H{"startAt": from, "endAt": to, "list": list})
+}
+
+// getACDPoolTargetACW returns the seat's running after-call work (null when none),
+// so a reloaded agent page can reopen its wrap-up panel.
+func (h *Handlers) getACDPoolTargetACW(c *gin.Context) {
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	n, ok := h.acwSvc.Current(middleware.CurrentTenantID(c), id)
+	if !ok {
+		response.Success(c, "success", nil)
+		return
+	}
+	response.Success(c, "success", n)
+}
+
+// wrapUpACDPoolTarget ends the seat's after-call work with a disposition code and notes.
+func (h *Handlers) wrapUpACDPoolTarget(c *gin.Context) {
+	if h.acwSvc == nil {
+		response.Fail(c, "after-call work service unavailable", nil)
+		return
+	}
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req wrapUpReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	op := middleware.AuditOperator(c)
+	if op == "" {
+		response.Fail(c, "unauthorized", nil)
+		return
+	}
+	w, err := h.acwSvc.WrapUp(c.Request.Context(), middleware.CurrentTenantID(c), id, req.CallID, req.DispositionCode, req.Notes, op)
+	if errors.Is(err, sipserver.ErrACWNotFound) {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	response.Success(c, "success", gin.H{
+		"acdTargetId":     strconv.FormatUint(uint64(w.ACDTargetID), 10),
+		"dispositionCode": w.Code,
+		"notes":           w.Notes,
+		"acwSec":          int(w.EndedAt.Sub(w.StartedAt).Round(time.Second) / time.Second),
+		"wrapUpAt":        w.EndedAt,
+	})
+}
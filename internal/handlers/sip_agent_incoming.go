@@ -74,6 +74,7 @@ func sipAgentIncomingPayload(row models.ACDPoolTarget, snap sipagentpoll.Snapsho
 		"seatName":     strings.TrimSpace(row.Name),
 		"targetValue":  strings.TrimSpace(row.TargetValue),
 		"routeType":    row.RouteType,
+		"workState":    models.NormalizeACDWorkState(row.WorkState),
 		"callId":       snap.InboundCallID,
 		"callerNumber": snap.CallerNumber,
 		"phase":        snap.Phase,
@@ -106,7 +107,7 @@ func parseACDTargetIDsQuery(c *gin.Context) ([]uint, bool) {
 	return out, false
 }
 
-// streamSIPAgentIncoming pushes seat ringing state over Server-Sent Events (SSE).
+// streamSIPAgentIncoming pushes seat ringing and work state over Server-Sent Events (SSE).
 // Query: acdTargetIds=1,2,3 (required, comma-separated acd_pool_targets.id).
 // Events: ready, snapshot, heartbeat. Poll GET /sip-agent/incoming remains available.
 func (h *Handlers) streamSIPAgentIncoming(c *gin.Context) {
@@ -194,6 +195,11 @@ func (h *Handlers) streamSIPAgentIncoming(c *gin.Context) {
 			if !found {
 				continue
 			}
+			// Work-state transitions also land here; refresh the row for workState.
+			if fresh, err := models.GetActiveACDPoolTargetByID(h.db, acdID); err == nil {
+				row = fresh
+				rowByID[acdID] = fresh
+			}
 			snap := sipagentpoll.ResolveSnapshot(acdID, conversation.IsTransferInProgress)
 			writeSSE("snapshot", sipAgentIncomingPayload(row, snap))
 		case <-heartbeat.C:
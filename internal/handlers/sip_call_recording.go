@@ -0,0 +1,68 @@
+package handlers
+
+// Copyright (c) 2026 LingByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
+	"github.com/gin-gonic/gin"
+)
+
+// getSIPCallRecording streams a call recording (WAV, Ogg Opus or MP3),
+// decrypting envelope-encrypted objects on the fly. Supports Range requests
+// so <audio> seeking works; ?download=1 sends it as an attachment.
+func (h *Handlers) getSIPCallRecording(c *gin.Context) {
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var (
+		row persist.SIPCall
+		err error
+	)
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		row, err = persist.GetActiveSIPCallByID(h.db, id)
+	} else {
+		row, err = persist.GetActiveSIPCallForTenant(h.db, id, middleware.CurrentTenantID(c))
+	}
+	if err != nil {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if strings.TrimSpace(row.RecordingURL) == "" {
+		response.Fail(c, "no recording", nil)
+		return
+	}
+	audio, err := persist.FetchRecordingWAV(row.RecordingURL, nil)
+	if err != nil {
+		response.AbortWithStatusJSON(c, http.StatusBadGateway, err)
+		return
+	}
+	name := path.Base(row.RecordingURL)
+	if u, perr := url.Parse(row.RecordingURL); perr == nil && u.Path != "" {
+		name = path.Base(u.Path)
+	}
+	if c.Query("download") == "1" {
+		c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	}
+	c.Header("Cache-Control", "private, no-store")
+	// ServeContent sniffs when no type is set, which misses Ogg/MP3 on
+	// minimal images; recordings may be wav, ogg (Opus) or mp3.
+	c.Header("Content-Type", recorder.ContentTypeForKey(name))
+	modTime := row.UpdatedAt
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	http.ServeContent(c.Writer, c.Request, name, modTime, bytes.NewReader(audio))
+}
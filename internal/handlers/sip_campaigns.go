@@ -29,6 +29,7 @@ type sipCampaignCreateReq struct {
 	TaskConcurrency   int    `json:"task_concurrency"`
 	GlobalConcurrency int    `json:"global_concurrency"`
 	RequestURIFmt     string `json:"request_uri_fmt"`
+	TrunkGroupID      uint   `json:"trunk_group_id"`
 }
 
 type sipCampaignContactReq struct {
@@ -62,6 +63,12 @@ func (h *Handlers) createSIPCampaign(c *gin.Context) {
 		response.Fail(c, "name required", nil)
 		return
 	}
+	if req.TrunkGroupID > 0 {
+		if _, err := models.GetTrunkGroupForTenant(h.db, req.TrunkGroupID, tid); err != nil {
+			response.Fail(c, "trunk group not found", nil)
+			return
+		}
+	}
 	var spec datatypes.JSON
 	if s := strings.TrimSpace(req.ScriptSpec); s != "" {
 		spec = datatypes.JSON([]byte(s))
@@ -80,6 +87,7 @@ func (h *Handlers) createSIPCampaign(c *gin.Context) {
 		TaskConcurrency:   req.TaskConcurrency,
 		GlobalConcurrency: req.GlobalConcurrency,
 		RequestURIFmt:     strings.TrimSpace(req.RequestURIFmt),
+		TrunkGroupID:      req.TrunkGroupID,
 	}
 	if row.Scenario == "" {
 		row.Scenario = "campaign"
@@ -44,6 +44,23 @@ func (h *Handlers) registerSIPCenterCallsRoutes(g *gin.RouterGroup) {
 	{
 		read.GET("/calls", h.listSIPCalls)
 		read.GET("/calls/:id", h.getSIPCall)
+		read.GET("/calls/:id/recording", h.getSIPCallRecording)
+		read.GET("/calls/:id/recording/pauses", h.listCallRecordingPauses)
+		read.GET("/messages", h.listSIPMessages)
+		read.GET("/messages/:id", h.getSIPMessage)
+		read.GET("/click-to-call", h.listClickToCalls)
+		read.GET("/click-to-call/:id", h.getClickToCall)
+		read.GET("/click-to-call/:id/events", h.listClickToCallEvents)
+	}
+	write := g.Group("")
+	write.Use(middleware.RequireTenantPermissionAll("api.sip.calls.write"))
+	{
+		write.POST("/calls/:id/recording/pause", h.pauseCallRecording)
+		write.POST("/calls/:id/recording/resume", h.resumeCallRecording)
+		write.PUT("/calls/:id/legal-hold", h.setSIPCallLegalHold)
+		write.POST("/messages", h.sendSIPMessage)
+		write.POST("/click-to-call", h.createClickToCall)
+		write.POST("/click-to-call/:id/cancel", h.cancelClickToCall)
 	}
 }
 
@@ -57,6 +74,12 @@ func (h *Handlers) registerSIPCenterACDRoutes(g *gin.RouterGroup) {
 		read.GET("/sip-agent/incoming", h.pollSIPAgentIncoming)
 		read.GET("/sip-agent/incoming/stream", h.streamSIPAgentIncoming)
 		read.GET("/sip-agent/incoming/logs", h.listSIPAgentIncomingLogs)
+		read.GET("/acd-dispositions", h.listSIPDispositionCodes)
+		read.GET("/acd-dispositions/stats", h.getSIPDispositionStats)
+		read.GET("/acd-wallboard", h.getACDWallboard)
+		read.GET("/acd-wallboard/stream", h.streamACDWallboard)
+		read.GET("/acd-wallboard/reports/agents", h.getACDAgentDailyReport)
+		read.GET("/acd-pool/:id/timeline", h.getACDPoolTargetTimeline)
 	}
 	write := g.Group("")
 	write.Use(middleware.RequireTenantPermissionAll("api.sip.acd.write"))
@@ -66,12 +89,19 @@ func (h *Handlers) registerSIPCenterACDRoutes(g *gin.RouterGroup) {
 		write.POST("/acd-pool/reorder", h.reorderACDPoolTargets)
 		write.DELETE("/acd-pool/:id", h.deleteACDPoolTarget)
 		write.PUT("/acd-dispatch-mode", h.updateACDDispatchMode)
+		write.POST("/acd-dispositions", h.createSIPDispositionCode)
+		write.PUT("/acd-dispositions/:id", h.updateSIPDispositionCode)
+		write.DELETE("/acd-dispositions/:id", h.deleteSIPDispositionCode)
 	}
-	// Web-seat heartbeat: read OR write (browser agent token).
+	// Web-seat heartbeat, dial, wrap-up and work-state switch: read OR write (browser agent token).
 	seat := g.Group("")
 	seat.Use(middleware.RequireTenantPermissionAny("api.sip.acd.read", "api.sip.acd.write"))
 	{
 		seat.POST("/acd-pool/web-seat/heartbeat", h.webSeatACDHeartbeat)
+		seat.POST("/acd-pool/web-seat/dial", h.webSeatDial)
+		seat.GET("/acd-pool/:id/acw", h.getACDPoolTargetACW)
+		seat.POST("/acd-pool/:id/wrap-up", h.wrapUpACDPoolTarget)
+		seat.POST("/acd-pool/:id/work-state", h.setACDPoolTargetWorkState)
 	}
 }
 
@@ -120,12 +150,19 @@ func (h *Handlers) registerSIPCenterNumbersRoutes(g *gin.RouterGroup) {
 	read.Use(middleware.RequireTenantPermissionAll("api.sip.numbers.read"))
 	{
 		read.GET("/trunk-numbers", h.listTrunkNumbers)
+		read.GET("/trunk-groups", h.listTrunkGroups)
+		read.GET("/trunk-groups/:id", h.getTrunkGroup)
+		read.GET("/trunk-routes", h.listTrunkRoutes)
 	}
 	admin := g.Group("")
 	admin.Use(middleware.RequirePlatformAdmin())
 	{
 		admin.GET("/trunks", h.listTrunks)
+		admin.GET("/trunks/registrations", h.listTrunkRegistrations)
+		admin.GET("/trunks/health", h.listTrunkHealth)
+		admin.GET("/trunks/stats", h.listTrunkStats)
 		admin.GET("/trunks/:id", h.getTrunk)
+		admin.GET("/trunks/:id/registration", h.getTrunkRegistration)
 		admin.POST("/trunks", h.createTrunk)
 		admin.PUT("/trunks/:id", h.updateTrunk)
 		admin.DELETE("/trunks/:id", h.deleteTrunk)
@@ -135,5 +172,11 @@ func (h *Handlers) registerSIPCenterNumbersRoutes(g *gin.RouterGroup) {
 		admin.DELETE("/trunk-numbers/:id", h.deleteTrunkNumber)
 		admin.POST("/trunk-numbers/welcome-audio", h.uploadTrunkNumberAudio("welcome-audio"))
 		admin.POST("/trunk-numbers/transfer-ringing-audio", h.uploadTrunkNumberAudio("transfer-ringing-audio"))
+		admin.POST("/trunk-groups", h.createTrunkGroup)
+		admin.PUT("/trunk-groups/:id", h.updateTrunkGroup)
+		admin.DELETE("/trunk-groups/:id", h.deleteTrunkGroup)
+		admin.POST("/trunk-routes", h.createTrunkRoute)
+		admin.PUT("/trunk-routes/:id", h.updateTrunkRoute)
+		admin.DELETE("/trunk-routes/:id", h.deleteTrunkRoute)
 	}
 }
@@ -0,0 +1,217 @@
+package handlers
+
+// Copyright (c) 2026 LingByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/internal/sipserver"
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type clickToCallReq struct {
+	// Mode: ai (customer with AI voice / script) | agent (agent first, then customer, bridged).
+	Mode string `json:"mode"`
+	// Customer: phone number or registered SIP username.
+	Customer string `json:"customer"`
+	// CallerUser: tenant trunk number presented to the customer.
+	CallerUser   string `json:"callerUser"`
+	TrunkGroupID uint   `json:"trunkGroupId"`
+	RequestURI   string `json:"requestUri"`
+	// ExternalID is echoed back for the integrator's own bookkeeping.
+	ExternalID string `json:"externalId"`
+
+	ScriptTemplateID uint   `json:"scriptTemplateId"`
+	SystemPrompt     string `json:"systemPrompt"`
+
+	AgentTargetID uint   `json:"agentTargetId"`
+	AgentUser     string `json:"agentUser"`
+
+	// TenantID lets a platform admin originate on behalf of a tenant.
+	TenantID uint `json:"tenantId"`
+}
+
+// clickToCallTenantScope is 0 (all tenants) for platform admins.
+func clickToCallTenantScope(c *gin.Context) uint {
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		return 0
+	}
+	return middleware.CurrentTenantID(c)
+}
+
+func (h *Handlers) writeClickToCallError(c *gin.Context, err error) {
+	if errors.Is(err, sipserver.ErrClickToCallNotFound) {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	response.Fail(c, err.Error(), nil)
+}
+
+// createClickToCall originates one call; poll GET /click-to-call/:id (or list by
+// correlationId) for status: queued → agent_ringing → agent_answered →
+// customer_ringing → answered / bridged → completed | failed | canceled.
+func (h *Handlers) createClickToCall(c *gin.Context) {
+	if h.clickToCallSvc == nil {
+		response.Fail(c, "click-to-call service unavailable", nil)
+		return
+	}
+	var req clickToCallReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	tid := middleware.CurrentTenantID(c)
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		tid = req.TenantID
+	}
+	row, err := h.clickToCallSvc.Create(c.Request.Context(), sipserver.ClickToCallInput{
+		TenantID:         tid,
+		Mode:             req.Mode,
+		Customer:         req.Customer,
+		CallerUser:       req.CallerUser,
+		TrunkGroupID:     req.TrunkGroupID,
+		RequestURI:       req.RequestURI,
+		ExternalID:       req.ExternalID,
+		ScriptTemplateID: req.ScriptTemplateID,
+		SystemPrompt:     req.SystemPrompt,
+		AgentTargetID:    req.AgentTargetID,
+		AgentUser:        req.AgentUser,
+		CreateBy:         middleware.AuditOperator(c),
+	})
+	if err != nil {
+		h.writeClickToCallError(c, err)
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) listClickToCalls(c *gin.Context) {
+	if h.clickToCallSvc == nil {
+		response.Fail(c, "click-to-call service unavailable", nil)
+		return
+	}
+	page, size := ginutil.QueryPage(c, 100)
+	f := models.SIPClickToCallListFilter{
+		TenantID:      clickToCallTenantScope(c),
+		Status:        c.Query("status"),
+		Mode:          c.Query("mode"),
+		Origin:        c.Query("origin"),
+		CorrelationID: c.Query("correlationId"),
+		ExternalID:    c.Query("externalId"),
+		Customer:      c.Query("customer"),
+	}
+	if f.TenantID == 0 {
+		if s := strings.TrimSpace(c.Query("tenantId")); s != "" {
+			if v, perr := strconv.ParseUint(s, 10, 32); perr == nil {
+				f.TenantID = uint(v)
+			}
+		}
+	}
+	list, total, err := h.clickToCallSvc.List(c.Request.Context(), f, page, size)
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	ginutil.PageSuccess(c, list, total, page, size)
+}
+
+func (h *Handlers) getClickToCall(c *gin.Context) {
+	if h.clickToCallSvc == nil {
+		response.Fail(c, "click-to-call service unavailable", nil)
+		return
+	}
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	row, err := h.clickToCallSvc.Get(c.Request.Context(), clickToCallTenantScope(c), id)
+	if err != nil {
+		h.writeClickToCallError(c, err)
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) listClickToCallEvents(c *gin.Context) {
+	if h.clickToCallSvc == nil {
+		response.Fail(c, "click-to-call service unavailable", nil)
+		return
+	}
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	list, err := h.clickToCallSvc.Events(c.Request.Context(), clickToCallTenantScope(c), id)
+	if err != nil {
+		h.writeClickToCallError(c, err)
+		return
+	}
+	response.Success(c, "success", list)
+}
+
+// cancelClickToCall CANCELs ringing legs or hangs up an answered call.
+func (h *Handlers) cancelClickToCall(c *gin.Context) {
+	if h.clickToCallSvc == nil {
+		response.Fail(c, "click-to-call service unavailable", nil)
+		return
+	}
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	row, err := h.clickToCallSvc.Cancel(c.Request.Context(), clickToCallTenantScope(c), id, middleware.AuditOperator(c))
+	if err != nil {
+		h.writeClickToCallError(c, err)
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+// webSeatDial lets a browser agent call a customer (body: targetId, customer,
+// optional requestUri / externalId). The reply's correlationId is the call_id
+// for POST /webseat/v1/join and /hangup; progress arrives on the webseat
+// WebSocket as outbound_progress.
+func (h *Handlers) webSeatDial(c *gin.Context) {
+	if h.clickToCallSvc == nil {
+		response.Fail(c, "click-to-call service unavailable", nil)
+		return
+	}
+	var raw map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		response.Fail(c, "invalid body", nil)
+		return
+	}
+	targetID, err := webSeatTargetIDFromBody(raw)
+	if err != nil {
+		response.Fail(c, "invalid body: need targetId", nil)
+		return
+	}
+	var customer, requestURI, externalID string
+	_ = json.Unmarshal(raw["customer"], &customer)
+	_ = json.Unmarshal(raw["requestUri"], &requestURI)
+	_ = json.Unmarshal(raw["externalId"], &externalID)
+	op := middleware.AuditOperator(c)
+	if op == "" {
+		response.Fail(c, "unauthorized", nil)
+		return
+	}
+	row, err := h.clickToCallSvc.DialFromWebSeat(c.Request.Context(), sipserver.WebSeatDialInput{
+		TenantID:   middleware.CurrentTenantID(c),
+		TargetID:   targetID,
+		Operator:   op,
+		Customer:   customer,
+		RequestURI: requestURI,
+		ExternalID: externalID,
+	})
+	if err != nil {
+		h.writeClickToCallError(c, err)
+		return
+	}
+	response.Success(c, "success", row)
+}
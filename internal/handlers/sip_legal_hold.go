@@ -0,0 +1,60 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package handlers
+
+// Copyright (c) 2026 LingByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type legalHoldReq struct {
+	Hold   bool   `json:"hold"`
+	Reason string `json:"reason"`
+}
+
+// setSIPCallLegalHold places or releases a legal hold on a call. Held calls are
+// skipped by the retention purger (recording, transcript, script runs, events
+// and the CDR files that mention them).
+func (h *Handlers) setSIPCallLegalHold(c *gin.Context) {
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req legalHoldReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, "invalid body", err.Error())
+		return
+	}
+	var tenantID uint
+	if middleware.AuthPlatformAdminID(c) == 0 {
+		tenantID = middleware.CurrentTenantID(c)
+	}
+	row, err := persist.SetSIPCallLegalHold(c.Request.Context(), h.db, id, tenantID, req.Hold, req.Reason, middleware.AuditOperator(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if err != nil {
+		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+		return
+	}
+	response.Success(c, "success", gin.H{
+		"id":              row.ID,
+		"callId":          row.CallID,
+		"legalHold":       row.LegalHold,
+		"legalHoldReason": row.LegalHoldReason,
+		"legalHoldBy":     row.LegalHoldBy,
+		"legalHoldAt":     row.LegalHoldAt,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+// Copyright (c) 2026 LingByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/sipserver"
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type sipMessageSendReq struct {
+	// To is a SIP username (user or user@domain) or a phone number.
+	To string `json:"to"`
+	// ToType: user (default) | number.
+	ToType string `json:"toType"`
+	// From: tenant trunk number for toType number; From user otherwise.
+	From        string `json:"from"`
+	Body        string `json:"body"`
+	ContentType string `json:"contentType"`
+	// TenantID lets a platform admin send on behalf of a tenant.
+	TenantID uint `json:"tenantId"`
+}
+
+// sendSIPMessage queues one SIP MESSAGE; poll GET /messages/:id for the
+// delivery status (queued → sending → delivered / failed / expired).
+func (h *Handlers) sendSIPMessage(c *gin.Context) {
+	if h.chatSvc == nil {
+		response.Fail(c, "sip message service unavailable", nil)
+		return
+	}
+	var req sipMessageSendReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	tid := middleware.CurrentTenantID(c)
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		tid = req.TenantID
+	}
+	row, err := h.chatSvc.Send(c.Request.Context(), sipserver.SendChatInput{
+		TenantID:    tid,
+		To:          req.To,
+		ToType:      req.ToType,
+		From:        req.From,
+		Body:        req.Body,
+		ContentType: req.ContentType,
+		CreateBy:    middleware.AuditOperator(c),
+	})
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) listSIPMessages(c *gin.Context) {
+	page, size := ginutil.QueryPage(c, 100)
+	filter := persist.SIPMessageListFilter{
+		Direction: c.Query("direction"),
+		Status:    c.Query("status"),
+		Peer:      c.Query("peer"),
+	}
+	tid := middleware.CurrentTenantID(c)
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		tid = 0
+		if s := strings.TrimSpace(c.Query("tenantId")); s != "" {
+			if v, perr := strconv.ParseUint(s, 10, 32); perr == nil {
+				tid = uint(v)
+			}
+		}
+	}
+	list, total, err := persist.ListSIPMessagesPage(h.db, tid, page, size, filter)
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	ginutil.PageSuccess(c, list, total, page, size)
+}
+
+func (h *Handlers) getSIPMessage(c *gin.Context) {
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var tid uint
+	if middleware.AuthPlatformAdminID(c) == 0 {
+		tid = middleware.CurrentTenantID(c)
+	}
+	row, err := persist.GetSIPMessage(c.Request.Context(), h.db, tid, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", row)
+}
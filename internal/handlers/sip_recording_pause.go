@@ -0,0 +1,113 @@
+package handlers
+
+// Copyright (c) 2026 LingByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingPauseReq struct {
+	Reason string `json:"reason"`
+}
+
+// liveCallForRequest resolves :id to a live call session visible to the caller
+// (tenant users only see their own tenant's calls; platform admins see all).
+func (h *Handlers) liveCallForRequest(c *gin.Context) (*sipSession.CallSession, bool) {
+	callID := strings.TrimSpace(c.Param("id"))
+	if callID == "" {
+		response.Fail(c, "id required", nil)
+		return nil, false
+	}
+	if h.callSession == nil {
+		response.Fail(c, "sip stack not running", nil)
+		return nil, false
+	}
+	cs := h.callSession(callID)
+	if cs == nil {
+		response.Fail(c, "call not active", nil)
+		return nil, false
+	}
+	if middleware.AuthPlatformAdminID(c) == 0 && cs.TenantID() != middleware.CurrentTenantID(c) {
+		response.Fail(c, "call not active", nil)
+		return nil, false
+	}
+	return cs, true
+}
+
+// pauseCallRecording stops capturing the recording (and ASR transcript) of a live call
+// until resumed, e.g. while the caller reads out card details.
+func (h *Handlers) pauseCallRecording(c *gin.Context) {
+	cs, ok := h.liveCallForRequest(c)
+	if !ok {
+		return
+	}
+	var req recordingPauseReq
+	_ = c.ShouldBindJSON(&req)
+	err := cs.PauseRecording(sipSession.RecordingPauseSourceAPI, middleware.AuditOperator(c), req.Reason)
+	if errors.Is(err, sipSession.ErrRecordingAlreadyPaused) {
+		response.Fail(c, "recording already paused", nil)
+		return
+	}
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	response.Success(c, "success", gin.H{"callId": cs.CallID, "paused": true})
+}
+
+// resumeCallRecording ends the open pause and returns the closed interval.
+func (h *Handlers) resumeCallRecording(c *gin.Context) {
+	cs, ok := h.liveCallForRequest(c)
+	if !ok {
+		return
+	}
+	p, err := cs.ResumeRecording(middleware.AuditOperator(c))
+	if errors.Is(err, sipSession.ErrRecordingNotPaused) {
+		response.Fail(c, "recording not paused", nil)
+		return
+	}
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	response.Success(c, "success", gin.H{
+		"callId":     cs.CallID,
+		"paused":     false,
+		"startedAt":  p.Start,
+		"endedAt":    p.End,
+		"durationMs": p.Duration().Milliseconds(),
+		"pausedBy":   p.PausedBy,
+		"resumedBy":  p.ResumedBy,
+	})
+}
+
+// listCallRecordingPauses returns the pause audit trail of a call (live or ended).
+func (h *Handlers) listCallRecordingPauses(c *gin.Context) {
+	callID := strings.TrimSpace(c.Param("id"))
+	if callID == "" {
+		response.Fail(c, "id required", nil)
+		return
+	}
+	var tid uint
+	if middleware.AuthPlatformAdminID(c) == 0 {
+		tid = middleware.CurrentTenantID(c)
+	}
+	rows, err := models.ListSIPRecordingPauses(c.Request.Context(), h.db, tid, callID)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
+	response.Success(c, "success", gin.H{
+		"callId": callID,
+		"paused": sipSession.RecordingPausedFor(callID), // open pause is not in the audit table yet
+		"list":   rows,
+	})
+}
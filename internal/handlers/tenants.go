@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/LinByte/VoiceServer/cmd/bootstrap"
@@ -14,9 +15,12 @@ import (
 	"github.com/LinByte/VoiceServer/internal/models"
 	"github.com/LinByte/VoiceServer/pkg/ginutil"
 	"github.com/LinByte/VoiceServer/pkg/i18n"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"github.com/LinByte/VoiceServer/pkg/utils/access"
+	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -126,6 +130,17 @@ type tenantPlatformUpdateReq struct {
 	// RealtimeConfig is the credential blob (provider + per-vendor
 	// fields) consulted only when voiceMode == "realtime".
 	RealtimeConfig *json.RawMessage `json:"realtimeConfig"`
+	// PrivacyConfig is the PII redaction policy (pkg/redact.Policy);
+	// rejected when a custom pattern does not compile.
+	PrivacyConfig *json.RawMessage `json:"privacyConfig"`
+	// RetentionConfig is the data retention policy (models.RetentionPolicy);
+	// unset fields inherit the RETENTION_*_DAYS env defaults. CDR files are
+	// not covered: they expire on the platform-wide RETENTION_CDR_DAYS,
+	// reported as retentionEffective.cdrDays in the tenant detail.
+	RetentionConfig *json.RawMessage `json:"retentionConfig"`
+	// RecordingFormat selects wav / ogg (Opus) / mp3 for new recordings;
+	// nil = no change, "" = inherit RECORDING_FORMAT.
+	RecordingFormat *string `json:"recordingFormat"`
 }
 
 func (h *Handlers) getTenant(c *gin.Context) {
@@ -201,6 +216,31 @@ func (h *Handlers) updateTenantPlatform(c *gin.Context) {
 		response.Fail(c, "invalid contactEmail", nil)
 		return
 	}
+	if req.PrivacyConfig != nil {
+		p, err := redact.ParsePolicy(*req.PrivacyConfig)
+		if err == nil {
+			_, err = redact.Compile(p)
+		}
+		if err != nil {
+			response.Fail(c, "invalid privacyConfig: "+err.Error(), nil)
+			return
+		}
+	}
+	if req.RetentionConfig != nil {
+		if _, err := models.ParseRetentionPolicy(*req.RetentionConfig); err != nil {
+			response.Fail(c, "invalid retentionConfig: "+err.Error(), nil)
+			return
+		}
+	}
+	var recFormat recorder.Format
+	if req.RecordingFormat != nil {
+		f, err := recorder.ParseFormat(*req.RecordingFormat)
+		if err != nil {
+			response.Fail(c, "invalid recordingFormat", nil)
+			return
+		}
+		recFormat = f
+	}
 	op := "platform"
 	if err := models.UpdateActiveTenant(
 		h.db,
@@ -224,6 +264,25 @@ func (h *Handlers) updateTenantPlatform(c *gin.Context) {
 			return
 		}
 	}
+	if req.PrivacyConfig != nil {
+		if err := models.PatchTenantPrivacyConfigJSON(h.db, id, *req.PrivacyConfig, op); err != nil {
+			response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+			return
+		}
+		redact.InvalidateTenant(id)
+	}
+	if req.RetentionConfig != nil {
+		if err := models.PatchTenantRetentionConfigJSON(h.db, id, *req.RetentionConfig, op); err != nil {
+			response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if req.RecordingFormat != nil {
+		if err := models.PatchTenantRecordingFormat(h.db, id, string(recFormat), op); err != nil {
+			response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
 	t, err := models.GetActiveTenantByID(h.db, id)
 	if err != nil {
 		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
@@ -246,7 +305,59 @@ func (h *Handlers) deleteTenantPlatform(c *gin.Context) {
 		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
 		return
 	}
-	response.Success(c, "success", gin.H{"id": id})
+	// Crypto-shred: without the tenant KEKs its encrypted recordings are
+	// unreadable everywhere (storage, backups, CDN caches).
+	shredded, err := envelope.ShredTenant(c.Request.Context(), id)
+	if err != nil {
+		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+		return
+	}
+	response.Success(c, "success", gin.H{"id": id, "shreddedRecordingKeys": shredded})
+}
+
+// rotateTenantRecordingKeys creates a new recording KEK version for the tenant
+// and re-wraps existing recording data keys under it (audio is not re-uploaded).
+func (h *Handlers) rotateTenantRecordingKeys(c *gin.Context) {
+	id, err := utils.ParseID(c.Param("id"))
+	if err != nil {
+		response.Fail(c, "invalid id", nil)
+		return
+	}
+	if _, err := models.GetActiveTenantByID(h.db, id); err != nil {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if !envelope.Enabled() {
+		response.Fail(c, "recording encryption is not enabled", nil)
+		return
+	}
+	n, err := envelope.RotateTenant(c.Request.Context(), id)
+	if err != nil {
+		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+		return
+	}
+	response.Success(c, "success", gin.H{"id": id, "rewrapped": n})
+}
+
+// listTenantRetentionPurgeLogs returns the newest retention purge audit rows
+// of a tenant (?limit=, default 100). Tenant 0 holds platform-wide passes
+// such as CDR file expiry.
+func (h *Handlers) listTenantRetentionPurgeLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil {
+		response.Fail(c, "invalid id", nil)
+		return
+	}
+	limit := 100
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 && n <= 1000 {
+		limit = n
+	}
+	list, err := models.ListRetentionPurgeLogsDesc(c.Request.Context(), h.db, uint(id), limit)
+	if err != nil {
+		response.AbortWithStatusJSON(c, http.StatusInternalServerError, err)
+		return
+	}
+	response.Success(c, "success", gin.H{"list": list})
 }
 
 // listTenants returns tenant organizations for platform admin (e.g. assign trunk numbers).
@@ -0,0 +1,305 @@
+package handlers
+
+// Copyright (c) 2026 LingByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/internal/sipserver"
+	"github.com/LinByte/VoiceServer/pkg/ginutil"
+	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/response"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"github.com/gin-gonic/gin"
+)
+
+type trunkGroupMemberReq struct {
+	TrunkNumberID uint `json:"trunkNumberId"`
+	Priority      int  `json:"priority"`
+	Weight        *int `json:"weight"`
+}
+
+type trunkGroupWriteReq struct {
+	// TenantID 组所属租户（Snowflake，JSON 以字符串编解码）。
+	TenantID    uint                  `json:"tenantId,string"`
+	Name        string                `json:"name"`
+	Strategy    string                `json:"strategy"`
+	Description string                `json:"description"`
+	Members     []trunkGroupMemberReq `json:"members"`
+}
+
+type trunkRouteWriteReq struct {
+	TenantID    uint   `json:"tenantId,string"`
+	Prefix      string `json:"prefix"`
+	GroupID     uint   `json:"groupId"`
+	Cost        int    `json:"cost"`
+	Description string `json:"description"`
+}
+
+// trunkRoutingTenant 返回读接口的租户范围：平台管理员按 ?tenantId 过滤（空为全部），租户只看自己。
+func trunkRoutingTenant(c *gin.Context) uint {
+	if middleware.AuthPlatformAdminID(c) > 0 {
+		if v, err := strconv.ParseUint(strings.TrimSpace(c.Query("tenantId")), 10, 64); err == nil {
+			return uint(v)
+		}
+		return 0
+	}
+	return middleware.CurrentTenantID(c)
+}
+
+func (req trunkGroupWriteReq) members() []models.TrunkGroupMember {
+	out := make([]models.TrunkGroupMember, 0, len(req.Members))
+	for _, m := range req.Members {
+		w := 1
+		if m.Weight != nil {
+			w = *m.Weight
+		}
+		out = append(out, models.TrunkGroupMember{TrunkNumberID: m.TrunkNumberID, Priority: m.Priority, Weight: w})
+	}
+	return out
+}
+
+func (h *Handlers) listTrunkGroups(c *gin.Context) {
+	list, err := models.ListTrunkGroups(h.db, trunkRoutingTenant(c))
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", list)
+}
+
+func (h *Handlers) getTrunkGroup(c *gin.Context) {
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	row, err := models.GetTrunkGroupForTenant(h.db, id, trunkRoutingTenant(c))
+	if err != nil {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+// validateTrunkGroupReq 规范化名称与策略并校验成员；失败时已写响应。
+func (h *Handlers) validateTrunkGroupReq(c *gin.Context, req *trunkGroupWriteReq) bool {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.TenantID == 0 || req.Name == "" {
+		response.Fail(c, "tenantId and name required", nil)
+		return false
+	}
+	strategy, err := models.NormalizeTrunkGroupStrategy(req.Strategy)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return false
+	}
+	req.Strategy = strategy
+	if err := models.ValidateTrunkGroupMembers(h.db, req.TenantID, req.members()); err != nil {
+		response.Fail(c, err.Error(), nil)
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) createTrunkGroup(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	var req trunkGroupWriteReq
+	if !ginutil.BindJSON(c, &req) || !h.validateTrunkGroupReq(c, &req) {
+		return
+	}
+	row := models.TrunkGroup{
+		TenantID:    req.TenantID,
+		Name:        req.Name,
+		Strategy:    req.Strategy,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := h.db.Create(&row).Error; err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	if err := models.ReplaceTrunkGroupMembers(h.db, row.ID, req.members()); err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	h.reloadTrunkHealth()
+	row, _ = models.GetTrunkGroupForTenant(h.db, row.ID, 0)
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) updateTrunkGroup(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req trunkGroupWriteReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	existing, err := models.GetTrunkGroupForTenant(h.db, id, 0)
+	if err != nil {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	// 组不能换租户：已有路由和活动按租户引用它。
+	req.TenantID = existing.TenantID
+	if !h.validateTrunkGroupReq(c, &req) {
+		return
+	}
+	updates := map[string]any{
+		"name":        req.Name,
+		"strategy":    req.Strategy,
+		"description": strings.TrimSpace(req.Description),
+	}
+	if err := h.db.Model(&models.TrunkGroup{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	if err := models.ReplaceTrunkGroupMembers(h.db, id, req.members()); err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	h.reloadTrunkHealth()
+	row, _ := models.GetTrunkGroupForTenant(h.db, id, 0)
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) deleteTrunkGroup(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := models.GetTrunkGroupForTenant(h.db, id, 0); err != nil {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	if err := models.DeleteTrunkGroupCascade(h.db, id); err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	h.reloadTrunkHealth()
+	response.Success(c, "success", gin.H{"id": id})
+}
+
+func (h *Handlers) listTrunkRoutes(c *gin.Context) {
+	list, err := models.ListTrunkRoutes(h.db, trunkRoutingTenant(c))
+	if ginutil.WriteInternalError(c, err) {
+		return
+	}
+	response.Success(c, "success", list)
+}
+
+// validateTrunkRouteReq 把前缀规范为纯数字并校验中继组属于同一租户；失败时已写响应。
+func (h *Handlers) validateTrunkRouteReq(c *gin.Context, req *trunkRouteWriteReq) bool {
+	raw := strings.TrimSpace(req.Prefix)
+	req.Prefix = models.NormalizeTrunkRoutePrefix(raw)
+	if req.TenantID == 0 || req.GroupID == 0 {
+		response.Fail(c, "tenantId and groupId required", nil)
+		return false
+	}
+	if raw != "" && req.Prefix == "" {
+		response.Fail(c, "prefix must contain digits", nil)
+		return false
+	}
+	if _, err := models.GetTrunkGroupForTenant(h.db, req.GroupID, req.TenantID); err != nil {
+		response.Fail(c, "trunk group not found", nil)
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) createTrunkRoute(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	var req trunkRouteWriteReq
+	if !ginutil.BindJSON(c, &req) || !h.validateTrunkRouteReq(c, &req) {
+		return
+	}
+	row := models.TrunkRoute{
+		TenantID:    req.TenantID,
+		Prefix:      req.Prefix,
+		GroupID:     req.GroupID,
+		Cost:        req.Cost,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := h.db.Create(&row).Error; err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	response.Success(c, "success", row)
+}
+
+func (h *Handlers) updateTrunkRoute(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req trunkRouteWriteReq
+	if !ginutil.BindJSON(c, &req) {
+		return
+	}
+	var existing models.TrunkRoute
+	if err := h.db.First(&existing, id).Error; err != nil {
+		response.Fail(c, "not found", nil)
+		return
+	}
+	req.TenantID = existing.TenantID
+	if !h.validateTrunkRouteReq(c, &req) {
+		return
+	}
+	updates := map[string]any{
+		"prefix":      req.Prefix,
+		"group_id":    req.GroupID,
+		"cost":        req.Cost,
+		"description": strings.TrimSpace(req.Description),
+	}
+	if err := h.db.Model(&models.TrunkRoute{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	_ = h.db.First(&existing, id).Error
+	response.Success(c, "success", existing)
+}
+
+func (h *Handlers) deleteTrunkRoute(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.db.Delete(&models.TrunkRoute{}, id).Error; err != nil {
+		ginutil.WriteInternalError(c, err)
+		return
+	}
+	response.Success(c, "success", gin.H{"id": id})
+}
+
+// listTrunkHealth 返回被中继组引用的中继的 OPTIONS 心跳状态。
+func (h *Handlers) listTrunkHealth(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	list := []outbound.TrunkHealth{}
+	if h.trunkHealths != nil {
+		list = append(list, h.trunkHealths()...)
+	}
+	response.Success(c, "success", list)
+}
+
+// listTrunkStats 返回各中继近期外呼的 ASR / NER 及是否被降级。
+func (h *Handlers) listTrunkStats(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	list := []sipserver.TrunkStats{}
+	if h.trunkStats != nil {
+		list = append(list, h.trunkStats()...)
+	}
+	response.Success(c, "success", list)
+}
+
+// reloadTrunkHealth 在中继组或中继变更后让 OPTIONS 心跳按最新成员重建。
+func (h *Handlers) reloadTrunkHealth() {
+	if h.reloadTrunkHealthChecks != nil {
+		h.reloadTrunkHealthChecks()
+	}
+}
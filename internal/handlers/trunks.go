@@ -14,9 +14,13 @@ import (
 	"time"
 
 	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/dialog/langid"
 	"github.com/LinByte/VoiceServer/pkg/ginutil"
 	"github.com/LinByte/VoiceServer/pkg/middleware"
 	"github.com/LinByte/VoiceServer/pkg/response"
+	sipdtmf "github.com/LinByte/VoiceServer/pkg/sip/dtmf"
+	sipfax "github.com/LinByte/VoiceServer/pkg/sip/fax"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
 	"github.com/LinByte/VoiceServer/pkg/stores"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"github.com/LinByte/VoiceServer/pkg/welcomeaudio"
@@ -27,12 +31,28 @@ import (
 // 上传端点先在 HTTP 层硬截断，避免恶意客户端在内存里囤大 multipart payload。
 const maxWelcomeWAVBytes = 16 << 20
 
+// maxTrunkNumberACWSeconds 单个号码的话后处理时长上限（1 小时）。
+const maxTrunkNumberACWSeconds = 3600
+
 // trunkWriteReq 没有 providerCode 字段：供应商编码由后端在 BeforeCreate 钩子中生成，
 type trunkWriteReq struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Prefix      string `json:"prefix"`
 	LocalAddr   string `json:"local_addr"`
+	// 出局摘要鉴权。AuthPassword 为 nil 时更新保留原密码，"" 表示清空。
+	AuthUsername string  `json:"authUsername"`
+	AuthPassword *string `json:"authPassword"`
+	AuthRealm    string  `json:"authRealm"`
+	// 向运营商注册（AOR 用户为 AuthUsername）。RegistrarAddrs 逗号分隔，空则用 local_addr。
+	RegisterEnabled bool   `json:"registerEnabled"`
+	RegistrarAddrs  string `json:"registrarAddrs"`
+	RegisterExpires int    `json:"registerExpires"`
+	RegisterDomain  string `json:"registerDomain"`
+	// 带内 DTMF 检测模式：auto（默认）/ on / off。
+	InbandDTMF string `json:"inbandDtmf"`
+	// 传真模式：auto（默认）/ t38 / passthrough / off。
+	FaxMode string `json:"faxMode"`
 }
 
 type trunkNumberWriteReq struct {
@@ -68,6 +88,10 @@ type trunkNumberWriteReq struct {
 	TransferAgentBriefText string `json:"transferAgentBriefText"`
 	// TransferCallerBriefText 桥接前向主叫 TTS 播报模板（可选，最长 256 字）。留空则与坐席侧相同。
 	TransferCallerBriefText string `json:"transferCallerBriefText"`
+	// ACWSeconds 坐席话后处理（ACW）时长，0–3600 秒；0 使用 SIP_ACW_DEFAULT_SECONDS。
+	ACWSeconds int `json:"acwSeconds"`
+	// Languages 对话语种 JSON 数组（可选，最多 4 项，首项为默认语种），见 langid.Option。
+	Languages string `json:"languages"`
 }
 
 func (h *Handlers) listTrunks(c *gin.Context) {
@@ -97,11 +121,26 @@ func (h *Handlers) createTrunk(c *gin.Context) {
 		Description: strings.TrimSpace(req.Description),
 		Prefix:      strings.TrimSpace(req.Prefix),
 		LocalAddr:   strings.TrimSpace(req.LocalAddr),
+
+		AuthUsername: strings.TrimSpace(req.AuthUsername),
+		AuthRealm:    strings.TrimSpace(req.AuthRealm),
+
+		RegisterEnabled: req.RegisterEnabled,
+		RegistrarAddrs:  strings.TrimSpace(req.RegistrarAddrs),
+		RegisterExpires: req.RegisterExpires,
+		RegisterDomain:  strings.TrimSpace(req.RegisterDomain),
+
+		InbandDTMF: sipdtmf.NormalizeInbandMode(req.InbandDTMF),
+		FaxMode:    sipfax.NormalizeMode(req.FaxMode),
+	}
+	if req.AuthPassword != nil {
+		row.AuthPassword = *req.AuthPassword
 	}
 	if err := h.db.Create(&row).Error; err != nil {
 		ginutil.WriteInternalError(c, err)
 		return
 	}
+	h.reloadTrunkRegs()
 	response.Success(c, "success", row)
 }
 
@@ -143,11 +182,26 @@ func (h *Handlers) updateTrunk(c *gin.Context) {
 		"description": strings.TrimSpace(req.Description),
 		"prefix":      strings.TrimSpace(req.Prefix),
 		"local_addr":  strings.TrimSpace(req.LocalAddr),
+
+		"auth_username": strings.TrimSpace(req.AuthUsername),
+		"auth_realm":    strings.TrimSpace(req.AuthRealm),
+
+		"register_enabled": req.RegisterEnabled,
+		"registrar_addrs":  strings.TrimSpace(req.RegistrarAddrs),
+		"register_expires": req.RegisterExpires,
+		"register_domain":  strings.TrimSpace(req.RegisterDomain),
+
+		"inband_dtmf": sipdtmf.NormalizeInbandMode(req.InbandDTMF),
+		"fax_mode":    sipfax.NormalizeMode(req.FaxMode),
+	}
+	if req.AuthPassword != nil {
+		updates["auth_password"] = *req.AuthPassword
 	}
 	if err := h.db.Model(&models.Trunk{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 		ginutil.WriteInternalError(c, err)
 		return
 	}
+	h.reloadTrunkRegs()
 	row, _ := models.GetTrunkByID(h.db, id)
 	response.Success(c, "success", row)
 }
@@ -166,9 +220,46 @@ func (h *Handlers) deleteTrunk(c *gin.Context) {
 		ginutil.WriteInternalError(c, err)
 		return
 	}
+	h.reloadTrunkRegs()
 	response.Success(c, "success", gin.H{"id": id})
 }
 
+// listTrunkRegistrations 返回所有开启注册的中继的实时注册状态。
+func (h *Handlers) listTrunkRegistrations(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	list := []outbound.RegistrationStatus{}
+	if h.trunkRegistrations != nil {
+		list = append(list, h.trunkRegistrations()...)
+	}
+	response.Success(c, "success", list)
+}
+
+// getTrunkRegistration 返回单条中继的注册状态；未开启注册时 state 为空。
+func (h *Handlers) getTrunkRegistration(c *gin.Context) {
+	// Platform admin only (enforced by route middleware).
+	id, ok := ginutil.ParamID(c, "id")
+	if !ok {
+		return
+	}
+	if h.trunkRegistrations != nil {
+		for _, st := range h.trunkRegistrations() {
+			if st.TrunkID == id {
+				response.Success(c, "success", st)
+				return
+			}
+		}
+	}
+	response.Success(c, "success", outbound.RegistrationStatus{TrunkID: id})
+}
+
+// reloadTrunkRegs 在中继增删改后让 REGISTER 客户端与 OPTIONS 心跳按最新配置重建。
+func (h *Handlers) reloadTrunkRegs() {
+	if h.reloadTrunkRegistrations != nil {
+		h.reloadTrunkRegistrations()
+	}
+	h.reloadTrunkHealth()
+}
+
 func (h *Handlers) listTrunkNumbers(c *gin.Context) {
 	page, size := ginutil.QueryPage(c, 100)
 	var trunkID uint
@@ -267,6 +358,15 @@ func (h *Handlers) createTrunkNumber(c *gin.Context) {
 		response.Fail(c, err.Error(), nil)
 		return
 	}
+	if req.ACWSeconds < 0 || req.ACWSeconds > maxTrunkNumberACWSeconds {
+		response.Fail(c, "acwSeconds must be between 0 and 3600", nil)
+		return
+	}
+	languages, err := langid.NormalizeOptionsJSON(req.Languages)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
 	row := models.TrunkNumber{
 		TrunkID:               req.TrunkID,
 		TenantID:              req.TenantID,
@@ -287,6 +387,8 @@ func (h *Handlers) createTrunkNumber(c *gin.Context) {
 		TransferAgentBriefText:  briefText,
 		TransferCallerBriefText: callerBriefText,
 		OutboundTrunkNumberID:   req.OutboundTrunkNumberID,
+		ACWSeconds:              req.ACWSeconds,
+		LanguagesJSON:           languages,
 	}
 	if err := h.db.Create(&row).Error; err != nil {
 		ginutil.WriteInternalError(c, err)
@@ -371,6 +473,15 @@ func (h *Handlers) updateTrunkNumber(c *gin.Context) {
 		response.Fail(c, err.Error(), nil)
 		return
 	}
+	if req.ACWSeconds < 0 || req.ACWSeconds > maxTrunkNumberACWSeconds {
+		response.Fail(c, "acwSeconds must be between 0 and 3600", nil)
+		return
+	}
+	languages, err := langid.NormalizeOptionsJSON(req.Languages)
+	if err != nil {
+		response.Fail(c, err.Error(), nil)
+		return
+	}
 	updates := map[string]any{
 		"trunk_id":                 req.TrunkID,
 		"tenant_id":                req.TenantID,
@@ -391,6 +502,8 @@ func (h *Handlers) updateTrunkNumber(c *gin.Context) {
 		"transfer_agent_brief_text":  briefText,
 		"transfer_caller_brief_text": callerBriefText,
 		"outbound_trunk_number_id":     req.OutboundTrunkNumberID,
+		"acw_seconds":                  req.ACWSeconds,
+		"languages_json":               languages,
 	}
 	if err := h.db.Model(&models.TrunkNumber{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 		ginutil.WriteInternalError(c, err)
@@ -477,5 +590,6 @@ func (h *Handlers) deleteTrunkNumber(c *gin.Context) {
 		ginutil.WriteInternalError(c, err)
 		return
 	}
+	h.reloadTrunkHealth()
 	response.Success(c, "success", gin.H{"id": id})
 }
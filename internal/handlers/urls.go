@@ -4,6 +4,7 @@ package handlers
 // SPDX-License-Identifier: AGPL-3.0
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/LinByte/VoiceServer/cmd/bootstrap"
@@ -11,6 +12,8 @@ import (
 	"github.com/LinByte/VoiceServer/internal/sipserver"
 	"github.com/LinByte/VoiceServer/pkg/config"
 	"github.com/LinByte/VoiceServer/pkg/middleware"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"github.com/gin-gonic/gin"
@@ -20,6 +23,20 @@ import (
 type Handlers struct {
 	db          *gorm.DB
 	campaignSvc *sipserver.CampaignService
+	callSession func(callID string) *sipSession.CallSession
+
+	trunkRegistrations       func() []outbound.RegistrationStatus
+	reloadTrunkRegistrations func()
+
+	trunkHealths            func() []outbound.TrunkHealth
+	trunkStats              func() []sipserver.TrunkStats
+	reloadTrunkHealthChecks func()
+
+	sipWebSocket   http.HandlerFunc
+	chatSvc        *sipserver.ChatService
+	clickToCallSvc *sipserver.ClickToCallService
+	acwSvc         *sipserver.ACWService
+	wallboardSvc   *sipserver.WallboardService
 }
 
 func NewHandlers(db *gorm.DB) *Handlers {
@@ -34,6 +51,74 @@ func (h *Handlers) SetCampaignService(svc *sipserver.CampaignService) {
 	h.campaignSvc = svc
 }
 
+// SetCallSessionLookup wires live call lookup for in-call controls (recording pause/resume).
+func (h *Handlers) SetCallSessionLookup(fn func(callID string) *sipSession.CallSession) {
+	if h == nil {
+		return
+	}
+	h.callSession = fn
+}
+
+// SetTrunkRegistrationHooks wires carrier REGISTER client status and reload (trunk admin API).
+func (h *Handlers) SetTrunkRegistrationHooks(status func() []outbound.RegistrationStatus, reload func()) {
+	if h == nil {
+		return
+	}
+	h.trunkRegistrations = status
+	h.reloadTrunkRegistrations = reload
+}
+
+// SetTrunkRoutingHooks wires trunk OPTIONS health, per-trunk ASR / NER and the
+// health-check reload (trunk group admin API).
+func (h *Handlers) SetTrunkRoutingHooks(health func() []outbound.TrunkHealth, stats func() []sipserver.TrunkStats, reload func()) {
+	if h == nil {
+		return
+	}
+	h.trunkHealths = health
+	h.trunkStats = stats
+	h.reloadTrunkHealthChecks = reload
+}
+
+// SetSIPWebSocketHandler wires the SIP-over-WebSocket (RFC 7118) upgrade handler; nil keeps the route 404.
+func (h *Handlers) SetSIPWebSocketHandler(fn http.HandlerFunc) {
+	if h == nil {
+		return
+	}
+	h.sipWebSocket = fn
+}
+
+// SetChatService wires SIP MESSAGE routing for the send / status API (optional).
+func (h *Handlers) SetChatService(svc *sipserver.ChatService) {
+	if h == nil {
+		return
+	}
+	h.chatSvc = svc
+}
+
+// SetClickToCallService wires the API-originated call runner (optional).
+func (h *Handlers) SetClickToCallService(svc *sipserver.ClickToCallService) {
+	if h == nil {
+		return
+	}
+	h.clickToCallSvc = svc
+}
+
+// SetACWService wires agents' after-call work (wrap-up API, optional).
+func (h *Handlers) SetACWService(svc *sipserver.ACWService) {
+	if h == nil {
+		return
+	}
+	h.acwSvc = svc
+}
+
+// SetWallboardService wires the ACD wallboard and agent reports (optional).
+func (h *Handlers) SetWallboardService(svc *sipserver.WallboardService) {
+	if h == nil {
+		return
+	}
+	h.wallboardSvc = svc
+}
+
 func (h *Handlers) Register(engine *gin.Engine) {
 	engine.Use(middleware.LocaleMiddleware())
 	engine.GET("/.well-known/jwks.json", h.JWKSHandler)
@@ -58,6 +143,7 @@ func (h *Handlers) Register(engine *gin.Engine) {
 	h.registerVoiceCloneRoutes(protected)
 	h.registerLingechoWebSeatRoutes(r)
 	h.registerVoiceDialogRoutes(r)
+	r.GET(constants.SIPWebSocketPath, h.sipWebSocketUpgrade)
 }
 
 // registerCredentialRoutes mounts /credentials for tenant-scoped
@@ -107,10 +193,21 @@ func (h *Handlers) registerLingechoWebSeatRoutes(r *gin.RouterGroup) {
 		g.POST("/hangup", gin.WrapF(webseat.HangupHTTP))
 		g.POST("/reject", gin.WrapF(webseat.RejectHTTP))
 		g.GET("/ws", gin.WrapF(webseat.WebSocketHTTP))
+		g.GET("/ice-servers", gin.WrapF(webseat.ICEServersHTTP))
 		g.GET("/status/:callId", h.lingechoWebSeatStatus)
 	}
 }
 
+// sipWebSocketUpgrade hands the request to the SIP stack's WebSocket
+// listener. Authentication is SIP digest on REGISTER / INVITE, not JWT.
+func (h *Handlers) sipWebSocketUpgrade(c *gin.Context) {
+	if h.sipWebSocket == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sip websocket disabled"})
+		return
+	}
+	h.sipWebSocket(c.Writer, c.Request)
+}
+
 // registerTenantUserRoutes mounts /tenant-users for in-tenant user
 func (h *Handlers) registerTenantUserRoutes(r *gin.RouterGroup) {
 	g := r.Group("tenant-users")
@@ -153,6 +250,8 @@ func (h *Handlers) registerPlatformTenantRoutes(r *gin.RouterGroup) {
 		g.POST("", h.createTenantPlatform)
 		g.PUT("/:id", h.updateTenantPlatform)
 		g.DELETE("/:id", h.deleteTenantPlatform)
+		g.POST("/:id/recording-keys/rotate", h.rotateTenantRecordingKeys)
+		g.GET("/:id/retention/purge-logs", h.listTenantRetentionPurgeLogs)
 	}
 }
 
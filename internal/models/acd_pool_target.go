@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
 	"strconv"
@@ -49,6 +50,7 @@ type ACDPoolTarget struct {
 	SortOrder             int        `json:"sortOrder" gorm:"column:sort_order;not null;default:0;index"` // SortOrder: lower = higher transfer priority.
 	WorkState             string     `json:"workState" gorm:"size:24;not null;default:offline;index"` // WorkState: see ACDWorkState*; default offline until sign-in or integration sets available.
 	WorkStateAt           *time.Time `json:"workStateAt"`                                             // WorkStateAt: optional last transition (ring timeouts, metrics).
+	WorkStateReason       string     `json:"workStateReason,omitempty" gorm:"column:work_state_reason;size:32"` // WorkStateReason: break reason (ACDBreakReason*) while work_state = break.
 	WebSeatLastSeenAt     *time.Time `json:"webSeatLastSeenAt" gorm:"column:web_seat_last_seen_at"`   // WebSeatLastSeenAt: route_type=web only; last heartbeat from browser (keepalive). Used for pick + admin "链路在线".
 	// ShiftScheduleJSON optional weekly windows, e.g. [{"weekdays":[1,2,3,4,5],"start":"09:00","end":"18:00"}] (weekdays: 0=Sun .. 6=Sat). Empty = no restriction.
 	ShiftScheduleJSON string `json:"shiftSchedule" gorm:"column:shift_schedule_json;type:text"`
@@ -103,6 +105,26 @@ func NormalizeACDWorkState(s string) string {
 	}
 }
 
+// ErrInvalidACDBreakReason is returned for break reasons outside ACDBreakReason*.
+var ErrInvalidACDBreakReason = errors.New("invalid break reason")
+
+// NormalizeACDBreakReason validates a break reason for workState: only break
+// keeps one (empty = unspecified); other states always clear it.
+func NormalizeACDBreakReason(workState, reason string) (string, error) {
+	if workState != constants.ACDWorkStateBreak {
+		return "", nil
+	}
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	switch reason {
+	case "", constants.ACDBreakReasonRest, constants.ACDBreakReasonMeal, constants.ACDBreakReasonMeeting,
+		constants.ACDBreakReasonTraining, constants.ACDBreakReasonCoaching, constants.ACDBreakReasonBackoffice,
+		constants.ACDBreakReasonOther:
+		return reason, nil
+	default:
+		return "", ErrInvalidACDBreakReason
+	}
+}
+
 // NormalizeACDTrunkPort returns a valid SIP port or 6050.
 func NormalizeACDTrunkPort(p int) int {
 	if p <= 0 || p >= 65536 {
@@ -185,6 +207,22 @@ func GetActiveACDPoolTargetByID(db *gorm.DB, id uint) (ACDPoolTarget, error) {
 	return row, err
 }
 
+// ListACDPoolTargetsByTargetValue returns every active SIP / web pool row for one agent
+// (target_value = SIP username), across tenants and trunk-number scopes (BLF presence).
+func ListACDPoolTargetsByTargetValue(ctx context.Context, db *gorm.DB, targetValue string) ([]ACDPoolTarget, error) {
+	targetValue = strings.TrimSpace(targetValue)
+	if db == nil || targetValue == "" {
+		return nil, nil
+	}
+	var rows []ACDPoolTarget
+	err := ActiveACDPoolTargets(db.WithContext(ctx)).
+		Where("target_value = ?", targetValue).
+		Where("route_type IN ?", []string{constants.ACDPoolRouteTypeSIP, constants.ACDPoolRouteTypeWeb}).
+		Order("id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
 // FindSIPACDPoolTargetForIncomingPoll resolves a SIP pool row for the agent incoming poll API.
 // Exactly one of id, name, or targetValue should be set (id wins when > 0).
 func FindSIPACDPoolTargetForIncomingPoll(db *gorm.DB, tenantID, id uint, name, targetValue string) (ACDPoolTarget, bool, error) {
@@ -337,6 +375,9 @@ func BuildACDPoolTargetUpdateMap(
 	if existing.WorkState != workState {
 		u["work_state_at"] = now
 	}
+	if workState != constants.ACDWorkStateBreak {
+		u["work_state_reason"] = ""
+	}
 	if routeType == constants.ACDPoolRouteTypeWeb && workState == constants.ACDWorkStateAvailable {
 		u["web_seat_last_seen_at"] = now
 	}
@@ -369,23 +410,60 @@ func UpdateACDPoolTargetWebSeatHeartbeat(db *gorm.DB, id uint, operator string,
 }
 
 // UpdateACDPoolTargetWorkState updates work_state for an active row (admin, SIP transfer, or webseat).
+// Writes refused by the SetACDWorkStateHold hook are skipped silently; real
+// transitions are appended to acd_work_state_events.
 func UpdateACDPoolTargetWorkState(ctx context.Context, db *gorm.DB, id uint, workState string, updateBy string) error {
+	return UpdateACDPoolTargetWorkStateWithReason(ctx, db, id, workState, "", updateBy)
+}
+
+// UpdateACDPoolTargetWorkStateWithReason is UpdateACDPoolTargetWorkState with a break
+// reason (already normalized; ignored unless workState is break).
+func UpdateACDPoolTargetWorkStateWithReason(ctx context.Context, db *gorm.DB, id uint, workState, reason string, updateBy string) error {
 	if db == nil || id == 0 {
 		return nil
 	}
 	ws := NormalizeACDWorkState(workState)
+	if ws != constants.ACDWorkStateBreak {
+		reason = ""
+	}
+	if acdWorkStateHeld(id, ws) {
+		return nil
+	}
+	var prev ACDPoolTarget
+	if err := ActiveACDPoolTargets(db.WithContext(ctx)).Select("id, tenant_id, work_state, work_state_reason").
+		Where("id = ?", id).Limit(1).Find(&prev).Error; err != nil {
+		return err
+	}
 	now := time.Now()
 	u := map[string]any{
-		"work_state":    ws,
-		"work_state_at": now,
-		"updated_at":    now,
+		"work_state":        ws,
+		"work_state_reason": reason,
+		"work_state_at":     now,
+		"updated_at":        now,
 	}
 	meta := BaseModel{}
 	meta.SetUpdateInfo(updateBy)
 	if meta.UpdateBy != "" {
 		u["update_by"] = meta.UpdateBy
 	}
-	return ActiveACDPoolTargets(db.WithContext(ctx)).Where("id = ?", id).Updates(u).Error
+	if err := ActiveACDPoolTargets(db.WithContext(ctx)).Where("id = ?", id).Updates(u).Error; err != nil {
+		return err
+	}
+	if prev.ID > 0 {
+		// History is best-effort: the seat state itself is already written.
+		_ = AppendACDWorkStateEvent(ctx, db, ACDWorkStateEvent{
+			TenantID:    prev.TenantID,
+			ACDTargetID: id,
+			PrevState:   NormalizeACDWorkState(prev.WorkState),
+			PrevReason:  prev.WorkStateReason,
+			WorkState:   ws,
+			Reason:      reason,
+			Source:      updateBy,
+			At:          now,
+		})
+	}
+	NotifyACDWorkStateChanged(id, ws)
+	return nil
 }
 
 // WebSeatActorMayTouchRow allows heartbeat when CreateBy is empty or matches operator.
@@ -658,15 +736,42 @@ func SoftDeleteACDPoolTargetsByIDs(ctx context.Context, db *gorm.DB, ids []uint,
 // MarkStaleWebACDPoolTargetsOffline sets stale web seats to offline for abnormal-disconnect fallback.
 func MarkStaleWebACDPoolTargetsOffline(ctx context.Context, db *gorm.DB, now time.Time) (int64, error) {
 	freshSince := now.Add(-constants.WebSeatStaleAfter)
-	res := ActiveACDPoolTargets(db.WithContext(ctx)).
+	var rows []ACDPoolTarget
+	if err := ActiveACDPoolTargets(db.WithContext(ctx)).
+		Select("id, tenant_id, work_state, work_state_reason").
 		Where("route_type = ?", constants.ACDPoolRouteTypeWeb).
 		Where("work_state <> ?", constants.ACDWorkStateOffline).
 		Where("web_seat_last_seen_at IS NULL OR web_seat_last_seen_at <= ?", freshSince).
+		Find(&rows).Error; err != nil || len(rows) == 0 {
+		return 0, err
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	res := ActiveACDPoolTargets(db.WithContext(ctx)).
+		Where("id IN ?", ids).
+		Where("work_state <> ?", constants.ACDWorkStateOffline).
 		Updates(map[string]any{
-			"work_state":    constants.ACDWorkStateOffline,
-			"work_state_at": now,
-			"updated_at":    now,
+			"work_state":        constants.ACDWorkStateOffline,
+			"work_state_reason": "",
+			"work_state_at":     now,
+			"updated_at":        now,
 		})
+	if res.Error == nil {
+		for _, r := range rows {
+			_ = AppendACDWorkStateEvent(ctx, db, ACDWorkStateEvent{
+				TenantID:    r.TenantID,
+				ACDTargetID: r.ID,
+				PrevState:   NormalizeACDWorkState(r.WorkState),
+				PrevReason:  r.WorkStateReason,
+				WorkState:   constants.ACDWorkStateOffline,
+				Source:      "web-seat-stale",
+				At:          now,
+			})
+			NotifyACDWorkStateChanged(r.ID, constants.ACDWorkStateOffline)
+		}
+	}
 	return res.RowsAffected, res.Error
 }
 
@@ -683,14 +788,27 @@ type acdShiftWindow struct {
 
 // ACDFitsShiftSchedule reports whether t falls into any configured window. Empty or invalid JSON means 24/7 (eligible).
 func ACDFitsShiftSchedule(scheduleJSON string, t time.Time, loc *time.Location) bool {
+	windows, ok := parseACDShiftWindows(scheduleJSON)
+	if !ok {
+		return true
+	}
+	return acdShiftWindowsMatch(windows, t, loc)
+}
+
+// parseACDShiftWindows decodes ShiftScheduleJSON; false means no restriction (24/7).
+func parseACDShiftWindows(scheduleJSON string) ([]acdShiftWindow, bool) {
 	scheduleJSON = strings.TrimSpace(scheduleJSON)
 	if scheduleJSON == "" {
-		return true
+		return nil, false
 	}
 	var windows []acdShiftWindow
 	if err := json.Unmarshal([]byte(scheduleJSON), &windows); err != nil || len(windows) == 0 {
-		return true
+		return nil, false
 	}
+	return windows, true
+}
+
+func acdShiftWindowsMatch(windows []acdShiftWindow, t time.Time, loc *time.Location) bool {
 	if loc == nil {
 		loc = time.Local
 	}
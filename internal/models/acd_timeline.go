@@ -0,0 +1,260 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// ACDStateSegment is one continuous stretch of a seat in one work state.
+type ACDStateSegment struct {
+	WorkState string    `json:"workState"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Sec       int64     `json:"sec"`
+}
+
+// ACDInterval is a half-open time range [Start, End).
+type ACDInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ACDAdherence compares a seat's states with its ShiftScheduleJSON windows.
+type ACDAdherence struct {
+	ScheduledSec          int64   `json:"scheduledSec"`
+	AdherentSec           int64   `json:"adherentSec"`           // in shift and available / ringing / busy / acw
+	InShiftBreakSec       int64   `json:"inShiftBreakSec"`       // in shift, on break
+	InShiftOfflineSec     int64   `json:"inShiftOfflineSec"`     // in shift, offline or unknown
+	OutOfShiftLoggedInSec int64   `json:"outOfShiftLoggedInSec"` // not offline outside the schedule
+	Adherence             float64 `json:"adherence"`             // AdherentSec / ScheduledSec
+	Conformance           float64 `json:"conformance"`           // logged-in seconds / ScheduledSec
+}
+
+// ACDTimelineStats summarizes segments over one window.
+type ACDTimelineStats struct {
+	StateSeconds map[string]int64 `json:"stateSeconds"`
+	BreakSeconds map[string]int64 `json:"breakSeconds"` // by reason; "" = unspecified
+	// Occupancy is handling time (ringing + busy + acw) over handling plus
+	// available time; nil when the seat was never ready.
+	Occupancy *float64 `json:"occupancy"`
+	// Adherence is nil when the seat has no shift schedule (24/7).
+	Adherence *ACDAdherence `json:"adherence"`
+}
+
+// ACDTimeline is one seat's state history with computed stats.
+type ACDTimeline struct {
+	ACDTargetID uint              `json:"acdTargetId,string"`
+	Name        string            `json:"name"`
+	StartAt     time.Time         `json:"startAt"`
+	EndAt       time.Time         `json:"endAt"`
+	Segments    []ACDStateSegment `json:"segments"`
+	Shifts      []ACDInterval     `json:"shifts"`
+	ACDTimelineStats
+}
+
+func acdStateHandling(ws string) bool {
+	switch ws {
+	case constants.ACDWorkStateRinging, constants.ACDWorkStateBusy, constants.ACDWorkStateACW:
+		return true
+	}
+	return false
+}
+
+func acdStateReady(ws string) bool {
+	return ws == constants.ACDWorkStateAvailable || acdStateHandling(ws)
+}
+
+// ACDStateSegments turns transitions into contiguous segments covering
+// [from, to). initial is the state at from (zero WorkState = unknown: the
+// stretch before the first event is returned with an empty WorkState);
+// events must be sorted by At.
+func ACDStateSegments(initial ACDWorkStateEvent, events []ACDWorkStateEvent, from, to time.Time) []ACDStateSegment {
+	var out []ACDStateSegment
+	if !to.After(from) {
+		return out
+	}
+	cur := ACDStateSegment{WorkState: initial.WorkState, Reason: initial.Reason, Source: initial.Source, Start: from}
+	for _, ev := range events {
+		if ev.At.Before(from) {
+			cur.WorkState, cur.Reason, cur.Source = ev.WorkState, ev.Reason, ev.Source
+			continue
+		}
+		if !ev.At.Before(to) {
+			break
+		}
+		if ev.At.After(cur.Start) {
+			cur.End = ev.At
+			out = append(out, cur)
+		}
+		cur = ACDStateSegment{WorkState: ev.WorkState, Reason: ev.Reason, Source: ev.Source, Start: ev.At}
+	}
+	cur.End = to
+	out = append(out, cur)
+	for i := range out {
+		out[i].Sec = int64(out[i].End.Sub(out[i].Start) / time.Second)
+	}
+	return out
+}
+
+// ACDShiftIntervals expands ShiftScheduleJSON into in-shift intervals within
+// [from, to) at minute resolution; scheduled is false for an empty schedule (24/7).
+func ACDShiftIntervals(scheduleJSON string, from, to time.Time, loc *time.Location) (out []ACDInterval, scheduled bool) {
+	windows, ok := parseACDShiftWindows(scheduleJSON)
+	if !ok {
+		return nil, false
+	}
+	for t := from.Truncate(time.Minute); t.Before(to); t = t.Add(time.Minute) {
+		if !acdShiftWindowsMatch(windows, t, loc) {
+			continue
+		}
+		start, end := t, t.Add(time.Minute)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if n := len(out); n > 0 && out[n-1].End.Equal(start) {
+			out[n-1].End = end
+			continue
+		}
+		out = append(out, ACDInterval{Start: start, End: end})
+	}
+	return out, true
+}
+
+// acdOverlapSec is the overlap of [s, e) with the intervals, in seconds.
+func acdOverlapSec(s, e time.Time, ivs []ACDInterval) int64 {
+	var d time.Duration
+	for _, iv := range ivs {
+		a, b := iv.Start, iv.End
+		if a.Before(s) {
+			a = s
+		}
+		if b.After(e) {
+			b = e
+		}
+		if b.After(a) {
+			d += b.Sub(a)
+		}
+	}
+	return int64(d / time.Second)
+}
+
+// ComputeACDTimelineStats summarizes segments (and shift intervals when
+// scheduled) clipped to [from, to).
+func ComputeACDTimelineStats(segs []ACDStateSegment, shifts []ACDInterval, scheduled bool, from, to time.Time) ACDTimelineStats {
+	st := ACDTimelineStats{StateSeconds: map[string]int64{}, BreakSeconds: map[string]int64{}}
+	var adh *ACDAdherence
+	if scheduled {
+		adh = &ACDAdherence{}
+		adh.ScheduledSec = acdOverlapSec(from, to, shifts)
+	}
+	var handling, ready, loggedIn int64
+	for _, sg := range segs {
+		s, e := sg.Start, sg.End
+		if s.Before(from) {
+			s = from
+		}
+		if e.After(to) {
+			e = to
+		}
+		if !e.After(s) {
+			continue
+		}
+		sec := int64(e.Sub(s) / time.Second)
+		ws := sg.WorkState
+		if ws != "" {
+			st.StateSeconds[ws] += sec
+		}
+		if ws == constants.ACDWorkStateBreak {
+			st.BreakSeconds[sg.Reason] += sec
+		}
+		if acdStateReady(ws) {
+			ready += sec
+			if acdStateHandling(ws) {
+				handling += sec
+			}
+		}
+		on := ws != "" && ws != constants.ACDWorkStateOffline
+		if on {
+			loggedIn += sec
+		}
+		if adh == nil {
+			continue
+		}
+		in := acdOverlapSec(s, e, shifts)
+		switch {
+		case acdStateReady(ws):
+			adh.AdherentSec += in
+		case ws == constants.ACDWorkStateBreak:
+			adh.InShiftBreakSec += in
+		default:
+			adh.InShiftOfflineSec += in
+		}
+		if on {
+			adh.OutOfShiftLoggedInSec += sec - in
+		}
+	}
+	if ready > 0 {
+		occ := float64(handling) / float64(ready)
+		st.Occupancy = &occ
+	}
+	if adh != nil {
+		if adh.ScheduledSec > 0 {
+			adh.Adherence = float64(adh.AdherentSec) / float64(adh.ScheduledSec)
+			adh.Conformance = float64(loggedIn) / float64(adh.ScheduledSec)
+		}
+		st.Adherence = adh
+	}
+	return st
+}
+
+// acdInitialState is the seat's state at t: its last transition before t,
+// else the current row state when that was already set before t.
+func acdInitialState(ctx context.Context, db *gorm.DB, row ACDPoolTarget, t time.Time) (ACDWorkStateEvent, error) {
+	ev, ok, err := LastACDWorkStateEventBefore(ctx, db, row.ID, t)
+	if err != nil || ok {
+		return ev, err
+	}
+	if row.WorkStateAt != nil && row.WorkStateAt.Before(t) {
+		return ACDWorkStateEvent{WorkState: NormalizeACDWorkState(row.WorkState), Reason: row.WorkStateReason}, nil
+	}
+	return ACDWorkStateEvent{}, nil
+}
+
+// BuildACDTimeline loads a seat's transitions in [from, to) (cut at now) and
+// computes state seconds, break reasons, occupancy and shift adherence.
+func BuildACDTimeline(ctx context.Context, db *gorm.DB, row ACDPoolTarget, from, to, now time.Time) (ACDTimeline, error) {
+	if now.Before(to) {
+		to = now
+	}
+	tl := ACDTimeline{ACDTargetID: row.ID, Name: strings.TrimSpace(row.Name), StartAt: from, EndAt: to}
+	if !to.After(from) {
+		tl.Segments, tl.Shifts = []ACDStateSegment{}, []ACDInterval{}
+		tl.ACDTimelineStats = ComputeACDTimelineStats(nil, nil, false, from, to)
+		return tl, nil
+	}
+	initial, err := acdInitialState(ctx, db, row, from)
+	if err != nil {
+		return tl, err
+	}
+	evs, err := ListACDWorkStateEvents(ctx, db, 0, row.ID, from, to)
+	if err != nil {
+		return tl, err
+	}
+	tl.Segments = ACDStateSegments(initial, evs, from, to)
+	shifts, scheduled := ACDShiftIntervals(row.ShiftScheduleJSON, from, to, ACDShiftTimeLocation())
+	tl.Shifts = shifts
+	if tl.Shifts == nil {
+		tl.Shifts = []ACDInterval{}
+	}
+	tl.ACDTimelineStats = ComputeACDTimelineStats(tl.Segments, shifts, scheduled, from, to)
+	return tl, nil
+}
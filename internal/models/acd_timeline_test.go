@@ -0,0 +1,159 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeACDBreakReason(t *testing.T) {
+	if got, err := NormalizeACDBreakReason("break", " Meal "); err != nil || got != "meal" {
+		t.Fatalf("meal: %q, %v", got, err)
+	}
+	if got, err := NormalizeACDBreakReason("break", ""); err != nil || got != "" {
+		t.Fatalf("unspecified: %q, %v", got, err)
+	}
+	if _, err := NormalizeACDBreakReason("break", "nap"); err == nil {
+		t.Fatal("unknown reason accepted")
+	}
+	if got, err := NormalizeACDBreakReason("available", "meal"); err != nil || got != "" {
+		t.Fatalf("non-break keeps reason: %q, %v", got, err)
+	}
+}
+
+func TestACDStateSegments(t *testing.T) {
+	from := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	evs := []ACDWorkStateEvent{
+		{WorkState: "available", At: from},
+		{WorkState: "break", Reason: "meal", At: from.Add(30 * time.Minute)},
+		{WorkState: "available", At: to},
+	}
+	segs := ACDStateSegments(ACDWorkStateEvent{WorkState: "offline"}, evs, from, to)
+	if len(segs) != 2 {
+		t.Fatalf("segments: %+v", segs)
+	}
+	if segs[0].WorkState != "available" || segs[0].Sec != 1800 {
+		t.Fatalf("first: %+v", segs[0])
+	}
+	if segs[1].WorkState != "break" || segs[1].Reason != "meal" || !segs[1].End.Equal(to) {
+		t.Fatalf("second: %+v", segs[1])
+	}
+
+	segs = ACDStateSegments(ACDWorkStateEvent{}, evs[1:2], from, to)
+	if len(segs) != 2 || segs[0].WorkState != "" || segs[0].Sec != 1800 {
+		t.Fatalf("unknown initial: %+v", segs)
+	}
+}
+
+func TestACDShiftIntervals(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, loc) // Monday
+	to := from.AddDate(0, 0, 1)
+	ivs, scheduled := ACDShiftIntervals(`[{"weekdays":[1],"start":"09:00","end":"12:00"},{"weekdays":[1],"start":"13:00","end":"18:00"}]`, from, to, loc)
+	if !scheduled || len(ivs) != 2 {
+		t.Fatalf("intervals: %v %v", scheduled, ivs)
+	}
+	if !ivs[0].Start.Equal(from.Add(9*time.Hour)) || !ivs[1].End.Equal(from.Add(18*time.Hour)) {
+		t.Fatalf("bounds: %v", ivs)
+	}
+	if _, scheduled := ACDShiftIntervals("", from, to, loc); scheduled {
+		t.Fatal("empty schedule reported as scheduled")
+	}
+}
+
+func TestComputeACDTimelineStats(t *testing.T) {
+	from := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	to := from.Add(4 * time.Hour)
+	h := func(n float64) time.Time { return from.Add(time.Duration(n * float64(time.Hour))) }
+	segs := []ACDStateSegment{
+		{WorkState: "available", Start: h(0), End: h(1)}, // before the shift
+		{WorkState: "offline", Start: h(1), End: h(1.5)},
+		{WorkState: "busy", Start: h(1.5), End: h(2.5)},
+		{WorkState: "break", Reason: "meal", Start: h(2.5), End: h(3)},
+		{WorkState: "available", Start: h(3), End: h(4)},
+	}
+	shift := []ACDInterval{{Start: h(1), End: h(4)}} // 09:00–12:00
+	st := ComputeACDTimelineStats(segs, shift, true, from, to)
+
+	if st.StateSeconds["available"] != 7200 || st.StateSeconds["busy"] != 3600 || st.BreakSeconds["meal"] != 1800 {
+		t.Fatalf("seconds: %v %v", st.StateSeconds, st.BreakSeconds)
+	}
+	if st.Occupancy == nil || *st.Occupancy != 1.0/3 {
+		t.Fatalf("occupancy: %v", st.Occupancy)
+	}
+	a := st.Adherence
+	if a == nil || a.ScheduledSec != 10800 || a.AdherentSec != 7200 || a.InShiftBreakSec != 1800 || a.InShiftOfflineSec != 1800 {
+		t.Fatalf("adherence: %+v", a)
+	}
+	if a.OutOfShiftLoggedInSec != 3600 || a.Adherence != 7200.0/10800 || a.Conformance != 12600.0/10800 {
+		t.Fatalf("adherence ratios: %+v", a)
+	}
+
+	// Clipped to one hour, no schedule.
+	st = ComputeACDTimelineStats(segs, nil, false, h(1), h(2))
+	if st.Adherence != nil || st.StateSeconds["offline"] != 1800 || st.StateSeconds["busy"] != 1800 {
+		t.Fatalf("clipped: %+v", st)
+	}
+}
+
+func TestComputeACDTimelineStats_PerDay(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, loc) // Monday
+	now := from.Add(30 * time.Hour)                // Tuesday 06:00
+	to := from.AddDate(0, 0, 3)
+	evs := []ACDWorkStateEvent{
+		{WorkState: "available", At: from.Add(22 * time.Hour)},
+		{WorkState: "break", Reason: "meal", At: from.Add(23 * time.Hour)},
+		{WorkState: "available", At: from.Add(24*time.Hour + 30*time.Minute)},
+		{WorkState: "offline", At: from.Add(25 * time.Hour)},
+	}
+	initial := ACDWorkStateEvent{WorkState: "offline"}
+
+	// Same split as WallboardService.AgentDailyReport: the range is cut at
+	// now before segmenting, then each local day is clipped.
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+	segs := ACDStateSegments(initial, evs, from, end)
+	shifts, scheduled := ACDShiftIntervals(`[{"weekdays":[1,2],"start":"22:00","end":"23:59"}]`, from, end, loc)
+	days := map[string]ACDTimelineStats{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+		days[day.Format("2006-01-02")] = ComputeACDTimelineStats(segs, shifts, scheduled, day, dayEnd)
+	}
+	if len(days) != 2 {
+		t.Fatalf("days: %v", days)
+	}
+
+	d1, d2 := days["2026-03-02"], days["2026-03-03"]
+	if d1.StateSeconds["offline"] != 22*3600 || d1.StateSeconds["available"] != 3600 || d1.BreakSeconds["meal"] != 3600 {
+		t.Fatalf("day 1: %v %v", d1.StateSeconds, d1.BreakSeconds)
+	}
+	if a := d1.Adherence; a == nil || a.ScheduledSec != 119*60 || a.AdherentSec != 3600 || a.InShiftBreakSec != 59*60 {
+		t.Fatalf("day 1 adherence: %+v", a)
+	}
+	// Day 2 is cut at now (06:00), not midnight, and its shift has not started.
+	if d2.BreakSeconds["meal"] != 1800 || d2.StateSeconds["available"] != 1800 || d2.StateSeconds["offline"] != 5*3600 {
+		t.Fatalf("day 2 (cut at now): %v %v", d2.StateSeconds, d2.BreakSeconds)
+	}
+	if a := d2.Adherence; a == nil || a.ScheduledSec != 0 || a.OutOfShiftLoggedInSec != 3600 {
+		t.Fatalf("day 2 adherence: %+v", a)
+	}
+
+	// Per-state seconds agree with ACDStateDurationsByDay.
+	byDay := ACDStateDurationsByDay(initial.WorkState, evs, from, to, now, loc)
+	for day, st := range days {
+		for ws, sec := range byDay[day] {
+			if st.StateSeconds[ws] != sec {
+				t.Fatalf("%s %s: timeline %d, durations %d", day, ws, st.StateSeconds[ws], sec)
+			}
+		}
+		if len(byDay[day]) != len(st.StateSeconds) {
+			t.Fatalf("%s: timeline %v, durations %v", day, st.StateSeconds, byDay[day])
+		}
+	}
+}
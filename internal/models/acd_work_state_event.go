@@ -0,0 +1,187 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// ACDWorkStateEvent is one append-only work_state transition of an ACD seat
+// (acd_pool_targets.work_state is overwritten in place; this keeps the history).
+type ACDWorkStateEvent struct {
+	ID          uint      `json:"id,string" gorm:"primaryKey"`
+	TenantID    uint      `json:"tenantId,string" gorm:"index;not null;default:0"`
+	ACDTargetID uint      `json:"acdTargetId,string" gorm:"column:acd_target_id;index:idx_acd_ws_event_target_at,priority:1;not null"`
+	WorkState   string    `json:"workState" gorm:"size:24;not null"`
+	Reason      string    `json:"reason,omitempty" gorm:"size:32"` // break reason (ACDBreakReason*)
+	PrevState   string    `json:"prevState" gorm:"size:24;not null;default:''"`
+	PrevReason  string    `json:"prevReason,omitempty" gorm:"size:32"`
+	Source      string    `json:"source,omitempty" gorm:"size:128"` // writer (update_by): sip-transfer, acw, acd-shift, web-seat-stale, operator …
+	At          time.Time `json:"at" gorm:"index:idx_acd_ws_event_target_at,priority:2;not null"`
+}
+
+func (ACDWorkStateEvent) TableName() string {
+	return constants.ACDWorkStateEventTableName
+}
+
+// RecordACDWorkStateEvent appends one transition; unchanged states are not recorded.
+func RecordACDWorkStateEvent(ctx context.Context, db *gorm.DB, tenantID, targetID uint, prev, next, source string, at time.Time) error {
+	return AppendACDWorkStateEvent(ctx, db, ACDWorkStateEvent{
+		TenantID:    tenantID,
+		ACDTargetID: targetID,
+		WorkState:   next,
+		PrevState:   prev,
+		Source:      source,
+		At:          at,
+	})
+}
+
+// AppendACDWorkStateEvent is RecordACDWorkStateEvent with break reasons;
+// unchanged state + reason is not recorded.
+func AppendACDWorkStateEvent(ctx context.Context, db *gorm.DB, ev ACDWorkStateEvent) error {
+	if db == nil || ev.ACDTargetID == 0 || (ev.PrevState == ev.WorkState && ev.PrevReason == ev.Reason) {
+		return nil
+	}
+	ev.ID = 0
+	ev.Source = strings.TrimSpace(ev.Source)
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	return db.WithContext(ctx).Create(&ev).Error
+}
+
+// LastACDWorkStateEventBefore returns the seat's last transition before t.
+func LastACDWorkStateEventBefore(ctx context.Context, db *gorm.DB, targetID uint, t time.Time) (ACDWorkStateEvent, bool, error) {
+	var list []ACDWorkStateEvent
+	err := db.WithContext(ctx).Model(&ACDWorkStateEvent{}).
+		Where("acd_target_id = ? AND at < ?", targetID, t).
+		Order("at DESC").Order("id DESC").Limit(1).Find(&list).Error
+	if err != nil || len(list) == 0 {
+		return ACDWorkStateEvent{}, false, err
+	}
+	return list[0], true, nil
+}
+
+// ListACDWorkStateEvents returns transitions in [from, to) ordered by time.
+// tenantID 0 skips tenant scoping; targetID 0 means all seats.
+func ListACDWorkStateEvents(ctx context.Context, db *gorm.DB, tenantID, targetID uint, from, to time.Time) ([]ACDWorkStateEvent, error) {
+	q := db.WithContext(ctx).Model(&ACDWorkStateEvent{}).Where("at >= ? AND at < ?", from, to)
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	if targetID > 0 {
+		q = q.Where("acd_target_id = ?", targetID)
+	}
+	var out []ACDWorkStateEvent
+	err := q.Order("at ASC").Order("id ASC").Find(&out).Error
+	return out, err
+}
+
+// ACDWorkStatesBefore returns each seat's state at t (its last transition before t).
+func ACDWorkStatesBefore(ctx context.Context, db *gorm.DB, tenantID uint, targetIDs []uint, t time.Time) (map[uint]string, error) {
+	evs, err := ACDWorkStateEventsBefore(ctx, db, tenantID, targetIDs, t)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uint]string, len(evs))
+	for id, ev := range evs {
+		out[id] = ev.WorkState
+	}
+	return out, nil
+}
+
+// ACDWorkStateEventsBefore returns each seat's last transition before t.
+func ACDWorkStateEventsBefore(ctx context.Context, db *gorm.DB, tenantID uint, targetIDs []uint, t time.Time) (map[uint]ACDWorkStateEvent, error) {
+	out := make(map[uint]ACDWorkStateEvent, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return out, nil
+	}
+	sub := db.WithContext(ctx).Model(&ACDWorkStateEvent{}).
+		Select("acd_target_id, MAX(at) AS at").
+		Where("acd_target_id IN ? AND at < ?", targetIDs, t).
+		Group("acd_target_id")
+	if tenantID > 0 {
+		sub = sub.Where("tenant_id = ?", tenantID)
+	}
+	var rows []ACDWorkStateEvent
+	err := db.WithContext(ctx).Model(&ACDWorkStateEvent{}).
+		Joins("JOIN (?) prev_ev ON prev_ev.acd_target_id = "+constants.ACDWorkStateEventTableName+".acd_target_id AND prev_ev.at = "+constants.ACDWorkStateEventTableName+".at", sub).
+		Order("id ASC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.ACDTargetID] = r
+	}
+	return out, nil
+}
+
+// ACDStateDurations folds one seat's transitions into seconds spent per work
+// state within [from, to). initial is the state at from ("" = unknown, not
+// counted until the first event); events must be sorted by At.
+func ACDStateDurations(initial string, events []ACDWorkStateEvent, from, to time.Time) map[string]int64 {
+	out := make(map[string]int64)
+	if !to.After(from) {
+		return out
+	}
+	evs := append([]ACDWorkStateEvent(nil), events...)
+	sort.SliceStable(evs, func(i, j int) bool { return evs[i].At.Before(evs[j].At) })
+	state, since := initial, from
+	add := func(until time.Time) {
+		if state == "" || !until.After(since) {
+			return
+		}
+		out[state] += int64(until.Sub(since) / time.Second)
+	}
+	for _, ev := range evs {
+		at := ev.At
+		if at.Before(from) {
+			state = ev.WorkState
+			continue
+		}
+		if !at.Before(to) {
+			break
+		}
+		add(at)
+		state, since = ev.WorkState, at
+	}
+	add(to)
+	return out
+}
+
+// ACDStateDurationsByDay splits ACDStateDurations into local calendar days
+// (yyyy-MM-dd, in loc) covering [from, to); days after now are cut at now.
+func ACDStateDurationsByDay(initial string, events []ACDWorkStateEvent, from, to, now time.Time, loc *time.Location) map[string]map[string]int64 {
+	if loc == nil {
+		loc = time.Local
+	}
+	if now.Before(to) {
+		to = now
+	}
+	out := make(map[string]map[string]int64)
+	state := initial
+	i := 0
+	for day := from.In(loc); day.Before(to); {
+		y, m, d := day.Date()
+		next := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		end := next
+		if end.After(to) {
+			end = to
+		}
+		j := i
+		for j < len(events) && events[j].At.Before(end) {
+			j++
+		}
+		out[day.Format("2006-01-02")] = ACDStateDurations(state, events[i:j], day, end)
+		if j > i {
+			state = events[j-1].WorkState
+		}
+		i = j
+		day = next
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestACDStateDurations(t *testing.T) {
+	from := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	evs := []ACDWorkStateEvent{
+		{WorkState: "break", At: from.Add(-time.Minute)},
+		{WorkState: "available", At: from.Add(10 * time.Minute)},
+		{WorkState: "busy", At: from.Add(20 * time.Minute)},
+		{WorkState: "acw", At: from.Add(50 * time.Minute)},
+		{WorkState: "available", At: from.Add(55 * time.Minute)},
+		{WorkState: "offline", At: to.Add(time.Minute)},
+	}
+	got := ACDStateDurations("offline", evs, from, to)
+	want := map[string]int64{"break": 600, "available": 600 + 300, "busy": 1800, "acw": 300}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s: got %d want %d (%v)", k, got[k], v, got)
+		}
+	}
+
+	// Unknown initial state is not counted until the first transition.
+	got = ACDStateDurations("", evs[2:3], from, to)
+	if len(got) != 1 || got["busy"] != 2400 {
+		t.Fatalf("unknown initial: %v", got)
+	}
+}
+
+func TestACDStateDurationsByDay(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, loc)
+	evs := []ACDWorkStateEvent{
+		{WorkState: "available", At: from.Add(23 * time.Hour)},
+		{WorkState: "offline", At: from.Add(25 * time.Hour)},
+	}
+	now := from.Add(30 * time.Hour)
+	got := ACDStateDurationsByDay("offline", evs, from, from.AddDate(0, 0, 3), now, loc)
+	if len(got) != 2 {
+		t.Fatalf("days: %v", got)
+	}
+	d1, d2 := got["2026-03-02"], got["2026-03-03"]
+	if d1["offline"] != 23*3600 || d1["available"] != 3600 {
+		t.Fatalf("day 1: %v", d1)
+	}
+	if d2["available"] != 3600 || d2["offline"] != 5*3600 {
+		t.Fatalf("day 2 (cut at now): %v", d2)
+	}
+}
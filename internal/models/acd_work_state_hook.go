@@ -0,0 +1,60 @@
+package models
+
+import (
+	"sync"
+)
+
+// ACD work_state change observers (BLF presence, seat streams). Every
+// path that writes acd_pool_targets.work_state calls
+// NotifyACDWorkStateChanged after a successful update, and appends the
+// transition to acd_work_state_events (AppendACDWorkStateEvent).
+
+var (
+	acdWorkStateObserversMu sync.RWMutex
+	acdWorkStateObservers   []func(targetID uint, workState string)
+)
+
+// OnACDWorkStateChanged registers fn; it runs synchronously on the writer's goroutine and must not block.
+func OnACDWorkStateChanged(fn func(targetID uint, workState string)) {
+	if fn == nil {
+		return
+	}
+	acdWorkStateObserversMu.Lock()
+	acdWorkStateObservers = append(acdWorkStateObservers, fn)
+	acdWorkStateObserversMu.Unlock()
+}
+
+// NotifyACDWorkStateChanged fans one transition out to the observers.
+func NotifyACDWorkStateChanged(targetID uint, workState string) {
+	if targetID == 0 {
+		return
+	}
+	acdWorkStateObserversMu.RLock()
+	list := acdWorkStateObservers
+	acdWorkStateObserversMu.RUnlock()
+	for _, fn := range list {
+		fn(targetID, workState)
+	}
+}
+
+// acdWorkStateHold lets a subsystem pin a target's work_state: while it
+// returns true, UpdateACDPoolTargetWorkState skips the write (after-call
+// work keeps automatic "available" releases from ending ACW early).
+var (
+	acdWorkStateHoldMu sync.RWMutex
+	acdWorkStateHold   func(targetID uint, workState string) bool
+)
+
+// SetACDWorkStateHold installs fn (nil clears it); it runs on the writer's goroutine and must not block.
+func SetACDWorkStateHold(fn func(targetID uint, workState string) bool) {
+	acdWorkStateHoldMu.Lock()
+	acdWorkStateHold = fn
+	acdWorkStateHoldMu.Unlock()
+}
+
+func acdWorkStateHeld(targetID uint, workState string) bool {
+	acdWorkStateHoldMu.RLock()
+	fn := acdWorkStateHold
+	acdWorkStateHoldMu.RUnlock()
+	return fn != nil && fn(targetID, workState)
+}
@@ -118,6 +118,7 @@ var builtinPermissions = []struct {
 	ParentCode string
 }{
 	{constants.PermAPISIPCallsRead, "通话记录查看", constants.PermissionKindAPI, "api.sip"},
+	{constants.PermAPISIPCallsWrite, "通话控制", constants.PermissionKindAPI, "api.sip"},
 	{constants.PermAPISIPACDRead, "ACD 坐席查看", constants.PermissionKindAPI, "api.sip"},
 	{constants.PermAPISIPACDWrite, "ACD 坐席管理", constants.PermissionKindAPI, "api.sip"},
 	{constants.PermAPISIPScriptsRead, "话术模板查看", constants.PermissionKindAPI, "api.sip"},
@@ -0,0 +1,110 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
+	"gorm.io/gorm"
+)
+
+// RecordingDataKey is the wrapped per-object data key of one encrypted recording
+// (see pkg/stores/envelope). Rows are hard-deleted: a soft-deleted wrapped key
+// would survive crypto-shredding.
+type RecordingDataKey struct {
+	BaseModel
+	ObjectKey string `json:"objectKey" gorm:"column:object_key;size:512;uniqueIndex;not null"`
+	TenantID  uint   `json:"tenantId" gorm:"index;not null;default:0"`
+	KeyID     string `json:"keyId" gorm:"column:key_id;size:64;index;not null"`
+	Wrapped   []byte `json:"-" gorm:"column:wrapped_key;not null"`
+}
+
+func (RecordingDataKey) TableName() string {
+	return constants.RecordingDataKeyTableName
+}
+
+// RecordingKeyStore implements envelope.KeyStore on recording_data_keys.
+type RecordingKeyStore struct {
+	db *gorm.DB
+}
+
+var _ envelope.KeyStore = (*RecordingKeyStore)(nil)
+
+// NewRecordingKeyStore returns nil when db is nil (encryption stays off).
+func NewRecordingKeyStore(db *gorm.DB) *RecordingKeyStore {
+	if db == nil {
+		return nil
+	}
+	return &RecordingKeyStore{db: db}
+}
+
+func (s *RecordingKeyStore) PutDataKey(ctx context.Context, k envelope.DataKey) error {
+	row := &RecordingDataKey{
+		ObjectKey: k.ObjectKey,
+		TenantID:  k.TenantID,
+		KeyID:     k.KeyID,
+		Wrapped:   k.Wrapped,
+	}
+	row.CreatedAt = k.CreatedAt
+	// Re-uploading the same key (recorder retries) replaces the envelope.
+	if err := s.db.WithContext(ctx).Unscoped().Where("object_key = ?", k.ObjectKey).Delete(&RecordingDataKey{}).Error; err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Create(row).Error
+}
+
+func (s *RecordingKeyStore) GetDataKey(ctx context.Context, objectKey string) (envelope.DataKey, bool, error) {
+	var row RecordingDataKey
+	err := s.db.WithContext(ctx).Where("object_key = ?", objectKey).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return envelope.DataKey{}, false, nil
+	}
+	if err != nil {
+		return envelope.DataKey{}, false, err
+	}
+	return row.dataKey(), true, nil
+}
+
+func (s *RecordingKeyStore) ListStaleDataKeys(ctx context.Context, tenantID uint, keyID string, limit int) ([]envelope.DataKey, error) {
+	var rows []RecordingDataKey
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND key_id <> ?", tenantID, keyID).
+		Order("id ASC").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]envelope.DataKey, len(rows))
+	for i := range rows {
+		out[i] = rows[i].dataKey()
+	}
+	return out, nil
+}
+
+func (s *RecordingKeyStore) UpdateDataKeyWrap(ctx context.Context, objectKey, keyID string, wrapped []byte) error {
+	return s.db.WithContext(ctx).Model(&RecordingDataKey{}).
+		Where("object_key = ?", objectKey).
+		Updates(map[string]any{"key_id": keyID, "wrapped_key": wrapped}).Error
+}
+
+func (s *RecordingKeyStore) DeleteDataKey(ctx context.Context, objectKey string) error {
+	return s.db.WithContext(ctx).Unscoped().Where("object_key = ?", objectKey).Delete(&RecordingDataKey{}).Error
+}
+
+func (s *RecordingKeyStore) DeleteTenantDataKeys(ctx context.Context, tenantID uint) (int64, error) {
+	res := s.db.WithContext(ctx).Unscoped().Where("tenant_id = ?", tenantID).Delete(&RecordingDataKey{})
+	return res.RowsAffected, res.Error
+}
+
+func (r RecordingDataKey) dataKey() envelope.DataKey {
+	return envelope.DataKey{
+		ObjectKey: r.ObjectKey,
+		TenantID:  r.TenantID,
+		KeyID:     r.KeyID,
+		Wrapped:   r.Wrapped,
+		CreatedAt: r.CreatedAt,
+	}
+}
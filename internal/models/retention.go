@@ -0,0 +1,205 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Retention data kinds (retention_purge_logs.kind).
+const (
+	RetentionKindRecording     = "recording"
+	RetentionKindTranscript    = "transcript"
+	RetentionKindScriptRun     = "script_run"
+	RetentionKindCampaignEvent = "campaign_event"
+	RetentionKindCDRFile       = "cdr_file"
+)
+
+// Env defaults applied when a tenant policy leaves a field unset.
+// 0 / unset = keep forever.
+const (
+	EnvRetentionRecordingDays     = "RETENTION_RECORDING_DAYS"
+	EnvRetentionTranscriptDays    = "RETENTION_TRANSCRIPT_DAYS"
+	EnvRetentionScriptRunDays     = "RETENTION_SCRIPT_RUN_DAYS"
+	EnvRetentionCampaignEventDays = "RETENTION_CAMPAIGN_EVENT_DAYS"
+	// EnvRetentionCDRDays is platform-wide only: CDR JSONL files mix every
+	// tenant's calls, so they cannot follow a per-tenant policy.
+	EnvRetentionCDRDays = "RETENTION_CDR_DAYS"
+)
+
+// RetentionPolicy is the JSON stored in tenants.retention_config. A nil field
+// inherits the RETENTION_*_DAYS env default; 0 keeps that data forever.
+//
+//	{"recordingDays":90,"transcriptDays":180,"scriptRunDays":30,"campaignEventDays":30}
+type RetentionPolicy struct {
+	RecordingDays     *int `json:"recordingDays,omitempty"`
+	TranscriptDays    *int `json:"transcriptDays,omitempty"`
+	ScriptRunDays     *int `json:"scriptRunDays,omitempty"`
+	CampaignEventDays *int `json:"campaignEventDays,omitempty"`
+}
+
+// EffectiveRetention is a fully-resolved policy in days (0 = keep forever).
+type EffectiveRetention struct {
+	RecordingDays     int
+	TranscriptDays    int
+	ScriptRunDays     int
+	CampaignEventDays int
+}
+
+// ParseRetentionPolicy decodes a tenant retention blob; empty input yields an
+// all-inherit policy. Negative durations are rejected.
+func ParseRetentionPolicy(raw []byte) (RetentionPolicy, error) {
+	var p RetentionPolicy
+	s := strings.TrimSpace(string(raw))
+	if s == "" || s == "null" {
+		return p, nil
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return RetentionPolicy{}, err
+	}
+	for _, v := range []*int{p.RecordingDays, p.TranscriptDays, p.ScriptRunDays, p.CampaignEventDays} {
+		if v != nil && *v < 0 {
+			return RetentionPolicy{}, errors.New("retention days must be >= 0")
+		}
+	}
+	return p, nil
+}
+
+// Resolve fills unset fields from the RETENTION_*_DAYS env defaults.
+func (p RetentionPolicy) Resolve() EffectiveRetention {
+	pick := func(v *int, env string) int {
+		if v != nil {
+			return *v
+		}
+		if d := int(utils.GetIntEnv(env)); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return EffectiveRetention{
+		RecordingDays:     pick(p.RecordingDays, EnvRetentionRecordingDays),
+		TranscriptDays:    pick(p.TranscriptDays, EnvRetentionTranscriptDays),
+		ScriptRunDays:     pick(p.ScriptRunDays, EnvRetentionScriptRunDays),
+		CampaignEventDays: pick(p.CampaignEventDays, EnvRetentionCampaignEventDays),
+	}
+}
+
+// RetentionSummary 返回租户管理 API 展示的生效保留天数（租户策略叠加 env 默认值，0 = 永久），
+// 并附上平台级的 CDR 文件保留天数与 cdrScope=platform：CDR 文件不受租户策略约束。
+// 无法解析的策略按全部继承处理。
+func RetentionSummary(raw []byte) map[string]any {
+	p, _ := ParseRetentionPolicy(raw)
+	eff := p.Resolve()
+	return map[string]any{
+		"recordingDays":     eff.RecordingDays,
+		"transcriptDays":    eff.TranscriptDays,
+		"scriptRunDays":     eff.ScriptRunDays,
+		"campaignEventDays": eff.CampaignEventDays,
+		"cdrDays":           int(utils.GetIntEnv(EnvRetentionCDRDays)),
+		"cdrScope":          "platform",
+	}
+}
+
+// PatchTenantRetentionConfigJSON stores the tenant retention policy.
+// Callers validate the blob (ParseRetentionPolicy) beforehand.
+func PatchTenantRetentionConfigJSON(db *gorm.DB, id uint, retention json.RawMessage, updateBy string) error {
+	return db.Model(&Tenant{}).Where("id = ?", id).Updates(map[string]any{
+		"retention_config": datatypes.JSON(utils.CloneRawMessage(retention)),
+		"updated_at":       time.Now(),
+		"update_by":        updateBy,
+	}).Error
+}
+
+// RetentionTenant is one tenant to sweep with its stored policy blob.
+type RetentionTenant struct {
+	ID              uint
+	RetentionConfig datatypes.JSON
+}
+
+// ListRetentionTenants returns every tenant (soft-deleted ones included: their
+// data still ages out) plus the implicit tenant 0 for legacy unscoped rows.
+func ListRetentionTenants(ctx context.Context, db *gorm.DB) ([]RetentionTenant, error) {
+	var rows []RetentionTenant
+	err := db.WithContext(ctx).Unscoped().Model(&Tenant{}).
+		Select("id", "retention_config").Order("id ASC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return append([]RetentionTenant{{ID: 0}}, rows...), nil
+}
+
+// RetentionPurgeLog audits one purge pass over one data kind of one tenant.
+type RetentionPurgeLog struct {
+	BaseModel
+	TenantID   uint      `json:"tenantId" gorm:"index;not null;default:0"`
+	Kind       string    `json:"kind" gorm:"size:32;index;not null"`
+	Cutoff     time.Time `json:"cutoff" gorm:"not null"`
+	Rows       int64     `json:"rows" gorm:"default:0"`    // DB rows deleted / cleared
+	Objects    int       `json:"objects" gorm:"default:0"` // storage objects / files deleted
+	Failures   int       `json:"failures" gorm:"default:0"`
+	Detail     string    `json:"detail,omitempty" gorm:"type:text"`
+	StartedAt  time.Time `json:"startedAt" gorm:"index;not null"`
+	FinishedAt time.Time `json:"finishedAt" gorm:"not null"`
+}
+
+func (RetentionPurgeLog) TableName() string {
+	return constants.RetentionPurgeLogTableName
+}
+
+// InsertRetentionPurgeLog stores one audit row (best-effort).
+func InsertRetentionPurgeLog(ctx context.Context, db *gorm.DB, row *RetentionPurgeLog) error {
+	if db == nil || row == nil {
+		return nil
+	}
+	return db.WithContext(ctx).Create(row).Error
+}
+
+// ListRetentionPurgeLogsDesc returns the newest purge audit rows of a tenant.
+func ListRetentionPurgeLogsDesc(ctx context.Context, db *gorm.DB, tenantID uint, limit int) ([]RetentionPurgeLog, error) {
+	var list []RetentionPurgeLog
+	err := db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// PurgeSIPScriptRunsBefore hard-deletes up to limit script-run rows of tenantID's
+// campaigns created before cutoff, skipping calls on legal hold. Returns the rows
+// deleted; callers loop until it is below limit.
+func PurgeSIPScriptRunsBefore(ctx context.Context, db *gorm.DB, tenantID uint, cutoff time.Time, limit int) (int64, error) {
+	return purgeCampaignScopedBefore(ctx, db, &SIPScriptRun{}, tenantID, cutoff, limit)
+}
+
+// PurgeSIPCampaignEventsBefore is PurgeSIPScriptRunsBefore for sip_campaign_events.
+func PurgeSIPCampaignEventsBefore(ctx context.Context, db *gorm.DB, tenantID uint, cutoff time.Time, limit int) (int64, error) {
+	return purgeCampaignScopedBefore(ctx, db, &SIPCampaignEvent{}, tenantID, cutoff, limit)
+}
+
+func purgeCampaignScopedBefore(ctx context.Context, db *gorm.DB, model interface{ TableName() string }, tenantID uint, cutoff time.Time, limit int) (int64, error) {
+	if db == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	db = db.WithContext(ctx)
+	campaigns := db.Table(constants.SIP_CAMPAIGN_TABLE_NAME).Select("id").Where("tenant_id = ?", tenantID)
+	// NOT EXISTS 而非 NOT IN：被保全的通话里只要有一条 call_id 为 NULL，NOT IN 就恒为 NULL，整批都不会被清理。
+	held := db.Table(constants.SIP_CALL_TABLE_NAME+" AS held").Select("1").
+		Where("held.call_id = "+model.TableName()+".call_id AND held.legal_hold = ?", true)
+	var ids []uint
+	err := db.Unscoped().Model(model).
+		Where("campaign_id IN (?) AND created_at < ?", campaigns, cutoff).
+		Where("call_id = '' OR call_id IS NULL OR NOT EXISTS (?)", held).
+		Order("id ASC").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	res := db.Unscoped().Where("id IN ?", ids).Delete(model)
+	return res.RowsAffected, res.Error
+}
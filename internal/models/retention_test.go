@@ -0,0 +1,46 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package models
+
+import "testing"
+
+func TestParseRetentionPolicy_ResolveInheritsEnv(t *testing.T) {
+	t.Setenv(EnvRetentionRecordingDays, "90")
+	t.Setenv(EnvRetentionTranscriptDays, "30")
+	t.Setenv(EnvRetentionScriptRunDays, "")
+	t.Setenv(EnvRetentionCampaignEventDays, "")
+
+	p, err := ParseRetentionPolicy([]byte(`{"transcriptDays":0,"scriptRunDays":7}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	eff := p.Resolve()
+	want := EffectiveRetention{RecordingDays: 90, TranscriptDays: 0, ScriptRunDays: 7}
+	if eff != want {
+		t.Fatalf("resolve = %+v, want %+v", eff, want)
+	}
+
+	if p, err := ParseRetentionPolicy([]byte(" null ")); err != nil || p.Resolve().RecordingDays != 90 {
+		t.Fatalf("null policy: %+v %v", p, err)
+	}
+	if _, err := ParseRetentionPolicy([]byte(`{"recordingDays":-1}`)); err == nil {
+		t.Fatal("negative days must be rejected")
+	}
+}
+
+func TestRetentionSummary_ReportsPlatformCDR(t *testing.T) {
+	t.Setenv(EnvRetentionRecordingDays, "90")
+	t.Setenv(EnvRetentionTranscriptDays, "")
+	t.Setenv(EnvRetentionScriptRunDays, "")
+	t.Setenv(EnvRetentionCampaignEventDays, "")
+	t.Setenv(EnvRetentionCDRDays, "365")
+
+	got := RetentionSummary([]byte(`{"transcriptDays":30}`))
+	if got["recordingDays"] != 90 || got["transcriptDays"] != 30 || got["scriptRunDays"] != 0 {
+		t.Fatalf("summary = %v", got)
+	}
+	if got["cdrDays"] != 365 || got["cdrScope"] != "platform" {
+		t.Fatalf("cdr = %v / %v", got["cdrDays"], got["cdrScope"])
+	}
+}
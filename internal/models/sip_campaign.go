@@ -34,6 +34,8 @@ type SIPCampaign struct {
 	OutboundPort    int            `json:"outboundPort" gorm:"default:6050"`
 	SignalingAddr   string         `json:"signalingAddr" gorm:"size:128"`
 	RequestURIFmt   string         `json:"requestUriFmt" gorm:"size:256"` // e.g. sip:%s@gw.local:6050
+	// TrunkGroupID 非 0 时按中继组选路（主叫与网关取自组成员，忽略联系人 callerUser）。
+	TrunkGroupID uint `json:"trunkGroupId" gorm:"not null;default:0"`
 
 	TaskConcurrency   int            `json:"taskConcurrency" gorm:"default:5"`
 	GlobalConcurrency int            `json:"globalConcurrency" gorm:"default:20"`
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// SIPClickToCall is one API-originated call (sip_click_to_calls): either the customer
+// alone with an AI / script profile, or an agent leg first and then the customer bridged to it.
+type SIPClickToCall struct {
+	BaseModel
+	TenantID uint `json:"tenantId" gorm:"index;not null;default:0"`
+	// CorrelationID is the integrator-facing id (status polling, events); also the dial correlation of the AI leg.
+	CorrelationID string `json:"correlationId" gorm:"size:64;uniqueIndex;not null"`
+	ExternalID    string `json:"externalId,omitempty" gorm:"size:128;index"` // caller-supplied reference (CRM ticket …)
+
+	Mode             string `json:"mode" gorm:"size:16;index;not null"`               // ai|agent
+	Origin           string `json:"origin" gorm:"size:16;index;not null;default:api"` // api|web_seat
+	MediaProfile     string `json:"mediaProfile,omitempty" gorm:"size:24"`
+	ScriptTemplateID uint   `json:"scriptTemplateId,omitempty" gorm:"not null;default:0"`
+	SystemPrompt     string `json:"systemPrompt,omitempty" gorm:"type:text"`
+
+	Customer       string `json:"customer" gorm:"size:128;index;not null"`
+	CallerUser     string `json:"callerUser,omitempty" gorm:"size:128"`
+	TrunkGroupID   uint   `json:"trunkGroupId,omitempty" gorm:"not null;default:0"`
+	AgentTargetID  uint   `json:"agentTargetId,omitempty" gorm:"index;not null;default:0"` // acd_pool_targets.id
+	AgentUser      string `json:"agentUser,omitempty" gorm:"size:128"`                     // sip_users.username when no ACD row
+	AgentRouteType string `json:"agentRouteType,omitempty" gorm:"size:16"`                 // sip|web
+
+	Status         string     `json:"status" gorm:"size:24;index;not null;default:queued"`
+	AgentCallID    string     `json:"agentCallId,omitempty" gorm:"size:128;index"`
+	CustomerCallID string     `json:"customerCallId,omitempty" gorm:"size:128;index"`
+	SIPStatusCode  int        `json:"sipStatusCode,omitempty" gorm:"column:sip_status_code;not null;default:0"`
+	FailureReason  string     `json:"failureReason,omitempty" gorm:"type:text"`
+	AnsweredAt     *time.Time `json:"answeredAt,omitempty"`
+	EndedAt        *time.Time `json:"endedAt,omitempty" gorm:"index"`
+}
+
+func (SIPClickToCall) TableName() string {
+	return constants.SIPClickToCallTableName
+}
+
+// SIPClickToCallEvent is one lifecycle entry of a click-to-call (leg dial progress, bridge, end).
+type SIPClickToCallEvent struct {
+	BaseModel
+	ClickToCallID uint   `json:"clickToCallId" gorm:"index;not null"`
+	CallID        string `json:"callId,omitempty" gorm:"size:128;index"`
+	Leg           string `json:"leg" gorm:"size:16;not null"`   // agent|customer|api
+	State         string `json:"state" gorm:"size:32;not null"` // dial event state or click-to-call status
+	StatusCode    int    `json:"statusCode,omitempty" gorm:"not null;default:0"`
+	Message       string `json:"message,omitempty" gorm:"type:text"`
+}
+
+func (SIPClickToCallEvent) TableName() string {
+	return constants.SIPClickToCallEventTableName
+}
+
+// SIPClickToCallTerminal reports whether status is final (no further legs or events).
+func SIPClickToCallTerminal(status string) bool {
+	switch strings.TrimSpace(status) {
+	case constants.SIPClickToCallCompleted, constants.SIPClickToCallFailed, constants.SIPClickToCallCanceled:
+		return true
+	}
+	return false
+}
+
+// GetSIPClickToCall loads one row; tenantID 0 skips tenant scoping (platform admin).
+func GetSIPClickToCall(ctx context.Context, db *gorm.DB, tenantID, id uint) (SIPClickToCall, error) {
+	var row SIPClickToCall
+	q := db.WithContext(ctx).Where("id = ?", id)
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	err := q.First(&row).Error
+	return row, err
+}
+
+// FailUnfinishedSIPClickToCalls closes every row not yet in a final status
+// with status failed and reason, and returns their ids.
+func FailUnfinishedSIPClickToCalls(ctx context.Context, db *gorm.DB, reason string, at time.Time) ([]uint, error) {
+	final := []string{constants.SIPClickToCallCompleted, constants.SIPClickToCallFailed, constants.SIPClickToCallCanceled}
+	var ids []uint
+	err := db.WithContext(ctx).Model(&SIPClickToCall{}).Where("status NOT IN ?", final).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Model(&SIPClickToCall{}).Where("id IN ? AND status NOT IN ?", ids, final).Updates(map[string]any{
+		"status": constants.SIPClickToCallFailed, "failure_reason": reason, "ended_at": &at,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetSIPClickToCallByCorrelation loads the row for a correlation id (any tenant).
+func GetSIPClickToCallByCorrelation(ctx context.Context, db *gorm.DB, correlationID string) (SIPClickToCall, error) {
+	var row SIPClickToCall
+	err := db.WithContext(ctx).Where("correlation_id = ?", strings.TrimSpace(correlationID)).First(&row).Error
+	return row, err
+}
+
+// SIPClickToCallListFilter narrows ListSIPClickToCalls; zero values are ignored.
+type SIPClickToCallListFilter struct {
+	TenantID      uint
+	Status        string
+	Mode          string
+	Origin        string
+	CorrelationID string
+	ExternalID    string
+	Customer      string
+}
+
+// ListSIPClickToCalls returns one page, newest first.
+func ListSIPClickToCalls(ctx context.Context, db *gorm.DB, f SIPClickToCallListFilter, page, size int) ([]SIPClickToCall, int64, error) {
+	q := db.WithContext(ctx).Model(&SIPClickToCall{})
+	if f.TenantID > 0 {
+		q = q.Where("tenant_id = ?", f.TenantID)
+	}
+	if v := strings.TrimSpace(f.Status); v != "" {
+		q = q.Where("status = ?", v)
+	}
+	if v := strings.TrimSpace(f.Mode); v != "" {
+		q = q.Where("mode = ?", v)
+	}
+	if v := strings.TrimSpace(f.Origin); v != "" {
+		q = q.Where("origin = ?", v)
+	}
+	if v := strings.TrimSpace(f.CorrelationID); v != "" {
+		q = q.Where("correlation_id = ?", v)
+	}
+	if v := strings.TrimSpace(f.ExternalID); v != "" {
+		q = q.Where("external_id = ?", v)
+	}
+	if v := strings.TrimSpace(f.Customer); v != "" {
+		q = q.Where("customer = ?", v)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []SIPClickToCall
+	err := q.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&list).Error
+	return list, total, err
+}
+
+// ListSIPClickToCallEvents returns the lifecycle of one click-to-call, oldest first.
+func ListSIPClickToCallEvents(ctx context.Context, db *gorm.DB, clickToCallID uint) ([]SIPClickToCallEvent, error) {
+	var list []SIPClickToCallEvent
+	err := db.WithContext(ctx).Where("click_to_call_id = ?", clickToCallID).Order("id ASC").Find(&list).Error
+	return list, err
+}
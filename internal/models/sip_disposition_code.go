@@ -0,0 +1,124 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// SIPDispositionCode is one wrap-up code agents pick during after-call work.
+// When a tenant has at least one enabled code, wrap-up must use one of them.
+type SIPDispositionCode struct {
+	BaseModel
+
+	TenantID uint `json:"tenantId,string" gorm:"index;not null;default:0"`
+
+	Code      string `json:"code" gorm:"size:64;not null;index"`
+	Label     string `json:"label" gorm:"size:128;not null"`
+	SortOrder int    `json:"sortOrder" gorm:"column:sort_order;default:0"`
+	Enabled   bool   `json:"enabled" gorm:"default:true;index"`
+}
+
+func (SIPDispositionCode) TableName() string {
+	return constants.SIPDispositionCodeTableName
+}
+
+// maxDispositionCodeLen matches the sip_calls.disposition_code column.
+const maxDispositionCodeLen = 64
+
+// ErrDispositionCodeExists is returned when a tenant already has an active row with the same code.
+var ErrDispositionCodeExists = errors.New("disposition code already exists")
+
+// NormalizeSIPDispositionCode trims code and checks it is 1–64 chars of [A-Za-z0-9_.-].
+func NormalizeSIPDispositionCode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("code required")
+	}
+	if len(code) > maxDispositionCodeLen {
+		return "", fmt.Errorf("code longer than %d characters", maxDispositionCodeLen)
+	}
+	for _, r := range code {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+		default:
+			return "", fmt.Errorf("code may only contain letters, digits, '_', '-' and '.'")
+		}
+	}
+	return code, nil
+}
+
+// ActiveSIPDispositionCodes limits to non–soft-deleted rows.
+func ActiveSIPDispositionCodes(db *gorm.DB) *gorm.DB {
+	return db.Model(&SIPDispositionCode{})
+}
+
+// ListSIPDispositionCodes returns a tenant's codes in display order; enabledOnly drops disabled rows.
+func ListSIPDispositionCodes(ctx context.Context, db *gorm.DB, tenantID uint, enabledOnly bool) ([]SIPDispositionCode, error) {
+	q := ActiveSIPDispositionCodes(db.WithContext(ctx)).Where("tenant_id = ?", tenantID)
+	if enabledOnly {
+		q = q.Where("enabled = ?", true)
+	}
+	var list []SIPDispositionCode
+	err := q.Order("sort_order ASC").Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// GetSIPDispositionCodeForTenant returns one code row for tenant scope.
+func GetSIPDispositionCodeForTenant(db *gorm.DB, id uint, tenantID uint) (SIPDispositionCode, error) {
+	var row SIPDispositionCode
+	err := ActiveSIPDispositionCodes(db).Where("id = ? AND tenant_id = ?", id, tenantID).First(&row).Error
+	return row, err
+}
+
+// SIPDispositionCodeTaken reports whether tenantID already uses code on another active row.
+func SIPDispositionCodeTaken(db *gorm.DB, tenantID uint, code string, exceptID uint) (bool, error) {
+	var n int64
+	q := ActiveSIPDispositionCodes(db).Where("tenant_id = ? AND code = ?", tenantID, code)
+	if exceptID > 0 {
+		q = q.Where("id <> ?", exceptID)
+	}
+	err := q.Count(&n).Error
+	return n > 0, err
+}
+
+// ResolveWrapUpDisposition validates an agent's selection against the tenant's
+// enabled codes. With no codes configured any (normalized) code is accepted,
+// including none; otherwise a listed code is required.
+func ResolveWrapUpDisposition(codes []SIPDispositionCode, code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(codes) == 0 {
+		if code == "" {
+			return "", nil
+		}
+		return NormalizeSIPDispositionCode(code)
+	}
+	if code == "" {
+		return "", fmt.Errorf("disposition code required")
+	}
+	for _, c := range codes {
+		if c.Enabled && c.Code == code {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("unknown disposition code %q", code)
+}
+
+// SoftDeleteSIPDispositionCodeForTenant soft-deletes for tenant scope.
+func SoftDeleteSIPDispositionCodeForTenant(db *gorm.DB, id uint, tenantID uint, updateBy string) (int64, error) {
+	meta := BaseModel{}
+	meta.SoftDelete(updateBy)
+	updates := map[string]interface{}{
+		"updated_at": meta.UpdatedAt,
+		"deleted_at": meta.DeletedAt,
+	}
+	if meta.UpdateBy != "" {
+		updates["update_by"] = meta.UpdateBy
+	}
+	res := db.Model(&SIPDispositionCode{}).Where("id = ? AND tenant_id = ?", id, tenantID).Updates(updates)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestNormalizeSIPDispositionCode(t *testing.T) {
+	if got, err := NormalizeSIPDispositionCode("  sale.closed-2_x "); err != nil || got != "sale.closed-2_x" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	for _, bad := range []string{"", "   ", "has space", "中文", string(make([]byte, 65))} {
+		if _, err := NormalizeSIPDispositionCode(bad); err == nil {
+			t.Fatalf("%q accepted", bad)
+		}
+	}
+}
+
+func TestResolveWrapUpDisposition(t *testing.T) {
+	if got, err := ResolveWrapUpDisposition(nil, ""); err != nil || got != "" {
+		t.Fatalf("no list, empty: %q, %v", got, err)
+	}
+	if got, err := ResolveWrapUpDisposition(nil, " callback "); err != nil || got != "callback" {
+		t.Fatalf("no list, free code: %q, %v", got, err)
+	}
+	codes := []SIPDispositionCode{
+		{Code: "sale", Enabled: true},
+		{Code: "old", Enabled: false},
+	}
+	if _, err := ResolveWrapUpDisposition(codes, ""); err == nil {
+		t.Fatal("empty code accepted with list configured")
+	}
+	if _, err := ResolveWrapUpDisposition(codes, "old"); err == nil {
+		t.Fatal("disabled code accepted")
+	}
+	if _, err := ResolveWrapUpDisposition(codes, "other"); err == nil {
+		t.Fatal("unknown code accepted")
+	}
+	if got, err := ResolveWrapUpDisposition(codes, "sale"); err != nil || got != "sale" {
+		t.Fatalf("listed code: %q, %v", got, err)
+	}
+}
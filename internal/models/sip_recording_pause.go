@@ -0,0 +1,54 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// SIPRecordingPause is the audit row for one recording pause (PCI segment) on a call.
+type SIPRecordingPause struct {
+	BaseModel
+	TenantID   uint      `json:"tenantId" gorm:"index;not null;default:0"`
+	CallID     string    `json:"callId" gorm:"column:call_id;size:128;index;not null"`
+	Source     string    `json:"source" gorm:"size:16"` // api|script
+	Reason     string    `json:"reason,omitempty" gorm:"size:255"`
+	PausedBy   string    `json:"pausedBy,omitempty" gorm:"column:paused_by;size:128"`
+	ResumedBy  string    `json:"resumedBy,omitempty" gorm:"column:resumed_by;size:128"` // "hangup" when closed at teardown
+	StartedAt  time.Time `json:"startedAt" gorm:"column:started_at;index;not null"`
+	EndedAt    time.Time `json:"endedAt" gorm:"column:ended_at;not null"`
+	DurationMs int64     `json:"durationMs" gorm:"column:duration_ms;default:0"`
+}
+
+func (SIPRecordingPause) TableName() string {
+	return constants.SIPRecordingPauseTableName
+}
+
+// InsertSIPRecordingPause stores one closed pause interval (best-effort audit).
+func InsertSIPRecordingPause(ctx context.Context, db *gorm.DB, row *SIPRecordingPause) error {
+	if db == nil || row == nil || strings.TrimSpace(row.CallID) == "" {
+		return nil
+	}
+	if row.DurationMs == 0 && !row.EndedAt.IsZero() {
+		row.DurationMs = row.EndedAt.Sub(row.StartedAt).Milliseconds()
+	}
+	return db.WithContext(ctx).Create(row).Error
+}
+
+// ListSIPRecordingPauses returns the pause audit rows of one call, oldest first.
+// tenantID 0 skips tenant scoping (platform admin).
+func ListSIPRecordingPauses(ctx context.Context, db *gorm.DB, tenantID uint, callID string) ([]SIPRecordingPause, error) {
+	var list []SIPRecordingPause
+	q := db.WithContext(ctx).Where("call_id = ?", strings.TrimSpace(callID))
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	err := q.Order("started_at ASC").Order("id ASC").Find(&list).Error
+	return list, err
+}
@@ -41,6 +41,15 @@ type Tenant struct {
 	// `{provider, ...vendorFields}` convention as AsrConfig/TtsConfig/
 	// LlmConfig. Only consulted when VoiceMode == "realtime".
 	RealtimeConfig datatypes.JSON `json:"realtimeConfig,omitempty" gorm:"column:realtime_config;comment:实时多模态配置JSON"`
+	// PrivacyConfig is the PII redaction policy (pkg/redact.Policy JSON).
+	// Empty = process default from PII_REDACT_* env.
+	PrivacyConfig datatypes.JSON `json:"privacyConfig,omitempty" gorm:"column:privacy_config;comment:PII脱敏策略JSON"`
+	// RetentionConfig is the data retention policy (RetentionPolicy JSON).
+	// Empty = RETENTION_*_DAYS env defaults.
+	RetentionConfig datatypes.JSON `json:"retentionConfig,omitempty" gorm:"column:retention_config;comment:数据保留策略JSON"`
+	// RecordingFormat is the call recording container: wav / ogg (Opus) /
+	// mp3. Empty = RECORDING_FORMAT env default.
+	RecordingFormat string `json:"recordingFormat,omitempty" gorm:"column:recording_format;size:16;comment:录音格式 wav|ogg|mp3"`
 }
 
 func (Tenant) TableName() string {
@@ -78,6 +87,10 @@ func TenantPlatformDetail(t Tenant) map[string]any {
 	h["llmConfig"] = utils.JSONValueFromBytes(t.LlmConfig)
 	h["voiceMode"] = strings.TrimSpace(t.VoiceMode)
 	h["realtimeConfig"] = utils.JSONValueFromBytes(t.RealtimeConfig)
+	h["privacyConfig"] = utils.JSONValueFromBytes(t.PrivacyConfig)
+	h["retentionConfig"] = utils.JSONValueFromBytes(t.RetentionConfig)
+	h["retentionEffective"] = RetentionSummary(t.RetentionConfig)
+	h["recordingFormat"] = t.RecordingFormat
 	return h
 }
 
@@ -344,3 +357,23 @@ func PatchTenantAIConfigJSON(db *gorm.DB, id uint, asr, tts, llm, realtime *json
 	}
 	return db.Model(&Tenant{}).Where("id = ?", id).Updates(patch).Error
 }
+
+// PatchTenantRecordingFormat stores the tenant recording format ("" = inherit).
+// Callers normalise it with recorder.ParseFormat beforehand.
+func PatchTenantRecordingFormat(db *gorm.DB, id uint, format, updateBy string) error {
+	return db.Model(&Tenant{}).Where("id = ?", id).Updates(map[string]any{
+		"recording_format": format,
+		"updated_at":       time.Now(),
+		"update_by":        updateBy,
+	}).Error
+}
+
+// PatchTenantPrivacyConfigJSON stores the tenant PII redaction policy.
+// Callers validate the blob (redact.ParsePolicy + Compile) beforehand.
+func PatchTenantPrivacyConfigJSON(db *gorm.DB, id uint, privacy json.RawMessage, updateBy string) error {
+	return db.Model(&Tenant{}).Where("id = ?", id).Updates(map[string]any{
+		"privacy_config": datatypes.JSON(utils.CloneRawMessage(privacy)),
+		"updated_at":     time.Now(),
+		"update_by":      updateBy,
+	}).Error
+}
@@ -28,6 +28,21 @@ type Trunk struct {
 	NumberNames  []string       `json:"numberNames" gorm:"-" label:"号码名称"`
 	ProviderCode string         `json:"providerCode" gorm:"column:provider_code;size:64;uniqueIndex:idx_trunk_provider_code" label:"供应商编码"`
 	Provider     string         `json:"-" label:"供应商"`
+	// 出局鉴权：运营商对 INVITE / BYE / UPDATE / REFER 回 401/407 时使用的摘要账号。
+	// AuthUsername 为空表示不鉴权；密码只写不读（接口不回显）。
+	AuthUsername string `json:"authUsername,omitempty" gorm:"column:auth_username;size:128" label:"鉴权用户名"`
+	AuthPassword string `json:"-" gorm:"column:auth_password;size:256" label:"鉴权密码"`
+	AuthRealm    string `json:"authRealm,omitempty" gorm:"column:auth_realm;size:128" label:"鉴权域"`
+	// 向运营商注册：开启后按 RegistrarAddrs（逗号分隔，按顺序故障切换；空则用 LocalAddr）
+	// 以 AuthUsername@RegisterDomain 维持注册，半程续约。RegisterDomain 空则取注册服务器主机。
+	RegisterEnabled bool   `json:"registerEnabled" gorm:"column:register_enabled;default:false" label:"向运营商注册"`
+	RegistrarAddrs  string `json:"registrarAddrs,omitempty" gorm:"column:registrar_addrs;size:512" label:"注册服务器"`
+	RegisterExpires int    `json:"registerExpires,omitempty" gorm:"column:register_expires;default:0" label:"注册有效期(秒)"`
+	RegisterDomain  string `json:"registerDomain,omitempty" gorm:"column:register_domain;size:200" label:"注册域"`
+	// 带内 DTMF（音频里的双音多频）检测：auto 仅在未协商 telephone-event 时开启，on / off 强制。
+	InbandDTMF string `json:"inbandDtmf,omitempty" gorm:"column:inband_dtmf;size:16" label:"带内DTMF检测"`
+	// 传真：检测到 CNG/CED 后 auto 先尝试 T.38，被拒则 G.711 透传；t38 仅 T.38；passthrough 仅透传；off 不处理。
+	FaxMode string `json:"faxMode,omitempty" gorm:"column:fax_mode;size:16" label:"传真模式"`
 }
 
 type TrunkNumber struct {
@@ -62,6 +77,12 @@ type TrunkNumber struct {
 	TransferCallerBriefText string `json:"transferCallerBriefText,omitempty" gorm:"column:transfer_caller_brief_text;size:256" label:"主叫桥接前播报"`
 	ACDDispatchMode       string         `json:"acdDispatchMode,omitempty" gorm:"column:acd_dispatch_mode;size:24;index;default:weight" label:"ACD 分配模式"`
 	OutboundTrunkNumberID uint           `json:"outboundTrunkNumberId" gorm:"column:outbound_trunk_number_id;not null;default:0;index" label:"外呼号码"`
+	// ACWSeconds 坐席挂机后的话后处理（ACW）时长，到期自动恢复空闲；0 使用 SIP_ACW_DEFAULT_SECONDS。
+	ACWSeconds int `json:"acwSeconds" gorm:"column:acw_seconds;not null;default:0" label:"话后处理时长(秒)"`
+	// LanguagesJSON 号码支持的对话语种（可选），首项为默认语种，例如
+	// [{"lang":"zh-CN"},{"lang":"yue-HK","tts":{"voiceType":101019}},{"lang":"en-US","prompt":"Reply in English."}]。
+	// 两项及以上时按主叫首句识别语种，并切换 ASR 模型、TTS 音色与 LLM 系统提示词；空则使用租户默认配置。
+	LanguagesJSON string `json:"languages,omitempty" gorm:"column:languages_json;type:text" label:"对话语种"`
 }
 
 // BeforeCreate 后端自动分配供应商编码，前端无法覆盖（即便传入也会被丢弃）。
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// Trunk group strategies.
+const (
+	// TrunkGroupOrdered 按成员 priority 升序逐个尝试（主备）。
+	TrunkGroupOrdered = "ordered"
+	// TrunkGroupWeighted 按成员 weight 加权随机排出首选，其余成员依次作为备选。
+	TrunkGroupWeighted = "weighted"
+)
+
+// TrunkGroup 是租户的一组外呼号码（每个成员即一条 TrunkNumber：主叫 + 网关 + 并发上限）。
+// 外呼按 Strategy 排出尝试顺序，遇 408 / 5xx / 超时自动切到下一个成员。
+type TrunkGroup struct {
+	ID          uint               `json:"id" gorm:"primarykey"`
+	TenantID    uint               `json:"tenantId,string" gorm:"index;not null;default:0"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt     `json:"deletedAt" gorm:"index"`
+	Name        string             `json:"name" gorm:"size:128" label:"中继组名称"`
+	Strategy    string             `json:"strategy" gorm:"size:16;not null;default:ordered" label:"选路策略"`
+	Description string             `json:"description,omitempty" gorm:"size:512" label:"备注"`
+	Members     []TrunkGroupMember `json:"members" gorm:"foreignKey:GroupID"`
+}
+
+// TrunkGroupMember 是中继组里的一条外呼号码。
+type TrunkGroupMember struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	GroupID       uint      `json:"groupId" gorm:"index;not null"`
+	TrunkNumberID uint      `json:"trunkNumberId" gorm:"index;not null" label:"外呼号码"`
+	// Priority 越小越优先（ordered 策略）；weighted 策略下作为同权重时的次序。
+	Priority int `json:"priority" gorm:"not null;default:0" label:"优先级"`
+	// Weight 仅 weighted 策略使用；<= 0 的成员只作备选。
+	Weight int `json:"weight" gorm:"not null;default:1" label:"权重"`
+}
+
+// TrunkRoute 是租户的最低成本选路表项：被叫号码（仅数字）以 Prefix 开头时走 GroupID。
+// 多条命中时取最长前缀；同一前缀按 Cost 升序，各组成员依次拼成尝试顺序。
+// Prefix 为空表示默认路由。
+type TrunkRoute struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	TenantID    uint           `json:"tenantId,string" gorm:"index;not null;default:0"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"deletedAt" gorm:"index"`
+	Prefix      string         `json:"prefix" gorm:"size:32;index" label:"号码前缀"`
+	GroupID     uint           `json:"groupId" gorm:"index;not null" label:"中继组"`
+	Cost        int            `json:"cost" gorm:"not null;default:0" label:"成本"`
+	Description string         `json:"description,omitempty" gorm:"size:512" label:"备注"`
+}
+
+func (TrunkGroup) TableName() string {
+	return constants.SIPTrunkGroupTableName
+}
+
+func (TrunkGroupMember) TableName() string {
+	return constants.SIPTrunkGroupMemberTableName
+}
+
+func (TrunkRoute) TableName() string {
+	return constants.SIPTrunkRouteTableName
+}
+
+// NormalizeTrunkGroupStrategy 返回合法策略；空串视为 ordered。
+func NormalizeTrunkGroupStrategy(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", TrunkGroupOrdered:
+		return TrunkGroupOrdered, nil
+	case TrunkGroupWeighted:
+		return TrunkGroupWeighted, nil
+	}
+	return "", errors.New("strategy must be ordered or weighted")
+}
+
+// ListTrunkGroups 返回租户的中继组（含成员）；tenantID 为 0 时返回全部。
+func ListTrunkGroups(db *gorm.DB, tenantID uint) ([]TrunkGroup, error) {
+	var list []TrunkGroup
+	q := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("priority ASC, id ASC")
+	})
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	err := q.Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// GetTrunkGroupForTenant 按 ID 取中继组（含成员）；tenantID 为 0 时不校验租户。
+func GetTrunkGroupForTenant(db *gorm.DB, id, tenantID uint) (TrunkGroup, error) {
+	var g TrunkGroup
+	q := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("priority ASC, id ASC")
+	}).Where("id = ?", id)
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	err := q.First(&g).Error
+	return g, err
+}
+
+// ValidateTrunkGroupMembers 校验成员号码均属于租户、可外呼且不重复。
+func ValidateTrunkGroupMembers(db *gorm.DB, tenantID uint, members []TrunkGroupMember) error {
+	seen := make(map[uint]struct{}, len(members))
+	for _, m := range members {
+		if m.TrunkNumberID == 0 {
+			return errors.New("trunkNumberId required")
+		}
+		if _, dup := seen[m.TrunkNumberID]; dup {
+			return errors.New("duplicate trunkNumberId " + strconv.FormatUint(uint64(m.TrunkNumberID), 10))
+		}
+		seen[m.TrunkNumberID] = struct{}{}
+		if _, ok := ResolveACDOutboundFromTrunkNumber(db, tenantID, m.TrunkNumberID); !ok {
+			return errors.New("trunk number " + strconv.FormatUint(uint64(m.TrunkNumberID), 10) +
+				" must belong to the tenant, allow outbound and have a trunk gateway")
+		}
+	}
+	return nil
+}
+
+// ReplaceTrunkGroupMembers 用 members 整体替换组成员。
+func ReplaceTrunkGroupMembers(db *gorm.DB, groupID uint, members []TrunkGroupMember) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", groupID).Delete(&TrunkGroupMember{}).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		rows := make([]TrunkGroupMember, len(members))
+		for i, m := range members {
+			rows[i] = TrunkGroupMember{GroupID: groupID, TrunkNumberID: m.TrunkNumberID, Priority: m.Priority, Weight: m.Weight}
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// DeleteTrunkGroupCascade 软删除中继组，并删除其成员与引用它的路由。
+func DeleteTrunkGroupCascade(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&TrunkGroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&TrunkRoute{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&TrunkGroup{}, id).Error
+	})
+}
+
+// TrunkGroupCandidate 是一个可用于外呼的组成员：出局配置 + 选路参数。
+type TrunkGroupCandidate struct {
+	TrunkTransferConfig
+	Concurrent uint
+	Priority   int
+	Weight     int
+}
+
+// ResolveTrunkGroupCandidates 把组成员展开成出局配置，按 priority 排序；
+// 不属于租户、不可外呼或网关无效的成员被跳过。
+func ResolveTrunkGroupCandidates(db *gorm.DB, tenantID, groupID uint) (TrunkGroup, []TrunkGroupCandidate, error) {
+	g, err := GetTrunkGroupForTenant(db, groupID, tenantID)
+	if err != nil {
+		return g, nil, err
+	}
+	var out []TrunkGroupCandidate
+	for _, m := range g.Members {
+		var n TrunkNumber
+		if err := db.Where("id = ? AND tenant_id = ?", m.TrunkNumberID, tenantID).First(&n).Error; err != nil {
+			continue
+		}
+		cfg, ok := outboundConfigForNumber(db, n)
+		if !ok {
+			continue
+		}
+		out = append(out, TrunkGroupCandidate{
+			TrunkTransferConfig: cfg,
+			Concurrent:          n.Concurrent,
+			Priority:            m.Priority,
+			Weight:              m.Weight,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Priority < out[j].Priority })
+	return g, out, nil
+}
+
+// ListTrunkRoutes 返回租户的选路表，按前缀、成本排序；tenantID 为 0 时返回全部。
+func ListTrunkRoutes(db *gorm.DB, tenantID uint) ([]TrunkRoute, error) {
+	var list []TrunkRoute
+	q := db.Model(&TrunkRoute{})
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	err := q.Order("prefix ASC, cost ASC, id ASC").Find(&list).Error
+	return list, err
+}
+
+// MatchTrunkRoutes 返回被叫 phone 命中的路由：最长前缀优先，同前缀按 cost 升序。
+func MatchTrunkRoutes(db *gorm.DB, tenantID uint, phone string) ([]TrunkRoute, error) {
+	if db == nil || tenantID == 0 {
+		return nil, nil
+	}
+	routes, err := ListTrunkRoutes(db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return matchTrunkRoutes(routes, phone), nil
+}
+
+// NormalizeTrunkRoutePrefix 只保留数字：路由前缀按被叫原始数字匹配（不剥离国家码）。
+func NormalizeTrunkRoutePrefix(s string) string {
+	return dialDigitsOnly(s)
+}
+
+func matchTrunkRoutes(routes []TrunkRoute, phone string) []TrunkRoute {
+	digits := dialDigitsOnly(phone)
+	best := -1
+	var out []TrunkRoute
+	for _, r := range routes {
+		p := dialDigitsOnly(r.Prefix)
+		if !strings.HasPrefix(digits, p) || len(p) < best {
+			continue
+		}
+		if len(p) > best {
+			best = len(p)
+			out = out[:0]
+		}
+		out = append(out, r)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost < out[j].Cost
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// ListGroupedTrunkGateways 返回被任一中继组成员引用的 Trunk 网关（trunkID → host:port），
+// 供 OPTIONS 心跳探测。
+func ListGroupedTrunkGateways(db *gorm.DB) (map[uint]string, error) {
+	if db == nil {
+		return nil, nil
+	}
+	live := db.Model(&TrunkGroup{}).Select("id")
+	members := db.Model(&TrunkGroupMember{}).Select("trunk_number_id").Where("group_id IN (?)", live)
+	numbers := db.Model(&TrunkNumber{}).Select("trunk_id").Where("id IN (?)", members)
+	var trunks []Trunk
+	if err := db.Where("id IN (?)", numbers).Find(&trunks).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]string, len(trunks))
+	for _, t := range trunks {
+		if host, port, ok := parseTrunkLocalAddr(t.LocalAddr); ok {
+			out[t.ID] = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+	}
+	return out, nil
+}
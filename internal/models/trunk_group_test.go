@@ -0,0 +1,54 @@
+package models
+
+import "testing"
+
+func TestMatchTrunkRoutes_LongestPrefixThenCost(t *testing.T) {
+	routes := []TrunkRoute{
+		{ID: 1, Prefix: "", GroupID: 10, Cost: 0},
+		{ID: 2, Prefix: "86", GroupID: 20, Cost: 5},
+		{ID: 3, Prefix: "+86 138", GroupID: 30, Cost: 9},
+		{ID: 4, Prefix: "86138", GroupID: 40, Cost: 1},
+		{ID: 5, Prefix: "1", GroupID: 50, Cost: 0},
+	}
+	ids := func(rs []TrunkRoute) []uint {
+		var out []uint
+		for _, r := range rs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	cases := []struct {
+		phone string
+		want  []uint
+	}{
+		{"+86 138-0013-8000", []uint{4, 3}},
+		{"8601012345678", []uint{2}},
+		{"4420", []uint{1}},
+		{"15551234", []uint{5}},
+	}
+	for _, tc := range cases {
+		got := ids(matchTrunkRoutes(routes, tc.phone))
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %v want %v", tc.phone, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%s: got %v want %v", tc.phone, got, tc.want)
+			}
+		}
+	}
+	if got := matchTrunkRoutes(routes[1:], "4420"); len(got) != 0 {
+		t.Fatalf("no default route: got %v", ids(got))
+	}
+}
+
+func TestNormalizeTrunkGroupStrategy(t *testing.T) {
+	for in, want := range map[string]string{"": TrunkGroupOrdered, " Weighted ": TrunkGroupWeighted, "ordered": TrunkGroupOrdered} {
+		if got, err := NormalizeTrunkGroupStrategy(in); err != nil || got != want {
+			t.Errorf("%q → %q, %v", in, got, err)
+		}
+	}
+	if _, err := NormalizeTrunkGroupStrategy("random"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
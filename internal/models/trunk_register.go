@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// RegistrarAddrList 返回向运营商注册时依次尝试的 host:port 列表：
+// RegistrarAddrs 逗号分隔（按顺序故障切换），为空时退回 LocalAddr。端口缺省规则同 LocalAddr。
+func (t Trunk) RegistrarAddrList() []string {
+	raw := strings.TrimSpace(t.RegistrarAddrs)
+	if raw == "" {
+		raw = t.LocalAddr
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' || r == ' ' }) {
+		host, port, ok := parseTrunkLocalAddr(part)
+		if !ok || host == "" {
+			continue
+		}
+		addr := net.JoinHostPort(host, strconv.Itoa(port))
+		if !seen[addr] {
+			seen[addr] = true
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// ListRegisteringTrunks 返回开启了向运营商注册且配置了鉴权用户名的中继（未软删）。
+func ListRegisteringTrunks(db *gorm.DB) ([]Trunk, error) {
+	if db == nil {
+		return nil, nil
+	}
+	var list []Trunk
+	err := db.Where("register_enabled = ? AND auth_username <> ''", true).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// AcceptsSource 判断 ip 是否为本中继的信令来源：LocalAddr 与 RegistrarAddrs 中任一
+// 主机（IP 直接比较，域名按 DNS 解析）。用于只接受运营商侧发来的请求（如号码上的 MESSAGE）。
+func (t Trunk) AcceptsSource(ctx context.Context, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	raw := strings.TrimSpace(t.LocalAddr) + "," + strings.TrimSpace(t.RegistrarAddrs)
+	for _, part := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' || r == ' ' }) {
+		host, _, ok := parseTrunkLocalAddr(part)
+		if !ok || host == "" {
+			continue
+		}
+		if hip := net.ParseIP(host); hip != nil {
+			if hip.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if a.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TrunkNumberAcceptsSource 判断 ip 是否为号码 trunkNumberID 所属中继的信令来源；
+// 号码或中继不存在（含已软删）时返回 false。
+func TrunkNumberAcceptsSource(ctx context.Context, db *gorm.DB, trunkNumberID uint, ip net.IP) bool {
+	if db == nil || trunkNumberID == 0 || ip == nil {
+		return false
+	}
+	var num TrunkNumber
+	if err := db.WithContext(ctx).Select("id", "trunk_id").First(&num, trunkNumberID).Error; err != nil || num.TrunkID == 0 {
+		return false
+	}
+	var trunk Trunk
+	if err := db.WithContext(ctx).First(&trunk, num.TrunkID).Error; err != nil {
+		return false
+	}
+	return trunk.AcceptsSource(ctx, ip)
+}
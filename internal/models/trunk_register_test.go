@@ -0,0 +1,42 @@
+package models
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestTrunkRegistrarAddrList(t *testing.T) {
+	tr := Trunk{LocalAddr: "sip:10.0.0.1:5060"}
+	if got := tr.RegistrarAddrList(); !reflect.DeepEqual(got, []string{"10.0.0.1:5060"}) {
+		t.Fatalf("fallback to LocalAddr: %v", got)
+	}
+	tr.RegistrarAddrs = " reg1.carrier.cn:5070, sip:10.0.0.2:5060;transport=udp ,reg1.carrier.cn:5070"
+	want := []string{"reg1.carrier.cn:5070", "10.0.0.2:5060"}
+	if got := tr.RegistrarAddrList(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("registrars = %v, want %v", got, want)
+	}
+}
+
+func TestTrunkAcceptsSource(t *testing.T) {
+	ctx := context.Background()
+	tr := Trunk{LocalAddr: "sip:10.0.0.1:5060", RegistrarAddrs: "10.0.0.2:5070, sip:[2001:db8::5]:5060;transport=udp"}
+	for ip, want := range map[string]bool{
+		"10.0.0.1":    true,
+		"10.0.0.2":    true,
+		"2001:db8::5": true,
+		"10.0.0.3":    false,
+		"192.0.2.9":   false,
+	} {
+		if got := tr.AcceptsSource(ctx, net.ParseIP(ip)); got != want {
+			t.Errorf("AcceptsSource(%s) = %v, want %v", ip, got, want)
+		}
+	}
+	if (Trunk{}).AcceptsSource(ctx, net.ParseIP("10.0.0.1")) {
+		t.Fatal("trunk without addresses must reject every source")
+	}
+	if tr.AcceptsSource(ctx, nil) {
+		t.Fatal("nil source accepted")
+	}
+}
@@ -18,6 +18,9 @@ type TrunkTransferConfig struct {
 	CallerDisplay string // 主叫显示名，从 TrunkNumber.CallerDisplayName 取
 	TrunkID       uint
 	TrunkNumberID uint
+	AuthUsername  string // 出局摘要鉴权账号，从 Trunk.AuthUsername 取；空表示不鉴权
+	AuthPassword  string
+	AuthRealm     string
 }
 
 // SignalingAddr 返回 host:port，便于直接喂给 outbound.DialTarget.SignalingAddr。
@@ -88,6 +91,9 @@ func PickTrunkConfig(db *gorm.DB, tenantID uint, role TrunkPickRole) (TrunkTrans
 			CallerDisplay: strings.TrimSpace(number.CallerDisplayName),
 			TrunkID:       trunk.ID,
 			TrunkNumberID: number.ID,
+			AuthUsername:  strings.TrimSpace(trunk.AuthUsername),
+			AuthPassword:  trunk.AuthPassword,
+			AuthRealm:     strings.TrimSpace(trunk.AuthRealm),
 		}, true
 	}
 	return TrunkTransferConfig{}, false
@@ -115,6 +121,11 @@ func ResolveACDOutboundFromTrunkNumber(db *gorm.DB, tenantID, trunkNumberID uint
 	if err := db.Where("id = ? AND tenant_id = ?", trunkNumberID, tenantID).First(&n).Error; err != nil {
 		return TrunkTransferConfig{}, false
 	}
+	return outboundConfigForNumber(db, n)
+}
+
+// outboundConfigForNumber 把可外呼号码 n 展开成出局配置（网关取其 Trunk.LocalAddr）。
+func outboundConfigForNumber(db *gorm.DB, n TrunkNumber) (TrunkTransferConfig, bool) {
 	dir := strings.ToLower(strings.TrimSpace(n.Direction))
 	if dir != "outbound" && dir != "both" && dir != "all" {
 		return TrunkTransferConfig{}, false
@@ -134,6 +145,9 @@ func ResolveACDOutboundFromTrunkNumber(db *gorm.DB, tenantID, trunkNumberID uint
 		CallerDisplay: strings.TrimSpace(n.CallerDisplayName),
 		TrunkID:       trunk.ID,
 		TrunkNumberID: n.ID,
+		AuthUsername:  strings.TrimSpace(trunk.AuthUsername),
+		AuthPassword:  trunk.AuthPassword,
+		AuthRealm:     strings.TrimSpace(trunk.AuthRealm),
 	}, true
 }
 
@@ -166,9 +180,41 @@ func PickTrunkOutboundConfigByCaller(db *gorm.DB, tenantID uint, callerRaw strin
 		CallerDisplay: strings.TrimSpace(row.CallerDisplayName),
 		TrunkID:       trunk.ID,
 		TrunkNumberID: row.ID,
+		AuthUsername:  strings.TrimSpace(trunk.AuthUsername),
+		AuthPassword:  trunk.AuthPassword,
+		AuthRealm:     strings.TrimSpace(trunk.AuthRealm),
 	}, true
 }
 
+// FindTrunkAuthBySignalingAddr 按网关地址（host:port）反查配置了出局鉴权的 Trunk。
+// 用于 ACD 行手填网关、SIP REGISTER 等没有经过 Pick* 选号的外呼：只要下一跳
+// 与某条中继的 LocalAddr 一致，就用该中继的摘要账号回应 401/407。
+func FindTrunkAuthBySignalingAddr(db *gorm.DB, addr string) (TrunkTransferConfig, bool) {
+	addr = strings.TrimSpace(addr)
+	if db == nil || addr == "" {
+		return TrunkTransferConfig{}, false
+	}
+	var trunks []Trunk
+	if err := db.Where("auth_username <> '' AND local_addr <> ''").Find(&trunks).Error; err != nil {
+		return TrunkTransferConfig{}, false
+	}
+	for _, trunk := range trunks {
+		host, port, ok := parseTrunkLocalAddr(trunk.LocalAddr)
+		if !ok || !strings.EqualFold(net.JoinHostPort(host, strconv.Itoa(port)), addr) {
+			continue
+		}
+		return TrunkTransferConfig{
+			Host:         host,
+			Port:         port,
+			TrunkID:      trunk.ID,
+			AuthUsername: strings.TrimSpace(trunk.AuthUsername),
+			AuthPassword: trunk.AuthPassword,
+			AuthRealm:    strings.TrimSpace(trunk.AuthRealm),
+		}, true
+	}
+	return TrunkTransferConfig{}, false
+}
+
 // pickTrunkNumberForTenant 在「号码所属租户 = tenantID」的范围内，按 role 选一条号码。
 // 同时通过 INNER JOIN trunks 排除 trunk 已软删 / local_addr 为空的行。
 func pickTrunkNumberForTenant(db *gorm.DB, tenantID uint, role TrunkPickRole) (TrunkNumber, bool) {
@@ -0,0 +1,275 @@
+package sipserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/redact"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// After-call work (ACW).
+//
+// When an agent's call ends (SIP transfer bridge, web seat, click-to-call)
+// the seat moves to work_state "acw" for the acw_seconds of the call's trunk
+// number (inbound DID, else the seat's own number; 0 falls back to
+// SIP_ACW_DEFAULT_SECONDS, and 0 there releases agents at once). While ACW
+// runs, automatic "available" writes are held off; the agent ends it by
+// submitting a disposition code (required when the tenant configured any)
+// and notes, or the timer returns the seat to available. Either way the
+// outcome lands on the sip_calls row. A break / offline / admin change of
+// the work state cancels ACW.
+
+const (
+	envACWDefaultSeconds = "SIP_ACW_DEFAULT_SECONDS"
+	// maxWrapUpNotesRunes caps free-text notes stored on the call row.
+	maxWrapUpNotesRunes = 2000
+)
+
+var (
+	ErrACWNotFound  = errors.New("acw: seat is not in after-call work for this call")
+	ErrACWNoService = errors.New("acw: service not running")
+)
+
+// acwSession is one seat's running ACW period.
+type acwSession struct {
+	notice   webseat.ACWNotice
+	tenantID uint
+	started  time.Time
+	timer    *time.Timer
+}
+
+type ACWService struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	byTarget map[uint]*acwSession // acd_pool_targets.id
+}
+
+func NewACWService(db *gorm.DB) *ACWService {
+	return &ACWService{db: db, byTarget: make(map[uint]*acwSession)}
+}
+
+// Hold is the models.SetACDWorkStateHold hook: automatic transitions back to
+// available (ringing, busy) are refused while the seat is in ACW.
+func (s *ACWService) Hold(targetID uint, workState string) bool {
+	switch workState {
+	case constants.ACDWorkStateAvailable, constants.ACDWorkStateRinging, constants.ACDWorkStateBusy:
+	default:
+		return false
+	}
+	s.mu.Lock()
+	_, ok := s.byTarget[targetID]
+	s.mu.Unlock()
+	return ok
+}
+
+// WorkStateChanged is the models.OnACDWorkStateChanged observer: any state
+// other than acw written for a seat in ACW (break, offline, admin save) cancels it.
+func (s *ACWService) WorkStateChanged(targetID uint, workState string) {
+	if workState == constants.ACDWorkStateACW {
+		return
+	}
+	sess := s.take(targetID, "")
+	if sess == nil {
+		return
+	}
+	logger.SafeGo("acw-cancel", func() {
+		s.finish(sess, persist.WrapUp{EndReason: constants.ACWEndCancelled, By: "system"}, false)
+	})
+}
+
+// Begin puts the seat that handled callID into after-call work. It returns
+// false when ACW is disabled for the call (the caller then releases the seat).
+func (s *ACWService) Begin(callID string, targetID uint) bool {
+	callID = strings.TrimSpace(callID)
+	if s == nil || s.db == nil || callID == "" || targetID == 0 {
+		return false
+	}
+	s.mu.Lock()
+	if cur := s.byTarget[targetID]; cur != nil && cur.notice.CallID == callID {
+		s.mu.Unlock()
+		return true
+	}
+	s.mu.Unlock()
+	ctx := context.Background()
+	row, err := models.GetActiveACDPoolTargetByID(s.db, targetID)
+	if err != nil {
+		return false
+	}
+	secs := s.acwSeconds(ctx, callID, row)
+	if secs <= 0 {
+		return false
+	}
+	codes, err := models.ListSIPDispositionCodes(ctx, s.db, row.TenantID, true)
+	if err != nil {
+		logger.Warn("acw: disposition codes lookup failed", zap.Uint("tenant_id", row.TenantID), zap.Error(err))
+	}
+	now := time.Now()
+	sess := &acwSession{
+		notice: webseat.ACWNotice{
+			CallID:      callID,
+			ACDTargetID: targetID,
+			ACWSeconds:  secs,
+			ExpiresAt:   now.Add(time.Duration(secs) * time.Second),
+			Required:    len(codes) > 0,
+		},
+		tenantID: row.TenantID,
+		started:  now,
+	}
+	for _, c := range codes {
+		sess.notice.Dispositions = append(sess.notice.Dispositions, webseat.Disposition{Code: c.Code, Label: c.Label})
+	}
+
+	s.mu.Lock()
+	prev := s.byTarget[targetID]
+	s.byTarget[targetID] = sess
+	sess.timer = time.AfterFunc(time.Duration(secs)*time.Second, func() { s.expire(targetID, callID) })
+	s.mu.Unlock()
+	if prev != nil {
+		prev.timer.Stop()
+		logger.SafeGo("acw-cancel", func() {
+			s.finish(prev, persist.WrapUp{EndReason: constants.ACWEndCancelled, By: "system"}, false)
+		})
+	}
+
+	if err := models.UpdateACDPoolTargetWorkState(ctx, s.db, targetID, constants.ACDWorkStateACW, "acw"); err != nil {
+		logger.Warn("acw: set work state failed", zap.Uint("acd_target_id", targetID), zap.Error(err))
+	}
+	webseat.NotifyACWStart(sess.notice)
+	logger.Info("acw: started",
+		zap.String("call_id", callID),
+		zap.Uint("acd_target_id", targetID),
+		zap.Int("acw_seconds", secs),
+	)
+	return true
+}
+
+// acwSeconds resolves the ACW length from the call's inbound DID, else the seat's trunk number.
+func (s *ACWService) acwSeconds(ctx context.Context, callID string, row models.ACDPoolTarget) int {
+	var numberID uint
+	_ = persist.ActiveSIPCalls(s.db.WithContext(ctx)).Where("call_id = ?", callID).
+		Limit(1).Pluck("inbound_trunk_number_id", &numberID).Error
+	if numberID == 0 {
+		numberID = row.TrunkNumberID
+	}
+	if numberID > 0 {
+		if num, err := models.GetTrunkNumberByID(s.db, numberID); err == nil && num.ACWSeconds > 0 {
+			return num.ACWSeconds
+		}
+	}
+	return utils.GetIntEnvWithDefault(envACWDefaultSeconds, 0)
+}
+
+// Current returns the seat's running ACW (tenantID 0 skips tenant scoping).
+func (s *ACWService) Current(tenantID, targetID uint) (webseat.ACWNotice, bool) {
+	if s == nil {
+		return webseat.ACWNotice{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.byTarget[targetID]
+	if sess == nil || (tenantID > 0 && sess.tenantID != tenantID) {
+		return webseat.ACWNotice{}, false
+	}
+	return sess.notice, true
+}
+
+// WrapUp ends ACW with the agent's disposition. targetID or callID selects the
+// seat (targetID wins); tenantID 0 skips tenant scoping (web seat WebSocket).
+func (s *ACWService) WrapUp(ctx context.Context, tenantID, targetID uint, callID, code, notes, by string) (persist.WrapUp, error) {
+	if s == nil || s.db == nil {
+		return persist.WrapUp{}, ErrACWNoService
+	}
+	callID = strings.TrimSpace(callID)
+	s.mu.Lock()
+	if targetID == 0 {
+		for id, sess := range s.byTarget {
+			if sess.notice.CallID == callID {
+				targetID = id
+				break
+			}
+		}
+	}
+	sess := s.byTarget[targetID]
+	s.mu.Unlock()
+	if sess == nil || (callID != "" && sess.notice.CallID != callID) || (tenantID > 0 && sess.tenantID != tenantID) {
+		return persist.WrapUp{}, ErrACWNotFound
+	}
+	codes, err := models.ListSIPDispositionCodes(ctx, s.db, sess.tenantID, true)
+	if err != nil {
+		return persist.WrapUp{}, err
+	}
+	code, err = models.ResolveWrapUpDisposition(codes, code)
+	if err != nil {
+		return persist.WrapUp{}, err
+	}
+	notes = strings.TrimSpace(notes)
+	if utf8.RuneCountInString(notes) > maxWrapUpNotesRunes {
+		notes = string([]rune(notes)[:maxWrapUpNotesRunes])
+	}
+	if notes != "" {
+		notes = redact.ForTenant(ctx, sess.tenantID).Text(notes)
+	}
+	if s.take(targetID, sess.notice.CallID) != sess {
+		return persist.WrapUp{}, ErrACWNotFound
+	}
+	w := persist.WrapUp{Code: code, Notes: notes, By: by, EndReason: constants.ACWEndWrappedUp}
+	return s.finish(sess, w, true), nil
+}
+
+func (s *ACWService) expire(targetID uint, callID string) {
+	sess := s.take(targetID, callID)
+	if sess == nil {
+		return
+	}
+	s.finish(sess, persist.WrapUp{EndReason: constants.ACWEndExpired, By: "system"}, true)
+}
+
+// take removes and returns the seat's session (callID "" matches any call).
+func (s *ACWService) take(targetID uint, callID string) *acwSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.byTarget[targetID]
+	if sess == nil || (callID != "" && sess.notice.CallID != callID) {
+		return nil
+	}
+	delete(s.byTarget, targetID)
+	sess.timer.Stop()
+	return sess
+}
+
+// finish records the outcome on the call and, when release is set, returns the seat to available.
+func (s *ACWService) finish(sess *acwSession, w persist.WrapUp, release bool) persist.WrapUp {
+	ctx := context.Background()
+	w.ACDTargetID = sess.notice.ACDTargetID
+	w.StartedAt = sess.started
+	w.EndedAt = time.Now()
+	if err := persist.RecordSIPCallWrapUp(ctx, s.db, sess.notice.CallID, w); err != nil {
+		logger.Warn("acw: record wrap-up failed", zap.String("call_id", sess.notice.CallID), zap.Error(err))
+	}
+	if release {
+		if err := models.UpdateACDPoolTargetWorkState(ctx, s.db, w.ACDTargetID, constants.ACDWorkStateAvailable, "acw"); err != nil {
+			logger.Warn("acw: release seat failed", zap.Uint("acd_target_id", w.ACDTargetID), zap.Error(err))
+		}
+	}
+	webseat.NotifyACWEnd(sess.notice.CallID, w.ACDTargetID, w.EndReason, w.Code)
+	logger.Info("acw: ended",
+		zap.String("call_id", sess.notice.CallID),
+		zap.Uint("acd_target_id", w.ACDTargetID),
+		zap.String("reason", w.EndReason),
+		zap.String("disposition", w.Code),
+	)
+	return w
+}
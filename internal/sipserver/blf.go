@@ -0,0 +1,222 @@
+package sipserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/sip/server"
+	"github.com/LinByte/VoiceServer/pkg/sip/sipagentpoll"
+	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Busy-lamp-field presence for ACD agents.
+//
+// An agent's lamp is derived from its acd_pool_targets rows (one per
+// trunk-number scope; the most occupied state wins) plus the live
+// transfer leg routed to it:
+//
+//	ringing   → dialog early,      PIDF open + on-the-phone
+//	busy      → dialog confirmed,  PIDF open + on-the-phone
+//	acw       → dialog confirmed,  PIDF open + busy ("after-call work")
+//	break     → no dialog,         PIDF open + away
+//	available → no dialog,         PIDF open
+//	offline   → no dialog,         PIDF closed
+//
+// Every work_state transition re-publishes the agent to SIP subscribers
+// (Event: dialog / presence), the seat SSE stream and web seat pages.
+
+// blfStatePriority orders work states by occupancy for agents with several pool rows.
+var blfStatePriority = map[string]int{
+	constants.ACDWorkStateOffline:   0,
+	constants.ACDWorkStateAvailable: 1,
+	constants.ACDWorkStateBreak:     2,
+	constants.ACDWorkStateACW:       3,
+	constants.ACDWorkStateRinging:   4,
+	constants.ACDWorkStateBusy:      5,
+}
+
+type BLFService struct {
+	db  *gorm.DB
+	srv *server.SIPServer
+}
+
+func NewBLFService(db *gorm.DB, srv *server.SIPServer) *BLFService {
+	return &BLFService{db: db, srv: srv}
+}
+
+// blfCall is the live call shown on an agent's lamp.
+type blfCall struct {
+	callID string
+	remote string // addr-spec or tel: URI
+}
+
+// Presence is the server.PresenceSource for SUBSCRIBE.
+func (b *BLFService) Presence(user string) (server.AgentPresence, bool) {
+	if b == nil || b.db == nil {
+		return server.AgentPresence{}, false
+	}
+	ctx := context.Background()
+	rows, err := models.ListACDPoolTargetsByTargetValue(ctx, b.db, user)
+	if err != nil {
+		logger.Warn("blf: pool lookup failed", zap.String("user", user), zap.Error(err))
+	}
+	_, regErr := persist.FindOnlineSIPUserByAOR(ctx, b.db, user, "")
+	online := regErr == nil
+	if len(rows) == 0 {
+		if _, err := persist.FindSIPUserByAOR(ctx, b.db, user, ""); err != nil {
+			return server.AgentPresence{}, false
+		}
+		return server.AgentPresence{User: user, Open: online}, true
+	}
+	top := rows[0]
+	for _, row := range rows[1:] {
+		if blfStatePriority[models.NormalizeACDWorkState(row.WorkState)] > blfStatePriority[models.NormalizeACDWorkState(top.WorkState)] {
+			top = row
+		}
+	}
+	ws := models.NormalizeACDWorkState(top.WorkState)
+	return agentPresence(user, ws, b.liveCall(top.ID, ws)), true
+}
+
+func agentPresence(user, workState string, call blfCall) server.AgentPresence {
+	p := server.AgentPresence{User: user, Open: true}
+	dialog := func(state string) {
+		d := server.DialogInfo{
+			ID:        nonEmptyString(call.callID, "acd-"+user),
+			CallID:    call.callID,
+			Direction: "recipient",
+			State:     state,
+			RemoteURI: call.remote,
+		}
+		p.Dialogs = append(p.Dialogs, d)
+	}
+	switch workState {
+	case constants.ACDWorkStateRinging:
+		p.Activity, p.Note = server.PresenceActivityOnThePhone, "ringing"
+		dialog(server.DialogStateEarly)
+	case constants.ACDWorkStateBusy:
+		p.Activity, p.Note = server.PresenceActivityOnThePhone, "on call"
+		dialog(server.DialogStateConfirmed)
+	case constants.ACDWorkStateACW:
+		p.Activity, p.Note = server.PresenceActivityBusy, "after-call work"
+		call.remote = ""
+		dialog(server.DialogStateConfirmed)
+	case constants.ACDWorkStateBreak:
+		p.Activity, p.Note = server.PresenceActivityAway, "break"
+	case constants.ACDWorkStateOffline:
+		p.Open, p.Note = false, "offline"
+	}
+	return p
+}
+
+// liveCall finds the inbound leg routed to a seat (ringing offer, SIP
+// transfer or web seat binding) and its caller.
+func (b *BLFService) liveCall(targetID uint, workState string) blfCall {
+	if workState != constants.ACDWorkStateRinging && workState != constants.ACDWorkStateBusy {
+		return blfCall{}
+	}
+	var c blfCall
+	if snap := sipagentpoll.SnapshotByACDTarget(targetID); snap.Incoming {
+		c.callID = snap.InboundCallID
+		if n := strings.TrimSpace(snap.CallerNumber); n != "" {
+			c.remote = "tel:" + n
+		}
+	}
+	if c.callID == "" {
+		if id, ok := conversation.InboundCallIDForACDTarget(targetID); ok {
+			c.callID = id
+		} else if id, ok := webseat.InboundCallForWebACD(targetID); ok {
+			c.callID = id
+		}
+	}
+	if c.callID != "" && c.remote == "" {
+		cs := webseat.ActiveCallSession(c.callID)
+		if b.srv != nil {
+			if s := b.srv.GetCallSession(c.callID); s != nil {
+				cs = s
+			}
+		}
+		if cs != nil {
+			c.remote = headerAddrSpec(cs.RemoteFromHeader())
+		}
+	}
+	return c
+}
+
+// ACDTargetChanged is the models work_state observer: re-publish the seat's agent.
+func (b *BLFService) ACDTargetChanged(targetID uint, workState string) {
+	if b == nil {
+		return
+	}
+	sipagentpoll.NotifyACDTargetChanged(targetID)
+	go b.publishTarget(targetID)
+}
+
+func (b *BLFService) publishTarget(targetID uint) {
+	row, err := models.ReloadACDPoolTargetByID(b.db, targetID)
+	if err != nil || row.ID == 0 {
+		return
+	}
+	user := strings.TrimSpace(row.TargetValue)
+	ws := models.NormalizeACDWorkState(row.WorkState)
+	call := b.liveCall(row.ID, ws)
+	st := webseat.AgentState{
+		ACDTargetID: row.ID,
+		TenantID:    row.TenantID,
+		Name:        strings.TrimSpace(row.Name),
+		TargetValue: user,
+		RouteType:   row.RouteType,
+		WorkState:   ws,
+		DialogState: server.DialogStateTerminated,
+		CallID:      call.callID,
+		Remote:      call.remote,
+	}
+	if d := agentPresence(user, ws, call).Dialogs; len(d) > 0 {
+		st.DialogState = d[0].State
+	}
+	webseat.BroadcastAgentState(st)
+	if user == "" || b.srv == nil {
+		return
+	}
+	if p, ok := b.Presence(user); ok {
+		b.srv.PublishAgentPresence(p)
+	}
+}
+
+// UserRegistered re-publishes open / closed after a REGISTER binding change.
+func (b *BLFService) UserRegistered(user, _ string) {
+	if b == nil || b.srv == nil {
+		return
+	}
+	if p, ok := b.Presence(user); ok {
+		b.srv.PublishAgentPresence(p)
+	}
+}
+
+// headerAddrSpec returns the URI inside a From / To header value.
+func headerAddrSpec(h string) string {
+	h = strings.TrimSpace(h)
+	if i := strings.IndexByte(h, '<'); i >= 0 {
+		if j := strings.IndexByte(h[i:], '>'); j > 0 {
+			return h[i+1 : i+j]
+		}
+	}
+	if i := strings.IndexByte(h, ';'); i >= 0 {
+		h = h[:i]
+	}
+	return strings.TrimSpace(h)
+}
+
+func nonEmptyString(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/LinByte/VoiceServer/internal/constants"
 	"github.com/LinByte/VoiceServer/internal/models"
 	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/scriptlisten"
 	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
 	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
@@ -27,6 +28,7 @@ import (
 type CampaignService struct {
 	db                  *gorm.DB
 	dialTargetResolver  func(ctx context.Context, phone string) (outbound.DialTarget, bool)
+	trunkRouter         *TrunkRouter
 	dialer              Dialer
 	mu                  sync.Mutex
 	running             bool
@@ -82,6 +84,7 @@ type campaignDispatchTask struct {
 func NewCampaignService(db *gorm.DB) *CampaignService {
 	return &CampaignService{
 		db:                  db,
+		trunkRouter:         NewTrunkRouter(db, nil, nil),
 		pollInterval:        1500 * time.Millisecond,
 		globalConcurrency:   20,
 		dedupeWindow:        24 * time.Hour,
@@ -100,6 +103,16 @@ func (s *CampaignService) SetDialTargetResolver(fn func(ctx context.Context, pho
 	s.mu.Unlock()
 }
 
+// SetTrunkRouter injects the trunk group / least-cost router (health, capacity and stats aware).
+func (s *CampaignService) SetTrunkRouter(r *TrunkRouter) {
+	if s == nil || r == nil {
+		return
+	}
+	s.mu.Lock()
+	s.trunkRouter = r
+	s.mu.Unlock()
+}
+
 func (s *CampaignService) CreateCampaign(ctx context.Context, in CreateCampaignInput) (*models.SIPCampaign, error) {
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("campaign service unavailable")
@@ -259,6 +272,37 @@ func (s *CampaignService) HandleDialEvent(ctx context.Context, evt outbound.Dial
 				strings.TrimSpace(evt.StatusText),
 			),
 		})
+	case outbound.DialEventRerouted:
+		s.appendEvent(ctx, models.SIPCampaignEvent{
+			CampaignID:    campaignID,
+			ContactID:     contactID,
+			CallID:        evt.CallID,
+			CorrelationID: evt.CorrelationID,
+			Type:          "dial",
+			Level:         "warn",
+			Message: fmt.Sprintf(
+				"trunk failover sip=%d from_udp=%s uri=%s — retrying on next trunk group member",
+				evt.StatusCode,
+				strings.TrimSpace(evt.RemoteAddr),
+				strings.TrimSpace(evt.RequestURI),
+			),
+		})
+	case outbound.DialEventRedirected:
+		s.appendEvent(ctx, models.SIPCampaignEvent{
+			CampaignID:    campaignID,
+			ContactID:     contactID,
+			CallID:        evt.CallID,
+			CorrelationID: evt.CorrelationID,
+			Type:          "dial",
+			Level:         "info",
+			Message: fmt.Sprintf(
+				"redirected sip=%d from_udp=%s original=%s → uri=%s",
+				evt.StatusCode,
+				strings.TrimSpace(evt.RemoteAddr),
+				strings.TrimSpace(evt.OriginalRequestURI),
+				strings.TrimSpace(evt.RequestURI),
+			),
+		})
 	case outbound.DialEventFailed:
 		s.metrics.Failed.Add(1)
 		s.markAttemptFailed(ctx, campaignID, contactID, attemptNo, evt)
@@ -363,12 +407,29 @@ func parseCorrelation(v string) (campaignID uint, contactID uint, attemptNo int,
 	return uint(cID), uint(ctID), aNo, true
 }
 
-// RecordScriptStep writes one script trace row.
+// RecordScriptStep writes one script trace row, resolving the campaign
+// tenant's redaction policy from the correlation ID. Script runs resolve it
+// once up front and go through recordScriptStep instead.
 func (s *CampaignService) RecordScriptStep(ctx context.Context, evt outbound.ScriptRunEvent) error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	var tenantID uint
+	if campaignID, _, _, _ := parseCorrelation(evt.CorrelationID); campaignID > 0 {
+		if c, err := models.GetSIPCampaignByID(ctx, s.db, campaignID); err == nil {
+			tenantID = c.TenantID
+		}
+	}
+	return s.recordScriptStep(ctx, redact.ForTenant(ctx, tenantID), evt)
+}
+
+func (s *CampaignService) recordScriptStep(ctx context.Context, pii *redact.Engine, evt outbound.ScriptRunEvent) error {
 	if s == nil || s.db == nil {
 		return nil
 	}
 	campaignID, contactID, _, _ := parseCorrelation(evt.CorrelationID)
+	evt.InputText = redact.CallText(pii, evt.CallID, evt.InputText)
+	evt.OutputText = pii.Text(evt.OutputText)
 	row := models.SIPScriptRun{
 		CampaignID:    campaignID,
 		ContactID:     contactID,
@@ -467,19 +528,46 @@ func (s *CampaignService) RunScriptIfConfigured(ctx context.Context, leg outboun
 	if raw == "" {
 		return
 	}
+	started := s.runScriptSpec(ctx, leg, raw, scriptID, c.TenantID, func() {
+		cID, ctID, _, ok := parseCorrelation(leg.CorrelationID)
+		if ok {
+			s.appendEvent(context.Background(), models.SIPCampaignEvent{
+				CampaignID:    cID,
+				ContactID:     ctID,
+				CallID:        leg.CallID,
+				CorrelationID: leg.CorrelationID,
+				Type:          "script",
+				Level:         "info",
+				Message:       "script finished, hangup requested",
+				Meta:          datatypes.JSON([]byte(`{}`)),
+			})
+		}
+	})
+	if started {
+		releaseScriptMode = false
+	}
+}
+
+// runScriptSpec parses a hybrid script spec and runs it on the established leg in
+// the background, hanging up when it finishes normally (then onFinished, if set).
+// It returns false when the spec is invalid and nothing was started; the runner
+// clears the leg's script mode itself once started. tenantID selects the
+// redaction policy for the run's trace rows.
+func (s *CampaignService) runScriptSpec(ctx context.Context, leg outbound.EstablishedLeg, raw, scriptID string, tenantID uint, onFinished func()) bool {
 	script, err := outbound.ParseHybridScript(raw)
 	if err != nil {
 		if logger.Lg != nil {
-			logger.Lg.Warn("campaign script parse failed", zap.Uint("campaign_id", campaignID), zap.Error(err))
+			logger.Lg.Warn("campaign script parse failed", zap.String("call_id", leg.CallID),
+				zap.String("correlation_id", leg.CorrelationID), zap.Error(err))
 		}
-		return
+		return false
 	}
 	if scriptID != "" {
 		script.ID = scriptID
 	}
 	lastTurnIndex := 0
 	lastTurnReply := ""
-	runner := outbound.NewHybridScriptRunner(script, scriptRecorder{s: s}).WithHooks(outbound.RuntimeHooks{
+	runner := outbound.NewHybridScriptRunner(script, scriptRecorder{s: s, pii: redact.ForTenant(ctx, tenantID)}).WithHooks(outbound.RuntimeHooks{
 		OnSay: func(runCtx context.Context, runLeg outbound.EstablishedLeg, prompt string) error {
 			if runLeg.Session == nil {
 				return fmt.Errorf("script say: session not ready")
@@ -542,7 +630,6 @@ func (s *CampaignService) RunScriptIfConfigured(ctx context.Context, leg outboun
 			return false
 		},
 	})
-	releaseScriptMode = false
 	// Wrap with SafeGo so a panic inside the user-defined script runner
 	// (e.g. malformed script JSON, third-party LLM SDK bug) does NOT
 	// crash the whole SIP server.
@@ -557,20 +644,11 @@ func (s *CampaignService) RunScriptIfConfigured(ctx context.Context, leg outboun
 		// Script ended normally: actively send BYE so call does not linger after farewell.
 		time.Sleep(300 * time.Millisecond)
 		conversation.RequestSIPHangup(leg.CallID)
-		cID, ctID, _, ok := parseCorrelation(leg.CorrelationID)
-		if ok {
-			s.appendEvent(context.Background(), models.SIPCampaignEvent{
-				CampaignID:    cID,
-				ContactID:     ctID,
-				CallID:        leg.CallID,
-				CorrelationID: leg.CorrelationID,
-				Type:          "script",
-				Level:         "info",
-				Message:       "script finished, hangup requested",
-				Meta:          datatypes.JSON([]byte(`{}`)),
-			})
+		if onFinished != nil {
+			onFinished()
 		}
 	})
+	return true
 }
 
 type turnFetchResult struct {
@@ -661,15 +739,18 @@ func (s *CampaignService) fetchTurn(callID string, afterIndex int, notBefore tim
 	return turnFetchResult{}, false
 }
 
+// scriptRecorder persists the steps of one script run with the tenant's
+// redaction policy, resolved when the run starts.
 type scriptRecorder struct {
-	s *CampaignService
+	s   *CampaignService
+	pii *redact.Engine
 }
 
 func (r scriptRecorder) Record(ctx context.Context, event outbound.ScriptRunEvent) error {
 	if r.s == nil {
 		return nil
 	}
-	return r.s.RecordScriptStep(ctx, event)
+	return r.s.recordScriptStep(ctx, r.pii, event)
 }
 
 func (s *CampaignService) tryAcquireCampaignSlot(campaignID uint, limit int) bool {
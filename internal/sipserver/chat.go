@@ -0,0 +1,583 @@
+package sipserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/llm"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/sip/server"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SIP MESSAGE routing (RFC 3428 page mode).
+//
+// The platform terminates every MESSAGE (202 Accepted to the sender) and
+// originates a new one per hop, so delivery status is tracked the same
+// way for user relay, API sends and chatbot replies:
+//   - registered user → registered user: stored as a relay row, sent
+//     now when the recipient is online, otherwise kept queued until the
+//     next REGISTER (store-and-forward) or the TTL runs out;
+//   - trunk → tenant DID: answered by the tenant chatbot, using the same
+//     LLM provider / credentials as voice calls; accepted only from the
+//     signaling addresses of the trunk that owns the number;
+//   - HTTP API: to a registered user (queued like relay) or to a phone
+//     number through the tenant's outbound trunk (single attempt).
+
+const (
+	envChatSystemPrompt     = "SIP_CHAT_SYSTEM_PROMPT"
+	envChatSessionIdleMin   = "SIP_CHAT_SESSION_IDLE_MIN"
+	envMessageStoreTTLHours = "SIP_MESSAGE_STORE_TTL_HOURS"
+	envMessageMaxAttempts   = "SIP_MESSAGE_MAX_ATTEMPTS"
+
+	defaultChatSessionIdleMin   = 30
+	defaultMessageStoreTTLHours = 72
+	defaultMessageMaxAttempts   = 10
+
+	defaultChatSystemPrompt = "你是企业的短信客服助手。请用简洁、礼貌的中文回复客户，每条回复不超过 200 字，不要使用 Markdown。"
+
+	chatJanitorInterval = time.Minute
+	chatReplyTimeout    = 60 * time.Second
+)
+
+// Message send targets (SendChatInput.ToType).
+const (
+	ChatTargetUser   = "user"
+	ChatTargetNumber = "number"
+)
+
+var (
+	ErrChatRecipientNotFound = errors.New("sip message: recipient not found")
+	ErrChatRecipientScope    = errors.New("sip message: SIP user is not an agent of this tenant")
+	ErrChatNoOutboundTrunk   = errors.New("sip message: no outbound trunk for tenant")
+)
+
+type ChatService struct {
+	db   *gorm.DB
+	send func(ctx context.Context, req outbound.MessageRequest) (outbound.MessageResult, error)
+
+	mu       sync.Mutex
+	running  bool
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	sessions map[string]*chatSession
+	aorLocks map[string]*sync.Mutex
+}
+
+// chatSession is one chatbot conversation (tenant + DID + peer number).
+type chatSession struct {
+	mu       sync.Mutex
+	provider llm.LLMProvider
+	model    string
+	lastUsed time.Time
+}
+
+type SendChatInput struct {
+	TenantID uint
+	// To is a SIP username (optionally user@domain) or a phone number.
+	To     string
+	ToType string
+	// From is the tenant trunk number for ToType number, or the From user
+	// shown to a registered user; optional.
+	From        string
+	Body        string
+	ContentType string
+	CreateBy    string
+}
+
+func NewChatService(db *gorm.DB, out *outbound.Manager) *ChatService {
+	s := &ChatService{
+		db:       db,
+		sessions: map[string]*chatSession{},
+		aorLocks: map[string]*sync.Mutex{},
+	}
+	if out != nil {
+		s.send = out.SendMessage
+	}
+	return s
+}
+
+// Start runs the janitor: expires stored messages past their TTL and retires idle chatbot sessions.
+func (s *ChatService) Start() {
+	if s == nil || s.db == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(chatJanitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case now := <-ticker.C:
+				s.janitor(now)
+			}
+		}
+	}()
+}
+
+func (s *ChatService) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	close(s.stopCh)
+	s.running = false
+	sessions := s.sessions
+	s.sessions = map[string]*chatSession{}
+	s.mu.Unlock()
+	s.wg.Wait()
+	for _, cs := range sessions {
+		cs.provider.Hangup()
+	}
+}
+
+func (s *ChatService) janitor(now time.Time) {
+	if n, err := persist.ExpireQueuedSIPMessages(context.Background(), s.db, now); err != nil {
+		logger.Warn("sip message expire failed", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("sip messages expired", zap.Int64("count", n))
+	}
+	idle := time.Duration(utils.GetIntEnvWithDefault(envChatSessionIdleMin, defaultChatSessionIdleMin)) * time.Minute
+	var retired []*chatSession
+	s.mu.Lock()
+	for k, cs := range s.sessions {
+		if cs.mu.TryLock() {
+			if now.Sub(cs.lastUsed) > idle {
+				delete(s.sessions, k)
+				retired = append(retired, cs)
+			}
+			cs.mu.Unlock()
+		}
+	}
+	s.mu.Unlock()
+	for _, cs := range retired {
+		cs.provider.Hangup()
+	}
+}
+
+// HandleInbound is the server.MessageHandler: exact AOR match relays,
+// a tenant DID goes to the chatbot, a bare username match relays.
+func (s *ChatService) HandleInbound(ctx context.Context, in server.InboundMessage) (int, string) {
+	if s == nil || s.db == nil {
+		return 480, "Temporarily Unavailable"
+	}
+	if strings.TrimSpace(in.ToUser) == "" {
+		return 404, "Not Found"
+	}
+	if u, err := persist.FindSIPUserByAOR(ctx, s.db, in.ToUser, in.ToHost); err == nil {
+		return s.acceptRelay(ctx, in, u)
+	}
+	if in.DID.TenantID > 0 {
+		return s.acceptForBot(ctx, in)
+	}
+	if u, err := persist.FindSIPUserByAOR(ctx, s.db, in.ToUser, ""); err == nil {
+		return s.acceptRelay(ctx, in, u)
+	}
+	return 404, "Not Found"
+}
+
+func (s *ChatService) acceptRelay(ctx context.Context, in server.InboundMessage, u persist.SIPUser) (int, string) {
+	row := persist.SIPMessage{
+		Direction:   "relay",
+		Source:      persist.SIPMessageSourceSIP,
+		FromUser:    in.FromUser,
+		FromDomain:  in.FromHost,
+		ToUser:      u.Username,
+		ToDomain:    u.Domain,
+		ContentType: in.ContentType,
+		Body:        in.Body,
+		Status:      persist.SIPMessageStatusQueued,
+		CallID:      in.CallID,
+		ExpiresAt:   messageStoreDeadline(time.Now()),
+	}
+	if err := persist.CreateSIPMessage(ctx, s.db, &row); err != nil {
+		logger.Warn("sip message store failed", zap.String("to", u.Username), zap.Error(err))
+		return 500, "Server Internal Error"
+	}
+	if u.Online {
+		go s.DeliverPending(u.Username, u.Domain)
+	}
+	return 202, "Accepted"
+}
+
+func (s *ChatService) acceptForBot(ctx context.Context, in server.InboundMessage) (int, string) {
+	// Every accepted message costs an LLM query and a reply, so only the
+	// DID's own carrier may address the bot.
+	if in.Remote == nil || !models.TrunkNumberAcceptsSource(ctx, s.db, in.DID.TrunkNumberID, in.Remote.IP) {
+		logger.Warn("sip message to DID from unknown source",
+			zap.Uint("tenant_id", in.DID.TenantID),
+			zap.Uint("trunk_number_id", in.DID.TrunkNumberID),
+			zap.String("remote", in.Remote.String()))
+		return 403, "Forbidden"
+	}
+	row := persist.SIPMessage{
+		TenantID:      in.DID.TenantID,
+		TrunkNumberID: in.DID.TrunkNumberID,
+		Direction:     "inbound",
+		Source:        persist.SIPMessageSourceTrunk,
+		FromUser:      in.FromUser,
+		FromDomain:    in.FromHost,
+		ToUser:        in.ToUser,
+		ToDomain:      in.ToHost,
+		ContentType:   in.ContentType,
+		Body:          in.Body,
+		Status:        persist.SIPMessageStatusReceived,
+		CallID:        in.CallID,
+	}
+	if err := persist.CreateSIPMessage(ctx, s.db, &row); err != nil {
+		logger.Warn("sip message store failed", zap.String("to", in.ToUser), zap.Error(err))
+		return 500, "Server Internal Error"
+	}
+	go s.replyFromBot(in)
+	return 202, "Accepted"
+}
+
+// DeliverPending sends queued messages for user@domain in order; it is
+// the registrar observer. A retryable failure stops the flush and leaves
+// the rest queued for the next registration.
+func (s *ChatService) DeliverPending(user, domain string) {
+	if s == nil || s.db == nil || s.send == nil {
+		return
+	}
+	lk := s.aorLock(user)
+	lk.Lock()
+	defer lk.Unlock()
+
+	ctx := context.Background()
+	rows, err := persist.ListQueuedSIPMessagesForAOR(ctx, s.db, user, domain)
+	if err != nil {
+		logger.Warn("sip message queue lookup failed", zap.String("user", user), zap.Error(err))
+		return
+	}
+	for _, row := range rows {
+		if row.ExpiresAt != nil && !row.ExpiresAt.After(time.Now()) {
+			_ = persist.FinishSIPMessageAttempt(ctx, s.db, row.ID, persist.SIPMessageStatusExpired, 0, "store-and-forward TTL elapsed", "")
+			continue
+		}
+		if !s.deliverToUser(ctx, row) {
+			return
+		}
+	}
+}
+
+// deliverToUser runs one attempt; false means the recipient is unreachable right now.
+func (s *ChatService) deliverToUser(ctx context.Context, row persist.SIPMessage) bool {
+	u, err := persist.FindOnlineSIPUserByAOR(ctx, s.db, row.ToUser, row.ToDomain)
+	if err != nil {
+		return false
+	}
+	ok, err := persist.ClaimSIPMessage(ctx, s.db, row.ID, persist.SIPMessageStatusQueued)
+	if err != nil || !ok {
+		return true
+	}
+	res, sendErr := s.send(ctx, outbound.MessageRequest{
+		Target:      persist.DialTargetFromSIPUser(u),
+		FromUser:    row.FromUser,
+		ContentType: row.ContentType,
+		Body:        row.Body,
+	})
+	status, reason := persist.SIPMessageStatusDelivered, res.Reason
+	switch {
+	case sendErr == nil && res.Delivered():
+	case persist.SIPMessageRetryable(res.Status) && row.Attempts+1 < messageMaxAttempts():
+		status = persist.SIPMessageStatusQueued
+	default:
+		status = persist.SIPMessageStatusFailed
+	}
+	if sendErr != nil {
+		reason = sendErr.Error()
+	}
+	if err := persist.FinishSIPMessageAttempt(ctx, s.db, row.ID, status, res.Status, reason, res.CallID); err != nil {
+		logger.Warn("sip message status update failed", zap.Uint("id", row.ID), zap.Error(err))
+	}
+	logger.Info("sip message delivery attempt",
+		zap.Uint("id", row.ID),
+		zap.String("to", row.ToUser),
+		zap.Int("sip_status", res.Status),
+		zap.String("status", status))
+	return status != persist.SIPMessageStatusQueued
+}
+
+// sendToTrunk runs the single attempt for an outbound (trunk) row.
+func (s *ChatService) sendToTrunk(ctx context.Context, row persist.SIPMessage, target outbound.DialTarget) {
+	if ok, err := persist.ClaimSIPMessage(ctx, s.db, row.ID, persist.SIPMessageStatusQueued); err != nil || !ok {
+		return
+	}
+	res, err := s.send(ctx, outbound.MessageRequest{
+		Target:      target,
+		FromUser:    row.FromUser,
+		ContentType: row.ContentType,
+		Body:        row.Body,
+	})
+	status, reason := persist.SIPMessageStatusFailed, res.Reason
+	if err != nil {
+		reason = err.Error()
+	} else if res.Delivered() {
+		status = persist.SIPMessageStatusDelivered
+	}
+	if err := persist.FinishSIPMessageAttempt(ctx, s.db, row.ID, status, res.Status, reason, res.CallID); err != nil {
+		logger.Warn("sip message status update failed", zap.Uint("id", row.ID), zap.Error(err))
+	}
+	logger.Info("sip message sent to trunk",
+		zap.Uint("id", row.ID),
+		zap.Uint("tenant_id", row.TenantID),
+		zap.String("to", row.ToUser),
+		zap.Int("sip_status", res.Status),
+		zap.String("status", status))
+}
+
+func (s *ChatService) replyFromBot(in server.InboundMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), chatReplyTimeout)
+	defer cancel()
+	tenantID := in.DID.TenantID
+	cs, err := s.chatSessionFor(ctx, tenantID, in.ToUser, in.FromUser)
+	if err != nil {
+		logger.Warn("sip chatbot unavailable", zap.Uint("tenant_id", tenantID), zap.Error(err))
+		return
+	}
+	cs.mu.Lock()
+	answer, err := cs.provider.Query(in.Text, cs.model)
+	cs.lastUsed = time.Now()
+	cs.mu.Unlock()
+	if err != nil {
+		logger.Warn("sip chatbot query failed", zap.Uint("tenant_id", tenantID), zap.Error(err))
+		return
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" || s.send == nil {
+		return
+	}
+
+	target := outbound.DialTarget{RequestURI: in.FromURI}
+	if in.Remote != nil {
+		target.SignalingAddr = in.Remote.String()
+	}
+	if cfg, ok := models.ResolveACDOutboundFromTrunkNumber(s.db, tenantID, in.DID.TrunkNumberID); ok {
+		target = trunkMessageTarget(cfg, in.FromUser)
+	}
+	if strings.TrimSpace(target.RequestURI) == "" {
+		return
+	}
+	row := persist.SIPMessage{
+		TenantID:      tenantID,
+		TrunkNumberID: in.DID.TrunkNumberID,
+		Direction:     "outbound",
+		Source:        persist.SIPMessageSourceBot,
+		FromUser:      in.ToUser,
+		ToUser:        in.FromUser,
+		ToDomain:      in.FromHost,
+		ContentType:   outbound.DefaultMessageContentType,
+		Body:          answer,
+		Status:        persist.SIPMessageStatusQueued,
+		CreateBy:      "chatbot",
+	}
+	if err := persist.CreateSIPMessage(ctx, s.db, &row); err != nil {
+		logger.Warn("sip message store failed", zap.String("to", in.FromUser), zap.Error(err))
+		return
+	}
+	s.sendToTrunk(ctx, row, target)
+}
+
+func (s *ChatService) chatSessionFor(ctx context.Context, tenantID uint, did, peer string) (*chatSession, error) {
+	key := fmt.Sprintf("%d|%s|%s", tenantID, did, peer)
+	s.mu.Lock()
+	cs := s.sessions[key]
+	s.mu.Unlock()
+	if cs != nil {
+		return cs, nil
+	}
+	prompt := strings.TrimSpace(utils.GetEnv(envChatSystemPrompt))
+	if prompt == "" {
+		prompt = defaultChatSystemPrompt
+	}
+	p, model, err := conversation.NewTenantChatLLMProvider(ctx, tenantID, prompt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing := s.sessions[key]; existing != nil {
+		p.Hangup()
+		return existing, nil
+	}
+	cs = &chatSession{provider: p, model: model, lastUsed: time.Now()}
+	s.sessions[key] = cs
+	return cs, nil
+}
+
+// Send queues one API message. ToType user is tenant-scoped to SIP users
+// in the tenant's ACD pool (tenantID 0 = platform admin, unrestricted);
+// ToType number goes out through the tenant's outbound trunk. The
+// returned row is the initial state; poll Get for the delivery status.
+func (s *ChatService) Send(ctx context.Context, in SendChatInput) (persist.SIPMessage, error) {
+	if s == nil || s.db == nil || s.send == nil {
+		return persist.SIPMessage{}, errors.New("sip message service unavailable")
+	}
+	to := strings.TrimSpace(in.To)
+	if to == "" {
+		return persist.SIPMessage{}, errors.New("to is required")
+	}
+	if strings.TrimSpace(in.Body) == "" {
+		return persist.SIPMessage{}, outbound.ErrEmptyMessage
+	}
+	ct := strings.TrimSpace(in.ContentType)
+	if ct == "" {
+		ct = outbound.DefaultMessageContentType
+	}
+	switch strings.ToLower(strings.TrimSpace(in.ToType)) {
+	case "", ChatTargetUser:
+		return s.sendToUser(ctx, in, to, ct)
+	case ChatTargetNumber:
+		return s.sendToNumber(ctx, in, to, ct)
+	}
+	return persist.SIPMessage{}, fmt.Errorf("unsupported toType %q", in.ToType)
+}
+
+func (s *ChatService) sendToUser(ctx context.Context, in SendChatInput, to, ct string) (persist.SIPMessage, error) {
+	user, domain, _ := strings.Cut(to, "@")
+	if in.TenantID > 0 {
+		_, ok, err := models.FindSIPACDPoolTargetForIncomingPoll(s.db, in.TenantID, 0, "", user)
+		if err != nil {
+			return persist.SIPMessage{}, err
+		}
+		if !ok {
+			return persist.SIPMessage{}, ErrChatRecipientScope
+		}
+	}
+	u, err := persist.FindSIPUserByAOR(ctx, s.db, user, domain)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return persist.SIPMessage{}, ErrChatRecipientNotFound
+	}
+	if err != nil {
+		return persist.SIPMessage{}, err
+	}
+	row := persist.SIPMessage{
+		TenantID:    in.TenantID,
+		Direction:   "relay",
+		Source:      persist.SIPMessageSourceAPI,
+		FromUser:    strings.TrimSpace(in.From),
+		ToUser:      u.Username,
+		ToDomain:    u.Domain,
+		ContentType: ct,
+		Body:        in.Body,
+		Status:      persist.SIPMessageStatusQueued,
+		ExpiresAt:   messageStoreDeadline(time.Now()),
+		CreateBy:    in.CreateBy,
+	}
+	if err := persist.CreateSIPMessage(ctx, s.db, &row); err != nil {
+		return persist.SIPMessage{}, err
+	}
+	if u.Online {
+		go s.DeliverPending(u.Username, u.Domain)
+	}
+	return row, nil
+}
+
+func (s *ChatService) sendToNumber(ctx context.Context, in SendChatInput, to, ct string) (persist.SIPMessage, error) {
+	var (
+		cfg models.TrunkTransferConfig
+		ok  bool
+	)
+	if from := strings.TrimSpace(in.From); from != "" {
+		cfg, ok = models.PickTrunkOutboundConfigByCaller(s.db, in.TenantID, from)
+	} else {
+		cfg, ok = models.PickTrunkOutboundConfig(s.db, in.TenantID)
+	}
+	if !ok {
+		return persist.SIPMessage{}, ErrChatNoOutboundTrunk
+	}
+	row := persist.SIPMessage{
+		TenantID:      in.TenantID,
+		TrunkNumberID: cfg.TrunkNumberID,
+		Direction:     "outbound",
+		Source:        persist.SIPMessageSourceAPI,
+		FromUser:      cfg.CallerUser,
+		ToUser:        to,
+		ToDomain:      cfg.Host,
+		ContentType:   ct,
+		Body:          in.Body,
+		Status:        persist.SIPMessageStatusQueued,
+		CreateBy:      in.CreateBy,
+	}
+	if err := persist.CreateSIPMessage(ctx, s.db, &row); err != nil {
+		return persist.SIPMessage{}, err
+	}
+	target := trunkMessageTarget(cfg, to)
+	go s.sendToTrunk(context.Background(), row, target)
+	return row, nil
+}
+
+// Get returns one message for status polling; tenantID 0 = any tenant.
+func (s *ChatService) Get(ctx context.Context, tenantID, id uint) (persist.SIPMessage, error) {
+	if s == nil || s.db == nil {
+		return persist.SIPMessage{}, gorm.ErrRecordNotFound
+	}
+	return persist.GetSIPMessage(ctx, s.db, tenantID, id)
+}
+
+func (s *ChatService) aorLock(user string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lk := s.aorLocks[user]
+	if lk == nil {
+		lk = &sync.Mutex{}
+		s.aorLocks[user] = lk
+	}
+	return lk
+}
+
+func trunkMessageTarget(cfg models.TrunkTransferConfig, phone string) outbound.DialTarget {
+	return outbound.DialTarget{
+		RequestURI:        fmt.Sprintf("sip:%s@%s", strings.TrimSpace(phone), net.JoinHostPort(cfg.Host, fmt.Sprint(cfg.Port))),
+		SignalingAddr:     cfg.SignalingAddr(),
+		CallerUser:        cfg.CallerUser,
+		CallerDisplayName: cfg.CallerDisplay,
+		Auth:              trunkDigestCredentials(cfg),
+		TrunkID:           cfg.TrunkID,
+		TrunkNumberID:     cfg.TrunkNumberID,
+	}
+}
+
+func messageStoreDeadline(now time.Time) *time.Time {
+	t := now.Add(time.Duration(utils.GetIntEnvWithDefault(envMessageStoreTTLHours, defaultMessageStoreTTLHours)) * time.Hour)
+	return &t
+}
+
+func messageMaxAttempts() int {
+	return utils.GetIntEnvWithDefault(envMessageMaxAttempts, defaultMessageMaxAttempts)
+}
+
+// ChatService returns the SIP MESSAGE router (nil before Start).
+func (e *Embedded) ChatService() *ChatService {
+	if e == nil {
+		return nil
+	}
+	return e.chatSvc
+}
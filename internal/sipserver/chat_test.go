@@ -0,0 +1,70 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package sipserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/sip/server"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openTestDB returns an in-memory sqlite database with tables migrated.
+func openTestDB(t *testing.T, tables ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestChatHandleInbound_BotOnlyFromTrunkSource(t *testing.T) {
+	db := openTestDB(t, &persist.SIPUser{}, &persist.SIPMessage{}, &models.Trunk{}, &models.TrunkNumber{})
+	if err := db.Create(&models.Trunk{ID: 1, TenantID: 3, Name: "carrier", LocalAddr: "sip:203.0.113.10:5060"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.TrunkNumber{ID: 11, TrunkID: 1, TenantID: 3, Number: "4008001234"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	s := NewChatService(db, nil)
+
+	cases := []struct {
+		name   string
+		remote *net.UDPAddr
+		did    server.InboundDIDBinding
+	}{
+		{"spoofed source", &net.UDPAddr{IP: net.ParseIP("198.51.100.66"), Port: 5060}, server.InboundDIDBinding{TenantID: 3, TrunkNumberID: 11}},
+		{"unresolved trunk number", &net.UDPAddr{IP: net.ParseIP("203.0.113.10"), Port: 5060}, server.InboundDIDBinding{TenantID: 3}},
+		{"no remote", nil, server.InboundDIDBinding{TenantID: 3, TrunkNumberID: 11}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, _ := s.HandleInbound(context.Background(), server.InboundMessage{
+				CallID:   "msg-" + tc.name,
+				FromUser: "13800000000",
+				ToUser:   "4008001234",
+				Text:     "hi",
+				Body:     "hi",
+				Remote:   tc.remote,
+				DID:      tc.did,
+			})
+			if code != 403 {
+				t.Fatalf("status = %d, want 403", code)
+			}
+		})
+	}
+	var n int64
+	if err := db.Model(&persist.SIPMessage{}).Count(&n).Error; err != nil || n != 0 {
+		t.Fatalf("rejected messages stored: %d %v", n, err)
+	}
+}
@@ -0,0 +1,1063 @@
+package sipserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Click-to-call (API-originated single call).
+//
+// An integrator creates one call and follows it by correlation id:
+//   - ai: the customer is dialed with the AI voice profile (optional
+//     system prompt override) or a tenant script template;
+//   - agent: the agent is rung first — a SIP seat with MediaProfileNone, or
+//     a web seat through webseat.OfferOutbound — and the customer is dialed
+//     once the agent answers. A SIP agent is bridged by the transfer bridge
+//     (customer leg MediaProfileTransferBridge, CorrelationID = agent
+//     Call-ID); a web agent by webseat.AttachOutboundPeer.
+//
+// A web-seat agent can also dial a customer from the browser
+// (DialFromWebSeat, origin web_seat): the same agent-first flow, minus the
+// incoming card, with the caller ID of the agent's trunk number. Web
+// customer legs take early media, so the agent hears ringback through the
+// WebRTC bridge, and their sip_calls row carries agent_acd_target_id.
+//
+// Every dial event and status change lands in sip_click_to_call_events.
+// Runs live in memory, so a restart cannot follow them further:
+// FailOrphaned closes the unfinished rows left behind at startup.
+
+const (
+	envClickToCallRingTimeoutSec     = "SIP_CLICK_TO_CALL_RING_TIMEOUT_SEC"
+	defaultClickToCallRingTimeoutSec = 40
+
+	clickToCallCorrelationPrefix = "c2c-"
+
+	clickToCallLegAgent    = "agent"
+	clickToCallLegCustomer = "customer"
+	clickToCallLegAPI      = "api"
+)
+
+var (
+	ErrClickToCallNotFound     = errors.New("click-to-call: not found")
+	ErrClickToCallFinished     = errors.New("click-to-call: call already finished")
+	ErrClickToCallAgentMissing = errors.New("click-to-call: agent not found in tenant")
+	ErrClickToCallAgentBusy    = errors.New("click-to-call: agent is not available")
+	ErrClickToCallUnavailable  = errors.New("click-to-call: SIP service not running")
+	ErrClickToCallNoTrunk      = errors.New("click-to-call: agent has no outbound trunk number")
+)
+
+// ClickToCallDialer is the outbound.Manager surface the service drives.
+type ClickToCallDialer interface {
+	Dial(ctx context.Context, req outbound.DialRequest) (callID string, err error)
+	SendCANCEL(callID string) error
+	SendBYE(callID string) error
+}
+
+type ClickToCallInput struct {
+	TenantID uint
+	Mode     string
+	// Customer is the callee: phone number or registered SIP username.
+	Customer string
+	// CallerUser is the tenant trunk number shown to the customer; optional
+	// when TrunkGroupID or a least-cost route applies.
+	CallerUser   string
+	TrunkGroupID uint
+	RequestURI   string
+	ExternalID   string
+
+	// ai mode: ScriptTemplateID > 0 runs the template (script profile).
+	ScriptTemplateID uint
+	SystemPrompt     string
+
+	// agent mode: an acd_pool_targets row, or a SIP username of a tenant agent.
+	AgentTargetID uint
+	AgentUser     string
+
+	CreateBy string
+}
+
+type ClickToCallService struct {
+	db       *gorm.DB
+	dialer   ClickToCallDialer
+	campaign *CampaignService
+	reg      *persist.GormStore
+	// hangupBridge tears down a bridged agent + customer pair (keyed by the agent Call-ID).
+	hangupBridge func(callID string)
+	// persistLocalBye finalizes the sip_calls row of a web customer leg we
+	// hang up (the SIP server only persists BYEs it receives).
+	persistLocalBye func(callID string)
+	// afterCallWork takes a SIP agent who talked to the customer into ACW
+	// (true) instead of releasing the seat (web seats go through webseat).
+	afterCallWork func(callID string, targetID uint) bool
+
+	mu   sync.Mutex
+	runs map[string]*clickToCallRun // correlation id
+	legs map[string]*clickToCallRun // agent / customer Call-ID
+}
+
+// clickToCallRun is the in-memory state of one unfinished click-to-call.
+type clickToCallRun struct {
+	id            uint
+	tenantID      uint
+	correlationID string
+	mode          string
+	origin        string
+	agentRoute    string // sip|web (agent mode)
+	agentTargetID uint
+
+	customer   outbound.DialRequest
+	scriptID   string
+	scriptSpec string
+	prompt     string
+
+	agentCallID    string
+	customerCallID string
+	agentUp        bool
+	customerUp     bool
+	done           bool
+}
+
+func NewClickToCallService(db *gorm.DB, dialer ClickToCallDialer, campaign *CampaignService, reg *persist.GormStore, hangupBridge func(callID string)) *ClickToCallService {
+	return &ClickToCallService{
+		db:           db,
+		dialer:       dialer,
+		campaign:     campaign,
+		reg:          reg,
+		hangupBridge: hangupBridge,
+		runs:         map[string]*clickToCallRun{},
+		legs:         map[string]*clickToCallRun{},
+	}
+}
+
+// SetLocalByePersist wires sip_calls finalization for web customer legs hung up from our side.
+func (s *ClickToCallService) SetLocalByePersist(fn func(callID string)) {
+	if s != nil {
+		s.persistLocalBye = fn
+	}
+}
+
+// SetAfterCallWork wires the ACW hook for SIP agents (see ACWService.Begin).
+func (s *ClickToCallService) SetAfterCallWork(fn func(callID string, targetID uint) bool) {
+	if s != nil {
+		s.afterCallWork = fn
+	}
+}
+
+func newClickToCallCorrelationID() string {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s%d", clickToCallCorrelationPrefix, time.Now().UnixNano())
+	}
+	return clickToCallCorrelationPrefix + hex.EncodeToString(b)
+}
+
+func clickToCallRingTimeout() time.Duration {
+	return time.Duration(utils.GetIntEnvWithDefault(envClickToCallRingTimeoutSec, defaultClickToCallRingTimeoutSec)) * time.Second
+}
+
+// Create validates the request, stores the row and starts the first leg.
+func (s *ClickToCallService) Create(ctx context.Context, in ClickToCallInput) (models.SIPClickToCall, error) {
+	if s == nil || s.db == nil || s.dialer == nil {
+		return models.SIPClickToCall{}, ErrClickToCallUnavailable
+	}
+	in.Mode = strings.ToLower(strings.TrimSpace(in.Mode))
+	in.Customer = strings.TrimSpace(in.Customer)
+	if in.Customer == "" {
+		return models.SIPClickToCall{}, errors.New("customer is required")
+	}
+	if in.Mode != constants.SIPClickToCallModeAI && in.Mode != constants.SIPClickToCallModeAgent {
+		return models.SIPClickToCall{}, fmt.Errorf("mode must be %s or %s", constants.SIPClickToCallModeAI, constants.SIPClickToCallModeAgent)
+	}
+	customer, err := s.customerDialRequest(ctx, in)
+	if err != nil {
+		return models.SIPClickToCall{}, err
+	}
+	row := models.SIPClickToCall{
+		TenantID:      in.TenantID,
+		CorrelationID: newClickToCallCorrelationID(),
+		ExternalID:    strings.TrimSpace(in.ExternalID),
+		Mode:          in.Mode,
+		Origin:        constants.SIPClickToCallOriginAPI,
+		Customer:      in.Customer,
+		CallerUser:    strings.TrimSpace(in.CallerUser),
+		TrunkGroupID:  in.TrunkGroupID,
+		Status:        constants.SIPClickToCallQueued,
+	}
+	row.CreateBy = in.CreateBy
+	run := &clickToCallRun{
+		tenantID:      in.TenantID,
+		correlationID: row.CorrelationID,
+		mode:          in.Mode,
+		origin:        row.Origin,
+		customer:      customer,
+	}
+	var agentTarget outbound.DialTarget
+	switch in.Mode {
+	case constants.SIPClickToCallModeAI:
+		run.customer.MediaProfile = outbound.MediaProfileAI
+		run.prompt = strings.TrimSpace(in.SystemPrompt)
+		if in.ScriptTemplateID > 0 {
+			tpl, err := models.GetActiveSIPScriptTemplateForTenant(s.db, in.ScriptTemplateID, in.TenantID)
+			if err != nil {
+				return models.SIPClickToCall{}, fmt.Errorf("script template %d not found", in.ScriptTemplateID)
+			}
+			if !tpl.Enabled {
+				return models.SIPClickToCall{}, fmt.Errorf("script template %d is disabled", in.ScriptTemplateID)
+			}
+			run.customer.MediaProfile = outbound.MediaProfileScript
+			run.scriptID = strings.TrimSpace(tpl.ScriptID)
+			run.scriptSpec = strings.TrimSpace(string(tpl.ScriptSpec))
+			run.customer.ScriptID = run.scriptID
+			row.ScriptTemplateID = tpl.ID
+		}
+		row.SystemPrompt = run.prompt
+	case constants.SIPClickToCallModeAgent:
+		acd, err := s.resolveAgent(ctx, in)
+		if err != nil {
+			return models.SIPClickToCall{}, err
+		}
+		run.agentTargetID = acd.ID
+		run.agentRoute = acd.RouteType
+		row.AgentTargetID = acd.ID
+		row.AgentUser = strings.TrimSpace(in.AgentUser)
+		row.AgentRouteType = acd.RouteType
+		if acd.RouteType == constants.ACDPoolRouteTypeWeb {
+			run.customer.MediaProfile = outbound.MediaProfileNone
+			run.customer.EarlyMedia = true
+		} else {
+			agentTarget, err = s.agentDialTarget(ctx, acd)
+			if err != nil {
+				return models.SIPClickToCall{}, err
+			}
+			run.customer.MediaProfile = outbound.MediaProfileTransferBridge
+		}
+	}
+	row.MediaProfile = string(run.customer.MediaProfile)
+	return s.launch(ctx, row, run, agentTarget)
+}
+
+// launch stores the row and starts the first leg.
+func (s *ClickToCallService) launch(ctx context.Context, row models.SIPClickToCall, run *clickToCallRun, agentTarget outbound.DialTarget) (models.SIPClickToCall, error) {
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return models.SIPClickToCall{}, err
+	}
+	run.id = row.ID
+	s.mu.Lock()
+	s.runs[run.correlationID] = run
+	s.mu.Unlock()
+	s.appendEvent(run.id, "", clickToCallLegAPI, constants.SIPClickToCallQueued, 0, "created mode="+run.mode+" origin="+run.origin)
+
+	switch {
+	case run.mode == constants.SIPClickToCallModeAI:
+		s.dialCustomer(run)
+	case run.agentRoute == constants.ACDPoolRouteTypeWeb:
+		s.offerWebAgent(run)
+	default:
+		s.dialAgent(run, agentTarget)
+	}
+	return s.reload(ctx, row)
+}
+
+// WebSeatDialInput is a customer call placed by a web-seat agent from the browser.
+type WebSeatDialInput struct {
+	TenantID uint
+	// TargetID is the agent's web acd_pool_targets row (the one it heartbeats).
+	TargetID   uint
+	Operator   string
+	Customer   string
+	RequestURI string
+	ExternalID string
+}
+
+// DialFromWebSeat registers the agent's browser under a new correlation id and
+// dials the customer once it joined (POST /webseat/v1/join with call_id =
+// correlationId). The customer sees the agent's caller ID: the row's
+// sipCallerId when set, else its trunk number, which also picks the trunk.
+func (s *ClickToCallService) DialFromWebSeat(ctx context.Context, in WebSeatDialInput) (models.SIPClickToCall, error) {
+	if s == nil || s.db == nil || s.dialer == nil {
+		return models.SIPClickToCall{}, ErrClickToCallUnavailable
+	}
+	in.Customer = strings.TrimSpace(in.Customer)
+	if in.Customer == "" {
+		return models.SIPClickToCall{}, errors.New("customer is required")
+	}
+	acd, err := models.GetActiveACDPoolTargetByID(s.db, in.TargetID)
+	if err != nil || acd.TenantID != in.TenantID || acd.RouteType != constants.ACDPoolRouteTypeWeb {
+		return models.SIPClickToCall{}, ErrClickToCallAgentMissing
+	}
+	if !models.WebSeatActorMayTouchRow(acd, in.Operator) {
+		return models.SIPClickToCall{}, ErrClickToCallAgentMissing
+	}
+	if models.NormalizeACDWorkState(acd.WorkState) != constants.ACDWorkStateAvailable || !models.WebSeatLastSeenFresh(acd.WebSeatLastSeenAt) {
+		return models.SIPClickToCall{}, ErrClickToCallAgentBusy
+	}
+	if acd.TrunkNumberID == 0 {
+		return models.SIPClickToCall{}, ErrClickToCallNoTrunk
+	}
+	num, err := models.GetTrunkNumberByIDForTenant(s.db, acd.TrunkNumberID, in.TenantID)
+	if err != nil || strings.TrimSpace(num.Number) == "" {
+		return models.SIPClickToCall{}, ErrClickToCallNoTrunk
+	}
+	customer, err := s.customerDialRequest(ctx, ClickToCallInput{
+		TenantID:   in.TenantID,
+		Customer:   in.Customer,
+		CallerUser: strings.TrimSpace(num.Number),
+		RequestURI: in.RequestURI,
+	})
+	if err != nil {
+		return models.SIPClickToCall{}, err
+	}
+	customer.Scenario = outbound.ScenarioWebSeatOutbound
+	customer.MediaProfile = outbound.MediaProfileNone
+	customer.EarlyMedia = true
+	if cid := strings.TrimSpace(acd.SipCallerID); cid != "" {
+		customer.CallerUser = cid
+		customer.CallerDisplayName = strings.TrimSpace(acd.SipCallerDisplayName)
+	}
+	row := models.SIPClickToCall{
+		TenantID:       in.TenantID,
+		CorrelationID:  newClickToCallCorrelationID(),
+		ExternalID:     strings.TrimSpace(in.ExternalID),
+		Mode:           constants.SIPClickToCallModeAgent,
+		Origin:         constants.SIPClickToCallOriginWebSeat,
+		MediaProfile:   string(customer.MediaProfile),
+		Customer:       in.Customer,
+		CallerUser:     customer.CallerUser,
+		AgentTargetID:  acd.ID,
+		AgentRouteType: acd.RouteType,
+		Status:         constants.SIPClickToCallQueued,
+	}
+	row.CreateBy = in.Operator
+	run := &clickToCallRun{
+		tenantID:      in.TenantID,
+		correlationID: row.CorrelationID,
+		mode:          row.Mode,
+		origin:        row.Origin,
+		agentRoute:    acd.RouteType,
+		agentTargetID: acd.ID,
+		customer:      customer,
+	}
+	return s.launch(ctx, row, run, outbound.DialTarget{})
+}
+
+func (s *ClickToCallService) reload(ctx context.Context, row models.SIPClickToCall) (models.SIPClickToCall, error) {
+	if cur, err := models.GetSIPClickToCall(ctx, s.db, 0, row.ID); err == nil {
+		return cur, nil
+	}
+	return row, nil
+}
+
+// customerDialRequest builds the customer leg like a one-contact campaign:
+// trunk group, least-cost route or the caller trunk number, and the latest
+// REGISTER binding for extension-style callees.
+func (s *ClickToCallService) customerDialRequest(ctx context.Context, in ClickToCallInput) (outbound.DialRequest, error) {
+	req := outbound.DialRequest{
+		Scenario:     outbound.ScenarioClickToCall,
+		CallerUser:   strings.TrimSpace(in.CallerUser),
+		DialTenantID: in.TenantID,
+	}
+	if shouldResolveFromRegister(in.Customer) {
+		if s.campaign == nil {
+			return req, errors.New("customer extension lookup unavailable")
+		}
+		t, ok := s.campaign.resolveRegisteredDialTarget(ctx, in.Customer)
+		if !ok {
+			return req, fmt.Errorf("customer %q is not registered", in.Customer)
+		}
+		req.Target = t
+		if req.CallerUser == "" {
+			req.CallerUser = t.CallerUser
+		}
+		return req, nil
+	}
+	if s.campaign == nil {
+		return req, errors.New("outbound routing unavailable")
+	}
+	c := models.SIPCampaign{TenantID: in.TenantID, TrunkGroupID: in.TrunkGroupID}
+	ct := models.SIPCampaignContact{Phone: in.Customer, RequestURI: strings.TrimSpace(in.RequestURI), CallerUser: req.CallerUser}
+	target, fallbacks, grouped, err := s.campaign.buildDialPlan(c, ct)
+	if err != nil {
+		return req, err
+	}
+	req.Target = target
+	req.Fallbacks = fallbacks
+	if grouped {
+		req.CallerUser = ""
+	} else {
+		req.CallerDisplayName = strings.TrimSpace(target.CallerDisplayName)
+	}
+	return req, nil
+}
+
+// resolveAgent returns the tenant's ACD row for the agent; it must be available right now.
+func (s *ClickToCallService) resolveAgent(ctx context.Context, in ClickToCallInput) (models.ACDPoolTarget, error) {
+	var row models.ACDPoolTarget
+	switch {
+	case in.AgentTargetID > 0:
+		r, err := models.GetActiveACDPoolTargetByID(s.db, in.AgentTargetID)
+		if err != nil || r.TenantID != in.TenantID {
+			return row, ErrClickToCallAgentMissing
+		}
+		row = r
+	case strings.TrimSpace(in.AgentUser) != "":
+		r, ok, err := models.FindSIPACDPoolTargetForIncomingPoll(s.db, in.TenantID, 0, "", strings.TrimSpace(in.AgentUser))
+		if err != nil {
+			return row, err
+		}
+		if !ok {
+			return row, ErrClickToCallAgentMissing
+		}
+		row = r
+	default:
+		return row, errors.New("agentTargetId or agentUser is required in agent mode")
+	}
+	if models.NormalizeACDWorkState(row.WorkState) != constants.ACDWorkStateAvailable {
+		return row, ErrClickToCallAgentBusy
+	}
+	if row.RouteType == constants.ACDPoolRouteTypeWeb && !models.WebSeatLastSeenFresh(row.WebSeatLastSeenAt) {
+		return row, ErrClickToCallAgentBusy
+	}
+	return row, nil
+}
+
+// agentDialTarget resolves a SIP ACD row the way transfer does: registered
+// internal user, or the row's trunk fields with the tenant transfer trunk as fallback.
+func (s *ClickToCallService) agentDialTarget(ctx context.Context, row models.ACDPoolTarget) (outbound.DialTarget, error) {
+	var dt outbound.DialTarget
+	var callerUser, callerDisplay string
+	if tc, ok := models.PickTrunkTransferConfig(s.db, row.TenantID); ok {
+		callerUser, callerDisplay = tc.CallerUser, tc.CallerDisplay
+		if strings.EqualFold(strings.TrimSpace(row.SipSource), constants.ACDSipSourceTrunk) {
+			host, port, sig := row.SipTrunkHost, row.SipTrunkPort, row.SipTrunkSignalingAddr
+			if strings.TrimSpace(host) == "" {
+				host = tc.Host
+				if port <= 0 {
+					port = tc.Port
+				}
+				if strings.TrimSpace(sig) == "" {
+					sig = tc.SignalingAddr()
+				}
+			}
+			row.SipTrunkHost, row.SipTrunkPort, row.SipTrunkSignalingAddr = host, port, sig
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(row.SipSource), constants.ACDSipSourceTrunk) {
+		t, ok := outbound.DialTargetFromACDTrunk(row.TargetValue, row.SipTrunkHost, row.SipTrunkSignalingAddr, row.SipTrunkPort)
+		if !ok {
+			return dt, errors.New("agent trunk route is incomplete")
+		}
+		dt = t
+	} else {
+		u := strings.TrimSpace(row.TargetValue)
+		if s.reg == nil || u == "" {
+			return dt, ErrClickToCallAgentMissing
+		}
+		t, ok := s.reg.DialTargetForUsername(ctx, u)
+		if !ok {
+			return dt, fmt.Errorf("agent %q is not registered", u)
+		}
+		dt = t
+	}
+	dt.CallerUser = emptyOr(strings.TrimSpace(row.SipCallerID), callerUser)
+	dt.CallerDisplayName = emptyOr(strings.TrimSpace(row.SipCallerDisplayName), callerDisplay)
+	dt.ACDPoolTargetID = row.ID
+	return dt, nil
+}
+
+func (s *ClickToCallService) setACDWorkState(run *clickToCallRun, state string) {
+	if run.agentTargetID == 0 || run.agentRoute == constants.ACDPoolRouteTypeWeb {
+		return // web seats follow the webseat hub binding
+	}
+	_ = models.UpdateACDPoolTargetWorkState(context.Background(), s.db, run.agentTargetID, state, "click-to-call")
+}
+
+func (s *ClickToCallService) dialAgent(run *clickToCallRun, target outbound.DialTarget) {
+	s.setACDWorkState(run, constants.ACDWorkStateRinging)
+	req := outbound.DialRequest{
+		Scenario:      outbound.ScenarioClickToCall,
+		Target:        target,
+		CorrelationID: run.correlationID,
+		MediaProfile:  outbound.MediaProfileNone,
+		CallerUser:    target.CallerUser,
+		// Agent sees who is about to be called.
+		CallerDisplayName: run.customerDisplay(),
+		DialTenantID:      run.tenantID,
+	}
+	callID, err := s.dialer.Dial(context.Background(), req)
+	if err != nil {
+		s.finish(run, constants.SIPClickToCallFailed, "agent_dial_error: "+err.Error(), 0)
+		return
+	}
+	if !s.bindLeg(run, callID, clickToCallLegAgent) {
+		s.hangupLeg(callID)
+		return
+	}
+	s.armRingTimeout(run, callID, clickToCallLegAgent)
+}
+
+func (r *clickToCallRun) customerDisplay() string {
+	if u := strings.TrimSpace(r.customer.Target.RequestURI); u != "" {
+		if user, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(u, "sips:"), "sip:"), "@"); ok {
+			return user
+		}
+	}
+	return ""
+}
+
+func (s *ClickToCallService) dialCustomer(run *clickToCallRun) {
+	req := run.customer
+	s.mu.Lock()
+	if run.done {
+		s.mu.Unlock()
+		return
+	}
+	req.CorrelationID = run.correlationID
+	if run.mode == constants.SIPClickToCallModeAgent && run.agentRoute != constants.ACDPoolRouteTypeWeb {
+		// StartTransferBridge pairs the customer with the agent leg by this id.
+		req.CorrelationID = run.agentCallID
+	}
+	s.mu.Unlock()
+	callID, err := s.dialer.Dial(context.Background(), req)
+	if err != nil {
+		s.finish(run, constants.SIPClickToCallFailed, "customer_dial_error: "+err.Error(), 0)
+		s.hangupAgent(run)
+		return
+	}
+	if !s.bindLeg(run, callID, clickToCallLegCustomer) {
+		// Canceled or failed while Dial ran: nobody else knows this leg.
+		s.hangupLeg(callID)
+		return
+	}
+	if run.prompt != "" {
+		conversation.SetSIPCallSystemPrompt(callID, run.prompt)
+	}
+	if req.MediaProfile == outbound.MediaProfileScript {
+		conversation.MarkSIPScriptMode(callID)
+	}
+	s.armRingTimeout(run, callID, clickToCallLegCustomer)
+}
+
+func (s *ClickToCallService) offerWebAgent(run *clickToCallRun) {
+	_ = models.UpdateACDPoolTargetWorkState(context.Background(), s.db, run.agentTargetID, constants.ACDWorkStateRinging, "click-to-call")
+	var err error
+	if run.origin == constants.SIPClickToCallOriginWebSeat {
+		err = webseat.RegisterOutbound(run.correlationID, run.agentTargetID, s.webAgentHooks(run))
+	} else {
+		err = webseat.OfferOutbound(run.correlationID, run.agentTargetID, run.customerDisplay(), s.webAgentHooks(run))
+	}
+	if err != nil {
+		_ = models.UpdateACDPoolTargetWorkState(context.Background(), s.db, run.agentTargetID, constants.ACDWorkStateAvailable, "click-to-call")
+		s.finish(run, constants.SIPClickToCallFailed, err.Error(), 0)
+		return
+	}
+	s.setStatus(run, constants.SIPClickToCallAgentRinging, nil)
+}
+
+func (s *ClickToCallService) webAgentHooks(run *clickToCallRun) webseat.OutboundHooks {
+	return webseat.OutboundHooks{
+		OnAgentReady: func(string) {
+			s.mu.Lock()
+			run.agentUp = true
+			s.mu.Unlock()
+			s.setStatus(run, constants.SIPClickToCallAgentAnswered, nil)
+			s.appendEvent(run.id, "", clickToCallLegAgent, outbound.DialEventEstablished, 0, "web seat joined")
+			s.dialCustomer(run)
+		},
+		HangupPeer: func(string) {
+			s.mu.Lock()
+			callID, answered := run.customerCallID, run.customerUp
+			s.mu.Unlock()
+			if answered && callID != "" && s.persistLocalBye != nil {
+				s.persistLocalBye(callID)
+			}
+			s.hangupLeg(callID)
+		},
+		OnEnded: func(_ string, reason string) {
+			s.mu.Lock()
+			answered := run.customerUp
+			agentUp := run.agentUp
+			s.mu.Unlock()
+			switch {
+			case answered:
+				s.finish(run, constants.SIPClickToCallCompleted, "", 0)
+			case agentUp:
+				s.finish(run, constants.SIPClickToCallCanceled, "agent hung up before customer answered", 0)
+			default:
+				s.finish(run, constants.SIPClickToCallFailed, "agent_"+reason, 0)
+			}
+		},
+	}
+}
+
+// bindLeg records a Call-ID returned by Dial (the invited event may have done
+// it already). False when the run finished meanwhile: the caller owns the
+// leg and must hang it up.
+func (s *ClickToCallService) bindLeg(run *clickToCallRun, callID, leg string) bool {
+	callID = strings.TrimSpace(callID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run.done {
+		return false
+	}
+	if callID == "" {
+		return true
+	}
+	if leg == clickToCallLegAgent {
+		run.agentCallID = callID
+	} else {
+		run.customerCallID = callID
+	}
+	s.legs[callID] = run
+	return true
+}
+
+func (s *ClickToCallService) armRingTimeout(run *clickToCallRun, callID, leg string) {
+	time.AfterFunc(clickToCallRingTimeout(), func() {
+		s.mu.Lock()
+		up := run.customerUp
+		if leg == clickToCallLegAgent {
+			up = run.agentUp
+		}
+		pending := !run.done && !up
+		s.mu.Unlock()
+		if !pending {
+			return
+		}
+		s.appendEvent(run.id, callID, leg, "ring_timeout", 0, "no answer, sending CANCEL")
+		_ = s.dialer.SendCANCEL(callID)
+	})
+}
+
+// lookup maps a dial event to its run and leg. Events may arrive before Dial
+// returns the Call-ID, so unknown Call-IDs are matched by correlation id.
+func (s *ClickToCallService) lookup(evt outbound.DialEvent) (*clickToCallRun, string) {
+	callID := strings.TrimSpace(evt.CallID)
+	corr := strings.TrimSpace(evt.CorrelationID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run := s.legs[callID]; run != nil && callID != "" {
+		if callID == run.agentCallID {
+			return run, clickToCallLegAgent
+		}
+		return run, clickToCallLegCustomer
+	}
+	run, leg := s.runs[corr], clickToCallLegCustomer
+	if run != nil {
+		if run.mode == constants.SIPClickToCallModeAgent && run.agentRoute != constants.ACDPoolRouteTypeWeb {
+			leg = clickToCallLegAgent
+		}
+	} else if run = s.legs[corr]; run == nil || corr != run.agentCallID {
+		return nil, ""
+	}
+	if callID != "" {
+		s.legs[callID] = run
+		if leg == clickToCallLegAgent {
+			run.agentCallID = callID
+		} else {
+			run.customerCallID = callID
+		}
+	}
+	return run, leg
+}
+
+func clickToCallScenario(sc outbound.Scenario) bool {
+	return sc == outbound.ScenarioClickToCall || sc == outbound.ScenarioWebSeatOutbound
+}
+
+// HandleDialEvent folds outbound dial progress of click-to-call legs into the row and its events.
+func (s *ClickToCallService) HandleDialEvent(evt outbound.DialEvent) {
+	if s == nil || s.db == nil || !clickToCallScenario(evt.Scenario) {
+		return
+	}
+	run, leg := s.lookup(evt)
+	if run == nil {
+		return
+	}
+	msg := strings.TrimSpace(strings.TrimSpace(evt.StatusText) + " " + strings.TrimSpace(evt.Reason))
+	s.appendEvent(run.id, evt.CallID, leg, evt.State, evt.StatusCode, msg)
+	if run.agentRoute == constants.ACDPoolRouteTypeWeb && leg == clickToCallLegCustomer {
+		webseat.NotifyOutboundProgress(run.correlationID, evt.State, evt.StatusCode)
+	}
+	switch evt.State {
+	case outbound.DialEventInvited:
+		if leg == clickToCallLegAgent {
+			s.setStatus(run, constants.SIPClickToCallAgentRinging, map[string]any{"agent_call_id": evt.CallID})
+		} else {
+			s.setStatus(run, constants.SIPClickToCallCustomerRinging, map[string]any{"customer_call_id": evt.CallID})
+		}
+	case outbound.DialEventEstablished:
+		s.onEstablished(run, leg)
+	case outbound.DialEventFailed:
+		if leg == clickToCallLegAgent {
+			s.finish(run, constants.SIPClickToCallFailed, "agent_"+emptyOr(evt.Reason, "no_answer"), evt.StatusCode)
+			return
+		}
+		s.finish(run, constants.SIPClickToCallFailed, "customer_"+emptyOr(evt.Reason, "no_answer"), evt.StatusCode)
+		s.hangupAgent(run)
+	case outbound.DialEventEnded:
+		s.mu.Lock()
+		answered := run.customerUp
+		s.mu.Unlock()
+		if answered {
+			s.finish(run, constants.SIPClickToCallCompleted, "", 0)
+		} else {
+			s.finish(run, constants.SIPClickToCallCanceled, leg+" hung up before customer answered", 0)
+		}
+		if leg == clickToCallLegCustomer && run.agentRoute == constants.ACDPoolRouteTypeWeb {
+			webseat.HangupIfCustomerBye(run.correlationID)
+		}
+		if leg == clickToCallLegAgent && !answered {
+			s.mu.Lock()
+			callID := run.customerCallID
+			s.mu.Unlock()
+			s.hangupLeg(callID)
+		}
+	}
+}
+
+func (s *ClickToCallService) onEstablished(run *clickToCallRun, leg string) {
+	now := time.Now()
+	s.mu.Lock()
+	if leg == clickToCallLegAgent {
+		run.agentUp = true
+	} else {
+		run.customerUp = true
+	}
+	s.mu.Unlock()
+	if leg == clickToCallLegAgent {
+		s.setACDWorkState(run, constants.ACDWorkStateBusy)
+		s.setStatus(run, constants.SIPClickToCallAgentAnswered, nil)
+		// Not on the SIP response path: Dial writes the INVITE and emits events.
+		logger.SafeGo("click-to-call-dial-customer", func() { s.dialCustomer(run) })
+		return
+	}
+	status := constants.SIPClickToCallAnswered
+	if run.mode == constants.SIPClickToCallModeAgent {
+		status = constants.SIPClickToCallBridged
+	}
+	s.setStatus(run, status, map[string]any{"answered_at": &now})
+}
+
+// OnEstablished hands an answered customer leg to the web seat waiting for it.
+func (s *ClickToCallService) OnEstablished(leg outbound.EstablishedLeg) {
+	if s == nil || !clickToCallScenario(leg.Scenario) {
+		return
+	}
+	s.mu.Lock()
+	run := s.legs[strings.TrimSpace(leg.CallID)]
+	s.mu.Unlock()
+	if run == nil || run.agentRoute != constants.ACDPoolRouteTypeWeb || leg.CallID == run.agentCallID {
+		return
+	}
+	if err := webseat.AttachOutboundPeer(run.correlationID, leg.Session); err != nil {
+		logger.Warn("click-to-call: web seat bridge failed", zap.String("call_id", leg.CallID), zap.Error(err))
+		s.finish(run, constants.SIPClickToCallFailed, err.Error(), 0)
+		s.hangupLeg(leg.CallID)
+		webseat.HangupFull(run.correlationID)
+	}
+}
+
+// OnEarlyMedia plays a web customer leg's ringback / announcements to the agent's browser.
+func (s *ClickToCallService) OnEarlyMedia(leg outbound.EstablishedLeg) {
+	if s == nil || !clickToCallScenario(leg.Scenario) {
+		return
+	}
+	s.mu.Lock()
+	run := s.legs[strings.TrimSpace(leg.CallID)]
+	if run == nil {
+		run = s.runs[strings.TrimSpace(leg.CorrelationID)]
+	}
+	s.mu.Unlock()
+	if run == nil || run.agentRoute != constants.ACDPoolRouteTypeWeb || leg.CallID == run.agentCallID {
+		return
+	}
+	s.appendEvent(run.id, leg.CallID, clickToCallLegCustomer, "early_media", 0, leg.Session.NegotiatedCodec().Name)
+	if err := webseat.AttachOutboundEarlyMedia(run.correlationID, leg.Session); err != nil {
+		logger.Warn("click-to-call: early media bridge failed", zap.String("call_id", leg.CallID), zap.Error(err))
+	}
+}
+
+// AgentForCall returns the acd_pool_targets.id bridged to a live customer leg (sip_calls attribution).
+func (s *ClickToCallService) AgentForCall(callID string) (uint, bool) {
+	if s == nil {
+		return 0, false
+	}
+	callID = strings.TrimSpace(callID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := s.legs[callID]
+	if run == nil || run.agentTargetID == 0 || callID != run.customerCallID {
+		return 0, false
+	}
+	return run.agentTargetID, true
+}
+
+// RunScript runs the template script of an AI click-to-call leg; false when the
+// leg is not a click-to-call (the campaign runner handles it instead).
+func (s *ClickToCallService) RunScript(ctx context.Context, leg outbound.EstablishedLeg, scriptID string) bool {
+	if s == nil || leg.Scenario != outbound.ScenarioClickToCall {
+		return false
+	}
+	s.mu.Lock()
+	run := s.runs[strings.TrimSpace(leg.CorrelationID)]
+	s.mu.Unlock()
+	if run == nil || run.scriptSpec == "" || s.campaign == nil ||
+		!s.campaign.runScriptSpec(ctx, leg, run.scriptSpec, emptyOr(scriptID, run.scriptID), run.tenantID, nil) {
+		conversation.ClearSIPScriptMode(leg.CallID)
+	}
+	return true
+}
+
+// TenantForCorrelation returns the tenant of a live click-to-call leg (sip_calls attribution).
+func (s *ClickToCallService) TenantForCorrelation(correlationID string) (uint, bool) {
+	if s == nil {
+		return 0, false
+	}
+	correlationID = strings.TrimSpace(correlationID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run := s.runs[correlationID]; run != nil {
+		return run.tenantID, true
+	}
+	if run := s.legs[correlationID]; run != nil {
+		return run.tenantID, true
+	}
+	return 0, false
+}
+
+// CustomerCallForCorrelation returns the customer leg Call-ID of a live click-to-call.
+func (s *ClickToCallService) CustomerCallForCorrelation(correlationID string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := s.runs[strings.TrimSpace(correlationID)]
+	if run == nil || run.customerCallID == "" {
+		return "", false
+	}
+	return run.customerCallID, true
+}
+
+// AdoptCallID follows an outbound leg whose dialog Call-ID was rewritten.
+func (s *ClickToCallService) AdoptCallID(oldID, newID string) {
+	if s == nil || oldID == "" || newID == "" || oldID == newID {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := s.legs[oldID]
+	if run == nil {
+		return
+	}
+	delete(s.legs, oldID)
+	s.legs[newID] = run
+	if run.agentCallID == oldID {
+		run.agentCallID = newID
+	}
+	if run.customerCallID == oldID {
+		run.customerCallID = newID
+	}
+}
+
+// Cancel stops a click-to-call in any non-final state: ringing legs get
+// CANCEL, answered legs BYE (a bridged pair is torn down together).
+func (s *ClickToCallService) Cancel(ctx context.Context, tenantID, id uint, operator string) (models.SIPClickToCall, error) {
+	if s == nil || s.db == nil {
+		return models.SIPClickToCall{}, ErrClickToCallUnavailable
+	}
+	row, err := models.GetSIPClickToCall(ctx, s.db, tenantID, id)
+	if err != nil {
+		return row, ErrClickToCallNotFound
+	}
+	if models.SIPClickToCallTerminal(row.Status) {
+		return row, ErrClickToCallFinished
+	}
+	s.mu.Lock()
+	run := s.runs[row.CorrelationID]
+	s.mu.Unlock()
+	reason := "canceled by " + emptyOr(strings.TrimSpace(operator), "api")
+	if run == nil {
+		// Not owned by this process (restart): just close the row.
+		now := time.Now()
+		err := s.db.WithContext(ctx).Model(&models.SIPClickToCall{}).Where("id = ?", row.ID).Updates(map[string]any{
+			"status": constants.SIPClickToCallCanceled, "failure_reason": reason, "ended_at": &now,
+		}).Error
+		s.appendEvent(row.ID, "", clickToCallLegAPI, constants.SIPClickToCallCanceled, 0, reason)
+		if err != nil {
+			return row, err
+		}
+		return s.reload(ctx, row)
+	}
+	s.mu.Lock()
+	agentCallID, customerCallID := run.agentCallID, run.customerCallID
+	bridged := run.agentUp && run.customerUp && run.agentRoute == constants.ACDPoolRouteTypeSIP
+	s.mu.Unlock()
+	s.finish(run, constants.SIPClickToCallCanceled, reason, 0)
+	switch {
+	case run.agentRoute == constants.ACDPoolRouteTypeWeb:
+		if !webseat.HangupFull(run.correlationID) {
+			s.hangupLeg(customerCallID)
+		}
+	case bridged && s.hangupBridge != nil:
+		s.hangupBridge(agentCallID)
+	default:
+		s.hangupLeg(customerCallID)
+		s.hangupLeg(agentCallID)
+	}
+	return s.reload(ctx, row)
+}
+
+// FailOrphaned marks every unfinished row failed. Called once at startup,
+// before any run is created: legs of the previous process are gone, so
+// polling clients would otherwise see a ringing state forever.
+func (s *ClickToCallService) FailOrphaned(ctx context.Context) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, nil
+	}
+	const reason = "server_restart"
+	ids, err := models.FailUnfinishedSIPClickToCalls(ctx, s.db, reason, time.Now())
+	for _, id := range ids {
+		s.appendEvent(id, "", clickToCallLegAPI, constants.SIPClickToCallFailed, 0, reason)
+	}
+	return len(ids), err
+}
+
+func (s *ClickToCallService) hangupAgent(run *clickToCallRun) {
+	if run.mode != constants.SIPClickToCallModeAgent {
+		return
+	}
+	if run.agentRoute == constants.ACDPoolRouteTypeWeb {
+		webseat.HangupFull(run.correlationID)
+		return
+	}
+	s.mu.Lock()
+	callID := run.agentCallID
+	s.mu.Unlock()
+	s.hangupLeg(callID)
+}
+
+// hangupLeg ends one outbound leg whatever its state: CANCEL while ringing, else BYE.
+func (s *ClickToCallService) hangupLeg(callID string) {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return
+	}
+	if err := s.dialer.SendCANCEL(callID); err != nil {
+		_ = s.dialer.SendBYE(callID)
+	}
+}
+
+func (s *ClickToCallService) setStatus(run *clickToCallRun, status string, extra map[string]any) {
+	s.mu.Lock()
+	done := run.done
+	s.mu.Unlock()
+	if done {
+		return
+	}
+	updates := map[string]any{"status": status}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	if err := s.db.Model(&models.SIPClickToCall{}).Where("id = ?", run.id).Updates(updates).Error; err != nil {
+		logger.Warn("click-to-call: status update failed", zap.Uint("id", run.id), zap.Error(err))
+	}
+}
+
+// finish moves the run to a final status once and forgets it.
+func (s *ClickToCallService) finish(run *clickToCallRun, status, reason string, code int) {
+	s.mu.Lock()
+	if run.done {
+		s.mu.Unlock()
+		return
+	}
+	run.done = true
+	delete(s.runs, run.correlationID)
+	for _, id := range []string{run.agentCallID, run.customerCallID} {
+		if id != "" && s.legs[id] == run {
+			delete(s.legs, id)
+		}
+	}
+	customerCallID := run.customerCallID
+	talked := run.agentUp && run.customerUp
+	s.mu.Unlock()
+	if run.prompt != "" {
+		conversation.SetSIPCallSystemPrompt(customerCallID, "")
+	}
+	if !talked || run.agentRoute == constants.ACDPoolRouteTypeWeb || s.afterCallWork == nil ||
+		!s.afterCallWork(customerCallID, run.agentTargetID) {
+		s.setACDWorkState(run, constants.ACDWorkStateAvailable)
+	}
+	now := time.Now()
+	updates := map[string]any{"status": status, "ended_at": &now}
+	if reason != "" {
+		updates["failure_reason"] = reason
+	}
+	if code > 0 {
+		updates["sip_status_code"] = code
+	}
+	if err := s.db.Model(&models.SIPClickToCall{}).Where("id = ?", run.id).Updates(updates).Error; err != nil {
+		logger.Warn("click-to-call: finish update failed", zap.Uint("id", run.id), zap.Error(err))
+	}
+	s.appendEvent(run.id, "", clickToCallLegAPI, status, code, reason)
+}
+
+func (s *ClickToCallService) appendEvent(id uint, callID, leg, state string, code int, msg string) {
+	if s.db == nil || id == 0 {
+		return
+	}
+	ev := models.SIPClickToCallEvent{
+		ClickToCallID: id,
+		CallID:        strings.TrimSpace(callID),
+		Leg:           leg,
+		State:         state,
+		StatusCode:    code,
+		Message:       strings.TrimSpace(msg),
+	}
+	if err := s.db.Create(&ev).Error; err != nil {
+		logger.Warn("click-to-call: event insert failed", zap.Uint("id", id), zap.Error(err))
+	}
+}
+
+// Get / List / Events are the status-polling reads.
+func (s *ClickToCallService) Get(ctx context.Context, tenantID, id uint) (models.SIPClickToCall, error) {
+	if s == nil || s.db == nil {
+		return models.SIPClickToCall{}, ErrClickToCallUnavailable
+	}
+	row, err := models.GetSIPClickToCall(ctx, s.db, tenantID, id)
+	if err != nil {
+		return row, ErrClickToCallNotFound
+	}
+	return row, nil
+}
+
+func (s *ClickToCallService) List(ctx context.Context, f models.SIPClickToCallListFilter, page, size int) ([]models.SIPClickToCall, int64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, ErrClickToCallUnavailable
+	}
+	return models.ListSIPClickToCalls(ctx, s.db, f, page, size)
+}
+
+func (s *ClickToCallService) Events(ctx context.Context, tenantID, id uint) ([]models.SIPClickToCallEvent, error) {
+	if _, err := s.Get(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+	return models.ListSIPClickToCallEvents(ctx, s.db, id)
+}
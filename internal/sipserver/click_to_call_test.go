@@ -0,0 +1,446 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package sipserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+)
+
+// fakeC2CDialer hands out leg-1, leg-2, … and records hangups.
+// beforeReturn runs inside Dial, after the Call-ID exists but before the
+// caller sees it (dial events and cancels racing the INVITE).
+type fakeC2CDialer struct {
+	mu           sync.Mutex
+	dials        []outbound.DialRequest
+	cancels      []string
+	byes         []string
+	beforeReturn func(n int, req outbound.DialRequest, callID string)
+}
+
+func (d *fakeC2CDialer) Dial(_ context.Context, req outbound.DialRequest) (string, error) {
+	d.mu.Lock()
+	d.dials = append(d.dials, req)
+	n := len(d.dials)
+	hook := d.beforeReturn
+	d.mu.Unlock()
+	callID := fmt.Sprintf("leg-%d", n)
+	if hook != nil {
+		hook(n, req, callID)
+	}
+	return callID, nil
+}
+
+func (d *fakeC2CDialer) SendCANCEL(callID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cancels = append(d.cancels, callID)
+	return nil
+}
+
+func (d *fakeC2CDialer) SendBYE(callID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byes = append(d.byes, callID)
+	return nil
+}
+
+func (d *fakeC2CDialer) snapshot() (dials int, cancels []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.dials), append([]string(nil), d.cancels...)
+}
+
+type c2cHarness struct {
+	t       *testing.T
+	s       *ClickToCallService
+	dialer  *fakeC2CDialer
+	bridged []string
+	run     *clickToCallRun
+	rowID   uint
+}
+
+func newC2CHarness(t *testing.T) *c2cHarness {
+	t.Helper()
+	t.Setenv(envClickToCallRingTimeoutSec, "3600")
+	db := openTestDB(t, &models.SIPClickToCall{}, &models.SIPClickToCallEvent{})
+	h := &c2cHarness{t: t, dialer: &fakeC2CDialer{}}
+	h.s = NewClickToCallService(db, h.dialer, nil, nil, func(callID string) {
+		h.bridged = append(h.bridged, callID)
+	})
+	return h
+}
+
+// start launches a run like Create does, without agent / routing lookups:
+// mode agent rings a SIP seat first, mode ai dials the customer directly.
+func (h *c2cHarness) start(mode string) {
+	h.t.Helper()
+	row := models.SIPClickToCall{
+		TenantID:      1,
+		CorrelationID: newClickToCallCorrelationID(),
+		Mode:          mode,
+		Origin:        constants.SIPClickToCallOriginAPI,
+		Customer:      "13800000000",
+		Status:        constants.SIPClickToCallQueued,
+	}
+	run := &clickToCallRun{
+		tenantID:      1,
+		correlationID: row.CorrelationID,
+		mode:          mode,
+		origin:        row.Origin,
+		customer: outbound.DialRequest{
+			Scenario:     outbound.ScenarioClickToCall,
+			MediaProfile: outbound.MediaProfileAI,
+			Target:       outbound.DialTarget{RequestURI: "sip:13800000000@carrier.example"},
+		},
+	}
+	if mode == constants.SIPClickToCallModeAgent {
+		run.agentRoute = constants.ACDPoolRouteTypeSIP
+		run.customer.MediaProfile = outbound.MediaProfileTransferBridge
+	}
+	got, err := h.s.launch(context.Background(), row, run, outbound.DialTarget{RequestURI: "sip:1001@pbx.example"})
+	if err != nil {
+		h.t.Fatalf("launch: %v", err)
+	}
+	h.run, h.rowID = run, got.ID
+}
+
+func (h *c2cHarness) event(callID, corr, state string, code int, reason string) {
+	h.s.HandleDialEvent(outbound.DialEvent{
+		CallID:        callID,
+		CorrelationID: corr,
+		Scenario:      outbound.ScenarioClickToCall,
+		State:         state,
+		StatusCode:    code,
+		Reason:        reason,
+	})
+}
+
+// agentAnswers answers the agent leg and waits for the customer dial.
+func (h *c2cHarness) agentAnswers() {
+	h.t.Helper()
+	h.event("leg-1", h.run.correlationID, outbound.DialEventInvited, 0, "")
+	h.event("leg-1", h.run.correlationID, outbound.DialEventEstablished, 200, "")
+	h.waitFor("customer leg bound", func() bool {
+		h.s.mu.Lock()
+		defer h.s.mu.Unlock()
+		return h.s.legs["leg-2"] == h.run
+	})
+}
+
+func (h *c2cHarness) waitFor(what string, cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (h *c2cHarness) row() models.SIPClickToCall {
+	h.t.Helper()
+	row, err := h.s.Get(context.Background(), 0, h.rowID)
+	if err != nil {
+		h.t.Fatalf("get: %v", err)
+	}
+	return row
+}
+
+func (h *c2cHarness) cancel() {
+	h.t.Helper()
+	id := h.rowID
+	if id == 0 {
+		// Still inside launch: the only run is the one being started.
+		h.s.mu.Lock()
+		for _, r := range h.s.runs {
+			id = r.id
+		}
+		h.s.mu.Unlock()
+	}
+	if _, err := h.s.Cancel(context.Background(), 0, id, "ops"); err != nil {
+		h.t.Fatalf("cancel: %v", err)
+	}
+}
+
+func sameLegs(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := map[string]int{}
+	for _, id := range got {
+		seen[id]++
+	}
+	for _, id := range want {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
+
+func TestClickToCall_AgentFlow(t *testing.T) {
+	cases := []struct {
+		name        string
+		steps       func(h *c2cHarness)
+		wantStatus  string
+		wantReason  string
+		wantDials   int
+		wantCancels []string
+		wantBridged []string
+	}{
+		{
+			name: "agent does not answer",
+			steps: func(h *c2cHarness) {
+				h.event("leg-1", h.run.correlationID, outbound.DialEventInvited, 0, "")
+				if st := h.row().Status; st != constants.SIPClickToCallAgentRinging {
+					h.t.Fatalf("status after invite = %s", st)
+				}
+				h.event("leg-1", h.run.correlationID, outbound.DialEventFailed, 486, "busy")
+			},
+			wantStatus: constants.SIPClickToCallFailed,
+			wantReason: "agent_busy",
+			wantDials:  1,
+		},
+		{
+			name: "customer answers then hangs up",
+			steps: func(h *c2cHarness) {
+				h.agentAnswers()
+				if st := h.row().Status; st != constants.SIPClickToCallAgentAnswered {
+					h.t.Fatalf("status after agent answer = %s", st)
+				}
+				h.event("leg-2", "leg-1", outbound.DialEventInvited, 0, "")
+				if st := h.row().Status; st != constants.SIPClickToCallCustomerRinging {
+					h.t.Fatalf("status after customer invite = %s", st)
+				}
+				h.event("leg-2", "leg-1", outbound.DialEventEstablished, 200, "")
+				if row := h.row(); row.Status != constants.SIPClickToCallBridged || row.AnsweredAt == nil {
+					h.t.Fatalf("after customer answer: %s answered=%v", row.Status, row.AnsweredAt)
+				}
+				h.event("leg-2", "leg-1", outbound.DialEventEnded, 0, "")
+			},
+			wantStatus: constants.SIPClickToCallCompleted,
+			wantDials:  2,
+		},
+		{
+			name: "customer busy hangs up the agent",
+			steps: func(h *c2cHarness) {
+				h.agentAnswers()
+				h.event("leg-2", "leg-1", outbound.DialEventFailed, 486, "busy")
+			},
+			wantStatus:  constants.SIPClickToCallFailed,
+			wantReason:  "customer_busy",
+			wantDials:   2,
+			wantCancels: []string{"leg-1"},
+		},
+		{
+			name: "agent hangs up while customer rings",
+			steps: func(h *c2cHarness) {
+				h.agentAnswers()
+				h.event("leg-2", "leg-1", outbound.DialEventInvited, 0, "")
+				h.event("leg-1", "", outbound.DialEventEnded, 0, "")
+			},
+			wantStatus:  constants.SIPClickToCallCanceled,
+			wantReason:  "agent hung up before customer answered",
+			wantDials:   2,
+			wantCancels: []string{"leg-2"},
+		},
+		{
+			name: "cancel while customer rings",
+			steps: func(h *c2cHarness) {
+				h.agentAnswers()
+				h.event("leg-2", "leg-1", outbound.DialEventInvited, 0, "")
+				h.cancel()
+			},
+			wantStatus:  constants.SIPClickToCallCanceled,
+			wantReason:  "canceled by ops",
+			wantDials:   2,
+			wantCancels: []string{"leg-1", "leg-2"},
+		},
+		{
+			name: "cancel after bridge tears down the pair",
+			steps: func(h *c2cHarness) {
+				h.agentAnswers()
+				h.event("leg-2", "leg-1", outbound.DialEventEstablished, 200, "")
+				h.cancel()
+			},
+			wantStatus:  constants.SIPClickToCallCanceled,
+			wantReason:  "canceled by ops",
+			wantDials:   2,
+			wantBridged: []string{"leg-1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newC2CHarness(t)
+			h.start(constants.SIPClickToCallModeAgent)
+			tc.steps(h)
+
+			row := h.row()
+			if row.Status != tc.wantStatus || row.FailureReason != tc.wantReason || row.EndedAt == nil {
+				t.Fatalf("row = %s %q ended=%v, want %s %q", row.Status, row.FailureReason, row.EndedAt, tc.wantStatus, tc.wantReason)
+			}
+			dials, cancels := h.dialer.snapshot()
+			if dials != tc.wantDials || !sameLegs(cancels, tc.wantCancels) || !sameLegs(h.bridged, tc.wantBridged) {
+				t.Fatalf("dials=%d cancels=%v bridged=%v", dials, cancels, h.bridged)
+			}
+			h.s.mu.Lock()
+			defer h.s.mu.Unlock()
+			if len(h.s.runs) != 0 || len(h.s.legs) != 0 {
+				t.Fatalf("finished run still tracked: runs=%d legs=%d", len(h.s.runs), len(h.s.legs))
+			}
+		})
+	}
+}
+
+func TestClickToCall_EventsBeforeDialReturns(t *testing.T) {
+	t.Run("ai customer leg", func(t *testing.T) {
+		h := newC2CHarness(t)
+		h.dialer.beforeReturn = func(_ int, req outbound.DialRequest, callID string) {
+			h.event(callID, req.CorrelationID, outbound.DialEventInvited, 0, "")
+		}
+		h.start(constants.SIPClickToCallModeAI)
+		if row := h.row(); row.Status != constants.SIPClickToCallCustomerRinging || row.CustomerCallID != "leg-1" {
+			t.Fatalf("row = %s customer=%q", row.Status, row.CustomerCallID)
+		}
+		h.event("leg-1", "", outbound.DialEventEstablished, 200, "")
+		if st := h.row().Status; st != constants.SIPClickToCallAnswered {
+			t.Fatalf("status = %s", st)
+		}
+	})
+	t.Run("bridged customer leg keyed by agent call id", func(t *testing.T) {
+		h := newC2CHarness(t)
+		h.dialer.beforeReturn = func(n int, req outbound.DialRequest, callID string) {
+			if n == 2 {
+				if req.CorrelationID != "leg-1" {
+					t.Errorf("customer correlation = %q, want agent Call-ID", req.CorrelationID)
+				}
+				h.event(callID, req.CorrelationID, outbound.DialEventInvited, 0, "")
+			}
+		}
+		h.start(constants.SIPClickToCallModeAgent)
+		h.agentAnswers()
+		if row := h.row(); row.Status != constants.SIPClickToCallCustomerRinging || row.CustomerCallID != "leg-2" || row.AgentCallID != "leg-1" {
+			t.Fatalf("row = %s agent=%q customer=%q", row.Status, row.AgentCallID, row.CustomerCallID)
+		}
+	})
+}
+
+func TestClickToCall_AdoptCallID(t *testing.T) {
+	h := newC2CHarness(t)
+	h.start(constants.SIPClickToCallModeAI)
+	h.s.AdoptCallID("leg-1", "leg-1b")
+
+	h.event("leg-1b", "", outbound.DialEventEstablished, 200, "")
+	if st := h.row().Status; st != constants.SIPClickToCallAnswered {
+		t.Fatalf("status = %s", st)
+	}
+	if id, ok := h.s.CustomerCallForCorrelation(h.run.correlationID); !ok || id != "leg-1b" {
+		t.Fatalf("customer call = %q %v", id, ok)
+	}
+	h.cancel()
+	if _, cancels := h.dialer.snapshot(); !sameLegs(cancels, []string{"leg-1b"}) {
+		t.Fatalf("cancels = %v", cancels)
+	}
+}
+
+func TestClickToCall_CancelDuringDialHangsUpNewLeg(t *testing.T) {
+	cases := []struct {
+		name        string
+		mode        string
+		cancelOn    int
+		wantCancels []string
+	}{
+		{"ai customer", constants.SIPClickToCallModeAI, 1, []string{"leg-1"}},
+		{"sip agent", constants.SIPClickToCallModeAgent, 1, []string{"leg-1"}},
+		{"customer after agent answered", constants.SIPClickToCallModeAgent, 2, []string{"leg-1", "leg-2"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newC2CHarness(t)
+			h.dialer.beforeReturn = func(n int, _ outbound.DialRequest, _ string) {
+				if n == tc.cancelOn {
+					h.cancel()
+				}
+			}
+			h.start(tc.mode)
+			if tc.cancelOn == 2 {
+				h.event("leg-1", h.run.correlationID, outbound.DialEventEstablished, 200, "")
+				h.waitFor("customer leg hung up", func() bool {
+					_, cancels := h.dialer.snapshot()
+					return len(cancels) == len(tc.wantCancels)
+				})
+			}
+			if st := h.row().Status; st != constants.SIPClickToCallCanceled {
+				t.Fatalf("status = %s", st)
+			}
+			if _, cancels := h.dialer.snapshot(); !sameLegs(cancels, tc.wantCancels) {
+				t.Fatalf("cancels = %v, want %v", cancels, tc.wantCancels)
+			}
+			h.s.mu.Lock()
+			defer h.s.mu.Unlock()
+			if len(h.s.legs) != 0 {
+				t.Fatalf("orphan leg tracked: %v", h.s.legs)
+			}
+		})
+	}
+}
+
+func TestClickToCall_RestartRecovery(t *testing.T) {
+	h := newC2CHarness(t)
+	ctx := context.Background()
+	db := h.s.db
+	rows := []models.SIPClickToCall{
+		{TenantID: 1, CorrelationID: "c2c-a", Mode: "agent", Customer: "1", Status: constants.SIPClickToCallAgentRinging},
+		{TenantID: 1, CorrelationID: "c2c-b", Mode: "ai", Customer: "2", Status: constants.SIPClickToCallAnswered},
+		{TenantID: 1, CorrelationID: "c2c-c", Mode: "ai", Customer: "3", Status: constants.SIPClickToCallCompleted},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := h.s.FailOrphaned(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("FailOrphaned = %d, %v", n, err)
+	}
+	for i, want := range []string{constants.SIPClickToCallFailed, constants.SIPClickToCallFailed, constants.SIPClickToCallCompleted} {
+		got, _ := h.s.Get(ctx, 0, rows[i].ID)
+		if got.Status != want {
+			t.Fatalf("%s: status %s, want %s", got.CorrelationID, got.Status, want)
+		}
+		if want == constants.SIPClickToCallFailed && (got.FailureReason != "server_restart" || got.EndedAt == nil) {
+			t.Fatalf("%s: reason %q ended %v", got.CorrelationID, got.FailureReason, got.EndedAt)
+		}
+	}
+	if evs, _ := h.s.Events(ctx, 0, rows[0].ID); len(evs) != 1 || evs[0].State != constants.SIPClickToCallFailed {
+		t.Fatalf("events = %+v", evs)
+	}
+	if _, err := h.s.Cancel(ctx, 0, rows[0].ID, "ops"); !errors.Is(err, ErrClickToCallFinished) {
+		t.Fatalf("cancel failed row: %v", err)
+	}
+
+	// A row this process does not own (written by another instance) is
+	// closed without touching the dialer.
+	foreign := models.SIPClickToCall{TenantID: 1, CorrelationID: "c2c-d", Mode: "ai", Customer: "4", Status: constants.SIPClickToCallCustomerRinging}
+	if err := db.Create(&foreign).Error; err != nil {
+		t.Fatal(err)
+	}
+	got, err := h.s.Cancel(ctx, 1, foreign.ID, "ops")
+	if err != nil || got.Status != constants.SIPClickToCallCanceled {
+		t.Fatalf("cancel foreign = %s, %v", got.Status, err)
+	}
+	if dials, cancels := h.dialer.snapshot(); dials != 0 || len(cancels) != 0 {
+		t.Fatalf("dialer touched: dials=%d cancels=%v", dials, cancels)
+	}
+}
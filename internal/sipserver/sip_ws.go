@@ -0,0 +1,12 @@
+package sipserver
+
+import "net/http"
+
+// SIPWebSocketHandler returns the RFC 7118 SIP-over-WebSocket upgrade
+// handler to mount on the HTTP server, or nil when SIP_WS_ENABLED is off.
+func (e *Embedded) SIPWebSocketHandler() http.HandlerFunc {
+	if e == nil || e.sipServer == nil || !e.sipWSEnabled {
+		return nil
+	}
+	return e.sipServer.ServeWebSocket
+}
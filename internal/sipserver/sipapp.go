@@ -12,6 +12,7 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/config"
 	"github.com/LinByte/VoiceServer/pkg/logger"
 	"github.com/LinByte/VoiceServer/pkg/media"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
 	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
 	"github.com/LinByte/VoiceServer/pkg/sip/persist"
@@ -21,8 +22,10 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
 	"github.com/LinByte/VoiceServer/pkg/sip/voicedialog"
 	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
 	"github.com/LinByte/VoiceServer/pkg/voice/cdr"
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
+	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -44,6 +47,20 @@ type Embedded struct {
 	// JSON-Lines file. Owned by Embedded so Shutdown can flush +
 	// rotate the in-flight file before the process exits.
 	cdrWriter *cdr.Writer
+	// db backs trunk REGISTER client reloads (ReloadTrunkRegistrations).
+	db *gorm.DB
+	// trunkRouter orders trunk group members and keeps per-trunk ASR / NER.
+	trunkRouter *TrunkRouter
+	// sipWSEnabled exposes SIP over WebSocket (SIP_WS_ENABLED) on the HTTP server.
+	sipWSEnabled bool
+	// chatSvc routes SIP MESSAGE (relay, store-and-forward, chatbot, send API).
+	chatSvc *ChatService
+	// clickToCallSvc runs API-originated calls (AI or agent-first bridged).
+	clickToCallSvc *ClickToCallService
+	// acwSvc runs agents' after-call work (wrap-up codes, ACW timers).
+	acwSvc *ACWService
+	// wallboardSvc builds supervisor wallboards and agent daily reports.
+	wallboardSvc *WallboardService
 }
 
 func (e *Embedded) CampaignService() *CampaignService {
@@ -53,6 +70,44 @@ func (e *Embedded) CampaignService() *CampaignService {
 	return e.campaignSvc
 }
 
+// ClickToCallService returns the API-originated call runner (nil before Start).
+func (e *Embedded) ClickToCallService() *ClickToCallService {
+	if e == nil {
+		return nil
+	}
+	return e.clickToCallSvc
+}
+
+// ACWService returns the after-call work tracker (nil before Start).
+func (e *Embedded) ACWService() *ACWService {
+	if e == nil {
+		return nil
+	}
+	return e.acwSvc
+}
+
+// WallboardService returns the ACD wallboard / agent report builder (nil before Start).
+func (e *Embedded) WallboardService() *WallboardService {
+	if e == nil {
+		return nil
+	}
+	return e.wallboardSvc
+}
+
+// CallSession returns the live call session for callID: SIP-server legs
+// (inbound and outbound AI) first, then inbound legs handed to a web seat.
+func (e *Embedded) CallSession(callID string) *sipSession.CallSession {
+	if e == nil || callID == "" {
+		return nil
+	}
+	if e.sipServer != nil {
+		if cs := e.sipServer.GetCallSession(callID); cs != nil {
+			return cs
+		}
+	}
+	return webseat.ActiveCallSession(callID)
+}
+
 // warnIfSIPViaLoopback detects the common misconfig where outbound INVITE puts Via/Contact on 127.0.0.1.
 // Callees then send 100/180/200 to their own loopback — server never sees a response → timeout_no_final_response.
 func warnIfSIPViaLoopback(sipHostEffective, localIP, sipListenHost string) {
@@ -142,6 +197,8 @@ func Start(cfg Config) (*Embedded, error) {
 	var sipRegStore *persist.GormStore
 	var sipCallPersist *persist.CallStore
 	var campaignSvc *CampaignService
+	var clickToCallSvc *ClickToCallService
+	var trunkRouter *TrunkRouter
 
 	// 主叫身份：优先从 Trunk + TrunkNumber 推导（数据库可见即生效），找不到再回退到 SIP_CALLER_ID / SIP_CALLER_DISPLAY_NAME。
 	callerUser, callerDisplay := config.CallerIdentityFromEnv()
@@ -185,6 +242,8 @@ func Start(cfg Config) (*Embedded, error) {
 			}
 		},
 		OnDialogCallIDAdopted: func(oldID, newID, correlationID string) {
+			trunkRouter.adoptCallID(oldID, newID)
+			clickToCallSvc.AdoptCallID(oldID, newID)
 			conversation.MigrateTransferInviteOutboundCallID(correlationID, oldID, newID)
 			conversation.MigrateTransferBridgeOutboundCallID(correlationID, oldID, newID)
 		},
@@ -192,22 +251,31 @@ func Start(cfg Config) (*Embedded, error) {
 			conversation.StartTransferBridge(correlationID, cs, outboundCallID, nil)
 		},
 		OnScript: func(ctx context.Context, leg outbound.EstablishedLeg, scriptID string) {
+			if clickToCallSvc.RunScript(ctx, leg, scriptID) {
+				return
+			}
 			if campaignSvc != nil {
 				campaignSvc.RunScriptIfConfigured(ctx, leg, scriptID)
 			}
 		},
 		OnEvent: func(evt outbound.DialEvent) {
+			trunkRouter.observeEvent(evt)
 			if evt.Scenario == outbound.ScenarioTransferAgent && evt.MediaProfile == outbound.MediaProfileTransferBridge {
 				conversation.HandleTransferAgentDialEvent(evt)
 			}
 			if campaignSvc != nil {
 				campaignSvc.HandleDialEvent(context.Background(), evt)
 			}
+			clickToCallSvc.HandleDialEvent(evt)
+		},
+		OnEarlyMedia: func(leg outbound.EstablishedLeg) {
+			clickToCallSvc.OnEarlyMedia(leg)
 		},
 		OnEstablished: func(leg outbound.EstablishedLeg) {
 			if campaignSvc != nil {
 				campaignSvc.PrepareCallPrompt(leg.CallID, leg.CorrelationID)
 			}
+			clickToCallSvc.OnEstablished(leg)
 			if sipCallPersist == nil || leg.Session == nil {
 				return
 			}
@@ -228,24 +296,36 @@ func Start(cfg Config) (*Embedded, error) {
 				if cm, err := models.GetSIPCampaignByID(ctx, acdDB, campID); err == nil {
 					tenantID = cm.TenantID
 				}
+			} else if tid, ok := clickToCallSvc.TenantForCorrelation(leg.CorrelationID); ok {
+				tenantID = tid
 			}
+			agentID, _ := clickToCallSvc.AgentForCall(leg.CallID)
 			sipCallPersist.OnInvite(ctx, server.InvitePersistParams{
-				TenantID:    tenantID,
-				CallID:      leg.CallID,
-				From:        leg.FromHeader,
-				To:          leg.ToHeader,
-				RemoteSig:   leg.RemoteSignalingAddr,
-				RemoteRTP:   remoteRTP,
-				LocalRTP:    localRTP,
-				Codec:       neg.Name,
-				PayloadType: neg.PayloadType,
-				ClockRate:   neg.ClockRate,
-				CSeqInvite:  leg.CSeqInvite,
-				Direction:   "outbound",
+				TenantID:         tenantID,
+				CallID:           leg.CallID,
+				From:             leg.FromHeader,
+				To:               leg.ToHeader,
+				RemoteSig:        leg.RemoteSignalingAddr,
+				RemoteRTP:        remoteRTP,
+				LocalRTP:         localRTP,
+				Codec:            neg.Name,
+				PayloadType:      neg.PayloadType,
+				ClockRate:        neg.ClockRate,
+				CSeqInvite:       leg.CSeqInvite,
+				Direction:        "outbound",
+				AgentACDTargetID: agentID,
 			})
 			sipCallPersist.OnEstablished(ctx, leg.CallID)
 		},
 	})
+	// 非 Pick* 选号的外呼（ACD 行手填网关、转接等）按下一跳地址反查中继鉴权账号。
+	outMgr.SetTrunkAuthResolver(func(signalingAddr string) (outbound.DigestCredentials, bool) {
+		tc, ok := models.FindTrunkAuthBySignalingAddr(acdDB, signalingAddr)
+		if !ok {
+			return outbound.DigestCredentials{}, false
+		}
+		return outbound.DigestCredentials{Username: tc.AuthUsername, Password: tc.AuthPassword, Realm: tc.AuthRealm}, true
+	})
 
 	sipServerPtr = server.New(server.Config{
 		Host:                              cfg.Host,
@@ -256,9 +336,12 @@ func Start(cfg Config) (*Embedded, error) {
 		TransferBridgeInboundFromOutbound: outMgr.InboundCallIDForEstablishedTransferBridge,
 	})
 
+	trunkRouter = NewTrunkRouter(acdDB, outMgr.TrunkUp, capTracker.OutboundInUse)
 	em := &Embedded{
-		sipServer: sipServerPtr,
-		outMgr:    outMgr,
+		sipServer:   sipServerPtr,
+		outMgr:      outMgr,
+		db:          acdDB,
+		trunkRouter: trunkRouter,
 	}
 
 	// Best-effort CDR writer. Failure to mkdir / open the spool
@@ -270,10 +353,7 @@ func Start(cfg Config) (*Embedded, error) {
 	// alongside the repo so dev runs see CDR output without root.
 	// Production: set LINGECHOX_CDR_DIR to an absolute path that
 	// the log-shipper has read access to.
-	cdrDir := strings.TrimSpace(os.Getenv("LINGECHOX_CDR_DIR"))
-	if cdrDir == "" {
-		cdrDir = "trace"
-	}
+	cdrDir := cdr.DirFromEnv()
 	cw := cdr.NewWriter(cdr.Config{
 		Dir:      cdrDir,
 		BaseName: "cdr",
@@ -298,6 +378,7 @@ func Start(cfg Config) (*Embedded, error) {
 	}
 
 	campaignSvc = NewCampaignService(cfg.DB)
+	campaignSvc.SetTrunkRouter(trunkRouter)
 	sipRegStore = persist.NewGormStore(cfg.DB)
 	campaignSvc.SetDialTargetResolver(func(ctx context.Context, phone string) (outbound.DialTarget, bool) {
 		return sipRegStore.DialTargetForUsername(ctx, phone)
@@ -306,6 +387,46 @@ func Start(cfg Config) (*Embedded, error) {
 	sipServerPtr.SetRegisterStore(sipRegStore)
 	sipCallPersist = persist.NewCallStore(cfg.DB, logger.Lg)
 	sipServerPtr.SetCallPersist(sipCallPersist)
+	chatSvc := NewChatService(cfg.DB, outMgr)
+	chatSvc.Start()
+	sipServerPtr.SetMessageHandler(chatSvc.HandleInbound)
+	blf := NewBLFService(cfg.DB, sipServerPtr)
+	sipServerPtr.SetPresenceSource(blf.Presence)
+	models.OnACDWorkStateChanged(blf.ACDTargetChanged)
+	sipServerPtr.SetRegisterObserver(func(user, domain string) {
+		chatSvc.DeliverPending(user, domain)
+		blf.UserRegistered(user, domain)
+	})
+	em.chatSvc = chatSvc
+	clickToCallSvc = NewClickToCallService(cfg.DB, outMgr, campaignSvc, sipRegStore, sipServerPtr.HangupInboundCall)
+	if n, err := clickToCallSvc.FailOrphaned(context.Background()); err != nil {
+		logger.Warn("click-to-call: closing unfinished rows failed", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("click-to-call: unfinished rows from previous run marked failed", zap.Int("count", n))
+	}
+	clickToCallSvc.SetLocalByePersist(func(callID string) {
+		cs := sipServerPtr.GetCallSession(callID)
+		sipServerPtr.RemoveCallSession(callID)
+		if sipCallPersist == nil {
+			return
+		}
+		p := server.ByePersistParams{CallID: callID, Initiator: "local"}
+		if cs != nil {
+			p.RTCP = cs.RTCPStats()
+			p.CodecName = cs.NegotiatedCodec().Name
+		}
+		go sipCallPersist.OnBye(context.Background(), p)
+	})
+	em.clickToCallSvc = clickToCallSvc
+	acwSvc := NewACWService(cfg.DB)
+	models.SetACDWorkStateHold(acwSvc.Hold)
+	models.OnACDWorkStateChanged(acwSvc.WorkStateChanged)
+	clickToCallSvc.SetAfterCallWork(acwSvc.Begin)
+	conversation.SetAfterCallWorkStarter(acwSvc.Begin)
+	em.acwSvc = acwSvc
+	wallboardSvc := NewWallboardService(cfg.DB, acwSvc)
+	models.OnACDWorkStateChanged(wallboardSvc.WorkStateChanged)
+	em.wallboardSvc = wallboardSvc
 	sipServerPtr.SetInboundDIDBindingResolver(func(msg *stack.Message) server.InboundDIDBinding {
 		if acdDB == nil || msg == nil {
 			return server.InboundDIDBinding{}
@@ -350,6 +471,12 @@ func Start(cfg Config) (*Embedded, error) {
 	default:
 		sipServerPtr.SetInboundAllowUnknownDID(false)
 	}
+	// 浏览器软电话（JsSIP / SIP.js）走 SIP over WebSocket，媒体只能是 DTLS-SRTP + ICE。
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("SIP_WS_ENABLED"))) {
+	case "1", "true", "yes", "on":
+		em.sipWSEnabled = true
+		sipServerPtr.SetInboundDTLSAccept(true)
+	}
 	sipServerPtr.SetVoiceDialogWSLookup(func(callID string) string {
 		cid := strings.TrimSpace(callID)
 		if cid == "" || acdDB == nil {
@@ -390,6 +517,26 @@ func Start(cfg Config) (*Embedded, error) {
 		}
 		return ""
 	})
+	// Per-DID dialog languages (TrunkNumber.LanguagesJSON) for the
+	// native cascaded path; same Call-ID → to_number → TrunkNumber hop.
+	conversation.SetCallLanguagesResolver(func(callID string) string {
+		cid := strings.TrimSpace(callID)
+		if cid == "" || acdDB == nil {
+			return ""
+		}
+		callRow, err := persist.FindActiveSIPCallByCallID(context.Background(), acdDB, cid)
+		if err != nil {
+			return ""
+		}
+		called := strings.TrimSpace(callRow.ToNumber)
+		if called == "" {
+			return ""
+		}
+		if tn, ok := models.FindTrunkNumberByInboundDID(acdDB, called); ok {
+			return tn.LanguagesJSON
+		}
+		return ""
+	})
 	// Per-DID transfer-ringback URL resolver. Identical lookup path
 	// (Call-ID → sip_calls.to_number → TrunkNumber) but reads the
 	// TransferRingingURL column instead. Used by both the SIP transfer
@@ -448,6 +595,45 @@ func Start(cfg Config) (*Embedded, error) {
 		}
 		return ""
 	})
+	// Inbound call → its trunk: Call-ID → sip_calls.to_number →
+	// TrunkNumber → Trunk. nil when any hop is missing.
+	inboundTrunk := func(callID string) *models.Trunk {
+		cid := strings.TrimSpace(callID)
+		if cid == "" || acdDB == nil {
+			return nil
+		}
+		callRow, err := persist.FindActiveSIPCallByCallID(context.Background(), acdDB, cid)
+		if err != nil {
+			return nil
+		}
+		called := strings.TrimSpace(callRow.ToNumber)
+		if called == "" {
+			return nil
+		}
+		tn, ok := models.FindTrunkNumberByInboundDID(acdDB, called)
+		if !ok {
+			return nil
+		}
+		trunk, err := models.GetTrunkByIDBare(acdDB, tn.TrunkID)
+		if err != nil {
+			return nil
+		}
+		return &trunk
+	}
+	// Per-trunk in-band DTMF mode (Trunk.InbandDTMF). Empty falls back to SIP_INBAND_DTMF / auto.
+	conversation.SetInbandDTMFModeResolver(func(callID string) string {
+		if trunk := inboundTrunk(callID); trunk != nil {
+			return strings.TrimSpace(trunk.InbandDTMF)
+		}
+		return ""
+	})
+	// Per-trunk fax mode (Trunk.FaxMode). Empty falls back to SIP_FAX_MODE / auto.
+	conversation.SetFaxModeResolver(func(callID string) string {
+		if trunk := inboundTrunk(callID); trunk != nil {
+			return strings.TrimSpace(trunk.FaxMode)
+		}
+		return ""
+	})
 	conversation.SetTransferAgentBriefVarsResolver(func(callID string) conversation.TransferAgentBriefVars {
 		cid := strings.TrimSpace(callID)
 		vars := conversation.TransferAgentBriefVars{
@@ -480,6 +666,7 @@ func Start(cfg Config) (*Embedded, error) {
 	conversation.SetSIPTurnPersist(func(ctx context.Context, callID string, turn conversation.DialogTurn) {
 		sipCallPersist.SaveConversationTurn(ctx, callID, turn)
 	})
+	conversation.SetSIPCallLanguagePersist(sipCallPersist.OnLanguageDetected)
 	conversation.SetTransferDialTargetResolver(func(ctx context.Context, inboundCallID string, exclude []uint) (outbound.DialTarget, bool) {
 		return PickTransferDialTarget(ctx, acdDB, sipRegStore, inboundCallID, exclude)
 	})
@@ -526,6 +713,15 @@ func Start(cfg Config) (*Embedded, error) {
 		if acdDB == nil {
 			return nil
 		}
+		if id := req.Target.TrunkNumberID; id > 0 {
+			// Trunk group member: the limit is the member's own number.
+			if tn, err := models.GetTrunkNumberByID(acdDB, id); err == nil && tn.Concurrent > 0 &&
+				!capTracker.TryAcquireOutbound(callID, tn.ID, tn.Concurrent) {
+				return fmt.Errorf("outbound concurrent limit exceeded for trunk number %d (limit=%d)", tn.ID, tn.Concurrent)
+			}
+			trunkRouter.observeDial(callID, req.Target.TrunkID)
+			return nil
+		}
 		caller := strings.TrimSpace(req.CallerUser)
 		if caller == "" {
 			caller = strings.TrimSpace(req.Target.CallerUser)
@@ -563,7 +759,15 @@ func Start(cfg Config) (*Embedded, error) {
 		return sipServerPtr.GetCallSession(callID)
 	})
 	conversation.SetCallStore(sipServerPtr)
-	conversation.SetTransferPeerCallbacks(outMgr.SendBYE, sipServerPtr.SendUASBye)
+	conversation.SetFaxToneHandler(sipServerPtr.StartFax)
+	conversation.SetTransferPeerCallbacks(outMgr.SendBYE, func(callID string) error {
+		// Click-to-call bridges an agent leg we dialed ourselves (UAC): no UAS dialog to BYE.
+		err := sipServerPtr.SendUASBye(callID)
+		if err != nil && outMgr.SendBYE(callID) == nil {
+			return nil
+		}
+		return err
+	})
 	conversation.SetSIPHangup(func(callID string) {
 		callID = strings.TrimSpace(callID)
 		if callID == "" || sipServerPtr == nil {
@@ -593,6 +797,45 @@ func Start(cfg Config) (*Embedded, error) {
 		return []byte(t.AsrConfig), []byte(t.TtsConfig), []byte(t.LlmConfig),
 			[]byte(t.RealtimeConfig), t.VoiceMode, true
 	})
+	redact.SetPolicyLoader(func(ctx context.Context, tenantID uint) ([]byte, bool) {
+		if acdDB == nil || tenantID == 0 {
+			return nil, false
+		}
+		var t models.Tenant
+		if err := acdDB.WithContext(ctx).Select("privacy_config").Where("id = ?", tenantID).First(&t).Error; err != nil {
+			return nil, false
+		}
+		return []byte(t.PrivacyConfig), true
+	})
+	recorder.SetFormatLoader(func(ctx context.Context, tenantID uint) string {
+		if acdDB == nil {
+			return ""
+		}
+		var t models.Tenant
+		if err := acdDB.WithContext(ctx).Select("recording_format").Where("id = ?", tenantID).First(&t).Error; err != nil {
+			return ""
+		}
+		return t.RecordingFormat
+	})
+	if acdDB != nil && envelope.ConfigureFromEnv(models.NewRecordingKeyStore(acdDB)) && logger.Lg != nil {
+		logger.Lg.Info("sipapp: recordings are envelope-encrypted at rest")
+	}
+	sipSession.SetRecordingPauseAudit(func(ctx context.Context, p sipSession.RecordingPause) {
+		row := &models.SIPRecordingPause{
+			TenantID:  p.TenantID,
+			CallID:    p.CallID,
+			Source:    p.Source,
+			Reason:    p.Reason,
+			PausedBy:  p.PausedBy,
+			ResumedBy: p.ResumedBy,
+			StartedAt: p.Start.UTC(),
+			EndedAt:   p.End.UTC(),
+		}
+		if err := models.InsertSIPRecordingPause(ctx, acdDB, row); err != nil && logger.Lg != nil {
+			logger.Lg.Warn("sipapp: recording pause audit insert failed",
+				zap.String("call_id", p.CallID), zap.Error(err))
+		}
+	})
 	webseat.InitDefault(webseat.Config{
 		RemoveCallSession:     sipServerPtr.RemoveCallSession,
 		ForgetUASDialog:       sipServerPtr.ForgetUASDialog,
@@ -630,6 +873,26 @@ func Start(cfg Config) (*Embedded, error) {
 				WAVRecording:       wavRec,
 			})
 		},
+		RecordWebSeatQuality: func(ctx context.Context, callID string, q webseat.QualitySummary) {
+			if sipCallPersist == nil {
+				return
+			}
+			// Agent-first seats are keyed by the click-to-call correlation ID.
+			if id, ok := clickToCallSvc.CustomerCallForCorrelation(callID); ok {
+				callID = id
+			}
+			go sipCallPersist.OnWebSeatQuality(ctx, callID, q)
+		},
+		StartAfterCallWork: func(callID string, acdTargetID uint) bool {
+			if id, ok := clickToCallSvc.CustomerCallForCorrelation(callID); ok {
+				callID = id
+			}
+			return acwSvc.Begin(callID, acdTargetID)
+		},
+		WrapUp: func(ctx context.Context, callID, dispositionCode, notes string) error {
+			_, err := acwSvc.WrapUp(ctx, 0, 0, callID, dispositionCode, notes, "webseat")
+			return err
+		},
 	})
 	conversation.SetWebSeatTransfer(conversation.StartWebSeatHandoff)
 	useTLS := config.GlobalConfig.Server.SSLEnabled
@@ -665,6 +928,14 @@ func Start(cfg Config) (*Embedded, error) {
 		_, _ = fmt.Fprintf(os.Stdout, "sipapp: listening on udp %s:%d (SDP local-ip effective=%q cli=%q)\n", cfg.Host, cfg.Port, localIP, strings.TrimSpace(cfg.LocalIP))
 	}
 	logPlatformOutboundTrunkAtStartup(cfg.DB)
+	// Carrier trunks that deliver DIDs to a registered account: keep the
+	// bindings alive and read the DID from To on INVITEs sent to our Contact.
+	server.SetRegisteredContactMatcher(func(requestURI string) bool {
+		_, ok := outMgr.RegisteredContactTrunk(requestURI)
+		return ok
+	})
+	em.ReloadTrunkRegistrations()
+	em.ReloadTrunkHealth()
 
 	return em, nil
 }
@@ -697,6 +968,14 @@ func (e *Embedded) Shutdown(ctx context.Context) {
 	if e.campaignSvc != nil {
 		e.campaignSvc.StopWorker()
 	}
+	if e.chatSvc != nil {
+		e.chatSvc.Stop()
+	}
+	// Un-REGISTER while the UDP socket is still up.
+	if e.outMgr != nil {
+		e.outMgr.StopRegistrations()
+		e.outMgr.StopHealthChecks()
+	}
 	if e.sipServer != nil {
 		_ = e.sipServer.Stop()
 	}
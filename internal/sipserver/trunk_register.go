@@ -0,0 +1,62 @@
+package sipserver
+
+import (
+	"strings"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// trunkRegistrationConfigs 把开启「向运营商注册」的中继转换为 outbound REGISTER 客户端配置。
+func trunkRegistrationConfigs(db *gorm.DB) []outbound.RegistrationConfig {
+	trunks, err := models.ListRegisteringTrunks(db)
+	if err != nil {
+		if logger.Lg != nil {
+			logger.Lg.Warn("sipapp: list registering trunks failed", zap.Error(err))
+		}
+		return nil
+	}
+	out := make([]outbound.RegistrationConfig, 0, len(trunks))
+	for _, t := range trunks {
+		regs := t.RegistrarAddrList()
+		if len(regs) == 0 || t.AuthPassword == "" {
+			if logger.Lg != nil {
+				logger.Lg.Warn("sipapp: trunk registration skipped (no registrar or password)",
+					zap.Uint("trunk_id", t.ID))
+			}
+			continue
+		}
+		out = append(out, outbound.RegistrationConfig{
+			TrunkID:    t.ID,
+			Registrars: regs,
+			Domain:     strings.TrimSpace(t.RegisterDomain),
+			Expires:    t.RegisterExpires,
+			Auth: outbound.DigestCredentials{
+				Username: strings.TrimSpace(t.AuthUsername),
+				Password: t.AuthPassword,
+				Realm:    strings.TrimSpace(t.AuthRealm),
+			},
+		})
+	}
+	return out
+}
+
+// ReloadTrunkRegistrations re-reads registering trunks and reconciles the
+// REGISTER client loops (called after trunk create / update / delete).
+func (e *Embedded) ReloadTrunkRegistrations() {
+	if e == nil || e.outMgr == nil || e.db == nil {
+		return
+	}
+	e.outMgr.SetRegistrations(trunkRegistrationConfigs(e.db))
+}
+
+// TrunkRegistrations returns the live registration status of every registering trunk.
+func (e *Embedded) TrunkRegistrations() []outbound.RegistrationStatus {
+	if e == nil || e.outMgr == nil {
+		return nil
+	}
+	return e.outMgr.RegistrationStatuses()
+}
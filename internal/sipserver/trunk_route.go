@@ -0,0 +1,362 @@
+package sipserver
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Trunk group routing for campaign dials.
+//
+// A campaign bound to a trunk group, or a contact without a callerUser
+// on a tenant that has least-cost routes, is dialled through an ordered
+// member list: the first member becomes DialRequest.Target and the rest
+// DialRequest.Fallbacks, which the outbound manager walks on 408 / 5xx /
+// silence. Members at their TrunkNumber.Concurrent limit are skipped;
+// members whose trunk is down (OPTIONS keep-alive) or whose NER over the
+// last trunkStatsWindow attempts is below SIP_TRUNK_MIN_NER are moved to
+// the back rather than dropped, so a fully degraded group still dials.
+
+const (
+	trunkStatsWindow = 100
+
+	envTrunkMinNER        = "SIP_TRUNK_MIN_NER"
+	envTrunkStatsMinCalls = "SIP_TRUNK_STATS_MIN_CALLS"
+
+	defaultTrunkMinNER        = 0.5
+	defaultTrunkStatsMinCalls = 20
+)
+
+type trunkOutcome uint8
+
+const (
+	trunkAnswered trunkOutcome = iota
+	// trunkUserRejected: the network delivered the call but the callee
+	// did not answer (busy, no answer, declined, cancelled, unknown number).
+	trunkUserRejected
+	trunkNetworkFailed
+)
+
+// classifyTrunkFailure maps a final non-2xx status to an outcome. 0 means
+// no final response (timeout / transport error).
+func classifyTrunkFailure(status int) trunkOutcome {
+	switch status {
+	case 404, 480, 486, 487, 600, 603, 604:
+		return trunkUserRejected
+	}
+	return trunkNetworkFailed
+}
+
+// trunkStats is a ring of the most recent outcomes on one trunk.
+type trunkStats struct {
+	ring [trunkStatsWindow]trunkOutcome
+	n    int
+	next int
+}
+
+func (s *trunkStats) add(o trunkOutcome) {
+	s.ring[s.next] = o
+	s.next = (s.next + 1) % trunkStatsWindow
+	if s.n < trunkStatsWindow {
+		s.n++
+	}
+}
+
+func (s *trunkStats) counts() (attempts, answered, effective int) {
+	for i := 0; i < s.n; i++ {
+		switch s.ring[i] {
+		case trunkAnswered:
+			answered++
+			effective++
+		case trunkUserRejected:
+			effective++
+		}
+	}
+	return s.n, answered, effective
+}
+
+// TrunkStats is the admin-facing quality snapshot of one trunk.
+// ASR = answered / attempts; NER = (answered + callee-side rejects) / attempts.
+type TrunkStats struct {
+	TrunkID  uint    `json:"trunkId"`
+	Attempts int     `json:"attempts"`
+	Answered int     `json:"answered"`
+	ASR      float64 `json:"asr"`
+	NER      float64 `json:"ner"`
+	Up       bool    `json:"up"`
+	Demoted  bool    `json:"demoted"`
+}
+
+// TrunkRouter orders trunk group members and tracks per-trunk quality.
+type TrunkRouter struct {
+	db *gorm.DB
+	// up reports OPTIONS health; nil means every trunk is up.
+	up func(trunkID uint) bool
+	// inUse reports outbound slots held per TrunkNumber; nil means none.
+	inUse func(trunkNumberID uint) int
+
+	minNER   float64
+	minCalls int
+
+	mu    sync.Mutex
+	stats map[uint]*trunkStats
+	calls map[string]uint // Call-ID → trunk currently attempted
+}
+
+// NewTrunkRouter builds a router; up and inUse may be nil.
+func NewTrunkRouter(db *gorm.DB, up func(uint) bool, inUse func(uint) int) *TrunkRouter {
+	minNER := utils.GetFloatEnvWithDefault(envTrunkMinNER, defaultTrunkMinNER)
+	minCalls := utils.GetIntEnvWithDefault(envTrunkStatsMinCalls, defaultTrunkStatsMinCalls)
+	if minCalls <= 0 {
+		minCalls = defaultTrunkStatsMinCalls
+	}
+	return &TrunkRouter{
+		db:       db,
+		up:       up,
+		inUse:    inUse,
+		minNER:   minNER,
+		minCalls: minCalls,
+		stats:    make(map[uint]*trunkStats),
+		calls:    make(map[string]uint),
+	}
+}
+
+func (r *TrunkRouter) trunkUp(trunkID uint) bool {
+	return r.up == nil || r.up(trunkID)
+}
+
+// demotedLocked reports whether trunkID has enough samples and an NER
+// below the threshold. Caller holds r.mu.
+func (r *TrunkRouter) demotedLocked(trunkID uint) bool {
+	s := r.stats[trunkID]
+	if s == nil || s.n < r.minCalls {
+		return false
+	}
+	attempts, _, effective := s.counts()
+	return float64(effective)/float64(attempts) < r.minNER
+}
+
+// order applies strategy, drops members at capacity and moves degraded
+// members to the back: healthy, then demoted, then down.
+func (r *TrunkRouter) order(strategy string, cands []models.TrunkGroupCandidate) []models.TrunkGroupCandidate {
+	avail := make([]models.TrunkGroupCandidate, 0, len(cands))
+	for _, c := range cands {
+		if c.Concurrent > 0 && r.inUse != nil && r.inUse(c.TrunkNumberID) >= int(c.Concurrent) {
+			continue
+		}
+		avail = append(avail, c)
+	}
+	if strategy == models.TrunkGroupWeighted {
+		avail = weightedOrder(avail)
+	}
+	rank := make(map[uint]int, len(avail))
+	r.mu.Lock()
+	for _, c := range avail {
+		switch {
+		case !r.trunkUp(c.TrunkID):
+			rank[c.TrunkNumberID] = 2
+		case r.demotedLocked(c.TrunkID):
+			rank[c.TrunkNumberID] = 1
+		}
+	}
+	r.mu.Unlock()
+	sort.SliceStable(avail, func(i, j int) bool {
+		return rank[avail[i].TrunkNumberID] < rank[avail[j].TrunkNumberID]
+	})
+	return avail
+}
+
+// weightedOrder draws members without replacement in proportion to
+// Weight; members with Weight <= 0 keep their priority order at the end.
+func weightedOrder(cands []models.TrunkGroupCandidate) []models.TrunkGroupCandidate {
+	var pool, rest []models.TrunkGroupCandidate
+	for _, c := range cands {
+		if c.Weight > 0 {
+			pool = append(pool, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	out := make([]models.TrunkGroupCandidate, 0, len(cands))
+	for len(pool) > 0 {
+		total := 0
+		for _, c := range pool {
+			total += c.Weight
+		}
+		pick := rand.IntN(total)
+		i := 0
+		for ; pick >= pool[i].Weight; i++ {
+			pick -= pool[i].Weight
+		}
+		out = append(out, pool[i])
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+	return append(out, rest...)
+}
+
+// GroupPlan returns the members of groupID in dial order.
+func (r *TrunkRouter) GroupPlan(tenantID, groupID uint) ([]models.TrunkGroupCandidate, error) {
+	g, cands, err := models.ResolveTrunkGroupCandidates(r.db, tenantID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("trunk group %d: %w", groupID, err)
+	}
+	return r.order(g.Strategy, cands), nil
+}
+
+// RoutePlan returns the least-cost member list for phone: every group on
+// the longest matching prefix in cost order, de-duplicated. ok is false
+// when the tenant has no matching route.
+func (r *TrunkRouter) RoutePlan(tenantID uint, phone string) (plan []models.TrunkGroupCandidate, ok bool, err error) {
+	routes, err := models.MatchTrunkRoutes(r.db, tenantID, phone)
+	if err != nil || len(routes) == 0 {
+		return nil, false, err
+	}
+	seen := make(map[uint]struct{})
+	for _, rt := range routes {
+		cands, err := r.GroupPlan(tenantID, rt.GroupID)
+		if err != nil {
+			if logger.Lg != nil {
+				logger.Lg.Warn("sipapp: trunk route group skipped",
+					zap.Uint("route_id", rt.ID), zap.Uint("group_id", rt.GroupID), zap.Error(err))
+			}
+			continue
+		}
+		for _, c := range cands {
+			if _, dup := seen[c.TrunkNumberID]; dup {
+				continue
+			}
+			seen[c.TrunkNumberID] = struct{}{}
+			plan = append(plan, c)
+		}
+	}
+	return plan, true, nil
+}
+
+// observeDial records which trunk callID is about to try (dial gate).
+func (r *TrunkRouter) observeDial(callID string, trunkID uint) {
+	callID = strings.TrimSpace(callID)
+	if r == nil || callID == "" || trunkID == 0 {
+		return
+	}
+	r.mu.Lock()
+	r.calls[callID] = trunkID
+	r.mu.Unlock()
+}
+
+// adoptCallID follows a Call-ID rewrite on the outbound leg.
+func (r *TrunkRouter) adoptCallID(oldID, newID string) {
+	if r == nil || oldID == "" || newID == "" || oldID == newID {
+		return
+	}
+	r.mu.Lock()
+	if id, ok := r.calls[oldID]; ok {
+		delete(r.calls, oldID)
+		r.calls[newID] = id
+	}
+	r.mu.Unlock()
+}
+
+// observeEvent folds a dial outcome into the attempted trunk's stats.
+func (r *TrunkRouter) observeEvent(evt outbound.DialEvent) {
+	if r == nil {
+		return
+	}
+	var o trunkOutcome
+	switch evt.State {
+	case outbound.DialEventEstablished:
+		o = trunkAnswered
+	case outbound.DialEventFailed, outbound.DialEventRerouted:
+		o = classifyTrunkFailure(evt.StatusCode)
+	default:
+		return
+	}
+	callID := strings.TrimSpace(evt.CallID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trunkID, ok := r.calls[callID]
+	if !ok {
+		return
+	}
+	// Rerouted: the dial gate records the next trunk right after.
+	delete(r.calls, callID)
+	s := r.stats[trunkID]
+	if s == nil {
+		s = &trunkStats{}
+		r.stats[trunkID] = s
+	}
+	s.add(o)
+}
+
+// Stats returns every trunk with recorded attempts, ordered by trunk ID.
+func (r *TrunkRouter) Stats() []TrunkStats {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	out := make([]TrunkStats, 0, len(r.stats))
+	for id, s := range r.stats {
+		attempts, answered, effective := s.counts()
+		st := TrunkStats{TrunkID: id, Attempts: attempts, Answered: answered, Demoted: r.demotedLocked(id)}
+		if attempts > 0 {
+			st.ASR = float64(answered) / float64(attempts)
+			st.NER = float64(effective) / float64(attempts)
+		}
+		out = append(out, st)
+	}
+	r.mu.Unlock()
+	for i := range out {
+		out[i].Up = r.trunkUp(out[i].TrunkID)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TrunkID < out[j].TrunkID })
+	return out
+}
+
+// trunkHealthTargets lists the gateways of every trunk used by a trunk group.
+func trunkHealthTargets(db *gorm.DB) []outbound.HealthTarget {
+	gws, err := models.ListGroupedTrunkGateways(db)
+	if err != nil {
+		if logger.Lg != nil {
+			logger.Lg.Warn("sipapp: list grouped trunks failed", zap.Error(err))
+		}
+		return nil
+	}
+	out := make([]outbound.HealthTarget, 0, len(gws))
+	for id, addr := range gws {
+		out = append(out, outbound.HealthTarget{TrunkID: id, Addr: addr})
+	}
+	return out
+}
+
+// ReloadTrunkHealth re-reads the trunks used by trunk groups and
+// reconciles the OPTIONS keep-alive loops (called after group or trunk changes).
+func (e *Embedded) ReloadTrunkHealth() {
+	if e == nil || e.outMgr == nil || e.db == nil {
+		return
+	}
+	e.outMgr.SetHealthTargets(trunkHealthTargets(e.db))
+}
+
+// TrunkHealths returns the OPTIONS keep-alive status of every probed trunk.
+func (e *Embedded) TrunkHealths() []outbound.TrunkHealth {
+	if e == nil || e.outMgr == nil {
+		return nil
+	}
+	return e.outMgr.TrunkHealths()
+}
+
+// TrunkStats returns per-trunk ASR / NER over recent campaign dials.
+func (e *Embedded) TrunkStats() []TrunkStats {
+	if e == nil {
+		return nil
+	}
+	return e.trunkRouter.Stats()
+}
@@ -0,0 +1,397 @@
+package sipserver
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ACD wallboard.
+//
+// A supervisor view over acd_pool_targets: every seat's current work state,
+// time spent per state today (acd_work_state_events), today's call metrics
+// (sip_calls: handled, transfers offered / answered, talk / ACW / handle
+// averages) and per trunk number the callers waiting for an agent
+// (conversation.QueuedTransfers) with the longest wait. Streams re-send the
+// snapshot on every work_state change (coalesced) and every
+// SIP_WALLBOARD_INTERVAL_SECONDS. Daily reports use the same sources over a
+// date range. Transfer offers come from sip_calls.transfer_trace_json; the
+// ACD eligibility audit is in-memory only and not a history source.
+
+const (
+	envWallboardInterval = "SIP_WALLBOARD_INTERVAL_SECONDS"
+	// wallboardMinGap coalesces bursts of work_state changes into one push.
+	wallboardMinGap = time.Second
+)
+
+// WallboardAgent is one seat on the wallboard.
+type WallboardAgent struct {
+	ACDTargetID   uint                   `json:"acdTargetId,string"`
+	Name          string                 `json:"name"`
+	RouteType     string                 `json:"routeType"`
+	TrunkNumberID uint                   `json:"trunkNumberId"`
+	WorkState     string                 `json:"workState"`
+	StateReason   string                 `json:"stateReason,omitempty"` // break reason
+	StateSince    *time.Time             `json:"stateSince,omitempty"`
+	StateSec      int64                  `json:"stateSec"`     // time in the current state
+	StateSeconds  map[string]int64       `json:"stateSeconds"` // today, per work state
+	ACWExpiresAt  *time.Time             `json:"acwExpiresAt,omitempty"`
+	Today         persist.AgentCallStats `json:"today"`
+}
+
+// WallboardQueue is one trunk number's live queue and today's volume.
+type WallboardQueue struct {
+	TrunkNumberID    uint           `json:"trunkNumberId"`
+	Number           string         `json:"number"`
+	Waiting          int            `json:"waiting"`
+	LongestWaitSec   int64          `json:"longestWaitSec"`
+	AgentsByState    map[string]int `json:"agentsByState"` // seats bound to the number plus tenant-wide seats
+	CallsToday       int64          `json:"callsToday"`
+	TransferredToday int64          `json:"transferredToday"`
+}
+
+// Wallboard is one tenant snapshot.
+type Wallboard struct {
+	At             time.Time        `json:"at"`
+	Waiting        int              `json:"waiting"`
+	LongestWaitSec int64            `json:"longestWaitSec"`
+	AgentsByState  map[string]int   `json:"agentsByState"`
+	Agents         []WallboardAgent `json:"agents"`
+	Queues         []WallboardQueue `json:"queues"`
+}
+
+// AgentDayReport is one seat's metrics for one local calendar day: calls,
+// seconds per work state and break reason, occupancy and shift adherence.
+type AgentDayReport struct {
+	Day         string `json:"day"`
+	ACDTargetID uint   `json:"acdTargetId,string"`
+	Name        string `json:"name"`
+	persist.AgentCallStats
+	StateSeconds map[string]int64 `json:"stateSeconds"`
+	// Breakdown beyond StateSeconds (see models.ACDTimelineStats).
+	BreakSeconds map[string]int64     `json:"breakSeconds"`
+	Occupancy    *float64             `json:"occupancy"`
+	Adherence    *models.ACDAdherence `json:"adherence"`
+}
+
+type WallboardService struct {
+	db  *gorm.DB
+	acw *ACWService
+
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+func NewWallboardService(db *gorm.DB, acw *ACWService) *WallboardService {
+	return &WallboardService{db: db, acw: acw, subs: make(map[chan struct{}]struct{})}
+}
+
+// Interval is the periodic refresh of wallboard streams.
+func (s *WallboardService) Interval() time.Duration {
+	n := utils.GetIntEnvWithDefault(envWallboardInterval, 5)
+	if n <= 0 {
+		n = 5
+	}
+	return time.Duration(n) * time.Second
+}
+
+// MinGap is the shortest time between two pushes on one stream.
+func (s *WallboardService) MinGap() time.Duration { return wallboardMinGap }
+
+// WorkStateChanged is the models.OnACDWorkStateChanged observer: wake streams.
+func (s *WallboardService) WorkStateChanged(uint, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a coalescing change signal and its cancel func.
+func (s *WallboardService) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func (s *WallboardService) listTargets(ctx context.Context, tenantID, targetID uint) ([]models.ACDPoolTarget, error) {
+	q := models.ActiveACDPoolTargets(s.db.WithContext(ctx))
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	if targetID > 0 {
+		q = q.Where("id = ?", targetID)
+	}
+	var rows []models.ACDPoolTarget
+	err := q.Order("sort_order ASC").Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// statesAt returns each seat's state at t: its last recorded transition, else
+// the current row state when that was already set before t.
+func (s *WallboardService) statesAt(ctx context.Context, tenantID uint, rows []models.ACDPoolTarget, t time.Time) (map[uint]models.ACDWorkStateEvent, error) {
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	out, err := models.ACDWorkStateEventsBefore(ctx, s.db, tenantID, ids, t)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if _, ok := out[r.ID]; !ok && r.WorkStateAt != nil && r.WorkStateAt.Before(t) {
+			out[r.ID] = models.ACDWorkStateEvent{WorkState: models.NormalizeACDWorkState(r.WorkState), Reason: r.WorkStateReason}
+		}
+	}
+	return out, nil
+}
+
+func groupWorkStateEvents(evs []models.ACDWorkStateEvent) map[uint][]models.ACDWorkStateEvent {
+	out := make(map[uint][]models.ACDWorkStateEvent)
+	for _, ev := range evs {
+		out[ev.ACDTargetID] = append(out[ev.ACDTargetID], ev)
+	}
+	return out
+}
+
+// Snapshot builds the tenant's wallboard (tenantID 0 = all tenants).
+func (s *WallboardService) Snapshot(ctx context.Context, tenantID uint) (Wallboard, error) {
+	now := time.Now()
+	today := startOfDay(now)
+	wb := Wallboard{At: now, AgentsByState: map[string]int{}, Agents: []WallboardAgent{}, Queues: []WallboardQueue{}}
+
+	rows, err := s.listTargets(ctx, tenantID, 0)
+	if err != nil {
+		return wb, err
+	}
+	initial, err := s.statesAt(ctx, tenantID, rows, today)
+	if err != nil {
+		return wb, err
+	}
+	evs, err := models.ListACDWorkStateEvents(ctx, s.db, tenantID, 0, today, now.Add(time.Second))
+	if err != nil {
+		return wb, err
+	}
+	byTarget := groupWorkStateEvents(evs)
+	callRows, err := persist.ListAgentCallRows(ctx, s.db, tenantID, today, now.Add(time.Second))
+	if err != nil {
+		return wb, err
+	}
+	callStats := persist.AggregateAgentCalls(callRows, now.Location())
+	day := today.Format("2006-01-02")
+
+	for _, r := range rows {
+		ws := models.NormalizeACDWorkState(r.WorkState)
+		a := WallboardAgent{
+			ACDTargetID:   r.ID,
+			Name:          strings.TrimSpace(r.Name),
+			RouteType:     r.RouteType,
+			TrunkNumberID: r.TrunkNumberID,
+			WorkState:     ws,
+			StateSince:    r.WorkStateAt,
+			StateReason:   r.WorkStateReason,
+			StateSeconds:  models.ACDStateDurations(initial[r.ID].WorkState, byTarget[r.ID], today, now),
+		}
+		if r.WorkStateAt != nil && now.After(*r.WorkStateAt) {
+			a.StateSec = int64(now.Sub(*r.WorkStateAt) / time.Second)
+		}
+		if n, ok := s.acw.Current(tenantID, r.ID); ok {
+			exp := n.ExpiresAt
+			a.ACWExpiresAt = &exp
+		}
+		if st := callStats[persist.AgentDayKey{ACDTargetID: r.ID, Day: day}]; st != nil {
+			a.Today = *st
+		}
+		wb.AgentsByState[ws]++
+		wb.Agents = append(wb.Agents, a)
+	}
+
+	queues, err := s.queues(ctx, tenantID, rows, today, now)
+	if err != nil {
+		return wb, err
+	}
+	wb.Queues = queues
+	for _, q := range queues {
+		wb.Waiting += q.Waiting
+		if q.LongestWaitSec > wb.LongestWaitSec {
+			wb.LongestWaitSec = q.LongestWaitSec
+		}
+	}
+	return wb, nil
+}
+
+// queues builds per-number queue rows; callers on calls without an inbound
+// number land in a TrunkNumberID 0 row.
+func (s *WallboardService) queues(ctx context.Context, tenantID uint, rows []models.ACDPoolTarget, today, now time.Time) ([]WallboardQueue, error) {
+	nq := s.db.WithContext(ctx).Model(&models.TrunkNumber{})
+	if tenantID > 0 {
+		nq = nq.Where("tenant_id = ?", tenantID)
+	}
+	var nums []models.TrunkNumber
+	if err := nq.Order("id ASC").Find(&nums).Error; err != nil {
+		return nil, err
+	}
+	byNumber := make(map[uint]*WallboardQueue, len(nums))
+	out := make([]*WallboardQueue, 0, len(nums))
+	queue := func(id uint, number string) *WallboardQueue {
+		if q := byNumber[id]; q != nil {
+			return q
+		}
+		q := &WallboardQueue{TrunkNumberID: id, Number: number, AgentsByState: map[string]int{}}
+		byNumber[id] = q
+		out = append(out, q)
+		return q
+	}
+	for _, n := range nums {
+		queue(n.ID, strings.TrimSpace(n.Number))
+	}
+
+	if waiting := conversation.QueuedTransfers(); len(waiting) > 0 {
+		ids := make([]string, 0, len(waiting))
+		for _, w := range waiting {
+			ids = append(ids, w.InboundCallID)
+		}
+		var calls []struct {
+			CallID               string
+			TenantID             uint
+			InboundTrunkNumberID uint
+		}
+		cq := persist.ActiveSIPCalls(s.db.WithContext(ctx)).
+			Select("call_id, tenant_id, inbound_trunk_number_id").Where("call_id IN ?", ids)
+		if tenantID > 0 {
+			cq = cq.Where("tenant_id = ?", tenantID)
+		}
+		if err := cq.Scan(&calls).Error; err != nil {
+			return nil, err
+		}
+		numberOf := make(map[string]uint, len(calls))
+		for _, c := range calls {
+			numberOf[c.CallID] = c.InboundTrunkNumberID
+		}
+		for _, w := range waiting {
+			id, ok := numberOf[w.InboundCallID]
+			if !ok {
+				continue // other tenant
+			}
+			q := queue(id, "")
+			q.Waiting++
+			if wait := int64(now.Sub(w.Since) / time.Second); wait > q.LongestWaitSec {
+				q.LongestWaitSec = wait
+			}
+		}
+	}
+
+	counts, err := persist.CountCallsByInboundNumber(ctx, s.db, tenantID, today, now.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	for id, c := range counts {
+		if q := byNumber[id]; q != nil {
+			q.CallsToday, q.TransferredToday = c[0], c[1]
+		}
+	}
+	for _, r := range rows {
+		ws := models.NormalizeACDWorkState(r.WorkState)
+		if r.TrunkNumberID > 0 {
+			if q := byNumber[r.TrunkNumberID]; q != nil {
+				q.AgentsByState[ws]++
+			}
+			continue
+		}
+		for _, q := range out {
+			if q.TrunkNumberID > 0 {
+				q.AgentsByState[ws]++
+			}
+		}
+	}
+
+	list := make([]WallboardQueue, 0, len(out))
+	for _, q := range out {
+		list = append(list, *q)
+	}
+	return list, nil
+}
+
+// AgentDailyReport returns per-seat, per-day metrics for local days in
+// [from, to) (from at midnight; today is cut at now). targetID 0 means all
+// seats; tenantID 0 all tenants.
+func (s *WallboardService) AgentDailyReport(ctx context.Context, tenantID, targetID uint, from, to time.Time) ([]AgentDayReport, error) {
+	now := time.Now()
+	rows, err := s.listTargets(ctx, tenantID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := s.statesAt(ctx, tenantID, rows, from)
+	if err != nil {
+		return nil, err
+	}
+	evs, err := models.ListACDWorkStateEvents(ctx, s.db, tenantID, targetID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byTarget := groupWorkStateEvents(evs)
+	callRows, err := persist.ListAgentCallRows(ctx, s.db, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	callStats := persist.AggregateAgentCalls(callRows, from.Location())
+
+	if now.Before(to) {
+		to = now
+	}
+	var out []AgentDayReport
+	for _, r := range rows {
+		segs := models.ACDStateSegments(initial[r.ID], byTarget[r.ID], from, to)
+		shifts, scheduled := models.ACDShiftIntervals(r.ShiftScheduleJSON, from, to, models.ACDShiftTimeLocation())
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			end := day.AddDate(0, 0, 1)
+			if end.After(to) {
+				end = to
+			}
+			key := day.Format("2006-01-02")
+			st := models.ComputeACDTimelineStats(segs, shifts, scheduled, day, end)
+			rep := AgentDayReport{
+				Day:          key,
+				ACDTargetID:  r.ID,
+				Name:         strings.TrimSpace(r.Name),
+				StateSeconds: st.StateSeconds,
+				BreakSeconds: st.BreakSeconds,
+				Occupancy:    st.Occupancy,
+				Adherence:    st.Adherence,
+			}
+			if st := callStats[persist.AgentDayKey{ACDTargetID: r.ID, Day: key}]; st != nil {
+				rep.AgentCallStats = *st
+			}
+			out = append(out, rep)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].ACDTargetID < out[j].ACDTargetID
+	})
+	return out, nil
+}
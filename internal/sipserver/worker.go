@@ -267,7 +267,7 @@ func (s *CampaignService) processContact(ctx context.Context, dialer Dialer, cam
 			zap.String("script_id", strings.TrimSpace(campaign.ScriptID)),
 		)
 	}
-	target, err := buildDialTarget(s.db, campaign, contact)
+	target, fallbacks, grouped, err := s.buildDialPlan(campaign, contact)
 	if err != nil {
 		_ = s.db.WithContext(ctx).Model(&models.SIPCampaignContact{}).Where("id = ?", contact.ID).
 			Updates(map[string]any{"status": constants.SIPCampaignContactFailed, "failure_reason": err.Error()}).Error
@@ -296,6 +296,7 @@ func (s *CampaignService) processContact(ctx context.Context, dialer Dialer, cam
 	if resolveFromRegister {
 		if rt, ok := s.resolveRegisteredDialTarget(ctx, contact.Phone); ok {
 			target = rt
+			fallbacks, grouped = nil, false
 			targetSource = "register_resolved"
 			targetResolveNote = "resolver_or_db_hit"
 			if logger.Lg != nil {
@@ -357,6 +358,12 @@ func (s *CampaignService) processContact(ctx context.Context, dialer Dialer, cam
 		CallerUser:        strings.TrimSpace(contact.CallerUser),
 		CallerDisplayName: strings.TrimSpace(contact.CallerName),
 		DialTenantID:      campaign.TenantID,
+		Fallbacks:         fallbacks,
+	}
+	if grouped {
+		// 主叫随成员切换（prepareTrunk 仅在 req.CallerUser 为空时改写 From）。
+		req.CallerUser = ""
+		req.CallerDisplayName = ""
 	}
 	if req.CallerUser != "" && req.CallerDisplayName == "" {
 		req.CallerDisplayName = strings.TrimSpace(target.CallerDisplayName)
@@ -570,6 +577,18 @@ func (s *CampaignService) isDuplicateWithinWindow(ctx context.Context, contactID
 	return count > 0
 }
 
+// trunkDigestCredentials 把中继上配置的出局鉴权账号转成 outbound 的摘要凭据；未配置返回 nil。
+func trunkDigestCredentials(cfg models.TrunkTransferConfig) *outbound.DigestCredentials {
+	if cfg.AuthUsername == "" || cfg.AuthPassword == "" {
+		return nil
+	}
+	return &outbound.DigestCredentials{
+		Username: cfg.AuthUsername,
+		Password: cfg.AuthPassword,
+		Realm:    cfg.AuthRealm,
+	}
+}
+
 // buildDialTarget 决定一通外呼的 INVITE Request-URI + 信令地址。
 //
 // 网关（host:port）必须由数据库提供，且必须显式指定主叫号码（contact.callerUser）：
@@ -578,6 +597,7 @@ func (s *CampaignService) isDuplicateWithinWindow(ctx context.Context, contactID
 //  3. 再由其 Trunk.LocalAddr 解析 host:port。
 //
 // 未指定 callerUser 或 callerUser 不合法时，直接失败（不做“随便挑一条外呼号码”的回退）。
+// 中继组 / 最低成本路由见 CampaignService.buildDialPlan。
 func buildDialTarget(db *gorm.DB, c models.SIPCampaign, ct models.SIPCampaignContact) (outbound.DialTarget, error) {
 	callerUser := strings.TrimSpace(ct.CallerUser)
 	if callerUser == "" {
@@ -587,30 +607,68 @@ func buildDialTarget(db *gorm.DB, c models.SIPCampaign, ct models.SIPCampaignCon
 	if !ok {
 		return outbound.DialTarget{}, fmt.Errorf("callerUser=%q 未命中可外呼中继号码（需属于当前租户且 direction ∈ {outbound,both,all}）", callerUser)
 	}
-	host := cfg.Host
-	port := cfg.Port
-	sigAddr := cfg.SignalingAddr()
+	return dialTargetForTrunk(c, ct, cfg), nil
+}
 
-	if u := strings.TrimSpace(ct.RequestURI); u != "" {
-		return outbound.DialTarget{
-			RequestURI:        u,
-			SignalingAddr:     sigAddr,
-			CallerUser:        cfg.CallerUser,
-			CallerDisplayName: cfg.CallerDisplay,
-		}, nil
-	}
-	if tmpl := strings.TrimSpace(c.RequestURIFmt); tmpl != "" {
-		return outbound.DialTarget{
-			RequestURI:        fmt.Sprintf(tmpl, strings.TrimSpace(ct.Phone)),
-			SignalingAddr:     sigAddr,
-			CallerUser:        cfg.CallerUser,
-			CallerDisplayName: cfg.CallerDisplay,
-		}, nil
-	}
-	return outbound.DialTarget{
-		RequestURI:        fmt.Sprintf("sip:%s@%s:%d", strings.TrimSpace(ct.Phone), host, port),
-		SignalingAddr:     sigAddr,
+// dialTargetForTrunk 用一条出局配置拼出 DialTarget：Request-URI 依次取联系人 requestUri、
+// 活动 requestUriFmt，否则为 sip:phone@网关。
+func dialTargetForTrunk(c models.SIPCampaign, ct models.SIPCampaignContact, cfg models.TrunkTransferConfig) outbound.DialTarget {
+	t := outbound.DialTarget{
+		SignalingAddr:     cfg.SignalingAddr(),
 		CallerUser:        cfg.CallerUser,
 		CallerDisplayName: cfg.CallerDisplay,
-	}, nil
+		Auth:              trunkDigestCredentials(cfg),
+		TrunkID:           cfg.TrunkID,
+		TrunkNumberID:     cfg.TrunkNumberID,
+	}
+	switch {
+	case strings.TrimSpace(ct.RequestURI) != "":
+		t.RequestURI = strings.TrimSpace(ct.RequestURI)
+	case strings.TrimSpace(c.RequestURIFmt) != "":
+		t.RequestURI = fmt.Sprintf(strings.TrimSpace(c.RequestURIFmt), strings.TrimSpace(ct.Phone))
+	default:
+		t.RequestURI = fmt.Sprintf("sip:%s@%s:%d", strings.TrimSpace(ct.Phone), cfg.Host, cfg.Port)
+	}
+	return t
+}
+
+// buildDialPlan 决定一通外呼的首选目标与故障切换备选（按顺序）：
+//  1. 活动绑定了中继组（trunkGroupId）：按组策略排出成员，主叫取自成员号码；
+//  2. 联系人未指定 callerUser 且租户配置了最低成本路由：按被叫前缀匹配路由；
+//  3. 否则回到 buildDialTarget（单一目标，无备选）。
+//
+// grouped 为 true 时主叫由各成员决定，调用方不应再用 contact.callerUser 覆盖 From。
+func (s *CampaignService) buildDialPlan(c models.SIPCampaign, ct models.SIPCampaignContact) (target outbound.DialTarget, fallbacks []outbound.DialTarget, grouped bool, err error) {
+	s.mu.Lock()
+	router := s.trunkRouter
+	s.mu.Unlock()
+	var plan []models.TrunkGroupCandidate
+	switch {
+	case c.TrunkGroupID > 0:
+		plan, err = router.GroupPlan(c.TenantID, c.TrunkGroupID)
+		if err != nil {
+			return outbound.DialTarget{}, nil, true, err
+		}
+	case strings.TrimSpace(ct.CallerUser) == "":
+		var ok bool
+		plan, ok, err = router.RoutePlan(c.TenantID, ct.Phone)
+		if err != nil {
+			return outbound.DialTarget{}, nil, true, err
+		}
+		if !ok {
+			target, err = buildDialTarget(s.db, c, ct)
+			return target, nil, false, err
+		}
+	default:
+		target, err = buildDialTarget(s.db, c, ct)
+		return target, nil, false, err
+	}
+	if len(plan) == 0 {
+		return outbound.DialTarget{}, nil, true, fmt.Errorf("中继组无可用成员（号码无效、不可外呼或并发已满）")
+	}
+	target = dialTargetForTrunk(c, ct, plan[0].TrunkTransferConfig)
+	for _, m := range plan[1:] {
+		fallbacks = append(fallbacks, dialTargetForTrunk(c, ct, m.TrunkTransferConfig))
+	}
+	return target, fallbacks, true, nil
 }
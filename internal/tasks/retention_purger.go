@@ -0,0 +1,247 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"github.com/LinByte/VoiceServer/pkg/stores"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"github.com/LinByte/VoiceServer/pkg/voice/cdr"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// EnvRetentionPurgeCron is the 5-field cron spec of the purge run
+	// (default daily 03:30 server time). "off" disables the scheduler.
+	EnvRetentionPurgeCron = "RETENTION_PURGE_CRON"
+	// EnvRetentionPurgeBatch bounds rows touched per statement (default 500).
+	EnvRetentionPurgeBatch = "RETENTION_PURGE_BATCH"
+
+	defaultRetentionPurgeCron  = "30 3 * * *"
+	defaultRetentionPurgeBatch = 500
+)
+
+// RetentionPurger deletes recordings, transcripts, script runs, campaign
+// events and rotated CDR files older than each tenant's retention policy.
+// Calls on legal hold are skipped; every non-empty pass is written to
+// retention_purge_logs.
+type RetentionPurger struct {
+	db     *gorm.DB
+	spec   string
+	batch  int
+	cron   *cron.Cron
+	mu     sync.Mutex // serialises runs (cron tick vs. manual Run)
+	nowFn  func() time.Time
+	cdrDir string
+}
+
+func NewRetentionPurger(db *gorm.DB) *RetentionPurger {
+	spec := strings.TrimSpace(utils.GetEnv(EnvRetentionPurgeCron))
+	if spec == "" {
+		spec = defaultRetentionPurgeCron
+	}
+	batch := utils.GetIntEnvWithDefault(EnvRetentionPurgeBatch, defaultRetentionPurgeBatch)
+	if batch <= 0 {
+		batch = defaultRetentionPurgeBatch
+	}
+	return &RetentionPurger{
+		db:     db,
+		spec:   spec,
+		batch:  batch,
+		nowFn:  time.Now,
+		cdrDir: cdr.DirFromEnv(),
+	}
+}
+
+func (p *RetentionPurger) Start() {
+	if p == nil || p.db == nil || strings.EqualFold(p.spec, "off") {
+		return
+	}
+	c := cron.New()
+	if _, err := c.AddFunc(p.spec, p.safeRun); err != nil {
+		if logger.Lg != nil {
+			logger.Lg.Warn("retention purger: bad cron spec", zap.String("spec", p.spec), zap.Error(err))
+		}
+		return
+	}
+	p.cron = c
+	c.Start()
+}
+
+func (p *RetentionPurger) Stop() {
+	if p == nil || p.cron == nil {
+		return
+	}
+	<-p.cron.Stop().Done()
+}
+
+func (p *RetentionPurger) safeRun() {
+	defer func() {
+		if r := recover(); r != nil && logger.Lg != nil {
+			logger.Lg.Error("retention purger panic recovered", zap.Any("panic", r))
+		}
+	}()
+	p.Run(context.Background())
+}
+
+// Run performs one full purge pass.
+func (p *RetentionPurger) Run(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tenants, err := models.ListRetentionTenants(ctx, p.db)
+	if err != nil {
+		if logger.Lg != nil {
+			logger.Lg.Warn("retention purger: list tenants failed", zap.Error(err))
+		}
+		return
+	}
+	now := p.nowFn()
+	for _, t := range tenants {
+		pol, err := models.ParseRetentionPolicy(t.RetentionConfig)
+		if err != nil {
+			if logger.Lg != nil {
+				logger.Lg.Warn("retention purger: bad tenant policy", zap.Uint("tenantId", t.ID), zap.Error(err))
+			}
+			continue
+		}
+		eff := pol.Resolve()
+		if eff.RecordingDays > 0 {
+			p.purgeRecordings(ctx, t.ID, cutoffDays(now, eff.RecordingDays))
+		}
+		if eff.TranscriptDays > 0 {
+			p.purgeRows(ctx, t.ID, models.RetentionKindTranscript, cutoffDays(now, eff.TranscriptDays), persist.PurgeSIPCallTurnsBefore)
+		}
+		if eff.ScriptRunDays > 0 {
+			p.purgeRows(ctx, t.ID, models.RetentionKindScriptRun, cutoffDays(now, eff.ScriptRunDays), models.PurgeSIPScriptRunsBefore)
+		}
+		if eff.CampaignEventDays > 0 {
+			p.purgeRows(ctx, t.ID, models.RetentionKindCampaignEvent, cutoffDays(now, eff.CampaignEventDays), models.PurgeSIPCampaignEventsBefore)
+		}
+	}
+	if days := int(utils.GetIntEnv(models.EnvRetentionCDRDays)); days > 0 {
+		p.purgeCDRFiles(ctx, cutoffDays(now, days))
+	}
+}
+
+func cutoffDays(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, -days)
+}
+
+func (p *RetentionPurger) purgeRecordings(ctx context.Context, tenantID uint, cutoff time.Time) {
+	row := models.RetentionPurgeLog{TenantID: tenantID, Kind: models.RetentionKindRecording, Cutoff: cutoff, StartedAt: time.Now()}
+	store := envelope.Wrap(stores.Default(), tenantID)
+	var lastErr error
+	var after uint
+	for {
+		calls, err := persist.ListSIPCallRecordingsBefore(ctx, p.db, tenantID, cutoff, after, p.batch)
+		if err != nil {
+			lastErr = err
+			break
+		}
+		if len(calls) == 0 {
+			break
+		}
+		done := make([]uint, 0, len(calls))
+		for _, c := range calls {
+			after = c.ID
+			key := persist.RecordingStorageKey(c.RecordingURL)
+			if key != "" && store != nil {
+				if err := store.Delete(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					row.Failures++
+					lastErr = err
+					continue
+				}
+				row.Objects++
+			}
+			done = append(done, c.ID)
+		}
+		if err := persist.MarkSIPCallRecordingsPurged(ctx, p.db, done, time.Now()); err != nil {
+			lastErr = err
+			break
+		}
+		row.Rows += int64(len(done))
+		if len(calls) < p.batch {
+			break
+		}
+	}
+	p.finish(ctx, &row, lastErr)
+}
+
+type batchPurgeFunc func(ctx context.Context, db *gorm.DB, tenantID uint, cutoff time.Time, limit int) (int64, error)
+
+func (p *RetentionPurger) purgeRows(ctx context.Context, tenantID uint, kind string, cutoff time.Time, fn batchPurgeFunc) {
+	row := models.RetentionPurgeLog{TenantID: tenantID, Kind: kind, Cutoff: cutoff, StartedAt: time.Now()}
+	var lastErr error
+	for {
+		n, err := fn(ctx, p.db, tenantID, cutoff, p.batch)
+		row.Rows += n
+		if err != nil {
+			row.Failures++
+			lastErr = err
+			break
+		}
+		if n < int64(p.batch) {
+			break
+		}
+	}
+	p.finish(ctx, &row, lastErr)
+}
+
+func (p *RetentionPurger) purgeCDRFiles(ctx context.Context, cutoff time.Time) {
+	row := models.RetentionPurgeLog{Kind: models.RetentionKindCDRFile, Cutoff: cutoff, StartedAt: time.Now()}
+	ids, err := persist.ListLegalHoldCallIDs(ctx, p.db)
+	if err != nil {
+		// Without the hold list we cannot tell which files must survive.
+		row.Failures++
+		p.finish(ctx, &row, err)
+		return
+	}
+	held := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		held[id] = struct{}{}
+	}
+	res, err := cdr.PurgeRotated(p.cdrDir, "cdr", cutoff, held)
+	row.Objects = len(res.Removed)
+	row.Failures = res.Failed
+	if len(res.Held) > 0 {
+		row.Detail = "kept on legal hold: " + strings.Join(res.Held, ",")
+	}
+	p.finish(ctx, &row, err)
+}
+
+func (p *RetentionPurger) finish(ctx context.Context, row *models.RetentionPurgeLog, err error) {
+	row.FinishedAt = time.Now()
+	if err != nil {
+		if row.Detail != "" {
+			row.Detail += "; "
+		}
+		row.Detail += err.Error()
+		if logger.Lg != nil {
+			logger.Lg.Warn("retention purge failed", zap.Uint("tenantId", row.TenantID), zap.String("kind", row.Kind), zap.Error(err))
+		}
+	}
+	if row.Rows == 0 && row.Objects == 0 && row.Failures == 0 && row.Detail == "" {
+		return
+	}
+	if logger.Lg != nil {
+		logger.Lg.Info("retention purge",
+			zap.Uint("tenantId", row.TenantID), zap.String("kind", row.Kind), zap.Time("cutoff", row.Cutoff),
+			zap.Int64("rows", row.Rows), zap.Int("objects", row.Objects), zap.Int("failures", row.Failures))
+	}
+	if err := models.InsertRetentionPurgeLog(ctx, p.db, row); err != nil && logger.Lg != nil {
+		logger.Lg.Warn("retention purge log insert failed", zap.Error(err))
+	}
+}
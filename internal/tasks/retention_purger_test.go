@@ -0,0 +1,268 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/models"
+	"github.com/LinByte/VoiceServer/pkg/sip/persist"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testTenant = 7
+
+func newPurgerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&persist.SIPCall{}, &models.SIPCampaign{}, &models.SIPScriptRun{},
+		&models.SIPCampaignEvent{}, &models.RetentionPurgeLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newTestPurger(db *gorm.DB, batch int) *RetentionPurger {
+	return &RetentionPurger{db: db, batch: batch, nowFn: time.Now}
+}
+
+func purgeLogs(t *testing.T, db *gorm.DB, kind string) []models.RetentionPurgeLog {
+	t.Helper()
+	var out []models.RetentionPurgeLog
+	if err := db.Where("kind = ?", kind).Find(&out).Error; err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestPurgeRecordings_SkipsLegalHold(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("UPLOAD_DIR", dir)
+	db := newPurgerTestDB(t)
+	now := time.Now()
+	old, cutoff := now.AddDate(0, 0, -40), now.AddDate(0, 0, -30)
+
+	calls := []persist.SIPCall{
+		{ID: 1, CallID: "old-1", TenantID: testTenant, CreatedAt: old},
+		{ID: 2, CallID: "old-2", TenantID: testTenant, CreatedAt: old},
+		{ID: 3, CallID: "old-3", TenantID: testTenant, CreatedAt: old},
+		{ID: 4, CallID: "held", TenantID: testTenant, CreatedAt: old, LegalHold: true},
+		{ID: 5, CallID: "recent", TenantID: testTenant, CreatedAt: now},
+		{ID: 6, CallID: "other-tenant", TenantID: testTenant + 1, CreatedAt: old},
+	}
+	for i := range calls {
+		key := "sip/recordings/" + calls[i].CallID + ".wav"
+		calls[i].RecordingURL = "/uploads/" + key
+		path := filepath.Join(dir, key)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("RIFF"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&calls).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Batch 2 over three purgeable calls: one full page, one short page.
+	newTestPurger(db, 2).purgeRecordings(context.Background(), testTenant, cutoff)
+
+	purged := map[string]bool{"old-1": true, "old-2": true, "old-3": true}
+	var rows []persist.SIPCall
+	if err := db.Order("id").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range rows {
+		_, statErr := os.Stat(filepath.Join(dir, "sip/recordings", c.CallID+".wav"))
+		if purged[c.CallID] {
+			if c.RecordingURL != "" || c.RecordingPurgedAt == nil || !errors.Is(statErr, os.ErrNotExist) {
+				t.Fatalf("%s not purged: url=%q purgedAt=%v stat=%v", c.CallID, c.RecordingURL, c.RecordingPurgedAt, statErr)
+			}
+			continue
+		}
+		if c.RecordingURL == "" || c.RecordingPurgedAt != nil || statErr != nil {
+			t.Fatalf("%s must survive: url=%q stat=%v", c.CallID, c.RecordingURL, statErr)
+		}
+	}
+	logs := purgeLogs(t, db, models.RetentionKindRecording)
+	if len(logs) != 1 || logs[0].Rows != 3 || logs[0].Objects != 3 || logs[0].Failures != 0 {
+		t.Fatalf("purge log = %+v", logs)
+	}
+}
+
+func TestPurgeSIPCallTurns_SkipsLegalHold(t *testing.T) {
+	db := newPurgerTestDB(t)
+	now := time.Now()
+	old, cutoff := now.AddDate(0, 0, -40), now.AddDate(0, 0, -30)
+	turns := datatypes.JSON(`[{"asrText":"hi"}]`)
+	calls := []persist.SIPCall{
+		{ID: 1, CallID: "old-1", TenantID: testTenant, CreatedAt: old, Turns: turns},
+		{ID: 2, CallID: "old-2", TenantID: testTenant, CreatedAt: old, Turns: turns},
+		{ID: 3, CallID: "old-3", TenantID: testTenant, CreatedAt: old, Turns: turns},
+		{ID: 4, CallID: "held", TenantID: testTenant, CreatedAt: old, Turns: turns, LegalHold: true},
+		{ID: 5, CallID: "recent", TenantID: testTenant, CreatedAt: now, Turns: turns},
+		{ID: 6, CallID: "other-tenant", TenantID: testTenant + 1, CreatedAt: old, Turns: turns},
+	}
+	if err := db.Create(&calls).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	newTestPurger(db, 2).purgeRows(context.Background(), testTenant, models.RetentionKindTranscript, cutoff, persist.PurgeSIPCallTurnsBefore)
+
+	var kept []string
+	if err := db.Model(&persist.SIPCall{}).Where("turns IS NOT NULL").Order("id").Pluck("call_id", &kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"held", "recent", "other-tenant"}; !equalStrings(kept, want) {
+		t.Fatalf("calls with turns = %v, want %v", kept, want)
+	}
+	logs := purgeLogs(t, db, models.RetentionKindTranscript)
+	if len(logs) != 1 || logs[0].Rows != 3 {
+		t.Fatalf("purge log = %+v", logs)
+	}
+}
+
+func TestPurgeCampaignScoped_SkipsLegalHold(t *testing.T) {
+	db := newPurgerTestDB(t)
+	now := time.Now()
+	old, cutoff := now.AddDate(0, 0, -40), now.AddDate(0, 0, -30)
+	campaigns := []models.SIPCampaign{
+		{BaseModel: models.BaseModel{ID: 100}, TenantID: testTenant, Name: "mine"},
+		{BaseModel: models.BaseModel{ID: 200}, TenantID: testTenant + 1, Name: "theirs"},
+	}
+	if err := db.Create(&campaigns).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&persist.SIPCall{ID: 1, CallID: "held", TenantID: testTenant, CreatedAt: old, LegalHold: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	type seed struct {
+		campaignID uint
+		callID     string
+		at         time.Time
+	}
+	seeds := []seed{
+		{100, "plain-1", old},
+		{100, "plain-2", old},
+		{100, "", old}, // campaign-level row without a call
+		{100, "held", old},
+		{100, "plain-3", now},
+		{200, "plain-4", old},
+	}
+	var runs []models.SIPScriptRun
+	var events []models.SIPCampaignEvent
+	for i, s := range seeds {
+		base := models.BaseModel{ID: uint(i + 1), CreatedAt: s.at}
+		runs = append(runs, models.SIPScriptRun{BaseModel: base, CampaignID: s.campaignID, CallID: s.callID, StepID: "s"})
+		events = append(events, models.SIPCampaignEvent{BaseModel: base, CampaignID: s.campaignID, CallID: s.callID,
+			Type: "script", Level: "info", Meta: datatypes.JSON(`{}`)})
+	}
+	if err := db.Create(&runs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	p := newTestPurger(db, 2)
+	p.purgeRows(context.Background(), testTenant, models.RetentionKindScriptRun, cutoff, models.PurgeSIPScriptRunsBefore)
+	p.purgeRows(context.Background(), testTenant, models.RetentionKindCampaignEvent, cutoff, models.PurgeSIPCampaignEventsBefore)
+
+	want := []string{"held", "plain-3", "plain-4"}
+	for _, tc := range []struct {
+		kind  string
+		model any
+	}{
+		{models.RetentionKindScriptRun, &models.SIPScriptRun{}},
+		{models.RetentionKindCampaignEvent, &models.SIPCampaignEvent{}},
+	} {
+		var kept []string
+		if err := db.Unscoped().Model(tc.model).Order("id").Pluck("call_id", &kept).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !equalStrings(kept, want) {
+			t.Fatalf("%s kept %v, want %v", tc.kind, kept, want)
+		}
+		logs := purgeLogs(t, db, tc.kind)
+		if len(logs) != 1 || logs[0].Rows != 3 {
+			t.Fatalf("%s purge log = %+v", tc.kind, logs)
+		}
+	}
+}
+
+func TestPurgeRows_BatchingStops(t *testing.T) {
+	db := newPurgerTestDB(t)
+	cases := []struct {
+		name      string
+		pages     []int64
+		err       error
+		wantCalls int
+		wantRows  int64
+	}{
+		{name: "short page ends", pages: []int64{3, 3, 1}, wantCalls: 3, wantRows: 7},
+		{name: "empty page ends", pages: []int64{3, 3, 0}, wantCalls: 3, wantRows: 6},
+		{name: "nothing to do", pages: []int64{0}, wantCalls: 1, wantRows: 0},
+		{name: "error ends", pages: []int64{3}, err: errors.New("db gone"), wantCalls: 1, wantRows: 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			fn := func(_ context.Context, _ *gorm.DB, _ uint, _ time.Time, limit int) (int64, error) {
+				if limit != 3 {
+					t.Fatalf("limit = %d, want batch 3", limit)
+				}
+				if calls >= len(tc.pages) {
+					t.Fatalf("called again after page %d", calls)
+				}
+				n := tc.pages[calls]
+				calls++
+				return n, tc.err
+			}
+			kind := "test-" + tc.name
+			newTestPurger(db, 3).purgeRows(context.Background(), testTenant, kind, time.Now(), fn)
+			if calls != tc.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tc.wantCalls)
+			}
+			logs := purgeLogs(t, db, kind)
+			if tc.wantRows == 0 && tc.err == nil {
+				if len(logs) != 0 {
+					t.Fatalf("empty pass must not be logged: %+v", logs)
+				}
+				return
+			}
+			if len(logs) != 1 || logs[0].Rows != tc.wantRows || (tc.err != nil) != (logs[0].Failures == 1) {
+				t.Fatalf("purge log = %+v", logs)
+			}
+		})
+	}
+}
+
+func equalStrings(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	g := append([]string(nil), got...)
+	w := append([]string(nil), want...)
+	sort.Strings(g)
+	sort.Strings(w)
+	for i := range g {
+		if g[i] != w[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -45,6 +45,11 @@ type SIPConfig struct {
 	SIPVADBargeIn      bool    `env:"SIP_VAD_BARGE_IN"`
 	SIPVADThreshold    float64 `env:"SIP_VAD_THRESHOLD"`
 	SIPVADConsecFrames int     `env:"SIP_VAD_CONSEC_FRAMES"`
+	SIPVADEngine       string  `env:"SIP_VAD_ENGINE"`
+	SIPVADModelPath    string  `env:"SIP_VAD_MODEL_PATH"`
+	SIPEndOfTurn       bool    `env:"SIP_END_OF_TURN"`
+	SIPEOTFastSilence  int     `env:"SIP_EOT_FAST_SILENCE_MS"`
+	SIPEOTHoldMax      int     `env:"SIP_EOT_HOLD_MS"`
 }
 
 // ServerConfig server configuration
@@ -202,6 +207,11 @@ func Load() error {
 			SIPVADBargeIn:      getBoolOrDefault("SIPVADBargeIn", true),
 			SIPVADThreshold:    getFloatOrDefault("SIP_VAD_THRESHOLD", 3200.0),
 			SIPVADConsecFrames: getIntOrDefault("SIP_VAD_CONSEC_FRAMES", 4),
+			SIPVADEngine:       getStringOrDefault("SIP_VAD_ENGINE", "rms"),
+			SIPVADModelPath:    getStringOrDefault("SIP_VAD_MODEL_PATH", "models/vad_gru.json"),
+			SIPEndOfTurn:       getBoolOrDefault("SIP_END_OF_TURN", false),
+			SIPEOTFastSilence:  getIntOrDefault("SIP_EOT_FAST_SILENCE_MS", 300),
+			SIPEOTHoldMax:      getIntOrDefault("SIP_EOT_HOLD_MS", 700),
 		},
 		JWT: JWTConfig{
 			Algorithm:    getStringOrDefault("JWT_ALGORITHM", "RS256"),
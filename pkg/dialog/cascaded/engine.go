@@ -59,6 +59,11 @@ type Engine struct {
 	// persistence (test default).
 	turnPersister TurnPersister
 
+	// Optional semantic end-of-turn detection. When set, a turnStage
+	// is inserted after the ASR side (asr / hotword) so early commits
+	// and held incomplete finals are settled before the LLM sees them.
+	endOfTurn *EndOfTurnConfig
+
 	attached   atomic.Bool // single-shot Attach guard
 	detachOnce sync.Once   // idempotent Detach guard
 
@@ -141,6 +146,14 @@ func WithTurnPersister(p TurnPersister) Option {
 	return func(e *Engine) { e.turnPersister = p }
 }
 
+// WithEndOfTurn enables semantic end-of-turn detection: a turnStage
+// between the ASR side and the LLM that commits complete-looking
+// partials after a short VAD silence and holds finals that read cut
+// off. See turn_stage.go.
+func WithEndOfTurn(cfg EndOfTurnConfig) Option {
+	return func(e *Engine) { e.endOfTurn = &cfg }
+}
+
 // New builds an Engine for the supplied Config. Apply Options for
 // VAD / providers / future hooks.
 func New(cfg engine.Config, opts ...Option) *Engine {
@@ -267,6 +280,19 @@ func (e *Engine) Attach(ctx context.Context, port engine.MediaPort, lg engine.Lo
 		stages = out
 		lg.Info("cascaded engine: hotword stage enabled")
 	}
+	if e.endOfTurn != nil {
+		// Splice after the last ASR-side stage so the completer sees
+		// hotword-corrected text: VAD? → ASR → hotword? → turn → LLM.
+		ts := newTurnStage(*e.endOfTurn)
+		at := -1
+		for i, st := range stages {
+			if n := st.Name(); n == "asr" || n == "hotword" {
+				at = i
+			}
+		}
+		stages = append(stages[:at+1], append([]pipeline.Stage{ts}, stages[at+1:]...)...)
+		lg.Info("cascaded engine: end-of-turn stage enabled")
+	}
 	if e.vadDetector != nil {
 		vs := newVADStage(
 			e.vadDetector,
@@ -0,0 +1,216 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cascaded
+
+// LanguageRaceASR — first-utterance language identification by racing
+// one recogniser per candidate language.
+//
+// Until the caller's first final transcript every PCM chunk is fed to
+// all candidates. Once one candidate commits a final the race waits up
+// to `wait` for the others, hands every final to langid.Decide and
+// keeps only the winner: PCM and callbacks then flow to / from that
+// recogniser alone and the losers are closed. onDecide runs before the
+// winning transcript is forwarded, so a caller-side hook can switch the
+// LLM prompt and TTS voice before the first reply is generated.
+//
+// Before the decision only the default (first) candidate's partials
+// are forwarded, and only its errors keep their fatal flag — a losing
+// vendor session dying must not end the call.
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/dialog/langid"
+)
+
+// DefaultLanguageRaceWait is how long the race waits for the other
+// candidates after the first final transcript.
+const DefaultLanguageRaceWait = 800 * time.Millisecond
+
+// LanguageCandidate is one recogniser entered in the race.
+type LanguageCandidate struct {
+	Lang string // BCP-47 tag the recogniser is configured for
+	ASR  ASRRecognizer
+	// Close releases the recogniser once it lost the race (optional).
+	Close func()
+}
+
+// LanguageRaceASR implements ASRRecognizer over several candidates.
+type LanguageRaceASR struct {
+	cands    []LanguageCandidate
+	tags     []string
+	wait     time.Duration
+	onDecide func(lang string)
+
+	mu     sync.Mutex
+	emitMu sync.Mutex // keeps the decision's final ahead of later winner callbacks
+	onText func(text string, isFinal bool)
+	onErr  func(err error, fatal bool)
+	finals map[int]string
+	timer  *time.Timer
+	winner int // -1 until decided
+}
+
+// NewLanguageRaceASR races cands (the first is the default language).
+// wait <= 0 uses DefaultLanguageRaceWait; onDecide may be nil.
+func NewLanguageRaceASR(cands []LanguageCandidate, wait time.Duration, onDecide func(lang string)) *LanguageRaceASR {
+	if wait <= 0 {
+		wait = DefaultLanguageRaceWait
+	}
+	r := &LanguageRaceASR{
+		cands:    cands,
+		wait:     wait,
+		onDecide: onDecide,
+		finals:   map[int]string{},
+		winner:   -1,
+	}
+	for i, c := range cands {
+		r.tags = append(r.tags, c.Lang)
+		i := i
+		c.ASR.SetTextCallback(func(text string, isFinal bool) { r.candidateText(i, text, isFinal) })
+		c.ASR.SetErrorCallback(func(err error, fatal bool) { r.candidateErr(i, err, fatal) })
+	}
+	return r
+}
+
+// Language returns the decided language, "" while the race is open.
+func (r *LanguageRaceASR) Language() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.winner < 0 {
+		return ""
+	}
+	return r.cands[r.winner].Lang
+}
+
+// ProcessPCM implements ASRRecognizer. Before the decision the default
+// candidate's error is returned; the others' are dropped.
+func (r *LanguageRaceASR) ProcessPCM(ctx context.Context, pcm []byte) error {
+	r.mu.Lock()
+	w := r.winner
+	r.mu.Unlock()
+	if w >= 0 {
+		return r.cands[w].ASR.ProcessPCM(ctx, pcm)
+	}
+	var first error
+	for i, c := range r.cands {
+		if err := c.ASR.ProcessPCM(ctx, pcm); err != nil && i == 0 {
+			first = err
+		}
+	}
+	return first
+}
+
+// SetTextCallback implements ASRRecognizer.
+func (r *LanguageRaceASR) SetTextCallback(cb func(text string, isFinal bool)) {
+	r.mu.Lock()
+	r.onText = cb
+	r.mu.Unlock()
+}
+
+// SetErrorCallback implements ASRRecognizer.
+func (r *LanguageRaceASR) SetErrorCallback(cb func(err error, fatal bool)) {
+	r.mu.Lock()
+	r.onErr = cb
+	r.mu.Unlock()
+}
+
+func (r *LanguageRaceASR) candidateText(i int, text string, isFinal bool) {
+	r.mu.Lock()
+	if r.winner >= 0 || (!isFinal && i == 0) {
+		forward := r.winner == i || r.winner < 0
+		cb := r.onText
+		r.mu.Unlock()
+		if forward && cb != nil {
+			r.emitMu.Lock()
+			cb(text, isFinal)
+			r.emitMu.Unlock()
+		}
+		return
+	}
+	if !isFinal || strings.TrimSpace(text) == "" {
+		r.mu.Unlock()
+		return
+	}
+	if prev := r.finals[i]; prev != "" {
+		text = prev + " " + text
+	}
+	r.finals[i] = text
+	if len(r.finals) < len(r.cands) {
+		if r.timer == nil {
+			r.timer = time.AfterFunc(r.wait, r.decide)
+		}
+		r.mu.Unlock()
+		return
+	}
+	r.decideLocked()
+}
+
+func (r *LanguageRaceASR) candidateErr(i int, err error, fatal bool) {
+	r.mu.Lock()
+	cb := r.onErr
+	w := r.winner
+	r.mu.Unlock()
+	if cb == nil || err == nil || (w >= 0 && w != i) {
+		return
+	}
+	if w < 0 && i != 0 {
+		fatal = false
+	}
+	cb(err, fatal)
+}
+
+func (r *LanguageRaceASR) decide() {
+	r.mu.Lock()
+	if r.winner >= 0 {
+		r.mu.Unlock()
+		return
+	}
+	r.decideLocked()
+}
+
+// decideLocked picks the winner; called with r.mu held, releases it.
+func (r *LanguageRaceASR) decideLocked() {
+	results := make([]langid.Result, 0, len(r.finals))
+	for i, c := range r.cands {
+		if t, ok := r.finals[i]; ok {
+			results = append(results, langid.Result{Lang: c.Lang, Text: t})
+		}
+	}
+	lang := langid.Decide(results, r.tags)
+	r.winner = 0
+	for i, t := range r.tags {
+		if t == lang {
+			r.winner = i
+			break
+		}
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	text := r.finals[r.winner]
+	r.finals = nil
+	cb := r.onText
+	var losers []func()
+	for i, c := range r.cands {
+		if i != r.winner && c.Close != nil {
+			losers = append(losers, c.Close)
+		}
+	}
+	r.emitMu.Lock()
+	r.mu.Unlock()
+	if r.onDecide != nil {
+		r.onDecide(r.cands[r.winner].Lang)
+	}
+	if text != "" && cb != nil {
+		cb(text, true)
+	}
+	r.emitMu.Unlock()
+	for _, c := range losers {
+		go c()
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cascaded
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type raceSink struct {
+	mu    sync.Mutex
+	texts []string
+	errs  []bool
+}
+
+func (s *raceSink) text(text string, isFinal bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if isFinal {
+		text = "F:" + text
+	}
+	s.texts = append(s.texts, text)
+}
+
+func (s *raceSink) err(_ error, fatal bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, fatal)
+}
+
+func (s *raceSink) snapshot() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.texts...)
+}
+
+func newRace(t *testing.T, wait time.Duration) (*LanguageRaceASR, []*fakeASR, *raceSink, *[]string, *atomic.Int32) {
+	t.Helper()
+	asrs := []*fakeASR{{}, {}, {}}
+	var closed atomic.Int32
+	langs := []string{"zh-CN", "yue-HK", "en-US"}
+	cands := make([]LanguageCandidate, len(asrs))
+	for i := range asrs {
+		cands[i] = LanguageCandidate{Lang: langs[i], ASR: asrs[i], Close: func() { closed.Add(1) }}
+	}
+	decided := &[]string{}
+	var mu sync.Mutex
+	r := NewLanguageRaceASR(cands, wait, func(lang string) {
+		mu.Lock()
+		*decided = append(*decided, lang)
+		mu.Unlock()
+	})
+	sink := &raceSink{}
+	r.SetTextCallback(sink.text)
+	r.SetErrorCallback(sink.err)
+	return r, asrs, sink, decided, &closed
+}
+
+func TestLanguageRace_AllFinalsDecideImmediately(t *testing.T) {
+	r, asrs, sink, decided, closed := newRace(t, time.Hour)
+	ctx := context.Background()
+	if err := r.ProcessPCM(ctx, []byte{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range asrs {
+		if a.pcmCount() != 1 {
+			t.Fatalf("pcm not fanned out: %d", a.pcmCount())
+		}
+	}
+	asrs[0].emit("我想", false)
+	asrs[1].emit("我想查", false) // non-default partial: dropped
+	asrs[0].emit("我想查下我个电话费", true)
+	asrs[2].emit("what song cha", true)
+	if r.Language() != "" {
+		t.Fatal("decided too early")
+	}
+	asrs[1].emit("我想查下我嘅電話費", true)
+	if r.Language() != "yue-HK" || len(*decided) != 1 || (*decided)[0] != "yue-HK" {
+		t.Fatalf("lang=%q decided=%v", r.Language(), *decided)
+	}
+	got := sink.snapshot()
+	if len(got) != 2 || got[0] != "我想" || got[1] != "F:我想查下我嘅電話費" {
+		t.Fatalf("forwarded=%v", got)
+	}
+	// Only the winner hears audio and speaks from now on.
+	_ = r.ProcessPCM(ctx, []byte{3})
+	if asrs[1].pcmCount() != 2 || asrs[0].pcmCount() != 1 || asrs[2].pcmCount() != 1 {
+		t.Fatalf("pcm after decision: %d %d %d", asrs[0].pcmCount(), asrs[1].pcmCount(), asrs[2].pcmCount())
+	}
+	asrs[0].emit("ignored", true)
+	asrs[1].emit("好呀", true)
+	if got := sink.snapshot(); got[len(got)-1] != "F:好呀" || len(got) != 3 {
+		t.Fatalf("after decision=%v", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for closed.Load() != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if closed.Load() != 2 {
+		t.Fatalf("losers closed=%d", closed.Load())
+	}
+}
+
+func TestLanguageRace_TimeoutDecidesOnPartialResults(t *testing.T) {
+	r, asrs, sink, _, _ := newRace(t, 20*time.Millisecond)
+	asrs[0].emit("hello I want to check my bill", true)
+	asrs[2].emit("hello I want to check my bill", true)
+	deadline := time.Now().Add(time.Second)
+	for r.Language() == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if r.Language() != "en-US" {
+		t.Fatalf("lang=%q", r.Language())
+	}
+	if got := sink.snapshot(); len(got) != 1 || got[0] != "F:hello I want to check my bill" {
+		t.Fatalf("forwarded=%v", got)
+	}
+}
+
+func TestLanguageRace_ErrorsBeforeDecisionOnlyFatalFromDefault(t *testing.T) {
+	r, asrs, sink, _, _ := newRace(t, time.Hour)
+	asrs[2].emitErr(errors.New("en down"), true)
+	asrs[0].emitErr(errors.New("zh down"), true)
+	sink.mu.Lock()
+	errs := append([]bool(nil), sink.errs...)
+	sink.mu.Unlock()
+	if len(errs) != 2 || errs[0] || !errs[1] {
+		t.Fatalf("errs=%v", errs)
+	}
+	asrs[0].failNext = errors.New("boom")
+	if err := r.ProcessPCM(context.Background(), []byte{1}); err == nil {
+		t.Fatal("default candidate error not returned")
+	}
+	asrs[1].failNext = errors.New("ignored")
+	if err := r.ProcessPCM(context.Background(), []byte{1}); err != nil {
+		t.Fatalf("loser error leaked: %v", err)
+	}
+}
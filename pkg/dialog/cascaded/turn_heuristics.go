@@ -0,0 +1,116 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cascaded
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TurnCompleteness is a TurnCompleter's verdict on a transcript.
+type TurnCompleteness int
+
+const (
+	// TurnUnknown leaves timing to the recogniser's own endpointing.
+	TurnUnknown TurnCompleteness = iota
+	// TurnComplete reads as a finished utterance; it may be answered
+	// as soon as the caller goes quiet.
+	TurnComplete
+	// TurnIncomplete reads as cut mid-sentence ("我想查一下那个",
+	// "I want to and"); the reply waits for more speech.
+	TurnIncomplete
+)
+
+// TurnCompleter judges whether a (partial) transcript is a finished
+// utterance. Implementations must be cheap and safe for concurrent
+// use; a small LLM classifier can sit behind it as long as it answers
+// within a few tens of milliseconds.
+type TurnCompleter interface {
+	Complete(text string) TurnCompleteness
+}
+
+// TurnCompleterFunc adapts a function to TurnCompleter.
+type TurnCompleterFunc func(text string) TurnCompleteness
+
+// Complete implements TurnCompleter.
+func (f TurnCompleterFunc) Complete(text string) TurnCompleteness { return f(text) }
+
+// HeuristicTurnCompleter is the default TurnCompleter: punctuation,
+// sentence-final particles and dangling conjunctions / fillers for
+// Mandarin, Cantonese and English. Anything it cannot call is
+// TurnUnknown.
+var HeuristicTurnCompleter TurnCompleter = TurnCompleterFunc(judgeTurn)
+
+var (
+	// Trailing words that leave a Chinese sentence hanging.
+	zhDangling = []string{
+		"然后", "还有", "還有", "就是", "因为", "因為", "所以", "但是", "可是", "不过", "不過",
+		"如果", "而且", "或者", "还是", "還是", "以及", "比如", "的话", "的話", "那个", "那個",
+		"这个", "這個", "帮我", "幫我", "请问", "請問", "和", "跟", "与", "與", "或", "把",
+		"嗯", "呃", "额", "額",
+		"同埋", "跟住", "之后", "之後", "但係", "即係", "嗰個", "呢個", "我想問",
+	}
+	// Sentence-final particles and short closed answers.
+	zhFinalParticles = "吗嗎呢吧啊呀嘛啦咩喇㗎囉啰哦喔了"
+	zhClosed         = []string{"谢谢", "謝謝", "再见", "再見", "好的", "是的", "不是", "没有", "沒有", "不用", "可以", "唔该", "唔該", "多谢", "多謝", "係", "冇"}
+
+	enDangling = map[string]bool{
+		"and": true, "or": true, "but": true, "because": true, "so": true, "the": true,
+		"a": true, "an": true, "to": true, "of": true, "for": true, "with": true,
+		"my": true, "your": true, "um": true, "uh": true, "like": true, "if": true,
+		"then": true, "is": true, "are": true, "was": true, "i": true, "we": true,
+		"want": true, "need": true, "about": true, "in": true, "on": true, "at": true,
+	}
+	enClosed = map[string]bool{
+		"yes": true, "no": true, "yeah": true, "yep": true, "nope": true, "thanks": true,
+		"bye": true, "please": true, "okay": true, "ok": true, "right": true, "correct": true,
+	}
+)
+
+func judgeTurn(text string) TurnCompleteness {
+	t := strings.TrimSpace(text)
+	if t == "" {
+		return TurnUnknown
+	}
+	last, _ := utf8.DecodeLastRuneInString(t)
+	switch {
+	case strings.HasSuffix(t, "...") || last == '…':
+		return TurnIncomplete
+	case strings.ContainsRune("，,、：:；;-—", last):
+		return TurnIncomplete
+	case strings.ContainsRune("。！？!?.", last):
+		return TurnComplete
+	}
+	if unicode.Is(unicode.Han, last) {
+		for _, w := range zhDangling {
+			if strings.HasSuffix(t, w) {
+				return TurnIncomplete
+			}
+		}
+		if strings.ContainsRune(zhFinalParticles, last) {
+			return TurnComplete
+		}
+		for _, w := range zhClosed {
+			if strings.HasSuffix(t, w) {
+				return TurnComplete
+			}
+		}
+		return TurnUnknown
+	}
+	words := strings.FieldsFunc(strings.ToLower(t), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		return TurnUnknown
+	}
+	w := words[len(words)-1]
+	switch {
+	case enDangling[w]:
+		return TurnIncomplete
+	case enClosed[w]:
+		return TurnComplete
+	}
+	return TurnUnknown
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cascaded
+
+// turnStage — semantic end-of-turn detection between the ASR side
+// (asr / hotword) and the LLM.
+//
+// Cloud ASR endpointing waits a fixed silence (typically 500–800 ms)
+// before committing a final, whatever the caller said. turnStage
+// combines local VAD silence with what the transcript reads like:
+//
+//   - Early commit: the latest partial reads complete (TurnComplete)
+//     and the caller has been silent for FastSilence → emit it as
+//     KindTextFinal right away. The recogniser's own final for the
+//     same speech arrives later and is dropped, or reduced to the
+//     words the early commit had not seen. Until that final arrives
+//     no further early commit is made.
+//   - Hold: a final that reads cut off (TurnIncomplete) is held for
+//     up to HoldMax. Speech resuming (new partials) keeps it held and
+//     the next final is merged into one user turn; otherwise it is
+//     released when HoldMax passes without new partials.
+//
+// TurnUnknown changes nothing, so a completer that cannot tell keeps
+// today's timing. Silence is measured in PCM time from the
+// passthrough audio frames (asrStage forwards them), not wall time,
+// so jitter-buffer bursts don't fake silence.
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/LinByte/VoiceServer/pkg/dialog/engine"
+	"github.com/LinByte/VoiceServer/pkg/dialog/pipeline"
+)
+
+// Default end-of-turn timings.
+const (
+	DefaultTurnFastSilence = 300 * time.Millisecond
+	DefaultTurnHoldMax     = 700 * time.Millisecond
+)
+
+// SpeechActivity reports whether one PCM frame (mono PCM16LE at the
+// bridge rate) ends in caller speech. Implemented by
+// *pkg/voice/vad.ProbDetector; it must be a separate instance from
+// the barge-in detector since both keep per-frame state.
+type SpeechActivity interface {
+	IsSpeech(pcm []byte) bool
+}
+
+// EndOfTurnConfig configures WithEndOfTurn.
+type EndOfTurnConfig struct {
+	// Activity drives early commits; nil disables them (holding
+	// incomplete finals still works).
+	Activity SpeechActivity
+	// Completer judges transcripts; nil = HeuristicTurnCompleter.
+	Completer TurnCompleter
+	// FastSilence is the silence after a complete-looking partial
+	// before it is committed; <= 0 = DefaultTurnFastSilence.
+	FastSilence time.Duration
+	// HoldMax bounds how long an incomplete final waits for more
+	// speech; <= 0 = DefaultTurnHoldMax.
+	HoldMax time.Duration
+	// OnDecision observes early commits ("early") and holds
+	// ("hold" / "merge" / "release") for metrics; optional.
+	OnDecision func(kind, text string)
+}
+
+type turnStage struct {
+	cfg EndOfTurnConfig
+}
+
+func newTurnStage(cfg EndOfTurnConfig) *turnStage {
+	if cfg.Completer == nil {
+		cfg.Completer = HeuristicTurnCompleter
+	}
+	if cfg.FastSilence <= 0 {
+		cfg.FastSilence = DefaultTurnFastSilence
+	}
+	if cfg.HoldMax <= 0 {
+		cfg.HoldMax = DefaultTurnHoldMax
+	}
+	return &turnStage{cfg: cfg}
+}
+
+// Name implements pipeline.Stage.
+func (turnStage) Name() string { return "turn" }
+
+// turnState is the per-Run state, kept off the stage so Run stays
+// re-entrant.
+type turnState struct {
+	partial   string        // latest partial not yet committed
+	committed string        // early-committed text awaiting the ASR final
+	silence   time.Duration // PCM time since the last speech frame
+	held      string        // incomplete final waiting for more speech
+	holdTimer *time.Timer
+	holdC     <-chan time.Time
+}
+
+// Run implements pipeline.Stage.
+func (s *turnStage) Run(ctx context.Context, in <-chan pipeline.Frame, out chan<- pipeline.Frame, lg engine.Logger) error {
+	defer close(out)
+	st := &turnState{}
+	defer st.stopHold()
+	emit := func(text string) error {
+		return sendOrCancel(ctx, out, pipeline.Frame{Kind: pipeline.KindTextFinal, Text: text})
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-st.holdC:
+			text := st.held
+			st.stopHold()
+			s.observe(lg, "release", text)
+			if err := emit(text); err != nil {
+				return err
+			}
+		case f, ok := <-in:
+			if !ok {
+				if st.held != "" {
+					text := st.held
+					st.stopHold()
+					return emit(text)
+				}
+				return nil
+			}
+			switch f.Kind {
+			case pipeline.KindPCM:
+				if err := sendOrCancel(ctx, out, f); err != nil {
+					return err
+				}
+				if text := s.onPCM(st, f.PCM); text != "" {
+					s.observe(lg, "early", text)
+					if err := emit(text); err != nil {
+						return err
+					}
+				}
+			case pipeline.KindTextInterim:
+				st.partial = f.Text
+				if st.held != "" {
+					// Speech resumed: keep holding until its final.
+					st.startHold(s.cfg.HoldMax)
+				}
+				if err := sendOrCancel(ctx, out, f); err != nil {
+					return err
+				}
+			case pipeline.KindTextFinal:
+				text, ok := s.onFinal(st, f.Text)
+				if !ok {
+					continue
+				}
+				if st.held != "" {
+					text = joinTranscripts(st.held, text)
+					st.stopHold()
+					s.observe(lg, "merge", text)
+				}
+				if s.cfg.Completer.Complete(text) == TurnIncomplete {
+					st.held = text
+					st.startHold(s.cfg.HoldMax)
+					s.observe(lg, "hold", text)
+					continue
+				}
+				f.Text = text
+				if err := sendOrCancel(ctx, out, f); err != nil {
+					return err
+				}
+			default:
+				if err := sendOrCancel(ctx, out, f); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// onPCM updates silence accounting and returns the partial to commit
+// early, if any.
+func (s *turnStage) onPCM(st *turnState, pcm engine.PCMFrame) string {
+	if s.cfg.Activity == nil {
+		return ""
+	}
+	if s.cfg.Activity.IsSpeech(pcm.Data) {
+		st.silence = 0
+		return ""
+	}
+	if pcm.SampleRate > 0 {
+		st.silence += time.Duration(len(pcm.Data)/2) * time.Second / time.Duration(pcm.SampleRate)
+	}
+	// One early commit per ASR final: interims the recogniser re-sends
+	// for speech already committed must not be committed again.
+	if st.partial == "" || st.held != "" || st.committed != "" || st.silence < s.cfg.FastSilence {
+		return ""
+	}
+	if s.cfg.Completer.Complete(st.partial) != TurnComplete {
+		return ""
+	}
+	text := st.partial
+	st.partial = ""
+	st.committed = text
+	st.silence = 0
+	return text
+}
+
+// onFinal reconciles an ASR final with an earlier early commit.
+// ok=false drops the final (already answered).
+func (s *turnStage) onFinal(st *turnState, text string) (string, bool) {
+	st.partial = ""
+	committed := st.committed
+	st.committed = ""
+	if committed == "" {
+		return text, true
+	}
+	rest, covered := transcriptRemainder(committed, text)
+	if !covered {
+		return text, true
+	}
+	if rest == "" {
+		return "", false
+	}
+	return rest, true
+}
+
+func (s *turnStage) observe(lg engine.Logger, kind, text string) {
+	lg.Debug("turn stage: "+kind, engine.F("text", text))
+	if s.cfg.OnDecision != nil {
+		s.cfg.OnDecision(kind, text)
+	}
+}
+
+func (st *turnState) startHold(d time.Duration) {
+	if st.holdTimer != nil {
+		st.holdTimer.Stop()
+	}
+	st.holdTimer = time.NewTimer(d)
+	st.holdC = st.holdTimer.C
+}
+
+func (st *turnState) stopHold() {
+	if st.holdTimer != nil {
+		st.holdTimer.Stop()
+	}
+	st.holdTimer, st.holdC, st.held = nil, nil, ""
+}
+
+// transcriptRemainder compares a final with the early-committed text,
+// ignoring punctuation, spacing and case. covered reports whether the
+// final's head matches the committed text in at least 80% of rune
+// positions (the recogniser may revise a word); rest is what the
+// final adds beyond it.
+func transcriptRemainder(committed, final string) (rest string, covered bool) {
+	c, f := []rune(normalizeTranscript(committed)), []rune(normalizeTranscript(final))
+	n := 0
+	for i := 0; i < len(c) && i < len(f); i++ {
+		if c[i] == f[i] {
+			n++
+		}
+	}
+	if len(c) == 0 || n*5 < len(c)*4 {
+		return "", false
+	}
+	if len(f) <= len(c) {
+		return "", true
+	}
+	// Map the normalised cut back onto the original final so the
+	// remainder keeps its punctuation and spacing.
+	seen := 0
+	for i, r := range final {
+		if keepTranscriptRune(r) {
+			if seen == len(c) {
+				return strings.TrimLeftFunc(final[i:], func(r rune) bool { return !keepTranscriptRune(r) }), true
+			}
+			seen++
+		}
+	}
+	return "", true
+}
+
+func normalizeTranscript(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if keepTranscriptRune(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func keepTranscriptRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// joinTranscripts concatenates two fragments of one utterance, with a
+// space only between Latin words.
+func joinTranscripts(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	la, _ := utf8.DecodeLastRuneInString(a)
+	fb, _ := utf8.DecodeRuneInString(b)
+	if la < utf8.RuneSelf && fb < utf8.RuneSelf && la != utf8.RuneError && fb != utf8.RuneError {
+		return a + " " + b
+	}
+	return a + b
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cascaded
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/dialog/engine"
+	"github.com/LinByte/VoiceServer/pkg/dialog/pipeline"
+)
+
+// fakeActivity reports speech for any frame with a non-zero byte.
+type fakeActivity struct{}
+
+func (fakeActivity) IsSpeech(pcm []byte) bool {
+	for _, b := range pcm {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func pcmFrame(speech bool) pipeline.Frame {
+	data := make([]byte, 640) // 20 ms @ 16 kHz
+	if speech {
+		data[0] = 1
+	}
+	return pipeline.Frame{Kind: pipeline.KindPCM, PCM: engine.PCMFrame{Data: data, SampleRate: 16000}}
+}
+
+func textFrame(text string, final bool) pipeline.Frame {
+	if final {
+		return pipeline.Frame{Kind: pipeline.KindTextFinal, Text: text}
+	}
+	return pipeline.Frame{Kind: pipeline.KindTextInterim, Text: text}
+}
+
+// runTurnStage feeds frames, closes the input and returns the final
+// transcripts in emission order.
+func runTurnStage(t *testing.T, cfg EndOfTurnConfig, frames ...pipeline.Frame) []string {
+	t.Helper()
+	in := make(chan pipeline.Frame, len(frames))
+	for _, f := range frames {
+		in <- f
+	}
+	close(in)
+	out := make(chan pipeline.Frame, 256)
+	if err := newTurnStage(cfg).Run(context.Background(), in, out, engine.NopLogger{}); err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	var finals []string
+	for f := range out {
+		if f.Kind == pipeline.KindTextFinal {
+			finals = append(finals, f.Text)
+		}
+	}
+	return finals
+}
+
+func silence(n int) []pipeline.Frame {
+	fs := make([]pipeline.Frame, n)
+	for i := range fs {
+		fs[i] = pcmFrame(false)
+	}
+	return fs
+}
+
+func TestTurnStage_EarlyCommitDropsDuplicateFinal(t *testing.T) {
+	frames := []pipeline.Frame{pcmFrame(true), textFrame("帮我查一下话费吧", false)}
+	frames = append(frames, silence(15)...)
+	frames = append(frames, textFrame("帮我查一下话费吧。", true))
+	got := runTurnStage(t, EndOfTurnConfig{Activity: fakeActivity{}}, frames...)
+	if len(got) != 1 || got[0] != "帮我查一下话费吧" {
+		t.Fatalf("finals=%q", got)
+	}
+}
+
+func TestTurnStage_EarlyCommitIgnoresRepeatedInterim(t *testing.T) {
+	// The recogniser re-sends the committed interim (now punctuated)
+	// before its final; silence keeps running but nothing new was said.
+	frames := []pipeline.Frame{pcmFrame(true), textFrame("帮我查一下话费吧", false)}
+	frames = append(frames, silence(15)...)
+	frames = append(frames, textFrame("帮我查一下话费吧。", false))
+	frames = append(frames, silence(2)...)
+	frames = append(frames, textFrame("帮我查一下话费吧。", true))
+	got := runTurnStage(t, EndOfTurnConfig{Activity: fakeActivity{}}, frames...)
+	if len(got) != 1 || got[0] != "帮我查一下话费吧" {
+		t.Fatalf("finals=%q", got)
+	}
+}
+
+func TestTurnStage_EarlyCommitForwardsRemainder(t *testing.T) {
+	frames := []pipeline.Frame{textFrame("帮我查一下话费吧", false)}
+	frames = append(frames, silence(15)...)
+	frames = append(frames, textFrame("帮我查一下话费吧，还有流量。", true))
+	got := runTurnStage(t, EndOfTurnConfig{Activity: fakeActivity{}}, frames...)
+	if len(got) != 2 || got[1] != "还有流量。" {
+		t.Fatalf("finals=%q", got)
+	}
+}
+
+func TestTurnStage_NoEarlyCommitWithoutSilenceOrCompleteness(t *testing.T) {
+	// 280 ms of silence is under the default 300 ms.
+	frames := []pipeline.Frame{textFrame("Can you check my bill?", false)}
+	frames = append(frames, silence(14)...)
+	frames = append(frames, pcmFrame(true), textFrame("我想问一下", false))
+	frames = append(frames, silence(30)...)
+	frames = append(frames, textFrame("Can you check my bill? 我想问一下", true))
+	got := runTurnStage(t, EndOfTurnConfig{Activity: fakeActivity{}}, frames...)
+	if len(got) != 1 || got[0] != "Can you check my bill? 我想问一下" {
+		t.Fatalf("finals=%q", got)
+	}
+}
+
+func TestTurnStage_HoldsIncompleteFinalAndMerges(t *testing.T) {
+	got := runTurnStage(t, EndOfTurnConfig{HoldMax: time.Hour},
+		textFrame("我想查一下那个", true),
+		textFrame("流量", false),
+		textFrame("流量套餐。", true),
+	)
+	if len(got) != 1 || got[0] != "我想查一下那个流量套餐。" {
+		t.Fatalf("finals=%q", got)
+	}
+	got = runTurnStage(t, EndOfTurnConfig{HoldMax: time.Hour},
+		textFrame("I want to check my bill and", true),
+		textFrame("my data plan", true),
+	)
+	if len(got) != 1 || got[0] != "I want to check my bill and my data plan" {
+		t.Fatalf("finals=%q", got)
+	}
+}
+
+func TestTurnStage_HoldReleasedAfterHoldMax(t *testing.T) {
+	in := make(chan pipeline.Frame, 4)
+	out := make(chan pipeline.Frame, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTurnStage(EndOfTurnConfig{HoldMax: 20 * time.Millisecond}).Run(ctx, in, out, engine.NopLogger{})
+	}()
+	in <- textFrame("我想查一下那个", true)
+	select {
+	case f := <-out:
+		if f.Kind != pipeline.KindTextFinal || f.Text != "我想查一下那个" {
+			t.Fatalf("got %+v", f)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("held final never released")
+	}
+}
+
+func TestTurnStage_InputCloseFlushesHeld(t *testing.T) {
+	got := runTurnStage(t, EndOfTurnConfig{HoldMax: time.Hour}, textFrame("因为", true))
+	if len(got) != 1 || got[0] != "因为" {
+		t.Fatalf("finals=%q", got)
+	}
+}
+
+func TestHeuristicTurnCompleter(t *testing.T) {
+	cases := []struct {
+		text string
+		want TurnCompleteness
+	}{
+		{"帮我查一下话费。", TurnComplete},
+		{"你们几点下班呢", TurnComplete},
+		{"好的谢谢", TurnComplete},
+		{"我想查一下那个", TurnIncomplete},
+		{"我想办宽带，然后", TurnIncomplete},
+		{"我想問下同埋", TurnIncomplete},
+		{"我想查話費㗎", TurnComplete},
+		{"我想查话费", TurnUnknown},
+		{"I want to check my bill and", TurnIncomplete},
+		{"um", TurnIncomplete},
+		{"yes", TurnComplete},
+		{"Where is my order?", TurnComplete},
+		{"so I was thinking...", TurnIncomplete},
+		{"check my balance", TurnUnknown},
+		{"", TurnUnknown},
+	}
+	for _, c := range cases {
+		if got := HeuristicTurnCompleter.Complete(c.text); got != c.want {
+			t.Errorf("%q = %d, want %d", c.text, got, c.want)
+		}
+	}
+}
+
+func TestTranscriptRemainder(t *testing.T) {
+	cases := []struct {
+		committed, final, rest string
+		covered                bool
+	}{
+		{"帮我查一下话费吧", "帮我查一下话费吧。", "", true},
+		{"帮我查一下话费吧", "帮我查一下话费吧，还有流量", "还有流量", true},
+		{"Check my bill", "check my bill, please.", "please.", true},
+		{"帮我查一下话费吧", "帮我查一下花费吧", "", true},
+		{"帮我查一下话费吧", "我要办宽带", "", false},
+	}
+	for _, c := range cases {
+		rest, covered := transcriptRemainder(c.committed, c.final)
+		if rest != c.rest || covered != c.covered {
+			t.Errorf("(%q,%q) = %q,%v want %q,%v", c.committed, c.final, rest, covered, c.rest, c.covered)
+		}
+	}
+}
+
+func TestEngine_WithEndOfTurnSplicesAfterHotword(t *testing.T) {
+	e := New(engine.Config{}, WithEndOfTurn(EndOfTurnConfig{}), WithTextRewriter(&fakeRewriter{}))
+	if e.endOfTurn == nil {
+		t.Fatal("option not applied")
+	}
+	port := newFakePort()
+	detach, err := e.Attach(context.Background(), port, engine.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = detach(context.Background())
+}
@@ -62,10 +62,9 @@ import (
 )
 
 // BargeInDetector is the minimal interface the VAD stage needs.
-// Implemented by *pkg/sip/vad.Detector in production; tests provide
-// a deterministic fake. Kept as an interface so cascaded does NOT
-// import pkg/sip/vad (which would invert the dialog → sip layering
-// the rest of the package carefully avoids).
+// Implemented by the pkg/voice/vad detectors (RMS, spectral, neural)
+// in production; tests provide a deterministic fake. Kept as an
+// interface so cascaded does NOT import a VAD implementation.
 type BargeInDetector interface {
 	// CheckBargeIn returns true when pcm crosses the speech-energy
 	// threshold AND synthPlaying is true. pcm is mono signed 16-bit
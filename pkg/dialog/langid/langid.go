@@ -0,0 +1,300 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package langid holds the per-number dialog language options and the
+// transcript-based language identification used to pick a caller's
+// language from the first utterance.
+//
+// Identification works on ASR output, not audio: the cascaded engine
+// races one recogniser per configured language on the first utterance
+// (cascaded.NewLanguageRaceASR) and hands every transcript to Decide.
+// The signals are script (Han / Latin / kana / Hangul) and Cantonese-
+// only vs Mandarin-only function characters — enough to tell zh / yue /
+// en hotline callers apart without shipping a model.
+package langid
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxOptions caps the languages of one number; every option beyond the
+// first costs one extra ASR session during the first utterance.
+const MaxOptions = 4
+
+// MaxPromptLen caps Option.Prompt (runes).
+const MaxPromptLen = 2000
+
+// Option is one dialog language of a trunk number. The first option of
+// a list is the default language of the call.
+type Option struct {
+	// Lang is a BCP-47 tag, e.g. zh-CN, yue-HK, en-US.
+	Lang string `json:"lang"`
+	// ASRModel overrides the recogniser model (QCloud engine_model_type,
+	// e.g. 16k_yue). Empty derives one from the tenant model.
+	ASRModel string `json:"asrModel,omitempty"`
+	// TTS is merged onto the tenant TTS config, e.g. {"voiceType":101019}.
+	TTS map[string]any `json:"tts,omitempty"`
+	// Prompt is appended to the LLM system prompt once the language is
+	// chosen; empty uses ReplyInstruction.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// Result is one recogniser's transcript of the first utterance.
+type Result struct {
+	Lang string // language the recogniser was configured for
+	Text string
+}
+
+// Base reduces a BCP-47 tag to the language key used for detection:
+// zh, yue, en, ja, ko … Hong Kong / Macau Chinese and zh-yue map to yue.
+func Base(tag string) string {
+	t := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if t == "" {
+		return ""
+	}
+	switch {
+	case t == "zh-yue", strings.HasPrefix(t, "zh-hk"), strings.HasPrefix(t, "zh-mo"), strings.HasPrefix(t, "zh-hant-hk"):
+		return "yue"
+	}
+	if i := strings.IndexByte(t, '-'); i > 0 {
+		return t[:i]
+	}
+	return t
+}
+
+func validTag(tag string) bool {
+	parts := strings.Split(tag, "-")
+	if len(parts[0]) < 2 || len(parts[0]) > 3 {
+		return false
+	}
+	for _, p := range parts {
+		if p == "" || len(p) > 8 {
+			return false
+		}
+		for _, r := range p {
+			if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validModel(m string) bool {
+	for _, r := range m {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return len(m) <= 64
+}
+
+// ParseOptions decodes and validates a number's languages JSON, e.g.
+// [{"lang":"zh-CN"},{"lang":"yue-HK","asrModel":"16k_yue","tts":{"voiceType":101019}}].
+// Empty input yields nil (single tenant-default language).
+func ParseOptions(raw string) ([]Option, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" || raw == "[]" {
+		return nil, nil
+	}
+	var opts []Option
+	if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+		return nil, fmt.Errorf("languages must be a JSON array: %w", err)
+	}
+	if len(opts) > MaxOptions {
+		return nil, fmt.Errorf("languages allows at most %d entries", MaxOptions)
+	}
+	seen := map[string]bool{}
+	for i := range opts {
+		o := &opts[i]
+		o.Lang = strings.ReplaceAll(strings.TrimSpace(o.Lang), "_", "-")
+		if !validTag(o.Lang) {
+			return nil, fmt.Errorf("languages[%d].lang %q is not a BCP-47 tag", i, o.Lang)
+		}
+		b := Base(o.Lang)
+		if seen[b] {
+			return nil, fmt.Errorf("languages[%d].lang %q duplicates another entry", i, o.Lang)
+		}
+		seen[b] = true
+		o.ASRModel = strings.TrimSpace(o.ASRModel)
+		if !validModel(o.ASRModel) {
+			return nil, fmt.Errorf("languages[%d].asrModel is invalid", i)
+		}
+		o.Prompt = strings.TrimSpace(o.Prompt)
+		if len([]rune(o.Prompt)) > MaxPromptLen {
+			return nil, fmt.Errorf("languages[%d].prompt exceeds %d characters", i, MaxPromptLen)
+		}
+		if len(o.TTS) == 0 {
+			o.TTS = nil
+		}
+	}
+	return opts, nil
+}
+
+// NormalizeOptionsJSON validates raw and returns its canonical JSON ("" when empty).
+func NormalizeOptionsJSON(raw string) (string, error) {
+	opts, err := ParseOptions(raw)
+	if err != nil || len(opts) == 0 {
+		return "", err
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Tags returns the Lang of every option, in order.
+func Tags(opts []Option) []string {
+	out := make([]string, 0, len(opts))
+	for _, o := range opts {
+		out = append(out, o.Lang)
+	}
+	return out
+}
+
+// Cantonese-only and Mandarin-only function characters. Shared
+// characters (呢, 嗎, 係 as in 關係) are left out on purpose.
+const (
+	yueMarkers = "嘅咗唔喺哋冇啲乜嘢嚟噉嗰畀啱㗎嘞佢睇揾搵攞諗"
+	cmnMarkers = "的了是们們这這么麼没沒"
+)
+
+type textStats struct {
+	han, latin, kana, hangul int
+	yue, cmn                 int
+}
+
+func statsOf(text string) textStats {
+	var st textStats
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			st.kana++
+		case unicode.Is(unicode.Hangul, r):
+			st.hangul++
+		case unicode.Is(unicode.Han, r):
+			st.han++
+			if strings.ContainsRune(yueMarkers, r) {
+				st.yue++
+			} else if strings.ContainsRune(cmnMarkers, r) {
+				st.cmn++
+			}
+		case r <= unicode.MaxLatin1 && unicode.IsLetter(r):
+			st.latin++
+		}
+	}
+	return st
+}
+
+func cjkBase(b string) bool {
+	switch b {
+	case "zh", "yue", "ja", "ko":
+		return true
+	}
+	return false
+}
+
+// guess maps transcript statistics to a base language, or "".
+func (st textStats) guess() string {
+	letters := st.han + st.latin + st.kana + st.hangul
+	switch {
+	case letters == 0:
+		return ""
+	case st.kana > 0 && st.kana*5 >= st.han:
+		return "ja"
+	case st.hangul*2 > letters:
+		return "ko"
+	case st.latin*2 > letters:
+		return "latin"
+	case st.yue > st.cmn:
+		return "yue"
+	}
+	return "zh"
+}
+
+// match returns the candidate tag for a guessed base: Latin script goes to
+// the first non-CJK candidate; Chinese falls back between zh and yue when
+// only one of them is configured.
+func match(guess string, candidates []string) string {
+	if guess == "" {
+		return ""
+	}
+	for _, c := range candidates {
+		b := Base(c)
+		if b == guess || (guess == "latin" && !cjkBase(b)) {
+			return c
+		}
+	}
+	if guess == "zh" || guess == "yue" {
+		for _, c := range candidates {
+			if b := Base(c); b == "zh" || b == "yue" {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// Detect guesses which of candidates text is written in; "" when the
+// text carries no usable signal or matches none of them.
+func Detect(text string, candidates []string) string {
+	return match(statsOf(text).guess(), candidates)
+}
+
+// Decide picks the caller's language from the transcripts of recognisers
+// raced on the same utterance, falling back to candidates[0].
+//
+// Han-script recognisers are the reliable judges: they spell foreign
+// speech in Latin and keep Cantonese particles, whereas a Latin-script
+// recogniser turns any audio into plausible words. Their transcripts are
+// pooled and run through Detect; the other recognisers only decide when
+// they are the only ones that heard anything.
+func Decide(results []Result, candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	var cjk strings.Builder
+	var other string
+	for _, r := range results {
+		text := strings.TrimSpace(r.Text)
+		if text == "" {
+			continue
+		}
+		if cjkBase(Base(r.Lang)) {
+			cjk.WriteString(text)
+			cjk.WriteByte(' ')
+		} else if other == "" {
+			other = r.Lang
+		}
+	}
+	if cjk.Len() > 0 {
+		if lang := Detect(cjk.String(), candidates); lang != "" {
+			return lang
+		}
+	} else if other != "" {
+		return other
+	}
+	return candidates[0]
+}
+
+// ReplyInstruction is the default system-prompt suffix asking the LLM
+// to answer in lang.
+func ReplyInstruction(lang string) string {
+	switch Base(lang) {
+	case "zh":
+		return "请使用普通话（简体中文）回答。"
+	case "yue":
+		return "請用廣東話口語（繁體中文）回答。"
+	case "en":
+		return "Please reply in English."
+	case "ja":
+		return "日本語で回答してください。"
+	case "ko":
+		return "한국어로 답변해 주세요."
+	}
+	return fmt.Sprintf("Please reply in the caller's language (%s).", lang)
+}
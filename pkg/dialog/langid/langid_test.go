@@ -0,0 +1,134 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package langid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase(t *testing.T) {
+	cases := map[string]string{
+		"zh-CN":   "zh",
+		"zh_TW":   "zh",
+		"yue-HK":  "yue",
+		"zh-HK":   "yue",
+		"zh-yue":  "yue",
+		"en-US":   "en",
+		" EN ":    "en",
+		"":        "",
+		"ja":      "ja",
+		"zh-Hans": "zh",
+	}
+	for in, want := range cases {
+		if got := Base(in); got != want {
+			t.Errorf("Base(%q)=%q want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := ParseOptions(` [{"lang":"zh_CN"},{"lang":"yue-HK","asrModel":" 16k_yue ","tts":{"voiceType":101019},"prompt":" 用粵語 "},{"lang":"en-US","tts":{}}] `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 3 || opts[0].Lang != "zh-CN" || opts[1].ASRModel != "16k_yue" || opts[1].Prompt != "用粵語" || opts[2].TTS != nil {
+		t.Fatalf("opts=%+v", opts)
+	}
+	if got := Tags(opts); strings.Join(got, ",") != "zh-CN,yue-HK,en-US" {
+		t.Fatalf("tags=%v", got)
+	}
+	for _, raw := range []string{"", "null", "[]"} {
+		if opts, err := ParseOptions(raw); err != nil || opts != nil {
+			t.Fatalf("%q: opts=%v err=%v", raw, opts, err)
+		}
+	}
+	bad := []string{
+		`{"lang":"zh"}`,
+		`[{"lang":""}]`,
+		`[{"lang":"chinese-simplified"}]`,
+		`[{"lang":"zh-CN"},{"lang":"zh-TW"}]`,
+		`[{"lang":"en","asrModel":"16k en"}]`,
+		`[{"lang":"zh"},{"lang":"en"},{"lang":"yue"},{"lang":"ja"},{"lang":"ko"}]`,
+		`[{"lang":"en","prompt":"` + strings.Repeat("x", MaxPromptLen+1) + `"}]`,
+	}
+	for _, raw := range bad {
+		if _, err := ParseOptions(raw); err == nil {
+			t.Errorf("expected error for %.60s", raw)
+		}
+	}
+}
+
+func TestNormalizeOptionsJSON(t *testing.T) {
+	got, err := NormalizeOptionsJSON(`[ {"lang":"en-US", "asrModel":""} ]`)
+	if err != nil || got != `[{"lang":"en-US"}]` {
+		t.Fatalf("got=%q err=%v", got, err)
+	}
+	if got, err := NormalizeOptionsJSON(" "); err != nil || got != "" {
+		t.Fatalf("empty: got=%q err=%v", got, err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	all := []string{"zh-CN", "yue-HK", "en-US"}
+	cases := []struct {
+		text  string
+		cands []string
+		want  string
+	}{
+		{"我想查一下我的话费", all, "zh-CN"},
+		{"我想查下我嘅電話費，唔該", all, "yue-HK"},
+		{"hello I want to check my bill", all, "en-US"},
+		{"我想check一下my bill please", all, "en-US"},
+		{"你好", all, "zh-CN"},
+		{"我想查下我嘅電話費", []string{"zh-CN", "en-US"}, "zh-CN"},
+		{"我想查一下我的话费", []string{"yue-HK", "en-US"}, "yue-HK"},
+		{"hello", []string{"zh-CN", "yue-HK"}, ""},
+		{"请问", []string{"en-US"}, ""},
+		{"。。。123", all, ""},
+		{"こんにちは、予約したいです", []string{"zh-CN", "ja-JP"}, "ja-JP"},
+		{"안녕하세요", []string{"zh-CN", "ko-KR"}, "ko-KR"},
+	}
+	for _, c := range cases {
+		if got := Detect(c.text, c.cands); got != c.want {
+			t.Errorf("Detect(%q,%v)=%q want %q", c.text, c.cands, got, c.want)
+		}
+	}
+}
+
+func TestDecide(t *testing.T) {
+	all := []string{"zh-CN", "yue-HK", "en-US"}
+	cases := []struct {
+		name    string
+		results []Result
+		want    string
+	}{
+		{"mandarin", []Result{{"zh-CN", "我想查一下我的话费"}, {"yue-HK", "我想查一下我的話費"}, {"en-US", "what's young cha"}}, "zh-CN"},
+		{"cantonese", []Result{{"zh-CN", "我想查下我个电话费"}, {"yue-HK", "我想查下我嘅電話費唔該"}, {"en-US", "no song cha ha"}}, "yue-HK"},
+		{"english", []Result{{"zh-CN", "hello I want to check my bill"}, {"yue-HK", "哈囉"}, {"en-US", "hello I want to check my bill"}}, "en-US"},
+		{"latin only", []Result{{"en-US", "yes please"}}, "en-US"},
+		{"nothing heard", []Result{{"zh-CN", " "}, {"en-US", ""}}, "zh-CN"},
+		{"no signal", []Result{{"zh-CN", "123"}}, "zh-CN"},
+	}
+	for _, c := range cases {
+		if got := Decide(c.results, all); got != c.want {
+			t.Errorf("%s: Decide=%q want %q", c.name, got, c.want)
+		}
+	}
+	if got := Decide(nil, nil); got != "" {
+		t.Fatalf("no candidates: %q", got)
+	}
+}
+
+func TestReplyInstruction(t *testing.T) {
+	if got := ReplyInstruction("en-GB"); got != "Please reply in English." {
+		t.Fatal(got)
+	}
+	if got := ReplyInstruction("zh-HK"); !strings.Contains(got, "廣東話") {
+		t.Fatal(got)
+	}
+	if got := ReplyInstruction("fr-FR"); !strings.Contains(got, "fr-FR") {
+		t.Fatal(got)
+	}
+}
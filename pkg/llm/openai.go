@@ -13,6 +13,7 @@ import (
 
 	"github.com/LinByte/VoiceServer/internal/constants"
 	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/sashabaranov/go-openai"
@@ -170,7 +171,7 @@ func (h *LLMHandler) QueryWithOptions(text string, options QueryOptions) (string
 	})
 
 	logger.Debug("Added user message to history",
-		zap.String("user_text", text),
+		zap.String("user_text", redact.Text(text)),
 		zap.Int("total_messages", len(h.messages)),
 	)
 
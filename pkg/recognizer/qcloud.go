@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/LinByte/VoiceServer/pkg/media"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	gonanoid "github.com/matoous/go-nanoid"
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-speech-sdk-go/asr"
@@ -57,6 +58,24 @@ type QCloudASROption struct {
 	// SentenceNotify is invoked on each Tencent OnSentenceEnd (fragment = 本句增量, cumulative = 当前累计).
 	// Optional; used by offline streaming consumers (e.g. call analysis WebSocket).
 	SentenceNotify func(fragment string, cumulative string) `json:"-" yaml:"-"`
+	// WordInfo 对应腾讯云 word_info：0 不返回词级时间戳；1 返回（不含标点）；2 含标点。
+	WordInfo int `json:"wordInfo" yaml:"word_info" default:"0"`
+	// WordsNotify receives the word list of each finished sentence when
+	// WordInfo > 0. Offsets are relative to the first audio sent on the
+	// stream. When the engine model returns no word list, it receives the
+	// whole sentence as one entry with Sentence set. Optional; used by PII
+	// recording redaction (pkg/redact).
+	WordsNotify func(words []QCloudWord) `json:"-" yaml:"-"`
+}
+
+// QCloudWord is one word_list entry from a Tencent sentence-end result.
+type QCloudWord struct {
+	Word    string
+	StartMs int64
+	EndMs   int64
+	// Sentence marks a whole-sentence span sent in place of a missing
+	// word list.
+	Sentence bool
 }
 
 func NewQcloudASROption(appId string, secretId string, secretKey string) QCloudASROption {
@@ -172,7 +191,7 @@ func (asq *QCloudASR) OnRecognitionResultChange(response *asr.SpeechRecognitionR
 // OnSentenceEnd implementation of SpeechRecognitionListener
 func (asq *QCloudASR) OnSentenceEnd(response *asr.SpeechRecognitionResponse) {
 	logFields := logrus.Fields{
-		"voiceTextStr": response.Result.VoiceTextStr,
+		"voiceTextStr": redact.Text(response.Result.VoiceTextStr),
 	}
 	if asq.Handler != nil {
 		logFields["sessionID"] = asq.Handler.GetSession().ID
@@ -186,6 +205,22 @@ func (asq *QCloudASR) OnSentenceEnd(response *asr.SpeechRecognitionResponse) {
 	if asq.opt.SentenceNotify != nil {
 		asq.opt.SentenceNotify(response.Result.VoiceTextStr, asq.sentence)
 	}
+	if asq.opt.WordsNotify != nil {
+		if len(response.Result.WordList) > 0 {
+			words := make([]QCloudWord, 0, len(response.Result.WordList))
+			for _, w := range response.Result.WordList {
+				words = append(words, QCloudWord{Word: w.Word, StartMs: int64(w.StartTime), EndMs: int64(w.EndTime)})
+			}
+			asq.opt.WordsNotify(words)
+		} else if response.Result.VoiceTextStr != "" && response.Result.EndTime > response.Result.StartTime {
+			asq.opt.WordsNotify([]QCloudWord{{
+				Word:     response.Result.VoiceTextStr,
+				StartMs:  int64(response.Result.StartTime),
+				EndMs:    int64(response.Result.EndTime),
+				Sentence: true,
+			}})
+		}
+	}
 	if asq.transcribeResult != nil {
 		// Sentence boundary: treat as final for streaming consumers (SIP / WebSocket).
 		// Previously this passed false, so only OnRecognitionComplete emitted isLast=true
@@ -313,6 +348,9 @@ func applyQCloudASRTuning(r *asr.SpeechRecognizer, opt QCloudASROption) {
 	if opt.FilterModal > 0 {
 		r.FilterModal = opt.FilterModal
 	}
+	if opt.WordInfo > 0 {
+		r.WordInfo = opt.WordInfo
+	}
 }
 
 func (asq *QCloudASR) ConnAndReceive(dialogID string) error {
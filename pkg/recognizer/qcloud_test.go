@@ -0,0 +1,37 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package recognizer
+
+import (
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-speech-sdk-go/asr"
+)
+
+func TestQCloudASR_WordsNotifySentenceFallback(t *testing.T) {
+	var got [][]QCloudWord
+	opt := NewQcloudASROption("", "", "")
+	opt.WordInfo = 1
+	opt.WordsNotify = func(words []QCloudWord) { got = append(got, words) }
+	asq := NewQcloudASR(opt)
+
+	asq.OnSentenceEnd(&asr.SpeechRecognitionResponse{Result: asr.SpeechRecognitionResponseResult{
+		StartTime: 100, EndTime: 900, VoiceTextStr: "我的手机",
+		WordList: []asr.SpeechRecognitionResponseResultWord{{Word: "我的", StartTime: 100, EndTime: 400}, {Word: "手机", StartTime: 400, EndTime: 900}},
+	}})
+	asq.OnSentenceEnd(&asr.SpeechRecognitionResponse{Result: asr.SpeechRecognitionResponseResult{
+		StartTime: 1000, EndTime: 3000, VoiceTextStr: "13812345678",
+	}})
+
+	if len(got) != 2 {
+		t.Fatalf("notifications = %d, want 2", len(got))
+	}
+	if len(got[0]) != 2 || got[0][0].Sentence {
+		t.Fatalf("word list: %+v", got[0])
+	}
+	want := QCloudWord{Word: "13812345678", StartMs: 1000, EndMs: 3000, Sentence: true}
+	if len(got[1]) != 1 || got[1][0] != want {
+		t.Fatalf("sentence fallback: %+v", got[1])
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package redact
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AudioMode selects how redacted recording ranges are rendered.
+type AudioMode string
+
+const (
+	AudioOff     AudioMode = ""        // leave recordings untouched
+	AudioSilence AudioMode = "silence" // zero the samples
+	AudioBeep    AudioMode = "beep"    // 1 kHz tone at -12 dBFS
+)
+
+// ParseAudioMode normalises a config string; unknown values map to AudioOff.
+func ParseAudioMode(s string) AudioMode {
+	switch AudioMode(strings.ToLower(strings.TrimSpace(s))) {
+	case AudioSilence:
+		return AudioSilence
+	case AudioBeep:
+		return AudioBeep
+	default:
+		return AudioOff
+	}
+}
+
+const (
+	beepHz        = 1000.0
+	beepAmplitude = 8192 // ≈ -12 dBFS for PCM16
+)
+
+// WordTiming is one recognised token with its offset from the start of
+// the utterance, as reported by ASR vendors that expose word timestamps
+// (Tencent realtime ASR with word_info=1).
+type WordTiming struct {
+	Text    string
+	StartMs int64
+	EndMs   int64
+}
+
+// TimeRange is a wall-clock interval to redact in the recording.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// AudioRanges returns the wall-clock ranges whose words overlap a PII
+// match. words are joined without separators (CJK ASR output has no
+// spaces, and digit groups are often split across tokens) before
+// scanning, so a phone number spread over several tokens is still found.
+// utteranceStart anchors the word offsets; pad widens each range on both
+// sides to cover ASR timestamp slop.
+func AudioRanges(e *Engine, utteranceStart time.Time, words []WordTiming, pad time.Duration) []TimeRange {
+	if !e.Enabled() || len(words) == 0 || utteranceStart.IsZero() {
+		return nil
+	}
+	var b strings.Builder
+	offsets := make([]int, len(words)+1)
+	for i, w := range words {
+		offsets[i] = b.Len()
+		b.WriteString(w.Text)
+	}
+	offsets[len(words)] = b.Len()
+	var out []TimeRange
+	for _, m := range e.Find(b.String()) {
+		first, last := -1, -1
+		for i := range words {
+			if offsets[i] < m.End && offsets[i+1] > m.Start {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		if first < 0 {
+			continue
+		}
+		out = append(out, TimeRange{
+			Start: utteranceStart.Add(time.Duration(words[first].StartMs)*time.Millisecond - pad),
+			End:   utteranceStart.Add(time.Duration(words[last].EndMs)*time.Millisecond + pad),
+		})
+	}
+	return out
+}
+
+// SampleRange is a half-open [Start, End) interval in samples on one
+// recording leg's timeline.
+type SampleRange struct {
+	Start int64
+	End   int64
+}
+
+// ToSampleRanges converts wall-clock ranges to sample offsets relative to
+// base at rate, sorted and merged. Ranges entirely before base are dropped.
+func ToSampleRanges(ranges []TimeRange, base time.Time, rate int) []SampleRange {
+	if len(ranges) == 0 || rate <= 0 {
+		return nil
+	}
+	out := make([]SampleRange, 0, len(ranges))
+	for _, r := range ranges {
+		s := r.Start.Sub(base).Nanoseconds() * int64(rate) / 1_000_000_000
+		e := r.End.Sub(base).Nanoseconds() * int64(rate) / 1_000_000_000
+		if s < 0 {
+			s = 0
+		}
+		if e <= s {
+			continue
+		}
+		out = append(out, SampleRange{Start: s, End: e})
+	}
+	return MergeSampleRanges(out)
+}
+
+// MergeSampleRanges sorts rs and coalesces overlapping / touching ranges.
+func MergeSampleRanges(rs []SampleRange) []SampleRange {
+	if len(rs) < 2 {
+		return rs
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+	out := rs[:1]
+	for _, r := range rs[1:] {
+		last := &out[len(out)-1]
+		if r.Start <= last.End {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// MaskPCM16 overwrites the samples of a mono PCM16 LE block that fall
+// inside ranges. firstSample is the block's position on the leg timeline
+// so callers can stream a recording through in fixed-size blocks; the
+// beep phase is derived from the absolute sample index and therefore
+// stays continuous across block boundaries.
+func MaskPCM16(pcm []byte, firstSample int64, rate int, ranges []SampleRange, mode AudioMode) {
+	if mode == AudioOff || len(ranges) == 0 || rate <= 0 {
+		return
+	}
+	n := int64(len(pcm) / 2)
+	blockEnd := firstSample + n
+	for _, r := range ranges {
+		if r.End <= firstSample || r.Start >= blockEnd {
+			continue
+		}
+		from := r.Start
+		if from < firstSample {
+			from = firstSample
+		}
+		to := r.End
+		if to > blockEnd {
+			to = blockEnd
+		}
+		for abs := from; abs < to; abs++ {
+			i := (abs - firstSample) * 2
+			binary.LittleEndian.PutUint16(pcm[i:i+2], uint16(mode.Sample(abs, rate)))
+		}
+	}
+}
+
+// Sample returns the replacement PCM16 value at absolute sample index
+// abs: 0 for silence, a phase-continuous 1 kHz sine for beep.
+func (m AudioMode) Sample(abs int64, rate int) int16 {
+	if m != AudioBeep || rate <= 0 {
+		return 0
+	}
+	return int16(beepAmplitude * math.Sin(2*math.Pi*beepHz*float64(abs)/float64(rate)))
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Env keys for the process-wide default policy (used when no tenant
+// policy is stored, and for log lines that are not tenant scoped).
+const (
+	EnvEnabled = "PII_REDACT_ENABLED" // "true" turns redaction on
+	EnvRules   = "PII_REDACT_RULES"   // comma list, e.g. "phone,cn_id,bank_card,email"; empty = all built-ins
+	EnvMask    = "PII_REDACT_MASK"    // replacement text; default "***"
+	EnvAudio   = "PII_REDACT_AUDIO"   // "", "silence" or "beep"
+	EnvCustom  = "PII_REDACT_CUSTOM"  // JSON array of {"name","pattern"}
+)
+
+// Policy is the JSON shape stored in tenants.privacy_config and accepted
+// by the tenant admin API.
+//
+//	{"enabled":true,"rules":["phone","bank_card"],"mask":"[已脱敏]",
+//	 "custom":[{"name":"order","pattern":"ORD-\\d{8}"}],"audio":"beep"}
+//
+// Audio blanks only the matched words when the ASR reports word
+// timestamps (QCloud with word_info). Otherwise, as with realtime agents
+// or QCloud models that ignore word_info, it blanks the whole utterance
+// that contains a match.
+type Policy struct {
+	Enabled bool         `json:"enabled"`
+	Rules   []Kind       `json:"rules,omitempty"`
+	Mask    string       `json:"mask,omitempty"`
+	Custom  []CustomRule `json:"custom,omitempty"`
+	Audio   AudioMode    `json:"audio,omitempty"`
+}
+
+// CustomRule is one operator-supplied regular expression (RE2 syntax).
+type CustomRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// ParsePolicy decodes a tenant policy blob. Empty input yields a
+// disabled policy and no error.
+func ParsePolicy(raw []byte) (Policy, error) {
+	var p Policy
+	if len(strings.TrimSpace(string(raw))) == 0 || strings.TrimSpace(string(raw)) == "null" {
+		return p, nil
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// PolicyFromEnv builds the process default policy from PII_REDACT_*.
+func PolicyFromEnv() Policy {
+	p := Policy{
+		Enabled: utils.GetBoolEnv(EnvEnabled),
+		Mask:    utils.GetEnv(EnvMask),
+		Audio:   ParseAudioMode(utils.GetEnv(EnvAudio)),
+	}
+	for _, k := range strings.Split(utils.GetEnv(EnvRules), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			p.Rules = append(p.Rules, Kind(k))
+		}
+	}
+	if raw := strings.TrimSpace(utils.GetEnv(EnvCustom)); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &p.Custom)
+	}
+	return p
+}
+
+// PolicyLoader returns the raw privacy_config JSON for one tenant.
+// ok=false means "no tenant row / no policy" and falls back to Default.
+type PolicyLoader func(ctx context.Context, tenantID uint) (raw []byte, ok bool)
+
+// tenantCacheTTL bounds how long an edited tenant policy can lag behind
+// the DB. Short enough for admins, long enough that a busy call does not
+// hit the tenants table on every ASR turn.
+const tenantCacheTTL = 30 * time.Second
+
+type cachedEngine struct {
+	eng *Engine
+	at  time.Time
+	// bad is the stored policy that failed to parse/compile, so a broken
+	// row is warned about once per edit rather than on every reload.
+	bad string
+}
+
+var (
+	mu          sync.RWMutex
+	loader      PolicyLoader
+	defaultEng  *Engine
+	defaultInit bool
+	tenantCache = map[uint]cachedEngine{}
+)
+
+// SetPolicyLoader installs the DB-backed tenant policy loader and drops
+// any cached tenant engines. nil clears the loader (tests).
+func SetPolicyLoader(fn PolicyLoader) {
+	mu.Lock()
+	loader = fn
+	tenantCache = map[uint]cachedEngine{}
+	mu.Unlock()
+}
+
+// SetDefault overrides the process default engine (tests / bootstrap).
+// Passing nil disables default redaction.
+func SetDefault(e *Engine) {
+	mu.Lock()
+	defaultEng = e
+	defaultInit = true
+	mu.Unlock()
+}
+
+// Default returns the env-driven process engine, compiling it on first
+// use. May be nil when PII_REDACT_ENABLED is off. An invalid env policy
+// is logged and falls back to the built-in rules rather than to no
+// redaction at all.
+func Default() *Engine {
+	mu.RLock()
+	if defaultInit {
+		e := defaultEng
+		mu.RUnlock()
+		return e
+	}
+	mu.RUnlock()
+	p := PolicyFromEnv()
+	e, err := Compile(p)
+	if err != nil {
+		logger.Warn("redact: invalid PII_REDACT_* policy; using built-in rules", zap.Error(err))
+		e, _ = Compile(Policy{Enabled: p.Enabled, Mask: p.Mask, Audio: p.Audio})
+	}
+	mu.Lock()
+	if !defaultInit {
+		defaultEng = e
+		defaultInit = true
+	}
+	e = defaultEng
+	mu.Unlock()
+	return e
+}
+
+// ForTenant resolves the engine for tenantID: the tenant's stored policy
+// when present (even if it disables redaction), otherwise Default(). A
+// stored policy that no longer parses or compiles is logged and treated
+// as absent.
+func ForTenant(ctx context.Context, tenantID uint) *Engine {
+	if tenantID == 0 {
+		return Default()
+	}
+	mu.RLock()
+	fn := loader
+	c, hit := tenantCache[tenantID]
+	mu.RUnlock()
+	if fn == nil {
+		return Default()
+	}
+	if hit && time.Since(c.at) < tenantCacheTTL {
+		return c.eng
+	}
+	raw, ok := fn(ctx, tenantID)
+	next := cachedEngine{eng: Default(), at: time.Now()}
+	if ok && len(strings.TrimSpace(string(raw))) > 0 {
+		p, err := ParsePolicy(raw)
+		if err == nil {
+			var e *Engine
+			if e, err = Compile(p); err == nil {
+				next.eng = e
+			}
+		}
+		if err != nil {
+			next.bad = string(raw)
+			if !hit || c.bad != next.bad {
+				logger.Warn("redact: invalid tenant privacy policy; using default",
+					zap.Uint("tenant_id", tenantID), zap.Error(err))
+			}
+		}
+	}
+	mu.Lock()
+	tenantCache[tenantID] = next
+	mu.Unlock()
+	return next.eng
+}
+
+// InvalidateTenant drops the cached engine so the next ForTenant reloads.
+// Called by the tenant admin handler after privacy_config is saved.
+func InvalidateTenant(tenantID uint) {
+	mu.Lock()
+	delete(tenantCache, tenantID)
+	mu.Unlock()
+}
+
+// Text masks s with the process default engine. Shorthand for log lines
+// that have no tenant in scope.
+func Text(s string) string { return Default().Text(s) }
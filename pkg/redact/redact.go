@@ -0,0 +1,304 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package redact masks personally identifiable information (PII) in
+// call transcripts, script traces, log lines and recordings before they
+// leave the process.
+//
+// docs/sip_gap_analysis.md §5 flagged that `sip_calls.turns`,
+// `sip_script_runs.input_text` and the ASR log lines carry caller phone
+// numbers / ID card numbers / bank cards in clear text. This package is
+// the single place that knows what "sensitive" looks like:
+//
+//   - Engine (this file): compiled rule set; Find returns byte spans,
+//     Text returns the masked string.
+//   - Policy / ForTenant (policy.go): JSON policy stored per tenant with
+//     an env-driven process default.
+//   - secure.go: per-call "secure step" windows during which DTMF and
+//     ASR text are masked wholesale (card PIN / CVV collection).
+//   - audio.go: maps matches onto ASR word timestamps and overwrites the
+//     corresponding PCM ranges with silence or a 1 kHz beep.
+//
+// Validation is deliberately conservative on the checksum-bearing rules
+// (ID card ISO 7064 check digit, bank card Luhn) so order numbers and
+// timestamps in transcripts are not shredded by accident.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies one built-in (or custom) redaction rule.
+type Kind string
+
+const (
+	KindPhone    Kind = "phone"     // mainland mobile / landline, optional +86
+	KindCNID     Kind = "cn_id"     // 18-digit resident ID with check digit
+	KindBankCard Kind = "bank_card" // 13-19 digits passing Luhn
+	KindEmail    Kind = "email"
+	KindCustom   Kind = "custom" // operator-supplied regex
+	KindDTMF     Kind = "dtmf"   // keypad digits captured during a secure step
+)
+
+// DefaultMask replaces every match when Policy.Mask is empty.
+const DefaultMask = "***"
+
+// BuiltinKinds lists the rules enabled when Policy.Rules is empty.
+var BuiltinKinds = []Kind{KindPhone, KindCNID, KindBankCard, KindEmail}
+
+// Match is one sensitive span inside the scanned text. Start/End are
+// byte offsets (s[Start:End] is the matched substring).
+type Match struct {
+	Kind  Kind
+	Name  string // custom rule name; empty for built-ins
+	Start int
+	End   int
+}
+
+type rule struct {
+	kind     Kind
+	name     string
+	re       *regexp.Regexp
+	validate func(digits string) bool
+	// digitBounded rejects matches glued to further digits, which Go's
+	// RE2 cannot express with look-around ("13800138000123" is not a
+	// phone number).
+	digitBounded bool
+}
+
+var (
+	rePhone    = regexp.MustCompile(`(?:\+?86[ -]?)?1[3-9]\d[ -]?\d{4}[ -]?\d{4}|0\d{2,3}-\d{7,8}`)
+	reCNID     = regexp.MustCompile(`\d{17}[\dXx]`)
+	reBankCard = regexp.MustCompile(`\d{4}(?:[ -]?\d{4}){2,3}(?:[ -]?\d{1,3})?`)
+	reEmail    = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// Engine is a compiled, immutable rule set. Safe for concurrent use.
+// A nil *Engine is valid and redacts nothing.
+type Engine struct {
+	rules []rule
+	mask  string
+	audio AudioMode
+}
+
+// Enabled reports whether the engine has at least one rule.
+func (e *Engine) Enabled() bool { return e != nil && len(e.rules) > 0 }
+
+// Mask returns the replacement string used by Text.
+func (e *Engine) Mask() string {
+	if e == nil || e.mask == "" {
+		return DefaultMask
+	}
+	return e.mask
+}
+
+// AudioMode returns how recordings should be redacted (AudioOff when the
+// policy did not ask for audio redaction).
+func (e *Engine) AudioMode() AudioMode {
+	if e == nil {
+		return AudioOff
+	}
+	return e.audio
+}
+
+// Find returns non-overlapping matches ordered by Start. When two rules
+// overlap the earlier (then longer) span wins so "+86 138…" is reported
+// once as a phone number rather than also as a bank-card fragment.
+func (e *Engine) Find(s string) []Match {
+	if !e.Enabled() || s == "" {
+		return nil
+	}
+	var all []Match
+	for _, r := range e.rules {
+		for _, loc := range r.re.FindAllStringIndex(s, -1) {
+			start, end := loc[0], loc[1]
+			if r.digitBounded && !digitBoundary(s, start, end) {
+				continue
+			}
+			if r.validate != nil && !r.validate(onlyDigits(s[start:end])) {
+				continue
+			}
+			all = append(all, Match{Kind: r.kind, Name: r.name, Start: start, End: end})
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Start != all[j].Start {
+			return all[i].Start < all[j].Start
+		}
+		return all[i].End > all[j].End
+	})
+	out := all[:0]
+	lastEnd := -1
+	for _, m := range all {
+		if m.Start < lastEnd {
+			continue
+		}
+		out = append(out, m)
+		lastEnd = m.End
+	}
+	return out
+}
+
+// Text returns s with every match replaced by the engine mask. Returns
+// s unchanged for a nil / empty engine.
+func (e *Engine) Text(s string) string {
+	ms := e.Find(s)
+	if len(ms) == 0 {
+		return s
+	}
+	mask := e.Mask()
+	var b strings.Builder
+	b.Grow(len(s))
+	prev := 0
+	for _, m := range ms {
+		b.WriteString(s[prev:m.Start])
+		b.WriteString(mask)
+		prev = m.End
+	}
+	b.WriteString(s[prev:])
+	return b.String()
+}
+
+// Compile builds an Engine from p. A disabled policy yields a nil
+// engine (valid; redacts nothing). Unknown rule kinds and invalid custom
+// patterns are reported so admin APIs can reject them at save time.
+func Compile(p Policy) (*Engine, error) {
+	if !p.Enabled {
+		return nil, nil
+	}
+	kinds := p.Rules
+	if len(kinds) == 0 {
+		kinds = BuiltinKinds
+	}
+	e := &Engine{mask: p.Mask, audio: ParseAudioMode(string(p.Audio))}
+	seen := map[Kind]bool{}
+	for _, k := range kinds {
+		k = Kind(strings.ToLower(strings.TrimSpace(string(k))))
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		switch k {
+		case KindPhone:
+			e.rules = append(e.rules, rule{kind: k, re: rePhone, digitBounded: true})
+		case KindCNID:
+			e.rules = append(e.rules, rule{kind: k, re: reCNID, validate: validCNID, digitBounded: true})
+		case KindBankCard:
+			e.rules = append(e.rules, rule{kind: k, re: reBankCard, validate: luhnValid, digitBounded: true})
+		case KindEmail:
+			e.rules = append(e.rules, rule{kind: k, re: reEmail})
+		default:
+			return nil, &RuleError{Kind: k}
+		}
+	}
+	for _, c := range p.Custom {
+		pat := strings.TrimSpace(c.Pattern)
+		if pat == "" {
+			continue
+		}
+		re, err := regexp.Compile(pat)
+		if err != nil {
+			return nil, &PatternError{Name: c.Name, Err: err}
+		}
+		e.rules = append(e.rules, rule{kind: KindCustom, name: strings.TrimSpace(c.Name), re: re})
+	}
+	return e, nil
+}
+
+// PatternError reports an invalid custom regex in a Policy.
+type PatternError struct {
+	Name string
+	Err  error
+}
+
+func (e *PatternError) Error() string {
+	if e.Name == "" {
+		return "redact: invalid custom pattern: " + e.Err.Error()
+	}
+	return "redact: invalid custom pattern " + e.Name + ": " + e.Err.Error()
+}
+
+func (e *PatternError) Unwrap() error { return e.Err }
+
+// RuleError reports a Policy.Rules entry that is not a built-in kind.
+type RuleError struct {
+	Kind Kind
+}
+
+func (e *RuleError) Error() string {
+	return "redact: unknown rule " + strconv.Quote(string(e.Kind)) + " (want one of phone, cn_id, bank_card, email)"
+}
+
+func digitBoundary(s string, start, end int) bool {
+	if start > 0 && isDigit(s[start-1]) {
+		return false
+	}
+	if end < len(s) && isDigit(s[end]) {
+		return false
+	}
+	return true
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isDigit(c) || c == 'X' || c == 'x' {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// validCNID checks the GB 11643 / ISO 7064 MOD 11-2 check digit.
+func validCNID(d string) bool {
+	if len(d) != 18 {
+		return false
+	}
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	const checks = "10X98765432"
+	sum := 0
+	for i := 0; i < 17; i++ {
+		if !isDigit(d[i]) {
+			return false
+		}
+		sum += int(d[i]-'0') * weights[i]
+	}
+	last := d[17]
+	if last == 'x' {
+		last = 'X'
+	}
+	return checks[sum%11] == last
+}
+
+// luhnValid implements the ISO/IEC 7812 Luhn check used by card PANs.
+func luhnValid(d string) bool {
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		if !isDigit(d[i]) {
+			return false
+		}
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
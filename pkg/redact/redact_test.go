@@ -0,0 +1,247 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package redact
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func mustCompile(t *testing.T, p Policy) *Engine {
+	t.Helper()
+	e, err := Compile(p)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	return e
+}
+
+func TestEngine_BuiltinRules(t *testing.T) {
+	e := mustCompile(t, Policy{Enabled: true})
+	cases := []struct {
+		in, want string
+	}{
+		{"我的手机是13812345678谢谢", "我的手机是***谢谢"},
+		{"call +86 138 1234 5678 now", "call *** now"},
+		{"座机 010-88886666", "座机 ***"},
+		{"身份证11010519491231002X", "身份证***"},
+		{"卡 4111 1111 1111 1111", "卡 ***"},
+		{"mail foo.bar@example.com ok", "mail *** ok"},
+		// Not PII: glued to more digits / bad checksums.
+		{"订单13812345678123", "订单13812345678123"},
+		{"11010519491231002Y", "11010519491231002Y"},
+		{"4111111111111112", "4111111111111112"},
+	}
+	for _, c := range cases {
+		if got := e.Text(c.in); got != c.want {
+			t.Errorf("Text(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestEngine_RulesMaskAndCustom(t *testing.T) {
+	e := mustCompile(t, Policy{
+		Enabled: true,
+		Rules:   []Kind{KindEmail},
+		Mask:    "[x]",
+		Custom:  []CustomRule{{Name: "order", Pattern: `ORD-\d{4}`}},
+	})
+	got := e.Text("13812345678 a@b.io ORD-1234")
+	if got != "13812345678 [x] [x]" {
+		t.Fatalf("got %q", got)
+	}
+	ms := e.Find("ORD-9999")
+	if len(ms) != 1 || ms[0].Kind != KindCustom || ms[0].Name != "order" {
+		t.Fatalf("custom match: %+v", ms)
+	}
+
+	_, err := Compile(Policy{Enabled: true, Custom: []CustomRule{{Name: "bad", Pattern: "("}}})
+	var pe *PatternError
+	if !errors.As(err, &pe) || pe.Name != "bad" {
+		t.Fatalf("want PatternError, got %v", err)
+	}
+}
+
+func TestCompile_UnknownRuleRejected(t *testing.T) {
+	_, err := Compile(Policy{Enabled: true, Rules: []Kind{KindPhone, "bankcard"}})
+	var re *RuleError
+	if !errors.As(err, &re) || re.Kind != "bankcard" {
+		t.Fatalf("want RuleError for bankcard, got %v", err)
+	}
+	e := mustCompile(t, Policy{Enabled: true, Rules: []Kind{" Bank_Card ", ""}})
+	if got := e.Text("卡 4111 1111 1111 1111"); got != "卡 ***" {
+		t.Fatalf("normalized kind: %q", got)
+	}
+}
+
+func TestEngine_DisabledAndNil(t *testing.T) {
+	e, err := Compile(Policy{Enabled: false, Rules: []Kind{KindPhone}})
+	if err != nil || e != nil {
+		t.Fatalf("disabled policy: e=%v err=%v", e, err)
+	}
+	if e.Enabled() || e.Text("13812345678") != "13812345678" || e.AudioMode() != AudioOff {
+		t.Fatal("nil engine must be a no-op")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(`{"enabled":true,"rules":["phone"],"audio":"beep"}`))
+	if err != nil || !p.Enabled || len(p.Rules) != 1 || p.Audio != AudioBeep {
+		t.Fatalf("p=%+v err=%v", p, err)
+	}
+	if p, err := ParsePolicy([]byte(" null ")); err != nil || p.Enabled {
+		t.Fatalf("null: p=%+v err=%v", p, err)
+	}
+	if _, err := ParsePolicy([]byte("{")); err == nil {
+		t.Fatal("want error on bad JSON")
+	}
+}
+
+func TestForTenant_LoaderAndCache(t *testing.T) {
+	SetDefault(nil)
+	defer SetPolicyLoader(nil)
+	calls := 0
+	SetPolicyLoader(func(_ context.Context, id uint) ([]byte, bool) {
+		calls++
+		if id == 7 {
+			return []byte(`{"enabled":true,"rules":["phone"]}`), true
+		}
+		return nil, false
+	})
+	if got := ForTenant(context.Background(), 7).Text("13812345678"); got != DefaultMask {
+		t.Fatalf("tenant 7: %q", got)
+	}
+	ForTenant(context.Background(), 7)
+	if calls != 1 {
+		t.Fatalf("expected cached engine, loader calls=%d", calls)
+	}
+	InvalidateTenant(7)
+	ForTenant(context.Background(), 7)
+	if calls != 2 {
+		t.Fatalf("expected reload after invalidate, loader calls=%d", calls)
+	}
+	if ForTenant(context.Background(), 8).Enabled() {
+		t.Fatal("tenant without policy should fall back to (disabled) default")
+	}
+}
+
+func TestForTenant_InvalidPolicyWarnsOnce(t *testing.T) {
+	SetDefault(nil)
+	defer SetPolicyLoader(nil)
+	core, logs := observer.New(zap.WarnLevel)
+	prev := logger.Lg
+	logger.Lg = zap.New(core)
+	defer func() { logger.Lg = prev }()
+
+	expire := func() {
+		mu.Lock()
+		c := tenantCache[9]
+		c.at = time.Time{}
+		tenantCache[9] = c
+		mu.Unlock()
+	}
+	raw := `{"enabled":true,"rules":["bankcard"]}`
+	SetPolicyLoader(func(context.Context, uint) ([]byte, bool) { return []byte(raw), true })
+	if ForTenant(context.Background(), 9).Enabled() {
+		t.Fatal("invalid tenant policy should fall back to (disabled) default")
+	}
+	// Force reloads past the TTL: the same broken row must not re-warn.
+	for i := 0; i < 3; i++ {
+		expire()
+		ForTenant(context.Background(), 9)
+	}
+	if n := logs.Len(); n != 1 {
+		t.Fatalf("warnings = %d, want 1", n)
+	}
+	if f := logs.All()[0].ContextMap(); f["tenant_id"] != uint64(9) {
+		t.Fatalf("warning fields: %v", f)
+	}
+	raw = `{"enabled":true,"custom":[{"name":"x","pattern":"("}]}`
+	expire()
+	ForTenant(context.Background(), 9)
+	if n := logs.Len(); n != 2 {
+		t.Fatalf("edited broken policy should warn again, warnings = %d", n)
+	}
+}
+
+func TestSecureWindows(t *testing.T) {
+	const id = "call-secure"
+	e := mustCompile(t, Policy{Enabled: true})
+	if DTMF(id, "5") != "5" || CallText(e, id, "hello") != "hello" {
+		t.Fatal("outside secure step nothing is masked")
+	}
+	BeginSecure(id)
+	BeginSecure(id) // idempotent
+	if !InSecure(id) || DTMF(id, "5") != SecureMask || CallText(e, id, "1234") != SecureMask {
+		t.Fatal("inside secure step input must be masked")
+	}
+	EndSecure(id)
+	if InSecure(id) {
+		t.Fatal("EndSecure did not close window")
+	}
+	BeginSecure(id) // left open: TakeSecureWindows closes it
+	ws := TakeSecureWindows(id)
+	if len(ws) != 2 || ws[1].End.Before(ws[1].Start) {
+		t.Fatalf("windows: %+v", ws)
+	}
+	if TakeSecureWindows(id) != nil {
+		t.Fatal("state should be released after Take")
+	}
+}
+
+func TestAudioRanges_SplitTokens(t *testing.T) {
+	e := mustCompile(t, Policy{Enabled: true, Rules: []Kind{KindPhone}})
+	base := time.Unix(1000, 0)
+	words := []WordTiming{
+		{Text: "号码", StartMs: 0, EndMs: 400},
+		{Text: "138", StartMs: 500, EndMs: 900},
+		{Text: "1234", StartMs: 900, EndMs: 1400},
+		{Text: "5678", StartMs: 1400, EndMs: 1900},
+		{Text: "好的", StartMs: 2500, EndMs: 2900},
+	}
+	rs := AudioRanges(e, base, words, 100*time.Millisecond)
+	if len(rs) != 1 {
+		t.Fatalf("ranges: %+v", rs)
+	}
+	if want := base.Add(400 * time.Millisecond); !rs[0].Start.Equal(want) {
+		t.Errorf("start = %v, want %v", rs[0].Start, want)
+	}
+	if want := base.Add(2000 * time.Millisecond); !rs[0].End.Equal(want) {
+		t.Errorf("end = %v, want %v", rs[0].End, want)
+	}
+}
+
+func TestToSampleRangesAndMask(t *testing.T) {
+	base := time.Unix(0, 0)
+	srs := ToSampleRanges([]TimeRange{
+		{Start: base.Add(20 * time.Millisecond), End: base.Add(30 * time.Millisecond)},
+		{Start: base.Add(-time.Second), End: base.Add(10 * time.Millisecond)},
+		{Start: base.Add(25 * time.Millisecond), End: base.Add(40 * time.Millisecond)},
+	}, base, 8000)
+	want := []SampleRange{{0, 80}, {160, 320}}
+	if len(srs) != len(want) || srs[0] != want[0] || srs[1] != want[1] {
+		t.Fatalf("sample ranges = %+v, want %+v", srs, want)
+	}
+
+	pcm := make([]byte, 400*2)
+	for i := 0; i < len(pcm); i += 2 {
+		binary.LittleEndian.PutUint16(pcm[i:], 1000)
+	}
+	// Block starts at absolute sample 100: only the second range intersects.
+	MaskPCM16(pcm, 100, 8000, srs, AudioSilence)
+	at := func(abs int) int16 { return int16(binary.LittleEndian.Uint16(pcm[(abs-100)*2:])) }
+	if at(159) != 1000 || at(160) != 0 || at(319) != 0 || at(320) != 1000 {
+		t.Fatalf("mask edges: %d %d %d %d", at(159), at(160), at(319), at(320))
+	}
+	if AudioBeep.Sample(2, 8000) == 0 || AudioSilence.Sample(2, 8000) != 0 {
+		t.Fatal("beep must be non-zero, silence zero")
+	}
+}
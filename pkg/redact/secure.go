@@ -0,0 +1,155 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package redact
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecureMask replaces keypad input and ASR text captured while a call is
+// inside a secure script step. Deliberately not configurable per tenant:
+// a secure step collects card PIN / CVV / verification codes, where even
+// the digit count is sensitive.
+const SecureMask = "[secure]"
+
+// SecureWindow is one closed secure interval on a call, in wall-clock
+// time. Recording redaction consumes these to blank the matching audio.
+type SecureWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+type secureState struct {
+	openAt  time.Time
+	windows []SecureWindow
+}
+
+var (
+	secureMu    sync.Mutex
+	secureCalls = map[string]*secureState{}
+)
+
+// BeginSecure marks callID as being inside a secure step (hybrid script
+// steps with "secure": true). Repeated calls while already open are
+// no-ops so nested retries do not reset the window start.
+func BeginSecure(callID string) {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return
+	}
+	secureMu.Lock()
+	defer secureMu.Unlock()
+	st := secureCalls[callID]
+	if st == nil {
+		st = &secureState{}
+		secureCalls[callID] = st
+	}
+	if st.openAt.IsZero() {
+		st.openAt = time.Now()
+	}
+}
+
+// EndSecure closes the open secure window for callID, if any.
+func EndSecure(callID string) {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return
+	}
+	secureMu.Lock()
+	defer secureMu.Unlock()
+	st := secureCalls[callID]
+	if st == nil || st.openAt.IsZero() {
+		return
+	}
+	st.windows = append(st.windows, SecureWindow{Start: st.openAt, End: time.Now()})
+	st.openAt = time.Time{}
+}
+
+// InSecure reports whether callID is currently inside a secure step.
+func InSecure(callID string) bool {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return false
+	}
+	secureMu.Lock()
+	defer secureMu.Unlock()
+	st := secureCalls[callID]
+	return st != nil && !st.openAt.IsZero()
+}
+
+// TakeSecureWindows returns every secure window recorded for callID
+// (closing a still-open one at now) and forgets the call. Called once
+// at teardown by the recording path.
+func TakeSecureWindows(callID string) []SecureWindow {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return nil
+	}
+	secureMu.Lock()
+	defer secureMu.Unlock()
+	st := secureCalls[callID]
+	delete(secureCalls, callID)
+	if st == nil {
+		return nil
+	}
+	if !st.openAt.IsZero() {
+		st.windows = append(st.windows, SecureWindow{Start: st.openAt, End: time.Now()})
+	}
+	return st.windows
+}
+
+// SecureWindowsSoFar returns the secure windows recorded for callID so
+// far, with a still-open one ending at end, without forgetting the call.
+// Rolling recording parts use it to blank secure audio before upload.
+func SecureWindowsSoFar(callID string, end time.Time) []SecureWindow {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return nil
+	}
+	secureMu.Lock()
+	defer secureMu.Unlock()
+	st := secureCalls[callID]
+	if st == nil {
+		return nil
+	}
+	out := append([]SecureWindow(nil), st.windows...)
+	if !st.openAt.IsZero() {
+		out = append(out, SecureWindow{Start: st.openAt, End: end})
+	}
+	return out
+}
+
+// ForgetSecure drops every secure window recorded for callID. Called
+// from CallSession.Stop, which every call reaches, so calls that never
+// flush a recording (no recorder, failed before teardown) do not leave
+// entries behind.
+func ForgetSecure(callID string) {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return
+	}
+	secureMu.Lock()
+	delete(secureCalls, callID)
+	secureMu.Unlock()
+}
+
+// DTMF returns the loggable form of a keypad digit for callID: the digit
+// itself normally, SecureMask inside a secure step.
+func DTMF(callID, digit string) string {
+	if InSecure(callID) {
+		return SecureMask
+	}
+	return digit
+}
+
+// CallText masks s for callID: wholesale SecureMask inside a secure
+// step, otherwise e.Text(s).
+func CallText(e *Engine, callID, s string) string {
+	if strings.TrimSpace(s) != "" && InSecure(callID) {
+		return SecureMask
+	}
+	return e.Text(s)
+}
@@ -0,0 +1,36 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/LinByte/VoiceServer/pkg/dialog/tenantcfg"
+	"github.com/LinByte/VoiceServer/pkg/llm"
+)
+
+// ErrTenantChatLLMUnavailable means the tenant has no usable llmConfig.
+var ErrTenantChatLLMUnavailable = errors.New("sip chat LLM: incomplete tenant llmConfig")
+
+// NewTenantChatLLMProvider builds one text-chat LLM session (SIP MESSAGE
+// chatbot) from the tenant's voice llmConfig: same factory, credentials
+// and default model as the voice pipeline. The provider keeps the
+// conversation history; call Hangup when the chat is retired.
+func NewTenantChatLLMProvider(ctx context.Context, tenantID uint, systemPrompt string) (llm.LLMProvider, string, error) {
+	env, loaded, err := tenantcfg.Resolve(ctx, tenantID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !loaded || !voiceEnvLLMReady(env) {
+		return nil, "", fmt.Errorf("%w (tenant %d)", ErrTenantChatLLMUnavailable, tenantID)
+	}
+	p, err := llm.NewLLMProvider(ctx, env.LLMProvider, env.LLMAPIKey, llmAPIURLForProvider(env), systemPrompt)
+	if err != nil {
+		return nil, "", err
+	}
+	model := env.LLMModel
+	if model == "" {
+		model = "qwen-plus"
+	}
+	return p, model, nil
+}
@@ -44,6 +44,7 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/dialog/cascaded"
 	"github.com/LinByte/VoiceServer/pkg/llm"
 	"github.com/LinByte/VoiceServer/pkg/recognizer"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"github.com/LinByte/VoiceServer/pkg/synthesizer"
 	sipasr "github.com/LinByte/VoiceServer/pkg/voice/asr"
@@ -56,11 +57,11 @@ import (
 // enableNativeStereoRecorder mirrors the legacy attachVoiceInner
 // recorder bootstrap. SIP_RECORDER_CHUNK_SECS controls rolling
 // upload cadence; storage bucket is configured by pkg/stores.
-func enableNativeStereoRecorder(cs *sipSession.CallSession, lg *zap.Logger) {
+func enableNativeStereoRecorder(cs *sipSession.CallSession, pii *redact.Engine, lg *zap.Logger) {
 	if cs == nil {
 		return
 	}
-	cfg := siprecorder.Config{Logger: lg}
+	cfg := siprecorder.Config{Logger: lg, RedactMode: pii.AudioMode()}
 	if secs, err := strconv.Atoi(strings.TrimSpace(os.Getenv("SIP_RECORDER_CHUNK_SECS"))); err == nil && secs > 0 {
 		cfg.ChunkInterval = time.Duration(secs) * time.Second
 	}
@@ -80,8 +81,9 @@ func enableNativeStereoRecorder(cs *sipSession.CallSession, lg *zap.Logger) {
 // recognizers move out of the legacy helpers.
 //
 // The returned *sipasr.Pipeline already satisfies cascaded.ASRRecognizer
-// (the interface was carved to match it).
-func buildNativeCascadedASR(env VoiceEnv, lg *zap.Logger) (cascaded.ASRRecognizer, error) {
+// (the interface was carved to match it). A non-nil pii wraps it so the
+// stream's word timestamps blank PII in the recording.
+func buildNativeCascadedASR(env VoiceEnv, pii *callAudioRedactor, lg *zap.Logger) (cascaded.ASRRecognizer, error) {
 	if strings.TrimSpace(env.ASRAppID) == "" ||
 		strings.TrimSpace(env.ASRSecretID) == "" ||
 		strings.TrimSpace(env.ASRSecretKey) == "" {
@@ -91,6 +93,8 @@ func buildNativeCascadedASR(env VoiceEnv, lg *zap.Logger) (cascaded.ASRRecognize
 	if env.ASRModelType != "" {
 		opt.ModelType = env.ASRModelType
 	}
+	stream := pii.asrStream()
+	stream.wire(&opt)
 	asrOutRate := 16000
 	if strings.Contains(strings.ToLower(opt.ModelType), "8k") {
 		asrOutRate = 8000
@@ -104,6 +108,9 @@ func buildNativeCascadedASR(env VoiceEnv, lg *zap.Logger) (cascaded.ASRRecognize
 	if err != nil {
 		return nil, fmt.Errorf("native cascaded ASR: pipeline: %w", err)
 	}
+	if stream != nil {
+		return &redactingASR{Pipeline: pipe, stream: stream}, nil
+	}
 	return pipe, nil
 }
 
@@ -120,6 +127,25 @@ type nativeCascadedLLM struct {
 	// reply so the caller side hears the agent, not the AI talking
 	// over hold music.
 	callID string
+	// basePrompt is the system prompt at build time; language switches
+	// append their instruction to it (see setLanguagePrompt).
+	basePrompt string
+}
+
+// setLanguagePrompt replaces the system prompt with basePrompt plus the
+// dialog-language instruction suffix.
+func (s *nativeCascadedLLM) setLanguagePrompt(suffix string) {
+	if s == nil || s.provider == nil {
+		return
+	}
+	prompt := s.basePrompt
+	if suffix != "" {
+		if prompt != "" {
+			prompt += "\n\n"
+		}
+		prompt += suffix
+	}
+	s.provider.SetSystemPrompt(prompt)
 }
 
 // StreamReply implements cascaded.LLMService. Delegates to
@@ -203,7 +229,7 @@ func buildNativeCascadedLLM(ctx context.Context, env VoiceEnv, callID string, lg
 	// Mirror legacy attachVoiceInner: install the transfer function
 	// tool. nil-safe under the hood when callID is empty.
 	registerSIPTransferTool(provider, callID, TransferConfirmRequired(env), lg)
-	return &nativeCascadedLLM{provider: provider, model: model, callID: callID}, provider, nil
+	return &nativeCascadedLLM{provider: provider, model: model, callID: callID, basePrompt: systemPrompt}, provider, nil
 }
 
 // --- Turn persister adapter -----------------------------------------
@@ -309,6 +335,7 @@ func buildNativeTurnPersister(env VoiceEnv, callID string, provider llm.LLMProvi
 // goroutine — atomic.Pointer makes that safe.
 type nativeCascadedTTS struct {
 	pipe   *siptts.Pipeline
+	svc    synthesizer.SynthesisService // provider connection, released by Close
 	onPCM  atomic.Pointer[func(pcm []byte) error]
 	ctx    atomic.Pointer[context.Context]
 	logger *zap.Logger
@@ -355,6 +382,20 @@ func (a *nativeCascadedTTS) Finalize(ctx context.Context, onPCM func(pcm []byte)
 	return nil
 }
 
+// Close stops the pipeline and releases the provider connection.
+func (a *nativeCascadedTTS) Close() error {
+	if a == nil {
+		return nil
+	}
+	if a.pipe != nil {
+		a.pipe.Stop()
+	}
+	if a.svc == nil {
+		return nil
+	}
+	return a.svc.Close()
+}
+
 // buildNativeCascadedTTS constructs a siptts.Pipeline for the
 // tenant's TTS credentials and wraps it as cascaded.TTSService.
 // bridgeRate is the SIP-side PCM rate the engine's MediaPort
@@ -374,7 +415,7 @@ func buildNativeCascadedTTS(env VoiceEnv, bridgeRate int, recorderTap func(pcm [
 		return nil, fmt.Errorf("native cascaded TTS: provider: %w", err)
 	}
 	ttsCloudSR := sipVoiceTTSCloudSampleRate(env, ttsHandle.SampleRate, bridgeRate)
-	adapter := &nativeCascadedTTS{logger: lg}
+	adapter := &nativeCascadedTTS{svc: ttsHandle.Service, logger: lg}
 	pipe, err := siptts.New(siptts.Config{
 		Service:       ttsHandle.Stream,
 		SampleRate:    ttsCloudSR,
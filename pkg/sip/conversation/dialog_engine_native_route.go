@@ -65,6 +65,8 @@ import (
 
 	"github.com/LinByte/VoiceServer/pkg/dialog/cascaded"
 	"github.com/LinByte/VoiceServer/pkg/dialog/engine"
+	"github.com/LinByte/VoiceServer/pkg/dialog/langid"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	sipMetrics "github.com/LinByte/VoiceServer/pkg/sip/metrics"
 	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"go.uber.org/zap"
@@ -267,11 +269,28 @@ func attachVoiceViaNativeCascaded(
 	}
 	// Enable stereo recording (parity with the legacy path) — the
 	// recorder is a baseline product capability, not optional.
-	enableNativeStereoRecorder(cs, lg)
+	pii := redact.ForTenant(ctx, cs.TenantID())
+	enableNativeStereoRecorder(cs, pii, lg)
 	// Build the three production-side adapters. Each failure path
 	// closes the port + bumps the err metric. We do NOT fall back
 	// to legacy — native is the production path now.
-	asrSvc, err := buildNativeCascadedASR(env, lg)
+	//
+	// Recorder tap: feed every TTS frame into the stereo recorder
+	// so AI audio is captured at the bridge rate. cs.WriteAIPCM is
+	// safe-nil internally; the tap is a thin wrapper for that.
+	recorderTap := func(pcm []byte) { cs.WriteAIPCM(pcm) }
+	// Per-number languages: the default language's ASR model / voice /
+	// prompt, plus a first-utterance race when several are configured.
+	lang := &nativeCallLanguage{
+		callID:      cs.CallID,
+		env:         env,
+		opts:        resolveCallLanguages(cs.CallID, lg),
+		bridgeRate:  port.SampleRate(),
+		recorderTap: recorderTap,
+		pii:         newCallAudioRedactor(cs, pii, lg),
+		lg:          lg,
+	}
+	asrSvc, err := lang.buildASR()
 	if err != nil {
 		_ = port.Close()
 		sipMetrics.VoiceAttachNative(false)
@@ -283,16 +302,15 @@ func attachVoiceViaNativeCascaded(
 		sipMetrics.VoiceAttachNative(false)
 		return fmt.Errorf("native cascaded attach: LLM: %w", err)
 	}
-	// Recorder tap: feed every TTS frame into the stereo recorder
-	// so AI audio is captured at the bridge rate. cs.WriteAIPCM is
-	// safe-nil internally; the tap is a thin wrapper for that.
-	recorderTap := func(pcm []byte) { cs.WriteAIPCM(pcm) }
-	ttsSvc, err := buildNativeCascadedTTS(env, port.SampleRate(), recorderTap, lg)
+	ttsSvc, err := buildNativeCascadedTTS(lang.defaultEnv(), port.SampleRate(), recorderTap, lg)
 	if err != nil {
 		_ = port.Close()
 		sipMetrics.VoiceAttachNative(false)
 		return fmt.Errorf("native cascaded attach: TTS: %w", err)
 	}
+	lang.llm, _ = llmSvc.(*nativeCascadedLLM)
+	lang.tts = &languageTTS{svc: ttsSvc}
+	lang.applyDefault()
 	// Hotword corrector — same env-driven config the legacy path
 	// uses (SIP_HOTWORD_CORRECTIONS / _JSON). nil-safe inside the
 	// stage so an unconfigured tenant just passes text through.
@@ -310,13 +328,16 @@ func attachVoiceViaNativeCascaded(
 	// cascaded.New (rather than engine.New) so we can pass the
 	// per-call provider Options. The registry-registered factory
 	// stays in place for callers that don't need injection.
-	eng := cascaded.New(cfg,
+	opts := []cascaded.Option{
 		cascaded.WithASRRecognizer(asrSvc),
 		cascaded.WithLLMService(llmSvc),
-		cascaded.WithTTSService(ttsSvc),
+		cascaded.WithTTSService(lang.tts),
 		cascaded.WithTextRewriter(hotword),
 		cascaded.WithTurnPersister(persister),
-	)
+	}
+	// Barge-in (SIP_VAD_*) and semantic end-of-turn (SIP_END_OF_TURN).
+	opts = append(opts, nativeTurnTakingOptions(port.SampleRate(), port.TriggerBargeIn, lg)...)
+	eng := cascaded.New(cfg, opts...)
 	lg.Info("native cascaded attach: routing through cascaded.Engine",
 		zap.String("call_id", cs.CallID),
 		zap.String("tenant_id", port.TenantID()),
@@ -324,6 +345,7 @@ func attachVoiceViaNativeCascaded(
 		zap.String("asr_provider", env.ASRProvider),
 		zap.String("llm_provider", env.LLMProvider),
 		zap.String("tts_provider", env.TTSProvider),
+		zap.Strings("languages", langid.Tags(lang.opts)),
 	)
 	attachErr := cs.AttachVoiceConversation(func() error {
 		_, e := eng.Attach(ctx, port, NewZapEngineLogger(lg))
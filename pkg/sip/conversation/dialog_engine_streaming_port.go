@@ -16,11 +16,11 @@ package conversation
 //     IsSynthesized:true} and pumps it through MediaSession.SendToOutput,
 //     resampling when the caller-supplied SampleRate differs from the
 //     bridge rate.
-//   - OnBargeIn(): the registration is stored but NOT fired by the port
-//     itself in this phase. The legacy attachers own VAD (sipvad) and
-//     fire barge-in by draining outputs directly. A future PR moves
-//     VAD into a pipeline.Stage so the engine can fire OnBargeIn
-//     through here; until then this is a pass-through stub.
+//   - OnBargeIn(): the registration is stored and fired from
+//     TriggerBargeIn, which the native attach hands to the cascaded
+//     engine's vadStage (nativeTurnTakingOptions). The legacy
+//     attachers still run their own detector and drain outputs
+//     directly.
 //
 // Lifecycle:
 //
@@ -271,10 +271,8 @@ func (p *StreamingCallSessionPort) SendOutputPCM(frame engine.PCMFrame) error {
 	return nil
 }
 
-// OnBargeIn stores the callback. Phase-3 stub: the port itself does
-// not fire it (VAD lives in legacy attachers + sipvad today). Future
-// PR moves VAD into a pipeline.Stage that will invoke this through
-// (*StreamingCallSessionPort).fireBargeIn — see the package doc.
+// OnBargeIn stores the callback; TriggerBargeIn fires it when the
+// cascaded engine's vadStage detects the caller talking over TTS.
 //
 // Replacing a previous registration is allowed; nil clears it.
 func (p *StreamingCallSessionPort) OnBargeIn(fn func()) {
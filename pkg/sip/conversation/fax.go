@@ -0,0 +1,94 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+import (
+	"os"
+	"sync"
+
+	sipfax "github.com/LinByte/VoiceServer/pkg/sip/fax"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+)
+
+// faxModeResolver returns the per-trunk fax mode (Trunk.FaxMode) for an
+// inbound Call-ID; "" falls back to SIP_FAX_MODE, then auto.
+// faxToneHandler is pkg/sip/server's StartFax, wired in
+// internal/sipserver/sipapp.go.
+var (
+	faxHooksMu      sync.RWMutex
+	faxModeResolver func(callID string) string
+	faxToneHandler  func(callID, tone string)
+)
+
+// SetFaxModeResolver installs the per-trunk fax mode lookup. Pass nil
+// to clear (tests).
+func SetFaxModeResolver(fn func(callID string) string) {
+	faxHooksMu.Lock()
+	faxModeResolver = fn
+	faxHooksMu.Unlock()
+}
+
+// SetFaxToneHandler installs the callback run (on its own goroutine)
+// when CNG / CED is heard on a call. Pass nil to disable detection.
+func SetFaxToneHandler(fn func(callID, tone string)) {
+	faxHooksMu.Lock()
+	faxToneHandler = fn
+	faxHooksMu.Unlock()
+}
+
+// ResolveFaxMode returns the normalized fax mode for callID.
+func ResolveFaxMode(callID string) string {
+	faxHooksMu.RLock()
+	fn := faxModeResolver
+	faxHooksMu.RUnlock()
+	if fn != nil {
+		if mode := fn(callID); mode != "" {
+			return sipfax.NormalizeMode(mode)
+		}
+	}
+	return sipfax.NormalizeMode(os.Getenv("SIP_FAX_MODE"))
+}
+
+// FaxWatch sits in the ASR feed: it listens for fax tones and, once the
+// call is a fax call, keeps its audio away from ASR / VAD / barge-in.
+// Not safe for concurrent use; one per inbound audio stream.
+type FaxWatch struct {
+	cs      *sipSession.CallSession
+	det     *sipfax.ToneDetector
+	handler func(callID, tone string)
+	fired   bool
+}
+
+// NewFaxWatch returns nil when fax detection is off for this call or no
+// handler is wired. Shared by AttachVoicePipeline and pkg/sip/voicedialog.
+func NewFaxWatch(cs *sipSession.CallSession) *FaxWatch {
+	if cs == nil {
+		return nil
+	}
+	faxHooksMu.RLock()
+	h := faxToneHandler
+	faxHooksMu.RUnlock()
+	if h == nil || ResolveFaxMode(cs.CallID) == sipfax.ModeOff {
+		return nil
+	}
+	return &FaxWatch{cs: cs, det: sipfax.NewToneDetector(cs.PCMSampleRate()), handler: h}
+}
+
+// Drop feeds one chunk of caller PCM and reports whether it must not
+// reach ASR: a fax tone was just heard or the call is already in fax
+// mode.
+func (w *FaxWatch) Drop(pcm []byte) bool {
+	if w == nil {
+		return false
+	}
+	if w.fired || w.cs.FaxMode() != "" {
+		return true
+	}
+	if tone := w.det.Process(pcm); tone != "" {
+		w.fired = true
+		go w.handler(w.cs.CallID, tone)
+		return true
+	}
+	return false
+}
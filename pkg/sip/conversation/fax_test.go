@@ -0,0 +1,55 @@
+package conversation
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	sipfax "github.com/LinByte/VoiceServer/pkg/sip/fax"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+)
+
+func TestFaxWatch_FiresOnCNG(t *testing.T) {
+	got := make(chan string, 1)
+	SetFaxToneHandler(func(callID, tone string) { got <- callID + "/" + tone })
+	defer SetFaxToneHandler(nil)
+	SetFaxModeResolver(func(string) string { return "" })
+	defer SetFaxModeResolver(nil)
+
+	w := NewFaxWatch(&sipSession.CallSession{CallID: "fax-watch-1"})
+	if w == nil {
+		t.Fatal("watch disabled")
+	}
+	// Zero-value session: 16 kHz internal PCM, 20 ms frames.
+	frame := make([]byte, 640)
+	dropped := false
+	for i := 0; i < 40 && !dropped; i++ {
+		for j := 0; j < 320; j++ {
+			v := 4000 * math.Sin(2*math.Pi*1100*float64(i*320+j)/16000)
+			binary.LittleEndian.PutUint16(frame[j*2:], uint16(int16(v)))
+		}
+		dropped = w.Drop(frame)
+	}
+	select {
+	case v := <-got:
+		if v != "fax-watch-1/"+sipfax.ToneCNG {
+			t.Fatalf("handler got %q", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+	if !w.Drop(make([]byte, 320)) {
+		t.Fatal("audio after fax tone still reaches ASR")
+	}
+}
+
+func TestFaxWatch_Off(t *testing.T) {
+	SetFaxToneHandler(func(string, string) {})
+	defer SetFaxToneHandler(nil)
+	SetFaxModeResolver(func(string) string { return "off" })
+	defer SetFaxModeResolver(nil)
+	if NewFaxWatch(&sipSession.CallSession{CallID: "fax-watch-2"}) != nil {
+		t.Fatal("watch enabled with fax mode off")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+import (
+	"os"
+	"sync"
+
+	sipdtmf "github.com/LinByte/VoiceServer/pkg/sip/dtmf"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+)
+
+// inbandDTMFModeResolver, when set, returns the per-trunk in-band DTMF
+// mode (Trunk.InbandDTMF: auto / on / off) for an inbound Call-ID.
+// Wired in internal/sipserver/sipapp.go next to the welcome resolver.
+// "" falls back to SIP_INBAND_DTMF, then auto.
+var (
+	inbandDTMFModeResolverMu sync.RWMutex
+	inbandDTMFModeResolver   func(callID string) string
+)
+
+// SetInbandDTMFModeResolver installs the per-trunk mode lookup. Pass
+// nil to clear (tests). Safe for concurrent calls.
+func SetInbandDTMFModeResolver(fn func(callID string) string) {
+	inbandDTMFModeResolverMu.Lock()
+	inbandDTMFModeResolver = fn
+	inbandDTMFModeResolverMu.Unlock()
+}
+
+func resolveInbandDTMFMode(callID string) string {
+	inbandDTMFModeResolverMu.RLock()
+	fn := inbandDTMFModeResolver
+	inbandDTMFModeResolverMu.RUnlock()
+	if fn != nil {
+		if mode := fn(callID); mode != "" {
+			return mode
+		}
+	}
+	return os.Getenv("SIP_INBAND_DTMF")
+}
+
+// NewInbandDTMFDetector returns a Goertzel detector over the call's
+// decoded PCM, or nil when in-band detection is off for this call
+// (auto mode only enables it without a negotiated telephone-event PT).
+// Shared by AttachVoicePipeline and pkg/sip/voicedialog.
+func NewInbandDTMFDetector(cs *sipSession.CallSession) *sipdtmf.InbandDetector {
+	if cs == nil {
+		return nil
+	}
+	if !sipdtmf.InbandEnabled(resolveInbandDTMFMode(cs.CallID), cs.DTMFPayloadType() != 0) {
+		return nil
+	}
+	return sipdtmf.NewInbandDetector(cs.PCMSampleRate())
+}
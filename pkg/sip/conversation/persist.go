@@ -5,6 +5,9 @@ import (
 	"sync"
 
 	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/redact"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+	"go.uber.org/zap"
 )
 
 var sipTurnPersist func(ctx context.Context, callID string, turn DialogTurn)
@@ -26,6 +29,14 @@ func persistSIPTurn(ctx context.Context, callID string, turn DialogTurn) {
 	if callID == "" {
 		return
 	}
+	// PCI: nothing said while recording is paused may be stored, the
+	// transcript included.
+	if sipSession.RecordingPausedFor(callID) {
+		if logger.Lg != nil {
+			logger.Lg.Debug("sip: dialog turn not persisted (recording paused)", zap.String("call_id", callID))
+		}
+		return
+	}
 	if sipTurnPersist == nil {
 		warnTurnPersistOnce.Do(func() {
 			if logger.Lg != nil {
@@ -42,3 +53,12 @@ func persistSIPTurn(ctx context.Context, callID string, turn DialogTurn) {
 func RecordDialogTurn(ctx context.Context, callID string, turn DialogTurn) {
 	persistSIPTurn(ctx, callID, turn)
 }
+
+// pausedOrRedacted is the loggable form of caller speech: a fixed marker
+// while recording is paused (PCI), otherwise the PII-redacted text.
+func pausedOrRedacted(cs *sipSession.CallSession, pii *redact.Engine, text string) string {
+	if cs.RecordingPaused() {
+		return "[recording paused]"
+	}
+	return redact.CallText(pii, cs.CallID, text)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/recognizer"
+	"github.com/LinByte/VoiceServer/pkg/redact"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+	sipasr "github.com/LinByte/VoiceServer/pkg/voice/asr"
+	"go.uber.org/zap"
+)
+
+// piiAudioPad widens every blanked range to cover ASR timestamp slop.
+const piiAudioPad = 200 * time.Millisecond
+
+// callAudioRedactor blanks PII in one call's recording. Word timestamps
+// give the tightest ranges; ASR results without them (QCloud models
+// that ignore word_info, realtime agents) blank the whole utterance
+// instead, which is logged once per call so operators know why.
+type callAudioRedactor struct {
+	cs     *sipSession.CallSession
+	pii    *redact.Engine
+	lg     *zap.Logger
+	coarse sync.Once
+}
+
+// newCallAudioRedactor returns nil when the policy leaves recordings
+// untouched; every method is nil-safe.
+func newCallAudioRedactor(cs *sipSession.CallSession, pii *redact.Engine, lg *zap.Logger) *callAudioRedactor {
+	if cs == nil || pii.AudioMode() == redact.AudioOff {
+		return nil
+	}
+	return &callAudioRedactor{cs: cs, pii: pii, lg: ensureVoiceLogger(lg)}
+}
+
+// utterance blanks [start, end] when text holds PII.
+func (r *callAudioRedactor) utterance(start, end time.Time, text, source string) {
+	if r == nil || start.IsZero() || !end.After(start) {
+		return
+	}
+	r.noteCoarse(source)
+	r.cs.RedactRecording(redact.AudioRanges(r.pii, start, []redact.WordTiming{{
+		Text:  text,
+		EndMs: end.Sub(start).Milliseconds(),
+	}}, piiAudioPad)...)
+}
+
+func (r *callAudioRedactor) noteCoarse(source string) {
+	r.coarse.Do(func() {
+		r.lg.Warn("sip voice: ASR gave no word timestamps; PII audio redaction blanks whole utterances",
+			zap.String("call_id", r.cs.CallID),
+			zap.String("source", source),
+		)
+	})
+}
+
+// asrStream returns a redactor bound to one ASR stream. QCloud offsets
+// count from the first PCM fed to that stream, so each stream keeps its
+// own anchor.
+func (r *callAudioRedactor) asrStream() *asrRedactStream {
+	if r == nil {
+		return nil
+	}
+	return &asrRedactStream{call: r}
+}
+
+// asrRedactStream anchors one QCloud stream's word offsets.
+type asrRedactStream struct {
+	call    *callAudioRedactor
+	fedAtNS atomic.Int64
+}
+
+// wire asks QCloud for word timestamps and routes them to the recording.
+func (s *asrRedactStream) wire(opt *recognizer.QCloudASROption) {
+	if s == nil {
+		return
+	}
+	opt.WordInfo = 1
+	opt.WordsNotify = s.words
+}
+
+// fed marks the first PCM handed to the stream.
+func (s *asrRedactStream) fed() {
+	if s != nil {
+		s.fedAtNS.CompareAndSwap(0, time.Now().UnixNano())
+	}
+}
+
+func (s *asrRedactStream) words(words []recognizer.QCloudWord) {
+	fed := s.fedAtNS.Load()
+	if fed == 0 || len(words) == 0 {
+		return
+	}
+	if words[0].Sentence {
+		s.call.noteCoarse("qcloud_sentence")
+	}
+	wt := make([]redact.WordTiming, len(words))
+	for i, w := range words {
+		wt[i] = redact.WordTiming{Text: w.Word, StartMs: w.StartMs, EndMs: w.EndMs}
+	}
+	s.call.cs.RedactRecording(redact.AudioRanges(s.call.pii, time.Unix(0, fed), wt, piiAudioPad)...)
+}
+
+// redactingASR stamps the stream anchor on the first PCM it forwards.
+type redactingASR struct {
+	*sipasr.Pipeline
+	stream *asrRedactStream
+}
+
+// ProcessPCM implements cascaded.ASRRecognizer.
+func (a *redactingASR) ProcessPCM(ctx context.Context, pcm []byte) error {
+	a.stream.fed()
+	return a.Pipeline.ProcessPCM(ctx, pcm)
+}
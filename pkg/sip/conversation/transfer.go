@@ -10,6 +10,7 @@ import (
 
 	"github.com/LinByte/VoiceServer/pkg/logger"
 	"github.com/LinByte/VoiceServer/pkg/media"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/scriptlisten"
 	sipdtmf "github.com/LinByte/VoiceServer/pkg/sip/dtmf"
 	"github.com/LinByte/VoiceServer/pkg/sip/historyinfo"
@@ -77,7 +78,7 @@ func HandleSIPINFODTMF(inboundCallID string, contentType, body string, lg *zap.L
 		scriptlisten.PublishDTMF(inboundCallID, d)
 		lg.Info("sip info dtmf (script)",
 			zap.String("call_id", inboundCallID),
-			zap.String("digit", d),
+			zap.String("digit", redact.DTMF(inboundCallID, d)),
 		)
 		return
 	}
@@ -106,6 +107,7 @@ func TriggerTransferToAgent(ctx context.Context, inboundCallID string, lg *zap.L
 			zap.String("inbound_call_id", inboundCallID))
 		return
 	}
+	markTransferQueued(inboundCallID)
 
 	transferMu.Lock()
 	d := transferDialer
@@ -655,6 +657,10 @@ func CleanupCallState(callID string) {
 		recordTransferNoAnswerForCurrentTarget(inbound)
 	}
 	sipagentpoll.ClearByInbound(callID)
+	if sipAgent {
+		// The seat's release below is held off while it is in after-call work.
+		beginTransferAfterCallWork(inbound)
+	}
 	releaseTransferACDWorkState(callID)
 	transferStarted.Delete(callID)
 	stopTransferRinging(callID)
@@ -662,4 +668,6 @@ func CleanupCallState(callID string) {
 	ResetTransferRoutingState(callID)
 	ClearSIPScriptMode(callID)
 	cleanupSIPTransferConfirm(callID)
+	clearTransferQueued(callID)
+	clearTransferQueued(inbound)
 }
@@ -9,6 +9,7 @@ import (
 var (
 	acdPoolWorkStateMu sync.RWMutex
 	acdPoolWorkStateFn func(ctx context.Context, targetID uint, workState string) error
+	acdAfterCallWorkFn func(inboundCallID string, targetID uint) bool
 )
 
 // SetACDPoolTargetWorkStateUpdater wires acd_pool_targets.work_state updates from the transfer layer.
@@ -18,6 +19,28 @@ func SetACDPoolTargetWorkStateUpdater(fn func(ctx context.Context, targetID uint
 	acdPoolWorkStateMu.Unlock()
 }
 
+// SetAfterCallWorkStarter wires the ACW hook run when a bridged SIP agent's call ends;
+// fn returns true when the seat entered after-call work.
+func SetAfterCallWorkStarter(fn func(inboundCallID string, targetID uint) bool) {
+	acdPoolWorkStateMu.Lock()
+	acdAfterCallWorkFn = fn
+	acdPoolWorkStateMu.Unlock()
+}
+
+// beginTransferAfterCallWork hands the SIP agent that took inboundCallID to the ACW hook.
+func beginTransferAfterCallWork(inboundCallID string) {
+	id, ok := PeekInboundTransferACDTargetID(inboundCallID)
+	if !ok || id == 0 {
+		return
+	}
+	acdPoolWorkStateMu.RLock()
+	fn := acdAfterCallWorkFn
+	acdPoolWorkStateMu.RUnlock()
+	if fn != nil {
+		fn(inboundCallID, id)
+	}
+}
+
 func markTransferACDWorkState(targetID uint, workState string) {
 	targetID = uint(targetID)
 	workState = strings.TrimSpace(workState)
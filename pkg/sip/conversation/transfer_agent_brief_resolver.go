@@ -116,6 +116,23 @@ func buildTransferAgentBriefVars(inboundCallID string) TransferAgentBriefVars {
 	}
 }
 
+// InboundCallIDForACDTarget is the reverse of PeekInboundTransferACDTargetID: the inbound
+// Call-ID currently routed to acd_pool_targets.id (BLF dialog state).
+func InboundCallIDForACDTarget(targetID uint) (string, bool) {
+	if targetID == 0 {
+		return "", false
+	}
+	var found string
+	transferLastACDRowByInbound.Range(func(k, v any) bool {
+		if id, ok := v.(uint); ok && id == targetID {
+			found, _ = k.(string)
+			return false
+		}
+		return true
+	})
+	return found, found != ""
+}
+
 // PeekInboundTransferACDTargetID returns the last routed acd_pool_targets.id without clearing.
 func PeekInboundTransferACDTargetID(callID string) (uint, bool) {
 	callID = strings.TrimSpace(callID)
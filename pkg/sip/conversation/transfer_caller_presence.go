@@ -62,6 +62,7 @@ func abandonTransferBecauseCallerGone(inboundCallID string) {
 	sipagentpoll.ClearByInbound(inboundCallID)
 	releaseTransferACDWorkState(inboundCallID)
 	transferStarted.Delete(inboundCallID)
+	clearTransferQueued(inboundCallID)
 }
 
 func scheduleTransferRetryToNextAgent(inboundCallID string, lg *zap.Logger) {
@@ -118,6 +119,7 @@ func AbortTransferOnAgentReject(inboundCallID string) {
 	transferStarted.Delete(inboundCallID)
 	transferLastACDRowByInbound.Delete(inboundCallID)
 	transferExcludeReset(inboundCallID)
+	clearTransferQueued(inboundCallID)
 }
 
 func terminateTransferBecauseAgentRejected(inbound string, sipCode int, reason string) {
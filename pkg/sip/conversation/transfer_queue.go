@@ -0,0 +1,44 @@
+package conversation
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// transferQueuedAt remembers when each inbound caller first asked for an agent
+// (Call-ID → time.Time) so wallboards can show queue depth and longest wait.
+var transferQueuedAt sync.Map
+
+// QueuedTransfer is one inbound caller waiting for an agent.
+type QueuedTransfer struct {
+	InboundCallID string
+	Since         time.Time
+}
+
+func markTransferQueued(inboundCallID string) {
+	transferQueuedAt.LoadOrStore(inboundCallID, time.Now())
+}
+
+// QueuedTransfers lists callers in the transfer flow that are not bridged to an agent yet.
+func QueuedTransfers() []QueuedTransfer {
+	var out []QueuedTransfer
+	transferQueuedAt.Range(func(k, v any) bool {
+		callID, _ := k.(string)
+		since, _ := v.(time.Time)
+		if callID == "" || ActiveTransferBridgeForCallID(callID) || ActiveWebSeatBridge(callID) {
+			return true
+		}
+		// A SIP agent already answered (the bridge may be tearing down).
+		if sipAgent, _ := PeekInboundTransferFlags(callID); sipAgent {
+			return true
+		}
+		out = append(out, QueuedTransfer{InboundCallID: callID, Since: since})
+		return true
+	})
+	return out
+}
+
+func clearTransferQueued(callID string) {
+	transferQueuedAt.Delete(strings.TrimSpace(callID))
+}
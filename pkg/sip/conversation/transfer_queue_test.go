@@ -0,0 +1,47 @@
+package conversation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueuedTransfers(t *testing.T) {
+	const waiting, answered = "in-queued", "in-queued-answered"
+	markTransferQueued(waiting)
+	markTransferQueued(answered)
+	MarkInboundHadSIPAgentTransfer(answered)
+	t.Cleanup(func() {
+		clearTransferQueued(waiting)
+		clearTransferQueued(answered)
+		TakeInboundTransferFlags(answered)
+	})
+
+	first := queuedSince(t, waiting)
+	markTransferQueued(waiting) // retry to the next agent keeps the original wait
+	if again := queuedSince(t, waiting); !again.Equal(first) {
+		t.Fatalf("wait restarted: %v -> %v", first, again)
+	}
+	for _, q := range QueuedTransfers() {
+		if q.InboundCallID == answered {
+			t.Fatal("answered call still queued")
+		}
+	}
+
+	clearTransferQueued(waiting)
+	for _, q := range QueuedTransfers() {
+		if q.InboundCallID == waiting {
+			t.Fatal("cleared call still queued")
+		}
+	}
+}
+
+func queuedSince(t *testing.T, callID string) (since time.Time) {
+	t.Helper()
+	for _, q := range QueuedTransfers() {
+		if q.InboundCallID == callID {
+			return q.Since
+		}
+	}
+	t.Fatalf("%s not queued", callID)
+	return
+}
@@ -20,10 +20,10 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/logger"
 	"github.com/LinByte/VoiceServer/pkg/media"
 	"github.com/LinByte/VoiceServer/pkg/recognizer"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/scriptlisten"
 	sipdtmf "github.com/LinByte/VoiceServer/pkg/sip/dtmf"
 	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
-	sipvad "github.com/LinByte/VoiceServer/pkg/sip/vad"
 	"github.com/LinByte/VoiceServer/pkg/synthesizer"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	sipasr "github.com/LinByte/VoiceServer/pkg/voice/asr"
@@ -40,6 +40,24 @@ var (
 	sipTransferPendingByCallID = map[string]bool{}
 )
 
+// SetSIPCallSystemPrompt overrides the LLM system prompt for the next media attach of
+// callID (consumed once). An empty prompt clears a pending override, e.g. when the
+// dial fails before the leg is answered.
+func SetSIPCallSystemPrompt(callID, prompt string) {
+	callID = strings.TrimSpace(callID)
+	if callID == "" {
+		return
+	}
+	prompt = strings.TrimSpace(prompt)
+	sipSystemPromptMu.Lock()
+	defer sipSystemPromptMu.Unlock()
+	if prompt == "" {
+		delete(sipSystemPromptByCallID, callID)
+		return
+	}
+	sipSystemPromptByCallID[callID] = prompt
+}
+
 func popSIPCallSystemPrompt(callID string) string {
 	callID = strings.TrimSpace(callID)
 	if callID == "" {
@@ -243,6 +261,14 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 	if env.ASRModelType != "" {
 		asrOpt.ModelType = env.ASRModelType
 	}
+	// PII policy resolved once per call: masks transcript log lines and,
+	// when audio redaction is on, blanks matched words in the recording.
+	// Tencent word offsets count from the first PCM fed to ASR, which
+	// piiStream anchors (welcome playback holds ASR back, never resumes
+	// it mid-call, so audio time tracks wall time from there on).
+	pii := redact.ForTenant(ctx, cs.TenantID())
+	piiStream := newCallAudioRedactor(cs, pii, lg).asrStream()
+	piiStream.wire(&asrOpt)
 	asrSvc := recognizer.NewQcloudASR(asrOpt)
 
 	asrOutRate := 16000
@@ -309,7 +335,8 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 	// SIP_RECORDER_CHUNK_SECS 调节滚动分片上传周期（0 = 通话结束一次性写）。
 	// 存储桶由 pkg/stores 后端自己读取（COS_BUCKET_NAME / S3_BUCKET / …）。
 	recCfg := siprecorder.Config{
-		Logger: lg,
+		Logger:     lg,
+		RedactMode: pii.AudioMode(),
 	}
 	if secs, err := strconv.Atoi(strings.TrimSpace(os.Getenv("SIP_RECORDER_CHUNK_SECS"))); err == nil && secs > 0 {
 		recCfg.ChunkInterval = time.Duration(secs) * time.Second
@@ -402,18 +429,15 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 				zap.String("reason", reason))
 		}
 	}
-	var vadDet *sipvad.Detector
-	if config.GlobalConfig.SIP.SIPVADBargeIn {
-		vadDet = sipvad.NewDetector()
-		vadDet.SetLogger(lg)
-		vadDet.SetThreshold(config.GlobalConfig.SIP.SIPVADThreshold)
-		vadDet.SetConsecutiveFrames(config.GlobalConfig.SIP.SIPVADConsecFrames)
-		lg.Info("sip voice: RMS VAD barge-in enabled (TTS playback only)",
+	vadDet := NewSIPBargeInDetector(asrInRate, lg)
+	if vadDet != nil {
+		lg.Info("sip voice: VAD barge-in enabled (TTS playback only)",
+			zap.String("engine", config.GlobalConfig.SIP.SIPVADEngine),
 			zap.Float64("threshold_effective", config.GlobalConfig.SIP.SIPVADThreshold),
 			zap.Int("consecutive_frames", config.GlobalConfig.SIP.SIPVADConsecFrames),
 		)
 	} else {
-		lg.Info("sip voice: RMS VAD barge-in disabled (SIP_VAD_BARGE_IN)")
+		lg.Info("sip voice: VAD barge-in disabled (SIP_VAD_BARGE_IN)")
 	}
 
 	// Same incremental strategy as pkg/hardware: ASRStateManager extracts new sentences from
@@ -459,7 +483,7 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 
 			lg.Info("sip voice asr trigger",
 				zap.String("call_id", cs.CallID),
-				zap.String("user_text", userText),
+				zap.String("user_text", pausedOrRedacted(cs, pii, userText)),
 				zap.Bool("asr_isFinal", asrIsFinal),
 				zap.String("trigger", trigger),
 			)
@@ -636,12 +660,12 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 		if corrected != incremental {
 			lg.Info("sip voice asr corrected",
 				zap.String("call_id", cs.CallID),
-				zap.String("raw_text", incremental),
-				zap.String("corrected_text", corrected),
+				zap.String("raw_text", pausedOrRedacted(cs, pii, incremental)),
+				zap.String("corrected_text", pausedOrRedacted(cs, pii, corrected)),
 			)
 		}
 		if isFillerOnlyUtterance(incremental) {
-			lg.Debug("sip voice asr skip filler-only", zap.String("text", incremental))
+			lg.Debug("sip voice asr skip filler-only", zap.String("text", pausedOrRedacted(cs, pii, incremental)))
 			return
 		}
 		triggerTurn(corrected, true, "final")
@@ -651,6 +675,22 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 		lg.Warn("sip voice asr", zap.Error(err), zap.Bool("fatal", fatal))
 	})
 
+	onDTMF := func(_ context.Context, digit string) {
+		lg.Info("sip dtmf", zap.String("digit", redact.DTMF(cs.CallID, digit)), zap.String("call_id", cs.CallID))
+		if isSIPScriptMode(cs.CallID) {
+			scriptlisten.PublishDTMF(cs.CallID, digit)
+		}
+	}
+	// In-band tones (gateways without telephone-event / INFO) are decoded
+	// here and replaced by silence so ASR and barge-in never hear them.
+	inbandDTMF := NewInbandDTMFDetector(cs)
+	if inbandDTMF != nil {
+		lg.Info("sip voice: in-band dtmf detection enabled", zap.String("call_id", cs.CallID))
+	}
+	// CNG / CED hands the call to the fax path (pkg/sip/server StartFax);
+	// from then on caller audio never reaches ASR or VAD.
+	faxWatch := NewFaxWatch(cs)
+
 	proc := media.NewPacketProcessor("sip-voice-asr-feed", media.PriorityHigh,
 		func(c context.Context, _ *media.MediaSession, packet media.MediaPacket) error {
 			ap, ok := packet.(*media.AudioPacket)
@@ -667,8 +707,12 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 			// by EnableRecorder. Welcome / barge-in branches below may
 			// short-circuit ASR processing but recording continues.
 			cs.WriteCallerPCM(pcm16)
+			if faxWatch.Drop(pcm16) {
+				return nil
+			}
+			pcm16 = inbandDTMF.Filter(c, pcm16, onDTMF)
 			if welcomePlaying.Load() {
-				// Same RMS VAD path as TTS barge-in (requires SIP_VAD_BARGE_IN enabled).
+				// Same VAD path as TTS barge-in (requires SIP_VAD_BARGE_IN enabled).
 				if vadDet != nil && vadDet.CheckBargeIn(pcm16, true) {
 					cancelWelcomeIfPlaying("vad_user_speech")
 				} else {
@@ -684,7 +728,7 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 				}
 				pcmASR = out
 			}
-			// RMS VAD on 16 kHz PCM (same as media decode path); only while TTS is playing.
+			// VAD on bridge-rate PCM (same as media decode path); only while TTS is playing.
 			// Do not return early here: skipping ProcessPCM would drop user audio to ASR for the whole window.
 			allowBargeIn := true
 			if vadDet != nil && ttsPlaying.Load() {
@@ -694,7 +738,7 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 				}
 			}
 			if allowBargeIn && vadDet != nil && ttsPlaying.Load() && vadDet.CheckBargeIn(pcm16, true) {
-				lg.Info("sip voice: RMS barge-in, stopping TTS", zap.String("call_id", cs.CallID))
+				lg.Info("sip voice: VAD barge-in, stopping TTS", zap.String("call_id", cs.CallID))
 				ttsPipe.Stop()
 				// Stop() cancels the pipeline ctx used by Speak(); re-Start so a later Speak in the same turn
 				// is not stuck on a dead context.
@@ -702,6 +746,7 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 				ttsPlaying.Store(false)
 				ttsStartedAtNS.Store(0)
 			}
+			piiStream.fed()
 			err := pipe.ProcessPCM(c, pcmASR)
 			if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
 				return nil
@@ -711,12 +756,7 @@ func attachVoiceInner(ctx context.Context, cs *sipSession.CallSession, env Voice
 
 	ms.RegisterProcessor(proc)
 
-	sipdtmf.AttachProcessor(ms, "sip-dtmf", func(_ context.Context, digit string) {
-		lg.Info("sip dtmf", zap.String("digit", digit), zap.String("call_id", cs.CallID))
-		if isSIPScriptMode(cs.CallID) {
-			scriptlisten.PublishDTMF(cs.CallID, digit)
-		}
-	})
+	sipdtmf.AttachProcessor(ms, "sip-dtmf", onDTMF)
 
 	// Start RTP read/write before welcome so the first inbound packet can update symmetric RTP
 	// (see pkg/sip/rtp/session.go) before we send audio to the SDP-private address.
@@ -0,0 +1,254 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+// Per-number dialog languages for the native cascaded path.
+//
+// TrunkNumber.LanguagesJSON lists the languages a hotline accepts; the
+// first one is the call's default. With two or more, every candidate
+// gets its own ASR session for the first utterance
+// (cascaded.LanguageRaceASR); the winning language then switches the
+// LLM system prompt and the TTS voice before the first reply, and is
+// written to sip_calls.detected_language. One entry simply pins the
+// call to that language.
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/dialog/cascaded"
+	"github.com/LinByte/VoiceServer/pkg/dialog/langid"
+	"go.uber.org/zap"
+)
+
+// callLanguagesResolver returns the raw languages JSON of the inbound
+// number behind a Call-ID. Wired in internal/sipserver/sipapp.go next
+// to the welcome resolver; "" = tenant default language only.
+var (
+	callLanguagesResolverMu sync.RWMutex
+	callLanguagesResolver   func(callID string) string
+
+	sipCallLanguagePersist func(ctx context.Context, callID, lang string)
+)
+
+// SetCallLanguagesResolver installs the per-number languages lookup.
+// Pass nil to clear (tests). Safe for concurrent calls.
+func SetCallLanguagesResolver(fn func(callID string) string) {
+	callLanguagesResolverMu.Lock()
+	callLanguagesResolver = fn
+	callLanguagesResolverMu.Unlock()
+}
+
+// SetSIPCallLanguagePersist registers the sink for a call's detected
+// language (cmd wires persist.CallStore.OnLanguageDetected).
+func SetSIPCallLanguagePersist(fn func(ctx context.Context, callID, lang string)) {
+	sipCallLanguagePersist = fn
+}
+
+// resolveCallLanguages parses the number's languages; a row that fails
+// validation (written before the API checked it) is ignored.
+func resolveCallLanguages(callID string, lg *zap.Logger) []langid.Option {
+	callLanguagesResolverMu.RLock()
+	fn := callLanguagesResolver
+	callLanguagesResolverMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	opts, err := langid.ParseOptions(fn(callID))
+	if err != nil {
+		if lg != nil {
+			lg.Warn("sip voice: invalid number languages; using tenant default", zap.String("call_id", callID), zap.Error(err))
+		}
+		return nil
+	}
+	return opts
+}
+
+// languageRaceWait is SIP_LANGUAGE_RACE_WAIT_MS (default 800).
+func languageRaceWait() time.Duration {
+	if ms, err := strconv.Atoi(strings.TrimSpace(os.Getenv("SIP_LANGUAGE_RACE_WAIT_MS"))); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return cascaded.DefaultLanguageRaceWait
+}
+
+// qcloudASRModelForLanguage derives a QCloud engine model for lang,
+// keeping the sample-rate prefix of the tenant model. Mandarin keeps
+// the tenant model itself; QCloud ships Cantonese at 16 kHz only.
+func qcloudASRModelForLanguage(tenantModel, lang string) string {
+	rate := "16k"
+	if strings.HasPrefix(strings.ToLower(tenantModel), "8k") {
+		rate = "8k"
+	}
+	switch b := langid.Base(lang); b {
+	case "zh", "":
+		return tenantModel
+	case "yue":
+		return "16k_yue"
+	default:
+		return rate + "_" + b
+	}
+}
+
+// envForLanguage applies one language option to the tenant env: ASR
+// model and TTS config overrides.
+func envForLanguage(env VoiceEnv, opt langid.Option) VoiceEnv {
+	if opt.ASRModel != "" {
+		env.ASRModelType = opt.ASRModel
+	} else {
+		env.ASRModelType = qcloudASRModelForLanguage(env.ASRModelType, opt.Lang)
+	}
+	if len(opt.TTS) > 0 && env.TTSConfigRaw != nil {
+		raw := make(map[string]any, len(env.TTSConfigRaw)+len(opt.TTS))
+		for k, v := range env.TTSConfigRaw {
+			raw[k] = v
+		}
+		for k, v := range opt.TTS {
+			raw[k] = v
+		}
+		env.TTSConfigRaw = raw
+	}
+	return env
+}
+
+// languagePrompt is the system-prompt suffix for opt.
+func languagePrompt(opt langid.Option) string {
+	if opt.Prompt != "" {
+		return opt.Prompt
+	}
+	return langid.ReplyInstruction(opt.Lang)
+}
+
+// languageTTS is a cascaded.TTSService whose backend can be replaced
+// mid-call; the ttsStage keeps calling the same instance.
+type languageTTS struct {
+	mu  sync.Mutex
+	svc cascaded.TTSService
+}
+
+func (t *languageTTS) current() cascaded.TTSService {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.svc
+}
+
+// swap installs svc and closes the replaced backend so each language
+// switch does not leak a provider connection.
+func (t *languageTTS) swap(svc cascaded.TTSService) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	old := t.svc
+	t.svc = svc
+	if cl, ok := old.(interface{ Close() error }); ok && old != svc {
+		_ = cl.Close()
+	}
+}
+
+// Speak implements cascaded.TTSService.
+func (t *languageTTS) Speak(ctx context.Context, text string, onPCM func(pcm []byte) error) error {
+	return t.current().Speak(ctx, text, onPCM)
+}
+
+// Finalize implements cascaded.TTSService.
+func (t *languageTTS) Finalize(ctx context.Context, onPCM func(pcm []byte) error) error {
+	return t.current().Finalize(ctx, onPCM)
+}
+
+// nativeCallLanguage owns the language state of one native cascaded call.
+type nativeCallLanguage struct {
+	callID      string
+	env         VoiceEnv // tenant env before language overrides
+	opts        []langid.Option
+	bridgeRate  int
+	recorderTap func(pcm []byte)
+	pii         *callAudioRedactor
+	lg          *zap.Logger
+
+	// Set by the attach path once built; the race cannot decide before
+	// audio flows, i.e. after attach.
+	llm *nativeCascadedLLM
+	tts *languageTTS
+}
+
+// defaultEnv is the tenant env with the default language applied.
+func (l *nativeCallLanguage) defaultEnv() VoiceEnv {
+	if len(l.opts) == 0 {
+		return l.env
+	}
+	return envForLanguage(l.env, l.opts[0])
+}
+
+// buildASR returns a single recogniser for zero / one language and a
+// first-utterance race for several.
+func (l *nativeCallLanguage) buildASR() (cascaded.ASRRecognizer, error) {
+	if len(l.opts) < 2 {
+		return buildNativeCascadedASR(l.defaultEnv(), l.pii, l.lg)
+	}
+	cands := make([]cascaded.LanguageCandidate, 0, len(l.opts))
+	for _, opt := range l.opts {
+		asr, err := buildNativeCascadedASR(envForLanguage(l.env, opt), l.pii, l.lg)
+		if err != nil {
+			for _, c := range cands {
+				if c.Close != nil {
+					c.Close()
+				}
+			}
+			return nil, err
+		}
+		c := cascaded.LanguageCandidate{Lang: opt.Lang, ASR: asr}
+		if cl, ok := asr.(interface{ Close() error }); ok {
+			c.Close = func() { _ = cl.Close() }
+		}
+		cands = append(cands, c)
+	}
+	return cascaded.NewLanguageRaceASR(cands, languageRaceWait(), l.switchTo), nil
+}
+
+// applyDefault points the LLM at the default language.
+func (l *nativeCallLanguage) applyDefault() {
+	if len(l.opts) > 0 && l.llm != nil {
+		l.llm.setLanguagePrompt(languagePrompt(l.opts[0]))
+	}
+}
+
+// switchTo is the race's decision hook: it re-targets LLM and TTS when
+// the caller speaks a non-default language and records the result.
+func (l *nativeCallLanguage) switchTo(lang string) {
+	if fn := sipCallLanguagePersist; fn != nil {
+		go fn(context.Background(), l.callID, lang)
+	}
+	if len(l.opts) == 0 || lang == l.opts[0].Lang {
+		if l.lg != nil {
+			l.lg.Info("sip voice: caller language detected", zap.String("call_id", l.callID), zap.String("lang", lang))
+		}
+		return
+	}
+	var opt langid.Option
+	for _, o := range l.opts {
+		if o.Lang == lang {
+			opt = o
+		}
+	}
+	if l.llm != nil {
+		l.llm.setLanguagePrompt(languagePrompt(opt))
+	}
+	if l.tts != nil {
+		svc, err := buildNativeCascadedTTS(envForLanguage(l.env, opt), l.bridgeRate, l.recorderTap, l.lg)
+		if err != nil {
+			if l.lg != nil {
+				l.lg.Warn("sip voice: TTS for detected language unavailable; keeping default voice",
+					zap.String("call_id", l.callID), zap.String("lang", lang), zap.Error(err))
+			}
+		} else {
+			l.tts.swap(svc)
+		}
+	}
+	if l.lg != nil {
+		l.lg.Info("sip voice: switched dialog language", zap.String("call_id", l.callID), zap.String("lang", lang))
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/dialog/langid"
+)
+
+func TestQCloudASRModelForLanguage(t *testing.T) {
+	cases := []struct{ tenant, lang, want string }{
+		{"16k_zh_large", "zh-CN", "16k_zh_large"},
+		{"", "zh-CN", ""},
+		{"8k_zh", "en-US", "8k_en"},
+		{"", "en-US", "16k_en"},
+		{"8k_zh", "yue-HK", "16k_yue"},
+		{"16k_zh", "ja-JP", "16k_ja"},
+	}
+	for _, c := range cases {
+		if got := qcloudASRModelForLanguage(c.tenant, c.lang); got != c.want {
+			t.Errorf("(%q,%q)=%q want %q", c.tenant, c.lang, got, c.want)
+		}
+	}
+}
+
+func TestEnvForLanguage(t *testing.T) {
+	env := VoiceEnv{ASRModelType: "16k_zh", TTSConfigRaw: map[string]any{"provider": "qcloud", "voiceType": 101001}}
+	got := envForLanguage(env, langid.Option{Lang: "yue-HK", TTS: map[string]any{"voiceType": 101019}})
+	if got.ASRModelType != "16k_yue" || got.TTSConfigRaw["voiceType"] != 101019 || got.TTSConfigRaw["provider"] != "qcloud" {
+		t.Fatalf("env=%+v", got)
+	}
+	if env.TTSConfigRaw["voiceType"] != 101001 {
+		t.Fatal("tenant TTS config mutated")
+	}
+	got = envForLanguage(env, langid.Option{Lang: "en-US", ASRModel: "16k_en_large"})
+	if got.ASRModelType != "16k_en_large" || got.TTSConfigRaw["voiceType"] != 101001 {
+		t.Fatalf("env=%+v", got)
+	}
+}
+
+func TestLanguagePrompt(t *testing.T) {
+	if got := languagePrompt(langid.Option{Lang: "en-US", Prompt: "Answer briefly in English."}); got != "Answer briefly in English." {
+		t.Fatal(got)
+	}
+	if got := languagePrompt(langid.Option{Lang: "yue-HK"}); !strings.Contains(got, "廣東話") {
+		t.Fatal(got)
+	}
+}
+
+func TestResolveCallLanguages(t *testing.T) {
+	t.Cleanup(func() { SetCallLanguagesResolver(nil) })
+	if got := resolveCallLanguages("c1", nil); got != nil {
+		t.Fatalf("no resolver: %v", got)
+	}
+	SetCallLanguagesResolver(func(callID string) string {
+		if callID == "bad" {
+			return `[{"lang":"zh"},{"lang":"zh-TW"}]`
+		}
+		return `[{"lang":"zh-CN"},{"lang":"en-US"}]`
+	})
+	if got := resolveCallLanguages("c1", nil); len(got) != 2 || got[1].Lang != "en-US" {
+		t.Fatalf("got=%v", got)
+	}
+	if got := resolveCallLanguages("bad", nil); got != nil {
+		t.Fatalf("invalid row should be ignored: %v", got)
+	}
+}
+
+// countingTTS is a cascaded.TTSService that counts Speak and Close calls.
+type countingTTS struct {
+	spoke, closed int
+}
+
+func (f *countingTTS) Speak(context.Context, string, func(pcm []byte) error) error {
+	f.spoke++
+	return nil
+}
+
+func (f *countingTTS) Finalize(context.Context, func(pcm []byte) error) error { return nil }
+
+func (f *countingTTS) Close() error {
+	f.closed++
+	return nil
+}
+
+func TestLanguageTTSSwapClosesOld(t *testing.T) {
+	zh, en, yue := &countingTTS{}, &countingTTS{}, &countingTTS{}
+	tts := &languageTTS{svc: zh}
+
+	tts.swap(en)
+	if zh.closed != 1 || en.closed != 0 {
+		t.Fatalf("after first swap: zh.closed=%d en.closed=%d", zh.closed, en.closed)
+	}
+	if err := tts.Speak(context.Background(), "hello", nil); err != nil || en.spoke != 1 || zh.spoke != 0 {
+		t.Fatalf("speak routed to old service: err=%v zh=%d en=%d", err, zh.spoke, en.spoke)
+	}
+
+	tts.swap(yue)
+	tts.swap(yue) // re-selecting the live service must not close it
+	if zh.closed != 1 || en.closed != 1 || yue.closed != 0 {
+		t.Fatalf("closes: zh=%d en=%d yue=%d", zh.closed, en.closed, yue.closed)
+	}
+}
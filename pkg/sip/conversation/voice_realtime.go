@@ -42,6 +42,7 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/realtime"
 	_ "github.com/LinByte/VoiceServer/pkg/realtime/aliyunomni"     // self-registers as aliyun_omni
 	_ "github.com/LinByte/VoiceServer/pkg/realtime/volcdialogue" // self-registers as volcengine_dialogue
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	siprecorder "github.com/LinByte/VoiceServer/pkg/voice/recorder"
 	"go.uber.org/zap"
@@ -65,7 +66,11 @@ func attachRealtimeVoiceInner(ctx context.Context, cs *sipSession.CallSession, e
 
 	// Stereo recorder — same baseline as the pipeline path. Recording is
 	// not optional; tenants depend on call audit.
-	recCfg := siprecorder.Config{Logger: lg}
+	pii := redact.ForTenant(ctx, cs.TenantID())
+	recCfg := siprecorder.Config{Logger: lg, RedactMode: pii.AudioMode()}
+	// Realtime agents report transcripts without word timings, so PII
+	// audio redaction blanks the whole utterance bounded by server VAD.
+	piiAudio := newCallAudioRedactor(cs, pii, lg)
 	if secs, err := strconv.Atoi(strings.TrimSpace(os.Getenv("SIP_RECORDER_CHUNK_SECS"))); err == nil && secs > 0 {
 		recCfg.ChunkInterval = time.Duration(secs) * time.Second
 	}
@@ -108,6 +113,10 @@ func attachRealtimeVoiceInner(ctx context.Context, cs *sipSession.CallSession, e
 		// model's VAD doesn't trip on the WAV's echo and DashScope
 		// audio budget is not consumed during the connect cue.
 		welcomePlaying atomic.Bool
+		// Server-VAD bounds (unix nanos) of the caller's last utterance,
+		// for whole-utterance PII audio redaction.
+		userSpeechStartNs atomic.Int64
+		userSpeechEndNs   atomic.Int64
 		// Barge-in mute deadline (unix nanos). Set far into the future
 		// when EventUserSpeechStarted fires; pulled back to "now + grace"
 		// when EventUserSpeechEnded fires. While `time.Now() < deadline`
@@ -303,6 +312,8 @@ func attachRealtimeVoiceInner(ctx context.Context, cs *sipSession.CallSession, e
 			}
 			aiMutedUntilNs.Store(time.Now().Add(bargeInLongMute).UnixNano())
 			ttsPlaying.Store(false)
+			userSpeechStartNs.Store(time.Now().UnixNano())
+			userSpeechEndNs.Store(0)
 			lg.Info("sip voice (realtime): user speech started (server VAD) — barge-in",
 				zap.String("call_id", cs.CallID),
 				zap.Int("dropped_queued_packets", droppedPkts),
@@ -316,6 +327,7 @@ func attachRealtimeVoiceInner(ctx context.Context, cs *sipSession.CallSession, e
 			// the cancelled response inside this window gets dropped.
 			// After grace, the next response's audio flows freely.
 			aiMutedUntilNs.Store(time.Now().Add(bargeInGrace).UnixNano())
+			userSpeechEndNs.Store(time.Now().UnixNano())
 			lg.Info("sip voice (realtime): user speech ended (server VAD)",
 				zap.String("call_id", cs.CallID),
 				zap.Duration("ai_mute_grace", bargeInGrace),
@@ -332,9 +344,16 @@ func attachRealtimeVoiceInner(ctx context.Context, cs *sipSession.CallSession, e
 			assistantBuf.Reset()
 			assistantMu.Unlock()
 			userText.Store(ev.Text)
+			if start := userSpeechStartNs.Load(); start > 0 {
+				end := time.Now()
+				if e := userSpeechEndNs.Load(); e > start {
+					end = time.Unix(0, e)
+				}
+				piiAudio.utterance(time.Unix(0, start), end, ev.Text, "realtime")
+			}
 			lg.Info("sip voice (realtime): user transcript",
 				zap.String("call_id", cs.CallID),
-				zap.String("text", ev.Text),
+				zap.String("text", pausedOrRedacted(cs, pii, ev.Text)),
 			)
 			if c := recordSIPTransferIntent(cs.CallID, ev.Text); c > 0 && realtimeMatchTransferIntent("user", ev.Text, nil) {
 				lg.Info("sip voice (realtime): transfer intent recorded",
@@ -377,7 +396,7 @@ func attachRealtimeVoiceInner(ctx context.Context, cs *sipSession.CallSession, e
 				clean := realtimeStripMarker(full)
 				lg.Info("sip voice (realtime): assistant final",
 					zap.String("call_id", cs.CallID),
-					zap.String("text", clean),
+					zap.String("text", pii.Text(clean)),
 				)
 				// Transfer marker (legacy path when FC tools are off).
 				if !useTransferTool && realtimeMatchTransferIntent("assistant", full, nil) {
@@ -0,0 +1,67 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+// Turn taking for SIP voice calls: barge-in detectors for every path
+// (SIP_VAD_ENGINE picks rms / spectral / neural) and, on the native
+// cascaded path, semantic end-of-turn detection (SIP_END_OF_TURN).
+
+import (
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/config"
+	"github.com/LinByte/VoiceServer/pkg/dialog/cascaded"
+	voicevad "github.com/LinByte/VoiceServer/pkg/voice/vad"
+	"go.uber.org/zap"
+)
+
+// sipVADConfig maps the SIP_VAD_* settings onto a detector config for
+// PCM at sampleRate.
+func sipVADConfig(sampleRate int, lg *zap.Logger) voicevad.Config {
+	c := config.GlobalConfig.SIP
+	return voicevad.Config{
+		Engine:            c.SIPVADEngine,
+		SampleRate:        sampleRate,
+		Threshold:         c.SIPVADThreshold,
+		ConsecutiveFrames: c.SIPVADConsecFrames,
+		ModelPath:         c.SIPVADModelPath,
+		Logger:            lg,
+	}
+}
+
+// NewSIPBargeInDetector builds the configured barge-in detector for
+// uplink PCM at sampleRate, or nil when SIP_VAD_BARGE_IN is off.
+// Shared by the legacy attach paths here and in voicedialog.
+func NewSIPBargeInDetector(sampleRate int, lg *zap.Logger) voicevad.BargeInDetector {
+	if config.GlobalConfig == nil || !config.GlobalConfig.SIP.SIPVADBargeIn {
+		return nil
+	}
+	return voicevad.New(sipVADConfig(sampleRate, lg))
+}
+
+// nativeTurnTakingOptions returns the barge-in and end-of-turn options
+// for a native cascaded call whose uplink runs at bridgeRate.
+func nativeTurnTakingOptions(bridgeRate int, bargeIn func(), lg *zap.Logger) []cascaded.Option {
+	var opts []cascaded.Option
+	if det := NewSIPBargeInDetector(bridgeRate, lg); det != nil {
+		opts = append(opts, cascaded.WithVADDetector(det), cascaded.WithBargeInHandler(bargeIn))
+	}
+	if config.GlobalConfig == nil {
+		return opts
+	}
+	c := config.GlobalConfig.SIP
+	if c.SIPEndOfTurn {
+		lg.Info("sip voice: end-of-turn detection enabled",
+			zap.String("vad_engine", c.SIPVADEngine),
+			zap.Int("fast_silence_ms", c.SIPEOTFastSilence),
+			zap.Int("hold_ms", c.SIPEOTHoldMax))
+		opts = append(opts, cascaded.WithEndOfTurn(cascaded.EndOfTurnConfig{
+			// Own instance: the barge-in detector keeps separate state.
+			Activity:    voicevad.NewSpeech(sipVADConfig(bridgeRate, lg)),
+			FastSilence: time.Duration(c.SIPEOTFastSilence) * time.Millisecond,
+			HoldMax:     time.Duration(c.SIPEOTHoldMax) * time.Millisecond,
+		}))
+	}
+	return opts
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package conversation
+
+import (
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/config"
+	voicevad "github.com/LinByte/VoiceServer/pkg/voice/vad"
+	"go.uber.org/zap"
+)
+
+func TestNativeTurnTakingOptions(t *testing.T) {
+	prev := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = prev })
+
+	config.GlobalConfig = nil
+	if opts := nativeTurnTakingOptions(8000, func() {}, zap.NewNop()); len(opts) != 0 {
+		t.Fatalf("unloaded config: %d options", len(opts))
+	}
+
+	config.GlobalConfig = &config.Config{SIP: config.SIPConfig{
+		SIPVADBargeIn: true, SIPVADEngine: "spectral", SIPVADConsecFrames: 4,
+		SIPEndOfTurn: true, SIPEOTFastSilence: 300, SIPEOTHoldMax: 700,
+	}}
+	if _, ok := NewSIPBargeInDetector(8000, zap.NewNop()).(*voicevad.ProbDetector); !ok {
+		t.Fatal("spectral engine not honoured")
+	}
+	if opts := nativeTurnTakingOptions(8000, func() {}, zap.NewNop()); len(opts) != 3 {
+		t.Fatalf("want barge-in + handler + end-of-turn, got %d options", len(opts))
+	}
+
+	config.GlobalConfig.SIP.SIPVADBargeIn = false
+	config.GlobalConfig.SIP.SIPEndOfTurn = false
+	if NewSIPBargeInDetector(8000, zap.NewNop()) != nil {
+		t.Fatal("barge-in off must yield no detector")
+	}
+	if opts := nativeTurnTakingOptions(8000, func() {}, zap.NewNop()); len(opts) != 0 {
+		t.Fatalf("all off: %d options", len(opts))
+	}
+}
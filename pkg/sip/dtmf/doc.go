@@ -3,7 +3,12 @@
 // Requirements:
 //   - The remote SDP must offer a=rtpmap:PT telephone-event/8000 (or /48000); pkg/sip/session
 //     passes that payload type to the RTP input transport.
-//   - Key-up events are detected via the E (end) bit; in-band DTMF (audio tones) is not implemented.
+//   - Key-up events are detected via the E (end) bit.
+//
+// In-band DTMF (tones in the audio): InbandDetector runs Goertzel over decoded PCM in the
+// conversation / voicedialog ASR feed, reports digits through the same Handler and silences
+// tone frames before ASR. Per-trunk mode Trunk.InbandDTMF (or SIP_INBAND_DTMF): auto enables
+// it only when no telephone-event PT was negotiated; on / off force it.
 //
 // SIP INFO: many clients (e.g. Linphone) send DTMF via INFO + application/dtmf-relay; use
 // dtmf.DigitFromSIPINFO — handled in pkg/sip/server handleInfo → conversation.HandleSIPINFODTMF.
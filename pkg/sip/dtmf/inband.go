@@ -0,0 +1,240 @@
+package dtmf
+
+// In-band DTMF (ITU-T Q.23 / Q.24) for gateways that neither send
+// telephone-event nor SIP INFO and leave the tones in the audio.
+//
+// Decoded s16le mono PCM is cut into Goertzel blocks of 205 samples at
+// 8 kHz (25.6 ms; scaled for other rates) and each block is checked
+// for exactly one row and one column tone:
+//   - level: both tones above inbandMinAmplitude;
+//   - peak: each tone dominates the rest of its group by inbandRelPeak;
+//   - twist: column/row power within +8 dB / -4 dB (Q.24 normal and
+//     reverse twist);
+//   - purity: the two tones carry most of the block energy, which is
+//     what keeps vowels from talking off digits.
+// A digit is reported once it holds for inbandMinBlocks consecutive
+// blocks, and only once per key press (a non-tone block must separate
+// two reports of the same key). Filter delays the audio it passes on by
+// two blocks so a tone block, and the partial block before it, can
+// still be blanked once the tone is classified.
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strings"
+)
+
+// Per-trunk in-band detection modes.
+const (
+	// InbandModeAuto enables detection only when no telephone-event
+	// payload type was negotiated.
+	InbandModeAuto = "auto"
+	InbandModeOn   = "on"
+	InbandModeOff  = "off"
+)
+
+const (
+	inbandBlock8k      = 205
+	inbandMinBlocks    = 2 // ~51 ms, above the Q.24 40 ms minimum
+	inbandMinAmplitude = 300.0
+	inbandRelPeak      = 6.3 // 8 dB
+	inbandNormalTwist  = 6.3 // column up to 8 dB above row
+	inbandReverseTwist = 2.5 // row up to 4 dB above column
+	inbandPurity       = 0.5
+)
+
+var (
+	inbandRowHz = [4]float64{697, 770, 852, 941}
+	inbandColHz = [4]float64{1209, 1336, 1477, 1633}
+	inbandKeys  = [4][4]string{
+		{"1", "2", "3", "A"},
+		{"4", "5", "6", "B"},
+		{"7", "8", "9", "C"},
+		{"*", "0", "#", "D"},
+	}
+)
+
+// NormalizeInbandMode maps free-form config to an Inband* constant
+// (unknown / empty → auto).
+func NormalizeInbandMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "on", "1", "true", "yes", "always":
+		return InbandModeOn
+	case "off", "0", "false", "no", "never":
+		return InbandModeOff
+	default:
+		return InbandModeAuto
+	}
+}
+
+// InbandEnabled resolves a mode against the negotiated SDP.
+func InbandEnabled(mode string, telephoneEventNegotiated bool) bool {
+	switch NormalizeInbandMode(mode) {
+	case InbandModeOn:
+		return true
+	case InbandModeOff:
+		return false
+	default:
+		return !telephoneEventNegotiated
+	}
+}
+
+// InbandDetector is a streaming Goertzel DTMF detector. Not safe for
+// concurrent use; one per inbound audio stream.
+type InbandDetector struct {
+	n        int
+	rowCoef  [4]float64
+	colCoef  [4]float64
+	minPower float64
+
+	pending []float64
+	held    []byte // Filter's two-block delay line
+
+	candidate string // digit seen in the last block ("" = none)
+	hits      int    // consecutive blocks of candidate
+	reported  bool   // candidate already emitted
+}
+
+// NewInbandDetector builds a detector for s16le mono PCM at sampleRate.
+func NewInbandDetector(sampleRate int) *InbandDetector {
+	if sampleRate <= 0 {
+		sampleRate = 8000
+	}
+	d := &InbandDetector{n: inbandBlock8k * sampleRate / 8000}
+	for i := range inbandRowHz {
+		d.rowCoef[i] = 2 * math.Cos(2*math.Pi*inbandRowHz[i]/float64(sampleRate))
+		d.colCoef[i] = 2 * math.Cos(2*math.Pi*inbandColHz[i]/float64(sampleRate))
+	}
+	half := inbandMinAmplitude * float64(d.n) / 2
+	d.minPower = half * half
+	d.pending = make([]float64, 0, d.n)
+	return d
+}
+
+// Process consumes one chunk of PCM. It returns the digits completed
+// in this chunk and whether the chunk carried DTMF tone (so callers
+// can keep it away from ASR).
+func (d *InbandDetector) Process(pcm []byte) (digits []string, tone bool) {
+	if d == nil {
+		return nil, false
+	}
+	tone = d.candidate != ""
+	digits = d.scan(pcm, func(_ int, key, _ string) {
+		if key != "" {
+			tone = true
+		}
+	})
+	return digits, tone
+}
+
+// scan classifies every block completed by pcm and returns the digits
+// reported. onBlock sees each block's end offset in pcm, its key and
+// the previous block's key.
+func (d *InbandDetector) scan(pcm []byte, onBlock func(end int, key, prev string)) (digits []string) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		d.pending = append(d.pending, float64(int16(binary.LittleEndian.Uint16(pcm[i:]))))
+		if len(d.pending) < d.n {
+			continue
+		}
+		key := d.classify(d.pending)
+		d.pending = d.pending[:0]
+		onBlock(i+2, key, d.candidate)
+		if key != d.candidate {
+			d.candidate, d.hits, d.reported = key, 0, false
+		}
+		if key == "" {
+			continue
+		}
+		d.hits++
+		if d.hits >= inbandMinBlocks && !d.reported {
+			d.reported = true
+			digits = append(digits, key)
+		}
+	}
+	return digits
+}
+
+// Filter hands each detected digit to h and returns pcm delayed by two
+// analysis blocks (51.2 ms at 8 kHz), with tone blocks replaced by
+// silence, together with the block before each tone (its onset rarely
+// lines up with a block) and the block after (where the tail drains).
+// Without the delay the first block of every tone would reach ASR
+// before it is classified. The output is always len(pcm) bytes.
+func (d *InbandDetector) Filter(ctx context.Context, pcm []byte, h Handler) []byte {
+	if d == nil {
+		return pcm
+	}
+	block := d.n * 2
+	if d.held == nil {
+		d.held = make([]byte, 2*block)
+	}
+	// buf is the delay line followed by pcm: a block ending at offset
+	// end of pcm occupies buf[end+block : end+2*block] and the block
+	// before it buf[end : end+block], both still unsent.
+	buf := append(d.held, pcm...)
+	digits := d.scan(pcm, func(end int, key, prev string) {
+		switch {
+		case key != "" && prev == "":
+			clear(buf[end : end+2*block])
+		case key != "" || prev != "":
+			clear(buf[end+block : end+2*block])
+		}
+	})
+	if h != nil {
+		for _, k := range digits {
+			h(ctx, k)
+		}
+	}
+	out := make([]byte, len(pcm))
+	copy(out, buf)
+	d.held = append(d.held[:0], buf[len(pcm):]...)
+	return out
+}
+
+func goertzel(block []float64, coef float64) float64 {
+	var q1, q2 float64
+	for _, x := range block {
+		q0 := coef*q1 - q2 + x
+		q2, q1 = q1, q0
+	}
+	return q1*q1 + q2*q2 - coef*q1*q2
+}
+
+// classify returns the key one block carries, or "".
+func (d *InbandDetector) classify(block []float64) string {
+	var row, col [4]float64
+	ri, ci := 0, 0
+	for i := 0; i < 4; i++ {
+		row[i] = goertzel(block, d.rowCoef[i])
+		col[i] = goertzel(block, d.colCoef[i])
+		if row[i] > row[ri] {
+			ri = i
+		}
+		if col[i] > col[ci] {
+			ci = i
+		}
+	}
+	r, c := row[ri], col[ci]
+	if r < d.minPower || c < d.minPower {
+		return ""
+	}
+	if c > r*inbandNormalTwist || r > c*inbandReverseTwist {
+		return ""
+	}
+	for i := 0; i < 4; i++ {
+		if (i != ri && row[i]*inbandRelPeak > r) || (i != ci && col[i]*inbandRelPeak > c) {
+			return ""
+		}
+	}
+	var energy float64
+	for _, x := range block {
+		energy += x * x
+	}
+	// A pure tone of amplitude A gives Goertzel power (A·N/2)² over
+	// energy A²·N/2, so (r+c)/(energy·N/2) is ~1 for clean DTMF.
+	if r+c < inbandPurity*energy*float64(len(block))/2 {
+		return ""
+	}
+	return inbandKeys[ri][ci]
+}
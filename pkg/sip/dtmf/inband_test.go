@@ -0,0 +1,175 @@
+package dtmf
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func tonePCM(rate int, ms int, amp float64, freqs ...float64) []byte {
+	n := rate * ms / 1000
+	out := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		var v float64
+		for _, f := range freqs {
+			v += amp * math.Sin(2*math.Pi*f*float64(i)/float64(rate))
+		}
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(v)))
+	}
+	return out
+}
+
+func keyPCM(rate int, key string, ms int) []byte {
+	for r := range inbandKeys {
+		for c := range inbandKeys[r] {
+			if inbandKeys[r][c] == key {
+				return tonePCM(rate, ms, 6000, inbandRowHz[r], inbandColHz[c])
+			}
+		}
+	}
+	return nil
+}
+
+func feed(d *InbandDetector, pcm []byte, chunk int) []string {
+	var got []string
+	for len(pcm) > 0 {
+		n := chunk
+		if n > len(pcm) {
+			n = len(pcm)
+		}
+		digits, _ := d.Process(pcm[:n])
+		got = append(got, digits...)
+		pcm = pcm[n:]
+	}
+	return got
+}
+
+func TestInbandDetector_AllKeys(t *testing.T) {
+	for _, rate := range []int{8000, 16000} {
+		d := NewInbandDetector(rate)
+		var pcm []byte
+		for _, k := range []string{"1", "2", "3", "A", "4", "5", "6", "B", "7", "8", "9", "C", "*", "0", "#", "D"} {
+			pcm = append(pcm, keyPCM(rate, k, 80)...)
+			pcm = append(pcm, tonePCM(rate, 60, 0)...)
+		}
+		// 20 ms RTP frames.
+		got := strings.Join(feed(d, pcm, rate/50*2), "")
+		if got != "123A456B789C*0#D" {
+			t.Fatalf("rate=%d got %q", rate, got)
+		}
+	}
+}
+
+func TestInbandDetector_HeldKeyReportedOnce(t *testing.T) {
+	d := NewInbandDetector(8000)
+	pcm := append(keyPCM(8000, "5", 600), tonePCM(8000, 60, 0)...)
+	pcm = append(pcm, keyPCM(8000, "5", 80)...)
+	if got := feed(d, pcm, 320); strings.Join(got, "") != "55" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestInbandDetector_TooShort(t *testing.T) {
+	d := NewInbandDetector(8000)
+	if got := feed(d, keyPCM(8000, "9", 30), 320); len(got) != 0 {
+		t.Fatalf("30 ms tone reported %v", got)
+	}
+}
+
+func TestInbandDetector_RejectsNonDTMF(t *testing.T) {
+	cases := map[string][]byte{
+		"single row tone": tonePCM(8000, 200, 6000, 770),
+		"too quiet":       tonePCM(8000, 200, 100, 770, 1336),
+		"excess twist":    append([]byte(nil), mix(tonePCM(8000, 200, 300, 770), tonePCM(8000, 200, 8000, 1336))...),
+		"voice-like":      tonePCM(8000, 200, 3000, 220, 440, 770, 1336, 660, 1100),
+	}
+	for name, pcm := range cases {
+		if got := feed(NewInbandDetector(8000), pcm, 320); len(got) != 0 {
+			t.Errorf("%s: reported %v", name, got)
+		}
+	}
+}
+
+func mix(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := 0; i+1 < len(a) && i+1 < len(b); i += 2 {
+		v := int32(int16(binary.LittleEndian.Uint16(a[i:]))) + int32(int16(binary.LittleEndian.Uint16(b[i:])))
+		binary.LittleEndian.PutUint16(out[i:], uint16(int16(v)))
+	}
+	return out
+}
+
+func TestInbandDetector_FilterSilencesTone(t *testing.T) {
+	d := NewInbandDetector(8000)
+	var digits []string
+	h := func(_ context.Context, k string) { digits = append(digits, k) }
+	tone := keyPCM(8000, "#", 100)
+	silenced := 0
+	for i := 0; i+320 <= len(tone); i += 320 {
+		out := d.Filter(context.Background(), tone[i:i+320], h)
+		if allZero(out) {
+			silenced++
+		}
+	}
+	if len(digits) != 1 || digits[0] != "#" {
+		t.Fatalf("digits=%v", digits)
+	}
+	if silenced < 3 {
+		t.Fatalf("only %d of 5 tone frames silenced", silenced)
+	}
+	// The two-block delay and the hangover block drain, then audio passes.
+	speech := tonePCM(8000, 20, 3000, 300)
+	for range 4 {
+		d.Filter(context.Background(), speech, h)
+	}
+	if out := d.Filter(context.Background(), speech, h); allZero(out) {
+		t.Fatal("non-tone frame silenced")
+	}
+}
+
+func TestInbandDetector_FilterNeverLeaksToneOnset(t *testing.T) {
+	d := NewInbandDetector(8000)
+	lead := tonePCM(8000, 45, 3000, 300)
+	tone := keyPCM(8000, "5", 100)
+	tail := tonePCM(8000, 120, 3000, 300)
+	in := append(append(append([]byte{}, lead...), tone...), tail...)
+	var out []byte
+	for i := 0; i < len(in); i += 320 {
+		end := min(i+320, len(in))
+		out = append(out, d.Filter(context.Background(), in[i:end], nil)...)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("out=%d bytes, want %d", len(out), len(in))
+	}
+	// Output lags input by two blocks; every tone sample must be silenced.
+	delay := d.n * 4
+	if !allZero(out[len(lead)+delay : len(lead)+len(tone)+delay]) {
+		t.Fatal("tone audio reached the output")
+	}
+	if allZero(out[delay : delay+320]) {
+		t.Fatal("leading speech silenced")
+	}
+	if allZero(out[len(out)-320:]) {
+		t.Fatal("trailing speech silenced")
+	}
+}
+
+func allZero(b []byte) bool {
+	for _, x := range b {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInbandEnabled(t *testing.T) {
+	if !InbandEnabled("", false) || InbandEnabled("", true) {
+		t.Fatal("auto should follow telephone-event negotiation")
+	}
+	if !InbandEnabled("ON", true) || InbandEnabled("off", false) {
+		t.Fatal("explicit modes not honoured")
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package fax receives faxes on inbound SIP calls.
+//
+//   - ToneDetector spots CNG (1100 Hz, calling fax) and CED (2100 Hz,
+//     answering fax / modem) in decoded call audio so the call can
+//     leave the AI pipeline.
+//   - Receiver is a software T.30 answering terminal (ITU-T T.30
+//     phase B–E, non-ECM, MH/MR) driven by T.38 IFP packets over a
+//     Transport (pkg/sip/udptl.Session).
+//   - EncodeTIFF / EncodePDF wrap the received T.4 pages without
+//     re-encoding (TIFF Class F, PDF CCITTFaxDecode); Save writes both
+//     to a stores.Store.
+//
+// The SIP side (re-INVITE to image/t38, G.711 pass-through fallback,
+// CDR fields) lives in pkg/sip/server/fax.go.
+package fax
@@ -0,0 +1,92 @@
+package fax
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeTIFF_MultiPage(t *testing.T) {
+	pages := []Page{{Data: mhPage(3), Rows: 3, Fine: true}, {Data: mhPage(2), Rows: 2, TwoD: true}}
+	b, err := EncodeTIFF(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	if string(b[:4]) != "II*\x00" {
+		t.Fatalf("header % x", b[:4])
+	}
+	off := le.Uint32(b[4:])
+	var heights []uint32
+	for off != 0 {
+		n := int(le.Uint16(b[off:]))
+		tags := map[uint16]uint32{}
+		for i := 0; i < n; i++ {
+			e := b[int(off)+2+i*12:]
+			tags[le.Uint16(e)] = le.Uint32(e[8:])
+		}
+		if tags[256] != 1728 || tags[259] != 3 {
+			t.Fatalf("width/compression = %d/%d", tags[256], tags[259])
+		}
+		strip := b[tags[273] : tags[273]+tags[279]]
+		if !bytes.Equal(strip, pages[len(heights)].Data) {
+			t.Fatal("strip data mismatch")
+		}
+		heights = append(heights, tags[257])
+		off = le.Uint32(b[int(off)+2+n*12:])
+	}
+	if len(heights) != 2 || heights[0] != 3 || heights[1] != 2 {
+		t.Fatalf("heights = %v", heights)
+	}
+}
+
+func TestEncodePDF(t *testing.T) {
+	b, err := EncodePDF([]Page{{Data: mhPage(3), Rows: 3}, {Data: mhPage(4), Rows: 4, TwoD: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	for _, want := range []string{"%PDF-1.4", "/Count 2", "/K 0 /Columns 1728 /Rows 3", "/K 1 /Columns 1728 /Rows 4", "%%EOF"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	// xref offsets must point at "N 0 obj".
+	xref := strings.LastIndex(s, "\nxref\n") + 1
+	lines := strings.Split(s[xref:], "\n")
+	for i, l := range lines[3 : 3+8] {
+		off, err := strconv.Atoi(l[:10])
+		if err != nil || !strings.HasPrefix(s[off:], strconv.Itoa(i+1)+" 0 obj") {
+			t.Fatalf("xref entry %d = %q", i+1, l)
+		}
+	}
+}
+
+type memStore map[string][]byte
+
+func (m memStore) Read(key string) (io.ReadCloser, int64, error) {
+	return io.NopCloser(bytes.NewReader(m[key])), int64(len(m[key])), nil
+}
+func (m memStore) Write(key string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	m[key] = b
+	return err
+}
+func (m memStore) Delete(key string) error         { delete(m, key); return nil }
+func (m memStore) Exists(key string) (bool, error) { _, ok := m[key]; return ok, nil }
+func (m memStore) PublicURL(key string) string     { return "https://files.example/" + key }
+
+func TestSave(t *testing.T) {
+	st := memStore{}
+	doc, err := Save(st, "abc@1.2.3.4", &Result{Pages: []Page{{Data: mhPage(1), Rows: 1}}}, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.PDFKey != "sip/faxes/abc_1_2_3_4_1700000000.pdf" || len(st[doc.TIFFKey]) == 0 || !strings.HasSuffix(doc.URL, ".pdf") {
+		t.Fatalf("doc = %+v", doc)
+	}
+}
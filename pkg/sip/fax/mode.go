@@ -0,0 +1,34 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package fax
+
+import "strings"
+
+// Per-trunk fax handling modes (Trunk.FaxMode / SIP_FAX_MODE).
+const (
+	// ModeAuto detects CNG / CED, tries T.38 and falls back to G.711
+	// pass-through when the peer refuses the re-INVITE.
+	ModeAuto = "auto"
+	// ModeT38 only accepts T.38; a refused re-INVITE ends the call.
+	ModeT38 = "t38"
+	// ModePassthrough skips T.38 and relays fax audio as G.711.
+	ModePassthrough = "passthrough"
+	// ModeOff disables fax detection (calls stay with the AI).
+	ModeOff = "off"
+)
+
+// NormalizeMode maps free-form config to a Mode* constant (unknown /
+// empty → auto).
+func NormalizeMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "t38", "t.38":
+		return ModeT38
+	case "passthrough", "pass-through", "g711", "g711_passthrough":
+		return ModePassthrough
+	case "off", "0", "false", "no", "none":
+		return ModeOff
+	default:
+		return ModeAuto
+	}
+}
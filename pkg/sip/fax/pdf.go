@@ -0,0 +1,66 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package fax
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// EncodePDF wraps pages in a PDF, one image per page decoded by the
+// viewer's CCITTFaxDecode filter (K=0 for MH, K=1 for MR).
+func EncodePDF(pages []Page) ([]byte, error) {
+	if len(pages) == 0 {
+		return nil, errors.New("fax: no pages")
+	}
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string, stream []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s", len(offsets), body)
+		if stream != nil {
+			buf.WriteString("\nstream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream")
+		}
+		buf.WriteString("\nendobj\n")
+	}
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Objects: 1 catalog, 2 page tree, then page / content / image per page.
+	kids := ""
+	for i := range pages {
+		kids += fmt.Sprintf("%d 0 R ", 3+i*3)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(pages)), nil)
+	for i, p := range pages {
+		yres := 98.0
+		if p.Fine {
+			yres = 196
+		}
+		w := float64(pageWidth) / 204 * 72
+		h := float64(p.Rows) / yres * 72
+		page, content, image := 3+i*3, 4+i*3, 5+i*3
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im%d %d 0 R >> >> /Contents %d 0 R >>",
+			w, h, page, image, content), nil)
+		draw := []byte(fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im%d Do Q", w, h, page))
+		obj(fmt.Sprintf("<< /Length %d >>", len(draw)), draw)
+		k := 0
+		if p.TwoD {
+			k = 1
+		}
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 1 /ColorSpace /DeviceGray "+
+			"/Filter /CCITTFaxDecode /DecodeParms << /K %d /Columns %d /Rows %d /EndOfLine true >> /Length %d >>",
+			pageWidth, p.Rows, k, pageWidth, p.Rows, len(p.Data)), p.Data)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package fax
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/stores"
+)
+
+// Document is where Save put a received fax.
+type Document struct {
+	TIFFKey string
+	PDFKey  string
+	URL     string // public URL of the PDF (TIFF when PDF failed), may be ""
+}
+
+// Save writes the received pages as TIFF and PDF under
+// sip/faxes/<callID>_<unix>.{tif,pdf}.
+func Save(store stores.Store, callID string, res *Result, now time.Time) (Document, error) {
+	var doc Document
+	if store == nil {
+		return doc, errors.New("fax: storage backend unavailable")
+	}
+	if res == nil || len(res.Pages) == 0 {
+		return doc, errors.New("fax: no pages")
+	}
+	base := fmt.Sprintf("sip/faxes/%s_%d", sanitizeKey(callID), now.Unix())
+	tif, err := EncodeTIFF(res.Pages)
+	if err != nil {
+		return doc, err
+	}
+	if err := store.Write(base+".tif", bytes.NewReader(tif)); err != nil {
+		return doc, err
+	}
+	doc.TIFFKey = base + ".tif"
+	doc.URL = strings.TrimSpace(stores.PublicObjectURL(store, doc.TIFFKey))
+	pdf, err := EncodePDF(res.Pages)
+	if err != nil {
+		return doc, err
+	}
+	if err := store.Write(base+".pdf", bytes.NewReader(pdf)); err != nil {
+		return doc, err
+	}
+	doc.PDFKey = base + ".pdf"
+	if u := strings.TrimSpace(stores.PublicObjectURL(store, doc.PDFKey)); u != "" {
+		doc.URL = u
+	}
+	return doc, nil
+}
+
+func sanitizeKey(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "call"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
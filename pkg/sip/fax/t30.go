@@ -0,0 +1,439 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package fax
+
+// T.30 answering terminal over T.38 (transferred TCF, non-ECM).
+//
+// Phase B: CED, then CSI + DIS every t30DISRepeat until the caller
+// sends TSI + DCS (or T1 expires). The caller's TCF arrives as
+// t4-non-ecm-data and is judged by its share of zero octets: CFR when
+// clean, FTT to make the sender retrain (usually at a lower rate).
+// Phase C: one page of T.4 data up to t4-non-ecm-sig-end.
+// Phase D: MPS (next page), EOM (back to phase B), EOP (done) are all
+// confirmed with MCF; CRP repeats our last response.
+// Phase E: DCN.
+//
+// HDLC frames are carried as in spandsp: octets are in the same
+// (already bit-reversed) order as the FCF constants below, without
+// the FCS — T.38 replaces it with the hdlc-fcs-OK field.
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/udptl"
+)
+
+// T.30 facsimile control fields.
+const (
+	fcfDIS = 0x80
+	fcfCSI = 0x40
+	fcfDCS = 0x82
+	fcfTSI = 0x42
+	fcfCFR = 0x84
+	fcfFTT = 0x44
+	fcfMPS = 0x72
+	fcfEOM = 0x8E
+	fcfEOP = 0x2E
+	fcfMCF = 0x8C
+	fcfRTN = 0x4C
+	fcfDCN = 0xFA
+	fcfCRP = 0x1A
+)
+
+// disFIF advertises: ready to receive, V.27ter/V.29/V.17, fine
+// resolution, 2-D coding (MR), 1728 px width, unlimited length,
+// 0 ms minimum scan line time.
+var disFIF = []byte{0x00, 0xEE, 0x74}
+
+const (
+	t30DISRepeat   = 3 * time.Second
+	t30T1          = 35 * time.Second
+	tcfMinZeroRate = 0.9
+	pageWidth      = 1728
+)
+
+// Result statuses.
+const (
+	StatusOK      = "ok"      // DCN after EOP
+	StatusPartial = "partial" // pages received, session ended abnormally
+	StatusFailed  = "failed"
+)
+
+// Transport carries IFP packets (pkg/sip/udptl.Session satisfies it).
+type Transport interface {
+	Send(ifp []byte) error
+	// Receive returns the next IFPs in order, or (nil, nil) on timeout.
+	Receive(timeout time.Duration) ([][]byte, error)
+}
+
+// Config tunes the receiver. Zero values take the defaults.
+type Config struct {
+	LocalID     string        // CSI, up to 20 characters
+	Version     int           // negotiated T38FaxVersion
+	CEDDuration time.Duration // default 3 s
+	IdleTimeout time.Duration // no IFP for this long fails the session; default 30 s
+	Timeout     time.Duration // whole session; default 15 min
+}
+
+// Page is one received page of raw T.4 data (MSB-first, as on the
+// wire), 1728 pixels wide.
+type Page struct {
+	Data []byte
+	Rows int
+	Fine bool // 7.7 lines/mm (196 dpi) vs 3.85 (98 dpi)
+	TwoD bool // MR coding (T.4 2-D) vs MH
+}
+
+// Result is the outcome of one fax session.
+type Result struct {
+	Status   string
+	Reason   string // why a non-ok session ended
+	RemoteID string // sender TSI
+	BitRate  int
+	Pages    []Page
+}
+
+type t30Phase int
+
+const (
+	phaseB t30Phase = iota
+	phaseTCF
+	phasePage
+	phasePostPage
+	phaseEnd // EOP confirmed, waiting for DCN
+	phaseDone
+)
+
+type receiver struct {
+	tr  Transport
+	cfg Config
+	res *Result
+
+	phase   t30Phase
+	fine    bool
+	twoD    bool
+	frame   []byte
+	nonECM  []byte
+	last    [][]byte
+	disAt   time.Time
+	startAt time.Time
+	gotEOP  bool
+	badPage bool // page data without a decodable line: answer RTN
+}
+
+// Receive runs one T.30 receive session until DCN, timeout, ctx
+// cancellation or a transport error. The result is never nil.
+func Receive(ctx context.Context, tr Transport, cfg Config) *Result {
+	if cfg.CEDDuration <= 0 {
+		cfg.CEDDuration = 3 * time.Second
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 30 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Minute
+	}
+	r := &receiver{tr: tr, cfg: cfg, res: &Result{}, startAt: time.Now()}
+	err := r.run(ctx)
+	switch {
+	case err == nil && r.gotEOP:
+		r.res.Status = StatusOK
+	case len(r.res.Pages) > 0:
+		r.res.Status = StatusPartial
+	default:
+		r.res.Status = StatusFailed
+	}
+	if err != nil {
+		r.res.Reason = err.Error()
+	}
+	return r.res
+}
+
+func (r *receiver) run(ctx context.Context) error {
+	if err := r.send(udptl.Indicator(udptl.IndCED)); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(r.cfg.CEDDuration):
+	}
+	if err := r.sendDIS(); err != nil {
+		return err
+	}
+	lastRx := time.Now()
+	for r.phase != phaseDone {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now := time.Now()
+		if now.Sub(r.startAt) > r.cfg.Timeout {
+			return errors.New("session timeout")
+		}
+		if now.Sub(lastRx) > r.cfg.IdleTimeout {
+			return errors.New("idle timeout")
+		}
+		if r.phase == phaseB && now.Sub(r.disAt) >= t30DISRepeat {
+			if now.Sub(r.startAt) > t30T1 && r.res.RemoteID == "" && len(r.res.Pages) == 0 {
+				return errors.New("T1 expired: no DCS")
+			}
+			if err := r.sendDIS(); err != nil {
+				return err
+			}
+		}
+		ifps, err := r.tr.Receive(500 * time.Millisecond)
+		if err != nil {
+			return err
+		}
+		if len(ifps) > 0 {
+			lastRx = time.Now()
+		}
+		for _, b := range ifps {
+			p, err := udptl.ParseIFP(b, r.cfg.Version)
+			if err != nil {
+				continue
+			}
+			if err := r.handle(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *receiver) send(p udptl.IFP) error {
+	return r.tr.Send(p.Marshal(r.cfg.Version))
+}
+
+// sendFrames sends one V.21 HDLC burst: preamble, frames, no-signal.
+func (r *receiver) sendFrames(frames ...[]byte) error {
+	r.last = frames
+	if err := r.send(udptl.Indicator(udptl.IndV21Preamble)); err != nil {
+		return err
+	}
+	for i, f := range frames {
+		end := udptl.FieldHDLCFCSOK
+		if i == len(frames)-1 {
+			end = udptl.FieldHDLCFCSOKSigEnd
+		}
+		if err := r.send(udptl.DataIFP(udptl.DataV21,
+			udptl.Field{Type: udptl.FieldHDLCData, Data: f},
+			udptl.Field{Type: end})); err != nil {
+			return err
+		}
+	}
+	return r.send(udptl.Indicator(udptl.IndNoSignal))
+}
+
+func (r *receiver) sendDIS() error {
+	r.disAt = time.Now()
+	r.phase = phaseB
+	return r.sendFrames(
+		hdlcFrame(fcfCSI, false, encodeID(r.cfg.LocalID)...),
+		hdlcFrame(fcfDIS, true, disFIF...),
+	)
+}
+
+func (r *receiver) sendResponse(fcf byte) error {
+	return r.sendFrames(hdlcFrame(fcf, true))
+}
+
+func (r *receiver) handle(p udptl.IFP) error {
+	if !p.Data {
+		return nil
+	}
+	if p.DataType != udptl.DataV21 {
+		if r.res.BitRate == 0 || r.phase == phaseTCF {
+			r.res.BitRate = udptl.DataRate(p.DataType)
+		}
+		for _, f := range p.Fields {
+			switch f.Type {
+			case udptl.FieldT4NonECMData:
+				r.nonECM = append(r.nonECM, f.Data...)
+			case udptl.FieldT4NonECMSigEnd:
+				r.nonECM = append(r.nonECM, f.Data...)
+				if err := r.nonECMEnd(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, f := range p.Fields {
+		switch f.Type {
+		case udptl.FieldHDLCData:
+			r.frame = append(r.frame, f.Data...)
+		case udptl.FieldHDLCFCSOK, udptl.FieldHDLCFCSOKSigEnd:
+			frame := append(r.frame, f.Data...)
+			r.frame = nil
+			if err := r.onFrame(frame); err != nil {
+				return err
+			}
+		default: // FCS bad or bare sig-end: drop the partial frame
+			r.frame = nil
+		}
+	}
+	return nil
+}
+
+func (r *receiver) onFrame(f []byte) error {
+	if len(f) < 3 || f[0] != 0xFF {
+		return nil
+	}
+	fif := f[3:]
+	switch f[2] &^ 0x01 {
+	case fcfTSI:
+		r.res.RemoteID = decodeID(fif)
+	case fcfDCS:
+		if len(fif) >= 2 {
+			r.fine = fif[1]&0x40 != 0
+			r.twoD = fif[1]&0x80 != 0
+		}
+		r.phase = phaseTCF
+		r.nonECM = nil
+	case fcfMPS, fcfEOM, fcfEOP:
+		if r.phase != phasePostPage {
+			// Retransmitted post-page message: our MCF was lost.
+			return r.resend()
+		}
+		resp := byte(fcfMCF)
+		if r.badPage {
+			resp = fcfRTN
+		}
+		if err := r.sendResponse(resp); err != nil {
+			return err
+		}
+		switch {
+		case r.badPage:
+			// Sender retrains (DCS + TCF) and repeats the page.
+			r.badPage = false
+			r.phase = phaseB
+			r.disAt = time.Now()
+		case f[2]&^0x01 == fcfMPS:
+			r.phase = phasePage
+		case f[2]&^0x01 == fcfEOM:
+			return r.sendDIS()
+		default:
+			r.gotEOP = true
+			r.phase = phaseEnd
+		}
+	case fcfCRP:
+		return r.resend()
+	case fcfDCN:
+		r.phase = phaseDone
+	}
+	return nil
+}
+
+func (r *receiver) resend() error {
+	if len(r.last) == 0 {
+		return nil
+	}
+	return r.sendFrames(r.last...)
+}
+
+func (r *receiver) nonECMEnd() error {
+	data := r.nonECM
+	r.nonECM = nil
+	switch r.phase {
+	case phaseTCF:
+		if tcfOK(data) {
+			r.phase = phasePage
+			return r.sendResponse(fcfCFR)
+		}
+		r.phase = phaseB
+		r.disAt = time.Now()
+		return r.sendResponse(fcfFTT)
+	case phasePage:
+		if rows := countRows(data, r.twoD); rows > 0 {
+			r.res.Pages = append(r.res.Pages, Page{Data: data, Rows: rows, Fine: r.fine, TwoD: r.twoD})
+		} else {
+			r.badPage = true
+		}
+		r.phase = phasePostPage
+	}
+	return nil
+}
+
+// tcfOK judges the training check: ~1.5 s of zeros.
+func tcfOK(data []byte) bool {
+	if len(data) < 64 {
+		return false
+	}
+	zero := 0
+	for _, b := range data {
+		if b == 0 {
+			zero++
+		}
+	}
+	return float64(zero)/float64(len(data)) >= tcfMinZeroRate
+}
+
+// countRows counts coded scan lines: EOL-separated segments carrying
+// at least one 1 bit (fill bits and RTC are all zeros between EOLs).
+// With MR coding the tag bit after each EOL is skipped.
+func countRows(data []byte, twoD bool) int {
+	rows, zeros := 0, 0
+	started, seg, skip := false, false, false
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bit := b>>uint(i)&1 == 1
+			if skip {
+				skip, zeros = false, 0
+				continue
+			}
+			if !bit {
+				zeros++
+				continue
+			}
+			if zeros >= 11 {
+				if seg {
+					rows++
+				}
+				seg, started, skip, zeros = false, true, twoD, 0
+				continue
+			}
+			zeros = 0
+			if started {
+				seg = true
+			}
+		}
+	}
+	if seg {
+		rows++
+	}
+	return rows
+}
+
+func hdlcFrame(fcf byte, final bool, fif ...byte) []byte {
+	ctrl := byte(0x03)
+	if final {
+		ctrl = 0x13
+	}
+	return append([]byte{0xFF, ctrl, fcf}, fif...)
+}
+
+// encodeID packs a CSI/TSI: 20 characters, last character first,
+// space padded.
+func encodeID(id string) []byte {
+	if len(id) > 20 {
+		id = id[:20]
+	}
+	out := []byte(strings.Repeat(" ", 20))
+	for i := 0; i < len(id); i++ {
+		out[i] = id[len(id)-1-i]
+	}
+	return out
+}
+
+func decodeID(fif []byte) string {
+	b := make([]byte, len(fif))
+	for i := range fif {
+		b[len(fif)-1-i] = fif[i]
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,179 @@
+package fax
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/udptl"
+)
+
+// pipeTransport connects the receiver to a scripted sender.
+type pipeTransport struct {
+	toSender   chan []byte
+	toReceiver chan []byte
+}
+
+func (p *pipeTransport) Send(ifp []byte) error {
+	p.toSender <- append([]byte(nil), ifp...)
+	return nil
+}
+
+func (p *pipeTransport) Receive(timeout time.Duration) ([][]byte, error) {
+	select {
+	case b := <-p.toReceiver:
+		return [][]byte{b}, nil
+	case <-time.After(timeout):
+		return nil, nil
+	}
+}
+
+type bitWriter struct {
+	buf  []byte
+	nbit int
+}
+
+func (w *bitWriter) bits(s string) {
+	for _, c := range s {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if c == '1' {
+			w.buf[len(w.buf)-1] |= 0x80 >> uint(w.nbit%8)
+		}
+		w.nbit++
+	}
+}
+
+// mhPage codes rows all-white MH lines followed by RTC.
+func mhPage(rows int) []byte {
+	w := &bitWriter{}
+	for i := 0; i < rows; i++ {
+		w.bits("000000000001" + "010011011" + "00110101") // EOL, white 1728 makeup, white 0
+	}
+	for i := 0; i < 6; i++ {
+		w.bits("000000000001")
+	}
+	return w.buf
+}
+
+// sender plays the calling terminal until it gets want, or fails.
+type sender struct {
+	t  *testing.T
+	tr *pipeTransport
+}
+
+func (s *sender) send(p udptl.IFP) { s.tr.toReceiver <- p.Marshal(0) }
+
+func (s *sender) frames(frames ...[]byte) {
+	s.send(udptl.Indicator(udptl.IndV21Preamble))
+	for i, f := range frames {
+		end := udptl.FieldHDLCFCSOK
+		if i == len(frames)-1 {
+			end = udptl.FieldHDLCFCSOKSigEnd
+		}
+		s.send(udptl.DataIFP(udptl.DataV21, udptl.Field{Type: udptl.FieldHDLCData, Data: f}, udptl.Field{Type: end}))
+	}
+}
+
+func (s *sender) nonECM(dataType int, data []byte) {
+	s.send(udptl.Indicator(udptl.IndV29_9600Training))
+	for len(data) > 100 {
+		s.send(udptl.DataIFP(dataType, udptl.Field{Type: udptl.FieldT4NonECMData, Data: data[:100]}))
+		data = data[100:]
+	}
+	s.send(udptl.DataIFP(dataType, udptl.Field{Type: udptl.FieldT4NonECMSigEnd, Data: data}))
+}
+
+// expect reads receiver output until an HDLC frame with fcf arrives.
+func (s *sender) expect(fcf byte) []byte {
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case b := <-s.tr.toSender:
+			p, err := udptl.ParseIFP(b, 0)
+			if err != nil || !p.Data {
+				continue
+			}
+			for _, f := range p.Fields {
+				if len(f.Data) >= 3 && f.Data[2] == fcf {
+					return f.Data
+				}
+			}
+		case <-deadline:
+			s.t.Errorf("timed out waiting for FCF %#x", fcf)
+			return nil
+		}
+	}
+}
+
+func TestReceive_TwoPagesWithRetrain(t *testing.T) {
+	tr := &pipeTransport{toSender: make(chan []byte, 256), toReceiver: make(chan []byte, 256)}
+	done := make(chan *Result, 1)
+	go func() {
+		done <- Receive(context.Background(), tr, Config{LocalID: "+861012345", CEDDuration: time.Millisecond, IdleTimeout: 5 * time.Second})
+	}()
+
+	s := &sender{t: t, tr: tr}
+	if csi := s.expect(fcfCSI); decodeID(csi[3:]) != "+861012345" {
+		t.Fatalf("CSI = %q", decodeID(csi[3:]))
+	}
+	if dis := s.expect(fcfDIS); !bytes.Equal(dis[3:], disFIF) {
+		t.Fatalf("DIS = % x", dis)
+	}
+	dcs := hdlcFrame(fcfDCS|0x01, true, 0x00, 0x46, 0x00) // fine, V.29 9600
+	tsi := hdlcFrame(fcfTSI|0x01, false, encodeID("SENDER 1")...)
+
+	// First training is noisy → FTT, then a clean one → CFR.
+	s.frames(tsi, dcs)
+	s.nonECM(udptl.DataV29_9600, bytes.Repeat([]byte{0x55}, 1800))
+	s.expect(fcfFTT)
+	s.frames(tsi, dcs)
+	s.nonECM(udptl.DataV29_9600, make([]byte, 1800))
+	s.expect(fcfCFR)
+
+	s.nonECM(udptl.DataV29_9600, mhPage(3))
+	s.frames(hdlcFrame(fcfMPS|0x01, true))
+	s.expect(fcfMCF)
+	s.frames(hdlcFrame(fcfMPS|0x01, true)) // MCF "lost": repeated MPS must not add a page
+	s.expect(fcfMCF)
+	s.nonECM(udptl.DataV29_9600, mhPage(5))
+	s.frames(hdlcFrame(fcfEOP|0x01, true))
+	s.expect(fcfMCF)
+	s.frames(hdlcFrame(fcfDCN|0x01, true))
+
+	var res *Result
+	select {
+	case res = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receiver did not finish")
+	}
+	if res.Status != StatusOK || res.RemoteID != "SENDER 1" || res.BitRate != 9600 {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(res.Pages) != 2 || res.Pages[0].Rows != 3 || res.Pages[1].Rows != 5 || !res.Pages[0].Fine || res.Pages[0].TwoD {
+		t.Fatalf("pages = %+v", res.Pages)
+	}
+}
+
+func TestReceive_IdleTimeout(t *testing.T) {
+	tr := &pipeTransport{toSender: make(chan []byte, 256), toReceiver: make(chan []byte)}
+	res := Receive(context.Background(), tr, Config{CEDDuration: time.Millisecond, IdleTimeout: 300 * time.Millisecond})
+	if res.Status != StatusFailed || res.Reason == "" {
+		t.Fatalf("result = %+v", res)
+	}
+}
+
+func TestCountRows_MR(t *testing.T) {
+	w := &bitWriter{}
+	for i := 0; i < 4; i++ {
+		w.bits("000000000001" + "1" + "010011011" + "00110101") // EOL, 1-D tag, white line
+	}
+	for i := 0; i < 6; i++ {
+		w.bits("0000000000011") // RTC in MR: EOL + tag 1
+	}
+	if got := countRows(w.buf, true); got != 4 {
+		t.Fatalf("rows = %d", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package fax
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// TIFF field types.
+const (
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    uint32 // inline value or offset
+}
+
+// EncodeTIFF writes pages as a multi-page TIFF Class F (little-endian,
+// one strip per page, Compression=3 with the T.4 data as received).
+func EncodeTIFF(pages []Page) ([]byte, error) {
+	if len(pages) == 0 {
+		return nil, errors.New("fax: no pages")
+	}
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.Write([]byte{'I', 'I', 42, 0, 0, 0, 0, 0})
+	prevNext := 4 // where the previous IFD's "next" offset goes
+	for i, p := range pages {
+		dataOff := buf.Len()
+		buf.Write(p.Data)
+		if buf.Len()%2 == 1 {
+			buf.WriteByte(0)
+		}
+		ratOff := buf.Len()
+		yres := uint32(98)
+		if p.Fine {
+			yres = 196
+		}
+		for _, v := range []uint32{204, 1, yres, 1} {
+			_ = binary.Write(&buf, le, v)
+		}
+		t4opts := uint32(0)
+		if p.TwoD {
+			t4opts = 1
+		}
+		entries := []tiffEntry{
+			{254, tiffLong, 1, 2}, // NewSubfileType: page of a multi-page document
+			{256, tiffLong, 1, pageWidth},
+			{257, tiffLong, 1, uint32(p.Rows)},
+			{258, tiffShort, 1, 1},
+			{259, tiffShort, 1, 3},                                  // CCITT T.4
+			{262, tiffShort, 1, 0},                                  // WhiteIsZero
+			{266, tiffShort, 1, 1},                                  // FillOrder: MSB first
+			{273, tiffLong, 1, uint32(dataOff)},                     // StripOffsets
+			{277, tiffShort, 1, 1},                                  // SamplesPerPixel
+			{278, tiffLong, 1, uint32(p.Rows)},                      // RowsPerStrip
+			{279, tiffLong, 1, uint32(len(p.Data))},                 // StripByteCounts
+			{282, tiffRational, 1, uint32(ratOff)},                  // XResolution
+			{283, tiffRational, 1, uint32(ratOff + 8)},              // YResolution
+			{292, tiffLong, 1, t4opts},                              // T4Options
+			{296, tiffShort, 1, 2},                                  // ResolutionUnit: inch
+			{297, tiffShort, 2, uint32(i) | uint32(len(pages))<<16}, // PageNumber
+		}
+		ifdOff := buf.Len()
+		le.PutUint32(buf.Bytes()[prevNext:], uint32(ifdOff))
+		_ = binary.Write(&buf, le, uint16(len(entries)))
+		for _, e := range entries {
+			_ = binary.Write(&buf, le, e.tag)
+			_ = binary.Write(&buf, le, e.typ)
+			_ = binary.Write(&buf, le, e.count)
+			_ = binary.Write(&buf, le, e.value)
+		}
+		prevNext = buf.Len()
+		_ = binary.Write(&buf, le, uint32(0))
+	}
+	return buf.Bytes(), nil
+}
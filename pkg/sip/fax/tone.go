@@ -0,0 +1,115 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package fax
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Fax tones reported by ToneDetector.
+const (
+	ToneCNG = "cng" // calling tone: 1100 Hz, 0.5 s on / 3 s off
+	ToneCED = "ced" // called-station / answer tone: 2100 Hz, 2.6–4 s
+)
+
+const (
+	toneBlockMs      = 20
+	toneMinAmplitude = 200.0
+	tonePurity       = 0.6 // share of block energy in the tone bin
+	cngMinMs         = 400 // CNG bursts last 500 ms ±15%
+	cedMinMs         = 600
+)
+
+// ToneDetector is a streaming Goertzel CNG / CED detector over s16le
+// mono PCM. Not safe for concurrent use.
+type ToneDetector struct {
+	n        int
+	cngCoef  float64
+	cedCoef  float64
+	minPower float64
+
+	pending []float64
+
+	tone     string // tone held in the current run
+	run      int    // consecutive blocks of tone
+	reported bool
+}
+
+// NewToneDetector builds a detector for sampleRate (8000 if <= 0).
+func NewToneDetector(sampleRate int) *ToneDetector {
+	if sampleRate <= 0 {
+		sampleRate = 8000
+	}
+	d := &ToneDetector{n: sampleRate * toneBlockMs / 1000}
+	d.cngCoef = 2 * math.Cos(2*math.Pi*1100/float64(sampleRate))
+	d.cedCoef = 2 * math.Cos(2*math.Pi*2100/float64(sampleRate))
+	half := toneMinAmplitude * float64(d.n) / 2
+	d.minPower = half * half
+	d.pending = make([]float64, 0, d.n)
+	return d
+}
+
+// Process consumes PCM and returns ToneCNG / ToneCED the first time
+// either is held long enough, "" otherwise. Only the first tone of a
+// call is reported.
+func (d *ToneDetector) Process(pcm []byte) string {
+	if d == nil || d.reported {
+		return ""
+	}
+	for i := 0; i+1 < len(pcm); i += 2 {
+		d.pending = append(d.pending, float64(int16(binary.LittleEndian.Uint16(pcm[i:]))))
+		if len(d.pending) < d.n {
+			continue
+		}
+		t := d.classify(d.pending)
+		d.pending = d.pending[:0]
+		if t != d.tone {
+			d.tone, d.run = t, 0
+		}
+		if t == "" {
+			continue
+		}
+		d.run++
+		need := cngMinMs
+		if t == ToneCED {
+			need = cedMinMs
+		}
+		if d.run*toneBlockMs >= need {
+			d.reported = true
+			return t
+		}
+	}
+	return ""
+}
+
+func goertzel(block []float64, coef float64) float64 {
+	var q1, q2 float64
+	for _, x := range block {
+		q0 := coef*q1 - q2 + x
+		q2, q1 = q1, q0
+	}
+	return q1*q1 + q2*q2 - coef*q1*q2
+}
+
+func (d *ToneDetector) classify(block []float64) string {
+	cng := goertzel(block, d.cngCoef)
+	ced := goertzel(block, d.cedCoef)
+	p, t := cng, ToneCNG
+	if ced > cng {
+		p, t = ced, ToneCED
+	}
+	if p < d.minPower {
+		return ""
+	}
+	var energy float64
+	for _, x := range block {
+		energy += x * x
+	}
+	// Pure tone: power (A·N/2)² vs energy A²·N/2 → ratio ~1.
+	if energy == 0 || p/(energy*float64(len(block))/2) < tonePurity {
+		return ""
+	}
+	return t
+}
@@ -0,0 +1,65 @@
+package fax
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func sinePCM(rate, ms int, amp, hz float64) []byte {
+	n := rate * ms / 1000
+	out := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		v := amp * math.Sin(2*math.Pi*hz*float64(i)/float64(rate))
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(v)))
+	}
+	return out
+}
+
+func feedTone(d *ToneDetector, pcm []byte, chunk int) string {
+	for len(pcm) > 0 {
+		n := chunk
+		if n > len(pcm) {
+			n = len(pcm)
+		}
+		if t := d.Process(pcm[:n]); t != "" {
+			return t
+		}
+		pcm = pcm[n:]
+	}
+	return ""
+}
+
+func TestToneDetector(t *testing.T) {
+	for _, rate := range []int{8000, 16000} {
+		if got := feedTone(NewToneDetector(rate), sinePCM(rate, 500, 4000, 1100), rate/50*2); got != ToneCNG {
+			t.Errorf("rate=%d CNG: got %q", rate, got)
+		}
+		if got := feedTone(NewToneDetector(rate), sinePCM(rate, 1000, 4000, 2100), rate/50*2); got != ToneCED {
+			t.Errorf("rate=%d CED: got %q", rate, got)
+		}
+	}
+	cases := map[string][]byte{
+		"short CNG blip": sinePCM(8000, 200, 4000, 1100),
+		"speech-ish":     sinePCM(8000, 1000, 4000, 440),
+		"too quiet":      sinePCM(8000, 1000, 50, 2100),
+	}
+	for name, pcm := range cases {
+		if got := feedTone(NewToneDetector(8000), pcm, 320); got != "" {
+			t.Errorf("%s: reported %q", name, got)
+		}
+	}
+	d := NewToneDetector(8000)
+	feedTone(d, sinePCM(8000, 600, 4000, 1100), 320)
+	if got := feedTone(d, sinePCM(8000, 600, 4000, 1100), 320); got != "" {
+		t.Errorf("second burst reported %q", got)
+	}
+}
+
+func TestNormalizeMode(t *testing.T) {
+	for in, want := range map[string]string{"": ModeAuto, "T.38": ModeT38, "g711": ModePassthrough, "off": ModeOff, "bogus": ModeAuto} {
+		if got := NormalizeMode(in); got != want {
+			t.Errorf("NormalizeMode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
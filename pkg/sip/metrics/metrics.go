@@ -59,6 +59,11 @@ const (
 	MetricCallJitterMs     = "sip_call_jitter_ms"
 	MetricCallLossFraction = "sip_call_loss_fraction"
 	MetricCallMOSEstimate  = "sip_call_mos_estimate"
+
+	// RFC 4585 Generic NACK traffic, added once per call at call end.
+	// dir = sent (seqs we asked for) / received (NACKs from the peer).
+	MetricRTPNACKTotal       = "sip_rtp_nack_total"
+	MetricRTPRetransmitTotal = "sip_rtp_retransmit_total"
 )
 
 // Direction enum.
@@ -82,6 +87,8 @@ func init() {
 	metrics.RegisterLabels(MetricCallJitterMs)
 	metrics.RegisterLabels(MetricCallLossFraction)
 	metrics.RegisterLabels(MetricCallMOSEstimate)
+	metrics.RegisterLabels(MetricRTPNACKTotal, "dir")
+	metrics.RegisterLabels(MetricRTPRetransmitTotal)
 }
 
 // ---- Pre-allocated label maps ----
@@ -420,3 +427,19 @@ func ObserveCallQoS(rttMs uint32, jitterMs float64, lossFraction float64, mosEst
 			"per-call E-Model MOS estimate at call end (1..5)", mosEstimate)
 	}
 }
+
+var (
+	labelsNACKSent     = map[string]string{"dir": "sent"}
+	labelsNACKReceived = map[string]string{"dir": "received"}
+)
+
+// ObserveCallNACK adds one call's RFC 4585 NACK counters (from the
+// final RTCPSnapshot). Zero values are no-ops.
+func ObserveCallNACK(nackedSeqs, nacksReceived, retransmits uint64) {
+	metrics.Default.AddCounter(MetricRTPNACKTotal,
+		"RTP Generic NACK traffic by direction", labelsNACKSent, nackedSeqs)
+	metrics.Default.AddCounter(MetricRTPNACKTotal,
+		"RTP Generic NACK traffic by direction", labelsNACKReceived, nacksReceived)
+	metrics.Default.AddCounter(MetricRTPRetransmitTotal,
+		"RTP packets retransmitted in answer to peer NACKs", nil, retransmits)
+}
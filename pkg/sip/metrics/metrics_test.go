@@ -232,3 +232,17 @@ func TestObserveCallQoS_HappyPath(t *testing.T) {
 		}
 	}
 }
+
+func TestObserveCallNACK_CountsByDirection(t *testing.T) {
+	ObserveCallNACK(4, 2, 2)
+	out := snapshot(t)
+	for _, want := range []string{
+		MetricRTPNACKTotal + `{dir="sent"}`,
+		MetricRTPNACKTotal + `{dir="received"}`,
+		MetricRTPRetransmitTotal,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in /metrics", want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package metrics
+
+import "github.com/LinByte/VoiceServer/pkg/voice/metrics"
+
+// Outbound REGISTER client (trunk registrations with upstream carriers).
+//
+// Cardinality:
+//
+//   - sip_register_client_result_total{result} — 5 series
+//   - sip_register_client_registered            — 1 series (no labels;
+//     per-trunk state lives in the admin API, not here)
+
+const (
+	// MetricRegisterClientResultTotal counts finished REGISTER
+	// attempts (after any digest retry).
+	//
+	// labels:
+	//   result = "ok" | "auth_failed" | "rejected" | "timeout" | "error"
+	MetricRegisterClientResultTotal = "sip_register_client_result_total"
+
+	// MetricRegisterClientRegistered is the number of trunks currently
+	// holding a live registration.
+	MetricRegisterClientRegistered = "sip_register_client_registered"
+)
+
+// REGISTER client result enum.
+const (
+	RegisterResultOK         = "ok"
+	RegisterResultAuthFailed = "auth_failed" // 401/407 we could not answer
+	RegisterResultRejected   = "rejected"    // any other final non-2xx
+	RegisterResultTimeout    = "timeout"     // no final response
+	RegisterResultError      = "error"       // resolve / send failure
+)
+
+var (
+	labelsRegisterOK         = map[string]string{"result": RegisterResultOK}
+	labelsRegisterAuthFailed = map[string]string{"result": RegisterResultAuthFailed}
+	labelsRegisterRejected   = map[string]string{"result": RegisterResultRejected}
+	labelsRegisterTimeout    = map[string]string{"result": RegisterResultTimeout}
+	labelsRegisterError      = map[string]string{"result": RegisterResultError}
+)
+
+func init() {
+	metrics.RegisterLabels(MetricRegisterClientResultTotal, "result")
+	metrics.RegisterLabels(MetricRegisterClientRegistered)
+}
+
+// RegisterClientResult bumps the REGISTER client outcome counter.
+// Unknown results are dropped.
+func RegisterClientResult(result string) {
+	var labels map[string]string
+	switch result {
+	case RegisterResultOK:
+		labels = labelsRegisterOK
+	case RegisterResultAuthFailed:
+		labels = labelsRegisterAuthFailed
+	case RegisterResultRejected:
+		labels = labelsRegisterRejected
+	case RegisterResultTimeout:
+		labels = labelsRegisterTimeout
+	case RegisterResultError:
+		labels = labelsRegisterError
+	default:
+		return
+	}
+	metrics.Default.IncCounter(MetricRegisterClientResultTotal,
+		"Outbound REGISTER attempts to carrier registrars by outcome", labels)
+}
+
+// RegisterClientRegistered publishes how many trunks are registered.
+func RegisterClientRegistered(n int) {
+	metrics.Default.SetGauge(MetricRegisterClientRegistered,
+		"Trunks currently holding a live registration with their carrier", nil, float64(n))
+}
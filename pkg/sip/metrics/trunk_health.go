@@ -0,0 +1,71 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package metrics
+
+import "github.com/LinByte/VoiceServer/pkg/voice/metrics"
+
+// Trunk keep-alive (SIP OPTIONS pings toward carrier gateways).
+//
+// Cardinality:
+//
+//   - sip_trunk_options_total{result} — 4 series
+//   - sip_trunk_down                   — 1 series (no labels; per-trunk
+//     state lives in the admin API, not here)
+
+const (
+	// MetricTrunkOptionsTotal counts finished OPTIONS probes.
+	//
+	// labels:
+	//   result = "ok" | "rejected" | "timeout" | "error"
+	MetricTrunkOptionsTotal = "sip_trunk_options_total"
+
+	// MetricTrunkDown is the number of probed trunks currently marked down.
+	MetricTrunkDown = "sip_trunk_down"
+)
+
+// OPTIONS probe result enum.
+const (
+	TrunkOptionsOK       = "ok"       // any response proving the gateway is alive
+	TrunkOptionsRejected = "rejected" // 408 / 5xx from the gateway
+	TrunkOptionsTimeout  = "timeout"  // no response
+	TrunkOptionsError    = "error"    // resolve / send failure
+)
+
+var (
+	labelsTrunkOptionsOK       = map[string]string{"result": TrunkOptionsOK}
+	labelsTrunkOptionsRejected = map[string]string{"result": TrunkOptionsRejected}
+	labelsTrunkOptionsTimeout  = map[string]string{"result": TrunkOptionsTimeout}
+	labelsTrunkOptionsError    = map[string]string{"result": TrunkOptionsError}
+)
+
+func init() {
+	metrics.RegisterLabels(MetricTrunkOptionsTotal, "result")
+	metrics.RegisterLabels(MetricTrunkDown)
+}
+
+// TrunkOptionsResult bumps the OPTIONS probe outcome counter.
+// Unknown results are dropped.
+func TrunkOptionsResult(result string) {
+	var labels map[string]string
+	switch result {
+	case TrunkOptionsOK:
+		labels = labelsTrunkOptionsOK
+	case TrunkOptionsRejected:
+		labels = labelsTrunkOptionsRejected
+	case TrunkOptionsTimeout:
+		labels = labelsTrunkOptionsTimeout
+	case TrunkOptionsError:
+		labels = labelsTrunkOptionsError
+	default:
+		return
+	}
+	metrics.Default.IncCounter(MetricTrunkOptionsTotal,
+		"SIP OPTIONS keep-alive probes toward carrier trunks by outcome", labels)
+}
+
+// TrunkDown publishes how many probed trunks are marked down.
+func TrunkDown(n int) {
+	metrics.Default.SetGauge(MetricTrunkDown,
+		"Probed carrier trunks currently marked down by OPTIONS keep-alives", nil, float64(n))
+}
@@ -65,7 +65,11 @@ func (m *Manager) SendBYE(callID string) error {
 	}
 	leg.byeCSeqNext = cseq + 1
 	branch := randomHex(10)
-	msg := buildBYE(leg.params, leg.byeToHeader, leg.byeRequestURI, cseq, branch)
+	params, toH, reqURI := leg.params, leg.byeToHeader, leg.byeRequestURI
+	msg := buildBYE(params, toH, reqURI, cseq, branch)
+	leg.prepareInDialogLocked(msg, cseq, dst, func(cseq int, branch string) *stack.Message {
+		return buildBYE(params, toH, reqURI, cseq, branch)
+	})
 	// Reuse the same signaling peer as the INVITE so TCP/TLS in-
 	// dialog requests stay on the same connection per RFC 5923. Fall
 	// back to UDP via the shared sender when no peer is bound (e.g.
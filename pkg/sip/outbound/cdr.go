@@ -13,6 +13,7 @@ package outbound
 //        - 1xx (provisional) → no CDR change
 //        - 2xx OK INVITE     → leg.cdrSetAnswered()
 //        - non-2xx final     → leg.cdrSetError(code, reason)
+//        - 3xx redirect      → leg.cdrAddRedirect(original, target)
 //        - 2xx BYE           → leg.cdrSetHangup("local", "normal")
 //   3. CleanupLegIfPresent() (remote BYE) → leg.cdrSetHangup("remote", ...)
 //   4. cleanupLeg() flushes RTCP QoS, then emitCDR() writes one
@@ -28,6 +29,7 @@ import (
 	"sync"
 	"time"
 
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"github.com/LinByte/VoiceServer/pkg/voice/cdr"
 	voiceMetrics "github.com/LinByte/VoiceServer/pkg/voice/metrics"
 	"github.com/LinByte/VoiceServer/pkg/voice/qos"
@@ -132,6 +134,11 @@ type cdrState struct {
 	// errors collects short tags accumulated during the call (e.g.
 	// "dtls-fingerprint-mismatch", "srtp-install-failed").
 	errors []string
+
+	// redirectChain is the Request-URI path through 3xx redirects:
+	// the originally dialled URI first, the final target last. Empty
+	// when the leg was never redirected.
+	redirectChain []string
 }
 
 // cdrSnapshot is a lock-free, point-in-time copy of cdrState. We use a
@@ -149,6 +156,7 @@ type cdrSnapshot struct {
 	reasonClass   string
 	sipFinalCode  int
 	errors        []string
+	redirectChain []string
 }
 
 func (s *cdrState) snapshot() cdrSnapshot {
@@ -168,6 +176,9 @@ func (s *cdrState) snapshot() cdrSnapshot {
 	if len(s.errors) > 0 {
 		out.errors = append([]string(nil), s.errors...)
 	}
+	if len(s.redirectChain) > 0 {
+		out.redirectChain = append([]string(nil), s.redirectChain...)
+	}
 	return out
 }
 
@@ -250,6 +261,19 @@ func (leg *outLeg) cdrAddError(tag string) {
 	leg.cdr.errors = append(leg.cdr.errors, tag)
 }
 
+// cdrAddRedirect records one 3xx retarget from original's chain to uri.
+func (leg *outLeg) cdrAddRedirect(original, uri string) {
+	if leg == nil || uri == "" {
+		return
+	}
+	leg.cdr.mu.Lock()
+	defer leg.cdr.mu.Unlock()
+	if len(leg.cdr.redirectChain) == 0 && original != "" {
+		leg.cdr.redirectChain = append(leg.cdr.redirectChain, original)
+	}
+	leg.cdr.redirectChain = append(leg.cdr.redirectChain, uri)
+}
+
 // emitCDR builds the call record and hands it to the writer. Must
 // be called AFTER flushOutboundCallQoS so the RTCP snapshot is
 // available; reads it directly off rtpSess one more time here to
@@ -290,6 +314,16 @@ func (leg *outLeg) emitCDR() {
 	rec.HangupReason = snap.reasonClass
 	rec.SIPFinalCode = snap.sipFinalCode
 	rec.Errors = snap.errors
+	if n := len(snap.redirectChain); n > 0 {
+		rec.Extra = map[string]any{
+			"redirect_chain":    snap.redirectChain,
+			"final_request_uri": snap.redirectChain[n-1],
+		}
+	}
+	if n, paused := sipSession.RecordingPauseTotals(leg.params.CallID); n > 0 {
+		rec.RecordingPauses = n
+		rec.RecordingPausedMs = paused.Milliseconds()
+	}
 
 	// Per-call QoS. Reading RTCP one more time is cheap (one mutex
 	// take inside RTCPSnapshot) and keeps emitCDR robust against
@@ -0,0 +1,504 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+// UAC digest authentication (RFC 3261 §22.2, RFC 7616 / RFC 8760).
+//
+// Carrier trunks commonly answer the first INVITE with 401 (registrar /
+// UAS) or 407 (proxy) carrying a WWW-Authenticate / Proxy-Authenticate
+// challenge. The leg ACKs the challenge on the original transaction,
+// re-sends the INVITE with CSeq+1 and a fresh branch carrying
+// Authorization / Proxy-Authorization, and the Manager caches the
+// accepted nonce per (username, next hop) so later calls and in-dialog
+// requests (BYE, UPDATE refreshes, REFER) authenticate pre-emptively
+// with an incremented nonce-count instead of eating a round trip.
+//
+// Each request is retried at most once per fresh challenge, plus once
+// more when the server flags the nonce stale=true — a second plain 401
+// means the credentials are wrong and the failure is surfaced as-is.
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
+	"go.uber.org/zap"
+)
+
+// DigestCredentials are the trunk account used to answer 401/407
+// challenges. Realm is optional: when set, challenges for any other
+// realm are ignored (the request fails with the original status).
+type DigestCredentials struct {
+	Username string
+	Password string
+	Realm    string
+}
+
+func (c *DigestCredentials) usable() bool {
+	return c != nil && strings.TrimSpace(c.Username) != "" && c.Password != ""
+}
+
+// digestChallenge is one parsed WWW-Authenticate / Proxy-Authenticate value.
+type digestChallenge struct {
+	proxy     bool   // came from 407 → answer with Proxy-Authorization
+	realm     string // as sent by the server
+	nonce     string
+	opaque    string
+	algorithm string // canonical upper-case: MD5, MD5-SESS, SHA-256, SHA-256-SESS
+	qopAuth   bool   // server offered qop=auth
+	stale     bool
+}
+
+func (ch digestChallenge) headerName() string {
+	if ch.proxy {
+		return "Proxy-Authorization"
+	}
+	return "Authorization"
+}
+
+// parseDigestParams splits a comma separated auth-param list, honouring
+// quoted-strings (qop="auth,auth-int" must stay one value).
+func parseDigestParams(s string) map[string]string {
+	out := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s); i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+					b.WriteByte(s[i])
+					continue
+				}
+				if s[i] == '"' {
+					break
+				}
+				b.WriteByte(s[i])
+			}
+			val = b.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			val = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		out[key] = val
+	}
+	return out
+}
+
+// parseDigestChallenge parses one challenge header value. ok=false for
+// non-Digest schemes, unsupported algorithms or qop lists without "auth"
+// (auth-int only).
+func parseDigestChallenge(v string, proxy bool) (digestChallenge, bool) {
+	v = strings.TrimSpace(v)
+	if len(v) < 7 || !strings.EqualFold(v[:6], "digest") {
+		return digestChallenge{}, false
+	}
+	p := parseDigestParams(v[6:])
+	ch := digestChallenge{
+		proxy:     proxy,
+		realm:     p["realm"],
+		nonce:     p["nonce"],
+		opaque:    p["opaque"],
+		algorithm: strings.ToUpper(strings.TrimSpace(p["algorithm"])),
+		stale:     strings.EqualFold(strings.TrimSpace(p["stale"]), "true"),
+	}
+	if ch.nonce == "" {
+		return digestChallenge{}, false
+	}
+	if ch.algorithm == "" {
+		ch.algorithm = "MD5"
+	}
+	switch ch.algorithm {
+	case "MD5", "MD5-SESS", "SHA-256", "SHA-256-SESS":
+	default:
+		return digestChallenge{}, false
+	}
+	if q, ok := p["qop"]; ok {
+		for _, tok := range strings.Split(q, ",") {
+			if strings.EqualFold(strings.TrimSpace(tok), "auth") {
+				ch.qopAuth = true
+			}
+		}
+		if !ch.qopAuth {
+			return digestChallenge{}, false
+		}
+	}
+	return ch, true
+}
+
+// pickDigestChallenge selects the challenge to answer from a 401/407.
+// RFC 8760 §2.4: when the server offers several algorithms the client
+// uses the strongest it supports, so SHA-256 wins over MD5. realm, when
+// non-empty, restricts the candidates.
+func pickDigestChallenge(resp *stack.Message, realm string) (digestChallenge, bool) {
+	if resp == nil {
+		return digestChallenge{}, false
+	}
+	proxy := resp.StatusCode == 407
+	hdr := "WWW-Authenticate"
+	if proxy {
+		hdr = "Proxy-Authenticate"
+	}
+	realm = strings.TrimSpace(realm)
+	var best digestChallenge
+	found := false
+	for _, v := range resp.GetHeaders(hdr) {
+		ch, ok := parseDigestChallenge(v, proxy)
+		if !ok {
+			continue
+		}
+		if realm != "" && !strings.EqualFold(ch.realm, realm) {
+			continue
+		}
+		if !found || (strings.HasPrefix(ch.algorithm, "SHA-256") && !strings.HasPrefix(best.algorithm, "SHA-256")) {
+			best = ch
+			found = true
+		}
+	}
+	return best, found
+}
+
+func digestHash(algorithm, s string) string {
+	var h hash.Hash
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		h = sha256.New()
+	} else {
+		h = md5.New()
+	}
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// digestResponse computes the request-digest (RFC 7616 §3.4.1).
+func digestResponse(ch digestChallenge, cred DigestCredentials, method, uri, nc, cnonce string) string {
+	ha1 := digestHash(ch.algorithm, cred.Username+":"+ch.realm+":"+cred.Password)
+	if strings.HasSuffix(ch.algorithm, "-SESS") {
+		ha1 = digestHash(ch.algorithm, ha1+":"+ch.nonce+":"+cnonce)
+	}
+	ha2 := digestHash(ch.algorithm, method+":"+uri)
+	if ch.qopAuth {
+		return digestHash(ch.algorithm, ha1+":"+ch.nonce+":"+nc+":"+cnonce+":auth:"+ha2)
+	}
+	return digestHash(ch.algorithm, ha1+":"+ch.nonce+":"+ha2)
+}
+
+// digestAuthorization renders the Authorization / Proxy-Authorization value.
+func digestAuthorization(ch digestChallenge, cred DigestCredentials, method, uri string, nc uint32, cnonce string) string {
+	ncs := fmt.Sprintf("%08x", nc)
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s", algorithm=%s`,
+		cred.Username, ch.realm, ch.nonce, uri, digestResponse(ch, cred, method, uri, ncs, cnonce), ch.algorithm)
+	if ch.qopAuth || strings.HasSuffix(ch.algorithm, "-SESS") {
+		fmt.Fprintf(&b, `, cnonce="%s"`, cnonce)
+	}
+	if ch.qopAuth {
+		fmt.Fprintf(&b, ", qop=auth, nc=%s", ncs)
+	}
+	if ch.opaque != "" {
+		fmt.Fprintf(&b, `, opaque="%s"`, ch.opaque)
+	}
+	return b.String()
+}
+
+func newCNonce() string { return randomHex(8) }
+
+// digestSession is one accepted challenge shared by every request sent
+// to the same next hop with the same account. nc increments per use so
+// the server's replay protection accepts pre-emptive reuse.
+type digestSession struct {
+	mu   sync.Mutex
+	ch   digestChallenge
+	cred DigestCredentials
+	nc   uint32
+}
+
+// authorize stamps msg with the next Authorization / Proxy-Authorization
+// value. The digest-uri is the Request-URI of msg.
+func (s *digestSession) authorize(msg *stack.Message) {
+	if s == nil || msg == nil {
+		return
+	}
+	s.mu.Lock()
+	s.nc++
+	ch, cred, nc := s.ch, s.cred, s.nc
+	s.mu.Unlock()
+	msg.SetHeader(ch.headerName(), digestAuthorization(ch, cred, msg.Method, msg.RequestURI, nc, newCNonce()))
+}
+
+func digestCacheKey(cred *DigestCredentials, dst string) string {
+	if !cred.usable() {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(cred.Username)) + "@" + strings.TrimSpace(dst)
+}
+
+// digestSessionFor returns the cached session for key (nil when none).
+func (m *Manager) digestSessionFor(key string) *digestSession {
+	if m == nil || key == "" {
+		return nil
+	}
+	m.authMu.Lock()
+	defer m.authMu.Unlock()
+	return m.authCache[key]
+}
+
+// storeDigestSession replaces the cached session for key with a fresh
+// one built from ch (nc restarts at 0 for a new nonce).
+func (m *Manager) storeDigestSession(key string, ch digestChallenge, cred DigestCredentials) *digestSession {
+	s := &digestSession{ch: ch, cred: cred}
+	if m == nil || key == "" {
+		return s
+	}
+	m.authMu.Lock()
+	if m.authCache == nil {
+		m.authCache = make(map[string]*digestSession)
+	}
+	m.authCache[key] = s
+	m.authMu.Unlock()
+	return s
+}
+
+// SetTrunkAuthResolver installs the lookup used when a DialTarget carries
+// no Auth: given the next-hop signaling address (host:port) it returns
+// the trunk account to answer 401/407 with. nil disables the fallback.
+func (m *Manager) SetTrunkAuthResolver(fn func(signalingAddr string) (DigestCredentials, bool)) {
+	if m == nil {
+		return
+	}
+	m.authMu.Lock()
+	m.authResolver = fn
+	m.authMu.Unlock()
+}
+
+// digestCredentialsFor resolves credentials for a dial: explicit
+// target credentials first, then the trunk resolver by signaling address.
+func (m *Manager) digestCredentialsFor(target DialTarget, dst string) *DigestCredentials {
+	if target.Auth.usable() {
+		c := *target.Auth
+		return &c
+	}
+	if m == nil {
+		return nil
+	}
+	m.authMu.Lock()
+	fn := m.authResolver
+	m.authMu.Unlock()
+	if fn == nil {
+		return nil
+	}
+	addr := strings.TrimSpace(target.SignalingAddr)
+	if addr == "" {
+		addr = dst
+	}
+	if c, ok := fn(addr); ok && c.usable() {
+		return &c
+	}
+	return nil
+}
+
+// inDialogAuth remembers how to rebuild one in-flight in-dialog request
+// so a 401/407 can be answered with a re-sent copy (new CSeq + branch).
+type inDialogAuth struct {
+	build func(cseq int, branch string) *stack.Message
+	dst   *net.UDPAddr
+	tries int
+}
+
+// authRetryAllowed caps challenge answers: one per fresh nonce, plus a
+// second when the server reports the previous nonce as stale.
+func authRetryAllowed(tries int, ch digestChallenge) bool {
+	if ch.stale {
+		return tries < 2
+	}
+	return tries < 1
+}
+
+// authorizeINVITE stamps the pre-emptive credentials (cached nonce) on
+// the first INVITE and remembers the header so the 2xx ACK can echo it
+// (RFC 3261 §22.1).
+func (leg *outLeg) authorizeINVITE(msg *stack.Message) {
+	if leg == nil || leg.m == nil || msg == nil {
+		return
+	}
+	s := leg.m.digestSessionFor(leg.authKey)
+	if s == nil {
+		return
+	}
+	s.authorize(msg)
+	leg.sigMu.Lock()
+	leg.auth = s
+	leg.inviteAuthName = s.ch.headerName()
+	leg.inviteAuthValue = msg.GetHeader(leg.inviteAuthName)
+	leg.sigMu.Unlock()
+}
+
+// retryINVITEWithAuth answers a 401/407 to the INVITE: ACK the challenge
+// on the original transaction, then re-send the INVITE with CSeq+1, a
+// new branch and credentials. Returns false when the leg has no
+// credentials, the challenge is unusable or the retry budget is spent;
+// the caller then fails the leg with the original status.
+func (leg *outLeg) retryINVITEWithAuth(resp *stack.Message, from *net.UDPAddr) bool {
+	if leg == nil || leg.m == nil || !leg.authCred.usable() {
+		return false
+	}
+	ch, ok := pickDigestChallenge(resp, leg.authCred.Realm)
+	if !ok {
+		return false
+	}
+	m := leg.m
+	leg.sigMu.Lock()
+	if !authRetryAllowed(leg.inviteAuthTries, ch) {
+		leg.sigMu.Unlock()
+		return false
+	}
+	leg.inviteAuthTries++
+	// ACK for a non-2xx final shares the INVITE branch and CSeq
+	// number and targets the original Request-URI (RFC 3261 §17.1.1.3).
+	ack := buildACK(leg.params, resp, leg.params.RequestURI)
+	oldKey := leg.txKey
+	leg.params.CSeq++
+	leg.params.Branch = randomHex(10)
+	leg.txKey = inviteTxKey(leg.params.Branch, leg.params.CSeq)
+	params := leg.params
+	newKey := leg.txKey
+	s := m.storeDigestSession(leg.authKey, ch, *leg.authCred)
+	leg.auth = s
+	leg.sigMu.Unlock()
+
+	if err := leg.sendOnPeer(ack, from); err != nil {
+		logger.Warn("sip outbound ACK for auth challenge failed",
+			zap.String("call_id", params.CallID), zap.Error(err))
+	}
+	m.mu.Lock()
+	if oldKey != "" {
+		delete(m.legsByTx, oldKey)
+	}
+	if newKey != "" {
+		m.legsByTx[newKey] = leg
+	}
+	m.mu.Unlock()
+	// A new INVITE transaction: CANCEL must wait for its own 1xx again.
+	leg.gotProvisional.Store(false)
+
+	invite := buildINVITE(params)
+	s.authorize(invite)
+	leg.sigMu.Lock()
+	leg.inviteAuthName = ch.headerName()
+	leg.inviteAuthValue = invite.GetHeader(leg.inviteAuthName)
+	leg.sigMu.Unlock()
+	if err := leg.sendOnPeer(invite, leg.dst); err != nil {
+		logger.Warn("sip outbound authenticated INVITE send failed",
+			zap.String("call_id", params.CallID), zap.Error(err))
+		return false
+	}
+	logger.Info("sip outbound INVITE re-sent with credentials",
+		zap.String("call_id", params.CallID),
+		zap.Int("challenge_status", resp.StatusCode),
+		zap.String("realm", ch.realm),
+		zap.String("algorithm", ch.algorithm),
+		zap.Bool("stale", ch.stale),
+		zap.Int("cseq", params.CSeq),
+	)
+	return true
+}
+
+// prepareInDialogLocked stamps cached credentials on an in-dialog
+// request (BYE, UPDATE, REFER) and records how to rebuild it in case the
+// peer challenges anyway. Caller holds leg.sigMu.
+func (leg *outLeg) prepareInDialogLocked(msg *stack.Message, cseq int, dst *net.UDPAddr, build func(cseq int, branch string) *stack.Message) {
+	if leg == nil || msg == nil {
+		return
+	}
+	if len(leg.routeSet) > 0 {
+		transaction.SetRouteHeaders(msg, leg.routeSet)
+	}
+	if leg.auth == nil && leg.m != nil {
+		leg.auth = leg.m.digestSessionFor(leg.authKey)
+	}
+	if leg.auth != nil {
+		leg.auth.authorize(msg)
+	}
+	if !leg.authCred.usable() || build == nil {
+		return
+	}
+	if leg.authPending == nil {
+		leg.authPending = make(map[int]*inDialogAuth)
+	}
+	leg.authPending[cseq] = &inDialogAuth{build: build, dst: dst}
+}
+
+// handleInDialogAuth consumes the final response to an in-dialog
+// request. On 401/407 with a usable challenge it re-sends the request
+// with credentials and returns true (the response is fully handled).
+func (leg *outLeg) handleInDialogAuth(resp *stack.Message) bool {
+	if leg == nil || resp == nil || resp.StatusCode < 200 {
+		return false
+	}
+	cseq := stack.ParseCSeqNum(strings.TrimSpace(resp.GetHeader("CSeq")))
+	leg.sigMu.Lock()
+	p := leg.authPending[cseq]
+	delete(leg.authPending, cseq)
+	if p == nil || (resp.StatusCode != 401 && resp.StatusCode != 407) {
+		leg.sigMu.Unlock()
+		return false
+	}
+	ch, ok := pickDigestChallenge(resp, leg.authCred.Realm)
+	if !ok || !authRetryAllowed(p.tries, ch) {
+		leg.sigMu.Unlock()
+		return false
+	}
+	next := leg.byeCSeqNext
+	if next <= cseq {
+		next = cseq + 1
+	}
+	leg.byeCSeqNext = next + 1
+	s := leg.m.storeDigestSession(leg.authKey, ch, *leg.authCred)
+	leg.auth = s
+	msg := p.build(next, randomHex(10))
+	if len(leg.routeSet) > 0 {
+		transaction.SetRouteHeaders(msg, leg.routeSet)
+	}
+	s.authorize(msg)
+	leg.authPending[next] = &inDialogAuth{build: p.build, dst: p.dst, tries: p.tries + 1}
+	leg.sigMu.Unlock()
+
+	if err := leg.sendOnPeer(msg, p.dst); err != nil {
+		logger.Warn("sip outbound authenticated in-dialog request send failed",
+			zap.String("call_id", leg.params.CallID),
+			zap.String("method", msg.Method),
+			zap.Error(err))
+		return true
+	}
+	logger.Info("sip outbound in-dialog request re-sent with credentials",
+		zap.String("call_id", leg.params.CallID),
+		zap.String("method", msg.Method),
+		zap.Int("challenge_status", resp.StatusCode),
+		zap.Int("cseq", next),
+	)
+	return true
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestDigestResponse_RFCVectors(t *testing.T) {
+	// RFC 2617 §3.5.
+	md5ch := digestChallenge{realm: "testrealm@host.com", nonce: "dcd98b7102dd2f0e8b11d0f600bfb0c093", algorithm: "MD5", qopAuth: true}
+	got := digestResponse(md5ch, DigestCredentials{Username: "Mufasa", Password: "Circle Of Life"},
+		"GET", "/dir/index.html", "00000001", "0a4f113b")
+	if got != "6629fae49393a05397450978507c4ef1" {
+		t.Errorf("MD5 response = %s", got)
+	}
+	// RFC 7616 §3.9.1.
+	shach := digestChallenge{realm: "http-auth@example.org", nonce: "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", algorithm: "SHA-256", qopAuth: true}
+	got = digestResponse(shach, DigestCredentials{Username: "Mufasa", Password: "Circle of Life"},
+		"GET", "/dir/index.html", "00000001", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ")
+	if got != "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1" {
+		t.Errorf("SHA-256 response = %s", got)
+	}
+}
+
+func TestPickDigestChallenge(t *testing.T) {
+	resp := &stack.Message{StatusCode: 401}
+	resp.AddHeader("WWW-Authenticate", `Digest realm="carrier", nonce="n1", algorithm=MD5, qop="auth,auth-int"`)
+	resp.AddHeader("WWW-Authenticate", `Digest realm="carrier", nonce="n2", algorithm=SHA-256, qop="auth", opaque="op", stale=TRUE`)
+	ch, ok := pickDigestChallenge(resp, "")
+	if !ok || ch.algorithm != "SHA-256" || ch.nonce != "n2" || !ch.qopAuth || !ch.stale || ch.opaque != "op" || ch.proxy {
+		t.Fatalf("picked %+v ok=%v", ch, ok)
+	}
+	if _, ok := pickDigestChallenge(resp, "other"); ok {
+		t.Fatal("realm filter should reject carrier challenges")
+	}
+	only := &stack.Message{StatusCode: 407}
+	only.SetHeader("Proxy-Authenticate", `Digest realm="p", nonce="x", qop="auth-int"`)
+	if _, ok := pickDigestChallenge(only, ""); ok {
+		t.Fatal("auth-int only challenge is unsupported")
+	}
+	only.SetHeader("Proxy-Authenticate", `Digest realm="p", nonce="x"`)
+	if ch, ok := pickDigestChallenge(only, ""); !ok || !ch.proxy || ch.headerName() != "Proxy-Authorization" || ch.algorithm != "MD5" {
+		t.Fatalf("407 challenge: %+v ok=%v", ch, ok)
+	}
+}
+
+type capturePeer struct {
+	mu   sync.Mutex
+	sent []*stack.Message
+}
+
+func (p *capturePeer) Send(msg *stack.Message) error {
+	p.mu.Lock()
+	p.sent = append(p.sent, msg)
+	p.mu.Unlock()
+	return nil
+}
+func (p *capturePeer) Remote() *net.UDPAddr { return nil }
+func (p *capturePeer) Transport() Transport { return TransportUDP }
+func (p *capturePeer) Close() error         { return nil }
+
+func (p *capturePeer) take() []*stack.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := p.sent
+	p.sent = nil
+	return out
+}
+
+func newAuthTestLeg(m *Manager, peer signalingPeer, callID string) *outLeg {
+	cred := &DigestCredentials{Username: "trunk01", Password: "secret"}
+	dst := &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5060}
+	leg := &outLeg{
+		m: m,
+		params: inviteParams{
+			CallID:     callID,
+			FromUser:   "4001",
+			FromTag:    "ft",
+			SIPHost:    "192.0.2.1",
+			SIPPort:    5060,
+			RequestURI: "sip:13800138000@192.0.2.10",
+			Branch:     "b1",
+			CSeq:       1,
+		},
+		dst:      dst,
+		peer:     peer,
+		authCred: cred,
+		authKey:  digestCacheKey(cred, dst.String()),
+	}
+	leg.txKey = inviteTxKey(leg.params.Branch, leg.params.CSeq)
+	m.mu.Lock()
+	m.legs[callID] = leg
+	m.legsByTx[leg.txKey] = leg
+	m.mu.Unlock()
+	return leg
+}
+
+func challenge(status int, cseq string, hdr string) *stack.Message {
+	resp := &stack.Message{StatusCode: status, StatusText: "Unauthorized", Version: "SIP/2.0"}
+	resp.SetHeader("CSeq", cseq)
+	resp.SetHeader("To", "<sip:13800138000@192.0.2.10>;tag=uas")
+	if status == 407 {
+		resp.SetHeader("Proxy-Authenticate", hdr)
+	} else {
+		resp.SetHeader("WWW-Authenticate", hdr)
+	}
+	return resp
+}
+
+func TestINVITEChallenge_ReINVITEAndNonceReuse(t *testing.T) {
+	m := NewManager(ManagerConfig{})
+	peer := &capturePeer{}
+	leg := newAuthTestLeg(m, peer, "c1@test")
+
+	leg.handleResponse(context.Background(), challenge(401, "1 INVITE", `Digest realm="carrier", nonce="abc", qop="auth"`), nil)
+	sent := peer.take()
+	if len(sent) != 2 || sent[0].Method != stack.MethodAck || sent[1].Method != stack.MethodInvite {
+		t.Fatalf("want ACK + INVITE, got %d messages", len(sent))
+	}
+	if got := sent[0].GetHeader("CSeq"); got != "1 ACK" || !strings.Contains(sent[0].GetHeader("Via"), "z9hG4bKb1") {
+		t.Errorf("ACK must reuse the challenged transaction: cseq=%q via=%q", got, sent[0].GetHeader("Via"))
+	}
+	inv := sent[1]
+	if inv.GetHeader("CSeq") != "2 INVITE" || strings.Contains(inv.GetHeader("Via"), "z9hG4bKb1;") {
+		t.Errorf("re-INVITE needs CSeq+1 and a new branch: %q %q", inv.GetHeader("CSeq"), inv.GetHeader("Via"))
+	}
+	auth := inv.GetHeader("Authorization")
+	if !strings.Contains(auth, `username="trunk01"`) || !strings.Contains(auth, "nc=00000001") || !strings.Contains(auth, `uri="sip:13800138000@192.0.2.10"`) {
+		t.Errorf("Authorization = %q", auth)
+	}
+	m.mu.Lock()
+	_, oldKey := m.legsByTx[inviteTxKey("b1", 1)]
+	_, newKey := m.legsByTx[leg.txKey]
+	m.mu.Unlock()
+	if oldKey || !newKey {
+		t.Error("leg must be re-keyed to the new INVITE transaction")
+	}
+
+	// Same challenge again (wrong password): no second retry.
+	leg.handleResponse(context.Background(), challenge(401, "2 INVITE", `Digest realm="carrier", nonce="def", qop="auth"`), nil)
+	if n := len(peer.take()); n != 0 {
+		t.Fatalf("second plain challenge must not be retried, sent %d", n)
+	}
+
+	// Next call to the same trunk authenticates pre-emptively with nc=2.
+	peer2 := &capturePeer{}
+	leg2 := newAuthTestLeg(m, peer2, "c2@test")
+	invite := buildINVITE(leg2.params)
+	leg2.authorizeINVITE(invite)
+	if auth := invite.GetHeader("Authorization"); !strings.Contains(auth, `nonce="abc"`) || !strings.Contains(auth, "nc=00000002") {
+		t.Errorf("pre-emptive Authorization = %q", auth)
+	}
+}
+
+func TestInDialogChallenge_BYEAndREFER(t *testing.T) {
+	m := NewManager(ManagerConfig{})
+	m.send = func(*stack.Message, *net.UDPAddr) error { return nil }
+	peer := &capturePeer{}
+	leg := newAuthTestLeg(m, peer, "c3@test")
+	leg.byeToHeader = "<sip:13800138000@192.0.2.10>;tag=uas"
+	leg.byeRequestURI = "sip:13800138000@192.0.2.10:5060"
+	leg.byeCSeqNext = 2
+
+	if err := m.SendBYE("c3@test"); err != nil {
+		t.Fatal(err)
+	}
+	if bye := peer.take(); len(bye) != 1 || bye[0].GetHeader("Proxy-Authorization") != "" {
+		t.Fatal("first BYE goes out without credentials when nothing is cached")
+	}
+	leg.handleResponse(context.Background(), challenge(407, "2 BYE", `Digest realm="proxy", nonce="p1", algorithm=SHA-256`), nil)
+	sent := peer.take()
+	if len(sent) != 1 || sent[0].Method != stack.MethodBye || sent[0].GetHeader("CSeq") != "3 BYE" {
+		t.Fatalf("want re-sent BYE with CSeq 3, got %+v", sent)
+	}
+	if auth := sent[0].GetHeader("Proxy-Authorization"); !strings.Contains(auth, "algorithm=SHA-256") || strings.Contains(auth, "qop=") {
+		t.Errorf("Proxy-Authorization = %q", auth)
+	}
+
+	// REFER on the same dialog carries the cached credentials up front.
+	if err := m.SendREFER("c3@test", "sip:8001@192.0.2.20"); err != nil {
+		t.Fatal(err)
+	}
+	refer := peer.take()
+	if len(refer) != 1 || refer[0].Method != stack.MethodRefer || refer[0].GetHeader("Proxy-Authorization") == "" {
+		t.Fatalf("REFER should be pre-authorised: %+v", refer)
+	}
+	if refer[0].GetHeader("Refer-To") != "<sip:8001@192.0.2.20>" {
+		t.Errorf("Refer-To = %q", refer[0].GetHeader("Refer-To"))
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+// systemDNSResolver backs the RFC 3263 locator in production. The
+// standard library has no NAPTR lookup and hides TTLs, so NAPTR / SRV
+// go straight to the nameservers in /etc/resolv.conf (UDP, TCP on
+// truncation). A/AAAA stay on net.DefaultResolver so /etc/hosts and
+// the platform resolver keep working; they are cached for a fixed
+// systemIPTTL.
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	systemIPTTL      = time.Minute
+	dnsQueryTimeout  = 3 * time.Second
+	resolvConfPath   = "/etc/resolv.conf"
+	dnsTypeNAPTRCode = dnsmessage.Type(35)
+)
+
+type systemDNSResolver struct {
+	servers []string
+}
+
+func newSystemDNSResolver() *systemDNSResolver {
+	return &systemDNSResolver{servers: readResolvConf(resolvConfPath)}
+}
+
+func readResolvConf(path string) []string {
+	var out []string
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			fields := strings.Fields(sc.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				out = append(out, net.JoinHostPort(strings.Split(fields[1], "%")[0], "53"))
+			}
+		}
+	}
+	if len(out) == 0 {
+		out = []string{"127.0.0.1:53"}
+	}
+	return out
+}
+
+func (r *systemDNSResolver) LookupIP(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+	return ips, systemIPTTL, nil
+}
+
+func (r *systemDNSResolver) LookupSRV(ctx context.Context, name string) ([]SRVRecord, time.Duration, error) {
+	var out []SRVRecord
+	ttl, err := r.query(ctx, name, dnsmessage.TypeSRV, func(p *dnsmessage.Parser, h dnsmessage.ResourceHeader) error {
+		rr, err := p.SRVResource()
+		if err != nil {
+			return err
+		}
+		out = append(out, SRVRecord{
+			Target:   rr.Target.String(),
+			Port:     rr.Port,
+			Priority: rr.Priority,
+			Weight:   rr.Weight,
+		})
+		return nil
+	})
+	return out, ttl, err
+}
+
+func (r *systemDNSResolver) LookupNAPTR(ctx context.Context, name string) ([]NAPTRRecord, time.Duration, error) {
+	var out []NAPTRRecord
+	ttl, err := r.query(ctx, name, dnsTypeNAPTRCode, func(p *dnsmessage.Parser, h dnsmessage.ResourceHeader) error {
+		rr, err := p.UnknownResource()
+		if err != nil {
+			return err
+		}
+		n, err := parseNAPTRData(rr.Data)
+		if err != nil {
+			return err
+		}
+		out = append(out, n)
+		return nil
+	})
+	return out, ttl, err
+}
+
+// query sends one question and feeds every matching answer to each.
+// It returns the smallest TTL seen; NXDOMAIN is an empty answer.
+func (r *systemDNSResolver) query(ctx context.Context, name string, qtype dnsmessage.Type,
+	each func(*dnsmessage.Parser, dnsmessage.ResourceHeader) error) (time.Duration, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return 0, err
+	}
+	id := uint16(rand.UintN(1 << 16))
+	q := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := q.Pack()
+	if err != nil {
+		return 0, err
+	}
+	var lastErr error
+	for _, server := range r.servers {
+		resp, err := exchangeDNS(ctx, server, packed, id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return parseDNSAnswers(resp, qtype, each)
+	}
+	return 0, lastErr
+}
+
+func exchangeDNS(ctx context.Context, server string, packed []byte, id uint16) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var h dnsmessage.Header
+		var p dnsmessage.Parser
+		if h, err = p.Start(buf[:n]); err != nil || h.ID != id {
+			continue
+		}
+		if !h.Truncated {
+			return buf[:n], nil
+		}
+		break
+	}
+	// Truncated: retry over TCP (2-byte length prefix).
+	tc, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer tc.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = tc.SetDeadline(dl)
+	}
+	msg := make([]byte, 2+len(packed))
+	binary.BigEndian.PutUint16(msg, uint16(len(packed)))
+	copy(msg[2:], packed)
+	if _, err := tc.Write(msg); err != nil {
+		return nil, err
+	}
+	var lenBuf [2]byte
+	if _, err := io.ReadFull(tc, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	out := make([]byte, binary.BigEndian.Uint16(lenBuf[:]))
+	if _, err := io.ReadFull(tc, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func parseDNSAnswers(resp []byte, qtype dnsmessage.Type,
+	each func(*dnsmessage.Parser, dnsmessage.ResourceHeader) error) (time.Duration, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(resp)
+	if err != nil {
+		return 0, err
+	}
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("dns rcode %s", h.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return 0, err
+	}
+	var ttl time.Duration
+	for {
+		rh, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if rh.Type != qtype {
+			if err := p.SkipAnswer(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err := each(&p, rh); err != nil {
+			return 0, err
+		}
+		if d := time.Duration(rh.TTL) * time.Second; ttl == 0 || d < ttl {
+			ttl = d
+		}
+	}
+	return ttl, nil
+}
+
+// parseNAPTRData decodes RFC 3403 §4.1 RDATA. The replacement name is
+// never compressed, so it is read label by label.
+func parseNAPTRData(b []byte) (NAPTRRecord, error) {
+	var n NAPTRRecord
+	if len(b) < 4 {
+		return n, errors.New("naptr: short rdata")
+	}
+	n.Order = binary.BigEndian.Uint16(b[0:2])
+	n.Preference = binary.BigEndian.Uint16(b[2:4])
+	off := 4
+	strs := make([]string, 3)
+	for i := range strs {
+		if off >= len(b) || off+1+int(b[off]) > len(b) {
+			return n, errors.New("naptr: bad character-string")
+		}
+		l := int(b[off])
+		strs[i] = string(b[off+1 : off+1+l])
+		off += 1 + l
+	}
+	n.Flags, n.Service, n.Regexp = strs[0], strs[1], strs[2]
+	var labels []string
+	for {
+		if off >= len(b) {
+			return n, errors.New("naptr: bad replacement")
+		}
+		l := int(b[off])
+		off++
+		if l == 0 {
+			break
+		}
+		if off+l > len(b) {
+			return n, errors.New("naptr: bad replacement label")
+		}
+		labels = append(labels, string(b[off:off+l]))
+		off += l
+	}
+	n.Replacement = strings.Join(labels, ".")
+	return n, nil
+}
@@ -4,11 +4,14 @@
 package outbound
 
 import (
+	"context"
+	"net"
 	"strings"
 	"testing"
 
 	"github.com/LinByte/VoiceServer/pkg/sip/rtp"
 	"github.com/LinByte/VoiceServer/pkg/sip/sdp"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
 )
 
 func TestPrepareOutboundDTLSOffer_ReturnsCoherentMaterial(t *testing.T) {
@@ -141,3 +144,50 @@ func TestStartOutboundDTLSHandshake_NilSafe(t *testing.T) {
 	startOutboundDTLSHandshake(nil, nil, nil)
 	startOutboundDTLSHandshake(&outLeg{}, nil, &sdp.Info{})
 }
+
+func TestDial_WebSocketTargetOffersICELiteDTLS(t *testing.T) {
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.100", SIPPort: 5060})
+	ua := &fakeRegistrar{}
+	ua.reply = func(*stack.Message, *net.UDPAddr) *stack.Message { return nil }
+	ua.bind(m)
+
+	// Transfer-bridge legs normally force RTP/AVP; a browser can't take it.
+	callID, err := m.Dial(context.Background(), DialRequest{
+		Scenario:     ScenarioTransferAgent,
+		MediaProfile: MediaProfileTransferBridge,
+		Target: DialTarget{
+			RequestURI:    "sip:k3j2@df7jal23ls0d.invalid;transport=wss",
+			SignalingAddr: "198.51.100.9:51234",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.AbandonEarlyTransferInvite(callID)
+
+	waitSent(ua, 1)
+	sent, dsts := ua.requests()
+	if len(sent) == 0 {
+		t.Fatal("no INVITE sent")
+	}
+	inv := sent[0]
+	if dsts[0] != "198.51.100.9:51234" {
+		t.Errorf("sent to %s, want the registered flow", dsts[0])
+	}
+	if via := inv.GetHeader("Via"); !strings.HasPrefix(via, "SIP/2.0/WSS ") {
+		t.Errorf("Via = %q", via)
+	}
+	info, err := sdp.Parse(inv.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Proto != "UDP/TLS/RTP/SAVPF" || !info.HasICE() || !info.RTCPMux || len(info.Fingerprints) != 1 {
+		t.Fatalf("offer:\n%s", inv.Body)
+	}
+	if !strings.Contains(inv.Body, "a=ice-lite") || strings.Contains(inv.Body, "a=crypto:") {
+		t.Fatalf("offer:\n%s", inv.Body)
+	}
+	if len(info.NACKPayloadTypes) == 0 || strings.Count(inv.Body, "a=rtcp-mux") != 1 {
+		t.Fatalf("offer lacks rtcp-fb nack or repeats rtcp-mux:\n%s", inv.Body)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/sdp"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"go.uber.org/zap"
+)
+
+// RFC 3960 early media (gateway model). Carriers play ringback, "the
+// number you dialled…" announcements and queue music on a 18x with SDP
+// before the final answer. For DialRequest.EarlyMedia legs the first
+// such SDP points the leg's RTP socket at the far end and a provisional
+// CallSession is handed to ManagerConfig.OnEarlyMedia, so a live agent
+// hears the progress tones instead of silence. Later 18x SDPs are
+// ignored (no forking support); the 200 OK answer re-targets the socket
+// as usual. Encrypted offers (SDES / DTLS) are skipped: their keys are
+// only applied on the final answer.
+
+func (leg *outLeg) startEarlyMedia(resp *stack.Message, from *net.UDPAddr) {
+	if !leg.req.EarlyMedia || leg.m.cfg.OnEarlyMedia == nil || resp == nil {
+		return
+	}
+	if resp.StatusCode <= 100 || strings.TrimSpace(resp.Body) == "" {
+		return
+	}
+	if leg.dtlsPending != nil || len(leg.srtpOfferKey) > 0 {
+		return
+	}
+	if !leg.earlyMedia.CompareAndSwap(false, true) {
+		return
+	}
+	cs, err := leg.earlyMediaSession(resp.Body, from)
+	if err != nil {
+		logger.Warn("sip outbound early media ignored",
+			zap.String("call_id", leg.params.CallID),
+			zap.Int("status", resp.StatusCode),
+			zap.Error(err))
+		return
+	}
+	logger.Info("sip outbound early media",
+		zap.String("call_id", leg.params.CallID),
+		zap.Int("status", resp.StatusCode),
+		zap.String("codec", cs.NegotiatedCodec().Name),
+		zap.String("correlation_id", strings.TrimSpace(leg.req.CorrelationID)),
+	)
+	leg.m.cfg.OnEarlyMedia(EstablishedLeg{
+		CallID:              leg.params.CallID,
+		Scenario:            leg.req.Scenario,
+		CorrelationID:       leg.req.CorrelationID,
+		Session:             cs,
+		CreatedAt:           time.Now(),
+		ToHeader:            leg.params.RequestURI,
+		RemoteSignalingAddr: udpAddrString(from),
+		CSeqInvite:          fmt.Sprintf("%d INVITE", leg.params.CSeq),
+	})
+}
+
+func (leg *outLeg) earlyMediaSession(body string, from *net.UDPAddr) (*sipSession.CallSession, error) {
+	answer, err := sdp.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if sdp.IsDTLSTransport(answer.Proto) {
+		return nil, fmt.Errorf("encrypted early media (%s)", answer.Proto)
+	}
+	remoteIP := net.ParseIP(answer.IP)
+	if remoteIP == nil || answer.Port <= 0 {
+		return nil, fmt.Errorf("invalid RTP address %q:%d", answer.IP, answer.Port)
+	}
+	remoteRTP, _ := answerRTPAddr(remoteIP, answer.Port, from)
+	leg.rtpSess.SetRemoteAddr(remoteRTP)
+	cs, err := sipSession.NewCallSession(leg.params.CallID, leg.rtpSess, answer.Codecs)
+	if err != nil {
+		return nil, err
+	}
+	cs.SetTenantID(leg.req.DialTenantID)
+	return cs, nil
+}
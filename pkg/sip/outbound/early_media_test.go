@@ -0,0 +1,110 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+const earlyMediaSDP = "v=0\r\n" +
+	"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+	"s=-\r\n" +
+	"c=IN IP4 192.0.2.1\r\n" +
+	"t=0 0\r\n" +
+	"m=audio 40000 RTP/AVP 8\r\n" +
+	"a=rtpmap:8 PCMA/8000\r\n"
+
+func TestDial_EarlyMediaOnce(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		early []EstablishedLeg
+	)
+	m := NewManager(ManagerConfig{
+		SIPHost: "192.0.2.100",
+		SIPPort: 5060,
+		OnEarlyMedia: func(leg EstablishedLeg) {
+			mu.Lock()
+			early = append(early, leg)
+			mu.Unlock()
+		},
+	})
+	carrier := &fakeRegistrar{}
+	carrier.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if req.Method != stack.MethodInvite {
+			return nil
+		}
+		resp := registerResponse(req, 183)
+		resp.SetHeader("Content-Type", "application/sdp")
+		resp.Body = earlyMediaSDP
+		return resp
+	}
+	carrier.bind(m)
+
+	callID, err := m.Dial(context.Background(), DialRequest{
+		Scenario:     ScenarioWebSeatOutbound,
+		MediaProfile: MediaProfileNone,
+		EarlyMedia:   true,
+		Target: DialTarget{
+			RequestURI:    "sip:13800138000@192.0.2.1:5060",
+			SignalingAddr: "192.0.2.1:5060",
+		},
+		CorrelationID: "ws-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.AbandonEarlyTransferInvite(callID)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(early)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.mu.Lock()
+	leg := m.legs[callID]
+	m.mu.Unlock()
+	if leg == nil {
+		t.Fatal("leg not tracked")
+	}
+	// A second 18x with SDP is ignored.
+	again := &stack.Message{StatusCode: 180, Body: earlyMediaSDP}
+	leg.startEarlyMedia(again, nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(early) != 1 {
+		t.Fatalf("early media callbacks = %d, want 1", len(early))
+	}
+	got := early[0]
+	if got.CallID != callID || got.CorrelationID != "ws-1" || got.Scenario != ScenarioWebSeatOutbound {
+		t.Errorf("early leg = %+v", got)
+	}
+	if got.Session == nil || got.Session.NegotiatedCodec().Name != "pcma" {
+		t.Fatalf("early session codec: %+v", got.Session)
+	}
+	if ra := leg.rtpSess.RemoteAddr; ra == nil || ra.String() != "192.0.2.1:40000" {
+		t.Errorf("rtp remote = %v", ra)
+	}
+}
+
+func TestStartEarlyMedia_RequiresOptIn(t *testing.T) {
+	called := false
+	m := NewManager(ManagerConfig{OnEarlyMedia: func(EstablishedLeg) { called = true }})
+	leg := &outLeg{m: m, req: DialRequest{Scenario: ScenarioClickToCall}}
+	leg.startEarlyMedia(&stack.Message{StatusCode: 183, Body: earlyMediaSDP}, nil)
+	if called || leg.earlyMedia.Load() {
+		t.Fatal("early media applied without DialRequest.EarlyMedia")
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"go.uber.org/zap"
+)
+
+// RFC 3263 §4.3 failover for outbound INVITEs. Dial keeps every hop the
+// locator returned; when the current hop answers 503 or stays silent
+// (no response at all, not even 100 Trying, within the hop timeout)
+// it is blacklisted and the INVITE is re-sent to the next hop with a
+// new branch and CSeq+1. Once a hop has answered with anything the
+// call is bound to it.
+//
+// Above that sits trunk failover: when the whole trunk is unusable (no
+// hop answered, or a final 408 / 5xx) and DialRequest.Fallbacks has
+// another member, the INVITE moves to that member on the same Call-ID
+// and From tag. The dial gate runs again so the member's capacity is
+// reserved, and DialEventRerouted tells the routing layer which trunk
+// failed.
+
+// defaultHopTimeout is the silence budget per hop before failing over;
+// SIP_OUTBOUND_HOP_TIMEOUT_MS overrides. Only armed when another hop
+// or fallback trunk remains, so single-address targets behave exactly
+// as before.
+const defaultHopTimeout = 4 * time.Second
+
+func hopTimeout() time.Duration {
+	if ms, ok := envInt("SIP_OUTBOUND_HOP_TIMEOUT_MS"); ok && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return defaultHopTimeout
+}
+
+// SetDNSResolver replaces the resolver behind RFC 3263 target lookup
+// (nil → system DNS). Drops the lookup cache and blacklist.
+func (m *Manager) SetDNSResolver(res DNSResolver) {
+	if m == nil {
+		return
+	}
+	m.locatorMu.Lock()
+	m.locator = NewLocator(res)
+	m.locatorMu.Unlock()
+}
+
+// Locator returns the manager's RFC 3263 locator (created lazily).
+func (m *Manager) Locator() *Locator {
+	if m == nil {
+		return nil
+	}
+	m.locatorMu.Lock()
+	defer m.locatorMu.Unlock()
+	if m.locator == nil {
+		m.locator = NewLocator(m.cfg.DNSResolver)
+	}
+	return m.locator
+}
+
+// retryAfter reads Retry-After seconds from a 503 (0 when absent).
+func retryAfter(resp *stack.Message) time.Duration {
+	v := strings.TrimSpace(resp.GetHeader("Retry-After"))
+	if i := strings.IndexAny(v, " ;("); i >= 0 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// hasNextHopLocked reports whether hop failover is still possible. Caller holds sigMu.
+func (leg *outLeg) hasNextHopLocked() bool {
+	return !leg.hopBound && leg.hopIdx+1 < len(leg.hops)
+}
+
+// hasNextTrunkLocked reports whether a fallback trunk remains. Caller holds sigMu.
+func (leg *outLeg) hasNextTrunkLocked() bool {
+	return leg.trunkIdx < len(leg.req.Fallbacks)
+}
+
+// trunkFailoverStatus: final responses that condemn the trunk rather
+// than the callee (busy / decline / not found stay with the callee).
+func trunkFailoverStatus(st int) bool {
+	return st == 408 || (st >= 500 && st < 600)
+}
+
+// armHopTimer starts the silence timer for the INVITE currently in
+// flight (identified by txKey) when another hop remains.
+func (leg *outLeg) armHopTimer() {
+	leg.sigMu.Lock()
+	defer leg.sigMu.Unlock()
+	if leg.hopBound || (!leg.hasNextHopLocked() && !leg.hasNextTrunkLocked()) {
+		return
+	}
+	if leg.hopTimer != nil {
+		leg.hopTimer.Stop()
+	}
+	txKey := leg.txKey
+	leg.hopTimer = time.AfterFunc(hopTimeout(), func() {
+		leg.failoverINVITE(nil, nil, txKey)
+	})
+}
+
+// hopINVITEResponse runs for every INVITE response before the normal
+// handling. A 503 on the current transaction fails over when another
+// hop remains (handled=true); any other response for the current
+// transaction pins the leg to its hop. Responses for a transaction
+// already failed away from are swallowed.
+func (leg *outLeg) hopINVITEResponse(resp *stack.Message, from *net.UDPAddr) (handled bool) {
+	key := txKeyFromResponse(resp)
+	leg.sigMu.Lock()
+	cur, multi := leg.txKey, len(leg.hops) > 1 || len(leg.req.Fallbacks) > 0 || leg.redirect.count > 0
+	leg.sigMu.Unlock()
+	if !multi {
+		return false
+	}
+	if key != "" && key != cur {
+		return true
+	}
+	if resp.StatusCode == 503 && leg.failoverINVITE(resp, from, cur) {
+		return true
+	}
+	leg.bindHop()
+	return false
+}
+
+// bindHop pins the leg to its current hop once any response for the
+// INVITE arrives; no failover happens after that.
+func (leg *outLeg) bindHop() {
+	leg.sigMu.Lock()
+	leg.hopBound = true
+	if leg.hopTimer != nil {
+		leg.hopTimer.Stop()
+		leg.hopTimer = nil
+	}
+	leg.sigMu.Unlock()
+}
+
+// failoverINVITE moves the INVITE to the next hop. resp is the 503
+// (nil on timeout); txKey guards against a timer firing for an
+// INVITE that was already answered or re-sent. Returns false when no
+// hop is left, so the caller fails the leg as usual.
+func (leg *outLeg) failoverINVITE(resp *stack.Message, from *net.UDPAddr, txKey string) bool {
+	if leg == nil || leg.m == nil {
+		return false
+	}
+	m := leg.m
+	leg.sigMu.Lock()
+	if txKey != leg.txKey {
+		leg.sigMu.Unlock()
+		return false
+	}
+	if !leg.hasNextHopLocked() {
+		leg.sigMu.Unlock()
+		if resp == nil {
+			// Silent on every hop of this trunk.
+			m.Locator().Blacklist(leg.currentHop(), 0)
+			return leg.switchTrunk(txKey, 0)
+		}
+		return false
+	}
+	if leg.hopTimer != nil {
+		leg.hopTimer.Stop()
+		leg.hopTimer = nil
+	}
+	failed := leg.hops[leg.hopIdx]
+	var ack *stack.Message
+	if resp != nil {
+		// ACK the 503 on its own transaction (RFC 3261 §17.1.1.3).
+		ack = buildACK(leg.params, resp, leg.params.RequestURI)
+	}
+	leg.sigMu.Unlock()
+
+	reason, ban := "timeout", time.Duration(0)
+	if resp != nil {
+		reason, ban = strconv.Itoa(resp.StatusCode), retryAfter(resp)
+		if err := leg.sendOnPeer(ack, from); err != nil {
+			logger.Warn("sip outbound ACK for 503 failed",
+				zap.String("call_id", leg.params.CallID), zap.Error(err))
+		}
+	}
+	loc := m.Locator()
+	loc.Blacklist(failed, ban)
+
+	for {
+		leg.sigMu.Lock()
+		if !leg.hasNextHopLocked() {
+			leg.sigMu.Unlock()
+			st := 0
+			if resp != nil {
+				st = resp.StatusCode
+			}
+			return leg.switchTrunk(leg.txKey, st)
+		}
+		leg.hopIdx++
+		next := leg.hops[leg.hopIdx]
+		leg.sigMu.Unlock()
+
+		peer, err := m.peerForHop(next)
+		if err != nil {
+			logger.Warn("sip outbound failover hop unreachable",
+				zap.String("call_id", leg.params.CallID),
+				zap.String("hop", next.String()),
+				zap.Error(err))
+			loc.Blacklist(next, 0)
+			continue
+		}
+		if leg.resendINVITEToHop(next, peer) {
+			logger.Info("sip outbound INVITE failed over",
+				zap.String("call_id", leg.params.CallID),
+				zap.String("from_hop", failed.String()),
+				zap.String("to_hop", next.String()),
+				zap.String("reason", reason))
+			leg.armHopTimer()
+			return true
+		}
+		loc.Blacklist(next, 0)
+	}
+}
+
+func (m *Manager) peerForHop(h Hop) (signalingPeer, error) {
+	pool := m.signalingPoolForDial()
+	if pool == nil {
+		return nil, ErrNoSignalingSender
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+	defer cancel()
+	return pool.Get(ctx, h.Transport, h.Addr)
+}
+
+// resendINVITEToHop re-targets the leg at h and sends a fresh INVITE
+// transaction (new branch, CSeq+1), re-keying legsByTx.
+func (leg *outLeg) resendINVITEToHop(h Hop, peer signalingPeer) bool {
+	m := leg.m
+	leg.sigMu.Lock()
+	oldKey := leg.txKey
+	leg.dst = h.Addr
+	leg.transport = h.Transport
+	leg.params.ViaTransport = h.Transport
+	leg.params.CSeq++
+	leg.params.Branch = randomHex(10)
+	leg.txKey = inviteTxKey(leg.params.Branch, leg.params.CSeq)
+	leg.authKey = digestCacheKey(leg.authCred, h.Addr.String())
+	leg.auth = nil
+	leg.inviteAuthTries = 0
+	leg.inviteAuthName, leg.inviteAuthValue = "", ""
+	params, newKey := leg.params, leg.txKey
+	leg.sigMu.Unlock()
+
+	leg.peerMu.Lock()
+	leg.peer = peer
+	leg.peerMu.Unlock()
+	m.mu.Lock()
+	if oldKey != "" {
+		delete(m.legsByTx, oldKey)
+	}
+	if newKey != "" {
+		m.legsByTx[newKey] = leg
+	}
+	m.mu.Unlock()
+	leg.gotProvisional.Store(false)
+
+	invite := buildINVITE(params)
+	if leg.authCred != nil {
+		leg.authorizeINVITE(invite)
+	}
+	if err := peer.Send(invite); err != nil {
+		logger.Warn("sip outbound failover INVITE send failed",
+			zap.String("call_id", params.CallID),
+			zap.String("hop", h.String()),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (leg *outLeg) currentHop() Hop {
+	leg.sigMu.Lock()
+	defer leg.sigMu.Unlock()
+	if leg.hopIdx < len(leg.hops) {
+		return leg.hops[leg.hopIdx]
+	}
+	return Hop{}
+}
+
+// failoverTrunk answers a final 408 / 5xx to the INVITE: ACK it and
+// move to the next fallback trunk. false → no fallback left (or the
+// response is for an older transaction); the caller fails the leg.
+func (leg *outLeg) failoverTrunk(resp *stack.Message, from *net.UDPAddr) bool {
+	if leg == nil || leg.m == nil || resp == nil || !trunkFailoverStatus(resp.StatusCode) {
+		return false
+	}
+	leg.sigMu.Lock()
+	txKey := leg.txKey
+	if !leg.hasNextTrunkLocked() || (txKeyFromResponse(resp) != "" && txKeyFromResponse(resp) != txKey) {
+		leg.sigMu.Unlock()
+		return false
+	}
+	ack := buildACK(leg.params, resp, leg.params.RequestURI)
+	leg.sigMu.Unlock()
+	if err := leg.sendOnPeer(ack, from); err != nil {
+		logger.Warn("sip outbound ACK before trunk failover failed",
+			zap.String("call_id", leg.params.CallID), zap.Error(err))
+	}
+	return leg.switchTrunk(txKey, resp.StatusCode)
+}
+
+// switchTrunk re-targets the leg at the next usable fallback trunk and
+// sends the INVITE there. status is what the abandoned trunk answered
+// (0 = nothing).
+func (leg *outLeg) switchTrunk(txKey string, status int) bool {
+	m := leg.m
+	for {
+		leg.sigMu.Lock()
+		if txKey != leg.txKey || !leg.hasNextTrunkLocked() {
+			leg.sigMu.Unlock()
+			return false
+		}
+		if leg.hopTimer != nil {
+			leg.hopTimer.Stop()
+			leg.hopTimer = nil
+		}
+		prev := leg.req.Target
+		next := leg.req.Fallbacks[leg.trunkIdx]
+		leg.trunkIdx++
+		callID := leg.params.CallID
+		prevDst := udpAddrString(leg.dst)
+		leg.sigMu.Unlock()
+
+		if m.cfg.OnEvent != nil {
+			m.cfg.OnEvent(DialEvent{
+				CallID:        callID,
+				CorrelationID: strings.TrimSpace(leg.req.CorrelationID),
+				Scenario:      leg.req.Scenario,
+				MediaProfile:  leg.req.MediaProfile,
+				State:         DialEventRerouted,
+				StatusCode:    status,
+				Reason:        "trunk_failover",
+				RequestURI:    strings.TrimSpace(prev.RequestURI),
+				RemoteAddr:    prevDst,
+				At:            time.Now(),
+			})
+		}
+		hop, peer, err := leg.prepareTrunk(next)
+		if err != nil {
+			logger.Warn("sip outbound fallback trunk skipped",
+				zap.String("call_id", callID),
+				zap.String("request_uri", strings.TrimSpace(next.RequestURI)),
+				zap.Error(err))
+			status = 0
+			continue
+		}
+		leg.sigMu.Lock()
+		leg.resetRedirectsLocked()
+		leg.sigMu.Unlock()
+		if !leg.resendINVITEToHop(hop, peer) {
+			status = 0
+			continue
+		}
+		logger.Info("sip outbound INVITE moved to fallback trunk",
+			zap.String("call_id", callID),
+			zap.String("from_uri", strings.TrimSpace(prev.RequestURI)),
+			zap.String("to_uri", strings.TrimSpace(next.RequestURI)),
+			zap.String("hop", hop.String()),
+			zap.Int("failed_status", status))
+		leg.sigMu.Lock()
+		txKey = leg.txKey
+		leg.sigMu.Unlock()
+		leg.armHopTimer()
+		return true
+	}
+}
+
+// prepareTrunk reserves capacity for next (dial gate), resolves it and
+// connects to its first reachable hop, then swaps the leg's target,
+// caller identity and credentials over to it. Used for fallback trunks
+// and for 3xx redirect targets (redirect.go).
+func (leg *outLeg) prepareTrunk(next DialTarget) (Hop, signalingPeer, error) {
+	m := leg.m
+	callID := leg.params.CallID
+	req := leg.req
+	req.Target = next
+	req.Fallbacks = nil
+	m.releaseOutboundCapacity(callID)
+	m.dialGateMu.RLock()
+	dg := m.dialGate
+	m.dialGateMu.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+	defer cancel()
+	if dg != nil {
+		if err := dg(ctx, req, callID); err != nil {
+			return Hop{}, nil, err
+		}
+	}
+	loc := m.Locator()
+	hops, err := loc.Resolve(ctx, next)
+	if err != nil {
+		return Hop{}, nil, err
+	}
+	idx := 0
+	peer, err := m.peerForHop(hops[idx])
+	for err != nil && idx+1 < len(hops) {
+		loc.Blacklist(hops[idx], 0)
+		idx++
+		peer, err = m.peerForHop(hops[idx])
+	}
+	if err != nil {
+		return Hop{}, nil, err
+	}
+	cred := m.digestCredentialsFor(next, hops[idx].Addr.String())
+
+	leg.sigMu.Lock()
+	leg.req.Target = next
+	leg.params.RequestURI = strings.TrimSpace(next.RequestURI)
+	if strings.TrimSpace(leg.req.CallerUser) == "" && strings.TrimSpace(next.CallerUser) != "" {
+		leg.params.FromUser = strings.TrimSpace(next.CallerUser)
+		leg.params.FromDisplayName = strings.TrimSpace(next.CallerDisplayName)
+		leg.params.IdentityHeader = ""
+		if m.cfg.STIRSigner != nil {
+			if id, ok := signOutboundIdentity(m.cfg.STIRSigner, callID, leg.params.FromUser, extractTNFromRequestURI(leg.params.RequestURI)); ok {
+				leg.params.IdentityHeader = id
+			}
+		}
+	}
+	leg.authCred = cred
+	leg.hops = hops
+	leg.hopIdx = idx
+	leg.hopBound = false
+	leg.sigMu.Unlock()
+	return hops[idx], peer, nil
+}
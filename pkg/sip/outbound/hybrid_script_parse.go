@@ -25,7 +25,7 @@ type HybridScript struct {
 type HybridStep struct {
 	ID               string             `json:"id"`
 	Type             string             `json:"type"`
-	Prompt           string             `json:"prompt"`
+	Prompt           string             `json:"prompt"` // record_pause: pause reason for the audit trail
 	NextID           string             `json:"next_id"`
 	Retry            int                `json:"retry"`
 	TimeoutMS        int                `json:"timeout_ms"`
@@ -37,6 +37,10 @@ type HybridStep struct {
 	Transitions      []HybridTransition `json:"transitions"`
 	// DTMFTransitions: listen steps only — map keypad (0-9 * #) to next_id without ASR/LLM.
 	DTMFTransitions []HybridDTMFTransition `json:"dtmf_transitions"`
+	// Secure marks a PCI step (card PIN / CVV / verification code): while
+	// the call is on it, DTMF and ASR text are traced as redact.SecureMask
+	// and the recording is blanked for the step's duration.
+	Secure bool `json:"secure"`
 }
 
 // HybridDTMFTransition binds one DTMF key to the next script step (IVR-style).
@@ -73,6 +77,9 @@ func ParseHybridScript(raw string) (HybridScript, error) {
 		constants.SIPScriptStepLLMReply:  {},
 		constants.SIPScriptStepCondition: {},
 		constants.SIPScriptStepEnd:       {},
+
+		constants.SIPScriptStepRecordPause:  {},
+		constants.SIPScriptStepRecordResume: {},
 	}
 	for _, st := range s.Steps {
 		id := strings.TrimSpace(st.ID)
@@ -13,6 +13,8 @@ import (
 
 	"github.com/LinByte/VoiceServer/internal/constants"
 	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/redact"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"go.uber.org/zap"
 )
@@ -86,6 +88,16 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 	lastInput := ""
 	lastReply := ""
 	lastSayPrompt := ""
+	// lastInputSecure: lastInput was captured by a "secure" step. Routing
+	// still sees the real text; traces only ever see redact.SecureMask.
+	lastInputSecure := false
+	traceInput := func() string {
+		if lastInputSecure && lastInput != "" {
+			return redact.SecureMask
+		}
+		return lastInput
+	}
+	defer redact.EndSecure(leg.CallID)
 	for i := 0; i < maxSteps; i++ {
 		select {
 		case <-ctx.Done():
@@ -96,8 +108,13 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 		if !ok {
 			return fmt.Errorf("hybrid script step not found: %s", current)
 		}
+		if step.Secure {
+			redact.BeginSecure(leg.CallID)
+		} else {
+			redact.EndSecure(leg.CallID)
+		}
 		stepStart := time.Now()
-		startIn, startOut := lastInput, step.Prompt
+		startIn, startOut := traceInput(), step.Prompt
 		if strings.TrimSpace(step.Type) == constants.SIPScriptStepListen {
 			startIn, startOut = "", "-"
 		}
@@ -127,7 +144,7 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 						StepID:        step.ID,
 						StepType:      step.Type,
 						Result:        constants.SIPScriptRunFailed,
-						InputText:     lastInput,
+						InputText:     traceInput(),
 						OutputText:    err.Error(),
 					})
 					return err
@@ -164,7 +181,7 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 							StepID:        step.ID,
 							StepType:      step.Type,
 							Result:        constants.SIPScriptRunTimeout,
-							InputText:     lastInput,
+							InputText:     traceInput(),
 							OutputText:    err.Error(),
 						})
 					} else {
@@ -174,6 +191,7 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 					if d := strings.TrimSpace(res.DTMFDigit); d != "" {
 						dtmfUsed = true
 						lastInput = "dtmf:" + d
+						lastInputSecure = step.Secure || leg.Session.RecordingPaused()
 						if nid := matchDTMFNextID(step, d); nid != "" {
 							nextID = nid
 						}
@@ -185,11 +203,12 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 							StepID:        step.ID,
 							StepType:      step.Type,
 							Result:        constants.SIPScriptRunMatched,
-							InputText:     lastInput,
+							InputText:     traceInput(),
 							OutputText:    "dtmf -> " + nextID,
 						})
 					} else {
 						lastInput = strings.TrimSpace(res.InputText)
+						lastInputSecure = step.Secure || leg.Session.RecordingPaused()
 						lastReply = strings.TrimSpace(res.ReplyText)
 						out := lastReply
 						if out == "" {
@@ -203,7 +222,7 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 							StepID:        step.ID,
 							StepType:      step.Type,
 							Result:        constants.SIPScriptRunMatched,
-							InputText:     lastInput,
+							InputText:     traceInput(),
 							OutputText:    out,
 						})
 					}
@@ -220,7 +239,7 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 					StepID:        step.ID,
 					StepType:      step.Type,
 					Result:        constants.SIPScriptRunEnded,
-					InputText:     lastInput,
+					InputText:     traceInput(),
 					OutputText:    "end intent matched",
 				})
 			} else if !dtmfUsed {
@@ -250,7 +269,7 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 						StepID:        step.ID,
 						StepType:      step.Type,
 						Result:        constants.SIPScriptRunFailed,
-						InputText:     lastInput,
+						InputText:     traceInput(),
 						OutputText:    err.Error(),
 					})
 					return err
@@ -262,6 +281,8 @@ func (r *HybridScriptRunner) Run(ctx context.Context, leg EstablishedLeg) error
 			} else if fb := strings.TrimSpace(step.FallbackID); fb != "" {
 				nextID = fb
 			}
+		case constants.SIPScriptStepRecordPause, constants.SIPScriptStepRecordResume:
+			r.recordingControl(ctx, leg, step, stepStart)
 		case constants.SIPScriptStepEnd:
 			return nil
 		}
@@ -292,6 +313,46 @@ func (r *HybridScriptRunner) record(ctx context.Context, stepStart time.Time, ev
 	return r.Recorder.Record(ctx, event)
 }
 
+// recordingControl runs a record_pause / record_resume step. Failures
+// (no session, already paused, not paused) are traced but never abort the
+// script: a missing pause must not strand the caller mid-flow.
+func (r *HybridScriptRunner) recordingControl(ctx context.Context, leg EstablishedLeg, step HybridStep, stepStart time.Time) {
+	by := "script:" + r.Script.ID + "/" + step.ID
+	var err error
+	out := "recording paused"
+	if strings.TrimSpace(step.Type) == constants.SIPScriptStepRecordPause {
+		if leg.Session == nil {
+			err = fmt.Errorf("script record_pause: session not ready")
+		} else {
+			err = leg.Session.PauseRecording(sipSession.RecordingPauseSourceScript, by, strings.TrimSpace(step.Prompt))
+		}
+	} else {
+		out = "recording resumed"
+		if leg.Session == nil {
+			err = fmt.Errorf("script record_resume: session not ready")
+		} else {
+			var p sipSession.RecordingPause
+			if p, err = leg.Session.ResumeRecording(by); err == nil {
+				out = fmt.Sprintf("recording resumed after %dms", p.Duration().Milliseconds())
+			}
+		}
+	}
+	result := constants.SIPScriptRunMatched
+	if err != nil {
+		result, out = constants.SIPScriptRunFailed, err.Error()
+	}
+	_ = r.record(ctx, stepStart, ScriptRunEvent{
+		CallID:        leg.CallID,
+		CorrelationID: leg.CorrelationID,
+		ScriptID:      r.Script.ID,
+		ScriptVersion: r.Script.Version,
+		StepID:        step.ID,
+		StepType:      step.Type,
+		Result:        result,
+		OutputText:    out,
+	})
+}
+
 // finishWithRouteApology speaks SIP_SCRIPT_LLM_FAIL_PROMPT (default apology) and ends the script run successfully.
 func (r *HybridScriptRunner) finishWithRouteApology(ctx context.Context, leg EstablishedLeg, step HybridStep, routeErr error) error {
 	if r == nil {
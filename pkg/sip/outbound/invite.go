@@ -11,6 +11,7 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/sip/historyinfo"
 	"github.com/LinByte/VoiceServer/pkg/sip/identity"
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
 )
 
 // inviteParams carries dialog fields needed for INVITE and later ACK.
@@ -70,6 +71,9 @@ type inviteParams struct {
 	// outbound path. Set in Manager.Dial via ResolveTransport(target).
 	ViaTransport Transport
 
+	// Route is the preloaded Route set (DialTarget.Route); empty → no Route header.
+	Route []string
+
 	// IdentityHeader is the **already-rendered** RFC 8224 Identity
 	// header value (everything after "Identity: "). Empty → header
 	// omitted. Populated in Manager.Dial when ManagerConfig.STIRSigner
@@ -207,6 +211,7 @@ func buildINVITE(p inviteParams) *stack.Message {
 	msg.SetHeader("Call-ID", p.CallID)
 	msg.SetHeader("CSeq", fmt.Sprintf("%d INVITE", p.CSeq))
 	msg.SetHeader("Contact", formatOutboundContact(p.FromUser, p.SIPHost, p.SIPPort))
+	transaction.SetRouteHeaders(msg, p.Route)
 	// RFC 3325 P-Asserted-Identity: carrier-validated CLI carried separately
 	// from the (user-claimed) From header. Only emitted when the caller has
 	// explicitly populated AssertedIdentityURI — we do NOT auto-derive PAI
@@ -21,6 +21,7 @@ import (
 	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"github.com/LinByte/VoiceServer/pkg/sip/session_timer"
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
 	"github.com/LinByte/VoiceServer/pkg/voice/cdr"
 	"go.uber.org/zap"
 )
@@ -88,6 +89,16 @@ func isPrivateIPv4(ip net.IP) bool {
 	}
 }
 
+// answerRTPAddr is the media target of an SDP answer. NAT fallback for
+// outbound UAC legs: when the answer has a private media IP but the response
+// source is public/reachable, use response source IP + SDP port (overridden).
+func answerRTPAddr(remoteIP net.IP, port int, from *net.UDPAddr) (addr *net.UDPAddr, overridden bool) {
+	if from != nil && isPrivateIPv4(remoteIP) && from.IP != nil && from.IP.To4() != nil && !isPrivateIPv4(from.IP) {
+		return &net.UDPAddr{IP: from.IP, Port: port}, true
+	}
+	return &net.UDPAddr{IP: remoteIP, Port: port}, false
+}
+
 // newOutboundRTPSession allocates RTP UDP port based on env:
 // - SIP_RTP_PORT: fixed single port
 // - SIP_RTP_PORT_START/SIP_RTP_PORT_END: rotating range
@@ -185,6 +196,12 @@ type ManagerConfig struct {
 	// OnEvent reports dial lifecycle transitions for queue workers and metrics.
 	OnEvent func(DialEvent)
 
+	// OnEarlyMedia runs once per DialRequest.EarlyMedia leg when a 18x carries
+	// SDP: Session plays the far end's early media (ringback, announcements)
+	// on the leg's RTP socket. The 200 OK builds a new session on the same
+	// socket, handed to OnEstablished.
+	OnEarlyMedia func(EstablishedLeg)
+
 	// TLSConfig is the *tls.Config used for outbound SIPS / TLS
 	// signaling dials. nil → built lazily with system roots and
 	// ServerName = dial host (strict verification by default per
@@ -201,6 +218,10 @@ type ManagerConfig struct {
 	// signed PASSporT. Signing failures are soft (logged, then dial
 	// without Identity) — STIR never blocks a legitimate call.
 	STIRSigner *STIRSigner
+
+	// DNSResolver backs RFC 3263 next-hop lookup (resolve.go) when the
+	// target is a host name rather than an IP literal. nil → system DNS.
+	DNSResolver DNSResolver
 }
 
 // Manager owns outbound SIP legs keyed by Call-ID.
@@ -231,6 +252,31 @@ type Manager struct {
 	// races with itself, but tests inject and clear it freely.
 	cdrSinkMu sync.RWMutex
 	cdrSinkV  *cdr.Writer
+
+	// authMu guards the digest nonce cache (username@next-hop →
+	// accepted challenge) and the trunk credential resolver.
+	authMu       sync.Mutex
+	authCache    map[string]*digestSession
+	authResolver func(signalingAddr string) (DigestCredentials, bool)
+
+	// regMu guards the trunk REGISTER client loops (register.go).
+	// regByCallID outlives regs by the un-REGISTER transaction.
+	regMu       sync.Mutex
+	regs        map[uint]*registration
+	regByCallID map[string]*registration
+
+	// locator resolves targets per RFC 3263 (lazy, see failover.go).
+	locatorMu sync.Mutex
+	locator   *Locator
+
+	// probeMu guards the trunk OPTIONS keep-alive loops (options.go).
+	probeMu       sync.Mutex
+	probes        map[uint]*trunkProbe
+	probeByCallID map[string]*trunkProbe
+
+	// msgMu guards in-flight MESSAGE transactions by Call-ID (message.go).
+	msgMu       sync.Mutex
+	msgByCallID map[string]*messageTx
 }
 
 // NewManager constructs a manager; call BindSender before Dial.
@@ -330,6 +376,9 @@ func (m *Manager) HandleSIPResponse(resp *stack.Message, addr *net.UDPAddr) {
 	if m == nil || resp == nil {
 		return
 	}
+	if m.routeRegisterResponse(resp) || m.routeOptionsResponse(resp) || m.routeMessageResponse(resp) {
+		return
+	}
 	txKey := txKeyFromResponse(resp)
 	callID := strings.TrimSpace(resp.GetHeader("Call-ID"))
 	m.mu.Lock()
@@ -372,9 +421,6 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 	if strings.TrimSpace(req.Target.RequestURI) == "" {
 		return "", fmt.Errorf("sip/outbound: empty target request URI")
 	}
-	if strings.TrimSpace(req.Target.SignalingAddr) == "" {
-		return "", fmt.Errorf("sip/outbound: empty signaling address")
-	}
 
 	sigHost := strings.TrimSpace(m.cfg.SIPHost)
 	if sigHost == "" {
@@ -401,16 +447,16 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		}
 	}
 
-	// Resolve via UDP-style addr regardless of transport: the
-	// destination IP+Port is the same; only the wire transport
-	// differs. We carry the *net.UDPAddr through outLeg / pool
+	// RFC 3263: SignalingAddr (or the Request-URI host when empty) →
+	// ordered next hops. An IP literal short-circuits DNS. We carry
+	// the *net.UDPAddr through outLeg / pool regardless of transport
 	// because most downstream code (NAT detection, logging) reads
 	// IP+Port off it; the actual TCP/TLS conn is held by the peer.
-	addr, err := net.ResolveUDPAddr("udp", req.Target.SignalingAddr)
+	hops, err := m.Locator().Resolve(ctx, req.Target)
 	if err != nil {
 		return "", fmt.Errorf("sip/outbound: resolve signaling: %w", err)
 	}
-	transport := ResolveTransport(req.Target)
+	addr, transport := hops[0].Addr, hops[0].Transport
 
 	localPort := m.cfg.SIPPort
 	if localPort <= 0 {
@@ -436,18 +482,23 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		// Script callbacks prioritize SIP endpoint compatibility over wideband quality.
 		// Some UAs negotiate Opus but still produce garbled playout in this path.
 		codecs = sdp.TransferAgentBridgeOfferCodecs()
-	} else if req.Scenario == ScenarioTransferAgent && req.MediaProfile == MediaProfileTransferBridge {
+	} else if req.bridgedAgentLeg() {
 		codecs = sdp.TransferAgentBridgeOfferCodecs()
 	}
 
 	var (
 		mediaProto    string
 		sdpExtras     []string
+		sessionExtras []string
 		srtpOfferKey  []byte
 		srtpOfferSalt []byte
 		dtlsPending   *outboundDTLSPending
 	)
-	if req.OfferDTLSSRTP && req.MediaProfile == MediaProfileTransferBridge {
+	// A SIP-over-WebSocket contact is a WebRTC stack (browser / JsSIP):
+	// DTLS-SRTP with ICE is the only media it accepts, whatever the
+	// scenario's profile would otherwise pick.
+	webRTC := transport.IsWebSocket()
+	if req.OfferDTLSSRTP && req.MediaProfile == MediaProfileTransferBridge && !webRTC {
 		// DTLS-SRTP toward bridge profile peers is almost guaranteed
 		// to 488 (most softphones don't speak it). Don't fail the
 		// dial — just downgrade to RTP/AVP and log so the operator
@@ -455,11 +506,11 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		logger.Warn("sip outbound: OfferDTLSSRTP ignored on MediaProfileTransferBridge (downgrading to RTP/AVP)",
 			zap.String("scenario", string(req.Scenario)))
 	}
-	if req.Scenario == ScenarioTransferAgent && req.MediaProfile == MediaProfileTransferBridge {
+	if req.bridgedAgentLeg() && !webRTC {
 		// Bridged agent leg targets desk phones / common softphones; many reject RTP/SAVPF+SDES with 488.
 		// Plain RTP/AVP keeps SRTP on the customer inbound leg only.
 		mediaProto = "RTP/AVP"
-	} else if req.OfferDTLSSRTP {
+	} else if req.OfferDTLSSRTP || webRTC {
 		// RFC 5763/5764 DTLS-SRTP offer. We don't carry SDES extras
 		// alongside — the proto value forbids interleaving SAVP and
 		// SAVP-DTLS in the same m= block.
@@ -471,6 +522,17 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		mediaProto = proto
 		sdpExtras = extras
 		dtlsPending = pending
+		if webRTC {
+			ufrag, pwd, ierr := sdp.NewICECredentials()
+			if ierr != nil {
+				_ = rtpSess.Close()
+				return "", fmt.Errorf("sip/outbound: ice credentials: %w", ierr)
+			}
+			rtpSess.EnableICELite(ufrag, pwd)
+			mediaProto = "UDP/TLS/RTP/SAVPF"
+			sdpExtras = append(sdpExtras, sdp.ICELiteMediaLines(ufrag, pwd, localSDP, localPort, "0", true)...)
+			sessionExtras = append(sessionExtras, sdp.ICELiteLine)
+		}
 	} else {
 		// Standard outbound offers RTP/SAVPF + SDES; plain RTP answers downgrade via applyOutboundAnswerSRTP.
 		mediaProto = "RTP/SAVPF"
@@ -493,7 +555,17 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		srtpOfferKey = append([]byte(nil), mkey...)
 		srtpOfferSalt = append([]byte(nil), msalt...)
 	}
-	sdpBody := sdp.GenerateWithProtoExtras(localSDP, localPort, mediaProto, codecs, sdpExtras)
+	if sdp.IsAVPFProto(mediaProto) {
+		// RFC 4585 generic NACK; rtcp-mux (RFC 5761) is safe to offer
+		// since a=rtcp still names port+1 for peers that ignore it.
+		sdpExtras = append(sdpExtras, sdp.NACKFeedbackLines(codecs...)...)
+		if !webRTC {
+			sdpExtras = append(sdpExtras, sdp.RTCPMuxLine)
+		}
+	}
+	sdpBody := sdp.WithSessionAttributes(
+		sdp.GenerateWithProtoExtras(localSDP, localPort, mediaProto, codecs, sdpExtras),
+		sessionExtras...)
 
 	ip := m.cfg.SIPHost
 	if ip == "" {
@@ -533,6 +605,7 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		HistoryInfo:                 req.HistoryInfo,
 		Diversion:                   req.Diversion,
 		ViaTransport:                transport,
+		Route:                       req.Target.Route,
 	}
 
 	// RFC 8224 SHAKEN signing — opt-in via ManagerConfig.STIRSigner.
@@ -558,22 +631,48 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		srtpOfferKey:  srtpOfferKey,
 		srtpOfferSalt: srtpOfferSalt,
 		dtlsPending:   dtlsPending,
+		hops:          hops,
+	}
+	if cred := m.digestCredentialsFor(req.Target, addr.String()); cred != nil {
+		leg.authCred = cred
+		leg.authKey = digestCacheKey(cred, addr.String())
+		leg.authorizeINVITE(invite)
 	}
 
 	// Acquire signaling peer (UDP wraps shared listener;
 	// TCP/TLS dials + pools per-target). Done BEFORE registering
 	// the leg so a dial failure aborts cleanly without leaking
-	// txKey state.
+	// txKey state. A hop that refuses the connection is blacklisted
+	// and the next one tried.
 	pool := m.signalingPoolForDial()
 	if pool == nil {
 		_ = rtpSess.Close()
 		return "", fmt.Errorf("sip/outbound: signaling pool unavailable")
 	}
 	peer, err := pool.Get(ctx, transport, addr)
+	for err != nil && leg.hopIdx+1 < len(hops) {
+		m.Locator().Blacklist(hops[leg.hopIdx], 0)
+		logger.Warn("sip outbound: next hop unreachable, trying next",
+			zap.String("call_id", callID),
+			zap.String("hop", hops[leg.hopIdx].String()),
+			zap.Error(err))
+		leg.hopIdx++
+		addr, transport = hops[leg.hopIdx].Addr, hops[leg.hopIdx].Transport
+		peer, err = pool.Get(ctx, transport, addr)
+	}
 	if err != nil {
 		_ = rtpSess.Close()
 		return "", fmt.Errorf("sip/outbound: dial %s: %w", transport, err)
 	}
+	if leg.hopIdx > 0 {
+		leg.dst, leg.transport = addr, transport
+		leg.params.ViaTransport = transport
+		invite = buildINVITE(leg.params)
+		if leg.authCred != nil {
+			leg.authKey = digestCacheKey(leg.authCred, addr.String())
+			leg.authorizeINVITE(invite)
+		}
+	}
 	leg.peerMu.Lock()
 	leg.peer = peer
 	leg.peerMu.Unlock()
@@ -597,6 +696,7 @@ func (m *Manager) Dial(ctx context.Context, req DialRequest) (callID string, err
 		_ = rtpSess.Close()
 		return "", fmt.Errorf("sip/outbound: send INVITE: %w", err)
 	}
+	leg.armHopTimer()
 
 	logger.Info("sip outbound INVITE sent",
 		zap.String("call_id", callID),
@@ -755,6 +855,7 @@ type outLeg struct {
 	byeRequestURI string // in-dialog Request-URI (Contact)
 	byeRemote     *net.UDPAddr
 	byeCSeqNext   int
+	routeSet      []string // dialog route set from the 2xx Record-Route (RFC 3261 §12.1.2)
 	txKey         string
 
 	srtpOfferKey  []byte
@@ -773,6 +874,9 @@ type outLeg struct {
 	// CDR sink pay only the zero-value cost.
 	cdr cdrState
 
+	// earlyMedia is set once the first 18x SDP was applied (early_media.go).
+	earlyMedia atomic.Bool
+
 	// gotProvisional is set true on the first 1xx received for the
 	// INVITE. RFC 3261 §9.1 says CANCEL MUST NOT be sent before the
 	// first provisional — some strict proxies will silently drop a
@@ -792,6 +896,28 @@ type outLeg struct {
 	// init in startCancelRetransmit.
 	cancelStopMu sync.Mutex
 	cancelStop   chan struct{}
+
+	// Digest auth (digest.go). authCred/authKey are set at Dial and on hop /
+	// trunk failover (failover.go) before any response is processed; the
+	// rest is guarded by sigMu.
+	authCred        *DigestCredentials
+	authKey         string
+	auth            *digestSession
+	inviteAuthTries int
+	inviteAuthName  string // Authorization or Proxy-Authorization
+	inviteAuthValue string // echoed on the 2xx ACK
+	authPending     map[int]*inDialogAuth
+
+	// RFC 3263 next hops from Dial (failover.go); guarded by sigMu.
+	// hopBound is set once the current hop answered the INVITE;
+	// trunkIdx is the next req.Fallbacks entry to try.
+	hops     []Hop
+	hopIdx   int
+	hopBound bool
+	hopTimer *time.Timer
+	trunkIdx int
+	// redirect tracks 3xx recursion (redirect.go); guarded by sigMu.
+	redirect redirectState
 }
 
 func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from *net.UDPAddr) {
@@ -800,6 +926,18 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 	}
 	st := resp.StatusCode
 	cseqAll := strings.ToUpper(resp.GetHeader("CSeq"))
+	// Digest challenge on BYE / UPDATE / REFER: re-send with
+	// credentials and swallow the 401/407 (digest.go).
+	if !strings.Contains(cseqAll, "INVITE") && leg.handleInDialogAuth(resp) {
+		return
+	}
+	if strings.Contains(cseqAll, stack.MethodRefer) {
+		logger.Info("sip outbound REFER response",
+			zap.String("call_id", leg.params.CallID),
+			zap.Int("status", st),
+			zap.String("reason_phrase", strings.TrimSpace(resp.StatusText)))
+		return
+	}
 	if strings.Contains(cseqAll, "BYE") {
 		if st >= 200 && st < 300 {
 			// Local BYE acknowledged. Counted as "by=local" since we
@@ -831,6 +969,11 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		}
 		return
 	}
+	// RFC 3263 §4.3: 503 / silence from one hop moves the INVITE to
+	// the next (failover.go).
+	if strings.Contains(cseqAll, "INVITE") && leg.hopINVITEResponse(resp, from) {
+		return
+	}
 	if st >= 100 && st < 200 {
 		phrase := strings.TrimSpace(resp.StatusText)
 		// RFC 3261 §9.1: a CANCEL queued before the first provisional
@@ -842,6 +985,7 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 				go leg.fireDeferredCANCEL()
 			}
 		}
+		leg.startEarlyMedia(resp, from)
 		logger.Info("sip outbound provisional response",
 			zap.String("call_id", leg.params.CallID),
 			zap.Int("status", st),
@@ -852,20 +996,31 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		)
 		if leg.m.cfg.OnEvent != nil {
 			leg.m.cfg.OnEvent(DialEvent{
-				CallID:        leg.params.CallID,
-				CorrelationID: strings.TrimSpace(leg.req.CorrelationID),
-				Scenario:      leg.req.Scenario,
-				MediaProfile:  leg.req.MediaProfile,
-				State:         DialEventProvisional,
-				StatusCode:    st,
-				StatusText:    phrase,
-				RemoteAddr:    udpAddrString(from),
-				RequestURI:    strings.TrimSpace(leg.req.Target.RequestURI),
-				At:            time.Now(),
+				CallID:             leg.params.CallID,
+				CorrelationID:      strings.TrimSpace(leg.req.CorrelationID),
+				Scenario:           leg.req.Scenario,
+				MediaProfile:       leg.req.MediaProfile,
+				State:              DialEventProvisional,
+				StatusCode:         st,
+				StatusText:         phrase,
+				RemoteAddr:         udpAddrString(from),
+				RequestURI:         strings.TrimSpace(leg.req.Target.RequestURI),
+				OriginalRequestURI: leg.originalRequestURI(),
+				At:                 time.Now(),
 			})
 		}
 		return
 	}
+	if (st == 401 || st == 407) && strings.Contains(cseqAll, "INVITE") && leg.retryINVITEWithAuth(resp, from) {
+		return
+	}
+	// RFC 3261 §8.1.3.4: recurse on 3xx Contacts (redirect.go).
+	if strings.Contains(cseqAll, "INVITE") && leg.followRedirect(resp, from) {
+		return
+	}
+	if strings.Contains(cseqAll, "INVITE") && leg.failoverTrunk(resp, from) {
+		return
+	}
 	if st != 200 {
 		reason := strings.TrimSpace(resp.StatusText)
 		if reason == "" {
@@ -892,17 +1047,18 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		)
 		if leg.m.cfg.OnEvent != nil {
 			leg.m.cfg.OnEvent(DialEvent{
-				CallID:        leg.params.CallID,
-				CorrelationID: strings.TrimSpace(leg.req.CorrelationID),
-				Scenario:      leg.req.Scenario,
-				MediaProfile:  leg.req.MediaProfile,
-				State:         DialEventFailed,
-				StatusCode:    st,
-				Reason:        reason,
-				StatusText:    strings.TrimSpace(resp.StatusText),
-				RemoteAddr:    udpAddrString(from),
-				RequestURI:    strings.TrimSpace(leg.req.Target.RequestURI),
-				At:            time.Now(),
+				CallID:             leg.params.CallID,
+				CorrelationID:      strings.TrimSpace(leg.req.CorrelationID),
+				Scenario:           leg.req.Scenario,
+				MediaProfile:       leg.req.MediaProfile,
+				State:              DialEventFailed,
+				StatusCode:         st,
+				Reason:             reason,
+				StatusText:         strings.TrimSpace(resp.StatusText),
+				RemoteAddr:         udpAddrString(from),
+				RequestURI:         strings.TrimSpace(leg.req.Target.RequestURI),
+				OriginalRequestURI: leg.originalRequestURI(),
+				At:                 time.Now(),
 			})
 		}
 		leg.cleanupLeg()
@@ -938,11 +1094,8 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		leg.cleanupLeg()
 		return
 	}
-	remoteRTP := &net.UDPAddr{IP: remoteIP, Port: answer.Port}
-	// NAT fallback for outbound UAC legs: when answer SDP has a private media IP but
-	// response source is public/reachable, use response source IP + SDP port.
-	if from != nil && isPrivateIPv4(remoteIP) && from.IP != nil && from.IP.To4() != nil && !isPrivateIPv4(from.IP) {
-		remoteRTP = &net.UDPAddr{IP: from.IP, Port: answer.Port}
+	remoteRTP, natOverride := answerRTPAddr(remoteIP, answer.Port, from)
+	if natOverride {
 		logger.Info("sip outbound media target overridden (private SDP IP fallback)",
 			zap.String("call_id", leg.params.CallID),
 			zap.String("sdp_remote_ip", remoteIP.String()),
@@ -982,6 +1135,12 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		return
 	}
 	cs.SetTenantID(leg.req.DialTenantID)
+	if answer.RTCPMux {
+		leg.rtpSess.EnableRTCPMux()
+	}
+	if answer.SupportsNACK(cs.NegotiatedCodec().PayloadType) {
+		leg.rtpSess.EnableNACK()
+	}
 
 	ackURI := ackRequestURI(resp, leg.params.RequestURI)
 	ack := buildACK(leg.params, resp, ackURI)
@@ -989,6 +1148,14 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		leg.cleanupLeg()
 		return
 	}
+	routeSet := transaction.RouteHeadersForDialog(resp)
+	transaction.SetRouteHeaders(ack, routeSet)
+	// RFC 3261 §22.1: the 2xx ACK carries the INVITE's credentials.
+	leg.sigMu.Lock()
+	if leg.inviteAuthValue != "" {
+		ack.SetHeader(leg.inviteAuthName, leg.inviteAuthValue)
+	}
+	leg.sigMu.Unlock()
 	// ACK travels on the same transport as the INVITE — for TCP/TLS
 	// the peer holds the live conn; for UDP it wraps the shared
 	// listener and delivers to `from` (the actual response source,
@@ -1010,6 +1177,7 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 		leg.byeRemote = cloneUDPAddr(leg.dst)
 	}
 	leg.byeCSeqNext = leg.params.CSeq + 1
+	leg.routeSet = routeSet
 	leg.sigMu.Unlock()
 
 	leg.mu.Lock()
@@ -1085,7 +1253,9 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 				zap.String("call_id", leg.params.CallID))
 		}
 	default:
-		// MediaProfileNone
+		// MediaProfileNone. Early-media legs belong to the OnEarlyMedia /
+		// OnEstablished consumer, which may already be reading the socket.
+		startDefaultMedia = !leg.req.EarlyMedia
 	}
 
 	if startDefaultMedia {
@@ -1109,16 +1279,17 @@ func (leg *outLeg) handleResponse(ctx context.Context, resp *stack.Message, from
 	}
 	if leg.m.cfg.OnEvent != nil {
 		leg.m.cfg.OnEvent(DialEvent{
-			CallID:        leg.params.CallID,
-			CorrelationID: strings.TrimSpace(leg.req.CorrelationID),
-			Scenario:      leg.req.Scenario,
-			MediaProfile:  leg.req.MediaProfile,
-			State:         DialEventEstablished,
-			StatusCode:    200,
-			StatusText:    strings.TrimSpace(resp.StatusText),
-			RemoteAddr:    udpAddrString(from),
-			RequestURI:    strings.TrimSpace(leg.req.Target.RequestURI),
-			At:            time.Now(),
+			CallID:             leg.params.CallID,
+			CorrelationID:      strings.TrimSpace(leg.req.CorrelationID),
+			Scenario:           leg.req.Scenario,
+			MediaProfile:       leg.req.MediaProfile,
+			State:              DialEventEstablished,
+			StatusCode:         200,
+			StatusText:         strings.TrimSpace(resp.StatusText),
+			RemoteAddr:         udpAddrString(from),
+			RequestURI:         strings.TrimSpace(leg.req.Target.RequestURI),
+			OriginalRequestURI: leg.originalRequestURI(),
+			At:                 time.Now(),
 		})
 	}
 
@@ -1162,6 +1333,7 @@ func (leg *outLeg) cleanupLeg() {
 	// Stop any CANCEL retransmit goroutine for the same reason —
 	// once we drop the peer it would write to a nil socket.
 	leg.stopCANCELRetransmit()
+	leg.bindHop()
 	// Flush per-call QoS into the metrics histograms BEFORE we close
 	// the RTP session. After Close() the snapshot would be empty.
 	// This is the once-per-call observation point — one RTCP read,
@@ -1178,11 +1350,25 @@ func (leg *outLeg) cleanupLeg() {
 	callID := leg.params.CallID
 	m := leg.m
 	m.mu.Lock()
+	_, tracked := m.legs[callID]
 	delete(m.legs, callID)
 	if leg.txKey != "" {
 		delete(m.legsByTx, leg.txKey)
 	}
 	m.mu.Unlock()
+	leg.mu.Lock()
+	established := leg.established
+	leg.mu.Unlock()
+	if tracked && established && m.cfg.OnEvent != nil {
+		m.cfg.OnEvent(DialEvent{
+			CallID:        callID,
+			CorrelationID: leg.req.CorrelationID,
+			Scenario:      leg.req.Scenario,
+			MediaProfile:  leg.req.MediaProfile,
+			State:         DialEventEnded,
+			At:            time.Now(),
+		})
+	}
 	if leg.rtpSess != nil {
 		_ = leg.rtpSess.Close()
 	}
@@ -68,6 +68,30 @@ func TestBuildINVITE_ContainsMethod(t *testing.T) {
 	}
 }
 
+// TestBuildINVITE_PreloadedRoute checks that a registered user's Path
+// (DialTarget.Route) goes out as Route headers, top first.
+func TestBuildINVITE_PreloadedRoute(t *testing.T) {
+	p := inviteParams{
+		SIPHost:    "127.0.0.1",
+		SIPPort:    6050,
+		RequestURI: "sip:2001@10.1.1.20:5060",
+		CallID:     "route@127.0.0.1",
+		FromTag:    "abc",
+		Branch:     "branch1",
+		CSeq:       1,
+		FromUser:   "alice",
+		Route:      []string{"<sip:edge1.example.com;lr>", "<sip:edge2.example.com;lr>"},
+	}
+	msg := buildINVITE(p)
+	if r := msg.GetHeaders("Route"); len(r) != 2 || r[0] != p.Route[0] || r[1] != p.Route[1] {
+		t.Fatalf("Route = %q", r)
+	}
+	p.Route = nil
+	if r := buildINVITE(p).GetHeaders("Route"); len(r) != 0 {
+		t.Fatalf("unexpected Route %q", r)
+	}
+}
+
 // TestBuildINVITE_PAI_Privacy verifies that populating the RFC 3325 /
 // RFC 3323 fields on inviteParams produces correctly-formatted headers
 // on the outbound INVITE.
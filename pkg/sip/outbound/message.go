@@ -0,0 +1,226 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
+	"go.uber.org/zap"
+)
+
+// Out-of-dialog MESSAGE (RFC 3428).
+//
+// SendMessage runs one non-INVITE client transaction per call: over UDP
+// the request is retransmitted on Timer E (T1 doubling, capped at T2)
+// until a final response or Timer F; reliable transports send once.
+// One 401 / 407 is answered with the target's trunk account, the same
+// way Dial does for INVITE.
+
+// Timers are variables so tests can shrink them.
+var (
+	messageT1        = 500 * time.Millisecond
+	messageT2        = 4 * time.Second
+	messageTxTimeout = 32 * time.Second // Timer F = 64*T1
+)
+
+// DefaultMessageContentType is used when MessageRequest.ContentType is empty.
+const DefaultMessageContentType = "text/plain;charset=UTF-8"
+
+// ErrEmptyMessage is returned by SendMessage for a request without a body.
+var ErrEmptyMessage = errors.New("sip/outbound: empty MESSAGE body")
+
+// MessageRequest is one page-mode instant message.
+type MessageRequest struct {
+	Target DialTarget
+	// FromUser / FromDisplayName override Target.CallerUser and the
+	// Manager defaults (same precedence as DialRequest).
+	FromUser        string
+	FromDisplayName string
+	// ContentType defaults to DefaultMessageContentType.
+	ContentType string
+	Body        string
+}
+
+// MessageResult is the outcome of one MESSAGE transaction.
+type MessageResult struct {
+	CallID string
+	// Status is the final SIP status; 408 when Timer F fired.
+	Status int
+	Reason string
+}
+
+// Delivered reports a 2xx final response.
+func (r MessageResult) Delivered() bool { return r.Status >= 200 && r.Status < 300 }
+
+type messageTx struct {
+	respCh chan *stack.Message
+}
+
+// SendMessage sends req and waits for its final response. The error is
+// non-nil only when nothing could be sent (resolve / transport failure,
+// ctx cancelled); SIP rejections and timeouts are reported in the result.
+func (m *Manager) SendMessage(ctx context.Context, req MessageRequest) (MessageResult, error) {
+	if m == nil || m.send == nil {
+		return MessageResult{}, ErrNoSignalingSender
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		return MessageResult{}, ErrEmptyMessage
+	}
+	hops, err := m.Locator().Resolve(ctx, req.Target)
+	if err != nil {
+		return MessageResult{}, fmt.Errorf("sip/outbound: resolve signaling: %w", err)
+	}
+	dst, transport := hops[0].Addr, hops[0].Transport
+	peer, err := m.signalingPoolForDial().Get(ctx, transport, dst)
+	if err != nil {
+		return MessageResult{}, err
+	}
+
+	res := MessageResult{CallID: randomHex(12) + "@" + nonEmpty(m.cfg.SIPHost, "127.0.0.1")}
+	tx := &messageTx{respCh: make(chan *stack.Message, 4)}
+	m.msgMu.Lock()
+	if m.msgByCallID == nil {
+		m.msgByCallID = make(map[string]*messageTx)
+	}
+	m.msgByCallID[res.CallID] = tx
+	m.msgMu.Unlock()
+	defer func() {
+		m.msgMu.Lock()
+		delete(m.msgByCallID, res.CallID)
+		m.msgMu.Unlock()
+	}()
+
+	fromTag := randomHex(6)
+	cred := m.digestCredentialsFor(req.Target, dst.String())
+	key := digestCacheKey(cred, dst.String())
+	sess := m.digestSessionFor(key)
+	tries := 0
+	for cseq := 1; ; cseq++ {
+		branch := randomHex(10)
+		msg := m.buildMESSAGE(req, peer.Transport(), res.CallID, fromTag, cseq, branch)
+		if sess != nil {
+			sess.authorize(msg)
+		}
+		resp, err := tx.run(ctx, peer, msg, inviteTxKey(branch, cseq), transport == TransportUDP)
+		if err != nil {
+			return res, err
+		}
+		if resp == nil {
+			res.Status, res.Reason = 408, "Request Timeout"
+			logger.Warn("sip outbound MESSAGE timed out",
+				zap.String("call_id", res.CallID),
+				zap.String("dst", dst.String()))
+			return res, nil
+		}
+		res.Status, res.Reason = resp.StatusCode, strings.TrimSpace(resp.StatusText)
+		if (resp.StatusCode == 401 || resp.StatusCode == 407) && cred != nil {
+			if ch, ok := pickDigestChallenge(resp, cred.Realm); ok && authRetryAllowed(tries, ch) {
+				sess = m.storeDigestSession(key, ch, *cred)
+				tries++
+				continue
+			}
+		}
+		return res, nil
+	}
+}
+
+func (m *Manager) buildMESSAGE(req MessageRequest, transport Transport, callID, fromTag string, cseq int, branch string) *stack.Message {
+	fromUser := nonEmpty(req.FromUser, nonEmpty(req.Target.CallerUser, m.cfg.FromUser))
+	display := m.cfg.FromDisplayName
+	if strings.TrimSpace(req.FromUser) != "" {
+		display = req.FromDisplayName
+	} else if strings.TrimSpace(req.Target.CallerUser) != "" {
+		display = req.Target.CallerDisplayName
+	}
+	ct := nonEmpty(req.ContentType, DefaultMessageContentType)
+	msg := &stack.Message{
+		IsRequest:  true,
+		Method:     stack.MethodMessage,
+		RequestURI: strings.TrimSpace(req.Target.RequestURI),
+		Version:    "SIP/2.0",
+		Body:       req.Body,
+	}
+	msg.SetHeader("Via", formatVia(transport, m.cfg.SIPHost, m.cfg.SIPPort, branch))
+	msg.SetHeader("Max-Forwards", "70")
+	msg.SetHeader("From", formatOutboundFromHeader(display, fromUser, m.cfg.SIPHost, m.cfg.SIPPort, fromTag))
+	msg.SetHeader("To", formatToHeader(msg.RequestURI))
+	msg.SetHeader("Call-ID", callID)
+	msg.SetHeader("CSeq", fmt.Sprintf("%d %s", cseq, stack.MethodMessage))
+	transaction.SetRouteHeaders(msg, req.Target.Route)
+	msg.SetHeader("User-Agent", "SoulNexus-SIP/1.0")
+	msg.SetHeader("Content-Type", strings.TrimSpace(ct))
+	msg.SetHeader("Content-Length", strconv.Itoa(stack.BodyBytesLen(msg.Body)))
+	return msg
+}
+
+// run sends msg and waits for the final response matching txKey; nil
+// means Timer F fired.
+func (tx *messageTx) run(ctx context.Context, peer signalingPeer, msg *stack.Message, txKey string, retransmit bool) (*stack.Message, error) {
+	for len(tx.respCh) > 0 {
+		<-tx.respCh
+	}
+	if err := peer.Send(msg); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(messageTxTimeout)
+	defer timeout.Stop()
+	// Timer E only runs over UDP; a nil channel never fires.
+	interval := messageT1
+	var timerE *time.Timer
+	var timerEC <-chan time.Time
+	if retransmit {
+		timerE = time.NewTimer(interval)
+		defer timerE.Stop()
+		timerEC = timerE.C
+	}
+	for {
+		select {
+		case resp := <-tx.respCh:
+			if txKeyFromResponse(resp) != txKey {
+				continue
+			}
+			if resp.StatusCode >= 200 {
+				return resp, nil
+			}
+			// RFC 3261 §17.1.2.2: a provisional moves Timer E to T2.
+			interval = messageT2
+		case <-timerEC:
+			_ = peer.Send(msg)
+			if interval *= 2; interval > messageT2 {
+				interval = messageT2
+			}
+			timerE.Reset(interval)
+		case <-timeout.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// routeMessageResponse hands a MESSAGE response to its transaction.
+func (m *Manager) routeMessageResponse(resp *stack.Message) bool {
+	if !strings.HasSuffix(strings.ToUpper(strings.TrimSpace(resp.GetHeader("CSeq"))), stack.MethodMessage) {
+		return false
+	}
+	m.msgMu.Lock()
+	tx := m.msgByCallID[strings.TrimSpace(resp.GetHeader("Call-ID"))]
+	m.msgMu.Unlock()
+	if tx == nil {
+		return false
+	}
+	select {
+	case tx.respCh <- resp:
+	default:
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestSendMessage_DigestRetry(t *testing.T) {
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.1", SIPPort: 5060, FromUser: "gw"})
+	reg := &fakeRegistrar{}
+	reg.reply = func(req *stack.Message, _ *net.UDPAddr) *stack.Message {
+		if req.GetHeader("Proxy-Authorization") == "" {
+			resp := registerResponse(req, 407)
+			resp.SetHeader("Proxy-Authenticate", `Digest realm="carrier", nonce="n1"`)
+			return resp
+		}
+		return registerResponse(req, 202)
+	}
+	reg.bind(m)
+
+	res, err := m.SendMessage(context.Background(), MessageRequest{
+		Target: DialTarget{
+			RequestURI:    "sip:13800138000@192.0.2.20:5060",
+			SignalingAddr: "192.0.2.20:5060",
+			CallerUser:    "4001",
+			Auth:          &DigestCredentials{Username: "acct", Password: "pw", Realm: "carrier"},
+		},
+		Body: "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Delivered() || res.Status != 202 {
+		t.Fatalf("result %+v", res)
+	}
+	got, _ := reg.requests()
+	if len(got) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(got))
+	}
+	req := got[1]
+	if req.Method != stack.MethodMessage || req.GetHeader("CSeq") != "2 MESSAGE" {
+		t.Fatalf("retry %s / %q", req.Method, req.GetHeader("CSeq"))
+	}
+	if got[0].GetHeader("Call-ID") != req.GetHeader("Call-ID") {
+		t.Fatal("retry changed Call-ID")
+	}
+	if ct := req.GetHeader("Content-Type"); ct != DefaultMessageContentType {
+		t.Fatalf("content-type %q", ct)
+	}
+	if from := req.GetHeader("From"); !strings.Contains(from, "sip:4001@") {
+		t.Fatalf("from %q, want trunk caller", from)
+	}
+}
+
+func TestSendMessage_RetransmitThenTimeout(t *testing.T) {
+	t1, t2, tf := messageT1, messageT2, messageTxTimeout
+	messageT1, messageT2, messageTxTimeout = 10*time.Millisecond, 20*time.Millisecond, 120*time.Millisecond
+	defer func() { messageT1, messageT2, messageTxTimeout = t1, t2, tf }()
+
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.1", SIPPort: 5060})
+	var sent atomic.Int32
+	reg := &fakeRegistrar{}
+	reg.reply = func(*stack.Message, *net.UDPAddr) *stack.Message {
+		sent.Add(1)
+		return nil
+	}
+	reg.bind(m)
+
+	res, err := m.SendMessage(context.Background(), MessageRequest{
+		Target: DialTarget{RequestURI: "sip:1001@192.0.2.30:5060", SignalingAddr: "192.0.2.30:5060"},
+		Body:   "ping",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != 408 {
+		t.Fatalf("status %d, want 408", res.Status)
+	}
+	if n := sent.Load(); n < 3 {
+		t.Fatalf("sent %d times, want Timer E retransmissions", n)
+	}
+	m.msgMu.Lock()
+	left := len(m.msgByCallID)
+	m.msgMu.Unlock()
+	if left != 0 {
+		t.Fatalf("%d MESSAGE transactions leaked", left)
+	}
+}
+
+func TestSendMessage_EmptyBody(t *testing.T) {
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.1", SIPPort: 5060})
+	(&fakeRegistrar{reply: func(*stack.Message, *net.UDPAddr) *stack.Message { return nil }}).bind(m)
+	if _, err := m.SendMessage(context.Background(), MessageRequest{Body: "  "}); err != ErrEmptyMessage {
+		t.Fatalf("err %v", err)
+	}
+}
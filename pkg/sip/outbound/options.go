@@ -0,0 +1,368 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	sipMetrics "github.com/LinByte/VoiceServer/pkg/sip/metrics"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"go.uber.org/zap"
+)
+
+// Trunk keep-alive (RFC 3261 §11 OPTIONS).
+//
+// Each probed trunk gets one goroutine that sends OPTIONS to its gateway
+// over the shared UDP socket every interval. Any response except 408 /
+// 5xx (501 Not Implemented still proves the gateway is alive) counts as
+// a success. A trunk starts up, goes down after optionsDownAfter
+// consecutive failures and comes back on the first success. The routing
+// layer reads TrunkUp to skip or demote down members.
+
+// Timers are variables so tests can shrink them. SIP_TRUNK_OPTIONS_INTERVAL_SEC
+// overrides the interval.
+var (
+	optionsInterval  = 30 * time.Second
+	optionsTxTimeout = 5 * time.Second
+)
+
+func probeInterval() time.Duration {
+	if sec, ok := envInt("SIP_TRUNK_OPTIONS_INTERVAL_SEC"); ok && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return optionsInterval
+}
+
+// optionsDownAfter is how many consecutive failed probes mark a trunk down.
+const optionsDownAfter = 2
+
+// HealthTarget is one trunk gateway to keep probing.
+type HealthTarget struct {
+	TrunkID uint
+	// Addr is the gateway: IP:port, host:port or a domain (RFC 3263, UDP).
+	Addr string
+}
+
+// TrunkHealth is the admin-facing keep-alive snapshot of one trunk.
+type TrunkHealth struct {
+	TrunkID    uint       `json:"trunkId"`
+	Addr       string     `json:"addr"`
+	Up         bool       `json:"up"`
+	LastStatus int        `json:"lastStatus,omitempty"`
+	LastRTTMs  int64      `json:"lastRttMs,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+	Failures   int        `json:"failures"` // consecutive
+	CheckedAt  *time.Time `json:"checkedAt,omitempty"`
+	ChangedAt  time.Time  `json:"changedAt"`
+}
+
+type trunkProbe struct {
+	m       *Manager
+	target  HealthTarget
+	callID  string
+	fromTag string
+
+	// cseq is only touched by the run goroutine.
+	cseq int
+
+	respCh   chan *stack.Message
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+
+	mu     sync.Mutex
+	status TrunkHealth
+}
+
+func newTrunkProbe(m *Manager, t HealthTarget) *trunkProbe {
+	return &trunkProbe{
+		m:       m,
+		target:  t,
+		callID:  randomHex(12) + "@" + nonEmpty(m.cfg.SIPHost, "127.0.0.1"),
+		fromTag: randomHex(6),
+		respCh:  make(chan *stack.Message, 4),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+		status:  TrunkHealth{TrunkID: t.TrunkID, Addr: t.Addr, Up: true, ChangedAt: time.Now()},
+	}
+}
+
+func (p *trunkProbe) stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+	<-p.doneCh
+}
+
+func (p *trunkProbe) snapshot() TrunkHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+func (p *trunkProbe) run() {
+	defer close(p.doneCh)
+	ticker := time.NewTicker(probeInterval())
+	defer ticker.Stop()
+	for {
+		p.probe()
+		select {
+		case <-ticker.C:
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+func (p *trunkProbe) buildOPTIONS(host string, branch string) *stack.Message {
+	m := p.m
+	msg := &stack.Message{
+		IsRequest:  true,
+		Method:     stack.MethodOptions,
+		RequestURI: "sip:" + host,
+		Version:    "SIP/2.0",
+	}
+	local := fmt.Sprintf("sip:%s@%s:%d", sanitizeSIPUser(m.cfg.FromUser),
+		nonEmpty(m.cfg.SIPHost, "127.0.0.1"), nonZero(m.cfg.SIPPort, 6050))
+	msg.SetHeader("Via", formatVia(TransportUDP, m.cfg.SIPHost, m.cfg.SIPPort, branch))
+	msg.SetHeader("Max-Forwards", "70")
+	msg.SetHeader("From", "<"+local+">;tag="+p.fromTag)
+	msg.SetHeader("To", "<sip:"+host+">")
+	msg.SetHeader("Call-ID", p.callID)
+	msg.SetHeader("CSeq", fmt.Sprintf("%d %s", p.cseq, stack.MethodOptions))
+	msg.SetHeader("Contact", "<"+local+">")
+	msg.SetHeader("Accept", "application/sdp")
+	msg.SetHeader("User-Agent", "SoulNexus-SIP/1.0")
+	msg.SetHeader("Content-Length", "0")
+	return msg
+}
+
+// probe runs one OPTIONS transaction and folds the outcome into status.
+func (p *trunkProbe) probe() {
+	ctx, cancel := context.WithTimeout(context.Background(), optionsTxTimeout)
+	defer cancel()
+	hops, err := p.m.Locator().Resolve(ctx, DialTarget{
+		RequestURI:    "sip:" + p.target.Addr,
+		SignalingAddr: p.target.Addr,
+		Transport:     TransportUDP,
+	})
+	if err != nil {
+		p.record(0, 0, sipMetrics.TrunkOptionsError, err.Error())
+		return
+	}
+	dst := hops[0].Addr
+	host := hops[0].Host
+	if host == "" {
+		host = dst.IP.String()
+	}
+	p.cseq++
+	branch := randomHex(10)
+	for len(p.respCh) > 0 {
+		<-p.respCh
+	}
+	start := time.Now()
+	if err := p.m.send(p.buildOPTIONS(host, branch), dst); err != nil {
+		p.record(0, 0, sipMetrics.TrunkOptionsError, err.Error())
+		return
+	}
+	want := inviteTxKey(branch, p.cseq)
+	timer := time.NewTimer(optionsTxTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case resp := <-p.respCh:
+			if resp.StatusCode < 200 || txKeyFromResponse(resp) != want {
+				continue
+			}
+			st := resp.StatusCode
+			if st == 408 || (st >= 500 && st < 600 && st != 501) {
+				p.record(st, time.Since(start), sipMetrics.TrunkOptionsRejected, fmt.Sprintf("%d %s", st, resp.StatusText))
+			} else {
+				p.record(st, time.Since(start), sipMetrics.TrunkOptionsOK, "")
+			}
+			return
+		case <-timer.C:
+			p.record(0, 0, sipMetrics.TrunkOptionsTimeout, "no response to OPTIONS")
+			return
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+func (p *trunkProbe) record(status int, rtt time.Duration, result, errMsg string) {
+	sipMetrics.TrunkOptionsResult(result)
+	now := time.Now()
+	p.mu.Lock()
+	st := &p.status
+	wasUp := st.Up
+	st.LastStatus = status
+	st.LastError = errMsg
+	st.CheckedAt = &now
+	if result == sipMetrics.TrunkOptionsOK {
+		st.LastRTTMs = rtt.Milliseconds()
+		st.Failures = 0
+		st.Up = true
+	} else {
+		st.Failures++
+		if st.Failures >= optionsDownAfter {
+			st.Up = false
+		}
+	}
+	changed := st.Up != wasUp
+	if changed {
+		st.ChangedAt = now
+	}
+	snap := *st
+	p.mu.Unlock()
+	if !changed {
+		return
+	}
+	if snap.Up {
+		logger.Info("sip trunk up (OPTIONS)",
+			zap.Uint("trunk_id", snap.TrunkID), zap.String("addr", snap.Addr), zap.Int("status", status))
+	} else {
+		logger.Warn("sip trunk down (OPTIONS)",
+			zap.Uint("trunk_id", snap.TrunkID), zap.String("addr", snap.Addr),
+			zap.Int("failures", snap.Failures), zap.String("error", errMsg))
+	}
+	p.m.publishTrunkDownGauge()
+}
+
+// SetHealthTargets reconciles the OPTIONS keep-alive loops with targets:
+// new trunks start probing, removed or changed ones stop. Targets with
+// an empty Addr are ignored.
+func (m *Manager) SetHealthTargets(targets []HealthTarget) {
+	if m == nil {
+		return
+	}
+	want := make(map[uint]HealthTarget, len(targets))
+	for _, t := range targets {
+		t.Addr = strings.TrimSpace(t.Addr)
+		if t.TrunkID != 0 && t.Addr != "" {
+			want[t.TrunkID] = t
+		}
+	}
+	var stale, fresh []*trunkProbe
+	m.probeMu.Lock()
+	if m.probes == nil {
+		m.probes = make(map[uint]*trunkProbe)
+		m.probeByCallID = make(map[string]*trunkProbe)
+	}
+	for id, p := range m.probes {
+		if t, ok := want[id]; !ok || t != p.target {
+			stale = append(stale, p)
+			delete(m.probes, id)
+			delete(m.probeByCallID, p.callID)
+		}
+	}
+	for id, t := range want {
+		if _, ok := m.probes[id]; ok {
+			continue
+		}
+		p := newTrunkProbe(m, t)
+		m.probes[id] = p
+		m.probeByCallID[p.callID] = p
+		fresh = append(fresh, p)
+	}
+	m.probeMu.Unlock()
+	for _, p := range stale {
+		go p.stop()
+	}
+	for _, p := range fresh {
+		go p.run()
+	}
+	if len(stale) > 0 {
+		m.publishTrunkDownGauge()
+	}
+}
+
+// StopHealthChecks stops every keep-alive loop and waits for them.
+func (m *Manager) StopHealthChecks() {
+	if m == nil {
+		return
+	}
+	m.probeMu.Lock()
+	all := make([]*trunkProbe, 0, len(m.probes))
+	for id, p := range m.probes {
+		all = append(all, p)
+		delete(m.probes, id)
+		delete(m.probeByCallID, p.callID)
+	}
+	m.probeMu.Unlock()
+	for _, p := range all {
+		p.stop()
+	}
+	sipMetrics.TrunkDown(0)
+}
+
+// TrunkHealths returns every probed trunk's snapshot, ordered by trunk ID.
+func (m *Manager) TrunkHealths() []TrunkHealth {
+	if m == nil {
+		return nil
+	}
+	m.probeMu.Lock()
+	out := make([]TrunkHealth, 0, len(m.probes))
+	for _, p := range m.probes {
+		out = append(out, p.snapshot())
+	}
+	m.probeMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].TrunkID < out[j].TrunkID })
+	return out
+}
+
+// TrunkHealth returns the keep-alive snapshot for one trunk.
+func (m *Manager) TrunkHealth(trunkID uint) (TrunkHealth, bool) {
+	if m == nil {
+		return TrunkHealth{}, false
+	}
+	m.probeMu.Lock()
+	p := m.probes[trunkID]
+	m.probeMu.Unlock()
+	if p == nil {
+		return TrunkHealth{}, false
+	}
+	return p.snapshot(), true
+}
+
+// TrunkUp reports whether trunkID may be routed to. Trunks that are not
+// probed are assumed up.
+func (m *Manager) TrunkUp(trunkID uint) bool {
+	h, ok := m.TrunkHealth(trunkID)
+	return !ok || h.Up
+}
+
+// routeOptionsResponse hands an OPTIONS response to its probe.
+func (m *Manager) routeOptionsResponse(resp *stack.Message) bool {
+	if !strings.HasSuffix(strings.ToUpper(strings.TrimSpace(resp.GetHeader("CSeq"))), stack.MethodOptions) {
+		return false
+	}
+	m.probeMu.Lock()
+	p := m.probeByCallID[strings.TrimSpace(resp.GetHeader("Call-ID"))]
+	m.probeMu.Unlock()
+	if p == nil {
+		return false
+	}
+	select {
+	case p.respCh <- resp:
+	default:
+	}
+	return true
+}
+
+func (m *Manager) publishTrunkDownGauge() {
+	n := 0
+	m.probeMu.Lock()
+	for _, p := range m.probes {
+		if !p.snapshot().Up {
+			n++
+		}
+	}
+	m.probeMu.Unlock()
+	sipMetrics.TrunkDown(n)
+}
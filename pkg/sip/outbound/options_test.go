@@ -0,0 +1,177 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func waitTrunkHealth(t *testing.T, m *Manager, id uint, up bool) TrunkHealth {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if h, ok := m.TrunkHealth(id); ok && h.Up == up && h.CheckedAt != nil {
+			return h
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	h, _ := m.TrunkHealth(id)
+	t.Fatalf("trunk %d never reached up=%v: %+v", id, up, h)
+	return h
+}
+
+func TestTrunkHealth_OptionsDownAndRecover(t *testing.T) {
+	oldInterval, oldTimeout := optionsInterval, optionsTxTimeout
+	optionsInterval, optionsTxTimeout = 20*time.Millisecond, 30*time.Millisecond
+	defer func() { optionsInterval, optionsTxTimeout = oldInterval, oldTimeout }()
+
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.100", SIPPort: 5060})
+	var failing atomic.Bool
+	failing.Store(true)
+	gw := &fakeRegistrar{}
+	gw.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if req.Method != stack.MethodOptions || dst.String() != "192.0.2.10:5060" {
+			return nil
+		}
+		if failing.Load() {
+			return registerResponse(req, 503)
+		}
+		return registerResponse(req, 200)
+	}
+	gw.bind(m)
+
+	m.SetHealthTargets([]HealthTarget{{TrunkID: 7, Addr: "192.0.2.10:5060"}})
+	defer m.StopHealthChecks()
+
+	h := waitTrunkHealth(t, m, 7, false)
+	if h.Failures < optionsDownAfter || h.LastStatus != 503 {
+		t.Fatalf("down status: %+v", h)
+	}
+	if m.TrunkUp(7) {
+		t.Fatal("TrunkUp should report the trunk down")
+	}
+	if !m.TrunkUp(8) {
+		t.Fatal("unprobed trunks are up")
+	}
+	sent, _ := gw.requests()
+	if cseq := sent[0].GetHeader("CSeq"); cseq != "1 OPTIONS" {
+		t.Fatalf("CSeq = %q", cseq)
+	}
+
+	failing.Store(false)
+	h = waitTrunkHealth(t, m, 7, true)
+	if h.Failures != 0 || h.LastStatus != 200 {
+		t.Fatalf("recovered status: %+v", h)
+	}
+
+	m.SetHealthTargets(nil)
+	if _, ok := m.TrunkHealth(7); ok {
+		t.Fatal("removed target still probed")
+	}
+}
+
+func TestDial_FallbackTrunkOnServerError(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []DialEvent
+		gated  []string
+	)
+	m := NewManager(ManagerConfig{
+		SIPHost: "192.0.2.100",
+		SIPPort: 5060,
+		OnEvent: func(evt DialEvent) {
+			mu.Lock()
+			events = append(events, evt)
+			mu.Unlock()
+		},
+	})
+	m.SetDialGate(func(ctx context.Context, req DialRequest, callID string) error {
+		mu.Lock()
+		gated = append(gated, req.Target.SignalingAddr)
+		mu.Unlock()
+		return nil
+	})
+	carrier := &fakeRegistrar{}
+	carrier.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if req.Method != stack.MethodInvite {
+			return nil
+		}
+		if dst.String() == "192.0.2.1:5060" {
+			return registerResponse(req, 503)
+		}
+		return registerResponse(req, 180)
+	}
+	carrier.bind(m)
+
+	callID, err := m.Dial(context.Background(), DialRequest{
+		Target: DialTarget{
+			RequestURI:    "sip:13800138000@192.0.2.1:5060",
+			SignalingAddr: "192.0.2.1:5060",
+			CallerUser:    "02100000001",
+		},
+		Fallbacks: []DialTarget{{
+			RequestURI:    "sip:13800138000@192.0.2.2:5060",
+			SignalingAddr: "192.0.2.2:5060",
+			CallerUser:    "02100000002",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.AbandonEarlyTransferInvite(callID)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, dsts := carrier.requests(); len(dsts) >= 3 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sent, dsts := carrier.requests()
+	var got []string
+	for i, msg := range sent {
+		got = append(got, msg.Method+" "+msg.GetHeader("CSeq")+" → "+dsts[i])
+	}
+	want := []string{
+		"INVITE 1 INVITE → 192.0.2.1:5060",
+		"ACK 1 ACK → 192.0.2.1:5060",
+		"INVITE 2 INVITE → 192.0.2.2:5060",
+	}
+	if len(got) < 3 || strings.Join(got[:3], "\n") != strings.Join(want, "\n") {
+		t.Fatalf("sent:\n%s", strings.Join(got, "\n"))
+	}
+	if inv := sent[2]; inv.RequestURI != "sip:13800138000@192.0.2.2:5060" ||
+		!strings.Contains(inv.GetHeader("From"), "02100000002@") {
+		t.Fatalf("fallback INVITE: uri=%s from=%s", inv.RequestURI, inv.GetHeader("From"))
+	}
+	if sent[0].GetHeader("Call-ID") != sent[2].GetHeader("Call-ID") {
+		t.Error("fallback must keep the Call-ID")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(gated, ",") != "192.0.2.1:5060,192.0.2.2:5060" {
+		t.Errorf("dial gate saw %v", gated)
+	}
+	var rerouted *DialEvent
+	for i := range events {
+		if events[i].State == DialEventRerouted {
+			rerouted = &events[i]
+		}
+		if events[i].State == DialEventFailed {
+			t.Errorf("unexpected failed event: %+v", events[i])
+		}
+	}
+	if rerouted == nil || rerouted.StatusCode != 503 || rerouted.RemoteAddr != "192.0.2.1:5060" {
+		t.Fatalf("rerouted event: %+v", rerouted)
+	}
+}
@@ -17,6 +17,10 @@ package outbound
 //                RFC 3261 §18.2 + RFC 5923 conn reuse.
 //   * tlsPeer  — same shape as tcpPeer but on top of *tls.Conn.
 //                RFC 3261 §26.2 + sips: scheme.
+//   * flow peer — a udpPeer reporting WS / WSS: the UA's own
+//                WebSocket (RFC 7118), accepted by pkg/sip/server and
+//                bound on the shared endpoint, so the shared sender
+//                already writes onto it. We never dial WebSockets.
 //
 // The interface deliberately exposes a *net.UDPAddr for "remote
 // address" even when the conn is TCP/TLS — this lets the existing
@@ -84,12 +88,18 @@ type responseSink func(resp *stack.Message, addr *net.UDPAddr)
 // ---------------------------------------------------------------------------
 
 type udpPeer struct {
-	send udpSendFunc
-	dst  *net.UDPAddr
+	send      udpSendFunc
+	dst       *net.UDPAddr
+	transport Transport // TransportUDP, or WS / WSS for a server-side flow
 }
 
 func newUDPPeer(send udpSendFunc, dst *net.UDPAddr) *udpPeer {
-	return &udpPeer{send: send, dst: dst}
+	return &udpPeer{send: send, dst: dst, transport: TransportUDP}
+}
+
+// newFlowPeer sends over the inbound WebSocket flow bound to dst.
+func newFlowPeer(send udpSendFunc, dst *net.UDPAddr, transport Transport) *udpPeer {
+	return &udpPeer{send: send, dst: dst, transport: transport}
 }
 
 func (p *udpPeer) Send(msg *stack.Message) error {
@@ -100,9 +110,15 @@ func (p *udpPeer) Send(msg *stack.Message) error {
 }
 
 func (p *udpPeer) Remote() *net.UDPAddr { return p.dst }
-func (p *udpPeer) Transport() Transport { return TransportUDP }
 func (p *udpPeer) Close() error         { return nil }
 
+func (p *udpPeer) Transport() Transport {
+	if p == nil || !p.transport.IsValid() {
+		return TransportUDP
+	}
+	return p.transport
+}
+
 // ---------------------------------------------------------------------------
 // TCP / TLS peer — owns conn + read goroutine
 // ---------------------------------------------------------------------------
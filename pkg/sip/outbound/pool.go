@@ -178,6 +178,11 @@ func (p *signalingPool) Get(ctx context.Context, transport Transport, dst *net.U
 			return nil, fmt.Errorf("sip/outbound: pool has no UDP sender")
 		}
 		return newUDPPeer(p.cfg.UDPSend, dst), nil
+	case TransportWS, TransportWSS:
+		if p.cfg.UDPSend == nil {
+			return nil, fmt.Errorf("sip/outbound: pool has no UDP sender")
+		}
+		return newFlowPeer(p.cfg.UDPSend, dst, transport), nil
 	case TransportTCP, TransportTLS:
 		// fall through
 	default:
@@ -29,6 +29,7 @@ func flushOutboundCallQoS(leg *outLeg) {
 		return
 	}
 	snap := leg.rtpSess.RTCPSnapshot()
+	sipMetrics.ObserveCallNACK(snap.NACKedPackets, snap.NACKsReceived, snap.Retransmits)
 	if !snap.PeerSeenRR && snap.LocalJitter == 0 {
 		// No RTCP traffic at all — nothing to record. Avoid
 		// polluting histograms with synthetic zero data.
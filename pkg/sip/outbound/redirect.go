@@ -0,0 +1,281 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/historyinfo"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"go.uber.org/zap"
+)
+
+// RFC 3261 §8.1.3.4 redirect recursion for outbound INVITEs. A 3xx
+// final response (call forwarding, number portability) is ACKed and
+// its Contact URIs are queued in q-value order; the INVITE is re-sent
+// to the first one on the same Call-ID and From tag (new branch,
+// CSeq+1). When a redirected target answers with a final failure the
+// next queued contact is tried before trunk failover kicks in.
+//
+// The trunk stays the route: the redirected target keeps the current
+// SignalingAddr, credentials and caller identity, only the
+// Request-URI changes (like an outbound proxy). Each retarget appends
+// to the INVITE's History-Info (RFC 7044) and Diversion (RFC 5806)
+// chains so the far end sees where the call was forwarded from.
+
+// defaultMaxRedirects bounds how many times one leg may be retargeted
+// by 3xx responses; SIP_OUTBOUND_MAX_REDIRECTS overrides (0 disables
+// redirect recursion entirely).
+const defaultMaxRedirects = 5
+
+func maxRedirects() int {
+	if n, ok := envInt("SIP_OUTBOUND_MAX_REDIRECTS"); ok && n >= 0 {
+		return n
+	}
+	return defaultMaxRedirects
+}
+
+// redirectState is the per-leg recursion bookkeeping; guarded by sigMu.
+type redirectState struct {
+	// count is how many redirected INVITEs have been sent.
+	count int
+	// pending are contacts still to try, best first.
+	pending []string
+	// visited holds every Request-URI already tried (loop detection),
+	// normalised by redirectKey.
+	visited map[string]struct{}
+	// originalURI is the Request-URI before the first redirect.
+	originalURI string
+	// status is the 3xx that produced pending (History-Info cause).
+	status int
+}
+
+// redirectContact is one Contact from a 3xx with its q-value.
+type redirectContact struct {
+	uri string
+	q   float64
+}
+
+// parseRedirectContacts returns the sip/sips Contact URIs of a 3xx,
+// highest q first (RFC 3261 §16.6; missing q counts as 1.0, ties keep
+// header order).
+func parseRedirectContacts(resp *stack.Message) []string {
+	var list []redirectContact
+	for _, c := range splitContacts(resp.GetHeaders("Contact")) {
+		uri, params := c, ""
+		if lt := strings.Index(c, "<"); lt >= 0 {
+			gt := strings.Index(c[lt:], ">")
+			if gt < 0 {
+				continue
+			}
+			uri, params = c[lt+1:lt+gt], c[lt+gt+1:]
+		} else if semi := strings.Index(c, ";"); semi >= 0 {
+			// Without angle brackets every ;param is a header param.
+			uri, params = c[:semi], c[semi:]
+		}
+		uri = strings.TrimSpace(uri)
+		lower := strings.ToLower(uri)
+		if !strings.HasPrefix(lower, "sip:") && !strings.HasPrefix(lower, "sips:") {
+			continue
+		}
+		q := 1.0
+		if v, ok := uriParam(params, "q"); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+				q = f
+			}
+		}
+		list = append(list, redirectContact{uri: uri, q: q})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+	out := make([]string, 0, len(list))
+	for _, c := range list {
+		out = append(out, c.uri)
+	}
+	return out
+}
+
+// redirectKey normalises a Request-URI for loop detection.
+func redirectKey(uri string) string {
+	return strings.ToLower(strings.TrimSpace(uri))
+}
+
+// redirectReason renders the History-Info Reason for a 3xx retarget.
+func redirectReason(status int) string {
+	text := "Moved Temporarily"
+	switch status {
+	case 300:
+		text = "Multiple Choices"
+	case 301:
+		text = "Moved Permanently"
+	case 305:
+		text = "Use Proxy"
+	case 380:
+		text = "Alternative Service"
+	}
+	return "SIP;cause=" + strconv.Itoa(status) + ";text=\"" + text + "\""
+}
+
+// redirectDiversionReason maps the 3xx to an RFC 5806 reason.
+func redirectDiversionReason(status int) string {
+	if status == 301 || status == 302 {
+		return historyinfo.DiversionUnconditional
+	}
+	return historyinfo.DiversionUnknown
+}
+
+// followRedirect handles a final INVITE response before trunk
+// failover: a 3xx queues its contacts and retargets the INVITE; any
+// other final failure moves on to the next queued contact. false →
+// nothing left to try (or recursion disabled); the caller carries on
+// with trunk failover / failing the leg.
+func (leg *outLeg) followRedirect(resp *stack.Message, from *net.UDPAddr) bool {
+	if leg == nil || leg.m == nil || resp == nil {
+		return false
+	}
+	st := resp.StatusCode
+	if st < 300 || st >= 700 {
+		return false
+	}
+	limit := maxRedirects()
+	if limit == 0 {
+		return false
+	}
+	var contacts []string
+	if st < 400 {
+		contacts = parseRedirectContacts(resp)
+	}
+	leg.sigMu.Lock()
+	txKey := leg.txKey
+	if key := txKeyFromResponse(resp); key != "" && key != txKey {
+		leg.sigMu.Unlock()
+		return false
+	}
+	rd := &leg.redirect
+	if st < 400 {
+		if rd.visited == nil {
+			rd.visited = map[string]struct{}{redirectKey(leg.params.RequestURI): {}}
+			rd.originalURI = strings.TrimSpace(leg.params.RequestURI)
+		}
+		// A nested 3xx's contacts go ahead of the older alternatives.
+		rd.pending = append(contacts, rd.pending...)
+		rd.status = st
+	}
+	if len(rd.pending) == 0 {
+		leg.sigMu.Unlock()
+		if st < 400 {
+			logger.Warn("sip outbound redirect without usable contact",
+				zap.String("call_id", leg.params.CallID),
+				zap.Int("status", st))
+		}
+		return false
+	}
+	ack := buildACK(leg.params, resp, leg.params.RequestURI)
+	leg.sigMu.Unlock()
+	if err := leg.sendOnPeer(ack, from); err != nil {
+		logger.Warn("sip outbound ACK before redirect failed",
+			zap.String("call_id", leg.params.CallID), zap.Error(err))
+	}
+
+	for {
+		leg.sigMu.Lock()
+		if txKey != leg.txKey {
+			leg.sigMu.Unlock()
+			return false
+		}
+		var uri string
+		for len(rd.pending) > 0 && uri == "" {
+			c := rd.pending[0]
+			rd.pending = rd.pending[1:]
+			if _, seen := rd.visited[redirectKey(c)]; !seen {
+				uri = c
+			}
+		}
+		if uri == "" || rd.count >= limit {
+			if uri != "" {
+				logger.Warn("sip outbound redirect limit reached",
+					zap.String("call_id", leg.params.CallID),
+					zap.Int("limit", limit))
+			}
+			rd.pending = nil
+			leg.sigMu.Unlock()
+			return false
+		}
+		rd.count++
+		rd.visited[redirectKey(uri)] = struct{}{}
+		prev := leg.req.Target
+		next := prev
+		next.RequestURI = uri
+		prevURI := strings.TrimSpace(leg.params.RequestURI)
+		cause := rd.status
+		callID := leg.params.CallID
+		original := rd.originalURI
+		leg.params.HistoryInfo = historyinfo.AppendTransferEntry(leg.params.HistoryInfo, prevURI, uri, redirectReason(cause))
+		leg.params.Diversion = historyinfo.AppendDiversionEntry(leg.params.Diversion, prevURI, redirectDiversionReason(cause))
+		if leg.hopTimer != nil {
+			leg.hopTimer.Stop()
+			leg.hopTimer = nil
+		}
+		leg.sigMu.Unlock()
+
+		leg.cdrAddRedirect(original, uri)
+		if leg.m.cfg.OnEvent != nil {
+			leg.m.cfg.OnEvent(DialEvent{
+				CallID:             callID,
+				CorrelationID:      strings.TrimSpace(leg.req.CorrelationID),
+				Scenario:           leg.req.Scenario,
+				MediaProfile:       leg.req.MediaProfile,
+				State:              DialEventRedirected,
+				StatusCode:         st,
+				StatusText:         strings.TrimSpace(resp.StatusText),
+				RemoteAddr:         udpAddrString(from),
+				RequestURI:         uri,
+				OriginalRequestURI: original,
+				At:                 time.Now(),
+			})
+		}
+		hop, peer, err := leg.prepareTrunk(next)
+		if err == nil && leg.resendINVITEToHop(hop, peer) {
+			logger.Info("sip outbound INVITE redirected",
+				zap.String("call_id", callID),
+				zap.Int("status", st),
+				zap.String("from_uri", prevURI),
+				zap.String("to_uri", uri),
+				zap.String("hop", hop.String()))
+			leg.sigMu.Lock()
+			txKey = leg.txKey
+			leg.sigMu.Unlock()
+			leg.armHopTimer()
+			return true
+		}
+		logger.Warn("sip outbound redirect target skipped",
+			zap.String("call_id", callID),
+			zap.String("request_uri", uri),
+			zap.Error(err))
+	}
+}
+
+// resetRedirectsLocked drops queued contacts and the redirect additions
+// to History-Info / Diversion when the leg moves to a fallback trunk,
+// which dials its own Request-URI. Caller holds sigMu.
+func (leg *outLeg) resetRedirectsLocked() {
+	leg.redirect.pending = nil
+	leg.params.HistoryInfo = leg.req.HistoryInfo
+	leg.params.Diversion = leg.req.Diversion
+}
+
+// originalRequestURI is the pre-redirect Request-URI ("" when the leg
+// was never redirected).
+func (leg *outLeg) originalRequestURI() string {
+	leg.sigMu.Lock()
+	defer leg.sigMu.Unlock()
+	if leg.redirect.count == 0 {
+		return ""
+	}
+	return leg.redirect.originalURI
+}
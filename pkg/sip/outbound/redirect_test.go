@@ -0,0 +1,188 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestParseRedirectContacts_QOrder(t *testing.T) {
+	resp := &stack.Message{StatusCode: 302}
+	resp.AddHeader("Contact", `<sip:a@example.com>;q=0.5, "B" <sip:b@example.com;user=phone>;q=0.9`)
+	resp.AddHeader("Contact", `<tel:+15551234>, sip:c@example.com;expires=60, <sips:d@example.com>`)
+	got := parseRedirectContacts(resp)
+	want := []string{"sip:c@example.com", "sips:d@example.com", "sip:b@example.com;user=phone", "sip:a@example.com"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func waitSent(f *fakeRegistrar, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if sent, _ := f.requests(); len(sent) >= n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDial_FollowsRedirectInQOrder(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []DialEvent
+	)
+	m := NewManager(ManagerConfig{
+		SIPHost: "192.0.2.100",
+		SIPPort: 5060,
+		OnEvent: func(evt DialEvent) {
+			mu.Lock()
+			events = append(events, evt)
+			mu.Unlock()
+		},
+	})
+	carrier := &fakeRegistrar{}
+	carrier.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if req.Method != stack.MethodInvite {
+			return nil
+		}
+		switch req.RequestURI {
+		case "sip:13800138000@192.0.2.1:5060":
+			resp := registerResponse(req, 302)
+			resp.SetHeader("Contact", "<sip:13900139001@192.0.2.1:5060>;q=0.4, <sip:13900139002@192.0.2.1:5060>;q=0.8")
+			return resp
+		case "sip:13900139002@192.0.2.1:5060":
+			return registerResponse(req, 486)
+		}
+		return registerResponse(req, 180)
+	}
+	carrier.bind(m)
+
+	callID, err := m.Dial(context.Background(), DialRequest{
+		Target: DialTarget{
+			RequestURI:    "sip:13800138000@192.0.2.1:5060",
+			SignalingAddr: "192.0.2.1:5060",
+			CallerUser:    "02100000001",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.AbandonEarlyTransferInvite(callID)
+
+	waitSent(carrier, 5)
+	sent, _ := carrier.requests()
+	var got []string
+	for _, msg := range sent {
+		got = append(got, msg.Method+" "+msg.GetHeader("CSeq")+" "+msg.RequestURI)
+	}
+	want := []string{
+		"INVITE 1 INVITE sip:13800138000@192.0.2.1:5060",
+		"ACK 1 ACK sip:13800138000@192.0.2.1:5060",
+		"INVITE 2 INVITE sip:13900139002@192.0.2.1:5060",
+		"ACK 2 ACK sip:13900139002@192.0.2.1:5060",
+		"INVITE 3 INVITE sip:13900139001@192.0.2.1:5060",
+	}
+	if len(got) < 5 || strings.Join(got[:5], "\n") != strings.Join(want, "\n") {
+		t.Fatalf("sent:\n%s", strings.Join(got, "\n"))
+	}
+	first := sent[2]
+	if hi := first.GetHeader("History-Info"); !strings.Contains(hi, "sip:13800138000@") ||
+		!strings.Contains(hi, "sip:13900139002@") || !strings.Contains(hi, "cause%3D302") {
+		t.Errorf("History-Info = %q", hi)
+	}
+	if div := first.GetHeader("Diversion"); !strings.Contains(div, "sip:13800138000@") ||
+		!strings.Contains(div, "unconditional") {
+		t.Errorf("Diversion = %q", div)
+	}
+	if sent[0].GetHeader("Call-ID") != sent[4].GetHeader("Call-ID") {
+		t.Error("redirect must keep the Call-ID")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var redirected []string
+	var provisional *DialEvent
+	for i, e := range events {
+		switch e.State {
+		case DialEventRedirected:
+			redirected = append(redirected, e.RequestURI)
+			if e.OriginalRequestURI != "sip:13800138000@192.0.2.1:5060" {
+				t.Errorf("redirected event original = %q", e.OriginalRequestURI)
+			}
+		case DialEventProvisional:
+			provisional = &events[i]
+		case DialEventFailed:
+			t.Errorf("unexpected failed event: %+v", e)
+		}
+	}
+	if strings.Join(redirected, ",") != "sip:13900139002@192.0.2.1:5060,sip:13900139001@192.0.2.1:5060" {
+		t.Errorf("redirected events: %v", redirected)
+	}
+	if provisional == nil || provisional.RequestURI != "sip:13900139001@192.0.2.1:5060" ||
+		provisional.OriginalRequestURI != "sip:13800138000@192.0.2.1:5060" {
+		t.Errorf("provisional event: %+v", provisional)
+	}
+}
+
+func TestDial_RedirectLoopFails(t *testing.T) {
+	t.Setenv("SIP_OUTBOUND_MAX_REDIRECTS", "2")
+	failed := make(chan DialEvent, 1)
+	m := NewManager(ManagerConfig{
+		SIPHost: "192.0.2.100",
+		SIPPort: 5060,
+		OnEvent: func(evt DialEvent) {
+			if evt.State == DialEventFailed {
+				failed <- evt
+			}
+		},
+	})
+	carrier := &fakeRegistrar{}
+	carrier.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if req.Method != stack.MethodInvite {
+			return nil
+		}
+		// a → b → a: the second hop points back at an already-tried URI.
+		resp := registerResponse(req, 302)
+		if strings.Contains(req.RequestURI, "sip:a@") {
+			resp.SetHeader("Contact", "<sip:b@192.0.2.1:5060>")
+		} else {
+			resp.SetHeader("Contact", "<sip:a@192.0.2.1:5060>")
+		}
+		return resp
+	}
+	carrier.bind(m)
+
+	if _, err := m.Dial(context.Background(), DialRequest{
+		Target: DialTarget{RequestURI: "sip:a@192.0.2.1:5060", SignalingAddr: "192.0.2.1:5060"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case evt := <-failed:
+		if evt.StatusCode != 302 || evt.RequestURI != "sip:b@192.0.2.1:5060" ||
+			evt.OriginalRequestURI != "sip:a@192.0.2.1:5060" {
+			t.Fatalf("failed event: %+v", evt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("redirect loop never failed")
+	}
+	var invites int
+	sent, _ := carrier.requests()
+	for _, msg := range sent {
+		if msg.Method == stack.MethodInvite {
+			invites++
+		}
+	}
+	if invites != 2 {
+		t.Fatalf("sent %d INVITEs, want 2", invites)
+	}
+}
@@ -0,0 +1,78 @@
+package outbound
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func buildREFER(inv inviteParams, toHeader200, requestURI, referTo string, cseq int, branch string) *stack.Message {
+	reqURI := strings.TrimSpace(requestURI)
+	if reqURI == "" {
+		reqURI = inv.RequestURI
+	}
+	msg := &stack.Message{
+		IsRequest:  true,
+		Method:     stack.MethodRefer,
+		RequestURI: reqURI,
+		Version:    "SIP/2.0",
+	}
+	msg.SetHeader("Via", formatVia(inv.ViaTransport, inv.SIPHost, inv.SIPPort, branch))
+	msg.SetHeader("Max-Forwards", "70")
+	msg.SetHeader("From", formatOutboundFromHeader(inv.FromDisplayName, inv.FromUser, inv.SIPHost, inv.SIPPort, inv.FromTag))
+	if strings.TrimSpace(toHeader200) != "" {
+		msg.SetHeader("To", toHeader200)
+	} else {
+		msg.SetHeader("To", formatToHeader(inv.RequestURI))
+	}
+	msg.SetHeader("Call-ID", inv.CallID)
+	msg.SetHeader("CSeq", fmt.Sprintf("%d %s", cseq, stack.MethodRefer))
+	msg.SetHeader("Contact", formatOutboundContact(inv.FromUser, inv.SIPHost, inv.SIPPort))
+	msg.SetHeader("Refer-To", formatToHeader(referTo))
+	msg.SetHeader("Referred-By", formatOutboundContact(inv.FromUser, inv.SIPHost, inv.SIPPort))
+	msg.SetHeader("Content-Length", "0")
+	return msg
+}
+
+// SendREFER asks the remote party of an established outbound leg to
+// call referTo (RFC 3515 blind transfer). Like BYE it reuses the dialog
+// state from the 200 OK and answers a 401/407 with trunk credentials.
+// The 202 / NOTIFY progress is only logged.
+func (m *Manager) SendREFER(callID, referTo string) error {
+	if m == nil || m.send == nil {
+		return fmt.Errorf("sip/outbound: manager not ready")
+	}
+	callID = strings.TrimSpace(callID)
+	referTo = strings.TrimSpace(referTo)
+	if callID == "" || referTo == "" {
+		return fmt.Errorf("sip/outbound: empty call-id or refer-to")
+	}
+	leg := m.legByCallIDOrHostRewrite(callID)
+	if leg == nil {
+		return fmt.Errorf("sip/outbound: unknown call-id %s", callID)
+	}
+	leg.sigMu.Lock()
+	defer leg.sigMu.Unlock()
+	if strings.TrimSpace(leg.byeToHeader) == "" {
+		return fmt.Errorf("sip/outbound: dialog not ready for REFER")
+	}
+	dst := leg.byeRemote
+	if dst == nil {
+		dst = leg.dst
+	}
+	if dst == nil {
+		return fmt.Errorf("sip/outbound: no signaling address for REFER")
+	}
+	cseq := leg.byeCSeqNext
+	if cseq <= 0 {
+		cseq = leg.params.CSeq + 1
+	}
+	leg.byeCSeqNext = cseq + 1
+	params, toH, reqURI := leg.params, leg.byeToHeader, leg.byeRequestURI
+	msg := buildREFER(params, toH, reqURI, referTo, cseq, randomHex(10))
+	leg.prepareInDialogLocked(msg, cseq, dst, func(cseq int, branch string) *stack.Message {
+		return buildREFER(params, toH, reqURI, referTo, cseq, branch)
+	})
+	return leg.sendOnPeer(msg, dst)
+}
@@ -188,7 +188,13 @@ func (r *outRefresher) sendUPDATE() bool {
 	r.mu.Unlock()
 
 	branch := randomHex(10)
-	msg := buildUPDATE(leg.params, toH, reqURI, cseq, branch, se, minSE)
+	params := leg.params
+	msg := buildUPDATE(params, toH, reqURI, cseq, branch, se, minSE)
+	leg.sigMu.Lock()
+	leg.prepareInDialogLocked(msg, cseq, dst, func(cseq int, branch string) *stack.Message {
+		return buildUPDATE(params, toH, reqURI, cseq, branch, se, minSE)
+	})
+	leg.sigMu.Unlock()
 	if err := leg.sendOnPeer(msg, dst); err != nil {
 		logger.Warn("sip outbound refresh UPDATE send failed",
 			zap.String("call_id", leg.params.CallID),
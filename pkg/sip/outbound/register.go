@@ -0,0 +1,684 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	sipMetrics "github.com/LinByte/VoiceServer/pkg/sip/metrics"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"go.uber.org/zap"
+)
+
+// Outbound REGISTER client (RFC 3261 §10).
+//
+// Carriers that deliver DIDs to a registered account need us to keep a
+// binding alive. Each registering trunk gets one goroutine that sends
+// REGISTER, answers 401/407 with the trunk digest account, honours 423
+// Min-Expires and refreshes at half the granted expiry. Each registrar
+// is located like an INVITE target (RFC 3263, resolve.go) and sent over
+// the hop's transport; a hop that answers 503 or stays silent is
+// blacklisted and the next hop tried. When a registrar fails the next
+// address in the list is tried at once; when all fail the loop backs
+// off (30s doubling to 10m). The Contact carries a per-registration ;line= token so inbound
+// INVITEs sent to it can be matched back to the trunk
+// (RegisteredContactTrunk).
+
+// Timers are variables so tests can shrink them.
+var (
+	registerTxTimeout  = 8 * time.Second
+	registerRetryMin   = 30 * time.Second
+	registerRetryMax   = 10 * time.Minute
+	registerRefreshMin = 15 * time.Second
+)
+
+// DefaultRegisterExpires is requested when RegistrationConfig.Expires is unset.
+const DefaultRegisterExpires = 3600
+
+// RegistrationConfig describes one trunk registration.
+type RegistrationConfig struct {
+	TrunkID uint
+	// Registrars are IP, host:port or bare domain addresses tried in
+	// order (failover); each is located per RFC 3263.
+	Registrars []string
+	// Domain is the AOR host; empty uses the registrar host.
+	Domain string
+	// Expires is the requested binding lifetime in seconds.
+	Expires int
+	// Auth is the trunk account; Username is also the AOR user.
+	Auth DigestCredentials
+}
+
+func (c RegistrationConfig) equal(o RegistrationConfig) bool {
+	if c.TrunkID != o.TrunkID || c.Domain != o.Domain || c.Expires != o.Expires || c.Auth != o.Auth ||
+		len(c.Registrars) != len(o.Registrars) {
+		return false
+	}
+	for i := range c.Registrars {
+		if c.Registrars[i] != o.Registrars[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// RegistrationState is the lifecycle state reported for a trunk registration.
+type RegistrationState string
+
+const (
+	RegistrationRegistering  RegistrationState = "registering"
+	RegistrationRegistered   RegistrationState = "registered"
+	RegistrationFailed       RegistrationState = "failed"
+	RegistrationUnregistered RegistrationState = "unregistered"
+)
+
+// RegistrationStatus is the admin-facing snapshot of one registration.
+type RegistrationStatus struct {
+	TrunkID      uint              `json:"trunkId"`
+	State        RegistrationState `json:"state"`
+	Registrar    string            `json:"registrar,omitempty"`
+	Contact      string            `json:"contact,omitempty"`
+	Expires      int               `json:"expires,omitempty"`
+	RegisteredAt *time.Time        `json:"registeredAt,omitempty"`
+	ExpiresAt    *time.Time        `json:"expiresAt,omitempty"`
+	LastStatus   int               `json:"lastStatus,omitempty"`
+	LastError    string            `json:"lastError,omitempty"`
+	Failures     int               `json:"failures"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
+
+var errRegisterStopped = errors.New("sip/outbound: registration stopped")
+
+// registerError is a failed REGISTER transaction; result is a
+// sipMetrics.RegisterResult* value.
+type registerError struct {
+	result string
+	status int
+	msg    string
+	// retryAfter is the 503 Retry-After, used as the hop blacklist time.
+	retryAfter time.Duration
+}
+
+func (e *registerError) Error() string {
+	if e.status > 0 {
+		return fmt.Sprintf("%d %s", e.status, e.msg)
+	}
+	return e.msg
+}
+
+type registration struct {
+	m       *Manager
+	cfg     RegistrationConfig
+	callID  string
+	fromTag string
+	line    string
+
+	// cseq and idx are only touched by the run goroutine.
+	cseq int
+	idx  int
+
+	respCh   chan *stack.Message
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+
+	mu     sync.Mutex
+	status RegistrationStatus
+}
+
+func newRegistration(m *Manager, cfg RegistrationConfig) *registration {
+	r := &registration{
+		m:       m,
+		cfg:     cfg,
+		callID:  randomHex(12) + "@" + nonEmpty(m.cfg.SIPHost, "127.0.0.1"),
+		fromTag: randomHex(6),
+		line:    randomHex(6),
+		respCh:  make(chan *stack.Message, 8),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	r.status = RegistrationStatus{
+		TrunkID:   cfg.TrunkID,
+		State:     RegistrationRegistering,
+		Contact:   r.contact(),
+		UpdatedAt: time.Now(),
+	}
+	return r
+}
+
+func (r *registration) user() string { return sanitizeSIPUser(r.cfg.Auth.Username) }
+
+func (r *registration) contact() string {
+	return fmt.Sprintf("<sip:%s@%s:%d;line=%s>", r.user(),
+		nonEmpty(r.m.cfg.SIPHost, "127.0.0.1"), nonZero(r.m.cfg.SIPPort, 6050), r.line)
+}
+
+// domain is the configured AOR host or the host of the registrar address.
+func (r *registration) domain(registrar string) string {
+	if d := strings.TrimSpace(r.cfg.Domain); d != "" {
+		return d
+	}
+	if h, _, err := net.SplitHostPort(registrar); err == nil {
+		return h
+	}
+	return registrar
+}
+
+func (r *registration) buildREGISTER(registrar string, transport Transport, expires, cseq int, branch string) *stack.Message {
+	domain := r.domain(registrar)
+	aor := fmt.Sprintf("<sip:%s@%s>", r.user(), domain)
+	msg := &stack.Message{
+		IsRequest:  true,
+		Method:     stack.MethodRegister,
+		RequestURI: "sip:" + domain,
+		Version:    "SIP/2.0",
+	}
+	msg.SetHeader("Via", formatVia(transport, r.m.cfg.SIPHost, r.m.cfg.SIPPort, branch))
+	msg.SetHeader("Max-Forwards", "70")
+	msg.SetHeader("From", aor+";tag="+r.fromTag)
+	msg.SetHeader("To", aor)
+	msg.SetHeader("Call-ID", r.callID)
+	msg.SetHeader("CSeq", fmt.Sprintf("%d %s", cseq, stack.MethodRegister))
+	msg.SetHeader("Contact", r.contact())
+	msg.SetHeader("Expires", strconv.Itoa(expires))
+	msg.SetHeader("Allow", "INVITE, ACK, BYE, CANCEL, OPTIONS, UPDATE")
+	msg.SetHeader("User-Agent", "SoulNexus-SIP/1.0")
+	msg.SetHeader("Content-Length", "0")
+	return msg
+}
+
+// transact runs one REGISTER transaction against registrar, including
+// digest and 423 retries, trying its RFC 3263 hops in order. It returns
+// the granted expiry in seconds.
+func (r *registration) transact(registrar string, expires int, stop <-chan struct{}) (int, error) {
+	loc := r.m.Locator()
+	ctx, cancel := context.WithTimeout(context.Background(), registerTxTimeout)
+	hops, err := loc.Resolve(ctx, DialTarget{SignalingAddr: registrar, RequestURI: "sip:" + r.domain(registrar)})
+	cancel()
+	if err != nil {
+		return 0, &registerError{result: sipMetrics.RegisterResultError, msg: err.Error()}
+	}
+	var lastErr error
+	for _, hop := range hops {
+		peer, err := r.m.peerForHop(hop)
+		if err != nil {
+			loc.Blacklist(hop, 0)
+			lastErr = &registerError{result: sipMetrics.RegisterResultError, msg: err.Error()}
+			continue
+		}
+		granted, err := r.transactHop(registrar, hop, peer, expires, stop)
+		var re *registerError
+		if errors.As(err, &re) && (re.result == sipMetrics.RegisterResultTimeout || re.status == 503) {
+			loc.Blacklist(hop, re.retryAfter)
+			lastErr = err
+			continue
+		}
+		return granted, err
+	}
+	return 0, lastErr
+}
+
+// transactHop runs the REGISTER transaction against one resolved hop.
+func (r *registration) transactHop(registrar string, hop Hop, peer signalingPeer, expires int, stop <-chan struct{}) (int, error) {
+	key := digestCacheKey(&r.cfg.Auth, hop.Addr.String())
+	sess := r.m.digestSessionFor(key)
+	tries, bumped := 0, false
+	for {
+		r.cseq++
+		branch := randomHex(10)
+		msg := r.buildREGISTER(registrar, peer.Transport(), expires, r.cseq, branch)
+		if sess != nil {
+			sess.authorize(msg)
+		}
+		r.drain()
+		if err := peer.Send(msg); err != nil {
+			return 0, &registerError{result: sipMetrics.RegisterResultError, msg: err.Error()}
+		}
+		resp, err := r.await(inviteTxKey(branch, r.cseq), stop)
+		if err != nil {
+			return 0, err
+		}
+		switch code := resp.StatusCode; {
+		case code >= 200 && code < 300:
+			return grantedExpires(resp, r.line, expires), nil
+		case code == 401 || code == 407:
+			ch, ok := pickDigestChallenge(resp, r.cfg.Auth.Realm)
+			if !ok || !r.cfg.Auth.usable() || !authRetryAllowed(tries, ch) {
+				return 0, &registerError{result: sipMetrics.RegisterResultAuthFailed, status: code, msg: resp.StatusText}
+			}
+			sess = r.m.storeDigestSession(key, ch, r.cfg.Auth)
+			tries++
+		case code == 423:
+			minExp, _ := strconv.Atoi(strings.TrimSpace(resp.GetHeader("Min-Expires")))
+			if bumped || minExp <= expires {
+				return 0, &registerError{result: sipMetrics.RegisterResultRejected, status: code, msg: resp.StatusText}
+			}
+			expires, bumped = minExp, true
+		default:
+			return 0, &registerError{result: sipMetrics.RegisterResultRejected, status: code, msg: resp.StatusText,
+				retryAfter: retryAfter(resp)}
+		}
+	}
+}
+
+func (r *registration) drain() {
+	for {
+		select {
+		case <-r.respCh:
+		default:
+			return
+		}
+	}
+}
+
+// await waits for the final response matching txKey.
+func (r *registration) await(txKey string, stop <-chan struct{}) (*stack.Message, error) {
+	timer := time.NewTimer(registerTxTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case resp := <-r.respCh:
+			if resp.StatusCode < 200 || txKeyFromResponse(resp) != txKey {
+				continue
+			}
+			return resp, nil
+		case <-timer.C:
+			return nil, &registerError{result: sipMetrics.RegisterResultTimeout, msg: "no response from registrar"}
+		case <-stop:
+			return nil, errRegisterStopped
+		}
+	}
+}
+
+// grantedExpires reads the binding lifetime from our Contact's expires
+// param, then the Expires header, falling back to what we asked for.
+func grantedExpires(resp *stack.Message, line string, requested int) int {
+	for _, c := range splitContacts(resp.GetHeaders("Contact")) {
+		if !strings.Contains(strings.ToLower(c), "line="+line) {
+			continue
+		}
+		if v, ok := uriParam(c, "expires"); ok {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(resp.GetHeader("Expires"))); err == nil && n > 0 {
+		return n
+	}
+	return requested
+}
+
+// splitContacts splits comma-joined Contact values, ignoring commas
+// inside <...> or quotes.
+func splitContacts(values []string) []string {
+	var out []string
+	for _, v := range values {
+		depth, quoted, start := 0, false, 0
+		for i, c := range v {
+			switch {
+			case c == '"':
+				quoted = !quoted
+			case quoted:
+			case c == '<':
+				depth++
+			case c == '>':
+				depth--
+			case c == ',' && depth == 0:
+				out = append(out, strings.TrimSpace(v[start:i]))
+				start = i + 1
+			}
+		}
+		out = append(out, strings.TrimSpace(v[start:]))
+	}
+	return out
+}
+
+// uriParam returns the value of ;name= anywhere in s (URI or header
+// parameter), case-insensitive on the name.
+func uriParam(s, name string) (string, bool) {
+	lower := strings.ToLower(s)
+	idx := strings.Index(lower, ";"+name+"=")
+	if idx < 0 {
+		return "", false
+	}
+	v := s[idx+len(name)+2:]
+	if end := strings.IndexAny(v, ";>,? "); end >= 0 {
+		v = v[:end]
+	}
+	return strings.Trim(v, `"`), true
+}
+
+func (r *registration) run() {
+	defer close(r.doneCh)
+	backoff := registerRetryMin
+	for {
+		granted, err := r.cycle()
+		if errors.Is(err, errRegisterStopped) {
+			r.unregister()
+			return
+		}
+		wait := backoff
+		if err == nil {
+			backoff = registerRetryMin
+			wait = time.Duration(granted) * time.Second / 2
+			if wait < registerRefreshMin {
+				wait = registerRefreshMin
+			}
+		} else if backoff *= 2; backoff > registerRetryMax {
+			backoff = registerRetryMax
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.stopCh:
+			timer.Stop()
+			r.unregister()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// cycle tries each registrar once, starting from the last good one.
+func (r *registration) cycle() (int, error) {
+	var lastErr error
+	for i := 0; i < len(r.cfg.Registrars); i++ {
+		registrar := r.cfg.Registrars[r.idx]
+		granted, err := r.transact(registrar, r.cfg.Expires, r.stopCh)
+		if errors.Is(err, errRegisterStopped) {
+			return 0, err
+		}
+		if err == nil {
+			r.markRegistered(registrar, granted)
+			sipMetrics.RegisterClientResult(sipMetrics.RegisterResultOK)
+			return granted, nil
+		}
+		lastErr = err
+		r.markFailed(registrar, err)
+		var re *registerError
+		if errors.As(err, &re) {
+			sipMetrics.RegisterClientResult(re.result)
+		}
+		if logger.Lg != nil {
+			logger.Lg.Warn("sip register: registrar failed",
+				zap.Uint("trunk_id", r.cfg.TrunkID),
+				zap.String("registrar", registrar),
+				zap.Error(err))
+		}
+		r.idx = (r.idx + 1) % len(r.cfg.Registrars)
+	}
+	return 0, lastErr
+}
+
+// unregister removes the binding (Expires: 0) when one is live.
+func (r *registration) unregister() {
+	r.mu.Lock()
+	live := r.status.State == RegistrationRegistered
+	r.mu.Unlock()
+	if live {
+		registrar := r.cfg.Registrars[r.idx]
+		if _, err := r.transact(registrar, 0, nil); err != nil && logger.Lg != nil {
+			logger.Lg.Warn("sip register: unregister failed",
+				zap.Uint("trunk_id", r.cfg.TrunkID),
+				zap.String("registrar", registrar),
+				zap.Error(err))
+		}
+	}
+	r.mu.Lock()
+	r.status.State = RegistrationUnregistered
+	r.status.ExpiresAt = nil
+	r.status.UpdatedAt = time.Now()
+	r.mu.Unlock()
+	r.m.publishRegisteredGauge()
+}
+
+func (r *registration) markRegistered(registrar string, granted int) {
+	now := time.Now()
+	exp := now.Add(time.Duration(granted) * time.Second)
+	r.mu.Lock()
+	if r.status.State != RegistrationRegistered || r.status.Registrar != registrar {
+		r.status.RegisteredAt = &now
+	}
+	r.status.State = RegistrationRegistered
+	r.status.Registrar = registrar
+	r.status.Expires = granted
+	r.status.ExpiresAt = &exp
+	r.status.LastStatus = 200
+	r.status.LastError = ""
+	r.status.Failures = 0
+	r.status.UpdatedAt = now
+	r.mu.Unlock()
+	r.m.publishRegisteredGauge()
+}
+
+func (r *registration) markFailed(registrar string, err error) {
+	r.mu.Lock()
+	r.status.State = RegistrationFailed
+	r.status.Registrar = registrar
+	r.status.RegisteredAt = nil
+	r.status.ExpiresAt = nil
+	r.status.LastStatus = 0
+	var re *registerError
+	if errors.As(err, &re) {
+		r.status.LastStatus = re.status
+	}
+	r.status.LastError = err.Error()
+	r.status.Failures++
+	r.status.UpdatedAt = time.Now()
+	r.mu.Unlock()
+	r.m.publishRegisteredGauge()
+}
+
+func (r *registration) snapshot() RegistrationStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+func (r *registration) stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	<-r.doneCh
+}
+
+func normalizeRegistrationConfig(c RegistrationConfig) (RegistrationConfig, bool) {
+	var regs []string
+	for _, a := range c.Registrars {
+		if a = strings.TrimSpace(a); a != "" {
+			regs = append(regs, a)
+		}
+	}
+	c.Registrars = regs
+	c.Domain = strings.TrimSpace(c.Domain)
+	if c.Expires <= 0 {
+		c.Expires = DefaultRegisterExpires
+	}
+	return c, c.TrunkID > 0 && len(regs) > 0 && c.Auth.usable()
+}
+
+// SetRegistrations reconciles the running registrations with cfgs:
+// new trunks start registering, removed trunks unregister, and changed
+// configs restart. Requires BindSender.
+func (m *Manager) SetRegistrations(cfgs []RegistrationConfig) {
+	if m == nil || m.send == nil {
+		return
+	}
+	want := make(map[uint]RegistrationConfig, len(cfgs))
+	for _, c := range cfgs {
+		if c, ok := normalizeRegistrationConfig(c); ok {
+			want[c.TrunkID] = c
+		}
+	}
+	var stale, fresh []*registration
+	m.regMu.Lock()
+	if m.regs == nil {
+		m.regs = make(map[uint]*registration)
+		m.regByCallID = make(map[string]*registration)
+	}
+	for id, r := range m.regs {
+		if c, ok := want[id]; !ok || !r.cfg.equal(c) {
+			stale = append(stale, r)
+			delete(m.regs, id)
+		}
+	}
+	for id, c := range want {
+		if _, ok := m.regs[id]; ok {
+			continue
+		}
+		r := newRegistration(m, c)
+		m.regs[id] = r
+		m.regByCallID[r.callID] = r
+		fresh = append(fresh, r)
+	}
+	m.regMu.Unlock()
+	for _, r := range stale {
+		go m.retireRegistration(r)
+	}
+	for _, r := range fresh {
+		go r.run()
+	}
+}
+
+// StopRegistrations unregisters every trunk and waits for the loops to
+// exit (bounded by one transaction timeout). Call before stopping the
+// SIP server.
+func (m *Manager) StopRegistrations() {
+	if m == nil {
+		return
+	}
+	m.regMu.Lock()
+	all := make([]*registration, 0, len(m.regs))
+	for id, r := range m.regs {
+		all = append(all, r)
+		delete(m.regs, id)
+	}
+	m.regMu.Unlock()
+	var wg sync.WaitGroup
+	for _, r := range all {
+		wg.Add(1)
+		go func(r *registration) {
+			defer wg.Done()
+			m.retireRegistration(r)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// retireRegistration stops r and then drops its Call-ID route (kept
+// until the un-REGISTER answer has been consumed).
+func (m *Manager) retireRegistration(r *registration) {
+	r.stop()
+	m.regMu.Lock()
+	delete(m.regByCallID, r.callID)
+	m.regMu.Unlock()
+}
+
+// RegistrationStatuses returns a snapshot of every trunk registration,
+// ordered by trunk ID.
+func (m *Manager) RegistrationStatuses() []RegistrationStatus {
+	if m == nil {
+		return nil
+	}
+	m.regMu.Lock()
+	out := make([]RegistrationStatus, 0, len(m.regs))
+	for _, r := range m.regs {
+		out = append(out, r.snapshot())
+	}
+	m.regMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].TrunkID < out[j].TrunkID })
+	return out
+}
+
+// RegistrationStatus returns the registration snapshot for one trunk.
+func (m *Manager) RegistrationStatus(trunkID uint) (RegistrationStatus, bool) {
+	if m == nil {
+		return RegistrationStatus{}, false
+	}
+	m.regMu.Lock()
+	r := m.regs[trunkID]
+	m.regMu.Unlock()
+	if r == nil {
+		return RegistrationStatus{}, false
+	}
+	return r.snapshot(), true
+}
+
+// RegisteredContactTrunk reports which trunk registered the Contact an
+// inbound INVITE is addressed to: by the ;line= token first, then by the
+// account user part.
+func (m *Manager) RegisteredContactTrunk(requestURI string) (uint, bool) {
+	if m == nil || strings.TrimSpace(requestURI) == "" {
+		return 0, false
+	}
+	line, hasLine := uriParam(requestURI, "line")
+	user := requestURIUser(requestURI)
+	m.regMu.Lock()
+	defer m.regMu.Unlock()
+	if hasLine {
+		for id, r := range m.regs {
+			if strings.EqualFold(r.line, line) {
+				return id, true
+			}
+		}
+	}
+	if user == "" {
+		return 0, false
+	}
+	for id, r := range m.regs {
+		if r.user() == user {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func requestURIUser(uri string) string {
+	u := strings.TrimSpace(uri)
+	if i := strings.Index(u, ":"); i >= 0 && strings.HasPrefix(strings.ToLower(u), "sip") {
+		u = u[i+1:]
+	}
+	at := strings.Index(u, "@")
+	if at <= 0 {
+		return ""
+	}
+	return u[:at]
+}
+
+// routeRegisterResponse hands a REGISTER response to its registration.
+func (m *Manager) routeRegisterResponse(resp *stack.Message) bool {
+	if !strings.HasSuffix(strings.ToUpper(strings.TrimSpace(resp.GetHeader("CSeq"))), stack.MethodRegister) {
+		return false
+	}
+	m.regMu.Lock()
+	r := m.regByCallID[strings.TrimSpace(resp.GetHeader("Call-ID"))]
+	m.regMu.Unlock()
+	if r == nil {
+		return false
+	}
+	select {
+	case r.respCh <- resp:
+	default:
+	}
+	return true
+}
+
+func (m *Manager) publishRegisteredGauge() {
+	n := 0
+	m.regMu.Lock()
+	for _, r := range m.regs {
+		if r.snapshot().State == RegistrationRegistered {
+			n++
+		}
+	}
+	m.regMu.Unlock()
+	sipMetrics.RegisterClientRegistered(n)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+// fakeRegistrar answers REGISTERs sent through Manager.send via reply;
+// a nil response means the registrar is silent.
+type fakeRegistrar struct {
+	mu    sync.Mutex
+	got   []*stack.Message
+	dsts  []string
+	reply func(req *stack.Message, dst *net.UDPAddr) *stack.Message
+}
+
+func (f *fakeRegistrar) bind(m *Manager) {
+	m.send = func(msg *stack.Message, dst *net.UDPAddr) error {
+		f.mu.Lock()
+		f.got = append(f.got, msg)
+		f.dsts = append(f.dsts, dst.String())
+		f.mu.Unlock()
+		if resp := f.reply(msg, dst); resp != nil {
+			go m.HandleSIPResponse(resp, dst)
+		}
+		return nil
+	}
+}
+
+func (f *fakeRegistrar) requests() ([]*stack.Message, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*stack.Message(nil), f.got...), append([]string(nil), f.dsts...)
+}
+
+func registerResponse(req *stack.Message, code int) *stack.Message {
+	resp := &stack.Message{StatusCode: code, StatusText: "X", Version: "SIP/2.0"}
+	for _, h := range []string{"Via", "From", "Call-ID", "CSeq"} {
+		resp.SetHeader(h, req.GetHeader(h))
+	}
+	resp.SetHeader("To", req.GetHeader("To")+";tag=reg")
+	return resp
+}
+
+func waitRegistration(t *testing.T, m *Manager, id uint, state RegistrationState) RegistrationStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if st, ok := m.RegistrationStatus(id); ok && st.State == state {
+			return st
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	st, _ := m.RegistrationStatus(id)
+	t.Fatalf("trunk %d never reached %s: %+v", id, state, st)
+	return st
+}
+
+func TestRegistration_DigestRefreshAndUnregister(t *testing.T) {
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.1", SIPPort: 5060})
+	reg := &fakeRegistrar{}
+	reg.reply = func(req *stack.Message, _ *net.UDPAddr) *stack.Message {
+		if req.GetHeader("Authorization") == "" {
+			resp := registerResponse(req, 401)
+			resp.SetHeader("WWW-Authenticate", `Digest realm="carrier", nonce="n1", qop="auth"`)
+			return resp
+		}
+		resp := registerResponse(req, 200)
+		resp.SetHeader("Contact", `<sip:other@198.51.100.9>;expires=30, `+req.GetHeader("Contact")+";expires=120")
+		return resp
+	}
+	reg.bind(m)
+
+	m.SetRegistrations([]RegistrationConfig{{
+		TrunkID:    7,
+		Registrars: []string{"192.0.2.10:5060"},
+		Domain:     "carrier.example",
+		Auth:       DigestCredentials{Username: "02188880000", Password: "pw"},
+	}})
+	st := waitRegistration(t, m, 7, RegistrationRegistered)
+	if st.Expires != 120 || st.Registrar != "192.0.2.10:5060" || st.RegisteredAt == nil {
+		t.Fatalf("status = %+v", st)
+	}
+	sent, _ := reg.requests()
+	if len(sent) != 2 {
+		t.Fatalf("want REGISTER + authorised REGISTER, got %d", len(sent))
+	}
+	first, second := sent[0], sent[1]
+	if first.RequestURI != "sip:carrier.example" || first.GetHeader("To") != "<sip:02188880000@carrier.example>" ||
+		first.GetHeader("Expires") != "3600" {
+		t.Errorf("REGISTER uri=%q to=%q expires=%q", first.RequestURI, first.GetHeader("To"), first.GetHeader("Expires"))
+	}
+	if first.GetHeader("Call-ID") != second.GetHeader("Call-ID") || second.GetHeader("CSeq") != "2 REGISTER" {
+		t.Errorf("retry must keep Call-ID and bump CSeq: %q", second.GetHeader("CSeq"))
+	}
+	if auth := second.GetHeader("Authorization"); !strings.Contains(auth, `uri="sip:carrier.example"`) {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	contact := strings.Trim(first.GetHeader("Contact"), "<>")
+	if id, ok := m.RegisteredContactTrunk(contact); !ok || id != 7 {
+		t.Errorf("contact %q not matched: %d %v", contact, id, ok)
+	}
+	if id, ok := m.RegisteredContactTrunk("sip:02188880000@192.0.2.1"); !ok || id != 7 {
+		t.Errorf("user fallback not matched: %d %v", id, ok)
+	}
+	if _, ok := m.RegisteredContactTrunk("sip:4001@192.0.2.1"); ok {
+		t.Error("unrelated Request-URI must not match")
+	}
+
+	m.StopRegistrations()
+	sent, _ = reg.requests()
+	last := sent[len(sent)-1]
+	if last.GetHeader("Expires") != "0" || last.GetHeader("Authorization") == "" {
+		t.Errorf("un-REGISTER expires=%q auth=%q", last.GetHeader("Expires"), last.GetHeader("Authorization"))
+	}
+	if len(m.RegistrationStatuses()) != 0 {
+		t.Error("statuses should be empty after stop")
+	}
+}
+
+func TestRegistration_FailoverAndFailure(t *testing.T) {
+	defer func(d time.Duration) { registerTxTimeout = d }(registerTxTimeout)
+	registerTxTimeout = 50 * time.Millisecond
+
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.1", SIPPort: 5060})
+	reg := &fakeRegistrar{}
+	reg.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		switch dst.String() {
+		case "192.0.2.11:5060":
+			return nil
+		case "192.0.2.12:5060":
+			resp := registerResponse(req, 200)
+			resp.SetHeader("Expires", "600")
+			return resp
+		}
+		return registerResponse(req, 403)
+	}
+	reg.bind(m)
+
+	m.SetRegistrations([]RegistrationConfig{
+		{TrunkID: 1, Registrars: []string{"192.0.2.11:5060", "192.0.2.12:5060"}, Auth: DigestCredentials{Username: "a", Password: "p"}},
+		{TrunkID: 2, Registrars: []string{"192.0.2.13:5060"}, Auth: DigestCredentials{Username: "b", Password: "p"}},
+		{TrunkID: 3, Registrars: []string{"192.0.2.13:5060"}}, // no account: ignored
+	})
+	defer m.StopRegistrations()
+
+	st := waitRegistration(t, m, 1, RegistrationRegistered)
+	if st.Registrar != "192.0.2.12:5060" || st.Expires != 600 {
+		t.Fatalf("failover status = %+v", st)
+	}
+	_, dsts := reg.requests()
+	for _, d := range dsts {
+		if d == "192.0.2.11:5060" {
+			break
+		}
+		if d == "192.0.2.12:5060" {
+			t.Fatalf("first registrar should be tried first: %v", dsts)
+		}
+	}
+
+	failed := waitRegistration(t, m, 2, RegistrationFailed)
+	if failed.LastStatus != 403 || failed.Failures != 1 || failed.LastError == "" {
+		t.Errorf("failed status = %+v", failed)
+	}
+	if _, ok := m.RegistrationStatus(3); ok {
+		t.Error("trunk without credentials must not register")
+	}
+
+	// Dropping trunk 2 from the config stops its loop.
+	m.SetRegistrations([]RegistrationConfig{
+		{TrunkID: 1, Registrars: []string{"192.0.2.11:5060", "192.0.2.12:5060"}, Auth: DigestCredentials{Username: "a", Password: "p"}},
+	})
+	if all := m.RegistrationStatuses(); len(all) != 1 || all[0].TrunkID != 1 {
+		t.Errorf("after reconcile: %+v", all)
+	}
+}
+
+func TestRegistration_LocatesRegistrarAndFailsOverHops(t *testing.T) {
+	defer func(d time.Duration) { registerTxTimeout = d }(registerTxTimeout)
+	registerTxTimeout = 100 * time.Millisecond
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	tcpVia := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				for {
+					req, err := stack.ReadMessage(br)
+					if err != nil {
+						return
+					}
+					tcpVia <- req.GetHeader("Via")
+					resp := registerResponse(req, 200)
+					resp.SetHeader("Content-Length", "0")
+					_, _ = conn.Write([]byte(resp.String()))
+				}
+			}(conn)
+		}
+	}()
+	tcpPort := ln.Addr().(*net.TCPAddr).Port
+
+	dns := newFakeDNS()
+	dns.srv["_sip._udp.carrier.example"] = []SRVRecord{
+		{Target: "a.carrier.example", Port: 5060, Priority: 10},
+		{Target: "b.carrier.example", Port: 5060, Priority: 20},
+	}
+	dns.srv["_sip._tcp.carrier.example"] = []SRVRecord{{Target: "127.0.0.1", Port: uint16(tcpPort), Priority: 30}}
+	dns.ip["a.carrier.example"] = []net.IP{net.ParseIP("192.0.2.1")}
+	dns.ip["b.carrier.example"] = []net.IP{net.ParseIP("192.0.2.2")}
+
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.100", SIPPort: 5060, DNSResolver: dns})
+	defer m.ClosePool()
+	reg := &fakeRegistrar{}
+	reg.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if dst.String() == "192.0.2.1:5060" {
+			resp := registerResponse(req, 503)
+			resp.SetHeader("Retry-After", "120")
+			return resp
+		}
+		return nil // b is silent
+	}
+	reg.bind(m)
+
+	m.SetRegistrations([]RegistrationConfig{{TrunkID: 1, Registrars: []string{"carrier.example"},
+		Auth: DigestCredentials{Username: "a", Password: "p"}}})
+	defer m.StopRegistrations()
+
+	st := waitRegistration(t, m, 1, RegistrationRegistered)
+	if st.Registrar != "carrier.example" {
+		t.Fatalf("status = %+v", st)
+	}
+	if via := <-tcpVia; !strings.HasPrefix(via, "SIP/2.0/TCP ") {
+		t.Fatalf("TCP hop Via = %q", via)
+	}
+	sent, dsts := reg.requests()
+	if len(sent) < 2 || dsts[0] != "192.0.2.1:5060" || dsts[1] != "192.0.2.2:5060" {
+		t.Fatalf("UDP hops tried = %v", dsts)
+	}
+	if via := sent[0].GetHeader("Via"); !strings.HasPrefix(via, "SIP/2.0/UDP ") {
+		t.Fatalf("UDP hop Via = %q", via)
+	}
+	loc := m.Locator()
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+		if !loc.Blacklisted(Hop{Transport: TransportUDP, Addr: &net.UDPAddr{IP: net.ParseIP(ip), Port: 5060}}) {
+			t.Errorf("hop %s should be blacklisted", ip)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+// RFC 3263 "Locating SIP Servers": turns a DialTarget into an ordered
+// list of next hops (transport + IP:port).
+//
+//	IP literal                → that address, no DNS
+//	host:port (explicit port) → A/AAAA only
+//	host, transport known     → SRV for that transport, else A/AAAA
+//	host, no transport        → NAPTR picks transports (SIP+D2U /
+//	                            SIP+D2T / SIPS+D2T) → SRV; no NAPTR →
+//	                            SRV for every supported transport;
+//	                            nothing → A/AAAA on the default port
+//
+// SRV records are ordered by priority with weighted-random selection
+// inside a priority (RFC 2782). Lookups are cached for their TTL.
+// Hops that answered 503 or timed out are blacklisted for a while and
+// sorted to the end of later results; Manager fails the INVITE over to
+// the next hop (failover.go).
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NAPTRRecord is one RFC 3403 NAPTR answer.
+type NAPTRRecord struct {
+	Order       uint16
+	Preference  uint16
+	Flags       string
+	Service     string
+	Regexp      string
+	Replacement string
+}
+
+// SRVRecord is one RFC 2782 SRV answer.
+type SRVRecord struct {
+	Target   string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+}
+
+// DNSResolver is the lookup surface the locator needs. Each call
+// returns the RRset and its TTL; a name with no records returns an
+// empty slice and a nil error. Tests plug a fake in via
+// ManagerConfig.DNSResolver.
+type DNSResolver interface {
+	LookupNAPTR(ctx context.Context, name string) ([]NAPTRRecord, time.Duration, error)
+	LookupSRV(ctx context.Context, name string) ([]SRVRecord, time.Duration, error)
+	LookupIP(ctx context.Context, host string) ([]net.IP, time.Duration, error)
+}
+
+// Hop is one resolved next hop for a SIP request.
+type Hop struct {
+	Transport Transport
+	Addr      *net.UDPAddr
+	// Host is the name the address was resolved from (SRV target or
+	// URI host); empty for IP literals.
+	Host string
+}
+
+func (h Hop) key() string { return string(h.Transport) + "|" + h.Addr.String() }
+
+func (h Hop) String() string { return string(h.Transport) + ":" + h.Addr.String() }
+
+const (
+	defaultSIPPort  = 5060
+	defaultSIPSPort = 5061
+
+	locatorMinTTL      = 5 * time.Second
+	locatorMaxTTL      = time.Hour
+	locatorNegativeTTL = 30 * time.Second
+)
+
+// DefaultHopBlacklist is how long a hop that answered 503 without
+// Retry-After, or did not answer at all, is avoided.
+const DefaultHopBlacklist = time.Minute
+
+// Locator resolves SIP targets per RFC 3263 with a TTL cache and a
+// per-hop blacklist. Safe for concurrent use.
+type Locator struct {
+	res  DNSResolver
+	now  func() time.Time
+	intn func(n int) int
+
+	mu        sync.Mutex
+	cache     map[string]locatorEntry
+	blacklist map[string]time.Time
+}
+
+type locatorEntry struct {
+	until time.Time
+	naptr []NAPTRRecord
+	srv   []SRVRecord
+	ips   []net.IP
+}
+
+// NewLocator returns a locator over res (nil → system DNS).
+func NewLocator(res DNSResolver) *Locator {
+	if res == nil {
+		res = newSystemDNSResolver()
+	}
+	return &Locator{
+		res:       res,
+		now:       time.Now,
+		intn:      rand.IntN,
+		cache:     make(map[string]locatorEntry),
+		blacklist: make(map[string]time.Time),
+	}
+}
+
+// Blacklist avoids h for d (d <= 0 uses DefaultHopBlacklist).
+func (l *Locator) Blacklist(h Hop, d time.Duration) {
+	if l == nil || h.Addr == nil {
+		return
+	}
+	if d <= 0 {
+		d = DefaultHopBlacklist
+	}
+	l.mu.Lock()
+	l.blacklist[h.key()] = l.now().Add(d)
+	l.mu.Unlock()
+}
+
+// Blacklisted reports whether h is currently avoided.
+func (l *Locator) Blacklisted(h Hop) bool {
+	if l == nil || h.Addr == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.blacklist[h.key()]
+	if ok && !l.now().Before(until) {
+		delete(l.blacklist, h.key())
+		return false
+	}
+	return ok
+}
+
+// Resolve returns the ordered next hops for target. The signaling
+// address (host, host:port or IP) wins over the Request-URI host.
+// Blacklisted hops are kept but moved to the end.
+func (l *Locator) Resolve(ctx context.Context, target DialTarget) ([]Hop, error) {
+	host, port, err := targetHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	sips := strings.HasPrefix(strings.ToLower(strings.TrimSpace(target.RequestURI)), "sips:")
+	explicit := transportFromRequestURI(target.RequestURI)
+	if !explicit.IsValid() && target.Transport.IsValid() {
+		explicit = target.Transport
+	}
+	fallback := explicit
+	if !fallback.IsValid() {
+		fallback = TransportUDP
+		if sips {
+			fallback = TransportTLS
+		}
+	}
+
+	var hops []Hop
+	switch {
+	case net.ParseIP(host) != nil:
+		if port == 0 {
+			port = defaultPortFor(fallback)
+		}
+		hops = []Hop{{Transport: fallback, Addr: &net.UDPAddr{IP: net.ParseIP(host), Port: port}}}
+	case port > 0:
+		hops, err = l.addressHops(ctx, host, port, fallback)
+	default:
+		hops, err = l.locateDomain(ctx, host, explicit, sips)
+		if err == nil && len(hops) == 0 {
+			hops, err = l.addressHops(ctx, host, defaultPortFor(fallback), fallback)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(hops) == 0 {
+		return nil, fmt.Errorf("sip/outbound: no address for %s", host)
+	}
+	return l.demoteBlacklisted(hops), nil
+}
+
+// locateDomain runs the NAPTR / SRV stages for a bare host name.
+func (l *Locator) locateDomain(ctx context.Context, host string, explicit Transport, sips bool) ([]Hop, error) {
+	if explicit.IsValid() {
+		return l.srvHops(ctx, srvName(explicit, host), explicit)
+	}
+	naptrs, err := l.naptr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var hops []Hop
+	for _, n := range naptrs {
+		t, ok := naptrTransport(n, sips)
+		if !ok {
+			continue
+		}
+		hs, err := l.srvHops(ctx, n.Replacement, t)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, hs...)
+	}
+	if len(hops) > 0 {
+		return dedupeHops(hops), nil
+	}
+	transports := []Transport{TransportUDP, TransportTCP, TransportTLS}
+	if sips {
+		transports = []Transport{TransportTLS}
+	}
+	for _, t := range transports {
+		hs, err := l.srvHops(ctx, srvName(t, host), t)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, hs...)
+	}
+	return dedupeHops(hops), nil
+}
+
+func (l *Locator) srvHops(ctx context.Context, name string, t Transport) ([]Hop, error) {
+	recs, err := l.srv(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var hops []Hop
+	for _, r := range orderSRV(recs, l.intn) {
+		target := strings.TrimSuffix(r.Target, ".")
+		if target == "" {
+			// "." target: service decidedly not available here.
+			continue
+		}
+		hs, err := l.addressHops(ctx, target, int(r.Port), t)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, hs...)
+	}
+	return hops, nil
+}
+
+func (l *Locator) addressHops(ctx context.Context, host string, port int, t Transport) ([]Hop, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []Hop{{Transport: t, Addr: &net.UDPAddr{IP: ip, Port: port}}}, nil
+	}
+	ips, err := l.ip(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	hops := make([]Hop, 0, len(ips))
+	for _, ip := range ips {
+		hops = append(hops, Hop{Transport: t, Addr: &net.UDPAddr{IP: ip, Port: port}, Host: host})
+	}
+	return hops, nil
+}
+
+func (l *Locator) naptr(ctx context.Context, name string) ([]NAPTRRecord, error) {
+	key := "naptr|" + strings.ToLower(name)
+	if e, ok := l.cached(key); ok {
+		return e.naptr, nil
+	}
+	recs, ttl, err := l.res.LookupNAPTR(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("sip/outbound: NAPTR %s: %w", name, err)
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Order != recs[j].Order {
+			return recs[i].Order < recs[j].Order
+		}
+		return recs[i].Preference < recs[j].Preference
+	})
+	l.store(key, locatorEntry{naptr: recs}, ttl, len(recs) == 0)
+	return recs, nil
+}
+
+func (l *Locator) srv(ctx context.Context, name string) ([]SRVRecord, error) {
+	key := "srv|" + strings.ToLower(name)
+	if e, ok := l.cached(key); ok {
+		return e.srv, nil
+	}
+	recs, ttl, err := l.res.LookupSRV(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("sip/outbound: SRV %s: %w", name, err)
+	}
+	l.store(key, locatorEntry{srv: recs}, ttl, len(recs) == 0)
+	return recs, nil
+}
+
+func (l *Locator) ip(ctx context.Context, host string) ([]net.IP, error) {
+	key := "ip|" + strings.ToLower(host)
+	if e, ok := l.cached(key); ok {
+		return e.ips, nil
+	}
+	ips, ttl, err := l.res.LookupIP(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("sip/outbound: resolve %s: %w", host, err)
+	}
+	l.store(key, locatorEntry{ips: ips}, ttl, len(ips) == 0)
+	return ips, nil
+}
+
+func (l *Locator) cached(key string) (locatorEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.cache[key]
+	if !ok || !l.now().Before(e.until) {
+		delete(l.cache, key)
+		return locatorEntry{}, false
+	}
+	return e, true
+}
+
+func (l *Locator) store(key string, e locatorEntry, ttl time.Duration, negative bool) {
+	switch {
+	case negative:
+		ttl = locatorNegativeTTL
+	case ttl < locatorMinTTL:
+		ttl = locatorMinTTL
+	case ttl > locatorMaxTTL:
+		ttl = locatorMaxTTL
+	}
+	e.until = l.now().Add(ttl)
+	l.mu.Lock()
+	l.cache[key] = e
+	l.mu.Unlock()
+}
+
+func (l *Locator) demoteBlacklisted(hops []Hop) []Hop {
+	out := make([]Hop, 0, len(hops))
+	var bad []Hop
+	for _, h := range hops {
+		if l.Blacklisted(h) {
+			bad = append(bad, h)
+		} else {
+			out = append(out, h)
+		}
+	}
+	return append(out, bad...)
+}
+
+// orderSRV sorts by priority and, within one priority, draws records
+// weighted by Weight (RFC 2782; zero-weight records keep a small chance).
+func orderSRV(recs []SRVRecord, intn func(int) int) []SRVRecord {
+	byPrio := map[uint16][]SRVRecord{}
+	var prios []int
+	for _, r := range recs {
+		if _, ok := byPrio[r.Priority]; !ok {
+			prios = append(prios, int(r.Priority))
+		}
+		byPrio[r.Priority] = append(byPrio[r.Priority], r)
+	}
+	sort.Ints(prios)
+	out := make([]SRVRecord, 0, len(recs))
+	for _, p := range prios {
+		group := byPrio[uint16(p)]
+		for len(group) > 0 {
+			total := 0
+			for _, r := range group {
+				total += int(r.Weight) + 1
+			}
+			pick, n := intn(total), 0
+			for i, r := range group {
+				n += int(r.Weight) + 1
+				if pick < n {
+					out = append(out, r)
+					group = append(group[:i:i], group[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+func naptrTransport(n NAPTRRecord, sips bool) (Transport, bool) {
+	if !strings.EqualFold(n.Flags, "s") || strings.TrimSuffix(n.Replacement, ".") == "" {
+		return TransportUnset, false
+	}
+	switch strings.ToUpper(n.Service) {
+	case "SIPS+D2T":
+		return TransportTLS, true
+	case "SIP+D2T":
+		return TransportTCP, !sips
+	case "SIP+D2U":
+		return TransportUDP, !sips
+	}
+	return TransportUnset, false
+}
+
+func srvName(t Transport, host string) string {
+	switch t {
+	case TransportTLS:
+		return "_sips._tcp." + host
+	case TransportTCP:
+		return "_sip._tcp." + host
+	default:
+		return "_sip._udp." + host
+	}
+}
+
+func defaultPortFor(t Transport) int {
+	if t == TransportTLS {
+		return defaultSIPSPort
+	}
+	return defaultSIPPort
+}
+
+func dedupeHops(hops []Hop) []Hop {
+	seen := make(map[string]bool, len(hops))
+	out := hops[:0]
+	for _, h := range hops {
+		if !seen[h.key()] {
+			seen[h.key()] = true
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// targetHostPort picks the host to resolve: SignalingAddr when set,
+// otherwise the Request-URI host. port is 0 when none is given.
+func targetHostPort(target DialTarget) (host string, port int, err error) {
+	raw := strings.TrimSpace(target.SignalingAddr)
+	if raw == "" {
+		raw = requestURIHostPort(target.RequestURI)
+	}
+	if raw == "" {
+		return "", 0, fmt.Errorf("sip/outbound: empty signaling address")
+	}
+	if h, p, err := net.SplitHostPort(raw); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return "", 0, fmt.Errorf("sip/outbound: bad port in %q", raw)
+		}
+		return strings.TrimSuffix(h, "."), n, nil
+	}
+	return strings.TrimSuffix(strings.Trim(raw, "[]"), "."), 0, nil
+}
+
+// requestURIHostPort extracts host[:port] from sip:/sips: URIs.
+func requestURIHostPort(uri string) string {
+	u := strings.TrimSpace(strings.Trim(strings.TrimSpace(uri), "<>"))
+	if i := strings.Index(u, ":"); i >= 0 && strings.HasPrefix(strings.ToLower(u), "sip") {
+		u = u[i+1:]
+	}
+	if at := strings.LastIndex(u, "@"); at >= 0 {
+		u = u[at+1:]
+	}
+	if end := strings.IndexAny(u, ";?>"); end >= 0 {
+		u = u[:end]
+	}
+	return strings.TrimSpace(u)
+}
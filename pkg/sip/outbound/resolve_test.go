@@ -0,0 +1,282 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package outbound
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+// fakeDNS is an in-memory DNSResolver; calls counts lookups per name.
+type fakeDNS struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	naptr map[string][]NAPTRRecord
+	srv   map[string][]SRVRecord
+	ip    map[string][]net.IP
+	calls map[string]int
+}
+
+func newFakeDNS() *fakeDNS {
+	return &fakeDNS{
+		ttl:   time.Minute,
+		naptr: map[string][]NAPTRRecord{},
+		srv:   map[string][]SRVRecord{},
+		ip:    map[string][]net.IP{},
+		calls: map[string]int{},
+	}
+}
+
+func (f *fakeDNS) count(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+func (f *fakeDNS) LookupNAPTR(_ context.Context, name string) ([]NAPTRRecord, time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[name]++
+	return append([]NAPTRRecord(nil), f.naptr[name]...), f.ttl, nil
+}
+
+func (f *fakeDNS) LookupSRV(_ context.Context, name string) ([]SRVRecord, time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[name]++
+	return append([]SRVRecord(nil), f.srv[name]...), f.ttl, nil
+}
+
+func (f *fakeDNS) LookupIP(_ context.Context, host string) ([]net.IP, time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[host]++
+	return append([]net.IP(nil), f.ip[host]...), f.ttl, nil
+}
+
+func hopStrings(hops []Hop) []string {
+	out := make([]string, 0, len(hops))
+	for _, h := range hops {
+		out = append(out, h.String())
+	}
+	return out
+}
+
+func TestLocator_Resolve(t *testing.T) {
+	dns := newFakeDNS()
+	dns.naptr["carrier.example"] = []NAPTRRecord{
+		{Order: 20, Preference: 10, Flags: "s", Service: "SIP+D2U", Replacement: "_sip._udp.carrier.example"},
+		{Order: 10, Preference: 10, Flags: "s", Service: "SIP+D2T", Replacement: "_sip._tcp.carrier.example"},
+		{Order: 10, Preference: 20, Flags: "s", Service: "SIP+D2X", Replacement: "_sip._sctp.carrier.example"},
+	}
+	dns.srv["_sip._tcp.carrier.example"] = []SRVRecord{{Target: "sbc1.carrier.example.", Port: 5070, Priority: 10}}
+	dns.srv["_sip._udp.carrier.example"] = []SRVRecord{{Target: "sbc2.carrier.example.", Port: 5060, Priority: 10}}
+	dns.srv["_sip._udp.nosrv.example"] = nil
+	dns.ip["sbc1.carrier.example"] = []net.IP{net.ParseIP("192.0.2.1")}
+	dns.ip["sbc2.carrier.example"] = []net.IP{net.ParseIP("192.0.2.2"), net.ParseIP("2001:db8::2")}
+	dns.ip["nosrv.example"] = []net.IP{net.ParseIP("192.0.2.9")}
+	loc := NewLocator(dns)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		target DialTarget
+		want   []string
+	}{
+		{"naptr then srv", DialTarget{RequestURI: "sip:13800138000@carrier.example"},
+			[]string{"tcp:192.0.2.1:5070", "udp:192.0.2.2:5060", "udp:[2001:db8::2]:5060"}},
+		{"explicit transport skips naptr", DialTarget{RequestURI: "sip:1@x", SignalingAddr: "carrier.example", Transport: TransportUDP},
+			[]string{"udp:192.0.2.2:5060", "udp:[2001:db8::2]:5060"}},
+		{"a record fallback", DialTarget{RequestURI: "sip:1@nosrv.example"}, []string{"udp:192.0.2.9:5060"}},
+		{"explicit port is A only", DialTarget{RequestURI: "sip:1@x", SignalingAddr: "sbc1.carrier.example:5080"},
+			[]string{"udp:192.0.2.1:5080"}},
+		{"ip literal", DialTarget{RequestURI: "sip:1@198.51.100.7;transport=tcp"}, []string{"tcp:198.51.100.7:5060"}},
+		{"sips default port", DialTarget{RequestURI: "sips:1@198.51.100.7"}, []string{"tls:198.51.100.7:5061"}},
+	}
+	for _, tc := range cases {
+		hops, err := loc.Resolve(ctx, tc.target)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := hopStrings(hops); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: hops = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := loc.Resolve(ctx, DialTarget{RequestURI: "sip:1@unknown.example"}); err == nil {
+		t.Error("unresolvable host should fail")
+	}
+}
+
+func TestLocator_CacheAndBlacklist(t *testing.T) {
+	dns := newFakeDNS()
+	dns.ttl = 30 * time.Second
+	dns.srv["_sip._udp.carrier.example"] = []SRVRecord{
+		{Target: "a.carrier.example", Port: 5060, Priority: 10},
+		{Target: "b.carrier.example", Port: 5060, Priority: 20},
+	}
+	dns.ip["a.carrier.example"] = []net.IP{net.ParseIP("192.0.2.1")}
+	dns.ip["b.carrier.example"] = []net.IP{net.ParseIP("192.0.2.2")}
+	now := time.Unix(1_700_000_000, 0)
+	loc := NewLocator(dns)
+	loc.now = func() time.Time { return now }
+	target := DialTarget{RequestURI: "sip:1@carrier.example", Transport: TransportUDP}
+	ctx := context.Background()
+
+	hops, err := loc.Resolve(ctx, target)
+	if err != nil || len(hops) != 2 || hops[0].Addr.String() != "192.0.2.1:5060" {
+		t.Fatalf("hops = %v, %v", hopStrings(hops), err)
+	}
+	if _, err := loc.Resolve(ctx, target); err != nil {
+		t.Fatal(err)
+	}
+	if n := dns.count("_sip._udp.carrier.example"); n != 1 {
+		t.Errorf("SRV lookups within TTL = %d, want 1", n)
+	}
+	now = now.Add(31 * time.Second)
+	if _, err := loc.Resolve(ctx, target); err != nil {
+		t.Fatal(err)
+	}
+	if n := dns.count("_sip._udp.carrier.example"); n != 2 {
+		t.Errorf("SRV lookups after TTL = %d, want 2", n)
+	}
+
+	loc.Blacklist(hops[0], 10*time.Second)
+	demoted, _ := loc.Resolve(ctx, target)
+	if got := hopStrings(demoted); got[0] != "udp:192.0.2.2:5060" || got[1] != "udp:192.0.2.1:5060" {
+		t.Errorf("blacklisted hop not demoted: %v", got)
+	}
+	now = now.Add(11 * time.Second)
+	if loc.Blacklisted(hops[0]) {
+		t.Error("blacklist should expire")
+	}
+}
+
+func TestOrderSRV_PriorityAndWeight(t *testing.T) {
+	recs := []SRVRecord{
+		{Target: "backup", Priority: 20, Weight: 100},
+		{Target: "light", Priority: 10, Weight: 10},
+		{Target: "heavy", Priority: 10, Weight: 90},
+	}
+	// Weights+1 in record order within priority 10: light 11, heavy 91.
+	// Drawing 50 lands in heavy's slice; the last pick is forced.
+	got := orderSRV(recs, func(n int) int { return 50 % n })
+	var names []string
+	for _, r := range got {
+		names = append(names, r.Target)
+	}
+	if strings.Join(names, ",") != "heavy,light,backup" {
+		t.Errorf("order = %v", names)
+	}
+	got = orderSRV(recs, func(int) int { return 0 })
+	if got[0].Target != "light" || got[2].Target != "backup" {
+		t.Errorf("draw 0 should pick the first record of the lowest priority: %+v", got)
+	}
+}
+
+func TestParseNAPTRData(t *testing.T) {
+	b := []byte{0, 10, 0, 20}
+	for _, s := range []string{"s", "SIP+D2T", ""} {
+		b = append(b, byte(len(s)))
+		b = append(b, s...)
+	}
+	for _, l := range []string{"_sip", "_tcp", "carrier", "example"} {
+		b = append(b, byte(len(l)))
+		b = append(b, l...)
+	}
+	b = append(b, 0)
+	n, err := parseNAPTRData(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NAPTRRecord{Order: 10, Preference: 20, Flags: "s", Service: "SIP+D2T", Replacement: "_sip._tcp.carrier.example"}
+	if n != want {
+		t.Errorf("got %+v", n)
+	}
+	if _, err := parseNAPTRData(b[:9]); err == nil {
+		t.Error("truncated rdata should fail")
+	}
+}
+
+func TestDial_FailsOverToNextSRVHop(t *testing.T) {
+	t.Setenv("SIP_OUTBOUND_HOP_TIMEOUT_MS", "50")
+	dns := newFakeDNS()
+	dns.srv["_sip._udp.carrier.example"] = []SRVRecord{
+		{Target: "a.carrier.example", Port: 5060, Priority: 10},
+		{Target: "b.carrier.example", Port: 5060, Priority: 20},
+		{Target: "c.carrier.example", Port: 5060, Priority: 30},
+	}
+	dns.ip["a.carrier.example"] = []net.IP{net.ParseIP("192.0.2.1")}
+	dns.ip["b.carrier.example"] = []net.IP{net.ParseIP("192.0.2.2")}
+	dns.ip["c.carrier.example"] = []net.IP{net.ParseIP("192.0.2.3")}
+
+	m := NewManager(ManagerConfig{SIPHost: "192.0.2.100", SIPPort: 5060, DNSResolver: dns})
+	carrier := &fakeRegistrar{}
+	carrier.reply = func(req *stack.Message, dst *net.UDPAddr) *stack.Message {
+		if req.Method != stack.MethodInvite {
+			return nil
+		}
+		switch dst.String() {
+		case "192.0.2.1:5060":
+			resp := registerResponse(req, 503)
+			resp.SetHeader("Retry-After", "120")
+			return resp
+		case "192.0.2.3:5060":
+			return registerResponse(req, 180)
+		}
+		return nil // b is silent → hop timeout
+	}
+	carrier.bind(m)
+
+	callID, err := m.Dial(context.Background(), DialRequest{
+		Target: DialTarget{RequestURI: "sip:13800138000@carrier.example", Transport: TransportUDP},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.AbandonEarlyTransferInvite(callID)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, dsts := carrier.requests(); len(dsts) >= 4 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sent, dsts := carrier.requests()
+	var got []string
+	for i, msg := range sent {
+		got = append(got, msg.Method+" "+msg.GetHeader("CSeq")+" → "+dsts[i])
+	}
+	want := []string{
+		"INVITE 1 INVITE → 192.0.2.1:5060",
+		"ACK 1 ACK → 192.0.2.1:5060",
+		"INVITE 2 INVITE → 192.0.2.2:5060",
+		"INVITE 3 INVITE → 192.0.2.3:5060",
+	}
+	if strings.Join(got[:min(len(got), 4)], "\n") != strings.Join(want, "\n") {
+		t.Fatalf("sent:\n%s", strings.Join(got, "\n"))
+	}
+	if sent[0].GetHeader("Via") == sent[2].GetHeader("Via") {
+		t.Error("failover INVITE must use a new branch")
+	}
+	loc := m.Locator()
+	a := Hop{Transport: TransportUDP, Addr: &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5060}}
+	b := Hop{Transport: TransportUDP, Addr: &net.UDPAddr{IP: net.ParseIP("192.0.2.2"), Port: 5060}}
+	if !loc.Blacklisted(a) || !loc.Blacklisted(b) {
+		t.Error("503 and silent hops should be blacklisted")
+	}
+
+	// 180 from c bound the leg to it: no further failover.
+	time.Sleep(120 * time.Millisecond)
+	if sent, _ := carrier.requests(); len(sent) != 4 {
+		t.Errorf("unexpected requests after 180: %d", len(sent))
+	}
+}
@@ -41,6 +41,12 @@ const (
 	// TransportTLS is TCP wrapped in TLS, paired with the SIPS URI
 	// scheme on Contact / Via (RFC 3261 §26.2.1).
 	TransportTLS Transport = "tls"
+	// TransportWS / TransportWSS are SIP over WebSocket (RFC 7118).
+	// Only reachable through a flow the UA opened to us (browser /
+	// softphone registered with ;transport=ws); sends go through the
+	// shared sender, which routes them onto that connection.
+	TransportWS  Transport = "ws"
+	TransportWSS Transport = "wss"
 	// TransportUnset means "no preference" — selection falls through
 	// to the next precedence layer or to TransportUDP.
 	TransportUnset Transport = ""
@@ -50,7 +56,7 @@ const (
 // TransportUnset is NOT valid (caller should resolve before calling).
 func (t Transport) IsValid() bool {
 	switch t {
-	case TransportUDP, TransportTCP, TransportTLS:
+	case TransportUDP, TransportTCP, TransportTLS, TransportWS, TransportWSS:
 		return true
 	}
 	return false
@@ -61,6 +67,10 @@ func (t Transport) IsValid() bool {
 // when this returns true (RFC 3261 §26.2.1).
 func (t Transport) IsTLS() bool { return t == TransportTLS }
 
+// IsWebSocket reports whether the leg rides a SIP-over-WebSocket flow.
+// Such peers are WebRTC stacks: media must be DTLS-SRTP with ICE.
+func (t Transport) IsWebSocket() bool { return t == TransportWS || t == TransportWSS }
+
 // IsConnectionOriented reports whether the transport requires
 // per-target conn lifecycle management (TCP/TLS) versus the
 // shared-socket model (UDP).
@@ -78,6 +88,10 @@ func (t Transport) ViaToken() string {
 		return "SIP/2.0/TCP"
 	case TransportTLS:
 		return "SIP/2.0/TLS"
+	case TransportWS:
+		return "SIP/2.0/WS"
+	case TransportWSS:
+		return "SIP/2.0/WSS"
 	default:
 		return "SIP/2.0/UDP"
 	}
@@ -95,6 +109,10 @@ func parseTransportToken(s string) Transport {
 		return TransportTCP
 	case "tls":
 		return TransportTLS
+	case "ws":
+		return TransportWS
+	case "wss":
+		return TransportWSS
 	}
 	return TransportUnset
 }
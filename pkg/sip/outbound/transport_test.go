@@ -6,7 +6,7 @@ package outbound
 import "testing"
 
 func TestTransport_IsValid(t *testing.T) {
-	for _, tr := range []Transport{TransportUDP, TransportTCP, TransportTLS} {
+	for _, tr := range []Transport{TransportUDP, TransportTCP, TransportTLS, TransportWS, TransportWSS} {
 		if !tr.IsValid() {
 			t.Errorf("%s should be valid", tr)
 		}
@@ -32,6 +32,12 @@ func TestTransport_IsTLS_IsConnectionOriented(t *testing.T) {
 	if TransportUDP.IsConnectionOriented() {
 		t.Errorf("UDP must not be connection-oriented")
 	}
+	if TransportWS.IsConnectionOriented() || TransportWSS.IsConnectionOriented() {
+		t.Errorf("WS flows are accepted, never dialled: not pooled as connections")
+	}
+	if !TransportWSS.IsWebSocket() || TransportTLS.IsWebSocket() {
+		t.Errorf("IsWebSocket mismatch")
+	}
 }
 
 func TestTransport_ViaToken(t *testing.T) {
@@ -39,6 +45,8 @@ func TestTransport_ViaToken(t *testing.T) {
 		TransportUDP:   "SIP/2.0/UDP",
 		TransportTCP:   "SIP/2.0/TCP",
 		TransportTLS:   "SIP/2.0/TLS",
+		TransportWS:    "SIP/2.0/WS",
+		TransportWSS:   "SIP/2.0/WSS",
 		TransportUnset: "SIP/2.0/UDP", // safe default
 	}
 	for tr, want := range cases {
@@ -54,6 +62,8 @@ func TestParseTransportToken(t *testing.T) {
 		"UDP":     TransportUDP,
 		"  tcp  ": TransportTCP,
 		"TLS":     TransportTLS,
+		"ws":      TransportWS,
+		"WSS":     TransportWSS,
 		"":        TransportUnset,
 		"sctp":    TransportUnset, // we don't support SCTP
 		"garbage": TransportUnset,
@@ -18,6 +18,13 @@ const (
 	ScenarioTransferAgent Scenario = "transfer_agent"
 	// ScenarioCallback is a scheduled return call (same runtime as campaign, distinct for analytics).
 	ScenarioCallback Scenario = "callback"
+	// ScenarioClickToCall is an API-originated call: the customer leg with an AI / script
+	// profile, or an agent leg (MediaProfileNone) followed by a customer leg bridged to it
+	// (MediaProfileTransferBridge, CorrelationID = agent Call-ID).
+	ScenarioClickToCall Scenario = "click_to_call"
+	// ScenarioWebSeatOutbound is a customer leg dialed by a browser agent and
+	// bridged to its WebRTC seat (MediaProfileNone, EarlyMedia).
+	ScenarioWebSeatOutbound Scenario = "webseat_outbound"
 )
 
 // DialTarget is a minimal description of where to send INVITE.
@@ -27,8 +34,10 @@ type DialTarget struct {
 	WebSeat bool
 	// RequestURI is the SIP request URI, e.g. sip:+8613800138000@carrier.example;user=phone
 	RequestURI string
-	// SignalingAddr is the UDP address of the next SIP hop (proxy or UAS).
-	SignalingAddr string // host:port
+	// SignalingAddr is the next SIP hop (proxy or UAS): IP:port, host:port
+	// or a bare domain resolved per RFC 3263 (NAPTR/SRV). Empty → the
+	// Request-URI host is located instead.
+	SignalingAddr string
 	// Optional From/Contact user part (e.g. ACD pool sipCallerId). Empty → Dial uses Manager/env default.
 	CallerUser string
 	// Optional quoted display-name in From when CallerUser is set (or alone if DialRequest sets caller).
@@ -36,6 +45,10 @@ type DialTarget struct {
 
 	// ACDPoolTargetID is set when the target comes from acd_pool_targets (transfer routing); used for retries / bookkeeping.
 	ACDPoolTargetID uint `json:"-"`
+	// TrunkID / TrunkNumberID identify the trunk-group member this target was built from
+	// (routing layer stats and per-member capacity); zero for ad-hoc targets.
+	TrunkID       uint `json:"-"`
+	TrunkNumberID uint `json:"-"`
 
 	// Transport is the trunk-configured signaling transport for this
 	// target (UDP / TCP / TLS). Empty (TransportUnset) means "use
@@ -47,6 +60,16 @@ type DialTarget struct {
 	// hardcodes UDP. Slice 2 of batch 2A-out will switch the
 	// connection layer over.
 	Transport Transport
+
+	// Auth is the trunk account answering 401/407 challenges on this
+	// leg (INVITE and in-dialog requests). nil falls back to the
+	// Manager's trunk auth resolver (SetTrunkAuthResolver).
+	Auth *DigestCredentials `json:"-"`
+
+	// Route is a preloaded Route set, top first (RFC 3261 §8.1.2), e.g.
+	// the RFC 3327 Path of a SIP user registered through an edge proxy.
+	// SignalingAddr should then be the first Route hop. Empty for trunks.
+	Route []string `json:"-"`
 }
 
 // DialRequest is one outbound attempt.
@@ -70,6 +93,17 @@ type DialRequest struct {
 	// DialTenantID scopes per-tenant trunk-number outbound concurrency (campaign worker sets this).
 	DialTenantID uint `json:"-"`
 
+	// EarlyMedia applies SDP from 18x responses and reports it through
+	// ManagerConfig.OnEarlyMedia; the leg's default media is then not started
+	// on 200 OK (the early-media consumer owns the RTP socket). Plain RTP only.
+	EarlyMedia bool
+
+	// Fallbacks are further trunk-group members tried in order, on the
+	// same Call-ID, when Target answers 408 / 5xx or stays silent past
+	// every DNS hop (failover.go). Each switch re-runs the dial gate so
+	// per-member capacity applies, and emits DialEventRerouted.
+	Fallbacks []DialTarget
+
 	// AssertedIdentityURI (RFC 3325) is the carrier-verified caller URI
 	// that the platform is authorised to assert on this outbound leg.
 	// Typical value: the trunk_number that owns this outbound channel
@@ -150,6 +184,14 @@ const (
 	DialEventProvisional = "provisional"
 	DialEventEstablished = "established"
 	DialEventFailed      = "failed"
+	// DialEventRerouted: the current trunk failed (StatusCode, 0 = no
+	// answer) and the INVITE moved to the next DialRequest.Fallbacks entry.
+	DialEventRerouted = "rerouted"
+	// DialEventRedirected: a 3xx (StatusCode) retargeted the INVITE to
+	// RequestURI; OriginalRequestURI is what was dialled first.
+	DialEventRedirected = "redirected"
+	// DialEventEnded: an established leg was torn down (BYE either way).
+	DialEventEnded = "ended"
 )
 
 // DialEvent streams lightweight dial lifecycle transitions for queue/observability.
@@ -170,4 +212,22 @@ type DialEvent struct {
 	// RemoteAddr is the UDP signaling peer: INVITE destination for "invited";
 	// source address of SIP responses for provisional / failure / established.
 	RemoteAddr string
+	// OriginalRequestURI is the Request-URI before 3xx redirection; empty
+	// unless the leg was redirected (RequestURI is then the final target).
+	OriginalRequestURI string
+}
+
+// bridgedAgentLeg reports whether the leg is raw-bridged to another SIP leg
+// (desk phone / softphone on the far side) or to a G.711-only web seat: such
+// offers use the narrowband bridge codec set over plain RTP/AVP.
+func (r DialRequest) bridgedAgentLeg() bool {
+	switch r.Scenario {
+	case ScenarioWebSeatOutbound:
+		return true
+	case ScenarioTransferAgent:
+		return r.MediaProfile == MediaProfileTransferBridge
+	case ScenarioClickToCall:
+		return r.MediaProfile == MediaProfileTransferBridge || r.MediaProfile == MediaProfileNone
+	}
+	return false
 }
@@ -0,0 +1,175 @@
+package persist
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AgentCallRow is the slice of a sip_calls row that agent reports read.
+type AgentCallRow struct {
+	InviteAt               *time.Time
+	AckAt                  *time.Time
+	DurationSec            int
+	ACWSec                 int
+	AgentACDTargetID       uint
+	DispositionACDTargetID uint
+	TransferTraceJSON      datatypes.JSON
+}
+
+// AgentCallStats are one agent's call metrics over a period. TalkSec is the
+// call duration of handled calls; handle time is talk plus after-call work.
+type AgentCallStats struct {
+	CallsHandled      int64   `json:"callsHandled"`
+	TransfersOffered  int64   `json:"transfersOffered"`  // ACD rang this agent
+	TransfersReceived int64   `json:"transfersReceived"` // … and the agent answered
+	TransfersMissed   int64   `json:"transfersMissed"`   // no answer or rejected
+	TalkSec           int64   `json:"talkSec"`
+	ACWSec            int64   `json:"acwSec"`
+	AvgTalkSec        float64 `json:"avgTalkSec"`
+	AvgACWSec         float64 `json:"avgAcwSec"`
+	AvgHandleSec      float64 `json:"avgHandleSec"`
+}
+
+// AgentDayKey buckets AgentCallStats by agent and local calendar day (yyyy-MM-dd).
+type AgentDayKey struct {
+	ACDTargetID uint
+	Day         string
+}
+
+// handlingAgent is who talked to the customer: the seat that answered the
+// transfer, else the agent of an agent-bridged outbound call, else the seat
+// that wrapped it up.
+func (r AgentCallRow) handlingAgent(trace []sipCallTransferTraceEntry) uint {
+	for i := len(trace) - 1; i >= 0; i-- {
+		if trace[i].Outcome == "answered" && trace[i].ACDTargetID > 0 {
+			return trace[i].ACDTargetID
+		}
+	}
+	if r.AgentACDTargetID > 0 && r.AckAt != nil {
+		return r.AgentACDTargetID
+	}
+	return r.DispositionACDTargetID
+}
+
+// AggregateAgentCalls folds call rows into per-agent, per-day stats (days in loc).
+// Calls without an invite time are skipped.
+func AggregateAgentCalls(rows []AgentCallRow, loc *time.Location) map[AgentDayKey]*AgentCallStats {
+	if loc == nil {
+		loc = time.Local
+	}
+	out := make(map[AgentDayKey]*AgentCallStats)
+	get := func(id uint, day string) *AgentCallStats {
+		k := AgentDayKey{ACDTargetID: id, Day: day}
+		s := out[k]
+		if s == nil {
+			s = &AgentCallStats{}
+			out[k] = s
+		}
+		return s
+	}
+	acwCalls := make(map[AgentDayKey]int64)
+	for _, r := range rows {
+		if r.InviteAt == nil {
+			continue
+		}
+		day := r.InviteAt.In(loc).Format("2006-01-02")
+		trace := ParseSIPCallTransferTrace(r.TransferTraceJSON)
+		for _, e := range trace {
+			if e.ACDTargetID == 0 {
+				continue
+			}
+			s := get(e.ACDTargetID, day)
+			s.TransfersOffered++
+			if e.Outcome == "answered" {
+				s.TransfersReceived++
+			} else {
+				s.TransfersMissed++
+			}
+		}
+		id := r.handlingAgent(trace)
+		if id == 0 {
+			continue
+		}
+		s := get(id, day)
+		s.CallsHandled++
+		s.TalkSec += int64(r.DurationSec)
+		if r.ACWSec > 0 {
+			s.ACWSec += int64(r.ACWSec)
+			acwCalls[AgentDayKey{ACDTargetID: id, Day: day}]++
+		}
+	}
+	for k, s := range out {
+		s.finish(acwCalls[k])
+	}
+	return out
+}
+
+// Merge adds o into s (e.g. several days into a period total); call Finish afterwards.
+func (s *AgentCallStats) Merge(o AgentCallStats) {
+	s.CallsHandled += o.CallsHandled
+	s.TransfersOffered += o.TransfersOffered
+	s.TransfersReceived += o.TransfersReceived
+	s.TransfersMissed += o.TransfersMissed
+	s.TalkSec += o.TalkSec
+	s.ACWSec += o.ACWSec
+}
+
+// Finish recomputes the averages after Merge. ACW is averaged over all handled
+// calls here, since per-call ACW counts are not kept across merges.
+func (s *AgentCallStats) Finish() { s.finish(s.CallsHandled) }
+
+func (s *AgentCallStats) finish(acwCalls int64) {
+	s.AvgTalkSec, s.AvgACWSec, s.AvgHandleSec = 0, 0, 0
+	if s.CallsHandled > 0 {
+		s.AvgTalkSec = float64(s.TalkSec) / float64(s.CallsHandled)
+		s.AvgHandleSec = float64(s.TalkSec+s.ACWSec) / float64(s.CallsHandled)
+	}
+	if acwCalls > 0 {
+		s.AvgACWSec = float64(s.ACWSec) / float64(acwCalls)
+	}
+}
+
+// ListAgentCallRows loads calls invited in [from, to) that involved an agent.
+// tenantID 0 skips tenant scoping (platform admin).
+func ListAgentCallRows(ctx context.Context, db *gorm.DB, tenantID uint, from, to time.Time) ([]AgentCallRow, error) {
+	q := ActiveSIPCalls(db.WithContext(ctx)).
+		Select("invite_at, ack_at, duration_sec, acw_sec, agent_acd_target_id, disposition_acd_target_id, transfer_trace_json").
+		Where("invite_at >= ? AND invite_at < ?", from, to).
+		Where("(transfer_acd_target_id > 0 OR agent_acd_target_id > 0 OR disposition_acd_target_id > 0)")
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	var rows []AgentCallRow
+	err := q.Scan(&rows).Error
+	return rows, err
+}
+
+// CountCallsByInboundNumber counts calls invited in [from, to) per inbound trunk number:
+// all calls, and those that reached a live agent (SIP bridge or web seat).
+func CountCallsByInboundNumber(ctx context.Context, db *gorm.DB, tenantID uint, from, to time.Time) (map[uint][2]int64, error) {
+	type bucket struct {
+		InboundTrunkNumberID uint
+		Calls                int64
+		Transferred          int64
+	}
+	q := ActiveSIPCalls(db.WithContext(ctx)).
+		Select("inbound_trunk_number_id, COUNT(*) AS calls, "+
+			"SUM(CASE WHEN had_sip_transfer OR had_web_seat THEN 1 ELSE 0 END) AS transferred").
+		Where("invite_at >= ? AND invite_at < ?", from, to).
+		Where("inbound_trunk_number_id > 0")
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	var list []bucket
+	if err := q.Group("inbound_trunk_number_id").Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint][2]int64, len(list))
+	for _, b := range list {
+		out[b.InboundTrunkNumberID] = [2]int64{b.Calls, b.Transferred}
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package persist
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+func TestAggregateAgentCalls(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	day1 := time.Date(2026, 3, 2, 10, 0, 0, 0, loc)
+	day2 := day1.Add(24 * time.Hour)
+	ack := day1.Add(5 * time.Second)
+	rows := []AgentCallRow{
+		// 7 missed, 8 answered and wrapped up.
+		{InviteAt: &day1, DurationSec: 100, ACWSec: 20, DispositionACDTargetID: 8,
+			TransferTraceJSON: datatypes.JSON(`[{"acdTargetId":7,"outcome":"no_answer"},{"acdTargetId":8,"outcome":"answered"}]`)},
+		// outbound agent-first call by 8, no ACW.
+		{InviteAt: &day1, AckAt: &ack, DurationSec: 60, AgentACDTargetID: 8},
+		// outbound call by 8 never answered: not handled.
+		{InviteAt: &day1, DurationSec: 0, AgentACDTargetID: 8},
+		// next day, 7 answers.
+		{InviteAt: &day2, DurationSec: 30, ACWSec: 10,
+			TransferTraceJSON: datatypes.JSON(`[{"acdTargetId":7,"outcome":"answered"}]`)},
+		{InviteAt: nil, DurationSec: 99, AgentACDTargetID: 8},
+	}
+	got := AggregateAgentCalls(rows, loc)
+
+	a8 := got[AgentDayKey{ACDTargetID: 8, Day: "2026-03-02"}]
+	if a8 == nil || a8.CallsHandled != 2 || a8.TransfersReceived != 1 || a8.TalkSec != 160 || a8.ACWSec != 20 {
+		t.Fatalf("agent 8: %+v", a8)
+	}
+	if a8.AvgTalkSec != 80 || a8.AvgACWSec != 20 || a8.AvgHandleSec != 90 {
+		t.Fatalf("agent 8 averages: %+v", a8)
+	}
+	a7 := got[AgentDayKey{ACDTargetID: 7, Day: "2026-03-02"}]
+	if a7 == nil || a7.CallsHandled != 0 || a7.TransfersOffered != 1 || a7.TransfersMissed != 1 {
+		t.Fatalf("agent 7 day 1: %+v", a7)
+	}
+	b7 := got[AgentDayKey{ACDTargetID: 7, Day: "2026-03-03"}]
+	if b7 == nil || b7.CallsHandled != 1 || b7.TransfersReceived != 1 || b7.AvgHandleSec != 40 {
+		t.Fatalf("agent 7 day 2: %+v", b7)
+	}
+
+	var total AgentCallStats
+	total.Merge(*a7)
+	total.Merge(*b7)
+	total.Finish()
+	if total.TransfersOffered != 2 || total.CallsHandled != 1 || total.AvgTalkSec != 30 {
+		t.Fatalf("merged: %+v", total)
+	}
+}
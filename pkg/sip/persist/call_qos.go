@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	siprtp "github.com/LinByte/VoiceServer/pkg/sip/rtp"
+	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
 	"github.com/LinByte/VoiceServer/pkg/voice/qos"
 )
 
@@ -34,6 +35,30 @@ func QoSDBUpdatesFromRTCP(codecName string, clockRate int, snap siprtp.RTCPStats
 	if snap.RTTMs > 0 {
 		out["qos_rtt_ms"] = snap.RTTMs
 	}
+	if snap.NACKedPackets > 0 {
+		out["qos_nack_sent"] = snap.NACKedPackets
+	}
+	if snap.Retransmits > 0 {
+		out["qos_retransmits"] = snap.Retransmits
+	}
+	return out
+}
+
+// WebSeatQoSUpdates builds sip_calls column updates from a web seat's
+// connection-quality summary (nil when nothing was sampled).
+func WebSeatQoSUpdates(q webseat.QualitySummary) map[string]any {
+	if q.Samples == 0 {
+		return nil
+	}
+	out := map[string]any{
+		"webseat_rtt_ms":     float32(q.AvgRTTMs),
+		"webseat_max_rtt_ms": float32(q.MaxRTTMs),
+		"webseat_loss_pct":   float32(q.AvgLossPct),
+		"webseat_jitter_ms":  float32(q.AvgJitterMs),
+	}
+	if q.CandidateType != "" {
+		out["webseat_candidate_type"] = q.CandidateType
+	}
 	return out
 }
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	siprtp "github.com/LinByte/VoiceServer/pkg/sip/rtp"
+	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
 )
 
 func TestQoSDBUpdatesFromRTCP_empty(t *testing.T) {
@@ -26,3 +27,24 @@ func TestQoSDBUpdatesFromRTCP_withRTT(t *testing.T) {
 		t.Fatalf("rtt: %v", up["qos_rtt_ms"])
 	}
 }
+
+func TestQoSDBUpdatesFromRTCP_NACKCounters(t *testing.T) {
+	up := QoSDBUpdatesFromRTCP("opus", 48000, siprtp.RTCPStats{
+		LocalPacketsRecv: 500,
+		NACKedPackets:    7,
+		Retransmits:      3,
+	})
+	if up["qos_nack_sent"] != uint64(7) || up["qos_retransmits"] != uint64(3) {
+		t.Fatalf("updates = %v", up)
+	}
+}
+
+func TestWebSeatQoSUpdates(t *testing.T) {
+	if got := WebSeatQoSUpdates(webseat.QualitySummary{}); got != nil {
+		t.Fatalf("expected nil updates, got %v", got)
+	}
+	up := WebSeatQoSUpdates(webseat.QualitySummary{AvgRTTMs: 80, MaxRTTMs: 120, AvgLossPct: 1.5, CandidateType: "relay", Samples: 4})
+	if up["webseat_rtt_ms"] != float32(80) || up["webseat_max_rtt_ms"] != float32(120) || up["webseat_candidate_type"] != "relay" {
+		t.Fatalf("updates = %v", up)
+	}
+}
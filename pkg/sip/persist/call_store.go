@@ -10,11 +10,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/scriptlisten"
 	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
 	sipMetrics "github.com/LinByte/VoiceServer/pkg/sip/metrics"
 	sipServer "github.com/LinByte/VoiceServer/pkg/sip/server"
+	"github.com/LinByte/VoiceServer/pkg/sip/webseat"
 	"github.com/LinByte/VoiceServer/pkg/stores"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
 	"go.uber.org/zap"
@@ -52,6 +55,7 @@ func (s *CallStore) OnInvite(ctx context.Context, p sipServer.InvitePersistParam
 			p.RemoteRTP, p.LocalRTP, p.PayloadType, p.Codec, p.ClockRate, now,
 			p.TenantID, p.InboundTrunkNumberID,
 		)
+		row.AgentACDTargetID = p.AgentACDTargetID
 		if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
 			s.lg.Warn("sippersist invite create", zap.String("call_id", p.CallID), zap.Error(err))
 		}
@@ -61,9 +65,13 @@ func (s *CallStore) OnInvite(ctx context.Context, p sipServer.InvitePersistParam
 		s.lg.Warn("sippersist invite lookup", zap.String("call_id", p.CallID), zap.Error(err))
 		return
 	}
-	_ = s.db.WithContext(ctx).Model(&row).Updates(SIPCallInviteRefreshUpdateMap(
+	updates := SIPCallInviteRefreshUpdateMap(
 		p.From, p.To, p.RemoteSig, p.RemoteRTP, p.LocalRTP, p.Codec, p.PayloadType, p.ClockRate, p.TenantID, p.InboundTrunkNumberID, now,
-	)).Error
+	)
+	if p.AgentACDTargetID > 0 {
+		updates["agent_acd_target_id"] = p.AgentACDTargetID
+	}
+	_ = s.db.WithContext(ctx).Model(&row).Updates(updates).Error
 }
 
 // OnEstablished marks call established (ACK / media start).
@@ -75,6 +83,31 @@ func (s *CallStore) OnEstablished(ctx context.Context, callID string) {
 	_ = s.db.WithContext(ctx).Model(&SIPCall{}).Where("call_id = ?", callID).Updates(SIPCallEstablishedUpdateMap(now)).Error
 }
 
+// OnWebSeatQuality stores a web seat's WebRTC connection quality on the call row.
+func (s *CallStore) OnWebSeatQuality(ctx context.Context, callID string, q webseat.QualitySummary) {
+	if s == nil || s.db == nil || callID == "" {
+		return
+	}
+	up := WebSeatQoSUpdates(q)
+	if len(up) == 0 {
+		return
+	}
+	if err := s.db.WithContext(ctx).Model(&SIPCall{}).Where("call_id = ?", callID).Updates(up).Error; err != nil {
+		s.lg.Warn("sippersist webseat quality", zap.String("call_id", callID), zap.Error(err))
+	}
+}
+
+// OnLanguageDetected stores the caller language chosen from the first utterance.
+func (s *CallStore) OnLanguageDetected(ctx context.Context, callID, lang string) {
+	lang = strings.TrimSpace(lang)
+	if s == nil || s.db == nil || callID == "" || lang == "" {
+		return
+	}
+	if err := s.db.WithContext(ctx).Model(&SIPCall{}).Where("call_id = ?", callID).Update("detected_language", lang).Error; err != nil {
+		s.lg.Warn("sippersist detected language", zap.String("call_id", callID), zap.Error(err))
+	}
+}
+
 // OnBye finalizes SIPCall, optionally uploads SN3/SN2 recording as stereo WAV (L=user R=AI per-leg decode),
 // falling back to legacy mono mix, via pkg/stores.Default().
 func (s *CallStore) OnBye(ctx context.Context, p sipServer.ByePersistParams) {
@@ -116,6 +149,7 @@ func (s *CallStore) OnBye(ctx context.Context, p sipServer.ByePersistParams) {
 	if clockRate <= 0 && call.ClockRate > 0 {
 		clockRate = call.ClockRate
 	}
+	sipMetrics.ObserveCallNACK(p.RTCP.NACKedPackets, p.RTCP.NACKsReceived, p.RTCP.Retransmits)
 	if qosUp := QoSDBUpdatesFromRTCP(codecName, clockRate, p.RTCP); len(qosUp) > 0 {
 		for k, v := range qosUp {
 			updates[k] = v
@@ -155,6 +189,9 @@ func (s *CallStore) OnBye(ctx context.Context, p sipServer.ByePersistParams) {
 		if wav.Hash != "" {
 			updates["recording_hash"] = wav.Hash
 		}
+		if envelope.Enabled() {
+			updates["recording_encrypted"] = true
+		}
 		recordingSec = ResolveRecordingDurationSec(wav, nil)
 		ApplySIPCallDurationFromRecording(updates, call, recordingSec)
 		if recordingSec <= 0 {
@@ -177,7 +214,7 @@ func (s *CallStore) OnBye(ctx context.Context, p sipServer.ByePersistParams) {
 	}
 
 	c := strings.ToLower(codecName)
-	store := stores.Default()
+	store := envelope.Wrap(stores.Default(), call.TenantID)
 	var wav []byte
 	if len(raw) > 0 && store != nil {
 		switch {
@@ -233,6 +270,7 @@ func (s *CallStore) OnBye(ctx context.Context, p sipServer.ByePersistParams) {
 			} else if pub := strings.TrimSpace(stores.PublicObjectURL(store, key)); pub != "" {
 				updates["recording_url"] = pub
 				updates["recording_wav_bytes"] = len(wav)
+				updates["recording_encrypted"] = envelope.Enabled()
 				s.lg.Info("sippersist recording uploaded", zap.String("call_id", callID), zap.String("codec", codecName))
 			}
 		} else if len(raw) >= 3 && raw[0] == 'S' && raw[1] == 'N' && (raw[2] == '3' || raw[2] == '2' || raw[2] == '1') {
@@ -241,6 +279,7 @@ func (s *CallStore) OnBye(ctx context.Context, p sipServer.ByePersistParams) {
 				s.lg.Warn("sippersist raw recording upload", zap.String("call_id", callID), zap.Error(err))
 			} else if pub := strings.TrimSpace(stores.PublicObjectURL(store, snKey)); pub != "" {
 				updates["recording_url"] = pub
+				updates["recording_encrypted"] = envelope.Enabled()
 				s.lg.Info("sippersist raw SN recording uploaded (no WAV)", zap.String("call_id", callID), zap.String("codec", codecName), zap.Int("raw_bytes", len(raw)))
 			}
 		} else if len(raw) > 0 {
@@ -279,6 +318,17 @@ func (s *CallStore) SaveConversationTurn(ctx context.Context, callID string, t c
 	if !t.At.IsZero() {
 		now = t.At
 	}
+
+	row, err := FindActiveSIPCallByCallID(ctx, s.db, callID)
+	// PII never reaches sip_calls.turns in clear text: tenant policy when
+	// the call row is known, process default otherwise.
+	var tenantID uint
+	if err == nil {
+		tenantID = row.TenantID
+	}
+	pii := redact.ForTenant(ctx, tenantID)
+	userText = redact.CallText(pii, callID, userText)
+	assistantText = pii.Text(assistantText)
 	turn := SIPCallDialogTurn{
 		ASRText:      userText,
 		LLMText:      assistantText,
@@ -296,7 +346,6 @@ func (s *CallStore) SaveConversationTurn(ctx context.Context, callID string, t c
 		TurnGroupID:  t.TurnGroupID,
 	}
 
-	row, err := FindActiveSIPCallByCallID(ctx, s.db, callID)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		turnsBytes, jErr := MarshalSIPCallTurns([]SIPCallDialogTurn{turn})
 		if jErr != nil {
@@ -4,19 +4,25 @@ import (
 	"strings"
 
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
+	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
 )
 
-// ResolveRecordingDurationSec returns whole seconds from WAV bytes (header parse),
-// else from stored object key, else from recorder sample-derived DurationMs.
-// Never uses wall-clock BYE timestamps.
+// ResolveRecordingDurationSec returns whole seconds from audio bytes (WAV header
+// or Ogg Opus granule), else from stored object key, else from recorder
+// sample-derived DurationMs. Never uses wall-clock BYE timestamps.
+//
+// Compressed recorder output (ogg / mp3) skips the object download when
+// DurationMs is known: it is derived from the same sample count the
+// encoder was fed, and MP3 streams carry no reliable length header.
 func ResolveRecordingDurationSec(info gateway.RecordingInfo, wav []byte) int {
-	if sec := WAVDurationSec(wav); sec > 0 {
+	if sec := RecordingDurationSec(wav); sec > 0 {
 		return sec
 	}
 	key := strings.TrimSpace(info.Key)
-	if key != "" {
+	compressed := info.Format != "" && info.Format != string(recorder.FormatWAV)
+	if key != "" && !(compressed && info.DurationMs > 0) {
 		if b, err := readFromStore(key); err == nil && len(b) > 0 {
-			if sec := WAVDurationSec(b); sec > 0 {
+			if sec := RecordingDurationSec(b); sec > 0 {
 				return sec
 			}
 		}
@@ -29,3 +35,15 @@ func ResolveRecordingDurationSec(info gateway.RecordingInfo, wav []byte) int {
 	}
 	return 0
 }
+
+// RecordingDurationSec parses a WAV header or an Ogg Opus stream and returns
+// rounded seconds; 0 for other formats (MP3) or unparsable input.
+func RecordingDurationSec(b []byte) int {
+	if sec := WAVDurationSec(b); sec > 0 {
+		return sec
+	}
+	if ms := recorder.OggOpusDurationMs(b); ms > 0 {
+		return int((ms + 500) / 1000)
+	}
+	return 0
+}
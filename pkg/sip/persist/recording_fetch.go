@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/LinByte/VoiceServer/pkg/stores"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
 )
 
 // FetchRecordingWAV loads recording bytes from recording_url (http(s), storage key, or local uploads path).
@@ -56,7 +57,8 @@ func fetchHTTP(client *http.Client, u string) ([]byte, error) {
 }
 
 func readFromStore(key string) ([]byte, error) {
-	st := stores.Default()
+	// Decrypts envelope-encrypted recordings; plaintext objects pass through.
+	st := envelope.Wrap(stores.Default(), 0)
 	if st == nil {
 		return nil, fmt.Errorf("storage unavailable")
 	}
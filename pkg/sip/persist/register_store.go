@@ -11,6 +11,7 @@ import (
 
 	"github.com/LinByte/VoiceServer/internal/constants"
 	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"github.com/LinByte/VoiceServer/pkg/sip/server"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 
 	"gorm.io/gorm"
@@ -25,8 +26,8 @@ func NewGormStore(db *gorm.DB) *GormStore {
 	return &GormStore{db: db}
 }
 
-func (s *GormStore) SaveRegister(ctx context.Context, user, domain, contactURI string, sig *net.UDPAddr, expiresAt time.Time, userAgent string) error {
-	if s == nil || s.db == nil || sig == nil {
+func (s *GormStore) SaveRegister(ctx context.Context, user, domain string, b server.RegisterBinding) error {
+	if s == nil || s.db == nil || b.Sig == nil {
 		return nil
 	}
 	user = strings.TrimSpace(user)
@@ -35,14 +36,16 @@ func (s *GormStore) SaveRegister(ctx context.Context, user, domain, contactURI s
 		return nil
 	}
 	now := time.Now()
-	exp := expiresAt
+	exp := b.ExpiresAt
 	return UpsertSIPUserRegister(ctx, s.db, SIPUser{
 		Username:   user,
 		Domain:     domain,
-		ContactURI: contactURI,
-		RemoteIP:   sig.IP.String(),
-		RemotePort: sig.Port,
-		UserAgent:  userAgent,
+		ContactURI: b.ContactURI,
+		RemoteIP:   b.Sig.IP.String(),
+		RemotePort: b.Sig.Port,
+		UserAgent:  b.UserAgent,
+		Via:        b.Via,
+		Path:       strings.Join(b.Path, ", "),
 		Online:     true,
 		ExpiresAt:  &exp,
 		LastSeenAt: &now,
@@ -61,36 +64,65 @@ func (s *GormStore) DeleteRegister(ctx context.Context, user, domain string) err
 	return MarkSIPUserOffline(ctx, s.db, user, domain)
 }
 
-func (s *GormStore) LookupRegister(ctx context.Context, user, domain string) (*net.UDPAddr, bool, error) {
+func (s *GormStore) LookupRegister(ctx context.Context, user, domain string) (server.RegisterBinding, bool, error) {
+	var b server.RegisterBinding
 	if s == nil || s.db == nil {
-		return nil, false, nil
+		return b, false, nil
 	}
 	user = strings.TrimSpace(user)
 	domain = strings.TrimSpace(domain)
 	if user == "" || domain == "" {
-		return nil, false, nil
+		return b, false, nil
 	}
 	row, err := FindOnlineSIPUserByAOR(ctx, s.db, user, domain)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, false, nil
+			return b, false, nil
 		}
-		return nil, false, err
+		return b, false, err
 	}
 	if row.RemoteIP == "" || row.RemotePort <= 0 {
-		return nil, false, nil
+		return b, false, nil
 	}
 	ip := net.ParseIP(row.RemoteIP)
 	if ip == nil {
-		return nil, false, nil
+		return b, false, nil
 	}
-	return &net.UDPAddr{IP: ip, Port: row.RemotePort}, true, nil
+	b = server.RegisterBinding{
+		ContactURI: row.ContactURI,
+		Sig:        &net.UDPAddr{IP: ip, Port: row.RemotePort},
+		UserAgent:  row.UserAgent,
+		Via:        row.Via,
+		Path:       row.PathRoutes(),
+	}
+	if row.ExpiresAt != nil {
+		b.ExpiresAt = *row.ExpiresAt
+	}
+	return b, true, nil
 }
 
 // DialTargetFromSIPUser builds Request-URI + signaling UDP target from an online sip_users row.
 // Matches GormStore.DialTargetForUsername URI rules (SIP_DEFAULT_URI_PORT vs embedded listen port).
 // Caller must ensure RemoteIP/RemotePort/freshness are valid.
+// A WebSocket registration (Contact ;transport=ws|wss) dials its Contact
+// URI as-is over the flow it registered on (RFC 7118 §5.2).
+// A registration with a Path (RFC 3327) dials its Contact URI through the
+// edge proxy: RemoteIP/RemotePort is the first Path hop and the Path is
+// preloaded as Route.
 func DialTargetFromSIPUser(row SIPUser) outbound.DialTarget {
+	sig := net.JoinHostPort(row.RemoteIP, strconv.Itoa(row.RemotePort))
+	if uri, secure, ok := WebSocketContactURI(row.ContactURI); ok {
+		tr := outbound.TransportWS
+		if secure {
+			tr = outbound.TransportWSS
+		}
+		return outbound.DialTarget{RequestURI: uri, SignalingAddr: sig, Transport: tr}
+	}
+	if path := row.PathRoutes(); len(path) > 0 {
+		if uri := contactAddrSpec(row.ContactURI); uri != "" {
+			return outbound.DialTarget{RequestURI: uri, SignalingAddr: sig, Route: path}
+		}
+	}
 	d := EffectiveDialDomain(row.Domain, row.RemoteIP)
 	port := 6050
 	if ps := utils.GetEnv(constants.EnvSIPDefaultURIPort); ps != "" {
@@ -101,7 +133,6 @@ func DialTargetFromSIPUser(row SIPUser) outbound.DialTarget {
 		port = EffectiveRegisterDialRequestURIPort(port)
 	}
 	reqURI := fmt.Sprintf("sip:%s@%s:%d", row.Username, d, port)
-	sig := net.JoinHostPort(row.RemoteIP, strconv.Itoa(row.RemotePort))
 	return outbound.DialTarget{RequestURI: reqURI, SignalingAddr: sig}
 }
 
@@ -0,0 +1,93 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package persist
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecordingStorageKey extracts the storage object key from a recording_url
+// (public URL, uploads path or bare key).
+func RecordingStorageKey(recordingURL string) string {
+	return recordingStorageKey(recordingURL)
+}
+
+// ListSIPCallRecordingsBefore returns up to limit calls of tenantID created before
+// cutoff that still reference a recording and are not on legal hold, ordered by id
+// after afterID (cursor paging so failed deletes are not retried in the same run).
+func ListSIPCallRecordingsBefore(ctx context.Context, db *gorm.DB, tenantID uint, cutoff time.Time, afterID uint, limit int) ([]SIPCall, error) {
+	var rows []SIPCall
+	err := db.WithContext(ctx).Model(&SIPCall{}).
+		Select("id", "call_id", "tenant_id", "recording_url").
+		Where("tenant_id = ? AND created_at < ? AND id > ?", tenantID, cutoff, afterID).
+		Where("recording_url <> '' AND legal_hold = ?", false).
+		Order("id ASC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// MarkSIPCallRecordingsPurged clears recording_url on ids after their audio objects were deleted.
+func MarkSIPCallRecordingsPurged(ctx context.Context, db *gorm.DB, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Model(&SIPCall{}).Where("id IN ?", ids).Updates(map[string]any{
+		"recording_url":       "",
+		"recording_purged_at": at,
+	}).Error
+}
+
+// PurgeSIPCallTurnsBefore nulls the transcript turns of up to limit calls of tenantID
+// created before cutoff (legal holds excepted). Returns the number of calls cleared;
+// callers loop until it is below limit.
+func PurgeSIPCallTurnsBefore(ctx context.Context, db *gorm.DB, tenantID uint, cutoff time.Time, limit int) (int64, error) {
+	var ids []uint
+	err := db.WithContext(ctx).Model(&SIPCall{}).
+		Where("tenant_id = ? AND created_at < ? AND turns IS NOT NULL AND legal_hold = ?", tenantID, cutoff, false).
+		Order("id ASC").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	res := db.WithContext(ctx).Model(&SIPCall{}).Where("id IN ?", ids).
+		Update("turns", gorm.Expr("NULL"))
+	return res.RowsAffected, res.Error
+}
+
+// SetSIPCallLegalHold places or releases a legal hold on one call row.
+// tenantID 0 skips tenant scoping (platform admin).
+func SetSIPCallLegalHold(ctx context.Context, db *gorm.DB, id, tenantID uint, hold bool, reason, by string) (SIPCall, error) {
+	q := ActiveSIPCalls(db.WithContext(ctx)).Where("id = ?", id)
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	var row SIPCall
+	if err := q.First(&row).Error; err != nil {
+		return row, err
+	}
+	upd := map[string]any{
+		"legal_hold":        hold,
+		"legal_hold_reason": strings.TrimSpace(reason),
+		"legal_hold_by":     strings.TrimSpace(by),
+		"legal_hold_at":     time.Now(),
+	}
+	if !hold {
+		upd["legal_hold_reason"] = ""
+		upd["legal_hold_at"] = nil
+	}
+	if err := db.WithContext(ctx).Model(&SIPCall{}).Where("id = ?", row.ID).Updates(upd).Error; err != nil {
+		return row, err
+	}
+	err := db.WithContext(ctx).Where("id = ?", row.ID).First(&row).Error
+	return row, err
+}
+
+// ListLegalHoldCallIDs returns the Call-IDs currently on legal hold.
+func ListLegalHoldCallIDs(ctx context.Context, db *gorm.DB) ([]string, error) {
+	var ids []string
+	err := db.WithContext(ctx).Model(&SIPCall{}).Where("legal_hold = ?", true).Pluck("call_id", &ids).Error
+	return ids, err
+}
@@ -97,6 +97,16 @@ type SIPCall struct {
 	QoSJitterMs       float32 `json:"qosJitterMs,omitempty" gorm:"column:qos_jitter_ms"`
 	QoSPacketLossPct  float32 `json:"qosPacketLossPct,omitempty" gorm:"column:qos_packet_loss_pct"`
 	QoSMosEstimate    float32 `json:"qosMosEstimate,omitempty" gorm:"column:qos_mos_estimate"`
+	// QoSNACKSent / QoSRetransmits: RFC 4585 NACK 反馈计数（我方请求重传的包数 / 应对端请求重传的包数）。
+	QoSNACKSent       uint64  `json:"qosNackSent,omitempty" gorm:"column:qos_nack_sent;default:0"`
+	QoSRetransmits    uint64  `json:"qosRetransmits,omitempty" gorm:"column:qos_retransmits;default:0"`
+	// WebSeat* describe the browser agent's WebRTC leg (ICE RTT, downlink loss/jitter from
+	// the browser's receiver reports), averaged over the seat and written at seat teardown.
+	WebSeatRTTMs         float32 `json:"webSeatRttMs,omitempty" gorm:"column:webseat_rtt_ms"`
+	WebSeatMaxRTTMs      float32 `json:"webSeatMaxRttMs,omitempty" gorm:"column:webseat_max_rtt_ms"`
+	WebSeatLossPct       float32 `json:"webSeatLossPct,omitempty" gorm:"column:webseat_loss_pct"`
+	WebSeatJitterMs      float32 `json:"webSeatJitterMs,omitempty" gorm:"column:webseat_jitter_ms"`
+	WebSeatCandidateType string  `json:"webSeatCandidateType,omitempty" gorm:"column:webseat_candidate_type;size:16"`
 	State             string     `json:"state" gorm:"size:32;index"`
 	InviteAt          *time.Time `json:"inviteAt" gorm:"index"`
 	AckAt             *time.Time `json:"ackAt" gorm:"index"`
@@ -123,8 +133,37 @@ type SIPCall struct {
 	// TransferACDTargetID is the acd_pool_targets.id selected when the call was transferred to an agent.
 	// 0 means no transfer or unknown target.
 	TransferACDTargetID uint `json:"transferAcdTargetId,omitempty" gorm:"column:transfer_acd_target_id;index;default:0"`
+	// AgentACDTargetID is the acd_pool_targets.id of the agent who placed or took an
+	// agent-bridged outbound call (web-seat dial, click-to-call); 0 otherwise.
+	AgentACDTargetID uint `json:"agentAcdTargetId,omitempty" gorm:"column:agent_acd_target_id;index;default:0"`
 	// TransferTraceJSON stores ordered transfer attempts, e.g. [{"acdTargetId":1,"outcome":"no_answer"}].
 	TransferTraceJSON datatypes.JSON `json:"transferTrace,omitempty" gorm:"column:transfer_trace_json;type:json"`
+	// RecordingEncrypted marks recording_url as envelope-encrypted ciphertext
+	// (pkg/stores/envelope); play it through GET /calls/:id/recording.
+	RecordingEncrypted bool `json:"recordingEncrypted,omitempty" gorm:"column:recording_encrypted;default:false"`
+	// RecordingPurgedAt is set when the retention job deleted the audio object
+	// (recording_url is cleared at the same time).
+	RecordingPurgedAt *time.Time `json:"recordingPurgedAt,omitempty" gorm:"column:recording_purged_at"`
+	// LegalHold exempts the call (recording, turns, script runs, campaign events,
+	// CDR lines) from retention purges until released.
+	LegalHold       bool       `json:"legalHold,omitempty" gorm:"column:legal_hold;index;default:false"`
+	LegalHoldReason string     `json:"legalHoldReason,omitempty" gorm:"column:legal_hold_reason;size:255"`
+	LegalHoldBy     string     `json:"legalHoldBy,omitempty" gorm:"column:legal_hold_by;size:128"`
+	LegalHoldAt     *time.Time `json:"legalHoldAt,omitempty" gorm:"column:legal_hold_at"`
+	// Disposition* / ACW* record the agent's after-call work: the wrap-up code and notes
+	// (notes are redacted before storage), which seat wrapped up, and how ACW ended
+	// (wrapped_up, expired or cancelled).
+	DispositionCode        string     `json:"dispositionCode,omitempty" gorm:"column:disposition_code;size:64;index"`
+	DispositionNotes       string     `json:"dispositionNotes,omitempty" gorm:"column:disposition_notes;type:text"`
+	DispositionBy          string     `json:"dispositionBy,omitempty" gorm:"column:disposition_by;size:128"`
+	DispositionACDTargetID uint       `json:"dispositionAcdTargetId,omitempty" gorm:"column:disposition_acd_target_id;index;default:0"`
+	ACWStartedAt           *time.Time `json:"acwStartedAt,omitempty" gorm:"column:acw_started_at"`
+	WrapUpAt               *time.Time `json:"wrapUpAt,omitempty" gorm:"column:wrap_up_at;index"`
+	ACWSec                 int        `json:"acwSec,omitempty" gorm:"column:acw_sec;default:0"`
+	ACWEndReason           string     `json:"acwEndReason,omitempty" gorm:"column:acw_end_reason;size:16"`
+	// DetectedLanguage is the caller language picked from the first utterance when the
+	// inbound number offers several dialog languages (BCP-47, e.g. yue-HK).
+	DetectedLanguage string `json:"detectedLanguage,omitempty" gorm:"column:detected_language;size:16;index"`
 	// TransferTo is derived for UI (e.g. seat name / targetValue) and is not stored.
 	TransferTo string `json:"transferTo,omitempty" gorm:"-"`
 }
@@ -0,0 +1,189 @@
+package persist
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/internal/constants"
+	"gorm.io/gorm"
+)
+
+// SIP MESSAGE (RFC 3428) delivery states.
+const (
+	SIPMessageStatusQueued    = "queued"    // waiting for the recipient to (re-)register
+	SIPMessageStatusSending   = "sending"   // transaction in flight
+	SIPMessageStatusDelivered = "delivered" // 2xx from the recipient
+	SIPMessageStatusFailed    = "failed"    // final non-2xx / unreachable
+	SIPMessageStatusExpired   = "expired"   // store-and-forward TTL ran out
+	SIPMessageStatusReceived  = "received"  // inbound from a trunk, handled locally
+)
+
+// SIP MESSAGE origins (SIPMessage.Source).
+const (
+	SIPMessageSourceSIP   = "sip"   // registered user → registered user
+	SIPMessageSourceTrunk = "trunk" // carrier SMS to a tenant DID
+	SIPMessageSourceAPI   = "api"   // HTTP send API
+	SIPMessageSourceBot   = "bot"   // chatbot reply
+)
+
+// SIPMessage is one page-mode instant message routed by the platform (sip_messages).
+// Direction: inbound = received from a trunk, outbound = sent to a trunk,
+// relay = between registered users.
+type SIPMessage struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime;index;comment:Creation time"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty" gorm:"autoUpdateTime;comment:Update time"`
+	CreateBy  string    `json:"createBy,omitempty" gorm:"size:128;comment:Creator"`
+
+	TenantID      uint   `json:"tenantId" gorm:"index;not null;default:0"`
+	TrunkNumberID uint   `json:"trunkNumberId,omitempty" gorm:"index"`
+	Direction     string `json:"direction" gorm:"size:16;not null;index"`
+	Source        string `json:"source" gorm:"size:16;not null"`
+
+	FromUser   string `json:"fromUser" gorm:"size:128;index"`
+	FromDomain string `json:"fromDomain" gorm:"size:128"`
+	ToUser     string `json:"toUser" gorm:"size:128;index:idx_sip_message_to"`
+	ToDomain   string `json:"toDomain" gorm:"size:128;index:idx_sip_message_to"`
+
+	ContentType string `json:"contentType" gorm:"size:128"`
+	Body        string `json:"body" gorm:"type:text"`
+
+	Status      string     `json:"status" gorm:"size:16;not null;index"`
+	SIPStatus   int        `json:"sipStatus,omitempty"`
+	Reason      string     `json:"reason,omitempty" gorm:"size:256"`
+	Attempts    int        `json:"attempts"`
+	CallID      string     `json:"callId,omitempty" gorm:"size:128;index"` // last MESSAGE sent / the one received
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" gorm:"index"` // store-and-forward deadline
+}
+
+func (SIPMessage) TableName() string { return constants.SIPMessageTableName }
+
+func CreateSIPMessage(ctx context.Context, db *gorm.DB, row *SIPMessage) error {
+	return db.WithContext(ctx).Create(row).Error
+}
+
+// GetSIPMessage loads one message; tenantID 0 = any tenant (platform admin).
+func GetSIPMessage(ctx context.Context, db *gorm.DB, tenantID, id uint) (SIPMessage, error) {
+	q := db.WithContext(ctx).Where("id = ?", id)
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	var row SIPMessage
+	err := q.First(&row).Error
+	return row, err
+}
+
+// SIPMessageListFilter narrows ListSIPMessagesPage.
+type SIPMessageListFilter struct {
+	Direction string
+	Status    string
+	Peer      string // matches from_user or to_user
+}
+
+// ListSIPMessagesPage lists newest first; tenantID 0 = all tenants.
+func ListSIPMessagesPage(db *gorm.DB, tenantID uint, page, size int, f SIPMessageListFilter) ([]SIPMessage, int64, error) {
+	q := db.Model(&SIPMessage{})
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	if v := strings.TrimSpace(f.Direction); v != "" {
+		q = q.Where("direction = ?", v)
+	}
+	if v := strings.TrimSpace(f.Status); v != "" {
+		q = q.Where("status = ?", v)
+	}
+	if v := strings.TrimSpace(f.Peer); v != "" {
+		q = q.Where("from_user = ? OR to_user = ?", v, v)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []SIPMessage
+	err := q.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&list).Error
+	return list, total, err
+}
+
+// ListQueuedSIPMessagesForAOR returns messages waiting for user@domain, oldest first.
+// An empty domain matches any domain.
+func ListQueuedSIPMessagesForAOR(ctx context.Context, db *gorm.DB, user, domain string) ([]SIPMessage, error) {
+	q := db.WithContext(ctx).Model(&SIPMessage{}).
+		Where("status = ?", SIPMessageStatusQueued).
+		Where("to_user = ?", strings.TrimSpace(user))
+	if d := strings.TrimSpace(domain); d != "" {
+		q = q.Where("to_domain = ? OR to_domain = ''", d)
+	}
+	var list []SIPMessage
+	err := q.Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// ClaimSIPMessage moves a queued / new message to sending; false when
+// another worker already claimed it.
+func ClaimSIPMessage(ctx context.Context, db *gorm.DB, id uint, from string) (bool, error) {
+	res := db.WithContext(ctx).Model(&SIPMessage{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{
+			"status":     SIPMessageStatusSending,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// FinishSIPMessageAttempt records the outcome of one delivery attempt.
+func FinishSIPMessageAttempt(ctx context.Context, db *gorm.DB, id uint, status string, sipStatus int, reason, callID string) error {
+	now := time.Now()
+	updates := map[string]any{
+		"status":     status,
+		"sip_status": sipStatus,
+		"reason":     reason,
+		"updated_at": now,
+	}
+	if callID != "" {
+		updates["call_id"] = callID
+	}
+	if status == SIPMessageStatusDelivered {
+		updates["delivered_at"] = now
+	}
+	return db.WithContext(ctx).Model(&SIPMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ExpireQueuedSIPMessages marks queued messages past their deadline expired.
+func ExpireQueuedSIPMessages(ctx context.Context, db *gorm.DB, now time.Time) (int64, error) {
+	res := db.WithContext(ctx).Model(&SIPMessage{}).
+		Where("status = ?", SIPMessageStatusQueued).
+		Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+		Updates(map[string]any{
+			"status":     SIPMessageStatusExpired,
+			"updated_at": now,
+		})
+	return res.RowsAffected, res.Error
+}
+
+// FindSIPUserByAOR returns an active (online or not) SIP user; empty domain matches any.
+func FindSIPUserByAOR(ctx context.Context, db *gorm.DB, username, domain string) (SIPUser, error) {
+	q := ActiveSIPUsers(db.WithContext(ctx)).
+		Where("username = ?", strings.TrimSpace(username))
+	if d := strings.TrimSpace(domain); d != "" {
+		q = q.Where("domain = ?", d)
+	}
+	var row SIPUser
+	err := q.Order("online DESC, id DESC").First(&row).Error
+	return row, err
+}
+
+// SIPMessageRetryable reports whether a failed MESSAGE attempt should
+// stay queued for the next registration: timeouts, temporarily
+// unavailable, and server errors; any other final status is terminal.
+func SIPMessageRetryable(sipStatus int) bool {
+	switch {
+	case sipStatus == 0, sipStatus == 408, sipStatus == 480:
+		return true
+	case sipStatus >= 500 && sipStatus < 600:
+		return true
+	}
+	return false
+}
@@ -62,3 +62,44 @@ func trimURIParams(s string) string {
 	}
 	return strings.TrimSpace(s)
 }
+
+// contactAddrSpec 取 Contact 头里第一个 <...> 中的 URI（保留 URI 参数），无尖括号时取第一个逗号前的部分。
+func contactAddrSpec(contact string) string {
+	s := strings.TrimSpace(contact)
+	if l := strings.IndexByte(s, '<'); l >= 0 {
+		if r := strings.IndexByte(s[l:], '>'); r > 0 {
+			s = s[l+1 : l+r]
+		}
+	} else if i := strings.IndexByte(s, ','); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// WebSocketContactURI 从 REGISTER 存下的 Contact 头中取出 ;transport=ws|wss 的 SIP-URI（RFC 7118）。
+// 浏览器软电话的 Contact 主机通常是 xxx.invalid，只能原样作为 Request-URI，经注册所在的 WebSocket 连接送达。
+//
+//	<sip:k3j2@df7jal23ls0d.invalid;transport=ws>;expires=600 → "sip:k3j2@df7jal23ls0d.invalid;transport=ws", secure=false
+//
+// 非 WebSocket Contact 返回 ok=false。
+func WebSocketContactURI(contact string) (uri string, secure bool, ok bool) {
+	s := contactAddrSpec(contact)
+	low := strings.ToLower(s)
+	if !strings.HasPrefix(low, "sip:") && !strings.HasPrefix(low, "sips:") {
+		return "", false, false
+	}
+	for _, p := range strings.Split(low, ";")[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if k != "transport" {
+			continue
+		}
+		switch strings.TrimSpace(v) {
+		case "ws":
+			return s, false, true
+		case "wss":
+			return s, true, true
+		}
+		return "", false, false
+	}
+	return "", false, false
+}
@@ -23,3 +23,47 @@ func TestExtractSIPUserPart(t *testing.T) {
 		}
 	}
 }
+
+func TestWebSocketContactURI(t *testing.T) {
+	cases := []struct {
+		in     string
+		want   string
+		secure bool
+		ok     bool
+	}{
+		{`<sip:k3j2@df7jal23ls0d.invalid;transport=ws>;expires=600`, "sip:k3j2@df7jal23ls0d.invalid;transport=ws", false, true},
+		{`"Agent" <sip:1001@abc.invalid;transport=WSS;ob>`, "sip:1001@abc.invalid;transport=WSS;ob", true, true},
+		{`sip:1001@abc.invalid;transport=ws`, "sip:1001@abc.invalid;transport=ws", false, true},
+		{`<sip:1001@10.0.0.5:5060>`, "", false, false},
+		{`<sip:1001@10.0.0.5:5060;transport=tcp>`, "", false, false},
+		{``, "", false, false},
+	}
+	for _, tc := range cases {
+		got, secure, ok := WebSocketContactURI(tc.in)
+		if got != tc.want || secure != tc.secure || ok != tc.ok {
+			t.Errorf("WebSocketContactURI(%q) = %q,%v,%v", tc.in, got, secure, ok)
+		}
+	}
+}
+
+func TestDialTargetFromSIPUserWithPath(t *testing.T) {
+	row := SIPUser{
+		Username:   "2001",
+		Domain:     "pbx.example.com",
+		ContactURI: `<sip:2001@10.1.1.20:5060;ob>;expires=300`,
+		RemoteIP:   "203.0.113.9",
+		RemotePort: 5070,
+		Path:       "<sip:edge1.example.com;lr>, <sip:edge2.example.com;lr>",
+	}
+	d := DialTargetFromSIPUser(row)
+	if d.RequestURI != "sip:2001@10.1.1.20:5060;ob" || d.SignalingAddr != "203.0.113.9:5070" {
+		t.Fatalf("target %+v", d)
+	}
+	if len(d.Route) != 2 || d.Route[0] != "<sip:edge1.example.com;lr>" {
+		t.Fatalf("route %q", d.Route)
+	}
+	row.Path = ""
+	if d := DialTargetFromSIPUser(row); len(d.Route) != 0 || d.RequestURI == "sip:2001@10.1.1.20:5060;ob" {
+		t.Fatalf("no-path target %+v", d)
+	}
+}
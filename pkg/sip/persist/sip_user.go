@@ -12,6 +12,7 @@ import (
 
 	"github.com/LinByte/VoiceServer/internal/constants"
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -36,6 +37,17 @@ type SIPUser struct {
 	LastSeenAt *time.Time `json:"lastSeenAt" gorm:"index"`
 	UserAgent  string     `json:"userAgent" gorm:"size:256"`
 	Via        string     `json:"via" gorm:"type:text"`
+	// Path is the RFC 3327 Path of the last REGISTER (comma-joined, top
+	// first); non-empty means the UA sits behind an edge proxy.
+	Path string `json:"path,omitempty" gorm:"type:text"`
+}
+
+// PathRoutes returns the stored Path as a Route set, top first.
+func (s SIPUser) PathRoutes() []string {
+	if strings.TrimSpace(s.Path) == "" {
+		return nil
+	}
+	return transaction.SplitRouteValues([]string{s.Path})
 }
 
 func (SIPUser) TableName() string { return constants.SIP_USER_TABLE_NAME }
@@ -141,6 +153,7 @@ func UpsertSIPUserRegister(ctx context.Context, db *gorm.DB, user SIPUser) error
 		"remote_port":  user.RemotePort,
 		"user_agent":   user.UserAgent,
 		"via":          user.Via,
+		"path":         user.Path,
 		"online":       user.Online,
 		"expires_at":   user.ExpiresAt,
 		"last_seen_at": user.LastSeenAt,
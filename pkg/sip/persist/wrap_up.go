@@ -0,0 +1,80 @@
+package persist
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WrapUp is one finished after-call work period of the agent who handled a call.
+type WrapUp struct {
+	ACDTargetID uint
+	Code        string
+	Notes       string // already redacted
+	By          string
+	StartedAt   time.Time
+	EndedAt     time.Time
+	EndReason   string // wrapped_up | expired | cancelled
+}
+
+// WrapUpUpdates maps a wrap-up onto sip_calls columns.
+func WrapUpUpdates(w WrapUp) map[string]any {
+	u := map[string]any{
+		"disposition_code":          strings.TrimSpace(w.Code),
+		"disposition_notes":         strings.TrimSpace(w.Notes),
+		"disposition_by":            strings.TrimSpace(w.By),
+		"disposition_acd_target_id": w.ACDTargetID,
+		"acw_end_reason":            w.EndReason,
+	}
+	if !w.StartedAt.IsZero() {
+		u["acw_started_at"] = w.StartedAt
+	}
+	if !w.EndedAt.IsZero() {
+		u["wrap_up_at"] = w.EndedAt
+		if !w.StartedAt.IsZero() && w.EndedAt.After(w.StartedAt) {
+			u["acw_sec"] = int(w.EndedAt.Sub(w.StartedAt).Round(time.Second) / time.Second)
+		}
+	}
+	return u
+}
+
+// RecordSIPCallWrapUp stores the wrap-up on the call row (no-op for unknown Call-IDs).
+func RecordSIPCallWrapUp(ctx context.Context, db *gorm.DB, callID string, w WrapUp) error {
+	callID = strings.TrimSpace(callID)
+	if db == nil || callID == "" {
+		return nil
+	}
+	return db.WithContext(ctx).Model(&SIPCall{}).Where("call_id = ?", callID).Updates(WrapUpUpdates(w)).Error
+}
+
+// DispositionStat is one agent × disposition bucket of wrapped-up calls.
+type DispositionStat struct {
+	ACDTargetID     uint    `json:"acdTargetId,string"`
+	DispositionCode string  `json:"dispositionCode"`
+	Calls           int64   `json:"calls"`
+	AvgACWSec       float64 `json:"avgAcwSec"`
+	Expired         int64   `json:"expired"` // ACW ran out before the agent wrapped up
+}
+
+// SIPCallDispositionStats groups calls whose ACW ended in [from, to) by agent and disposition code.
+// tenantID 0 skips tenant scoping (platform admin); acdTargetID 0 means all agents.
+func SIPCallDispositionStats(ctx context.Context, db *gorm.DB, tenantID, acdTargetID uint, from, to time.Time) ([]DispositionStat, error) {
+	q := ActiveSIPCalls(db.WithContext(ctx)).
+		Select("disposition_acd_target_id AS acd_target_id, disposition_code, COUNT(*) AS calls, "+
+			"AVG(acw_sec) AS avg_acw_sec, SUM(CASE WHEN acw_end_reason = 'expired' THEN 1 ELSE 0 END) AS expired").
+		Where("disposition_acd_target_id > 0").
+		Where("wrap_up_at >= ? AND wrap_up_at < ?", from, to)
+	if tenantID > 0 {
+		q = q.Where("tenant_id = ?", tenantID)
+	}
+	if acdTargetID > 0 {
+		q = q.Where("disposition_acd_target_id = ?", acdTargetID)
+	}
+	var out []DispositionStat
+	err := q.Group("disposition_acd_target_id, disposition_code").
+		Order("disposition_acd_target_id ASC").Order("calls DESC").
+		Scan(&out).Error
+	return out, err
+}
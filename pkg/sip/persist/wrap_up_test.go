@@ -0,0 +1,28 @@
+package persist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWrapUpUpdates(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	up := WrapUpUpdates(WrapUp{
+		ACDTargetID: 7,
+		Code:        " sale ",
+		Notes:       "call back friday",
+		By:          "agent@tenant",
+		StartedAt:   start,
+		EndedAt:     start.Add(42400 * time.Millisecond),
+		EndReason:   "wrapped_up",
+	})
+	if up["disposition_code"] != "sale" || up["disposition_acd_target_id"] != uint(7) {
+		t.Fatalf("updates: %v", up)
+	}
+	if up["acw_sec"] != 42 || up["acw_end_reason"] != "wrapped_up" {
+		t.Fatalf("acw: %v", up)
+	}
+	if _, ok := WrapUpUpdates(WrapUp{EndReason: "expired"})["wrap_up_at"]; ok {
+		t.Fatal("zero EndedAt written")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package rtp
+
+// ice.go: ICE-lite (RFC 8445 §2.5) responder for WebRTC peers — SIP
+// over WebSocket softphones (JsSIP, SIP.js) refuse media without ICE.
+//
+// We are always the lite, controlled side with a single host candidate
+// (the media socket), so the only job is answering STUN Binding
+// requests that carry our ufrag with a MESSAGE-INTEGRITY keyed by our
+// ice-pwd (RFC 8445 §7.3). The peer's nominated pair (USE-CANDIDATE)
+// becomes the send target; after that, symmetric RTP keeps it fresh.
+//
+// Demux per RFC 7983: first byte 0-3 is STUN, 20-63 DTLS, 128-191
+// RTP/RTCP. With rtcp-mux RTCP shares the socket; muxed RTCP (PT
+// 192-223) is routed to the RTCP handler rather than parsed as RTP.
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/pion/stun"
+	"go.uber.org/zap"
+)
+
+type iceLiteState struct {
+	mu       sync.RWMutex
+	ufrag    string
+	pwd      string
+	selected bool
+}
+
+// EnableICELite makes the session answer STUN connectivity checks for
+// the local credentials advertised in SDP (a=ice-ufrag / a=ice-pwd).
+func (s *Session) EnableICELite(ufrag, pwd string) {
+	if s == nil {
+		return
+	}
+	s.ice.mu.Lock()
+	s.ice.ufrag = strings.TrimSpace(ufrag)
+	s.ice.pwd = pwd
+	s.ice.selected = false
+	s.ice.mu.Unlock()
+}
+
+// ICELiteEnabled reports whether EnableICELite was called with credentials.
+func (s *Session) ICELiteEnabled() bool {
+	if s == nil {
+		return false
+	}
+	s.ice.mu.RLock()
+	defer s.ice.mu.RUnlock()
+	return s.ice.ufrag != "" && s.ice.pwd != ""
+}
+
+// IsSTUNPacket reports whether the first byte of a datagram marks a
+// STUN message (RFC 7983 §7: 0-3).
+func IsSTUNPacket(b byte) bool { return b <= 3 }
+
+// isMuxedRTCP reports an RTCP packet sharing the RTP port (RFC 5761
+// §4: second byte = PT 192-223, i.e. RTCP SR/RR/SDES/BYE/APP/FB/XR).
+func isMuxedRTCP(pkt []byte) bool {
+	return len(pkt) >= 2 && pkt[1] >= 192 && pkt[1] <= 223
+}
+
+// handleSTUN answers a Binding request addressed to our ufrag. Anything
+// else (wrong ufrag, bad integrity, responses, indications) is dropped.
+func (s *Session) handleSTUN(pkt []byte, from *net.UDPAddr) {
+	s.ice.mu.RLock()
+	ufrag, pwd := s.ice.ufrag, s.ice.pwd
+	s.ice.mu.RUnlock()
+	if ufrag == "" || pwd == "" || s.Conn == nil || from == nil {
+		return
+	}
+	m := &stun.Message{Raw: append([]byte(nil), pkt...)}
+	if err := m.Decode(); err != nil || m.Type != stun.BindingRequest {
+		return
+	}
+	var user stun.Username
+	if err := user.GetFrom(m); err != nil {
+		return
+	}
+	// USERNAME is "<our ufrag>:<their ufrag>" (RFC 8445 §7.2.2).
+	if local, _, _ := strings.Cut(user.String(), ":"); local != ufrag {
+		return
+	}
+	integrity := stun.NewShortTermIntegrity(pwd)
+	if err := integrity.Check(m); err != nil {
+		return
+	}
+	resp, err := stun.Build(
+		stun.NewTransactionIDSetter(m.TransactionID),
+		stun.BindingSuccess,
+		&stun.XORMappedAddress{IP: from.IP, Port: from.Port},
+		integrity,
+		stun.Fingerprint,
+	)
+	if err != nil {
+		return
+	}
+	if _, err := s.Conn.WriteToUDP(resp.Raw, from); err != nil {
+		return
+	}
+	if m.Contains(stun.AttrUseCandidate) {
+		s.RemoteAddr = from
+		s.ice.mu.Lock()
+		first := !s.ice.selected
+		s.ice.selected = true
+		s.ice.mu.Unlock()
+		if first && logger.Lg != nil {
+			logger.Lg.Info("rtp ice-lite: peer nominated candidate pair",
+				zap.String("remote", from.String()),
+				zap.String("local_socket", s.LocalAddr.String()))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package rtp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/stun"
+)
+
+// useCandidate adds the ICE USE-CANDIDATE flag (RFC 8445 §16.1).
+type useCandidate struct{}
+
+func (useCandidate) AddTo(m *stun.Message) error {
+	m.Add(stun.AttrUseCandidate, nil)
+	return nil
+}
+
+func TestSession_ICELiteAnswersBindingRequest(t *testing.T) {
+	s, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.EnableICELite("locU", "localpasswordlocalpasswd")
+
+	peer, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.LocalAddr.Port}
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, _, err := s.ReceiveRTP(buf); err != nil && !errors.Is(err, ErrRTPDiscard) {
+				return
+			}
+		}
+	}()
+
+	send := func(user, pwd string, nominate bool) *stun.Message {
+		t.Helper()
+		setters := []stun.Setter{stun.TransactionID, stun.BindingRequest, stun.NewUsername(user)}
+		if nominate {
+			setters = append(setters, useCandidate{})
+		}
+		setters = append(setters, stun.NewShortTermIntegrity(pwd), stun.Fingerprint)
+		req := stun.MustBuild(setters...)
+		if _, err := peer.WriteToUDP(req.Raw, dst); err != nil {
+			t.Fatal(err)
+		}
+		_ = peer.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		buf := make([]byte, 1500)
+		n, _, err := peer.ReadFromUDP(buf)
+		if err != nil {
+			return nil
+		}
+		resp := &stun.Message{Raw: buf[:n]}
+		if err := resp.Decode(); err != nil {
+			t.Fatal(err)
+		}
+		if resp.TransactionID != req.TransactionID {
+			t.Fatal("transaction id mismatch")
+		}
+		return resp
+	}
+
+	// Wrong password → silently dropped (RFC 8445 §7.3.1.1 would 401; lite agents may drop).
+	if resp := send("locU:remU", "wrongpasswordwrongpasswd", false); resp != nil {
+		t.Fatalf("answered a check with bad integrity: %v", resp)
+	}
+	// Wrong ufrag → dropped.
+	if resp := send("other:remU", "localpasswordlocalpasswd", false); resp != nil {
+		t.Fatal("answered a check for another ufrag")
+	}
+
+	resp := send("locU:remU", "localpasswordlocalpasswd", true)
+	if resp == nil {
+		t.Fatal("no binding response")
+	}
+	if resp.Type != stun.BindingSuccess {
+		t.Fatalf("type = %v", resp.Type)
+	}
+	if err := stun.NewShortTermIntegrity("localpasswordlocalpasswd").Check(resp); err != nil {
+		t.Fatalf("response integrity: %v", err)
+	}
+	var xor stun.XORMappedAddress
+	if err := xor.GetFrom(resp); err != nil {
+		t.Fatal(err)
+	}
+	local := peer.LocalAddr().(*net.UDPAddr)
+	if xor.Port != local.Port || !xor.IP.Equal(local.IP) {
+		t.Fatalf("xor-mapped = %s, want %s", xor.String(), local)
+	}
+	// USE-CANDIDATE nominated the pair: it is now the send target.
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if ra := s.RemoteAddr; ra != nil && ra.Port == local.Port {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("remote = %v, want %v", s.RemoteAddr, local)
+}
+
+func TestSession_STUNIgnoredWithoutICELite(t *testing.T) {
+	if !IsSTUNPacket(0x00) || !IsSTUNPacket(0x01) || IsSTUNPacket(0x80) || IsSTUNPacket(20) {
+		t.Fatal("IsSTUNPacket demux ranges")
+	}
+	if !isMuxedRTCP([]byte{0x80, 200}) || isMuxedRTCP([]byte{0x80, 0x00}) || isMuxedRTCP([]byte{0x80, 0x80 | 111}) {
+		t.Fatal("isMuxedRTCP ranges")
+	}
+	s, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.ICELiteEnabled() {
+		t.Fatal("ICE-lite on by default")
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package rtp
+
+// nack.go: RTCP Generic NACK (RFC 4585 §6.2.1) in both directions.
+//
+// Send side: while NACK is negotiated, every RTP datagram we transmit
+// is kept — exactly as written to the wire, i.e. after SRTP — in a ring
+// indexed by sequence number. A Generic NACK from the peer for our SSRC
+// resends the stored datagram unchanged. There is no RFC 4588 RTX
+// stream: audio peers that offer plain "nack" expect the original
+// packet, and SRTP replay protection is unaffected because the receiver
+// never accepted that index.
+//
+// Receive side: the jitter buffer (SIPRTPTransport) reports sequence
+// holes through requestNACK. Each missing seq is asked for once, right
+// away (RFC 4585 §3.5.2 immediate mode is affordable at 50 pps), as a
+// compound RR + SDES + NACK packet.
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// nackHistorySize bounds the retransmission ring: ~10s of 20ms audio.
+	nackHistorySize = 512
+	// nackMaxAge drops requests for packets too old to still be useful
+	// to a realtime playout buffer.
+	nackMaxAge = time.Second
+	// nackMaxBatch caps the seqs asked for in one feedback packet; a
+	// longer gap is an outage, not loss, and is left to PLC.
+	nackMaxBatch = 16
+	// nackRequestTTL is how long a requested seq is remembered so the
+	// same hole is not NACKed twice.
+	nackRequestTTL = 2 * time.Second
+)
+
+type nackEntry struct {
+	valid  bool
+	seq    uint16
+	sentAt int64 // unix nano
+	data   []byte
+}
+
+// nackState is the per-session NACK machinery. Counters are atomic so
+// RTCPSnapshot never contends with the send path.
+type nackState struct {
+	enabled uint32 // atomic 0/1
+
+	mu        sync.Mutex
+	history   [nackHistorySize]nackEntry
+	requested map[uint16]int64 // seq → unix nano when we NACKed it
+
+	feedbackSent uint64 // NACK packets we sent
+	seqsNACKed   uint64 // seqs we asked for
+	feedbackRecv uint64 // NACK packets addressed to our SSRC
+	retransmits  uint64 // datagrams resent in answer to them
+}
+
+// EnableNACK turns on Generic NACK for this session: the outgoing
+// stream is buffered for retransmission and inbound holes are NACKed.
+// Call once the SDP answer has agreed a=rtcp-fb:<pt> nack under an
+// AVPF profile.
+func (s *Session) EnableNACK() {
+	if s == nil {
+		return
+	}
+	atomic.StoreUint32(&s.nack.enabled, 1)
+}
+
+// NACKEnabled reports whether EnableNACK was called.
+func (s *Session) NACKEnabled() bool {
+	return s != nil && atomic.LoadUint32(&s.nack.enabled) == 1
+}
+
+// rememberSent stores one wire datagram for possible retransmission.
+func (s *Session) rememberSent(seq uint16, wire []byte) {
+	if !s.NACKEnabled() {
+		return
+	}
+	n := &s.nack
+	n.mu.Lock()
+	e := &n.history[int(seq)%nackHistorySize]
+	e.valid = true
+	e.seq = seq
+	e.sentAt = time.Now().UnixNano()
+	e.data = append(e.data[:0], wire...)
+	n.mu.Unlock()
+}
+
+// handleNACK resends the packets a peer NACKed, if still buffered.
+func (s *Session) handleNACK(p *rtcp.TransportLayerNack) {
+	if !s.NACKEnabled() || p == nil || p.MediaSSRC != s.SSRC {
+		return
+	}
+	atomic.AddUint64(&s.nack.feedbackRecv, 1)
+	remote := s.RemoteAddr
+	if s.Conn == nil || remote == nil || atomic.LoadUint32(&s.closed) != 0 {
+		return
+	}
+	cutoff := time.Now().Add(-nackMaxAge).UnixNano()
+	var resend [][]byte
+	s.nack.mu.Lock()
+	for _, pair := range p.Nacks {
+		for _, seq := range pair.PacketList() {
+			e := &s.nack.history[int(seq)%nackHistorySize]
+			if !e.valid || e.seq != seq || e.sentAt < cutoff {
+				continue
+			}
+			resend = append(resend, append([]byte(nil), e.data...))
+		}
+	}
+	s.nack.mu.Unlock()
+	for _, b := range resend {
+		if _, err := s.Conn.WriteToUDP(b, remote); err != nil {
+			return
+		}
+		atomic.AddUint64(&s.nack.retransmits, 1)
+	}
+}
+
+// requestNACK asks the peer to resend seqs. Seqs already requested
+// within nackRequestTTL are skipped. No-op until NACK is negotiated
+// and the inbound SSRC is known.
+func (s *Session) requestNACK(seqs ...uint16) {
+	if !s.NACKEnabled() || len(seqs) == 0 || atomic.LoadUint32(&s.rxSSRCSeen) == 0 {
+		return
+	}
+	if len(seqs) > nackMaxBatch {
+		seqs = seqs[len(seqs)-nackMaxBatch:]
+	}
+	now := time.Now().UnixNano()
+	fresh := make([]uint16, 0, len(seqs))
+	s.nack.mu.Lock()
+	if s.nack.requested == nil {
+		s.nack.requested = make(map[uint16]int64)
+	}
+	for seq, at := range s.nack.requested {
+		if now-at > int64(nackRequestTTL) {
+			delete(s.nack.requested, seq)
+		}
+	}
+	for _, seq := range seqs {
+		if _, dup := s.nack.requested[seq]; dup {
+			continue
+		}
+		s.nack.requested[seq] = now
+		fresh = append(fresh, seq)
+	}
+	s.nack.mu.Unlock()
+	if len(fresh) == 0 {
+		return
+	}
+	fb := &rtcp.TransportLayerNack{
+		SenderSSRC: s.SSRC,
+		MediaSSRC:  atomic.LoadUint32(&s.rxSSRC),
+		Nacks:      rtcp.NackPairsFromSequenceNumbers(fresh),
+	}
+	// RFC 4585 §3.1: feedback rides in a compound packet led by a report.
+	if s.writeRTCP(s.rtcpWriteConn(), s.buildRR(), s.buildSDES(), fb) {
+		atomic.AddUint64(&s.nack.feedbackSent, 1)
+		atomic.AddUint64(&s.nack.seqsNACKed, uint64(len(fresh)))
+	}
+}
+
+// rtcpWriteConn is the socket RTCP leaves on: the RTP socket under
+// rtcp-mux, else the companion socket (nil when it failed to bind).
+func (s *Session) rtcpWriteConn() *net.UDPConn {
+	if s.RTCPMuxEnabled() {
+		return s.Conn
+	}
+	s.rtcpMu.Lock()
+	defer s.rtcpMu.Unlock()
+	return s.rtcpConn
+}
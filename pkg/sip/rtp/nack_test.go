@@ -0,0 +1,206 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package rtp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+func loopback(port int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+}
+
+func TestSession_NACKOnCompanionSocketRetransmits(t *testing.T) {
+	s, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.EnableNACK()
+
+	peer, err := net.ListenUDP("udp4", loopback(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	s.SetRemoteAddr(peer.LocalAddr().(*net.UDPAddr))
+
+	var sent [][]byte
+	var seqs []uint16
+	buf := make([]byte, 1500)
+	for i := 0; i < 3; i++ {
+		seqs = append(seqs, s.SeqNum)
+		if err := s.SendRTP([]byte{byte(i), 0xAA, 0xBB}, 0, 160); err != nil {
+			t.Fatal(err)
+		}
+		_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := peer.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, append([]byte(nil), buf[:n]...))
+	}
+
+	raw, err := rtcp.Marshal([]rtcp.Packet{&rtcp.TransportLayerNack{
+		SenderSSRC: 0x1234,
+		MediaSSRC:  s.SSRC,
+		Nacks:      rtcp.NackPairsFromSequenceNumbers([]uint16{seqs[1]}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := peer.WriteToUDP(raw, loopback(s.LocalAddr.Port+1)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		_ = peer.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := peer.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatal("no retransmission:", err)
+		}
+		if buf[1] >= 192 && buf[1] <= 223 {
+			continue // scheduled SR
+		}
+		if !bytes.Equal(buf[:n], sent[1]) {
+			t.Fatalf("retransmitted %x, want %x", buf[:n], sent[1])
+		}
+		break
+	}
+	snap := s.RTCPSnapshot()
+	if snap.NACKsReceived != 1 || snap.Retransmits != 1 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+}
+
+func TestSession_NACKIgnoredWhenNotNegotiated(t *testing.T) {
+	s, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetRemoteAddr(loopback(9))
+	if err := s.SendRTP([]byte{1}, 0, 160); err != nil {
+		t.Fatal(err)
+	}
+	s.handleNACK(&rtcp.TransportLayerNack{MediaSSRC: s.SSRC, Nacks: rtcp.NackPairsFromSequenceNumbers([]uint16{s.SeqNum - 1})})
+	if snap := s.RTCPSnapshot(); snap.NACKsReceived != 0 || snap.Retransmits != 0 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+}
+
+func TestSession_RTCPMuxCarriesNACKOnRTPPort(t *testing.T) {
+	a, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	for _, s := range []*Session{a, b} {
+		s.EnableRTCPMux()
+		s.EnableNACK()
+		s.rtcpMu.Lock()
+		companion := s.rtcpConn
+		s.rtcpMu.Unlock()
+		if companion != nil {
+			t.Fatal("companion socket kept under rtcp-mux")
+		}
+	}
+	a.SetRemoteAddr(loopback(b.LocalAddr.Port))
+	b.SetRemoteAddr(loopback(a.LocalAddr.Port))
+	if got := a.rtcpRemoteAddr(); got.Port != b.LocalAddr.Port {
+		t.Fatalf("muxed rtcp remote = %v", got)
+	}
+
+	// a only needs to read to see b's feedback.
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, _, err := a.ReceiveRTP(buf); err != nil && !errors.Is(err, ErrRTPDiscard) {
+				return
+			}
+		}
+	}()
+
+	first := a.SeqNum
+	for i := 0; i < 3; i++ {
+		if err := a.SendRTP([]byte{byte(i)}, 0, 160); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := make([]byte, 1500)
+	got := 0
+	for got < 3 {
+		_ = b.Conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, pkt, err := b.ReceiveRTP(buf)
+		if errors.Is(err, ErrRTPDiscard) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkt != nil {
+			got++
+		}
+	}
+
+	b.requestNACK(first + 1)
+	b.requestNACK(first + 1) // de-duplicated
+	for {
+		_ = b.Conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, pkt, err := b.ReceiveRTP(buf)
+		if errors.Is(err, ErrRTPDiscard) {
+			continue
+		}
+		if err != nil {
+			t.Fatal("no retransmission over the muxed port:", err)
+		}
+		if pkt.Header.SequenceNumber != first+1 {
+			t.Fatalf("seq = %d, want %d", pkt.Header.SequenceNumber, first+1)
+		}
+		break
+	}
+	if snap := b.RTCPSnapshot(); snap.NACKsSent != 1 || snap.NACKedPackets != 1 {
+		t.Fatalf("receiver snapshot = %+v", snap)
+	}
+	if snap := a.RTCPSnapshot(); snap.Retransmits != 1 {
+		t.Fatalf("sender snapshot = %+v", snap)
+	}
+}
+
+func TestJitterBufferHoleRequestsNACK(t *testing.T) {
+	s, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.EnableNACK()
+	s.rxSSRCSeen, s.rxSSRC = 1, 0xCAFE
+
+	tr := &SIPRTPTransport{sess: s, JitterPlaybackDelay: DefaultJitterPlaybackDelay}
+	now := time.Now()
+	tr.jbPush(&RTPPacket{Header: RTPHeader{SequenceNumber: 65534}}, now)
+	tr.jbPush(&RTPPacket{Header: RTPHeader{SequenceNumber: 1}}, now) // 65535, 0 missing across the wrap
+	tr.jbPush(&RTPPacket{Header: RTPHeader{SequenceNumber: 0}}, now) // late arrival, not a new hole
+
+	s.nack.mu.Lock()
+	defer s.nack.mu.Unlock()
+	if len(s.nack.requested) != 2 {
+		t.Fatalf("requested = %v", s.nack.requested)
+	}
+	for _, seq := range []uint16{65535, 0} {
+		if _, ok := s.nack.requested[seq]; !ok {
+			t.Fatalf("seq %d not NACKed", seq)
+		}
+	}
+}
@@ -9,8 +9,10 @@ package rtp
 //
 //   - A separate UDP socket bound on RTP-port + 1 to receive RTCP
 //     (matches the `a=rtcp:<port+1>` line we already advertise in
-//     the SDP offer/answer; see pkg/sip/sdp/sdp.go). RTCP-MUX (RFC
-//     5761) is NOT implemented yet — it's a follow-up.
+//     the SDP offer/answer; see pkg/sip/sdp/sdp.go). Once the SDP
+//     agrees a=rtcp-mux (RFC 5761), EnableRTCPMux drops that socket
+//     and RTCP shares the RTP port; ReceiveRTP demuxes it by PT.
+//   - SRTCP (RFC 3711 §3.4) whenever the session has SRTP contexts.
 //   - A periodic transmitter that fires a compound packet
 //     [SR | RR] + SDES(CNAME) every ~5s (RFC 3550 §6.2 default,
 //     with the §6.3 randomisation factor of [0.5, 1.5]).
@@ -20,6 +22,7 @@ package rtp
 //     RTT computation via the next outgoing RR.
 //   - RTT and peer-reported jitter / loss are surfaced via
 //     SessionStats so call records can show MOS-relevant metrics.
+//   - Generic NACK feedback (RFC 4585) lives in nack.go.
 //
 // Why not pion/interceptor: this stack runs on a custom Session +
 // raw UDP socket; pion/interceptor expects pion/webrtc primitives.
@@ -145,6 +148,62 @@ func (s *Session) startRTCP(rtpLocalPort int) {
 	go s.rtcpSendLoop(conn)
 }
 
+// EnableRTCPMux moves RTCP onto the RTP socket (RFC 5761) after the
+// offer/answer agreed a=rtcp-mux: the companion socket is released,
+// reports go to the peer's RTP address, and ReceiveRTP hands muxed
+// RTCP (PT 192-223) to the RTCP handler. Idempotent.
+func (s *Session) EnableRTCPMux() {
+	if s == nil || s.Conn == nil || !atomic.CompareAndSwapUint32(&s.rtcpMux, 0, 1) {
+		return
+	}
+	if atomic.LoadUint32(&s.closed) != 0 {
+		return
+	}
+	s.stopRTCP()
+	s.rtcpMu.Lock()
+	s.rtcpStopCh = make(chan struct{})
+	s.rtcpMu.Unlock()
+	go s.rtcpSendLoop(s.Conn)
+}
+
+// RTCPMuxEnabled reports whether RTCP shares the RTP socket.
+func (s *Session) RTCPMuxEnabled() bool {
+	return s != nil && atomic.LoadUint32(&s.rtcpMux) == 1
+}
+
+// handleMuxedRTCP parses an RTCP datagram read off the RTP socket.
+func (s *Session) handleMuxedRTCP(pkt []byte) {
+	raw, err := s.unprotectRTCP(pkt)
+	if err != nil {
+		return
+	}
+	pkts, err := rtcp.Unmarshal(raw)
+	if err != nil {
+		return
+	}
+	s.handleIncomingRTCP(pkts)
+}
+
+// protectRTCP applies SRTCP when the session has SRTP contexts.
+func (s *Session) protectRTCP(raw []byte) ([]byte, error) {
+	s.srtpMu.Lock()
+	defer s.srtpMu.Unlock()
+	if s.srtpEncrypt == nil {
+		return raw, nil
+	}
+	return s.srtpEncrypt.EncryptRTCP(nil, raw, nil)
+}
+
+// unprotectRTCP strips SRTCP when the session has SRTP contexts.
+func (s *Session) unprotectRTCP(raw []byte) ([]byte, error) {
+	s.srtpMu.Lock()
+	defer s.srtpMu.Unlock()
+	if s.srtpDecrypt == nil {
+		return raw, nil
+	}
+	return s.srtpDecrypt.DecryptRTCP(nil, raw, nil)
+}
+
 // stopRTCP closes the companion socket and stops both goroutines.
 // Idempotent. Called from Session.Close.
 func (s *Session) stopRTCP() {
@@ -323,7 +382,7 @@ func (s *Session) rtcpSendLoop(conn *net.UDPConn) {
 		s.rtcpMu.Lock()
 		stopCh := s.rtcpStopCh
 		s.rtcpMu.Unlock()
-		if stopCh == nil {
+		if stopCh == nil || atomic.LoadUint32(&s.closed) != 0 {
 			return
 		}
 		select {
@@ -350,27 +409,39 @@ func (s *Session) sendOneRTCP(conn *net.UDPConn) {
 	if s == nil || conn == nil {
 		return
 	}
+	var report rtcp.Packet
+	if atomic.LoadUint64(&s.txPackets) > 0 {
+		report = s.buildSR()
+	} else {
+		report = s.buildRR()
+	}
+	s.writeRTCP(conn, report, s.buildSDES())
+}
+
+// writeRTCP marshals a compound packet, applies SRTCP and sends it to
+// the peer's RTCP address. Reports whether the datagram left.
+func (s *Session) writeRTCP(conn *net.UDPConn, pkts ...rtcp.Packet) bool {
+	if s == nil || conn == nil {
+		return false
+	}
 	remote := s.rtcpRemoteAddr()
 	if remote == nil {
-		return
+		return false
 	}
-
-	var pkts []rtcp.Packet
-
-	txPackets := atomic.LoadUint64(&s.txPackets)
-	if txPackets > 0 {
-		pkts = append(pkts, s.buildSR())
-	} else {
-		pkts = append(pkts, s.buildRR())
+	// WebRTC media is always SRTP (RFC 8827): until DTLS has installed
+	// the contexts, plaintext RTCP would only be dropped by the browser.
+	if s.ICELiteEnabled() && !s.srtpActive() {
+		return false
 	}
-	pkts = append(pkts, s.buildSDES())
-
 	raw, err := rtcp.Marshal(pkts)
 	if err != nil {
 		if logger.Lg != nil {
 			logger.Lg.Warn("rtcp marshal failed", zap.Error(err))
 		}
-		return
+		return false
+	}
+	if raw, err = s.protectRTCP(raw); err != nil {
+		return false
 	}
 	if _, err := conn.WriteToUDP(raw, remote); err != nil {
 		// Don't spam: peers behind asymmetric NAT often refuse RTCP
@@ -382,12 +453,21 @@ func (s *Session) sendOneRTCP(conn *net.UDPConn) {
 					zap.Error(err))
 			})
 		}
+		return false
 	}
+	return true
+}
+
+func (s *Session) srtpActive() bool {
+	s.srtpMu.Lock()
+	defer s.srtpMu.Unlock()
+	return s.srtpEncrypt != nil
 }
 
 // rtcpRemoteAddr derives the peer's RTCP address. We prefer the
 // RemoteAddr we've learned (post symmetric-RTP NAT learning) and
-// shift to port+1, matching the SDP convention.
+// shift to port+1, matching the SDP convention; under rtcp-mux it is
+// the RTP address itself.
 func (s *Session) rtcpRemoteAddr() *net.UDPAddr {
 	if s == nil {
 		return nil
@@ -399,6 +479,9 @@ func (s *Session) rtcpRemoteAddr() *net.UDPAddr {
 	if src == nil || src.IP == nil || src.Port <= 0 {
 		return nil
 	}
+	if s.RTCPMuxEnabled() {
+		return &net.UDPAddr{IP: src.IP, Port: src.Port}
+	}
 	return &net.UDPAddr{IP: src.IP, Port: src.Port + 1}
 }
 
@@ -501,7 +584,11 @@ func (s *Session) rtcpReceiveLoop(conn *net.UDPConn) {
 		if n == 0 {
 			continue
 		}
-		pkts, perr := rtcp.Unmarshal(buf[:n])
+		raw, uerr := s.unprotectRTCP(buf[:n])
+		if uerr != nil {
+			continue
+		}
+		pkts, perr := rtcp.Unmarshal(raw)
 		if perr != nil {
 			continue
 		}
@@ -525,6 +612,8 @@ func (s *Session) handleIncomingRTCP(pkts []rtcp.Packet) {
 			s.processReportBlocks(v.Reports)
 		case *rtcp.ReceiverReport:
 			s.processReportBlocks(v.Reports)
+		case *rtcp.TransportLayerNack:
+			s.handleNACK(v)
 		}
 	}
 }
@@ -570,6 +659,14 @@ type RTCPStats struct {
 	LocalJitter uint32
 	// LocalPacketsRecv — RTP packets we accepted.
 	LocalPacketsRecv uint32
+	// NACKsSent / NACKedPackets — Generic NACK feedback packets we sent
+	// and the sequence numbers they asked for (nack.go).
+	NACKsSent     uint64
+	NACKedPackets uint64
+	// NACKsReceived / Retransmits — NACKs the peer sent about our
+	// stream and the packets we resent in answer.
+	NACKsReceived uint64
+	Retransmits   uint64
 }
 
 // RTCPSnapshot returns the RTCP-derived metrics. Nil-safe.
@@ -589,6 +686,10 @@ func (s *Session) RTCPSnapshot() RTCPStats {
 	out.LocalJitter = s.rxStats.jitter
 	out.LocalPacketsRecv = s.rxStats.packetsRecv
 	s.rxStats.mu.Unlock()
+	out.NACKsSent = atomic.LoadUint64(&s.nack.feedbackSent)
+	out.NACKedPackets = atomic.LoadUint64(&s.nack.seqsNACKed)
+	out.NACKsReceived = atomic.LoadUint64(&s.nack.feedbackRecv)
+	out.Retransmits = atomic.LoadUint64(&s.nack.retransmits)
 	return out
 }
 
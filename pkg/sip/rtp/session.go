@@ -21,8 +21,9 @@ var ErrRTPDiscard = errors.New("rtp: discard packet")
 
 // Session is a minimal RTP-over-UDP session.
 //
-// RTCP (and SRTCP) is not generated or parsed; SDP may still advertise a=rtcp for peer stacks.
-// Optional SRTP (SDES) encrypts/decrypts RTP payloads only ([Session.EnableSDESSRTP]).
+// RTCP (rtcp.go) runs on RTP port + 1, or on the RTP socket after [Session.EnableRTCPMux];
+// Generic NACK retransmission is opt-in via [Session.EnableNACK].
+// Optional SRTP (SDES) encrypts/decrypts RTP and RTCP ([Session.EnableSDESSRTP]).
 //
 // It is intentionally protocol-agnostic:
 // - Timestamp increments are provided by the caller via `samples` argument.
@@ -82,6 +83,10 @@ type Session struct {
 	dtlsMu    sync.Mutex
 	dtlsRoute *dtlsConn
 
+	// ice holds ICE-lite credentials for WebRTC peers (ice.go); STUN
+	// checks are only answered after EnableICELite.
+	ice iceLiteState
+
 	// RFC 3550 RTCP companion socket on RTP port + 1. nil when bind
 	// failed (best-effort) or before startRTCP / after stopRTCP.
 	// rtcpConn / rtcpStopCh are guarded by rtcpMu to keep the close
@@ -93,6 +98,10 @@ type Session struct {
 	rtcpLastSentRTPTS uint32 // atomic: last RTP timestamp we transmitted (for SR.RTPTime)
 	rxStats           rtcpReceiverStats
 	txEcho            rtcpSenderEcho
+	rtcpMux           uint32 // atomic 0/1: RTCP shares the RTP socket (RFC 5761)
+
+	// nack is the RFC 4585 Generic NACK state (nack.go).
+	nack nackState
 }
 
 type mirrorRemote struct {
@@ -284,6 +293,7 @@ func (s *Session) SendRTP(payload []byte, payloadType uint8, samples uint32) err
 		return fmt.Errorf("rtp: send: %w", err)
 	}
 	s.sendMirrorRTP(out, s.RemoteAddr)
+	s.rememberSent(pkt.Header.SequenceNumber, out)
 	atomic.AddUint64(&s.txPackets, 1)
 	atomic.AddUint64(&s.txBytes, uint64(len(payload)))
 	// Latch the RTP timestamp we just sent for the next SR.RTPTime.
@@ -340,6 +350,14 @@ func (s *Session) ReceiveRTP(buffer []byte) (n int, from *net.UDPAddr, packet *R
 		}
 	})
 
+	// RFC 7983 demux: STUN connectivity checks (first byte 0-3) are
+	// answered in place and never reach the RTP path or move the send
+	// target until the peer nominates the pair (ice.go).
+	if n >= 1 && IsSTUNPacket(buffer[0]) {
+		s.handleSTUN(buffer[:n], addr)
+		return n, addr, nil, ErrRTPDiscard
+	}
+
 	s.firstPacketOnce.Do(func() {
 		close(s.firstPacketCh)
 	})
@@ -374,6 +392,12 @@ func (s *Session) ReceiveRTP(buffer []byte) (n int, from *net.UDPAddr, packet *R
 		return n, addr, nil, ErrRTPDiscard
 	}
 
+	// RFC 5761 §4: muxed RTCP (PT 192-223) goes to the RTCP handler.
+	if (s.RTCPMuxEnabled() || s.ICELiteEnabled()) && isMuxedRTCP(buffer[:n]) {
+		s.handleMuxedRTCP(buffer[:n])
+		return n, addr, nil, ErrRTPDiscard
+	}
+
 	work := buffer[:n]
 	if s.srtpDecrypt != nil {
 		s.srtpMu.Lock()
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/LinByte/VoiceServer/pkg/media"
@@ -71,11 +72,13 @@ type SIPRTPTransport struct {
 
 	jbSlots         [jbSlotCount]jbSlot
 	jbNext          uint16
+	jbHighest       uint16 // newest seq pushed; a jump past +1 is a hole to NACK
 	jbStarted       bool
 	jbLossWait      time.Time
 	jbHoleSkips     int
 	jbAdaptiveDelay time.Duration
 	jbLastPLC       []byte
+	jbPLCOff        atomic.Bool // DisablePLC: holes stay holes (fax / modem audio)
 	readBuf         []byte
 
 	attached *media.MediaSession
@@ -197,8 +200,17 @@ func (t *SIPRTPTransport) jbRememberPLC(payload []byte) {
 	copy(t.jbLastPLC, payload[:n])
 }
 
+// DisablePLC stops the jitter buffer from concealing lost packets by
+// repeating the last frame. Repeated audio corrupts fax / modem
+// signals, so G.711 fax pass-through turns it off. Safe mid-call.
+func (t *SIPRTPTransport) DisablePLC() {
+	if t != nil {
+		t.jbPLCOff.Store(true)
+	}
+}
+
 func (t *SIPRTPTransport) jbPLCReplacement() []byte {
-	if t == nil || len(t.jbLastPLC) == 0 {
+	if t == nil || len(t.jbLastPLC) == 0 || t.jbPLCOff.Load() {
 		return nil
 	}
 	c := strings.ToLower(strings.TrimSpace(t.codec.Codec))
@@ -234,8 +246,33 @@ func (t *SIPRTPTransport) jbPush(pkt *RTPPacket, now time.Time) {
 	slot.held = jbHeld{pkt: cloneRTPPacketForJitter(pkt), at: now}
 	if !t.jbStarted {
 		t.jbNext = seq
+		t.jbHighest = seq
 		t.jbStarted = true
+		return
+	}
+	if d := seq - t.jbHighest; d != 0 && d < 0x8000 {
+		if d > 1 {
+			t.jbNACKHole(t.jbHighest+1, d-1)
+		}
+		t.jbHighest = seq
+	}
+}
+
+// jbNACKHole asks the peer to resend [first, first+count) while the
+// playout delay still leaves time for the retransmission to land.
+func (t *SIPRTPTransport) jbNACKHole(first, count uint16) {
+	if t.sess == nil || !t.sess.NACKEnabled() {
+		return
+	}
+	if count > nackMaxBatch {
+		first += count - nackMaxBatch
+		count = nackMaxBatch
+	}
+	seqs := make([]uint16, 0, count)
+	for i := uint16(0); i < count; i++ {
+		seqs = append(seqs, first+i)
 	}
+	t.sess.requestNACK(seqs...)
 }
 
 func (t *SIPRTPTransport) jbTryPop(now time.Time) *RTPPacket {
@@ -333,6 +333,10 @@ func TestJitterJBPLCNonPCM(t *testing.T) {
 	if len(tr.jbPLCReplacement()) != 3 {
 		t.Fatal("pcmu PLC")
 	}
+	tr.DisablePLC()
+	if tr.jbPLCReplacement() != nil {
+		t.Fatal("PLC still repeating after DisablePLC")
+	}
 }
 
 func TestJitterJBResetClears(t *testing.T) {
@@ -0,0 +1,116 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package sdp
+
+// ice.go: ICE-lite SDP attributes (RFC 8839) for WebRTC peers such as
+// SIP-over-WebSocket softphones. We only ever answer as a lite agent
+// with one host candidate — the RTP socket — so there is no candidate
+// gathering or trickle here; see pkg/sip/rtp/ice.go for the STUN side.
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// ICELiteLine is the session-level a=ice-lite attribute (RFC 8839 §5.3).
+const ICELiteLine = "a=ice-lite"
+
+// iceChars is the ice-char alphabet (RFC 8839 §5.4: ALPHA / DIGIT / "+" / "/").
+const iceChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// HasICE reports whether the offer carries ICE credentials.
+func (i *Info) HasICE() bool {
+	return i != nil && i.ICEUfrag != "" && i.ICEPwd != ""
+}
+
+// parseICEAttribute fills ICE / mux / mid fields from one SDP line.
+// Media-level values win over session-level ones.
+func parseICEAttribute(info *Info, line string, media bool) {
+	low := strings.ToLower(line)
+	switch {
+	case strings.HasPrefix(low, "a=ice-ufrag:"):
+		if v := strings.TrimSpace(line[len("a=ice-ufrag:"):]); v != "" && (media || info.ICEUfrag == "") {
+			info.ICEUfrag = v
+		}
+	case strings.HasPrefix(low, "a=ice-pwd:"):
+		if v := strings.TrimSpace(line[len("a=ice-pwd:"):]); v != "" && (media || info.ICEPwd == "") {
+			info.ICEPwd = v
+		}
+	case media && low == "a=rtcp-mux":
+		info.RTCPMux = true
+	case media && strings.HasPrefix(low, "a=mid:"):
+		info.MID = strings.TrimSpace(line[len("a=mid:"):])
+	}
+}
+
+// NewICECredentials returns a random ufrag (8 chars) and pwd (24 chars),
+// above the RFC 8839 §5.4 minimums of 4 and 22.
+func NewICECredentials() (ufrag, pwd string, err error) {
+	if ufrag, err = randomICEString(8); err != nil {
+		return "", "", err
+	}
+	if pwd, err = randomICEString(24); err != nil {
+		return "", "", err
+	}
+	return ufrag, pwd, nil
+}
+
+func randomICEString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("sip1/sdp: ice credentials: %w", err)
+	}
+	for i := range b {
+		b[i] = iceChars[int(b[i])%len(iceChars)]
+	}
+	return string(b), nil
+}
+
+// ICELiteMediaLines renders the m=audio attributes of an ICE-lite
+// answer: credentials, one host candidate per component (component 2
+// on port+1 only without rtcp-mux), end-of-candidates, and a=mid /
+// a=rtcp-mux when the offer had them. ICELiteLine goes at session level
+// via WithSessionAttributes.
+func ICELiteMediaLines(ufrag, pwd, ip string, port int, mid string, rtcpMux bool) []string {
+	lines := []string{
+		"a=ice-ufrag:" + ufrag,
+		"a=ice-pwd:" + pwd,
+		// RFC 8445 §5.1.2.1 host priority: (126<<24)|(65535<<8)|(256-component).
+		fmt.Sprintf("a=candidate:1 1 UDP %d %s %d typ host", 126<<24|65535<<8|255, ip, port),
+	}
+	if !rtcpMux {
+		lines = append(lines, fmt.Sprintf("a=candidate:1 2 UDP %d %s %d typ host", 126<<24|65535<<8|254, ip, port+1))
+	}
+	lines = append(lines, "a=end-of-candidates")
+	if mid != "" {
+		lines = append(lines, "a=mid:"+mid)
+	}
+	if rtcpMux {
+		lines = append(lines, "a=rtcp-mux")
+	}
+	return lines
+}
+
+// WithSessionAttributes inserts session-level attribute lines (e.g.
+// ICELiteLine) before the first m= line of body.
+func WithSessionAttributes(body string, lines ...string) string {
+	var add strings.Builder
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			add.WriteString(l + "\r\n")
+		}
+	}
+	if add.Len() == 0 {
+		return body
+	}
+	idx := strings.Index(body, "\r\nm=")
+	if idx < 0 {
+		if strings.HasPrefix(body, "m=") {
+			return add.String() + body
+		}
+		return body + add.String()
+	}
+	return body[:idx+2] + add.String() + body[idx+2:]
+}
@@ -0,0 +1,72 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_ICEAttributes(t *testing.T) {
+	body := "v=0\r\n" +
+		"o=- 1 1 IN IP4 0.0.0.0\r\n" +
+		"s=-\r\n" +
+		"a=ice-ufrag:sess\r\n" +
+		"a=ice-pwd:sessionpasswordsessionpw\r\n" +
+		"c=IN IP4 0.0.0.0\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111 0\r\n" +
+		"a=mid:0\r\n" +
+		"a=ice-ufrag:Xy7q\r\n" +
+		"a=rtcp-mux\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n"
+	info, err := Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ICEUfrag != "Xy7q" || info.ICEPwd != "sessionpasswordsessionpw" {
+		t.Fatalf("ice = %q / %q", info.ICEUfrag, info.ICEPwd)
+	}
+	if !info.HasICE() || !info.RTCPMux || info.MID != "0" {
+		t.Fatalf("info = %+v", info)
+	}
+
+	plain, err := Parse(Generate("192.0.2.1", 4000, DefaultOutboundOfferCodecs()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.HasICE() || plain.RTCPMux {
+		t.Fatalf("plain SDP parsed as ICE: %+v", plain)
+	}
+}
+
+func TestICELiteAnswerLines(t *testing.T) {
+	ufrag, pwd, err := NewICECredentials()
+	if err != nil || len(ufrag) < 4 || len(pwd) < 22 {
+		t.Fatalf("credentials %q %q %v", ufrag, pwd, err)
+	}
+	lines := ICELiteMediaLines(ufrag, pwd, "192.0.2.1", 4000, "0", true)
+	body := WithSessionAttributes(
+		GenerateWithProtoExtras("192.0.2.1", 4000, "UDP/TLS/RTP/SAVPF", DefaultOutboundOfferCodecs(), lines),
+		ICELiteLine)
+	if i, m := strings.Index(body, "a=ice-lite\r\n"), strings.Index(body, "m=audio"); i < 0 || i > m {
+		t.Fatalf("ice-lite not at session level:\n%s", body)
+	}
+	for _, want := range []string{
+		"a=candidate:1 1 UDP 2130706431 192.0.2.1 4000 typ host",
+		"a=mid:0", "a=rtcp-mux", "a=ice-pwd:" + pwd,
+	} {
+		if !strings.Contains(body, want+"\r\n") {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	info, err := Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ICEUfrag != ufrag || !info.RTCPMux {
+		t.Fatalf("round trip: %+v", info)
+	}
+	if strings.Contains(strings.Join(ICELiteMediaLines(ufrag, pwd, "192.0.2.1", 4000, "", false), "\n"), "a=mid") {
+		t.Error("empty mid rendered")
+	}
+}
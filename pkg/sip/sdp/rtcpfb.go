@@ -0,0 +1,72 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package sdp
+
+// rtcpfb.go: RTCP multiplexing (RFC 5761) and RTCP feedback (RFC 4585)
+// attributes. Only generic NACK is negotiated — PLI / FIR / REMB are
+// video feedback and have no meaning on an audio-only m= line.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RTCPMuxLine is the media-level a=rtcp-mux attribute (RFC 5761 §5.1.1).
+const RTCPMuxLine = "a=rtcp-mux"
+
+// IsAVPFProto reports whether proto is one of the feedback profiles
+// (RTP/AVPF, RTP/SAVPF, UDP/TLS/RTP/SAVPF). RFC 4585 §4.2: a=rtcp-fb is
+// only meaningful under these.
+func IsAVPFProto(proto string) bool {
+	return strings.HasSuffix(strings.ToUpper(strings.TrimSpace(proto)), "AVPF")
+}
+
+// SupportsNACK reports whether the peer offered generic NACK for pt,
+// either directly or via the "*" wildcard (RFC 4585 §4.2). Always
+// false outside an AVPF profile.
+func (i *Info) SupportsNACK(pt uint8) bool {
+	if i == nil || !IsAVPFProto(i.Proto) {
+		return false
+	}
+	for _, p := range i.NACKPayloadTypes {
+		if p == pt {
+			return true
+		}
+	}
+	return i.nackWildcard
+}
+
+// parseRTCPFeedback records a=rtcp-fb:<pt|*> nack lines (generic NACK
+// only; "nack pli" and other parameters are ignored).
+func parseRTCPFeedback(info *Info, line string) {
+	low := strings.ToLower(strings.TrimSpace(line))
+	if !strings.HasPrefix(low, "a=rtcp-fb:") {
+		return
+	}
+	f := strings.Fields(low[len("a=rtcp-fb:"):])
+	if len(f) != 2 || f[1] != "nack" {
+		return
+	}
+	if f[0] == "*" {
+		info.nackWildcard = true
+		return
+	}
+	if pt, err := strconv.Atoi(f[0]); err == nil && pt >= 0 && pt <= 127 {
+		info.NACKPayloadTypes = append(info.NACKPayloadTypes, uint8(pt))
+	}
+}
+
+// NACKFeedbackLines renders a=rtcp-fb:<pt> nack for each media codec,
+// skipping telephone-event (DTMF events are never retransmitted).
+func NACKFeedbackLines(codecs ...Codec) []string {
+	out := make([]string, 0, len(codecs))
+	for _, c := range codecs {
+		if strings.EqualFold(strings.TrimSpace(c.Name), "telephone-event") {
+			continue
+		}
+		out = append(out, fmt.Sprintf("a=rtcp-fb:%d nack", c.PayloadType))
+	}
+	return out
+}
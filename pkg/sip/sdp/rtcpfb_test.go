@@ -0,0 +1,68 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_RTCPFeedbackNACK(t *testing.T) {
+	body := "v=0\r\n" +
+		"o=- 1 1 IN IP4 198.51.100.1\r\n" +
+		"s=-\r\n" +
+		"c=IN IP4 198.51.100.1\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 4000 RTP/AVPF 111 0 101\r\n" +
+		"a=rtcp-mux\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"a=rtpmap:101 telephone-event/8000\r\n" +
+		"a=rtcp-fb:111 nack\r\n" +
+		"a=rtcp-fb:0 nack pli\r\n"
+	info, err := Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.RTCPMux {
+		t.Fatal("rtcp-mux not parsed")
+	}
+	if !info.SupportsNACK(111) {
+		t.Fatal("nack for 111 not parsed")
+	}
+	if info.SupportsNACK(0) {
+		t.Fatal("\"nack pli\" must not enable generic NACK")
+	}
+
+	// Same attributes under plain RTP/AVP carry no meaning.
+	avp, err := Parse(strings.Replace(body, "RTP/AVPF", "RTP/AVP", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avp.SupportsNACK(111) {
+		t.Fatal("NACK enabled outside an AVPF profile")
+	}
+}
+
+func TestParse_RTCPFeedbackWildcard(t *testing.T) {
+	body := "v=0\r\nc=IN IP4 198.51.100.1\r\nm=audio 4000 RTP/SAVPF 8\r\na=rtcp-fb:* nack\r\n"
+	info, err := Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.SupportsNACK(8) {
+		t.Fatal("wildcard nack not honoured")
+	}
+}
+
+func TestNACKFeedbackLines_SkipsTelephoneEvent(t *testing.T) {
+	got := NACKFeedbackLines(
+		Codec{PayloadType: 111, Name: "opus"},
+		Codec{PayloadType: 101, Name: "telephone-event"},
+		Codec{PayloadType: 0, Name: "pcmu"},
+	)
+	want := []string{"a=rtcp-fb:111 nack", "a=rtcp-fb:0 nack"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %v", got)
+	}
+	if !IsAVPFProto("UDP/TLS/RTP/SAVPF") || !IsAVPFProto("rtp/avpf") || IsAVPFProto("RTP/SAVP") {
+		t.Fatal("IsAVPFProto")
+	}
+}
@@ -24,8 +24,8 @@ type Info struct {
 	Codecs []Codec
 	// CryptoOffers lists a=crypto attributes from the first m=audio section (RFC 4568 SDES), if present.
 	CryptoOffers []CryptoOffer
-	// Fingerprints lists a=fingerprint values from the first m=audio
-	// section (RFC 8122). Multiple entries are allowed when the peer
+	// Fingerprints lists a=fingerprint values from the session level and
+	// the first m=audio section (RFC 8122; Firefox uses session level). Multiple entries are allowed when the peer
 	// has both an EC and an RSA cert configured; we accept any. Empty
 	// when DTLS-SRTP isn't negotiated.
 	Fingerprints []Fingerprint
@@ -33,6 +33,19 @@ type Info struct {
 	// section (RFC 5763 §5). DTLSRoleActPass is the default when the
 	// attribute is absent, per RFC 5763 §5.
 	DTLSRole DTLSRole
+	// ICEUfrag / ICEPwd are the peer's ICE credentials (RFC 8839 §5.4),
+	// taken from the session level or the first m=audio section. Set by
+	// WebRTC clients; empty for plain SIP endpoints.
+	ICEUfrag string
+	ICEPwd   string
+	// RTCPMux is true when the audio section carries a=rtcp-mux (RFC 5761).
+	RTCPMux bool
+	// MID is the audio section's a=mid (RFC 5888), echoed in the answer.
+	MID string
+	// NACKPayloadTypes lists payload types the audio section enables
+	// generic NACK for (a=rtcp-fb:<pt> nack, RFC 4585). See SupportsNACK.
+	NACKPayloadTypes []uint8
+	nackWildcard     bool
 }
 
 var (
@@ -88,6 +101,7 @@ func Parse(body string) (*Info, error) {
 	var payloadTypes []uint8
 	var mediaProto string
 	inAudioSection := false
+	sessionLevel := true
 	lines := strings.Split(body, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -96,8 +110,15 @@ func Parse(body string) (*Info, error) {
 		}
 
 		if strings.HasPrefix(line, "m=") {
+			sessionLevel = false
 			inAudioSection = strings.HasPrefix(strings.ToLower(line), "m=audio")
 		}
+		if sessionLevel || inAudioSection {
+			parseICEAttribute(info, line, inAudioSection)
+		}
+		if inAudioSection {
+			parseRTCPFeedback(info, line)
+		}
 
 		if strings.HasPrefix(line, "m=audio") {
 			m := reMAudio.FindStringSubmatch(line)
@@ -142,14 +163,14 @@ func Parse(body string) (*Info, error) {
 		}
 
 		// RFC 8122 a=fingerprint:<hash> <hex>
-		if inAudioSection && strings.HasPrefix(strings.ToLower(line), "a=fingerprint:") {
+		if (inAudioSection || sessionLevel) && strings.HasPrefix(strings.ToLower(line), "a=fingerprint:") {
 			rest := strings.TrimSpace(line[len("a=fingerprint:"):])
 			if fp := ParseFingerprint(rest); fp.HashFunc != "" {
 				info.Fingerprints = append(info.Fingerprints, fp)
 			}
 		}
 		// RFC 5763 a=setup:active|passive|actpass|holdconn
-		if inAudioSection && strings.HasPrefix(strings.ToLower(line), "a=setup:") {
+		if (inAudioSection || sessionLevel) && strings.HasPrefix(strings.ToLower(line), "a=setup:") {
 			rest := strings.TrimSpace(line[len("a=setup:"):])
 			if r := ParseRole(rest); r.IsValid() {
 				info.DTLSRole = r
@@ -0,0 +1,183 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package sdp
+
+// t38.go: T.38 fax over UDPTL (ITU-T T.38 Annex D, RFC 3362
+// image/t38). The m=image section lives beside (or replaces) m=audio,
+// so it is parsed separately from Parse — a T.38-only answer has no
+// audio codec and Parse would reject it.
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// T.38 error-correction schemes (a=T38FaxUdpEC).
+const (
+	T38ECRedundancy = "t38UDPRedundancy"
+	T38ECFEC        = "t38UDPFEC"
+	T38ECNone       = ""
+)
+
+// T38Params are the T.38 Annex D SDP attributes we negotiate.
+type T38Params struct {
+	Version        int    // a=T38FaxVersion (0..3)
+	MaxBitRate     int    // a=T38MaxBitRate (bps)
+	RateManagement string // a=T38FaxRateManagement: transferredTCF / localTCF
+	MaxBuffer      int    // a=T38FaxMaxBuffer
+	MaxDatagram    int    // a=T38FaxMaxDatagram
+	UDPEC          string // a=T38FaxUdpEC
+}
+
+// DefaultT38Params is what we offer: non-ECM up to V.17 14400 with
+// UDPTL redundancy and transferred TCF (the only mode T.38 over UDP
+// really supports).
+func DefaultT38Params() T38Params {
+	return T38Params{
+		Version:        0,
+		MaxBitRate:     14400,
+		RateManagement: "transferredTCF",
+		MaxBuffer:      262,
+		MaxDatagram:    176,
+		UDPEC:          T38ECRedundancy,
+	}
+}
+
+// T38Info is the m=image udptl t38 section of an SDP body.
+type T38Info struct {
+	IP     string
+	Port   int // 0 = declined
+	Params T38Params
+}
+
+// Addr returns the remote UDPTL address, or nil when declined / unparsable.
+func (t *T38Info) Addr() *net.UDPAddr {
+	if t == nil || t.Port <= 0 {
+		return nil
+	}
+	ip := net.ParseIP(t.IP)
+	if ip == nil {
+		return nil
+	}
+	return &net.UDPAddr{IP: ip, Port: t.Port}
+}
+
+// ParseT38 extracts the first m=image udptl t38 section. ok is false
+// when the body carries no such section. A media-level c= line
+// overrides the session-level one.
+func ParseT38(body string) (info *T38Info, ok bool) {
+	body = NormalizeBody(body)
+	sessionIP := ""
+	in := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		low := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(low, "m="):
+			if info != nil {
+				return info, true
+			}
+			f := strings.Fields(low[2:])
+			in = len(f) >= 4 && f[0] == "image" && f[2] == "udptl" && f[3] == "t38"
+			if !in {
+				continue
+			}
+			port, err := strconv.Atoi(f[1])
+			if err != nil {
+				in = false
+				continue
+			}
+			info = &T38Info{IP: sessionIP, Port: port}
+		case strings.HasPrefix(low, "c=in ip4 "):
+			ip := strings.TrimSpace(line[len("c=IN IP4 "):])
+			if info != nil && in {
+				info.IP = ip
+			} else if info == nil {
+				sessionIP = ip
+			}
+		case in && strings.HasPrefix(low, "a="):
+			parseT38Attribute(&info.Params, line[2:])
+		}
+	}
+	return info, info != nil
+}
+
+func parseT38Attribute(p *T38Params, attr string) {
+	name, val, _ := strings.Cut(attr, ":")
+	val = strings.TrimSpace(val)
+	n, _ := strconv.Atoi(val)
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "t38faxversion":
+		p.Version = n
+	case "t38maxbitrate":
+		p.MaxBitRate = n
+	case "t38faxratemanagement":
+		p.RateManagement = val
+	case "t38faxmaxbuffer":
+		p.MaxBuffer = n
+	case "t38faxmaxdatagram":
+		p.MaxDatagram = n
+	case "t38faxudpec":
+		p.UDPEC = val
+	}
+}
+
+// AnswerT38 narrows our parameters to what the offer allows: lowest
+// version and bit rate, the offerer's rate management, and redundancy
+// unless the offer asked for no error correction (FEC is not
+// implemented, so it also falls back to redundancy).
+func AnswerT38(offer T38Params) T38Params {
+	ans := DefaultT38Params()
+	if offer.Version < ans.Version {
+		ans.Version = offer.Version
+	}
+	if offer.MaxBitRate > 0 && offer.MaxBitRate < ans.MaxBitRate {
+		ans.MaxBitRate = offer.MaxBitRate
+	}
+	if offer.RateManagement != "" {
+		ans.RateManagement = offer.RateManagement
+	}
+	if offer.MaxDatagram > 0 && offer.MaxDatagram < ans.MaxDatagram {
+		ans.MaxDatagram = offer.MaxDatagram
+	}
+	if offer.UDPEC == T38ECNone {
+		ans.UDPEC = T38ECNone
+	}
+	return ans
+}
+
+// GenerateT38 builds a T.38-only SDP body (m=image <port> udptl t38).
+func GenerateT38(localIP string, localPort int, p T38Params) string {
+	if localIP == "" {
+		localIP = "127.0.0.1"
+	}
+	var b strings.Builder
+	sess := time.Now().Unix()
+	b.WriteString("v=0\r\n")
+	b.WriteString(fmt.Sprintf("o=- %d %d IN IP4 %s\r\n", sess, sess, localIP))
+	b.WriteString("s=" + DefaultSessionName + "\r\n")
+	b.WriteString(fmt.Sprintf("c=IN IP4 %s\r\n", localIP))
+	b.WriteString("t=0 0\r\n")
+	b.WriteString(fmt.Sprintf("m=image %d udptl t38\r\n", localPort))
+	b.WriteString(fmt.Sprintf("a=T38FaxVersion:%d\r\n", p.Version))
+	if p.MaxBitRate > 0 {
+		b.WriteString(fmt.Sprintf("a=T38MaxBitRate:%d\r\n", p.MaxBitRate))
+	}
+	if p.RateManagement != "" {
+		b.WriteString("a=T38FaxRateManagement:" + p.RateManagement + "\r\n")
+	}
+	if p.MaxBuffer > 0 {
+		b.WriteString(fmt.Sprintf("a=T38FaxMaxBuffer:%d\r\n", p.MaxBuffer))
+	}
+	if p.MaxDatagram > 0 {
+		b.WriteString(fmt.Sprintf("a=T38FaxMaxDatagram:%d\r\n", p.MaxDatagram))
+	}
+	if p.UDPEC != T38ECNone {
+		b.WriteString("a=T38FaxUdpEC:" + p.UDPEC + "\r\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseT38_ReInviteOffer(t *testing.T) {
+	body := "v=0\r\n" +
+		"o=- 1 2 IN IP4 10.0.0.1\r\n" +
+		"s=-\r\n" +
+		"c=IN IP4 10.0.0.1\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 0 RTP/AVP 8\r\n" +
+		"m=image 4002 udptl t38\r\n" +
+		"c=IN IP4 10.0.0.9\r\n" +
+		"a=T38FaxVersion:0\r\n" +
+		"a=T38MaxBitRate:9600\r\n" +
+		"a=T38FaxRateManagement:transferredTCF\r\n" +
+		"a=T38FaxMaxDatagram:400\r\n" +
+		"a=T38FaxUdpEC:t38UDPRedundancy\r\n"
+	info, ok := ParseT38(body)
+	if !ok {
+		t.Fatal("m=image not found")
+	}
+	if info.Port != 4002 || info.IP != "10.0.0.9" {
+		t.Fatalf("addr = %s:%d", info.IP, info.Port)
+	}
+	if info.Params.MaxBitRate != 9600 || info.Params.MaxDatagram != 400 || info.Params.UDPEC != T38ECRedundancy {
+		t.Fatalf("params = %+v", info.Params)
+	}
+	ans := AnswerT38(info.Params)
+	if ans.MaxBitRate != 9600 || ans.MaxDatagram != 176 || ans.RateManagement != "transferredTCF" {
+		t.Fatalf("answer = %+v", ans)
+	}
+}
+
+func TestParseT38_AudioOnly(t *testing.T) {
+	if _, ok := ParseT38("v=0\r\nc=IN IP4 1.2.3.4\r\nm=audio 4000 RTP/AVP 0\r\n"); ok {
+		t.Fatal("audio-only body reported T.38")
+	}
+}
+
+func TestGenerateT38_RoundTrip(t *testing.T) {
+	body := GenerateT38("192.0.2.5", 30000, DefaultT38Params())
+	if !strings.Contains(body, "m=image 30000 udptl t38\r\n") {
+		t.Fatalf("missing m=image:\n%s", body)
+	}
+	info, ok := ParseT38(body)
+	if !ok || info.Addr().String() != "192.0.2.5:30000" {
+		t.Fatalf("round trip = %+v", info)
+	}
+	if info.Params != DefaultT38Params() {
+		t.Fatalf("params = %+v", info.Params)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// Busy-lamp-field state: server-generated RFC 4235 dialog-info and
+// RFC 3863 PIDF (with RFC 4480 RPID activities) for agent presentities.
+//
+// The application owns the state (ACD work_state, live transfer legs)
+// and pushes it with PublishAgentPresence; SUBSCRIBE pulls the current
+// state through the PresenceSource for the initial NOTIFY.
+
+// RPID activities used for agent presence.
+const (
+	PresenceActivityOnThePhone = "on-the-phone"
+	PresenceActivityBusy       = "busy"
+	PresenceActivityAway       = "away"
+)
+
+// RFC 4235 dialog states used for BLF.
+const (
+	DialogStateEarly      = "early"
+	DialogStateConfirmed  = "confirmed"
+	DialogStateTerminated = "terminated"
+)
+
+const (
+	contentTypeDialogInfo = "application/dialog-info+xml"
+	contentTypePIDF       = "application/pidf+xml"
+)
+
+// DialogInfo is one <dialog> element.
+type DialogInfo struct {
+	ID     string
+	CallID string
+	// Direction: initiator | recipient (from the agent's point of view).
+	Direction     string
+	State         string
+	RemoteURI     string
+	RemoteDisplay string
+}
+
+// AgentPresence is the BLF state of one presentity (SIP user part).
+type AgentPresence struct {
+	User string
+	// Open is the PIDF basic status (signed in / registered).
+	Open bool
+	// Activity is an RPID activity (PresenceActivity*); empty = none.
+	Activity string
+	Note     string
+	Dialogs  []DialogInfo
+}
+
+// PresenceSource returns the current state of a presentity for initial
+// NOTIFYs; ok false means the server has no generated state for it.
+type PresenceSource func(user string) (AgentPresence, bool)
+
+// SetPresenceSource wires generated presence for SUBSCRIBE; nil keeps PUBLISH-only presence.
+func (s *SIPServer) SetPresenceSource(fn PresenceSource) {
+	if s == nil {
+		return
+	}
+	s.msgMu.Lock()
+	defer s.msgMu.Unlock()
+	s.presenceSource = fn
+}
+
+func (s *SIPServer) lookupPresence(user string) (AgentPresence, bool) {
+	s.msgMu.RLock()
+	fn := s.presenceSource
+	s.msgMu.RUnlock()
+	if fn == nil || user == "" {
+		return AgentPresence{}, false
+	}
+	return fn(user)
+}
+
+// PublishAgentPresence sends NOTIFYs to every dialog / presence
+// subscription on p.User.
+func (s *SIPServer) PublishAgentPresence(p AgentPresence) {
+	if s == nil || strings.TrimSpace(p.User) == "" {
+		return
+	}
+	globalPresence.fanout(s, func(sub *presenceSub) (string, string, bool) {
+		if !strings.EqualFold(sub.user, p.User) {
+			return "", "", false
+		}
+		return sub.render(p)
+	})
+}
+
+// render builds the NOTIFY body for sub's event package.
+func (sub *presenceSub) render(p AgentPresence) (body, contentType string, ok bool) {
+	switch sub.event {
+	case eventDialog:
+		v := sub.version
+		sub.version++
+		return dialogInfoXML(sub.presentity, v, p), contentTypeDialogInfo, true
+	case eventPresence:
+		return pidfXML(sub.presentity, p), contentTypePIDF, true
+	}
+	return "", "", false
+}
+
+// dialogInfoXML renders a full-state RFC 4235 document.
+func dialogInfoXML(entity string, version int, p AgentPresence) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<dialog-info xmlns="urn:ietf:params:xml:ns:dialog-info" version="`)
+	b.WriteString(strconv.Itoa(version))
+	b.WriteString(`" state="full" entity="`)
+	b.WriteString(xmlEscape(entity))
+	b.WriteString(`">` + "\n")
+	for _, d := range p.Dialogs {
+		id := nonEmptyStr(d.ID, d.CallID)
+		b.WriteString(`  <dialog id="` + xmlEscape(id) + `"`)
+		if d.CallID != "" {
+			b.WriteString(` call-id="` + xmlEscape(d.CallID) + `"`)
+		}
+		if d.Direction != "" {
+			b.WriteString(` direction="` + xmlEscape(d.Direction) + `"`)
+		}
+		b.WriteString(">\n    <state>" + xmlEscape(nonEmptyStr(d.State, DialogStateTerminated)) + "</state>\n")
+		if d.RemoteURI != "" {
+			b.WriteString("    <remote><identity")
+			if d.RemoteDisplay != "" {
+				b.WriteString(` display="` + xmlEscape(d.RemoteDisplay) + `"`)
+			}
+			b.WriteString(">" + xmlEscape(d.RemoteURI) + "</identity></remote>\n")
+		}
+		b.WriteString("  </dialog>\n")
+	}
+	b.WriteString("</dialog-info>\n")
+	return b.String()
+}
+
+// pidfXML renders an RFC 3863 document with an RPID person activity.
+func pidfXML(entity string, p AgentPresence) string {
+	basic := "closed"
+	if p.Open {
+		basic = "open"
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<presence xmlns="urn:ietf:params:xml:ns:pidf" xmlns:dm="urn:ietf:params:xml:ns:pidf:data-model" xmlns:rpid="urn:ietf:params:xml:ns:pidf:rpid" entity="`)
+	b.WriteString(xmlEscape(entity))
+	b.WriteString(`">` + "\n")
+	b.WriteString(`  <tuple id="t-` + xmlEscape(p.User) + `"><status><basic>` + basic + "</basic></status>")
+	if p.Note != "" {
+		b.WriteString("<note>" + xmlEscape(p.Note) + "</note>")
+	}
+	b.WriteString("</tuple>\n")
+	if p.Activity != "" {
+		b.WriteString(`  <dm:person id="p-` + xmlEscape(p.User) + `"><rpid:activities><rpid:` + xmlEscape(p.Activity) + "/></rpid:activities></dm:person>\n")
+	}
+	b.WriteString("</presence>\n")
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
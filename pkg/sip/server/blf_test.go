@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestDialogInfoAndPIDF(t *testing.T) {
+	p := AgentPresence{
+		User:     "1001",
+		Open:     true,
+		Activity: PresenceActivityOnThePhone,
+		Note:     "ringing",
+		Dialogs: []DialogInfo{{
+			CallID: "in-1", Direction: "recipient", State: DialogStateEarly,
+			RemoteURI: "tel:13800138000", RemoteDisplay: `A&B`,
+		}},
+	}
+	d := dialogInfoXML("sip:1001@pbx.example", 3, p)
+	for _, want := range []string{
+		`version="3" state="full" entity="sip:1001@pbx.example"`,
+		`<dialog id="in-1" call-id="in-1" direction="recipient">`,
+		`<state>early</state>`,
+		`<identity display="A&amp;B">tel:13800138000</identity>`,
+	} {
+		if !strings.Contains(d, want) {
+			t.Fatalf("dialog-info missing %q:\n%s", want, d)
+		}
+	}
+	x := pidfXML("sip:1001@pbx.example", p)
+	if !strings.Contains(x, "<basic>open</basic>") || !strings.Contains(x, "<rpid:on-the-phone/>") {
+		t.Fatalf("pidf:\n%s", x)
+	}
+	if x := pidfXML("sip:1001@pbx.example", AgentPresence{User: "1001"}); !strings.Contains(x, "<basic>closed</basic>") || strings.Contains(x, "rpid:activities") {
+		t.Fatalf("closed pidf:\n%s", x)
+	}
+}
+
+func TestSubscribeDialogEventNotifies(t *testing.T) {
+	if err := logger.Init(&logger.LogConfig{Level: "error", Filename: t.TempDir() + "/test.log", MaxSize: 1}, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(Config{Host: "127.0.0.1", Port: 0, LocalIP: "127.0.0.1"})
+	srv.SetPresenceSource(func(user string) (AgentPresence, bool) {
+		return AgentPresence{User: user, Open: true}, user == "1001"
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Stop() }()
+	host, sigPort := srv.ListenAddr()
+	serverSig := &net.UDPAddr{IP: net.ParseIP(host), Port: sigPort}
+
+	phone, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = phone.Close() }()
+	port := strconv.Itoa(phone.LocalAddr().(*net.UDPAddr).Port)
+	sub := strings.Join([]string{
+		"SUBSCRIBE sip:1001@" + host + " SIP/2.0",
+		"Via: SIP/2.0/UDP 127.0.0.1:" + port + ";branch=z9hG4bKblf1",
+		"Max-Forwards: 70",
+		"From: <sip:supervisor@" + host + ">;tag=s1",
+		"To: <sip:1001@" + host + ">",
+		"Call-ID: blf-sub-1",
+		"CSeq: 1 SUBSCRIBE",
+		"Contact: <sip:supervisor@127.0.0.1:" + port + ">",
+		"Event: dialog",
+		"Accept: application/dialog-info+xml",
+		"Expires: 600",
+		"Content-Length: 0",
+		"", "",
+	}, "\r\n")
+	if _, err := phone.WriteToUDP([]byte(sub), serverSig); err != nil {
+		t.Fatal(err)
+	}
+	var ok200, notify *stack.Message
+	for i := 0; i < 2; i++ {
+		m := readUDPSIP(t, phone)
+		if m.IsRequest {
+			notify = m
+		} else {
+			ok200 = m
+		}
+	}
+	if ok200 == nil || ok200.StatusCode != 200 || !strings.Contains(ok200.GetHeader("To"), ";tag=") {
+		t.Fatalf("SUBSCRIBE response %+v", ok200)
+	}
+	if notify == nil || notify.Method != stack.MethodNotify || notify.GetHeader("Event") != "dialog" {
+		t.Fatalf("initial NOTIFY %+v", notify)
+	}
+	if notify.RequestURI != "sip:supervisor@127.0.0.1:"+port {
+		t.Fatalf("NOTIFY Request-URI %q, want subscriber Contact", notify.RequestURI)
+	}
+	if notify.GetHeader("From") != ok200.GetHeader("To") {
+		t.Fatalf("NOTIFY From %q != 200 To %q", notify.GetHeader("From"), ok200.GetHeader("To"))
+	}
+	if !strings.Contains(notify.Body, `version="0"`) || strings.Contains(notify.Body, "<dialog ") {
+		t.Fatalf("initial body:\n%s", notify.Body)
+	}
+
+	srv.PublishAgentPresence(AgentPresence{User: "1001", Open: true, Dialogs: []DialogInfo{{CallID: "in-9", State: DialogStateConfirmed}}})
+	n2 := readUDPSIP(t, phone)
+	if n2.GetHeader("Content-Type") != contentTypeDialogInfo || !strings.Contains(n2.Body, `version="1"`) || !strings.Contains(n2.Body, "<state>confirmed</state>") {
+		t.Fatalf("update NOTIFY %s\n%s", n2.GetHeader("Content-Type"), n2.Body)
+	}
+	if stack.ParseCSeqNum(n2.GetHeader("CSeq")) <= stack.ParseCSeqNum(notify.GetHeader("CSeq")) {
+		t.Fatalf("CSeq did not advance: %q then %q", notify.GetHeader("CSeq"), n2.GetHeader("CSeq"))
+	}
+}
+
+func TestSubscribeUnknownEvent(t *testing.T) {
+	s := &SIPServer{}
+	m := &stack.Message{IsRequest: true, Method: stack.MethodSubscribe, RequestURI: "sip:1001@pbx.example", Version: "SIP/2.0"}
+	m.SetHeader("Event", "message-summary")
+	m.SetHeader("Call-ID", "x")
+	m.SetHeader("CSeq", "1 SUBSCRIBE")
+	resp := s.handleSubscribe(m, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5060})
+	if resp.StatusCode != 489 || !strings.Contains(resp.GetHeader("Allow-Events"), "dialog") {
+		t.Fatalf("%d %q", resp.StatusCode, resp.GetHeader("Allow-Events"))
+	}
+}
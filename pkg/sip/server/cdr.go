@@ -37,6 +37,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
 	"github.com/LinByte/VoiceServer/pkg/voice/cdr"
 )
 
@@ -138,6 +139,19 @@ func trackInboundCallEnd(callID, hangupBy, reasonClass, hangupReason string) {
 		rec.Errors = append([]string(nil), entry.errors...)
 	}
 	entry.mu.Unlock()
+	if n, paused := sipSession.RecordingPauseTotals(callID); n > 0 {
+		rec.RecordingPauses = n
+		rec.RecordingPausedMs = paused.Milliseconds()
+	}
+	if fr, ok := sipSession.FaxResultFor(callID); ok {
+		rec.FaxMode = fr.Mode
+		rec.FaxResult = fr.Status
+		rec.FaxReason = fr.Reason
+		rec.FaxPages = fr.Pages
+		rec.FaxRemoteID = fr.RemoteID
+		rec.FaxBitRate = fr.BitRate
+		rec.FaxDocument = fr.DocumentURL
+	}
 	rec.Finalize(time.Now())
 	sink.Emit(rec)
 }
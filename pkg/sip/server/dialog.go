@@ -12,6 +12,7 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
 	"github.com/LinByte/VoiceServer/pkg/sip/rtp"
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
 )
 
@@ -21,6 +22,7 @@ type uasDialogState struct {
 	byeTo     string // remote From from INVITE
 	byeReqURI string
 	nextCSeq  int
+	routeSet  []string // INVITE Record-Route in order (RFC 3261 §12.1.1)
 }
 
 func cloneUDP(a *net.UDPAddr) *net.UDPAddr {
@@ -92,6 +94,7 @@ func (s *SIPServer) rememberUASDialog(callID string, remote *net.UDPAddr, inv *s
 		byeTo:     inv.GetHeader("From"),
 		byeReqURI: reqURI,
 		nextCSeq:  parseInviteCSeqNext(inv.GetHeader("CSeq")),
+		routeSet:  transaction.RouteSetForUAS(inv),
 	}
 	s.dlgMu.Lock()
 	if s.uasDlg == nil {
@@ -153,6 +156,7 @@ func (s *SIPServer) buildUASBye(callID string) (*stack.Message, *net.UDPAddr, er
 	msg.SetHeader("To", d.byeTo)
 	msg.SetHeader("Call-ID", callID)
 	msg.SetHeader("CSeq", fmt.Sprintf("%d BYE", cseq))
+	transaction.SetRouteHeaders(msg, d.routeSet)
 	msg.SetHeader("Content-Length", "0")
 	return msg, cloneUDP(d.remote), nil
 }
@@ -192,6 +196,7 @@ func (s *SIPServer) buildReferNotify(callID string, sipfragBody string, subscrip
 	msg.SetHeader("To", d.byeTo)
 	msg.SetHeader("Call-ID", callID)
 	msg.SetHeader("CSeq", fmt.Sprintf("%d NOTIFY", cseq))
+	transaction.SetRouteHeaders(msg, d.routeSet)
 	msg.SetHeader("Event", "refer")
 	if strings.TrimSpace(subscriptionState) == "" {
 		subscriptionState = "active;expires=60"
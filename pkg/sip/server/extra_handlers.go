@@ -42,21 +42,3 @@ func (s *SIPServer) handleUpdate(msg *stack.Message, addr *net.UDPAddr) *stack.M
 	resp.SetHeader("Content-Length", "0")
 	return resp
 }
-
-func (s *SIPServer) handleMessage(msg *stack.Message, addr *net.UDPAddr) *stack.Message {
-	if s == nil || msg == nil {
-		return nil
-	}
-	if s.absorbNonInviteRetransmit(msg, addr) {
-		return nil
-	}
-	if strings.TrimSpace(msg.Body) == "" {
-		resp := s.makeResponse(msg, 200, "OK", "", "")
-		resp.SetHeader("Content-Length", "0")
-		return resp
-	}
-	resp := s.makeResponse(msg, 415, "Unsupported Media Type", "", "")
-	resp.SetHeader("Accept", "text/plain")
-	resp.SetHeader("Content-Length", "0")
-	return resp
-}
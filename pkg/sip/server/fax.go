@@ -0,0 +1,412 @@
+package server
+
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Inbound fax: T.38 gateway with G.711 pass-through fallback.
+//
+// conversation.FaxWatch hears CNG / CED in the caller audio and calls
+// StartFax (wired in internal/sipserver/sipapp.go). From then on the
+// call is out of the AI path: ASR / VAD stop receiving audio and the
+// inbound jitter buffer stops concealing loss. Then, by fax mode
+// (Trunk.FaxMode / SIP_FAX_MODE):
+//
+//   - auto / t38: we send an in-dialog re-INVITE offering only
+//     m=image udptl t38 on a fresh UDPTL port. On 2xx the AI media
+//     session is stopped and pkg/sip/fax receives the document over
+//     pkg/sip/udptl, stores it as TIFF + PDF and hangs up.
+//   - passthrough, or auto after the peer refused T.38: the call is
+//     bridged to SIP_FAX_PASSTHROUGH_URI through the transfer path,
+//     whose raw G.711 relay has no jitter buffer, PLC or VAD.
+//
+// Peers that switch to T.38 themselves (re-INVITE with m=image) are
+// answered in reinvite.go and land in the same receiver. The outcome is
+// kept in pkg/sip/session (FaxResultFor) for the CDR row.
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
+	sipfax "github.com/LinByte/VoiceServer/pkg/sip/fax"
+	"github.com/LinByte/VoiceServer/pkg/sip/sdp"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
+	"github.com/LinByte/VoiceServer/pkg/sip/udptl"
+	"github.com/LinByte/VoiceServer/pkg/stores"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
+	"go.uber.org/zap"
+)
+
+const (
+	faxReInviteT1      = 500 * time.Millisecond
+	faxReInviteT2      = 4 * time.Second
+	faxReInviteTimeout = 32 * time.Second // RFC 3261 Timer B
+)
+
+// faxReInvite is the T.38 re-INVITE we have in flight on a call.
+type faxReInvite struct {
+	cseq        int
+	dst         *net.UDPAddr
+	resp        chan *stack.Message
+	provisional atomic.Bool // 1xx seen: stop retransmitting
+	final       atomic.Bool
+	ack         atomic.Pointer[stack.Message]
+}
+
+// StartFax switches callID to fax handling after tone was heard. The
+// first call per Call-ID wins; it blocks until the fax session ends
+// (conversation runs it on its own goroutine).
+func (s *SIPServer) StartFax(callID, tone string) {
+	cs := s.GetCallSession(callID)
+	if cs == nil {
+		return
+	}
+	mode := conversation.ResolveFaxMode(callID)
+	if mode == sipfax.ModeOff {
+		return
+	}
+	initial := sipSession.FaxModeT38Pending
+	if mode == sipfax.ModePassthrough {
+		initial = sipSession.FaxModeG711Passthrough
+	}
+	ctx, ok := cs.BeginFax(initial)
+	if !ok {
+		return
+	}
+	cs.DisableJitterPLC()
+	logger.Info("sip fax: tone detected",
+		zap.String("call_id", callID),
+		zap.String("tone", tone),
+		zap.String("mode", mode),
+	)
+	if mode != sipfax.ModePassthrough {
+		if s.switchToT38(ctx, cs) {
+			return
+		}
+		if mode == sipfax.ModeT38 {
+			sipSession.SetFaxResult(callID, sipSession.FaxResult{
+				Mode:   sipSession.FaxModeT38,
+				Status: sipfax.StatusFailed,
+				Reason: "t38 refused by peer",
+			})
+			s.HangupInboundCall(callID)
+			return
+		}
+	}
+	s.startFaxPassthrough(ctx, cs)
+}
+
+// switchToT38 offers T.38 in a re-INVITE and, when accepted, runs the
+// receiver. false means the peer kept the audio stream.
+func (s *SIPServer) switchToT38(ctx context.Context, cs *sipSession.CallSession) bool {
+	callID := cs.CallID
+	sess, err := newUDPTLSession()
+	if err != nil {
+		logger.Warn("sip fax: udptl bind failed", zap.String("call_id", callID), zap.Error(err))
+		return false
+	}
+	offer := sdp.GenerateT38(s.localIP, sess.LocalAddr().Port, sdp.DefaultT38Params())
+	resp, sig, err := s.sendFaxReInvite(ctx, callID, offer)
+	if err != nil || resp.StatusCode >= 300 {
+		_ = sess.Close()
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		logger.Info("sip fax: t38 re-INVITE not accepted",
+			zap.String("call_id", callID),
+			zap.Int("status", status),
+			zap.Error(err),
+		)
+		return false
+	}
+	info, ok := sdp.ParseT38(resp.Body)
+	if !ok || info.Addr() == nil {
+		_ = sess.Close()
+		logger.Info("sip fax: peer answered re-INVITE without T.38", zap.String("call_id", callID))
+		return false
+	}
+	cs.SetFaxMode(sipSession.FaxModeT38)
+	s.runT38Receiver(ctx, cs, sess, info, sig)
+	return true
+}
+
+// runT38Receiver stops the AI media, receives the fax over UDPTL,
+// stores the document and hangs up.
+func (s *SIPServer) runT38Receiver(ctx context.Context, cs *sipSession.CallSession, sess *udptl.Session, info *sdp.T38Info, sig *net.UDPAddr) {
+	callID := cs.CallID
+	cs.StopMediaPreserveRTP()
+	sess.SetRemoteAddr(faxRemoteAddr(info, sig))
+	if info.Params.UDPEC == sdp.T38ECNone {
+		sess.SetRedundancy(0)
+	}
+	logger.Info("sip fax: t38 receiving",
+		zap.String("call_id", callID),
+		zap.String("remote_udptl", faxRemoteAddr(info, sig).String()),
+		zap.Int("max_bit_rate", info.Params.MaxBitRate),
+	)
+	res := sipfax.Receive(ctx, sess, sipfax.Config{
+		LocalID: strings.TrimSpace(os.Getenv("SIP_FAX_LOCAL_ID")),
+		Version: info.Params.Version,
+	})
+	_ = sess.Close()
+	out := sipSession.FaxResult{
+		Mode:     sipSession.FaxModeT38,
+		Status:   res.Status,
+		Reason:   res.Reason,
+		Pages:    len(res.Pages),
+		RemoteID: res.RemoteID,
+		BitRate:  res.BitRate,
+	}
+	// Record before the upload so a BYE racing it still sees the pages.
+	sipSession.SetFaxResult(callID, out)
+	if len(res.Pages) > 0 {
+		doc, err := sipfax.Save(envelope.Wrap(stores.Default(), cs.TenantID()), callID, res, time.Now())
+		if err != nil {
+			logger.Warn("sip fax: document upload failed", zap.String("call_id", callID), zap.Error(err))
+		} else {
+			out.DocumentURL = doc.URL
+			sipSession.SetFaxResult(callID, out)
+		}
+	}
+	logger.Info("sip fax: session finished",
+		zap.String("call_id", callID),
+		zap.String("status", res.Status),
+		zap.String("reason", res.Reason),
+		zap.Int("pages", len(res.Pages)),
+		zap.String("remote_id", res.RemoteID),
+		zap.String("document", out.DocumentURL),
+	)
+	if ctx.Err() == nil {
+		s.HangupInboundCall(callID)
+	}
+}
+
+// startFaxPassthrough relays the fax audio to SIP_FAX_PASSTHROUGH_URI.
+func (s *SIPServer) startFaxPassthrough(ctx context.Context, cs *sipSession.CallSession) {
+	callID := cs.CallID
+	cs.SetFaxMode(sipSession.FaxModeG711Passthrough)
+	target := strings.TrimSpace(os.Getenv("SIP_FAX_PASSTHROUGH_URI"))
+	if target == "" {
+		sipSession.SetFaxResult(callID, sipSession.FaxResult{
+			Mode:   sipSession.FaxModeG711Passthrough,
+			Status: sipfax.StatusFailed,
+			Reason: "no pass-through target",
+		})
+		logger.Warn("sip fax: G.711 pass-through needs SIP_FAX_PASSTHROUGH_URI; hanging up", zap.String("call_id", callID))
+		s.HangupInboundCall(callID)
+		return
+	}
+	sipSession.SetFaxResult(callID, sipSession.FaxResult{
+		Mode:   sipSession.FaxModeG711Passthrough,
+		Status: "relayed",
+	})
+	if !strings.HasPrefix(target, "<") {
+		target = "<" + target + ">"
+	}
+	var lg *zap.Logger
+	if logger.Lg != nil {
+		lg = logger.Lg.Named("sip-fax")
+	}
+	conversation.TriggerTransferFromReferTo(ctx, callID, target, lg, nil)
+}
+
+// faxRemoteAddr applies the same NAT rule as re-INVITE audio: a private
+// SDP address behind a public signalling address uses the latter.
+func faxRemoteAddr(info *sdp.T38Info, sig *net.UDPAddr) *net.UDPAddr {
+	addr := info.Addr()
+	if addr != nil && sig != nil && isPrivateIPv4(addr.IP) && sig.IP.To4() != nil && !isPrivateIPv4(sig.IP) {
+		addr = &net.UDPAddr{IP: sig.IP, Port: addr.Port}
+	}
+	return addr
+}
+
+// newUDPTLSession binds the UDPTL socket from the RTP port range when
+// one is configured (firewalls already allow it), else ephemeral.
+func newUDPTLSession() (*udptl.Session, error) {
+	start, hasStart := envInt("SIP_RTP_PORT_START")
+	end, hasEnd := envInt("SIP_RTP_PORT_END")
+	if hasStart && hasEnd && start > 0 && end >= start {
+		return bindFromPortRange(start, end, udptl.NewSession)
+	}
+	return udptl.NewSession(0)
+}
+
+// buildUASReInvite builds an in-dialog INVITE on an inbound (UAS) dialog.
+func (s *SIPServer) buildUASReInvite(callID, body string) (*stack.Message, *net.UDPAddr, int, error) {
+	callID = normUASCallID(callID)
+	s.dlgMu.Lock()
+	defer s.dlgMu.Unlock()
+	d := s.uasDlg[callID]
+	if d == nil || d.remote == nil {
+		return nil, nil, 0, fmt.Errorf("sip: no dialog for call-id")
+	}
+	if strings.TrimSpace(d.byeFrom) == "" || strings.TrimSpace(d.byeTo) == "" {
+		return nil, nil, 0, fmt.Errorf("sip: incomplete dialog headers")
+	}
+	cseq := d.nextCSeq
+	d.nextCSeq++
+	msg := &stack.Message{
+		IsRequest:  true,
+		Method:     stack.MethodInvite,
+		RequestURI: d.byeReqURI,
+		Version:    "SIP/2.0",
+	}
+	msg.SetHeader("Via", fmt.Sprintf("SIP/2.0/UDP %s:%d;branch=z9hG4bK%s;rport",
+		strings.TrimSpace(s.localIP), s.listenPort, randomHexBranch()))
+	msg.SetHeader("Max-Forwards", "70")
+	msg.SetHeader("From", d.byeFrom)
+	msg.SetHeader("To", d.byeTo)
+	msg.SetHeader("Call-ID", callID)
+	msg.SetHeader("CSeq", fmt.Sprintf("%d INVITE", cseq))
+	transaction.SetRouteHeaders(msg, d.routeSet)
+	msg.SetHeader("Contact", s.localContact(d.remote))
+	msg.SetHeader("Content-Type", "application/sdp")
+	msg.Body = body
+	msg.SetHeader("Content-Length", strconv.Itoa(stack.BodyBytesLen(body)))
+	return msg, cloneUDP(d.remote), cseq, nil
+}
+
+// sendFaxReInvite sends the T.38 offer, retransmits it over UDP until
+// a response arrives and ACKs the final response. It returns the final
+// response and the peer's signalling address.
+func (s *SIPServer) sendFaxReInvite(ctx context.Context, callID, body string) (*stack.Message, *net.UDPAddr, error) {
+	req, dst, cseq, err := s.buildUASReInvite(callID, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	ri := &faxReInvite{cseq: cseq, dst: dst, resp: make(chan *stack.Message, 1)}
+	s.faxReInvites.Store(callID, ri)
+	// Keep routing for a while after the final response so 2xx
+	// retransmissions are re-ACKed instead of reaching outbound.
+	defer time.AfterFunc(faxReInviteTimeout, func() { s.faxReInvites.CompareAndDelete(callID, ri) })
+
+	if err := s.SendSIP(req, dst); err != nil {
+		return nil, dst, err
+	}
+	timeout := time.NewTimer(faxReInviteTimeout)
+	defer timeout.Stop()
+	interval := faxReInviteT1
+	retransmit := time.NewTimer(interval)
+	defer retransmit.Stop()
+	var resp *stack.Message
+	for resp == nil {
+		select {
+		case resp = <-ri.resp:
+		case <-retransmit.C:
+			if !ri.provisional.Load() {
+				_ = s.SendSIP(req, dst)
+			}
+			if interval *= 2; interval > faxReInviteT2 {
+				interval = faxReInviteT2
+			}
+			retransmit.Reset(interval)
+		case <-timeout.C:
+			return nil, dst, fmt.Errorf("sip: re-INVITE timed out")
+		case <-ctx.Done():
+			return nil, dst, ctx.Err()
+		}
+	}
+	ack := &stack.Message{
+		IsRequest:  true,
+		Method:     stack.MethodAck,
+		RequestURI: req.RequestURI,
+		Version:    "SIP/2.0",
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// 2xx ACK is its own transaction (new branch), sent to the
+		// remote target; non-2xx ACK reuses the INVITE's Via.
+		ack.SetHeader("Via", fmt.Sprintf("SIP/2.0/UDP %s:%d;branch=z9hG4bK%s;rport",
+			strings.TrimSpace(s.localIP), s.listenPort, randomHexBranch()))
+		if uri := requestURIFromContact(resp.GetHeader("Contact")); uri != "" {
+			ack.RequestURI = uri
+		}
+	} else {
+		ack.SetHeader("Via", req.GetHeader("Via"))
+	}
+	ack.SetHeader("Max-Forwards", "70")
+	ack.SetHeader("From", req.GetHeader("From"))
+	if to := resp.GetHeader("To"); to != "" {
+		ack.SetHeader("To", to)
+	} else {
+		ack.SetHeader("To", req.GetHeader("To"))
+	}
+	ack.SetHeader("Call-ID", callID)
+	ack.SetHeader("CSeq", stack.WithCSeqACK(cseq))
+	transaction.SetRouteHeaders(ack, req.GetHeaders("Route"))
+	ack.SetHeader("Content-Length", "0")
+	ri.ack.Store(ack)
+	_ = s.SendSIP(ack, dst)
+	return resp, dst, nil
+}
+
+// handleFaxReInviteResponse claims responses to our T.38 re-INVITE.
+// Everything else falls through to the UAC (outbound) handler.
+func (s *SIPServer) handleFaxReInviteResponse(resp *stack.Message) bool {
+	if s == nil || resp == nil || resp.IsRequest {
+		return false
+	}
+	v, ok := s.faxReInvites.Load(strings.TrimSpace(resp.GetHeader("Call-ID")))
+	if !ok {
+		return false
+	}
+	ri := v.(*faxReInvite)
+	cseq := resp.GetHeader("CSeq")
+	if stack.ParseCSeqNum(cseq) != ri.cseq || !strings.Contains(strings.ToUpper(cseq), stack.MethodInvite) {
+		return false
+	}
+	if resp.StatusCode < 200 {
+		ri.provisional.Store(true)
+		return true
+	}
+	if ri.final.CompareAndSwap(false, true) {
+		ri.resp <- resp
+	} else if ack := ri.ack.Load(); ack != nil {
+		// Retransmitted final response: our ACK was lost.
+		_ = s.SendSIP(ack, ri.dst)
+	}
+	return true
+}
+
+// answerT38ReInvite accepts a peer's switch to T.38 (re-INVITE with
+// m=image udptl t38) and starts the receiver.
+func (s *SIPServer) answerT38ReInvite(msg *stack.Message, addr *net.UDPAddr, cs *sipSession.CallSession, offer *sdp.T38Info, toWithTag string) *stack.Message {
+	callID := cs.CallID
+	if mode := conversation.ResolveFaxMode(callID); mode == sipfax.ModeOff || mode == sipfax.ModePassthrough {
+		return s.makeResponse(msg, 488, "Not Acceptable Here", "", toWithTag)
+	}
+	if cs.FaxMode() == sipSession.FaxModeT38Pending {
+		// Glare with our own T.38 re-INVITE (RFC 3261 §14.2).
+		return s.makeResponse(msg, 491, "Request Pending", "", toWithTag)
+	}
+	ctx, ok := cs.BeginFax(sipSession.FaxModeT38)
+	if !ok {
+		return s.makeResponse(msg, 488, "Not Acceptable Here", "", toWithTag)
+	}
+	cs.DisableJitterPLC()
+	sess, err := newUDPTLSession()
+	if err != nil {
+		sipSession.SetFaxResult(callID, sipSession.FaxResult{Mode: sipSession.FaxModeT38, Status: sipfax.StatusFailed, Reason: "udptl bind failed"})
+		return s.makeResponse(msg, 500, "Internal Server Error", "", toWithTag)
+	}
+	ans := sdp.AnswerT38(offer.Params)
+	body := sdp.GenerateT38(s.localIP, sess.LocalAddr().Port, ans)
+	resp := s.makeResponse(msg, 200, "OK", body, toWithTag)
+	resp.SetHeader("Content-Type", "application/sdp")
+	resp.SetHeader("To", toWithTag)
+	resp.SetHeader("Contact", s.localContact(addr))
+	resp.SetHeader("Content-Length", strconv.Itoa(stack.BodyBytesLen(body)))
+	info := &sdp.T38Info{IP: offer.IP, Port: offer.Port, Params: ans}
+	logger.Info("sip fax: peer switched to t38", zap.String("call_id", callID))
+	go s.runT38Receiver(ctx, cs, sess, info, cloneUDP(addr))
+	return resp
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/sdp"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestBuildUASReInviteUsesDialogState(t *testing.T) {
+	s := &SIPServer{localIP: "203.0.113.5", listenPort: 5060, uasDlg: map[string]*uasDialogState{}}
+	s.uasDlg["c1"] = &uasDialogState{
+		remote:    &net.UDPAddr{IP: net.IPv4(198, 51, 100, 7), Port: 5070},
+		byeFrom:   "<sip:100@203.0.113.5>;tag=ours",
+		byeTo:     "<sip:caller@198.51.100.7>;tag=theirs",
+		byeReqURI: "sip:caller@198.51.100.7:5070",
+		nextCSeq:  2,
+	}
+	body := sdp.GenerateT38(s.localIP, 40010, sdp.DefaultT38Params())
+	msg, dst, cseq, err := s.buildUASReInvite("c1", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cseq != 2 || msg.GetHeader("CSeq") != "2 INVITE" || s.uasDlg["c1"].nextCSeq != 3 {
+		t.Fatalf("cseq=%d header=%q next=%d", cseq, msg.GetHeader("CSeq"), s.uasDlg["c1"].nextCSeq)
+	}
+	if msg.Method != stack.MethodInvite || msg.RequestURI != "sip:caller@198.51.100.7:5070" {
+		t.Fatalf("request line %s %s", msg.Method, msg.RequestURI)
+	}
+	if msg.GetHeader("From") != "<sip:100@203.0.113.5>;tag=ours" || msg.GetHeader("To") != "<sip:caller@198.51.100.7>;tag=theirs" {
+		t.Fatalf("from/to %q %q", msg.GetHeader("From"), msg.GetHeader("To"))
+	}
+	if dst.Port != 5070 {
+		t.Fatalf("dst %v", dst)
+	}
+	if info, ok := sdp.ParseT38(msg.Body); !ok || info.Port != 40010 {
+		t.Fatalf("body not a T.38 offer: %q", msg.Body)
+	}
+	if _, _, _, err := s.buildUASReInvite("unknown", body); err == nil {
+		t.Fatal("expected error for unknown dialog")
+	}
+}
+
+func TestHandleFaxReInviteResponseRouting(t *testing.T) {
+	s := &SIPServer{}
+	ri := &faxReInvite{cseq: 7, resp: make(chan *stack.Message, 1)}
+	s.faxReInvites.Store("c1", ri)
+	mk := func(callID, cseq string, code int) *stack.Message {
+		m := &stack.Message{StatusCode: code}
+		m.SetHeader("Call-ID", callID)
+		m.SetHeader("CSeq", cseq)
+		return m
+	}
+	if s.handleFaxReInviteResponse(mk("other", "7 INVITE", 200)) {
+		t.Fatal("claimed another call's response")
+	}
+	if s.handleFaxReInviteResponse(mk("c1", "8 BYE", 200)) {
+		t.Fatal("claimed a response to a different request")
+	}
+	if !s.handleFaxReInviteResponse(mk("c1", "7 INVITE", 100)) || !ri.provisional.Load() {
+		t.Fatal("provisional not recorded")
+	}
+	if !s.handleFaxReInviteResponse(mk("c1", "7 INVITE", 200)) {
+		t.Fatal("final not claimed")
+	}
+	if got := <-ri.resp; got.StatusCode != 200 {
+		t.Fatalf("final %d", got.StatusCode)
+	}
+	// A retransmitted 2xx is absorbed (re-ACKed), not queued again.
+	if !s.handleFaxReInviteResponse(mk("c1", "7 INVITE", 200)) || len(ri.resp) != 0 {
+		t.Fatal("retransmitted final not absorbed")
+	}
+}
+
+func TestFaxRemoteAddrNAT(t *testing.T) {
+	info := &sdp.T38Info{IP: "192.168.1.10", Port: 4000}
+	sig := &net.UDPAddr{IP: net.IPv4(198, 51, 100, 7), Port: 5060}
+	if got := faxRemoteAddr(info, sig); !got.IP.Equal(sig.IP) || got.Port != 4000 {
+		t.Fatalf("got %v", got)
+	}
+	info.IP = "198.51.100.9"
+	if got := faxRemoteAddr(info, sig); !strings.HasPrefix(got.String(), "198.51.100.9:") {
+		t.Fatalf("got %v", got)
+	}
+}
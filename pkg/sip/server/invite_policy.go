@@ -5,6 +5,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
 	"golang.org/x/time/rate"
 )
 
@@ -83,3 +84,26 @@ func (s *inviteRateState) allow(ip net.IP, perSec float64, burst int) bool {
 	}
 	return lim.Allow()
 }
+
+// screenRequest applies the out-of-dialog request policy shared by INVITE
+// and MESSAGE: source allowlist (SIP_INVITE_ALLOW_CIDRS), per-IP rate
+// limit and, when SIP_DIGEST_* is set, digest authentication. It returns
+// the rejection or challenge to send, or nil when msg may proceed.
+func (s *SIPServer) screenRequest(msg *stack.Message, addr *net.UDPAddr) *stack.Message {
+	if addr != nil && addr.IP != nil {
+		if !ipAllowed(s.inviteAllowNets, addr.IP) {
+			return s.makeResponse(msg, 403, "Forbidden", "", "")
+		}
+		if !s.inviteRate.allow(addr.IP, s.inviteRatePerSec, s.inviteBurst) {
+			return s.makeResponse(msg, 503, "Service Unavailable", "", "")
+		}
+	}
+	if s.inviteDigest != nil && !s.inviteDigest.verifyINVITE(msg) {
+		resp, err := s.inviteDigest.challenge401(msg)
+		if err != nil || resp == nil {
+			return s.makeResponse(msg, 500, "Internal Server Error", "", "")
+		}
+		return resp
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net"
+	"strings"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"go.uber.org/zap"
+)
+
+// Page-mode instant messages (RFC 3428 MESSAGE).
+//
+// The server only validates the payload (text/plain, or message/cpim
+// wrapping text/plain per RFC 3862) and hands it to the MessageHandler
+// installed by the application, which decides between user-to-user
+// relay, store-and-forward and the tenant chatbot. Requests are screened
+// like INVITE first (allowlist, rate limit, digest); empty bodies that
+// pass stay a 200 OK keep-alive.
+
+// messageAccept is the Accept header on 415 responses.
+const messageAccept = "text/plain, message/cpim"
+
+// InboundMessage is one MESSAGE accepted for routing.
+type InboundMessage struct {
+	CallID   string
+	FromUser string
+	FromHost string
+	// FromURI is the From addr-spec (reply target for trunk senders).
+	FromURI string
+	// ToUser / ToHost come from the Request-URI.
+	ToUser string
+	ToHost string
+	// ContentType / Body are the original payload (relayed unchanged);
+	// Text is the text/plain content with any CPIM wrapper removed.
+	ContentType string
+	Body        string
+	Text        string
+	Remote      *net.UDPAddr
+	// DID is the called-party binding when the target is a tenant trunk
+	// number (TenantID 0 otherwise).
+	DID InboundDIDBinding
+}
+
+// MessageHandler routes an inbound MESSAGE and returns the final status
+// (typically 202 Accepted; 404 / 480 when there is nowhere to deliver).
+// It runs on the signaling goroutine and must not block on delivery.
+type MessageHandler func(ctx context.Context, m InboundMessage) (status int, reason string)
+
+// SetMessageHandler wires MESSAGE routing; nil answers 501 to non-empty MESSAGEs.
+func (s *SIPServer) SetMessageHandler(fn MessageHandler) {
+	if s == nil {
+		return
+	}
+	s.msgMu.Lock()
+	defer s.msgMu.Unlock()
+	s.messageHandler = fn
+}
+
+// SetRegisterObserver is called (off the signaling goroutine) after a
+// REGISTER binding is saved, e.g. to flush stored messages for that AOR.
+func (s *SIPServer) SetRegisterObserver(fn func(user, domain string)) {
+	if s == nil {
+		return
+	}
+	s.msgMu.Lock()
+	defer s.msgMu.Unlock()
+	s.registerObserver = fn
+}
+
+func (s *SIPServer) notifyRegistered(user, domain string) {
+	s.msgMu.RLock()
+	fn := s.registerObserver
+	s.msgMu.RUnlock()
+	if fn != nil {
+		go fn(user, domain)
+	}
+}
+
+func (s *SIPServer) handleMessage(msg *stack.Message, addr *net.UDPAddr) *stack.Message {
+	if s == nil || msg == nil {
+		return nil
+	}
+	if s.absorbNonInviteRetransmit(msg, addr) {
+		return nil
+	}
+	// MESSAGE reaches user queues and the tenant chatbot, so it passes the
+	// same source / rate / digest screen as INVITE.
+	if resp := s.screenRequest(msg, addr); resp != nil {
+		resp.SetHeader("Content-Length", "0")
+		return resp
+	}
+	reply := func(code int, text string) *stack.Message {
+		resp := s.makeResponse(msg, code, text, "", "")
+		if code == 415 {
+			resp.SetHeader("Accept", messageAccept)
+		}
+		resp.SetHeader("Content-Length", "0")
+		return resp
+	}
+	if strings.TrimSpace(msg.Body) == "" {
+		return reply(200, "OK")
+	}
+	ct := strings.TrimSpace(msg.GetHeader("Content-Type"))
+	text, err := messageText(ct, msg.Body)
+	if errors.Is(err, errMessageMediaType) {
+		return reply(415, "Unsupported Media Type")
+	}
+	if err != nil {
+		return reply(400, "Bad Request")
+	}
+	s.msgMu.RLock()
+	fn := s.messageHandler
+	s.msgMu.RUnlock()
+	if fn == nil {
+		return reply(501, "Not Implemented")
+	}
+	in := InboundMessage{
+		CallID:      strings.TrimSpace(msg.GetHeader("Call-ID")),
+		FromURI:     stripAngle(extractSIPAddrSpec(msg.GetHeader("From"))),
+		ContentType: ct,
+		Body:        msg.Body,
+		Text:        text,
+		Remote:      cloneUDPAddr(addr),
+		DID:         s.resolveInboundDIDBinding(msg),
+	}
+	in.FromUser, in.FromHost, _ = parseURIUserHost(msg.GetHeader("From"))
+	if u, h, ok := parseURIUserHost(msg.RequestURI); ok {
+		in.ToUser, in.ToHost = u, h
+	} else {
+		in.ToUser, in.ToHost, _ = parseURIUserHost(msg.GetHeader("To"))
+	}
+	code, text := fn(context.Background(), in)
+	if code < 200 || code > 699 {
+		code, text = 500, "Server Internal Error"
+	}
+	if text == "" {
+		text = messageStatusText(code)
+	}
+	logger.Info("sip message routed",
+		zap.String("call_id", in.CallID),
+		zap.String("from", in.FromUser),
+		zap.String("to", in.ToUser),
+		zap.Uint("tenant_id", in.DID.TenantID),
+		zap.Int("status", code))
+	return reply(code, text)
+}
+
+func messageStatusText(code int) string {
+	switch code {
+	case 200:
+		return "OK"
+	case 202:
+		return "Accepted"
+	case 404:
+		return "Not Found"
+	case 480:
+		return "Temporarily Unavailable"
+	case 488:
+		return "Not Acceptable Here"
+	}
+	return "Error"
+}
+
+var errMessageMediaType = errors.New("sip message: unsupported content type")
+
+// messageText returns the text/plain payload of a MESSAGE body. A
+// missing Content-Type is treated as text/plain (RFC 3261 §20.15 leaves
+// it to the UAS; softphones routinely omit it).
+func messageText(contentType, body string) (string, error) {
+	mt := "text/plain"
+	if strings.TrimSpace(contentType) != "" {
+		t, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return "", errMessageMediaType
+		}
+		mt = t
+	}
+	switch mt {
+	case "text/plain":
+		return body, nil
+	case "message/cpim":
+		c, err := parseCPIM(body)
+		if err != nil {
+			return "", err
+		}
+		inner, _, err := mime.ParseMediaType(nonEmptyStr(c.ContentType, "text/plain"))
+		if err != nil || inner != "text/plain" {
+			return "", errMessageMediaType
+		}
+		return c.Body, nil
+	}
+	return "", errMessageMediaType
+}
+
+// cpimMessage is an RFC 3862 Common Presence and Instant Messaging
+// envelope: message headers, a blank line, MIME headers, a blank line,
+// then the content.
+type cpimMessage struct {
+	From        string
+	To          string
+	DateTime    string
+	ContentType string
+	Body        string
+}
+
+var errBadCPIM = errors.New("sip message: malformed message/cpim")
+
+func parseCPIM(raw string) (cpimMessage, error) {
+	var c cpimMessage
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	head, rest, ok := strings.Cut(raw, "\n\n")
+	if !ok {
+		return c, errBadCPIM
+	}
+	for _, line := range strings.Split(head, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return c, errBadCPIM
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "from":
+			c.From = value
+		case "to":
+			c.To = value
+		case "datetime":
+			c.DateTime = value
+		}
+	}
+	mimeHead, body, ok := strings.Cut(rest, "\n\n")
+	if !ok {
+		return c, errBadCPIM
+	}
+	for _, line := range strings.Split(mimeHead, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "content-type") {
+			c.ContentType = strings.TrimSpace(value)
+		}
+	}
+	c.Body = body
+	return c, nil
+}
+
+func nonEmptyStr(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}
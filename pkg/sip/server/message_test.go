@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestMessageText(t *testing.T) {
+	cpim := "From: <sip:alice@example.com>\r\nTo: <sip:bob@example.com>\r\nDateTime: 2026-01-02T03:04:05Z\r\n\r\n" +
+		"Content-Type: text/plain;charset=utf-8\r\n\r\nhello bob"
+	cases := []struct {
+		ct, body, want string
+		err            bool
+	}{
+		{"", "hi", "hi", false},
+		{"text/plain;charset=UTF-8", "hi", "hi", false},
+		{"message/cpim", cpim, "hello bob", false},
+		{"message/cpim", "garbage", "", true},
+		{"application/im-iscomposing+xml", "<x/>", "", true},
+	}
+	for _, tc := range cases {
+		got, err := messageText(tc.ct, tc.body)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("messageText(%q) = (%q, %v)", tc.ct, got, err)
+		}
+	}
+	c, err := parseCPIM(cpim)
+	if err != nil || c.From != "<sip:alice@example.com>" || c.DateTime == "" {
+		t.Fatalf("parseCPIM = %+v, %v", c, err)
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	if err := logger.Init(&logger.LogConfig{Level: "error", Filename: t.TempDir() + "/test.log", MaxSize: 1}, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	s := &SIPServer{}
+	addr := &net.UDPAddr{IP: net.ParseIP("192.0.2.7"), Port: 5060}
+	newMsg := func(ct, body string) *stack.Message {
+		m := &stack.Message{IsRequest: true, Method: stack.MethodMessage, RequestURI: "sip:1002@pbx.example", Version: "SIP/2.0", Body: body}
+		m.SetHeader("Via", "SIP/2.0/UDP 192.0.2.7:5060;branch=z9hG4bKm1")
+		m.SetHeader("From", "<sip:1001@pbx.example>;tag=a")
+		m.SetHeader("To", "<sip:1002@pbx.example>")
+		m.SetHeader("Call-ID", "msg-1")
+		m.SetHeader("CSeq", "1 MESSAGE")
+		if ct != "" {
+			m.SetHeader("Content-Type", ct)
+		}
+		return m
+	}
+
+	if resp := s.handleMessage(newMsg("", ""), addr); resp.StatusCode != 200 {
+		t.Fatalf("empty body: %d", resp.StatusCode)
+	}
+	if resp := s.handleMessage(newMsg("text/plain", "hi"), addr); resp.StatusCode != 501 {
+		t.Fatalf("no handler: %d", resp.StatusCode)
+	}
+	resp := s.handleMessage(newMsg("text/html", "<b>hi</b>"), addr)
+	if resp.StatusCode != 415 || resp.GetHeader("Accept") != messageAccept {
+		t.Fatalf("html: %d accept=%q", resp.StatusCode, resp.GetHeader("Accept"))
+	}
+
+	var got InboundMessage
+	s.SetMessageHandler(func(_ context.Context, m InboundMessage) (int, string) {
+		got = m
+		return 202, ""
+	})
+	resp = s.handleMessage(newMsg("text/plain", "hi"), addr)
+	if resp.StatusCode != 202 || resp.StatusText != "Accepted" {
+		t.Fatalf("routed: %d %q", resp.StatusCode, resp.StatusText)
+	}
+	if got.FromUser != "1001" || got.ToUser != "1002" || got.ToHost != "pbx.example" || got.Text != "hi" || got.CallID != "msg-1" {
+		t.Fatalf("inbound %+v", got)
+	}
+}
+
+func TestHandleMessage_Screening(t *testing.T) {
+	if err := logger.Init(&logger.LogConfig{Level: "error", Filename: t.TempDir() + "/test.log", MaxSize: 1}, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	handler := func(_ context.Context, _ InboundMessage) (int, string) {
+		calls++
+		return 202, ""
+	}
+	newMsg := func(n int) *stack.Message {
+		m := &stack.Message{IsRequest: true, Method: stack.MethodMessage, RequestURI: "sip:1002@pbx.example", Version: "SIP/2.0", Body: "hi"}
+		m.SetHeader("Via", fmt.Sprintf("SIP/2.0/UDP 192.0.2.7:5060;branch=z9hG4bKs%d", n))
+		m.SetHeader("From", "<sip:1001@pbx.example>;tag=a")
+		m.SetHeader("To", "<sip:1002@pbx.example>")
+		m.SetHeader("Call-ID", fmt.Sprintf("screen-%d", n))
+		m.SetHeader("CSeq", "1 MESSAGE")
+		m.SetHeader("Content-Type", "text/plain")
+		return m
+	}
+	addr := &net.UDPAddr{IP: net.ParseIP("192.0.2.7"), Port: 5060}
+
+	// Digest configured: no credentials gets a challenge, not the handler.
+	s := &SIPServer{inviteDigest: newSIPDigest("pbx.example", "trunk", "secret")}
+	s.SetMessageHandler(handler)
+	resp := s.handleMessage(newMsg(1), addr)
+	if resp == nil || resp.StatusCode != 401 || resp.GetHeader("WWW-Authenticate") == "" {
+		t.Fatalf("unauthenticated: %+v", resp)
+	}
+	forged := newMsg(2)
+	forged.SetHeader("Authorization", `Digest username="trunk", realm="pbx.example", nonce="made-up", uri="sip:1002@pbx.example", response="00"`)
+	if resp := s.handleMessage(forged, addr); resp == nil || resp.StatusCode != 401 {
+		t.Fatalf("forged credentials: %+v", resp)
+	}
+
+	// Source outside SIP_INVITE_ALLOW_CIDRS.
+	s = &SIPServer{inviteAllowNets: parseIPCIDRList("10.0.0.0/8")}
+	s.SetMessageHandler(handler)
+	if resp := s.handleMessage(newMsg(3), addr); resp == nil || resp.StatusCode != 403 {
+		t.Fatalf("disallowed source: %+v", resp)
+	}
+
+	// Per-IP rate limit shared with INVITE.
+	s = &SIPServer{inviteRatePerSec: 0.001, inviteBurst: 1}
+	s.SetMessageHandler(handler)
+	if resp := s.handleMessage(newMsg(4), addr); resp == nil || resp.StatusCode != 202 {
+		t.Fatalf("first message: %+v", resp)
+	}
+	if resp := s.handleMessage(newMsg(5), addr); resp == nil || resp.StatusCode != 503 {
+		t.Fatalf("over rate: %+v", resp)
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+}
@@ -11,16 +11,26 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
 )
 
-// Minimal in-memory presence broker: SUBSCRIBE (Event: presence) + PUBLISH (application/pidf+xml)
-// with NOTIFY fan-out. Suitable for single-process / lab deployments.
+// Minimal in-memory presence broker: SUBSCRIBE (Event: presence | dialog) + PUBLISH
+// (application/pidf+xml) with NOTIFY fan-out. Suitable for single-process / lab deployments.
+// Agent presentities additionally get server-generated state (blf.go).
+
+const (
+	eventPresence = "presence"
+	eventDialog   = "dialog"
+)
 
 type presenceSub struct {
 	presentity string
+	user       string // presentity user part (BLF key)
+	event      string // eventPresence | eventDialog
 	remote     *net.UDPAddr
 	req        *stack.Message // SUBSCRIBE for header echo
+	localTag   string         // our To tag on the 200 / From tag on NOTIFYs
 	expiresSec int
 	deadline   time.Time
 	notifyCSeq int
+	version    int // RFC 4235 dialog-info version, per subscription
 }
 
 type presenceBroker struct {
@@ -67,6 +77,18 @@ func parseSubscribeExpires(msg *stack.Message) int {
 	return 3600
 }
 
+// subscribeEvent returns the event package token of an Event header ("" → presence).
+func subscribeEvent(raw string) string {
+	ev := strings.ToLower(strings.TrimSpace(raw))
+	if i := strings.IndexByte(ev, ';'); i >= 0 {
+		ev = strings.TrimSpace(ev[:i])
+	}
+	if ev == "" {
+		return eventPresence
+	}
+	return ev
+}
+
 func (s *SIPServer) handleSubscribe(msg *stack.Message, addr *net.UDPAddr) *stack.Message {
 	if s == nil || msg == nil {
 		return nil
@@ -74,12 +96,10 @@ func (s *SIPServer) handleSubscribe(msg *stack.Message, addr *net.UDPAddr) *stac
 	if s.absorbNonInviteRetransmit(msg, addr) {
 		return nil
 	}
-	ev := strings.ToLower(strings.TrimSpace(msg.GetHeader("Event")))
-	if ev == "" {
-		ev = "presence"
-	}
-	if !strings.HasPrefix(ev, "presence") {
+	ev := subscribeEvent(msg.GetHeader("Event"))
+	if ev != eventPresence && ev != eventDialog {
 		resp := s.makeResponse(msg, 489, "Bad Event", "", "")
+		resp.SetHeader("Allow-Events", eventPresence+", "+eventDialog)
 		resp.SetHeader("Content-Length", "0")
 		return resp
 	}
@@ -87,10 +107,11 @@ func (s *SIPServer) handleSubscribe(msg *stack.Message, addr *net.UDPAddr) *stac
 	if key == "" {
 		return s.makeResponse(msg, 400, "Bad Request", "", "")
 	}
+	callID := strings.TrimSpace(msg.GetHeader("Call-ID"))
 	exp := parseSubscribeExpires(msg)
 	if exp == 0 {
 		globalPresence.mu.Lock()
-		globalPresence.pruneSubByCallID(strings.TrimSpace(msg.GetHeader("Call-ID")))
+		globalPresence.pruneSubByCallID(callID)
 		globalPresence.mu.Unlock()
 		r := s.makeResponse(msg, 200, "OK", "", "")
 		r.SetHeader("Expires", "0")
@@ -103,27 +124,71 @@ func (s *SIPServer) handleSubscribe(msg *stack.Message, addr *net.UDPAddr) *stac
 	if nC < 1 {
 		nC = 1
 	}
+	user, _, _ := parseURIUserHost(key)
 	globalPresence.mu.Lock()
-	globalPresence.subs = append(globalPresence.subs, &presenceSub{
-		presentity: key,
-		remote:     cloneUDPAddr(addr),
-		req:        cloneMsgShallow(msg),
-		expiresSec: exp,
-		deadline:   time.Now().Add(time.Duration(exp) * time.Second),
-		notifyCSeq: nC + 1,
-	})
-	body := globalPresence.publish[key]
+	// A refresh (same Call-ID) extends the existing subscription.
+	sub := globalPresence.subByCallID(callID)
+	if sub == nil {
+		sub = &presenceSub{
+			presentity: key,
+			user:       user,
+			event:      ev,
+			localTag:   newTag(),
+			notifyCSeq: nC + 1,
+		}
+		globalPresence.subs = append(globalPresence.subs, sub)
+	}
+	sub.remote = cloneUDPAddr(addr)
+	sub.req = cloneMsgShallow(msg)
+	sub.expiresSec = exp
+	sub.deadline = time.Now().Add(time.Duration(exp) * time.Second)
+	localTag := sub.localTag
+	body := ""
+	if ev == eventPresence {
+		body = globalPresence.publish[key]
+	}
 	globalPresence.mu.Unlock()
 
-	r := s.makeResponse(msg, 200, "OK", body, "")
+	r := s.makeResponse(msg, 200, "OK", body, withTag(msg.GetHeader("To"), localTag))
+	r.SetHeader("Contact", "<sip:"+strings.TrimSpace(s.localIP)+":"+strconv.Itoa(s.listenPort)+">")
 	r.SetHeader("Expires", strconv.Itoa(exp))
 	r.SetHeader("Subscription-State", "active;expires="+strconv.Itoa(exp))
 	if strings.TrimSpace(body) != "" {
-		r.SetHeader("Content-Type", "application/pidf+xml")
+		r.SetHeader("Content-Type", contentTypePIDF)
 	}
+	// RFC 6665 §4.2.1.2: the initial NOTIFY carries the current state.
+	go s.notifyInitial(callID, user)
 	return r
 }
 
+// notifyInitial sends the generated state to a new or refreshed
+// subscription. Dialog subscriptions always get a (possibly empty)
+// full document; presence falls back to the 200 OK PUBLISH body.
+func (s *SIPServer) notifyInitial(callID, user string) {
+	p, ok := s.lookupPresence(user)
+	if !ok {
+		p = AgentPresence{User: user}
+	}
+	globalPresence.fanout(s, func(sub *presenceSub) (string, string, bool) {
+		if strings.TrimSpace(sub.req.GetHeader("Call-ID")) != callID {
+			return "", "", false
+		}
+		if !ok && sub.event != eventDialog {
+			return "", "", false
+		}
+		return sub.render(p)
+	})
+}
+
+// withTag appends ;tag= to a To header that has none.
+func withTag(to, tag string) string {
+	to = strings.TrimSpace(to)
+	if to == "" || tag == "" || strings.Contains(strings.ToLower(to), ";tag=") {
+		return to
+	}
+	return to + ";tag=" + tag
+}
+
 func (s *SIPServer) handleNotifyPresence(msg *stack.Message, addr *net.UDPAddr) *stack.Message {
 	if s == nil || msg == nil {
 		return nil
@@ -154,14 +219,34 @@ func (b *presenceBroker) pruneSubByCallID(callID string) {
 	b.subs = out
 }
 
-func (b *presenceBroker) notifyPresentity(srv *SIPServer, presentity string, body string, contentType string) {
-	if b == nil || srv == nil || srv.ep == nil {
-		return
+func (b *presenceBroker) subByCallID(callID string) *presenceSub {
+	if callID == "" {
+		return nil
 	}
+	for _, sub := range b.subs {
+		if sub != nil && sub.req != nil && strings.TrimSpace(sub.req.GetHeader("Call-ID")) == callID {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (b *presenceBroker) notifyPresentity(srv *SIPServer, presentity string, body string, contentType string) {
 	key := strings.ToLower(strings.TrimSpace(presentity))
 	if key == "" {
 		return
 	}
+	b.fanout(srv, func(sub *presenceSub) (string, string, bool) {
+		return body, contentType, sub.presentity == key && sub.event == eventPresence
+	})
+}
+
+// fanout sends a NOTIFY to every live subscription for which render
+// returns ok; expired subscriptions are dropped. render runs under b.mu.
+func (b *presenceBroker) fanout(srv *SIPServer, render func(sub *presenceSub) (body, contentType string, ok bool)) {
+	if b == nil || srv == nil || srv.ep == nil {
+		return
+	}
 	type sendPair struct {
 		m    *stack.Message
 		addr *net.UDPAddr
@@ -169,14 +254,14 @@ func (b *presenceBroker) notifyPresentity(srv *SIPServer, presentity string, bod
 	var batch []sendPair
 	b.mu.Lock()
 	now := time.Now()
+	live := b.subs[:0]
 	for _, sub := range b.subs {
-		if sub == nil || sub.remote == nil || sub.req == nil {
+		if sub == nil || sub.remote == nil || sub.req == nil || now.After(sub.deadline) {
 			continue
 		}
-		if sub.presentity != key {
-			continue
-		}
-		if now.After(sub.deadline) {
+		live = append(live, sub)
+		body, ct, ok := render(sub)
+		if !ok {
 			continue
 		}
 		cseq := sub.notifyCSeq
@@ -184,11 +269,15 @@ func (b *presenceBroker) notifyPresentity(srv *SIPServer, presentity string, bod
 			cseq = 2
 		}
 		sub.notifyCSeq = cseq + 1
-		n := srv.buildNotifyForSubscription(sub, cseq, body, contentType)
+		n := srv.buildNotifyForSubscription(sub, cseq, body, ct)
 		if n != nil {
 			batch = append(batch, sendPair{m: n, addr: cloneUDPAddr(sub.remote)})
 		}
 	}
+	for i := len(live); i < len(b.subs); i++ {
+		b.subs[i] = nil
+	}
+	b.subs = live
 	b.mu.Unlock()
 	for _, p := range batch {
 		_ = srv.ep.Send(p.m, p.addr)
@@ -203,9 +292,12 @@ func (s *SIPServer) buildNotifyForSubscription(sub *presenceSub, cseq int, body
 	n := &stack.Message{
 		IsRequest:  true,
 		Method:     stack.MethodNotify,
-		RequestURI: strings.TrimSpace(req.RequestURI),
+		RequestURI: strings.TrimSpace(stripAngle(extractSIPAddrSpec(req.GetHeader("Contact")))),
 		Version:    "SIP/2.0",
 	}
+	if n.RequestURI == "" {
+		n.RequestURI = strings.TrimSpace(req.RequestURI)
+	}
 	if n.RequestURI == "" {
 		n.RequestURI = "sip:presence"
 	}
@@ -213,19 +305,20 @@ func (s *SIPServer) buildNotifyForSubscription(sub *presenceSub, cseq int, body
 	via := "SIP/2.0/UDP " + strings.TrimSpace(s.localIP) + ":" + strconv.Itoa(s.listenPort) + ";branch=z9hG4bK" + branch + ";rport"
 	n.SetHeader("Via", via)
 	n.SetHeader("Max-Forwards", "70")
-	n.SetHeader("From", req.GetHeader("To"))
+	n.SetHeader("From", withTag(req.GetHeader("To"), sub.localTag))
 	n.SetHeader("To", req.GetHeader("From"))
 	n.SetHeader("Call-ID", req.GetHeader("Call-ID"))
-	exp := sub.expiresSec
+	n.SetHeader("Contact", "<sip:"+strings.TrimSpace(s.localIP)+":"+strconv.Itoa(s.listenPort)+">")
+	exp := int(time.Until(sub.deadline).Seconds())
 	if exp <= 0 {
-		exp = 3600
+		exp = 1
 	}
 	n.SetHeader("CSeq", fmt.Sprintf("%d NOTIFY", cseq))
-	n.SetHeader("Event", "presence")
+	n.SetHeader("Event", nonEmptyStr(sub.event, eventPresence))
 	n.SetHeader("Subscription-State", "active;expires="+strconv.Itoa(exp))
 	if strings.TrimSpace(body) != "" {
 		if contentType == "" {
-			contentType = "application/pidf+xml"
+			contentType = contentTypePIDF
 		}
 		n.SetHeader("Content-Type", contentType)
 		n.Body = body
@@ -7,13 +7,17 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/LinByte/VoiceServer/pkg/config"
 	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/LinByte/VoiceServer/pkg/sip/transaction"
 	"go.uber.org/zap"
 )
 
@@ -107,9 +111,14 @@ func registrationKey(user, host string) string {
 }
 
 // parseContactUDPAddr prefers host:port from Contact; falls back to src.
+// A ;transport=ws|wss Contact (RFC 7118 §5.2, usually an .invalid host)
+// is only reachable over the flow it registered on, so src is used.
 func parseContactUDPAddr(contact string, src *net.UDPAddr) *net.UDPAddr {
 	c := extractSIPAddrSpec(contact)
 	c = stripAngle(c)
+	if contactIsWebSocket(c) && src != nil {
+		return src
+	}
 	if !strings.HasPrefix(strings.ToLower(c), "sip:") {
 		if src != nil {
 			return src
@@ -151,20 +160,52 @@ func parseContactUDPAddr(contact string, src *net.UDPAddr) *net.UDPAddr {
 	if err != nil || port <= 0 {
 		port = 6050
 	}
-	ip := net.ParseIP(host)
+	ip := lookupHopIP(host, port)
 	if ip == nil {
-		ips, err := net.LookupIP(host)
-		if err != nil || len(ips) == 0 {
-			if src != nil {
-				return src
-			}
-			return nil
+		if src != nil {
+			return src
 		}
-		ip = ips[0]
+		return nil
 	}
 	return &net.UDPAddr{IP: ip, Port: port}
 }
 
+// hopLocator resolves Contact / Path host names when a binding is
+// stored. Lookups are cached for their DNS TTL and bounded by
+// hopLookupTimeout so a slow resolver cannot stall the signaling
+// goroutine handling REGISTER. Variables so tests can swap them.
+var (
+	hopLocator       = outbound.NewLocator(nil)
+	hopLookupTimeout = 2 * time.Second
+)
+
+// lookupHopIP returns host's first address (host itself when it is an
+// IP literal), or nil when it does not resolve in time.
+func lookupHopIP(host string, port int) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hopLookupTimeout)
+	defer cancel()
+	hops, err := hopLocator.Resolve(ctx, outbound.DialTarget{SignalingAddr: net.JoinHostPort(host, strconv.Itoa(port))})
+	if err != nil || len(hops) == 0 {
+		return nil
+	}
+	return hops[0].Addr.IP
+}
+
+// contactIsWebSocket reports whether a Contact URI carries ;transport=ws or wss.
+func contactIsWebSocket(uri string) bool {
+	for _, p := range strings.Split(uri, ";")[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.EqualFold(k, "transport") {
+			v = strings.ToLower(strings.TrimSpace(v))
+			return v == "ws" || v == "wss"
+		}
+	}
+	return false
+}
+
 func parseExpiresRegister(msg *stack.Message) (seconds int, ok bool) {
 	if msg == nil {
 		return 0, false
@@ -211,7 +252,15 @@ func (s *SIPServer) upsertRegistration(msg *stack.Message, src *net.UDPAddr) {
 	key := registrationKey(user, host)
 	sec, _ := parseExpiresRegister(msg)
 	contact := strings.TrimSpace(msg.GetHeader("Contact"))
-	dst := parseContactUDPAddr(contact, src)
+	// RFC 3327: behind an edge proxy the Contact is only reachable
+	// through the Path, so terminating requests go to its first hop.
+	path := transaction.SplitRouteValues(msg.GetHeaders("Path"))
+	var dst *net.UDPAddr
+	if len(path) > 0 {
+		dst = routeHopAddr(path[0], src)
+	} else {
+		dst = parseContactUDPAddr(contact, src)
+	}
 	if dst == nil {
 		logger.Warn("sip register: no Contact / UDP target",
 			zap.String("aor", key),
@@ -226,28 +275,117 @@ func (s *SIPServer) upsertRegistration(msg *stack.Message, src *net.UDPAddr) {
 				zap.String("aor", key),
 				zap.Error(err))
 		} else {
+			if f, ok := s.wsFlowFor(src); ok {
+				f.trackAOR(user, host, false)
+			}
 			logger.Info("sip register removed",
 				zap.String("aor", key),
 				zap.String("remote", src.String()))
 		}
 		return
 	}
-	exp := time.Now().Add(time.Duration(sec) * time.Second)
-	ua := msg.GetHeader("User-Agent")
-	if err := st.SaveRegister(ctx, user, host, contact, dst, exp, ua); err != nil {
+	b := RegisterBinding{
+		ContactURI: contact,
+		Sig:        dst,
+		ExpiresAt:  time.Now().Add(time.Duration(sec) * time.Second),
+		UserAgent:  msg.GetHeader("User-Agent"),
+		Via:        transaction.TopVia(msg),
+		Path:       path,
+	}
+	if err := st.SaveRegister(ctx, user, host, b); err != nil {
 		logger.Warn("sip register db save failed",
 			zap.String("aor", key),
 			zap.Error(err))
 		return
 	}
+	if f, ok := s.wsFlowFor(src); ok && contactIsWebSocket(stripAngle(extractSIPAddrSpec(contact))) {
+		f.trackAOR(user, host, true)
+	}
+	s.notifyRegistered(user, host)
 	logger.Info("sip register bound",
 		zap.String("aor", key),
 		zap.String("dst", dst.String()),
+		zap.Int("path_hops", len(path)),
 		zap.Int("expires_sec", sec))
 }
 
+// routeHopAddr resolves a Route / Path URI to its UDP address (default
+// port 5060), falling back to fallback when it can't be resolved.
+func routeHopAddr(route string, fallback *net.UDPAddr) *net.UDPAddr {
+	host, port, ok := transaction.RouteHostPort(route)
+	if !ok {
+		return fallback
+	}
+	if port == 0 {
+		port = 5060
+	}
+	ip := lookupHopIP(host, port)
+	if ip == nil {
+		return fallback
+	}
+	return &net.UDPAddr{IP: ip, Port: port}
+}
+
+// registerSupportsPath reports whether the REGISTER listed "path" in Supported.
+func registerSupportsPath(msg *stack.Message) bool {
+	for _, v := range msg.GetHeaders("Supported") {
+		for _, opt := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(opt), "path") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serviceRoutes is the RFC 3608 Service-Route returned in 200 OK to
+// REGISTER: SIP_SERVICE_ROUTE (comma-separated URIs) when set, else this
+// server as a loose route for UAs that registered through a Path.
+func (s *SIPServer) serviceRoutes(msg *stack.Message, src *net.UDPAddr) []string {
+	if env := strings.TrimSpace(os.Getenv("SIP_SERVICE_ROUTE")); env != "" {
+		var out []string
+		for _, r := range transaction.SplitRouteValues([]string{env}) {
+			if !strings.HasPrefix(r, "<") {
+				r = "<" + r + ">"
+			}
+			out = append(out, r)
+		}
+		return out
+	}
+	if len(msg.GetHeaders("Path")) == 0 {
+		return nil
+	}
+	return []string{s.localRouteURI(src)}
+}
+
+// localRouteURI is this server as a loose-routing URI for Route /
+// Service-Route / Record-Route values.
+func (s *SIPServer) localRouteURI(addr *net.UDPAddr) string {
+	tr := ""
+	switch s.sigTransport(addr) {
+	case "TCP":
+		tr = ";transport=tcp"
+	case "TLS":
+		tr = ";transport=tls"
+	}
+	return fmt.Sprintf("<sip:%s:%d%s;lr>", strings.TrimSpace(s.localIP), s.listenPort, tr)
+}
+
+// isLocalRoute reports whether route names this server (RFC 3261 §16.4).
+func (s *SIPServer) isLocalRoute(route string) bool {
+	host, port, ok := transaction.RouteHostPort(route)
+	if !ok {
+		return false
+	}
+	if port != 0 && port != s.listenPort {
+		return false
+	}
+	return strings.EqualFold(host, strings.TrimSpace(s.localIP)) || strings.EqualFold(host, strings.TrimSpace(s.listenHost))
+}
+
 // prependProxyVia adds a Via on top so responses route back through this server.
-func prependProxyVia(msg *stack.Message, sipHost string, sipPort int) {
+// transport is the Via token of the outgoing flow ("" = UDP).
+func prependProxyVia(msg *stack.Message, sipHost string, sipPort int, transport string) {
 	if msg == nil {
 		return
 	}
@@ -259,7 +397,10 @@ func prependProxyVia(msg *stack.Message, sipHost string, sipPort int) {
 		h = "127.0.0.1"
 	}
 	branch := "z9hG4bK" + randomBranch()
-	via := fmt.Sprintf("SIP/2.0/UDP %s:%d;branch=%s;rport", h, sipPort, branch)
+	if transport = strings.ToUpper(strings.TrimSpace(transport)); transport == "" {
+		transport = "UDP"
+	}
+	via := fmt.Sprintf("SIP/2.0/%s %s:%d;branch=%s;rport", transport, h, sipPort, branch)
 	old := msg.GetHeaders("via")
 	if len(old) == 0 {
 		if v := msg.GetHeader("Via"); v != "" {
@@ -277,12 +418,33 @@ func prependProxyVia(msg *stack.Message, sipHost string, sipPort int) {
 	}
 }
 
+// registeredContactMatcher reports whether a Request-URI is one of the
+// Contacts we registered with an upstream carrier (outbound REGISTER
+// client). Such INVITEs carry the trunk account in the Request-URI and
+// the dialled DID in To.
+var registeredContactMatcher atomic.Pointer[func(requestURI string) bool]
+
+// SetRegisteredContactMatcher installs the registered-Contact check used
+// by InboundCalledPartyUser. nil disables.
+func SetRegisteredContactMatcher(fn func(requestURI string) bool) {
+	if fn == nil {
+		registeredContactMatcher.Store(nil)
+		return
+	}
+	registeredContactMatcher.Store(&fn)
+}
+
 // InboundCalledPartyUser returns the SIP user part used as DID / routing key from INVITE (Request-URI preferred, then To).
+// When the Request-URI is a Contact we registered with a carrier, To is used instead.
 func InboundCalledPartyUser(msg *stack.Message) string {
 	if msg == nil {
 		return ""
 	}
-	if ru := strings.TrimSpace(msg.RequestURI); ru != "" {
+	ru := strings.TrimSpace(msg.RequestURI)
+	if fn := registeredContactMatcher.Load(); fn != nil && ru != "" && (*fn)(ru) {
+		ru = ""
+	}
+	if ru != "" {
 		if u, _, ok := parseURIUserHost(ru); ok && u != "" {
 			return u
 		}
@@ -295,7 +457,11 @@ func InboundCalledPartyUser(msg *stack.Message) string {
 	return ""
 }
 
-func (s *SIPServer) proxyInviteToRegistrar(msg *stack.Message, dst *net.UDPAddr) error {
+// proxyInviteToRegistrar forwards an INVITE to a registered UA. Our own
+// Route entries are removed; a binding with a Path is retargeted to its
+// Contact and gets the Path preloaded as Route (RFC 3327 §5.3).
+func (s *SIPServer) proxyInviteToRegistrar(msg *stack.Message, b RegisterBinding) error {
+	dst := b.Sig
 	if s == nil || s.ep == nil || msg == nil || dst == nil {
 		return fmt.Errorf("sip: proxy invite: nil")
 	}
@@ -304,7 +470,18 @@ func (s *SIPServer) proxyInviteToRegistrar(msg *stack.Message, dst *net.UDPAddr)
 	if err != nil {
 		return err
 	}
-	prependProxyVia(fwd, s.localIP, s.listenPort)
+	routes := transaction.SplitRouteValues(fwd.GetHeaders("Route"))
+	for len(routes) > 0 && s.isLocalRoute(routes[0]) {
+		routes = routes[1:]
+	}
+	if len(b.Path) > 0 {
+		if uri := stripAngle(extractSIPAddrSpec(b.ContactURI)); uri != "" {
+			fwd.RequestURI = uri
+		}
+		routes = append(append([]string(nil), b.Path...), routes...)
+	}
+	transaction.SetRouteHeaders(fwd, routes)
+	prependProxyVia(fwd, s.localIP, s.listenPort, s.sigTransport(dst))
 	// Ensure Content-Length matches normalized body after parse/re-serialize.
 	fwd.SetHeader("Content-Length", strconv.Itoa(stack.BodyBytesLen(fwd.Body)))
 	return s.ep.Send(fwd, dst)
@@ -1,6 +1,17 @@
 package server
 
-import "testing"
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/outbound"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
 
 func TestParseURIUserHost_nameAddr(t *testing.T) {
 	cases := []struct {
@@ -22,3 +33,146 @@ func TestParseURIUserHost_nameAddr(t *testing.T) {
 		}
 	}
 }
+
+func TestInboundCalledPartyUser_RegisteredContact(t *testing.T) {
+	msg := &stack.Message{IsRequest: true, Method: stack.MethodInvite, RequestURI: "sip:trunk01@10.0.0.5:5060;line=ab12"}
+	msg.SetHeader("To", "<sip:02188880001@carrier.example>")
+	if got := InboundCalledPartyUser(msg); got != "trunk01" {
+		t.Fatalf("without matcher: %q", got)
+	}
+	SetRegisteredContactMatcher(func(uri string) bool { return strings.Contains(uri, "line=ab12") })
+	defer SetRegisteredContactMatcher(nil)
+	if got := InboundCalledPartyUser(msg); got != "02188880001" {
+		t.Fatalf("registered contact should route on To: %q", got)
+	}
+}
+
+func TestRegisterThroughEdgeProxyPath(t *testing.T) {
+	if err := logger.Init(&logger.LogConfig{Level: "error", Filename: t.TempDir() + "/test.log", MaxSize: 1}, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(Config{Host: "127.0.0.1", Port: 0, LocalIP: "127.0.0.1"})
+	st := &memRegisterStore{}
+	srv.SetRegisterStore(st)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Stop() }()
+	host, sigPort := srv.ListenAddr()
+	serverSig := &net.UDPAddr{IP: net.ParseIP(host), Port: sigPort}
+
+	// The "edge proxy" relays the UA's REGISTER and later receives the INVITE.
+	edge, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = edge.Close() }()
+	edgePort := edge.LocalAddr().(*net.UDPAddr).Port
+	path := "<sip:127.0.0.1:" + strconv.Itoa(edgePort) + ";lr>"
+	reg := strings.Join([]string{
+		"REGISTER sip:" + host + " SIP/2.0",
+		"Via: SIP/2.0/UDP 127.0.0.1:" + strconv.Itoa(edgePort) + ";branch=z9hG4bKedge",
+		"Via: SIP/2.0/UDP 10.1.1.20:5060;branch=z9hG4bKua",
+		"Max-Forwards: 69",
+		"From: <sip:2001@" + host + ">;tag=r1",
+		"To: <sip:2001@" + host + ">",
+		"Call-ID: path-reg-1",
+		"CSeq: 1 REGISTER",
+		"Contact: <sip:2001@10.1.1.20:5060>",
+		"Path: " + path,
+		"Supported: path",
+		"Expires: 300",
+		"Content-Length: 0",
+		"", "",
+	}, "\r\n")
+	if _, err := edge.WriteToUDP([]byte(reg), serverSig); err != nil {
+		t.Fatal(err)
+	}
+	resp := readUDPSIP(t, edge)
+	if resp.StatusCode != 200 {
+		t.Fatalf("REGISTER -> %d", resp.StatusCode)
+	}
+	if got := resp.GetHeader("Path"); got != path {
+		t.Fatalf("Path echo %q", got)
+	}
+	if sr := resp.GetHeader("Service-Route"); !strings.Contains(sr, ";lr") {
+		t.Fatalf("Service-Route %q", sr)
+	}
+
+	b, ok, _ := st.LookupRegister(context.Background(), "2001", host)
+	if !ok || b.Sig == nil || b.Sig.Port != edgePort || len(b.Path) != 1 {
+		t.Fatalf("binding %+v ok=%v", b, ok)
+	}
+
+	inv := &stack.Message{IsRequest: true, Method: stack.MethodInvite, RequestURI: "sip:2001@" + host, Version: "SIP/2.0"}
+	inv.SetHeader("Via", "SIP/2.0/UDP 198.51.100.7:5060;branch=z9hG4bKcaller")
+	inv.SetHeader("Route", "<sip:127.0.0.1:"+strconv.Itoa(sigPort)+";lr>")
+	inv.SetHeader("Call-ID", "path-inv-1")
+	inv.SetHeader("CSeq", "1 INVITE")
+	if err := srv.proxyInviteToRegistrar(inv, b); err != nil {
+		t.Fatal(err)
+	}
+	got := readUDPSIP(t, edge)
+	if got.RequestURI != "sip:2001@10.1.1.20:5060" {
+		t.Fatalf("Request-URI %q", got.RequestURI)
+	}
+	if r := got.GetHeaders("Route"); len(r) != 1 || r[0] != path {
+		t.Fatalf("Route %q (own route must be popped, Path preloaded)", r)
+	}
+}
+
+func readUDPSIP(t *testing.T, c *net.UDPConn) *stack.Message {
+	t.Helper()
+	buf := make([]byte, 8192)
+	_ = c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := c.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := stack.Parse(string(buf[:n]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// stallDNS resolves only names in ips; any other lookup blocks until
+// the caller's context gives up.
+type stallDNS struct{ ips map[string][]net.IP }
+
+func (stallDNS) LookupNAPTR(context.Context, string) ([]outbound.NAPTRRecord, time.Duration, error) {
+	return nil, 0, nil
+}
+
+func (stallDNS) LookupSRV(context.Context, string) ([]outbound.SRVRecord, time.Duration, error) {
+	return nil, 0, nil
+}
+
+func (d stallDNS) LookupIP(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if ips, ok := d.ips[host]; ok {
+		return ips, time.Minute, nil
+	}
+	<-ctx.Done()
+	return nil, 0, ctx.Err()
+}
+
+func TestRouteHopAddr_BoundedLookup(t *testing.T) {
+	defer func(l *outbound.Locator, d time.Duration) { hopLocator, hopLookupTimeout = l, d }(hopLocator, hopLookupTimeout)
+	hopLocator = outbound.NewLocator(stallDNS{ips: map[string][]net.IP{"edge.example": {net.ParseIP("198.51.100.7")}}})
+	hopLookupTimeout = 50 * time.Millisecond
+	fallback := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 5060}
+
+	if got := routeHopAddr("<sip:edge.example:5070;lr>", fallback); got.String() != "198.51.100.7:5070" {
+		t.Fatalf("resolved hop = %v", got)
+	}
+	start := time.Now()
+	if got := routeHopAddr("<sip:slow.example;lr>", fallback); got != fallback {
+		t.Fatalf("stalled lookup should fall back, got %v", got)
+	}
+	if el := time.Since(start); el > time.Second {
+		t.Fatalf("lookup not bounded: %v", el)
+	}
+	if got := parseContactUDPAddr("<sip:alice@slow.example:5062>", fallback); got != fallback {
+		t.Fatalf("contact with stalled host should fall back, got %v", got)
+	}
+}
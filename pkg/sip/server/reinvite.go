@@ -29,6 +29,10 @@ func (s *SIPServer) handleReInvite(msg *stack.Message, addr *net.UDPAddr, cs *si
 	toWithTag := ensureToTag(msg.GetHeader("To"))
 	s.registerPendingInvite(msg, addr, toWithTag)
 
+	if t38, ok := sdp.ParseT38(msg.Body); ok && t38.Port > 0 {
+		return s.answerT38ReInvite(msg, addr, cs, t38, toWithTag)
+	}
+
 	rtpSess := cs.RTPSession()
 	if rtpSess == nil {
 		return s.makeResponse(msg, 488, "Not Acceptable Here", "", toWithTag)
@@ -41,7 +45,7 @@ func (s *SIPServer) handleReInvite(msg *stack.Message, addr *net.UDPAddr, cs *si
 		resp := s.makeResponse(msg, 200, "OK", respSDP, toWithTag)
 		resp.SetHeader("Content-Type", "application/sdp")
 		resp.SetHeader("To", toWithTag)
-		resp.SetHeader("Contact", fmt.Sprintf("<sip:server@%s:%d>", s.localIP, s.listenPort))
+		resp.SetHeader("Contact", s.localContact(addr))
 		resp.SetHeader("Content-Length", strconv.Itoa(stack.BodyBytesLen(respSDP)))
 		return resp
 	}
@@ -117,12 +121,22 @@ func (s *SIPServer) handleReInvite(msg *stack.Message, addr *net.UDPAddr, cs *si
 	if te, ok := sdp.PickTelephoneEventFromOffer(offer.Codecs, neg.ClockRate); ok {
 		codecs = append(codecs, te)
 	}
+	// Keep rtcp-mux / NACK as agreed; RTCP can't move back off the RTP
+	// port mid-dialog, so a re-offer without a=rtcp-mux leaves it muxed.
+	if offer.RTCPMux {
+		rtpSess.EnableRTCPMux()
+		sdpExtras = append(sdpExtras, sdp.RTCPMuxLine)
+	}
+	if offer.SupportsNACK(neg.PayloadType) {
+		rtpSess.EnableNACK()
+		sdpExtras = append(sdpExtras, sdp.NACKFeedbackLines(neg)...)
+	}
 	respSDP := sdp.GenerateWithProtoExtras(s.localIP, rtpSess.LocalAddr.Port, offer.Proto, codecs, sdpExtras)
 
 	respMsg := s.makeResponse(msg, 200, "OK", respSDP, toWithTag)
 	respMsg.SetHeader("Content-Type", "application/sdp")
 	respMsg.SetHeader("To", toWithTag)
-	respMsg.SetHeader("Contact", fmt.Sprintf("<sip:server@%s:%d>", s.localIP, s.listenPort))
+	respMsg.SetHeader("Contact", s.localContact(addr))
 	respMsg.SetHeader("Allow", strings.Join([]string{
 		stack.MethodInvite,
 		stack.MethodAck,
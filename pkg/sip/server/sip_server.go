@@ -141,6 +141,17 @@ type SIPServer struct {
 	// other dialogs.
 	pendingDTLSMu sync.Mutex
 	pendingDTLS   map[string]*dtlsPendingState
+
+	// faxReInvites routes responses to the T.38 re-INVITE we send on a
+	// fax tone (Call-ID -> *faxReInvite). See fax.go.
+	faxReInvites sync.Map
+
+	// msgMu guards MESSAGE routing, the REGISTER observer (message.go)
+	// and the BLF presence source (blf.go).
+	msgMu            sync.RWMutex
+	messageHandler   MessageHandler
+	registerObserver func(user, domain string)
+	presenceSource   PresenceSource
 }
 
 // SetSTIRConfig installs the inbound STIR/SHAKEN verification
@@ -190,11 +201,22 @@ func envInt(name string) (int, bool) {
 }
 
 func newRTPSessionFromRange(start, end int) (*rtp.Session, error) {
+	sess, err := bindFromPortRange(start, end, rtp.NewSession)
+	if err != nil {
+		return nil, fmt.Errorf("rtp %w", err)
+	}
+	return sess, nil
+}
+
+// bindFromPortRange walks the rotating media port cursor (shared by RTP
+// and UDPTL sockets) until bind succeeds.
+func bindFromPortRange[T any](start, end int, bind func(port int) (T, error)) (T, error) {
 	rtpPortAllocMu.Lock()
 	defer rtpPortAllocMu.Unlock()
+	var zero T
 	span := end - start + 1
 	if span <= 0 {
-		return nil, fmt.Errorf("invalid RTP port range: %d-%d", start, end)
+		return zero, fmt.Errorf("invalid RTP port range: %d-%d", start, end)
 	}
 	if rtpPortNext < start || rtpPortNext > end {
 		rtpPortNext = start
@@ -206,13 +228,13 @@ func newRTPSessionFromRange(start, end int) (*rtp.Session, error) {
 		if rtpPortNext > end {
 			rtpPortNext = start
 		}
-		sess, err := rtp.NewSession(p)
+		v, err := bind(p)
 		if err == nil {
-			return sess, nil
+			return v, nil
 		}
 		lastErr = err
 	}
-	return nil, fmt.Errorf("rtp range exhausted %d-%d: %w", start, end, lastErr)
+	return zero, fmt.Errorf("range exhausted %d-%d: %w", start, end, lastErr)
 }
 
 func isPrivateIPv4(ip net.IP) bool {
@@ -290,6 +312,9 @@ func New(cfg Config) *SIPServer {
 					)
 				}
 			}
+			if s.handleFaxReInviteResponse(resp) {
+				return
+			}
 			if cfg.OnSIPResponse != nil {
 				cfg.OnSIPResponse(resp, addr)
 			}
@@ -690,19 +715,7 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 		return nil
 	}
 
-	if addr != nil && addr.IP != nil {
-		if !ipAllowed(s.inviteAllowNets, addr.IP) {
-			return s.makeResponse(msg, 403, "Forbidden", "", "")
-		}
-		if !s.inviteRate.allow(addr.IP, s.inviteRatePerSec, s.inviteBurst) {
-			return s.makeResponse(msg, 503, "Service Unavailable", "", "")
-		}
-	}
-	if s.inviteDigest != nil && !s.inviteDigest.verifyINVITE(msg) {
-		resp, err := s.inviteDigest.challenge401(msg)
-		if err != nil || resp == nil {
-			return s.makeResponse(msg, 500, "Internal Server Error", "", "")
-		}
+	if resp := s.screenRequest(msg, addr); resp != nil {
 		return resp
 	}
 
@@ -801,15 +814,15 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 	// REGISTERed AOR: proxy INVITE to that UA (same host: different user in Request-URI).
 	if u, h, ok := parseURIUserHost(msg.RequestURI); ok {
 		if st := s.registerStore(); st != nil {
-			dst, found, lerr := st.LookupRegister(context.Background(), u, h)
+			b, found, lerr := st.LookupRegister(context.Background(), u, h)
 			if lerr != nil {
 				logger.Warn("sip invite lookup register failed",
 					zap.String("call_id", callID),
 					zap.String("aor", registrationKey(u, h)),
 					zap.Error(lerr),
 				)
-			} else if found && dst != nil {
-				if err := s.proxyInviteToRegistrar(msg, dst); err != nil {
+			} else if found && b.Sig != nil {
+				if err := s.proxyInviteToRegistrar(msg, b); err != nil {
 					logger.Warn("sip invite proxy to registered UA failed",
 						zap.String("call_id", callID),
 						zap.String("aor", registrationKey(u, h)),
@@ -819,7 +832,8 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 					logger.Info("sip invite proxied to registered UA",
 						zap.String("call_id", callID),
 						zap.String("aor", registrationKey(u, h)),
-						zap.String("dst", dst.String()),
+						zap.String("dst", b.Sig.String()),
+						zap.Int("path_hops", len(b.Path)),
 					)
 					return nil
 				}
@@ -1003,6 +1017,24 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 		s.stashPendingDTLS(callID, dtlsAns.Pending)
 	}
 
+	// ICE-lite (RFC 8445 §2.5) for WebRTC offers such as SIP-over-WebSocket
+	// softphones: one host candidate on the RTP socket, which answers the
+	// peer's STUN connectivity checks.
+	var sessionExtras []string
+	if offer.HasICE() {
+		ufrag, pwd, ierr := sdp.NewICECredentials()
+		if ierr != nil {
+			_ = rtpSess.Close()
+			if flight != nil && fk != "" {
+				s.inviteFlights.Delete(fk)
+			}
+			return s.makeResponse(msg, 500, "Internal Server Error", "", "")
+		}
+		rtpSess.EnableICELite(ufrag, pwd)
+		sdpExtras = append(sdpExtras, sdp.ICELiteMediaLines(ufrag, pwd, s.localIP, rtpSess.LocalAddr.Port, offer.MID, offer.RTCPMux)...)
+		sessionExtras = append(sessionExtras, sdp.ICELiteLine)
+	}
+
 	// Store session for BYE.
 	s.mu.Lock()
 	// If a session already exists for this call, stop it first (idempotency-ish).
@@ -1093,7 +1125,21 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 	if te, ok := sdp.PickTelephoneEventFromOffer(offer.Codecs, neg.ClockRate); ok {
 		codecs = append(codecs, te)
 	}
-	respSDP := sdp.GenerateWithProtoExtras(s.localIP, localPort, offer.Proto, codecs, sdpExtras)
+	// RFC 5761 / RFC 4585: accept rtcp-mux and generic NACK when offered
+	// (ICELiteMediaLines already echoes a=rtcp-mux for WebRTC offers).
+	if offer.RTCPMux {
+		rtpSess.EnableRTCPMux()
+		if !offer.HasICE() {
+			sdpExtras = append(sdpExtras, sdp.RTCPMuxLine)
+		}
+	}
+	if offer.SupportsNACK(neg.PayloadType) {
+		rtpSess.EnableNACK()
+		sdpExtras = append(sdpExtras, sdp.NACKFeedbackLines(neg)...)
+	}
+	respSDP := sdp.WithSessionAttributes(
+		sdp.GenerateWithProtoExtras(s.localIP, localPort, offer.Proto, codecs, sdpExtras),
+		sessionExtras...)
 
 	// Use a single To-tag consistently across provisional/final responses.
 	toWithTag := ensureToTag(msg.GetHeader("To"))
@@ -1103,7 +1149,7 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 	respMsg.SetHeader("To", toWithTag)
 	// For dialog establishment many clients expect a Contact header from UAS.
 	// Use SDP local-ip as a reachable contact host.
-	respMsg.SetHeader("Contact", fmt.Sprintf("<sip:server@%s:%d>", s.localIP, s.listenPort))
+	respMsg.SetHeader("Contact", s.localContact(addr))
 	respMsg.SetHeader("Allow", strings.Join([]string{
 		stack.MethodInvite,
 		stack.MethodAck,
@@ -1168,7 +1214,7 @@ func (s *SIPServer) handleInvite(msg *stack.Message, addr *net.UDPAddr) *stack.M
 	if s.ep != nil && addr != nil {
 		ringing := s.makeResponse(msg, 180, "Ringing", "", toWithTag)
 		ringing.SetHeader("To", toWithTag)
-		ringing.SetHeader("Contact", fmt.Sprintf("<sip:server@%s:%d>", s.localIP, s.listenPort))
+		ringing.SetHeader("Contact", s.localContact(addr))
 		ringing.SetHeader("Content-Length", "0")
 		_ = s.ep.Send(ringing, addr)
 	}
@@ -1212,6 +1258,15 @@ func (s *SIPServer) handleAck(msg *stack.Message, addr *net.UDPAddr) *stack.Mess
 			)
 			return nil
 		}
+		// Fax calls (T.38 receiver or G.711 pass-through) never get the
+		// AI voice attached; this is typically the ACK to a T.38 re-INVITE.
+		if fm := cs.FaxMode(); fm != "" {
+			logger.Info("sip inbound ACK: skipping AI/voicedialog voice attach (fax call)",
+				zap.String("call_id", callID),
+				zap.String("fax_mode", fm),
+			)
+			return nil
+		}
 		if cs.InboundUnboundTenant() {
 			zl := logger.Lg
 			if err := conversation.AttachInboundNotBoundPlayback(context.Background(), cs, zl); err != nil {
@@ -1305,6 +1360,11 @@ func (s *SIPServer) handleBye(msg *stack.Message, addr *net.UDPAddr) *stack.Mess
 		if s.outboundBYELegCleanup != nil && strings.TrimSpace(tb.OutboundCallID) != "" {
 			s.outboundBYELegCleanup(tb.OutboundCallID, byeReasonClass)
 		}
+		if s.outboundBYELegCleanup != nil && tb.InboundCallID == callID {
+			// Click-to-call bridges two UAC legs: the "inbound" side is
+			// our own agent leg, which needs the same outbound cleanup.
+			s.outboundBYELegCleanup(callID, byeReasonClass)
+		}
 		if p := s.callPersistStore(); p != nil {
 			go p.OnBye(context.Background(), ByePersistParams{
 				CallID:             tb.InboundCallID,
@@ -1454,6 +1514,17 @@ func (s *SIPServer) handleRegister(msg *stack.Message, addr *net.UDPAddr) *stack
 	if exp := msg.GetHeader("Expires"); exp != "" {
 		resp.SetHeader("Expires", exp)
 	}
+	// RFC 3327 §5.3: echo the stored Path when the UA supports it.
+	if path := msg.GetHeaders("Path"); len(path) > 0 && registerSupportsPath(msg) {
+		for _, p := range path {
+			resp.AddHeader("Path", p)
+		}
+		resp.SetHeader("Supported", "path")
+	}
+	// RFC 3608: route set for the UA's originating requests.
+	for _, r := range s.serviceRoutes(msg, addr) {
+		resp.AddHeader("Service-Route", r)
+	}
 	resp.SetHeader("Content-Length", "0")
 	return resp
 }
@@ -1551,6 +1622,12 @@ func (s *SIPServer) makeResponse(req *stack.Message, code int, text string, body
 		if v := req.GetHeader("CSeq"); v != "" {
 			resp.SetHeader("CSeq", v)
 		}
+		// RFC 3261 §12.1.1: dialog-creating responses copy Record-Route.
+		if m := strings.ToUpper(req.Method); (m == stack.MethodInvite || m == stack.MethodSubscribe) && code > 100 && code < 300 {
+			for _, rr := range req.GetHeaders("Record-Route") {
+				resp.AddHeader("Record-Route", rr)
+			}
+		}
 	}
 	if strings.TrimSpace(toOverride) != "" {
 		resp.SetHeader("To", toOverride)
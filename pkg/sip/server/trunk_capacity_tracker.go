@@ -112,3 +112,13 @@ func (t *TrunkCapacityTracker) ReleaseOutbound(callID string) {
 		t.outboundCount[trunkID]--
 	}
 }
+
+// OutboundInUse returns how many outbound slots trunkNumberID currently holds.
+func (t *TrunkCapacityTracker) OutboundInUse(trunkNumberID uint) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.outboundCount[trunkNumberID]
+}
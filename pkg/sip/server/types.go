@@ -9,15 +9,29 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
 )
 
+// RegisterBinding is one REGISTER binding of an AOR.
+type RegisterBinding struct {
+	ContactURI string
+	// Sig is where terminating requests go: the first Path hop when the
+	// UA registered through an edge proxy, else the Contact (or source) address.
+	Sig       *net.UDPAddr
+	ExpiresAt time.Time
+	UserAgent string
+	Via       string // top Via of the REGISTER
+	// Path is the RFC 3327 Path of the REGISTER, top first. Terminating
+	// requests carry it as a preloaded Route set.
+	Path []string
+}
+
 // SIPRegisterStore persists REGISTER bindings for INVITE proxy and outbound dial lookup.
 // Implementations must be safe for concurrent use (e.g. GORM).
 type SIPRegisterStore interface {
-	// SaveRegister stores the resolved Contact signaling target (UDP), same as INVITE proxy destination.
-	SaveRegister(ctx context.Context, user, domain, contactURI string, sig *net.UDPAddr, expiresAt time.Time, userAgent string) error
+	// SaveRegister stores the binding; b.Sig is the INVITE proxy destination.
+	SaveRegister(ctx context.Context, user, domain string, b RegisterBinding) error
 
 	DeleteRegister(ctx context.Context, user, domain string) error
-	// LookupRegister returns the UDP signaling target for a registered AOR (Contact / Via path).
-	LookupRegister(ctx context.Context, user, domain string) (*net.UDPAddr, bool, error)
+	// LookupRegister returns the stored binding for a registered AOR.
+	LookupRegister(ctx context.Context, user, domain string) (RegisterBinding, bool, error)
 }
 
 // InboundDIDBinding ties an inbound INVITE's called-party (DID) to tenant + trunk_number row.
@@ -41,6 +55,7 @@ type InvitePersistParams struct {
 	ClockRate            int
 	CSeqInvite           string
 	Direction            string
+	AgentACDTargetID     uint // acd_pool_targets.id of the agent on an agent-bridged outbound leg
 }
 
 // ByePersistParams describes BYE-time persistence and recording metadata.
@@ -0,0 +1,281 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package server
+
+// ws_sig.go: SIP over WebSocket (RFC 7118) for browser / softphone
+// clients (JsSIP, SIP.js). The listener is an HTTP handler mounted on
+// the app's Gin server, so WSS comes from the HTTPS listener (or a
+// TLS-terminating proxy) rather than a separate socket.
+//
+// Each WebSocket message carries exactly one SIP message (§5). Accepted
+// connections are bound on the Endpoint as a stream flow keyed by the
+// synthesised remote address, so everything the server sends to that
+// address — responses, BYE, an INVITE proxied to a contact registered
+// with ;transport=ws — goes back over the same connection (§5.2: the
+// .invalid Contact host is unreachable any other way). When the
+// connection drops, the AORs registered over it go offline.
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// wsSubprotocol is the RFC 7118 §4.1 WebSocket subprotocol token.
+const wsSubprotocol = "sip"
+
+const (
+	wsReadLimit    = 64 << 10
+	wsPingInterval = 30 * time.Second
+	wsIdleTimeout  = 90 * time.Second
+	wsWriteTimeout = 5 * time.Second
+)
+
+var wsUpgrader = websocket.Upgrader{
+	Subprotocols:    []string{wsSubprotocol},
+	ReadBufferSize:  8 << 10,
+	WriteBufferSize: 8 << 10,
+	CheckOrigin:     wsOriginAllowed,
+}
+
+// wsOriginAllowed applies SIP_WS_ALLOWED_ORIGINS (comma list; empty =
+// any). SIP digest / REGISTER password still gate what a page can do.
+func wsOriginAllowed(r *http.Request) bool {
+	allowed := strings.TrimSpace(os.Getenv("SIP_WS_ALLOWED_ORIGINS"))
+	if allowed == "" || allowed == "*" {
+		return true
+	}
+	origin := strings.TrimSpace(r.Header.Get("Origin"))
+	for _, o := range strings.Split(allowed, ",") {
+		if strings.EqualFold(strings.TrimSpace(o), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// wsFlow is one accepted SIP-over-WebSocket connection.
+type wsFlow struct {
+	conn      *websocket.Conn
+	remote    *net.UDPAddr
+	transport string // WS / WSS
+
+	writeMu sync.Mutex
+
+	aorMu sync.Mutex
+	aors  map[string][2]string // registrationKey → user, host
+}
+
+func (f *wsFlow) SendMessage(msg *stack.Message) error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	_ = f.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return f.conn.WriteMessage(websocket.TextMessage, []byte(msg.String()))
+}
+
+func (f *wsFlow) Transport() string { return f.transport }
+
+func (f *wsFlow) ping() error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	return f.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+}
+
+// trackAOR remembers (bound=true) or forgets an AOR registered over this flow.
+func (f *wsFlow) trackAOR(user, host string, bound bool) {
+	key := registrationKey(user, host)
+	f.aorMu.Lock()
+	defer f.aorMu.Unlock()
+	if !bound {
+		delete(f.aors, key)
+		return
+	}
+	if f.aors == nil {
+		f.aors = make(map[string][2]string)
+	}
+	f.aors[key] = [2]string{user, host}
+}
+
+func (f *wsFlow) registeredAORs() [][2]string {
+	f.aorMu.Lock()
+	defer f.aorMu.Unlock()
+	out := make([][2]string, 0, len(f.aors))
+	for _, a := range f.aors {
+		out = append(out, a)
+	}
+	return out
+}
+
+// wsFlowFor returns the WebSocket flow bound to addr, if any.
+func (s *SIPServer) wsFlowFor(addr *net.UDPAddr) (*wsFlow, bool) {
+	if s == nil || s.ep == nil {
+		return nil, false
+	}
+	c, ok := s.ep.Stream(addr)
+	if !ok {
+		return nil, false
+	}
+	f, ok := c.(*wsFlow)
+	return f, ok
+}
+
+// sigTransport is the Via / Contact transport token for addr.
+func (s *SIPServer) sigTransport(addr *net.UDPAddr) string {
+	if s == nil || s.ep == nil {
+		return "UDP"
+	}
+	return s.ep.StreamTransport(addr)
+}
+
+// localContact is the Contact URI for responses to addr; flows get a
+// ;transport=ws|wss parameter so the UA keeps in-dialog requests on it.
+func (s *SIPServer) localContact(addr *net.UDPAddr) string {
+	if tr := s.sigTransport(addr); tr == "WS" || tr == "WSS" {
+		return fmt.Sprintf("<sip:server@%s:%d;transport=%s>", s.localIP, s.listenPort, strings.ToLower(tr))
+	}
+	return fmt.Sprintf("<sip:server@%s:%d>", s.localIP, s.listenPort)
+}
+
+// requestIsSecure reports whether the WebSocket arrived over TLS,
+// directly or via a TLS-terminating proxy.
+func requestIsSecure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("X-Forwarded-Proto")), "https")
+}
+
+// ServeWebSocket upgrades r to a SIP-over-WebSocket flow and serves it
+// until the client disconnects or the server stops.
+func (s *SIPServer) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	if s == nil || s.ep == nil {
+		http.Error(w, "sip server not ready", http.StatusServiceUnavailable)
+		return
+	}
+	offered := false
+	for _, p := range websocket.Subprotocols(r) {
+		if strings.EqualFold(p, wsSubprotocol) {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		// RFC 7118 §4.1: the client MUST request the "sip" subprotocol.
+		http.Error(w, "Sec-WebSocket-Protocol must include sip", http.StatusBadRequest)
+		return
+	}
+	host, portStr, err := net.SplitHostPort(r.RemoteAddr)
+	ip := net.ParseIP(host)
+	port, perr := net.LookupPort("tcp", portStr)
+	if err != nil || ip == nil || perr != nil {
+		http.Error(w, "bad remote address", http.StatusBadRequest)
+		return
+	}
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warn("sip ws upgrade failed", zap.String("remote", r.RemoteAddr), zap.Error(err))
+		return
+	}
+	transport := "WS"
+	if requestIsSecure(r) {
+		transport = "WSS"
+	}
+	f := &wsFlow{conn: conn, remote: &net.UDPAddr{IP: ip, Port: port}, transport: transport}
+	s.ep.BindStream(f.remote, f)
+	logger.Info("sip ws flow opened",
+		zap.String("remote", f.remote.String()),
+		zap.String("transport", transport))
+	s.runWSFlow(f)
+}
+
+func (s *SIPServer) runWSFlow(f *wsFlow) {
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		s.ep.UnbindStream(f.remote, f)
+		_ = f.conn.Close()
+		s.dropWSRegistrations(f)
+		logger.Info("sip ws flow closed", zap.String("remote", f.remote.String()))
+	}()
+
+	ctxDone := make(<-chan struct{})
+	s.ensureSigCtx()
+	if s.sigCtx != nil {
+		ctxDone = s.sigCtx.Done()
+	}
+	go func() {
+		t := time.NewTicker(wsPingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctxDone:
+				_ = f.conn.Close()
+				return
+			case <-t.C:
+				if err := f.ping(); err != nil {
+					_ = f.conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	f.conn.SetReadLimit(wsReadLimit)
+	_ = f.conn.SetReadDeadline(time.Now().Add(wsIdleTimeout))
+	f.conn.SetPongHandler(func(string) error {
+		return f.conn.SetReadDeadline(time.Now().Add(wsIdleTimeout))
+	})
+	for {
+		_, data, err := f.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = f.conn.SetReadDeadline(time.Now().Add(wsIdleTimeout))
+		if stack.IsSignalingNoiseDatagram(data) {
+			continue
+		}
+		msg, err := stack.Parse(string(data))
+		if err != nil || msg == nil {
+			logger.Warn("sip ws parse error",
+				zap.String("remote", f.remote.String()),
+				zap.Int("bytes", len(data)),
+				zap.Error(err))
+			continue
+		}
+		if !msg.IsRequest {
+			s.ep.InvokeOnSIPResponse(msg, f.remote)
+			continue
+		}
+		s.dispatchSignalingRequestTCP(msg, f.remote, f.SendMessage)
+	}
+}
+
+// dropWSRegistrations takes the AORs registered over a closed flow
+// offline: their .invalid Contact cannot be reached without it.
+func (s *SIPServer) dropWSRegistrations(f *wsFlow) {
+	aors := f.registeredAORs()
+	st := s.registerStore()
+	if st == nil || len(aors) == 0 {
+		return
+	}
+	for _, a := range aors {
+		if err := st.DeleteRegister(context.Background(), a[0], a[1]); err != nil {
+			logger.Warn("sip ws flow: register cleanup failed",
+				zap.String("aor", registrationKey(a[0], a[1])),
+				zap.Error(err))
+		}
+	}
+}
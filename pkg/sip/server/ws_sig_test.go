@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/sip/sdp"
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+	"github.com/gorilla/websocket"
+)
+
+type memRegisterStore struct {
+	mu    sync.Mutex
+	saved map[string]RegisterBinding
+}
+
+func (m *memRegisterStore) SaveRegister(_ context.Context, user, domain string, b RegisterBinding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.saved == nil {
+		m.saved = make(map[string]RegisterBinding)
+	}
+	m.saved[registrationKey(user, domain)] = b
+	return nil
+}
+
+func (m *memRegisterStore) DeleteRegister(_ context.Context, user, domain string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.saved, registrationKey(user, domain))
+	return nil
+}
+
+func (m *memRegisterStore) LookupRegister(_ context.Context, user, domain string) (RegisterBinding, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.saved[registrationKey(user, domain)]
+	return a, ok, nil
+}
+
+func newWSTestServer(t *testing.T) (*SIPServer, *memRegisterStore, string) {
+	t.Helper()
+	if err := logger.Init(&logger.LogConfig{Level: "error", Filename: t.TempDir() + "/test.log", MaxSize: 1}, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(Config{Host: "127.0.0.1", Port: 0, LocalIP: "192.0.2.10"})
+	st := &memRegisterStore{}
+	srv.SetRegisterStore(st)
+	hs := httptest.NewServer(http.HandlerFunc(srv.ServeWebSocket))
+	t.Cleanup(func() {
+		hs.Close()
+		_ = srv.Stop()
+	})
+	return srv, st, "ws" + strings.TrimPrefix(hs.URL, "http")
+}
+
+func dialSIPWS(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	d := websocket.Dialer{Subprotocols: []string{"sip"}}
+	c, resp, err := d.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != "sip" {
+		t.Fatalf("subprotocol = %q", resp.Header.Get("Sec-WebSocket-Protocol"))
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func readSIPWS(t *testing.T, c *websocket.Conn) *stack.Message {
+	t.Helper()
+	_ = c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := stack.Parse(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func writeSIPWS(t *testing.T, c *websocket.Conn, lines ...string) {
+	t.Helper()
+	if err := c.WriteMessage(websocket.TextMessage, []byte(strings.Join(lines, "\r\n"))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServeWebSocket_RequiresSIPSubprotocol(t *testing.T) {
+	_, _, url := newWSTestServer(t)
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("upgrade without the sip subprotocol must fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("resp = %v", resp)
+	}
+}
+
+func TestServeWebSocket_RegisterRoutesBackOverFlow(t *testing.T) {
+	srv, st, url := newWSTestServer(t)
+	c := dialSIPWS(t, url)
+
+	writeSIPWS(t, c,
+		"REGISTER sip:example.com SIP/2.0",
+		"Via: SIP/2.0/WSS df7jal23ls0d.invalid;branch=z9hG4bK56sdasks",
+		"From: <sip:1001@example.com>;tag=x1",
+		"To: <sip:1001@example.com>",
+		"Call-ID: reg-ws-1",
+		"CSeq: 1 REGISTER",
+		"Contact: <sip:k3j2@df7jal23ls0d.invalid;transport=ws>;expires=600",
+		"Expires: 600",
+		"Content-Length: 0",
+		"", "")
+	resp := readSIPWS(t, c)
+	if resp.StatusCode != 200 {
+		t.Fatalf("REGISTER → %d", resp.StatusCode)
+	}
+
+	b, ok, _ := st.LookupRegister(context.Background(), "1001", "example.com")
+	dst := b.Sig
+	if !ok || dst == nil || !dst.IP.IsLoopback() {
+		t.Fatalf("binding = %v %v (want the flow's remote, not the .invalid host)", dst, ok)
+	}
+	if tr := srv.ep.StreamTransport(dst); tr != "WS" {
+		t.Fatalf("flow transport = %q", tr)
+	}
+
+	// Anything sent to the registered target rides the WebSocket.
+	inv := &stack.Message{IsRequest: true, Method: stack.MethodInvite, RequestURI: "sip:k3j2@df7jal23ls0d.invalid;transport=ws", Version: "SIP/2.0"}
+	inv.SetHeader("Via", "SIP/2.0/UDP 198.51.100.7:5060;branch=z9hG4bKcarrier")
+	inv.SetHeader("Call-ID", "ws-proxy-1")
+	inv.SetHeader("CSeq", "1 INVITE")
+	if err := srv.proxyInviteToRegistrar(inv, b); err != nil {
+		t.Fatal(err)
+	}
+	got := readSIPWS(t, c)
+	if got.Method != stack.MethodInvite || !strings.HasPrefix(got.GetHeader("Via"), "SIP/2.0/WS 192.0.2.10:") {
+		t.Fatalf("proxied INVITE: %s / Via %q", got.Method, got.GetHeader("Via"))
+	}
+
+	// Dropping the connection takes the AOR offline.
+	_ = c.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok, _ := st.LookupRegister(context.Background(), "1001", "example.com"); !ok {
+			if _, bound := srv.ep.Stream(dst); bound {
+				t.Fatal("flow still bound after close")
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("registration survived the WebSocket")
+}
+
+func TestServeWebSocket_InviteAnswersICELiteDTLS(t *testing.T) {
+	srv, _, url := newWSTestServer(t)
+	srv.SetInboundAllowUnknownDID(true)
+	srv.SetInboundDTLSAccept(true)
+	c := dialSIPWS(t, url)
+
+	offer := strings.Join([]string{
+		"v=0",
+		"o=- 4611731400430051336 2 IN IP4 127.0.0.1",
+		"s=-",
+		"t=0 0",
+		"a=group:BUNDLE 0",
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111 0",
+		"c=IN IP4 198.51.100.20",
+		"a=ice-ufrag:Rf3q",
+		"a=ice-pwd:6fz8VbWdJ4rPq0Zx1mN2kL3j",
+		"a=fingerprint:sha-256 AB:CD:EF:00:11:22:33:44:55:66:77:88:99:AA:BB:CC:DD:EE:FF:00:11:22:33:44:55:66:77:88:99:AA:BB:CC",
+		"a=setup:actpass",
+		"a=mid:0",
+		"a=rtcp-mux",
+		"a=rtpmap:111 opus/48000/2",
+		"a=rtpmap:0 PCMU/8000",
+		"",
+	}, "\r\n")
+	writeSIPWS(t, c,
+		"INVITE sip:8001@example.com SIP/2.0",
+		"Via: SIP/2.0/WSS df7jal23ls0d.invalid;branch=z9hG4bKinvws",
+		"From: <sip:1001@example.com>;tag=ab",
+		"To: <sip:8001@example.com>",
+		"Call-ID: inv-ws-1",
+		"CSeq: 1 INVITE",
+		"Contact: <sip:k3j2@df7jal23ls0d.invalid;transport=ws;ob>",
+		"Content-Type: application/sdp",
+		"",
+		offer)
+
+	var final *stack.Message
+	for final == nil {
+		if m := readSIPWS(t, c); !m.IsRequest && m.StatusCode >= 200 {
+			final = m
+		}
+	}
+	if final.StatusCode != 200 {
+		t.Fatalf("INVITE → %d %s", final.StatusCode, final.StatusText)
+	}
+	if ct := final.GetHeader("Contact"); !strings.Contains(ct, ";transport=ws") {
+		t.Errorf("Contact = %q", ct)
+	}
+	ans, err := sdp.Parse(final.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ans.HasICE() || !ans.RTCPMux || ans.MID != "0" || len(ans.Fingerprints) == 0 {
+		t.Fatalf("answer lacks ICE-lite / DTLS:\n%s", final.Body)
+	}
+	if i := strings.Index(final.Body, "a=ice-lite"); i < 0 || i > strings.Index(final.Body, "m=audio") ||
+		!strings.Contains(final.Body, " typ host") {
+		t.Fatalf("answer:\n%s", final.Body)
+	}
+}
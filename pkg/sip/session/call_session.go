@@ -13,8 +13,11 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/logger"
 	"github.com/LinByte/VoiceServer/pkg/media"
 	"github.com/LinByte/VoiceServer/pkg/media/encoder"
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/sip/rtp"
 	"github.com/LinByte/VoiceServer/pkg/sip/sdp"
+	"github.com/LinByte/VoiceServer/pkg/stores"
+	"github.com/LinByte/VoiceServer/pkg/stores/envelope"
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
 	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
 	"go.uber.org/zap"
@@ -70,6 +73,9 @@ type CallSession struct {
 	// a stereo WAV directly at flush time — bypassing the SN3 → WAV
 	// post-processing step that used to live in pkg/utils.
 	rec *recorder.Recorder
+	// recPaused mirrors PauseRecording (recording_pause.go) under recMu
+	// so the legacy SN3 path and a recorder attached mid-pause honour it.
+	recPaused bool
 
 	// remoteFromHeader 保存呼入 INVITE 的原始 From header（含 display-name +
 	// SIP URI），由 sip/server 在创建 CallSession 后立刻 SetRemoteFromHeader
@@ -603,6 +609,10 @@ func (cs *CallSession) Stop() {
 	// racing with teardown can't trigger a duplicate BYE on an already
 	// dead dialog.
 	cs.StopSessionTimer()
+	endFax(cs.CallID)
+	// FlushRecorder normally consumed the secure windows already; this
+	// releases them for calls that never flushed.
+	redact.ForgetSecure(cs.CallID)
 	if cs.cancel != nil {
 		cs.cancel()
 	}
@@ -643,10 +653,22 @@ func (cs *CallSession) EnableRecorder(cfg recorder.Config) bool {
 	if cfg.Codec == "" {
 		cfg.Codec = cs.neg.Name
 	}
+	// Chunk parts and the final WAV are encrypted under the call's tenant
+	// key when recording encryption is configured.
+	if cfg.Store == nil {
+		cfg.Store = stores.Default()
+	}
+	cfg.Store = envelope.Wrap(cfg.Store, cs.TenantID())
+	if cfg.Format == "" {
+		cfg.Format = recorder.FormatForTenant(context.Background(), cs.TenantID())
+	}
 	r := recorder.New(cfg)
 	if r == nil {
 		return false
 	}
+	if cs.recPaused {
+		r.Pause()
+	}
 	cs.rec = r
 	return true
 }
@@ -694,14 +716,33 @@ func (cs *CallSession) WriteAIPCM(pcm []byte) {
 	r.WriteAI(pcm)
 }
 
+// RedactRecording blanks the given wall-clock ranges on both legs of the
+// stereo recording (PII found on ASR word timestamps). No-op when the
+// recorder is not attached.
+func (cs *CallSession) RedactRecording(ranges ...redact.TimeRange) {
+	if cs == nil || len(ranges) == 0 {
+		return
+	}
+	cs.recMu.Lock()
+	r := cs.rec
+	cs.recMu.Unlock()
+	r.Redact(ranges...)
+}
+
 // FlushRecorder finalises the recording and uploads the canonical stereo
 // WAV. Returns (RecordingInfo, true) on success, (zero, false) when the
 // recorder is not attached or upload failed (consult logs). Idempotent —
 // repeated calls return false after the first success.
+//
+// Secure script-step windows (pkg/redact) collected during the call are
+// applied here and released, and a still-open recording pause is closed
+// for audit, whether or not a recorder is attached.
 func (cs *CallSession) FlushRecorder(ctx context.Context) (gateway.RecordingInfo, bool) {
 	if cs == nil {
 		return gateway.RecordingInfo{}, false
 	}
+	secure := redact.TakeSecureWindows(cs.CallID)
+	closeRecordingPause(cs.CallID, RecordingPauseSourceHangup, true)
 	cs.recMu.Lock()
 	r := cs.rec
 	cs.rec = nil
@@ -709,6 +750,9 @@ func (cs *CallSession) FlushRecorder(ctx context.Context) (gateway.RecordingInfo
 	if r == nil {
 		return gateway.RecordingInfo{}, false
 	}
+	for _, w := range secure {
+		r.Redact(redact.TimeRange{Start: w.Start, End: w.End})
+	}
 	return r.Flush(ctx)
 }
 
@@ -745,7 +789,7 @@ func (cs *CallSession) appendRecordingFrame(dir byte, seq uint16, ts uint32, p [
 	maxB := 50 * 1024 * 1024
 	cs.recMu.Lock()
 	defer cs.recMu.Unlock()
-	if len(cs.recBuf) >= maxB {
+	if cs.recPaused || len(cs.recBuf) >= maxB {
 		return
 	}
 	rem := maxB - len(cs.recBuf)
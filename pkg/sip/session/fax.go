@@ -0,0 +1,156 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package session
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fax state per call.
+//
+// Once a CNG / CED tone (or a peer T.38 re-INVITE) turns a call into a
+// fax call, FaxMode is non-empty and the conversation layer stops
+// feeding ASR / VAD. The outcome (FaxResult) outlives the session for
+// a grace period so the CDR emitted after BYE can carry it.
+
+// Call fax modes.
+const (
+	FaxModeT38Pending      = "t38_pending"      // re-INVITE to image/t38 in flight
+	FaxModeT38             = "t38"              // UDPTL receiver running
+	FaxModeG711Passthrough = "g711_passthrough" // audio relayed untouched
+)
+
+// faxHistoryTTL keeps a finished call's fax state for late CDR emitters.
+const faxHistoryTTL = 30 * time.Minute
+
+// FaxResult is the outcome of a fax call (CDR fields).
+type FaxResult struct {
+	Mode        string // FaxModeT38 / FaxModeG711Passthrough
+	Status      string // ok / partial / failed / relayed
+	Reason      string
+	Pages       int
+	RemoteID    string // sender TSI
+	BitRate     int
+	DocumentURL string
+}
+
+type faxState struct {
+	mode    string
+	result  *FaxResult
+	cancel  context.CancelFunc
+	endedAt time.Time
+}
+
+var (
+	faxMu       sync.Mutex
+	faxByCallID = map[string]*faxState{}
+)
+
+func sweepFaxLocked(now time.Time) {
+	for id, st := range faxByCallID {
+		if !st.endedAt.IsZero() && now.Sub(st.endedAt) > faxHistoryTTL {
+			delete(faxByCallID, id)
+		}
+	}
+}
+
+// BeginFax switches the call to fax handling. Only the first caller
+// gets ok=true; ctx is cancelled when the call session stops.
+func (cs *CallSession) BeginFax(mode string) (ctx context.Context, ok bool) {
+	if cs == nil {
+		return nil, false
+	}
+	faxMu.Lock()
+	defer faxMu.Unlock()
+	sweepFaxLocked(time.Now())
+	if _, exists := faxByCallID[cs.CallID]; exists {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	faxByCallID[cs.CallID] = &faxState{mode: mode, cancel: cancel}
+	return ctx, true
+}
+
+// SetFaxMode updates the mode of a call already in BeginFax.
+func (cs *CallSession) SetFaxMode(mode string) {
+	if cs == nil {
+		return
+	}
+	faxMu.Lock()
+	if st := faxByCallID[cs.CallID]; st != nil {
+		st.mode = mode
+	}
+	faxMu.Unlock()
+}
+
+// FaxMode returns the call's fax mode ("" = not a fax call).
+func (cs *CallSession) FaxMode() string {
+	if cs == nil {
+		return ""
+	}
+	return FaxModeFor(cs.CallID)
+}
+
+// FaxModeFor is FaxMode by Call-ID.
+func FaxModeFor(callID string) string {
+	faxMu.Lock()
+	defer faxMu.Unlock()
+	if st := faxByCallID[strings.TrimSpace(callID)]; st != nil && st.endedAt.IsZero() {
+		return st.mode
+	}
+	return ""
+}
+
+// SetFaxResult records the fax outcome for callID.
+func SetFaxResult(callID string, r FaxResult) {
+	faxMu.Lock()
+	defer faxMu.Unlock()
+	st := faxByCallID[strings.TrimSpace(callID)]
+	if st == nil {
+		st = &faxState{mode: r.Mode, endedAt: time.Now()}
+		faxByCallID[strings.TrimSpace(callID)] = st
+	}
+	st.result = &r
+}
+
+// FaxResultFor returns the recorded fax outcome, if any. A call that
+// entered fax mode but never finished reports Status "failed".
+func FaxResultFor(callID string) (FaxResult, bool) {
+	faxMu.Lock()
+	defer faxMu.Unlock()
+	st := faxByCallID[strings.TrimSpace(callID)]
+	if st == nil {
+		return FaxResult{}, false
+	}
+	if st.result != nil {
+		return *st.result, true
+	}
+	return FaxResult{Mode: st.mode, Status: "failed", Reason: "call ended"}, true
+}
+
+// endFax cancels a running fax receiver and starts the history TTL.
+func endFax(callID string) {
+	faxMu.Lock()
+	st := faxByCallID[callID]
+	if st != nil && st.endedAt.IsZero() {
+		st.endedAt = time.Now()
+	}
+	faxMu.Unlock()
+	if st != nil && st.cancel != nil {
+		st.cancel()
+	}
+}
+
+// DisableJitterPLC turns off packet-loss concealment on the inbound
+// RTP jitter buffer (fax / modem tones must not be patched with
+// repeated frames).
+func (cs *CallSession) DisableJitterPLC() {
+	if cs == nil {
+		return
+	}
+	cs.rxTransport.DisablePLC()
+}
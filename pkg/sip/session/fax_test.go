@@ -0,0 +1,32 @@
+package session
+
+import "testing"
+
+func TestFaxState(t *testing.T) {
+	cs := &CallSession{CallID: "fax-state-1"}
+	ctx, ok := cs.BeginFax(FaxModeT38Pending)
+	if !ok || cs.FaxMode() != FaxModeT38Pending {
+		t.Fatalf("BeginFax ok=%v mode=%q", ok, cs.FaxMode())
+	}
+	if _, again := cs.BeginFax(FaxModeT38Pending); again {
+		t.Fatal("second BeginFax succeeded")
+	}
+	cs.SetFaxMode(FaxModeT38)
+	if FaxModeFor("fax-state-1") != FaxModeT38 {
+		t.Fatalf("mode = %q", FaxModeFor("fax-state-1"))
+	}
+	if r, ok := FaxResultFor("fax-state-1"); !ok || r.Status != "failed" || r.Mode != FaxModeT38 {
+		t.Fatalf("unfinished result = %+v", r)
+	}
+	SetFaxResult("fax-state-1", FaxResult{Mode: FaxModeT38, Status: "ok", Pages: 2})
+	endFax("fax-state-1")
+	if ctx.Err() == nil {
+		t.Fatal("fax context not cancelled at teardown")
+	}
+	if cs.FaxMode() != "" {
+		t.Fatal("mode still reported after teardown")
+	}
+	if r, ok := FaxResultFor("fax-state-1"); !ok || r.Pages != 2 {
+		t.Fatalf("result after teardown = %+v", r)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
 	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
 )
@@ -162,3 +163,19 @@ func TestCallSession_EnableRecorderForcesSessionFields(t *testing.T) {
 	// ByePersistParams.WAVRecording without conversion.
 	var _ gateway.RecordingInfo = info
 }
+
+// A call without a recorder never reaches FlushRecorder's
+// TakeSecureWindows; Stop must still release its secure windows.
+func TestStopReleasesSecureWindowsWithoutRecorder(t *testing.T) {
+	cs := &CallSession{CallID: "ut-secure-no-rec", pcmSampleRate: 8000}
+	redact.BeginSecure(cs.CallID)
+	redact.EndSecure(cs.CallID)
+	redact.BeginSecure(cs.CallID)
+	cs.Stop()
+	if redact.InSecure(cs.CallID) {
+		t.Fatal("open secure window survived Stop")
+	}
+	if ws := redact.TakeSecureWindows(cs.CallID); ws != nil {
+		t.Fatalf("secure windows leaked after Stop: %+v", ws)
+	}
+}
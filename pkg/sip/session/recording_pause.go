@@ -0,0 +1,222 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recording pause / resume for PCI-sensitive segments.
+//
+// While a call is paused the stereo recorder drops both legs, the legacy
+// SN3 blob skips RTP frames, and RecordingPausedFor reports true so the
+// transcript persistence path can suppress ASR text. Pause is enforced
+// where text is stored or logged, not at recognition: ASR keeps running
+// so the dialog continues, and the conversation package replaces the
+// paused speech with a marker in persisted turns and log lines
+// (pausedOrRedacted). Every closed pause
+// is handed to the audit sink (SetRecordingPauseAudit) with who paused,
+// who resumed and how long it lasted. Pause history stays queryable by
+// Call-ID for a short grace period after teardown so CDR emitters that
+// run after FlushRecorder still see it.
+
+// Recording pause sources.
+const (
+	RecordingPauseSourceAPI    = "api"
+	RecordingPauseSourceScript = "script"
+	RecordingPauseSourceHangup = "hangup" // ResumedBy when a pause was still open at teardown
+)
+
+var (
+	ErrRecordingAlreadyPaused = errors.New("recording already paused")
+	ErrRecordingNotPaused     = errors.New("recording not paused")
+)
+
+// RecordingPause is one pause interval on a call (audit row / CDR entry).
+// End is zero while the pause is still open.
+type RecordingPause struct {
+	CallID    string
+	TenantID  uint
+	Source    string // api / script
+	Reason    string
+	PausedBy  string
+	ResumedBy string
+	Start     time.Time
+	End       time.Time
+}
+
+// Duration returns End-Start, measured up to now for an open pause.
+func (p RecordingPause) Duration() time.Duration {
+	end := p.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(p.Start)
+}
+
+// pauseHistoryTTL keeps a finished call's pauses around for late CDR
+// emitters; entries are swept on the next pause or call teardown.
+const pauseHistoryTTL = 10 * time.Minute
+
+type pauseHistory struct {
+	pauses  []RecordingPause
+	endedAt time.Time // set at teardown
+}
+
+var (
+	pauseMu       sync.Mutex
+	pauseByCallID = map[string]*pauseHistory{}
+	pauseAudit    func(ctx context.Context, p RecordingPause)
+)
+
+// SetRecordingPauseAudit installs the sink receiving each closed pause
+// (wired to the sip_recording_pauses table by the SIP app). nil disables.
+func SetRecordingPauseAudit(fn func(ctx context.Context, p RecordingPause)) {
+	pauseMu.Lock()
+	pauseAudit = fn
+	pauseMu.Unlock()
+}
+
+// RecordingPausedFor reports whether callID currently has recording
+// paused. Used by paths that only know the Call-ID (dialog turn
+// persistence).
+func RecordingPausedFor(callID string) bool {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	h := pauseByCallID[strings.TrimSpace(callID)]
+	if h == nil || len(h.pauses) == 0 {
+		return false
+	}
+	return h.pauses[len(h.pauses)-1].End.IsZero()
+}
+
+// RecordingPausesFor returns a copy of every pause recorded on callID
+// (the last one may still be open).
+func RecordingPausesFor(callID string) []RecordingPause {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	h := pauseByCallID[strings.TrimSpace(callID)]
+	if h == nil {
+		return nil
+	}
+	return append([]RecordingPause(nil), h.pauses...)
+}
+
+// RecordingPauseTotals summarises callID's pauses for CDR rows: count
+// and total paused time (an open pause counts up to now).
+func RecordingPauseTotals(callID string) (n int, total time.Duration) {
+	for _, p := range RecordingPausesFor(callID) {
+		n++
+		total += p.Duration()
+	}
+	return n, total
+}
+
+func sweepPauseHistoryLocked(now time.Time) {
+	for id, h := range pauseByCallID {
+		if !h.endedAt.IsZero() && now.Sub(h.endedAt) > pauseHistoryTTL {
+			delete(pauseByCallID, id)
+		}
+	}
+}
+
+// PauseRecording stops capturing both legs of the call recording until
+// ResumeRecording. source is RecordingPauseSourceAPI / Script; by names
+// the operator (user id, "script:<id>/<step>").
+func (cs *CallSession) PauseRecording(source, by, reason string) error {
+	if cs == nil {
+		return ErrRecordingNotPaused
+	}
+	now := time.Now()
+	pauseMu.Lock()
+	sweepPauseHistoryLocked(now)
+	h := pauseByCallID[cs.CallID]
+	if h == nil {
+		h = &pauseHistory{}
+		pauseByCallID[cs.CallID] = h
+	}
+	if n := len(h.pauses); n > 0 && h.pauses[n-1].End.IsZero() {
+		pauseMu.Unlock()
+		return ErrRecordingAlreadyPaused
+	}
+	h.pauses = append(h.pauses, RecordingPause{
+		CallID:   cs.CallID,
+		TenantID: cs.TenantID(),
+		Source:   strings.TrimSpace(source),
+		Reason:   strings.TrimSpace(reason),
+		PausedBy: strings.TrimSpace(by),
+		Start:    now,
+	})
+	pauseMu.Unlock()
+
+	cs.recMu.Lock()
+	cs.recPaused = true
+	r := cs.rec
+	cs.recMu.Unlock()
+	r.Pause()
+	return nil
+}
+
+// ResumeRecording restarts capture after PauseRecording and returns the
+// closed pause (also delivered to the audit sink).
+func (cs *CallSession) ResumeRecording(by string) (RecordingPause, error) {
+	if cs == nil {
+		return RecordingPause{}, ErrRecordingNotPaused
+	}
+	p, ok := closeRecordingPause(cs.CallID, by, false)
+	if !ok {
+		return RecordingPause{}, ErrRecordingNotPaused
+	}
+	cs.recMu.Lock()
+	cs.recPaused = false
+	r := cs.rec
+	cs.recMu.Unlock()
+	r.Resume()
+	return p, nil
+}
+
+// RecordingPaused reports whether this call's recording is paused.
+func (cs *CallSession) RecordingPaused() bool {
+	if cs == nil {
+		return false
+	}
+	return RecordingPausedFor(cs.CallID)
+}
+
+// closeRecordingPause ends the open pause on callID (if any) and emits
+// it to the audit sink. teardown marks the history for TTL sweeping and
+// sweeps calls whose grace period is over.
+func closeRecordingPause(callID, by string, teardown bool) (RecordingPause, bool) {
+	now := time.Now()
+	pauseMu.Lock()
+	if teardown {
+		sweepPauseHistoryLocked(now)
+	}
+	h := pauseByCallID[callID]
+	if h == nil {
+		pauseMu.Unlock()
+		return RecordingPause{}, false
+	}
+	if teardown {
+		h.endedAt = now
+	}
+	n := len(h.pauses)
+	if n == 0 || !h.pauses[n-1].End.IsZero() {
+		pauseMu.Unlock()
+		return RecordingPause{}, false
+	}
+	h.pauses[n-1].End = now
+	h.pauses[n-1].ResumedBy = strings.TrimSpace(by)
+	p := h.pauses[n-1]
+	audit := pauseAudit
+	pauseMu.Unlock()
+	if audit != nil {
+		audit(context.Background(), p)
+	}
+	return p, true
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/voice/recorder"
+)
+
+func TestCallSession_PauseResumeRecording(t *testing.T) {
+	cs := &CallSession{CallID: "ut-pause", pcmSampleRate: 8000}
+	var audited []RecordingPause
+	SetRecordingPauseAudit(func(_ context.Context, p RecordingPause) { audited = append(audited, p) })
+	defer SetRecordingPauseAudit(nil)
+
+	if _, err := cs.ResumeRecording("agent"); !errors.Is(err, ErrRecordingNotPaused) {
+		t.Fatalf("resume before pause: %v", err)
+	}
+	if err := cs.PauseRecording(RecordingPauseSourceAPI, "agent-1", "card number"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.PauseRecording(RecordingPauseSourceAPI, "agent-1", ""); !errors.Is(err, ErrRecordingAlreadyPaused) {
+		t.Fatalf("double pause: %v", err)
+	}
+	if !cs.RecordingPaused() || !RecordingPausedFor("ut-pause") {
+		t.Fatal("call should report paused")
+	}
+	// A recorder enabled mid-pause starts paused.
+	if !cs.EnableRecorder(recorder.Config{Store: &fakeStore{}}) || !cs.rec.Paused() {
+		t.Fatal("recorder enabled during pause must start paused")
+	}
+	p, err := cs.ResumeRecording("agent-2")
+	if err != nil || p.PausedBy != "agent-1" || p.ResumedBy != "agent-2" || p.Reason != "card number" {
+		t.Fatalf("resume: %+v err=%v", p, err)
+	}
+	if cs.RecordingPaused() || cs.rec.Paused() {
+		t.Fatal("call should no longer be paused")
+	}
+
+	// A pause left open at hangup is closed by FlushRecorder.
+	_ = cs.PauseRecording(RecordingPauseSourceScript, "script:s/1", "")
+	cs.FlushRecorder(context.Background())
+	if len(audited) != 2 || audited[1].ResumedBy != RecordingPauseSourceHangup {
+		t.Fatalf("audit: %+v", audited)
+	}
+	if n, _ := RecordingPauseTotals("ut-pause"); n != 2 {
+		t.Fatalf("totals n=%d, want 2 (history kept for CDR)", n)
+	}
+}
+
+func TestFlushRecorder_SweepsExpiredPauseHistory(t *testing.T) {
+	pauseMu.Lock()
+	pauseByCallID["ut-pause-old"] = &pauseHistory{
+		pauses:  []RecordingPause{{CallID: "ut-pause-old", Start: time.Now().Add(-time.Hour), End: time.Now().Add(-time.Hour)}},
+		endedAt: time.Now().Add(-pauseHistoryTTL - time.Minute),
+	}
+	pauseMu.Unlock()
+
+	cs := &CallSession{CallID: "ut-pause-sweep", pcmSampleRate: 8000}
+	cs.FlushRecorder(context.Background())
+	if RecordingPausesFor("ut-pause-old") != nil {
+		t.Fatal("expired pause history should be swept at teardown")
+	}
+}
@@ -63,6 +63,8 @@ type Endpoint struct {
 	mu       sync.Mutex
 	handlers map[string]HandlerFunc
 	tr       *UDPTransport
+	// streams are connection-oriented flows Send prefers over UDP (stream.go).
+	streams map[string]StreamConn
 }
 
 // NewEndpoint constructs an endpoint. Call Open then Serve.
@@ -135,11 +137,15 @@ func (e *Endpoint) ListenAddr() net.Addr {
 	return e.tr.conn.LocalAddr()
 }
 
-// Send writes a SIP message to addr using the bound UDP socket.
+// Send writes a SIP message to addr: over the flow bound to addr (BindStream)
+// when there is one, else on the bound UDP socket.
 func (e *Endpoint) Send(msg *Message, addr *net.UDPAddr) error {
 	if e == nil {
 		return fmt.Errorf("sip1/stack: nil endpoint")
 	}
+	if ok, err := e.sendStream(msg, addr); ok {
+		return err
+	}
 	e.mu.Lock()
 	tr := e.tr
 	e.mu.Unlock()
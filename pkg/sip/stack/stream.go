@@ -0,0 +1,86 @@
+package stack
+
+import (
+	"fmt"
+	"net"
+)
+
+// StreamConn is a connection-oriented SIP flow accepted by the server
+// (e.g. SIP over WebSocket, RFC 7118). While bound, Endpoint.Send
+// writes messages for the flow's remote address onto it instead of the
+// UDP socket, so responses, in-dialog requests and INVITEs routed to a
+// registered contact follow the connection the UA opened.
+type StreamConn interface {
+	// SendMessage writes one complete SIP message as a single frame.
+	SendMessage(msg *Message) error
+	// Transport is the Via transport token ("WS", "WSS").
+	Transport() string
+}
+
+// streamKey identifies a flow by the synthesised remote address.
+func streamKey(addr *net.UDPAddr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+// BindStream routes sends for addr over c until UnbindStream.
+func (e *Endpoint) BindStream(addr *net.UDPAddr, c StreamConn) {
+	k := streamKey(addr)
+	if e == nil || k == "" || c == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.streams == nil {
+		e.streams = make(map[string]StreamConn)
+	}
+	e.streams[k] = c
+}
+
+// UnbindStream removes the route for addr when it still points at c
+// (a reconnect from the same address may already have replaced it).
+func (e *Endpoint) UnbindStream(addr *net.UDPAddr, c StreamConn) {
+	k := streamKey(addr)
+	if e == nil || k == "" {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if cur, ok := e.streams[k]; ok && cur == c {
+		delete(e.streams, k)
+	}
+}
+
+// Stream returns the flow bound to addr, if any.
+func (e *Endpoint) Stream(addr *net.UDPAddr) (StreamConn, bool) {
+	k := streamKey(addr)
+	if e == nil || k == "" {
+		return nil, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.streams[k]
+	return c, ok
+}
+
+// StreamTransport returns the Via transport token for addr: the bound
+// flow's token, or "UDP".
+func (e *Endpoint) StreamTransport(addr *net.UDPAddr) string {
+	if c, ok := e.Stream(addr); ok {
+		return c.Transport()
+	}
+	return "UDP"
+}
+
+func (e *Endpoint) sendStream(msg *Message, addr *net.UDPAddr) (bool, error) {
+	c, ok := e.Stream(addr)
+	if !ok {
+		return false, nil
+	}
+	if msg == nil {
+		return true, fmt.Errorf("sip1/stack: nil message")
+	}
+	return true, c.SendMessage(msg)
+}
@@ -0,0 +1,53 @@
+package stack
+
+import (
+	"net"
+	"testing"
+)
+
+type fakeStream struct {
+	token string
+	got   []*Message
+}
+
+func (f *fakeStream) SendMessage(msg *Message) error {
+	f.got = append(f.got, msg)
+	return nil
+}
+
+func (f *fakeStream) Transport() string { return f.token }
+
+func TestEndpoint_SendPrefersBoundStream(t *testing.T) {
+	ep := NewEndpoint(EndpointConfig{Host: "127.0.0.1"})
+	addr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 7), Port: 53211}
+	ws := &fakeStream{token: "WSS"}
+	ep.BindStream(addr, ws)
+
+	msg := &Message{IsRequest: true, Method: MethodOptions, RequestURI: "sip:u@example.invalid", Version: "SIP/2.0"}
+	if err := ep.Send(msg, addr); err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.got) != 1 || ws.got[0] != msg {
+		t.Fatalf("stream got %d messages", len(ws.got))
+	}
+	if tr := ep.StreamTransport(addr); tr != "WSS" {
+		t.Fatalf("StreamTransport = %q", tr)
+	}
+	if tr := ep.StreamTransport(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 8), Port: 5060}); tr != "UDP" {
+		t.Fatalf("unbound StreamTransport = %q", tr)
+	}
+
+	// A stale flow must not drop the route of its replacement.
+	ep.UnbindStream(addr, &fakeStream{token: "WS"})
+	if _, ok := ep.Stream(addr); !ok {
+		t.Fatal("unbind by another conn removed the route")
+	}
+	ep.UnbindStream(addr, ws)
+	if _, ok := ep.Stream(addr); ok {
+		t.Fatal("route survived unbind")
+	}
+	// Falls back to UDP, which is not open here.
+	if err := ep.Send(msg, addr); err == nil {
+		t.Fatal("expected UDP send error on unopened endpoint")
+	}
+}
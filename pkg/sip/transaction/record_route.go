@@ -1,6 +1,8 @@
 package transaction
 
 import (
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/LinByte/VoiceServer/pkg/sip/stack"
@@ -12,16 +14,120 @@ func RouteHeadersForDialog(resp *stack.Message) []string {
 	if resp == nil {
 		return nil
 	}
-	rr := resp.GetHeaders("Record-Route")
+	rr := SplitRouteValues(resp.GetHeaders("Record-Route"))
 	if len(rr) == 0 {
 		return nil
 	}
 	out := make([]string, 0, len(rr))
 	for i := len(rr) - 1; i >= 0; i-- {
-		v := strings.TrimSpace(rr[i])
-		if v != "" {
-			out = append(out, v)
+		out = append(out, rr[i])
+	}
+	return out
+}
+
+// RouteSetForUAS returns the UAS-side route set of a dialog-creating request:
+// its Record-Route values in appearance order (RFC 3261 §12.1.1).
+func RouteSetForUAS(req *stack.Message) []string {
+	if req == nil {
+		return nil
+	}
+	return SplitRouteValues(req.GetHeaders("Record-Route"))
+}
+
+// SplitRouteValues splits Route / Record-Route / Path / Service-Route field-values,
+// which may be comma-joined in one header line. Commas inside <...> or quotes are kept.
+func SplitRouteValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		depth, quoted, start := 0, false, 0
+		for i, c := range v {
+			switch {
+			case c == '"':
+				quoted = !quoted
+			case quoted:
+			case c == '<':
+				depth++
+			case c == '>':
+				depth--
+			case c == ',' && depth == 0:
+				if s := strings.TrimSpace(v[start:i]); s != "" {
+					out = append(out, s)
+				}
+				start = i + 1
+			}
+		}
+		if s := strings.TrimSpace(v[start:]); s != "" {
+			out = append(out, s)
 		}
 	}
 	return out
 }
+
+// SetRouteHeaders replaces msg's Route header fields with routes, top first.
+// An empty route set removes Route entirely.
+func SetRouteHeaders(msg *stack.Message, routes []string) {
+	if msg == nil {
+		return
+	}
+	delete(msg.Headers, "route")
+	delete(msg.HeadersMulti, "route")
+	for _, r := range routes {
+		if r = strings.TrimSpace(r); r != "" {
+			msg.AddHeader("Route", r)
+		}
+	}
+}
+
+// RouteHostPort returns the host and port (0 when absent) of a route or URI value,
+// e.g. "<sip:edge.example.com:5070;lr>" -> edge.example.com, 5070.
+func RouteHostPort(route string) (host string, port int, ok bool) {
+	u := strings.TrimSpace(route)
+	if i := strings.Index(u, "<"); i >= 0 {
+		u = u[i+1:]
+		if j := strings.Index(u, ">"); j >= 0 {
+			u = u[:j]
+		}
+	}
+	lower := strings.ToLower(u)
+	switch {
+	case strings.HasPrefix(lower, "sips:"):
+		u = u[5:]
+	case strings.HasPrefix(lower, "sip:"):
+		u = u[4:]
+	default:
+		return "", 0, false
+	}
+	if i := strings.IndexAny(u, ";?"); i >= 0 {
+		u = u[:i]
+	}
+	if at := strings.LastIndex(u, "@"); at >= 0 {
+		u = u[at+1:]
+	}
+	if h, p, err := net.SplitHostPort(u); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return "", 0, false
+		}
+		return h, n, h != ""
+	}
+	u = strings.TrimSuffix(strings.TrimPrefix(u, "["), "]")
+	return u, 0, u != ""
+}
+
+// IsLooseRoute reports whether a route value's URI carries the ;lr parameter.
+func IsLooseRoute(route string) bool {
+	u := route
+	if i := strings.Index(u, "<"); i >= 0 {
+		u = u[i+1:]
+		if j := strings.Index(u, ">"); j >= 0 {
+			u = u[:j]
+		}
+	}
+	for _, p := range strings.Split(u, ";")[1:] {
+		k, _, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.EqualFold(k, "lr") {
+			return true
+		}
+	}
+	return false
+}
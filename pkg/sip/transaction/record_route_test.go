@@ -0,0 +1,72 @@
+package transaction
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/LinByte/VoiceServer/pkg/sip/stack"
+)
+
+func TestRouteSets(t *testing.T) {
+	raw := "INVITE sip:a@b SIP/2.0\r\n" +
+		"Record-Route: <sip:p1.example.com;lr>, <sip:p2.example.com;lr>\r\n" +
+		"Record-Route: <sip:p3.example.com:5070;lr>\r\n" +
+		"Call-ID: c\r\nCSeq: 1 INVITE\r\nContent-Length: 0\r\n\r\n"
+	m, err := stack.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uas := RouteSetForUAS(m)
+	want := []string{"<sip:p1.example.com;lr>", "<sip:p2.example.com;lr>", "<sip:p3.example.com:5070;lr>"}
+	if !reflect.DeepEqual(uas, want) {
+		t.Fatalf("uas route set %q", uas)
+	}
+	uac := RouteHeadersForDialog(m)
+	if len(uac) != 3 || uac[0] != want[2] || uac[2] != want[0] {
+		t.Fatalf("uac route set %q", uac)
+	}
+}
+
+func TestSplitRouteValuesKeepsQuotedCommas(t *testing.T) {
+	got := SplitRouteValues([]string{`"Edge, Inc" <sip:e1;lr>,<sip:e2;lr>`})
+	if len(got) != 2 || got[0] != `"Edge, Inc" <sip:e1;lr>` {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestSetRouteHeaders(t *testing.T) {
+	m := &stack.Message{IsRequest: true, Method: "BYE", RequestURI: "sip:x", Version: "SIP/2.0"}
+	m.SetHeader("Route", "<sip:old;lr>")
+	SetRouteHeaders(m, []string{"<sip:a;lr>", "<sip:b;lr>"})
+	if got := m.GetHeaders("Route"); len(got) != 2 || got[0] != "<sip:a;lr>" {
+		t.Fatalf("%q", got)
+	}
+	SetRouteHeaders(m, nil)
+	if m.GetHeader("Route") != "" || len(m.GetHeaders("Route")) != 0 {
+		t.Fatal("route not removed")
+	}
+}
+
+func TestRouteHostPort(t *testing.T) {
+	cases := []struct {
+		in   string
+		host string
+		port int
+	}{
+		{"<sip:edge.example.com:5070;lr>", "edge.example.com", 5070},
+		{"<sip:sbc@10.0.0.1;lr>", "10.0.0.1", 0},
+		{"sip:[2001:db8::1]:5060;lr", "2001:db8::1", 5060},
+	}
+	for _, c := range cases {
+		h, p, ok := RouteHostPort(c.in)
+		if !ok || h != c.host || p != c.port {
+			t.Errorf("%s -> %q %d %v", c.in, h, p, ok)
+		}
+	}
+	if _, _, ok := RouteHostPort("<tel:+1555>"); ok {
+		t.Error("tel URI accepted")
+	}
+	if !IsLooseRoute("<sip:p;lr>") || IsLooseRoute("<sip:p>") {
+		t.Error("IsLooseRoute")
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package udptl is the T.38 fax transport: UDPTL framing (ITU-T T.38
+// Annex / RFC 3362 image/t38) and the IFP (Internet Facsimile Protocol)
+// packets it carries.
+//
+// Framing (aligned PER, as spoken by spandsp / Asterisk / FreeSWITCH):
+//   - seq-number: 2 bytes big-endian.
+//   - primary-ifp-packet: open type (length determinant + IFP bytes).
+//   - error-recovery: 0x00 + count + secondary IFPs (newest first) for
+//     t38UDPRedundancy; 0x80 for t38UDPFEC (FEC is parsed past but not
+//     used for recovery).
+//
+// Session owns one UDP socket per fax leg. Send repeats the last
+// Redundancy IFPs as secondaries; Receive returns IFPs in sequence
+// order, filling gaps from the secondaries of the next packet and
+// dropping duplicates. The T.30 engine on top lives in pkg/sip/fax.
+package udptl
@@ -0,0 +1,180 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package udptl
+
+// T.30 indicators (T.38 §6.1 Type-of-msg t30-indicator).
+const (
+	IndNoSignal = iota
+	IndCNG
+	IndCED
+	IndV21Preamble
+	IndV27_2400Training
+	IndV27_4800Training
+	IndV29_7200Training
+	IndV29_9600Training
+	IndV17_7200ShortTraining
+	IndV17_7200LongTraining
+	IndV17_9600ShortTraining
+	IndV17_9600LongTraining
+	IndV17_12000ShortTraining
+	IndV17_12000LongTraining
+	IndV17_14400ShortTraining
+	IndV17_14400LongTraining
+)
+
+// Data types (T.38 §6.1 Type-of-msg data): the modem the payload
+// would have travelled over.
+const (
+	DataV21 = iota
+	DataV27_2400
+	DataV27_4800
+	DataV29_7200
+	DataV29_9600
+	DataV17_7200
+	DataV17_9600
+	DataV17_12000
+	DataV17_14400
+)
+
+// Field types (T.38 §6.1 Data-Field).
+const (
+	FieldHDLCData = iota
+	FieldHDLCSigEnd
+	FieldHDLCFCSOK
+	FieldHDLCFCSBad
+	FieldHDLCFCSOKSigEnd
+	FieldHDLCFCSBadSigEnd
+	FieldT4NonECMData
+	FieldT4NonECMSigEnd
+)
+
+// DataRate returns the bit rate of a data type (0 if unknown).
+func DataRate(dataType int) int {
+	switch dataType {
+	case DataV21:
+		return 300
+	case DataV27_2400:
+		return 2400
+	case DataV27_4800:
+		return 4800
+	case DataV29_7200, DataV17_7200:
+		return 7200
+	case DataV29_9600, DataV17_9600:
+		return 9600
+	case DataV17_12000:
+		return 12000
+	case DataV17_14400:
+		return 14400
+	}
+	return 0
+}
+
+// Field is one element of an IFP data-field.
+type Field struct {
+	Type int
+	Data []byte
+}
+
+// IFP is a decoded Internet Facsimile Protocol packet: either an
+// indicator (Data false) or a data message with fields.
+type IFP struct {
+	Data      bool
+	Indicator int // valid when !Data
+	DataType  int // valid when Data
+	Fields    []Field
+}
+
+// Indicator builds an indicator IFP.
+func Indicator(ind int) IFP { return IFP{Indicator: ind} }
+
+// DataIFP builds a data IFP.
+func DataIFP(dataType int, fields ...Field) IFP {
+	return IFP{Data: true, DataType: dataType, Fields: fields}
+}
+
+// Marshal encodes the IFP. version is the negotiated T38FaxVersion:
+// version 0 packs the field type in 3 bits without the extension bit
+// (the original T.38 ASN.1), later versions add it.
+func (p IFP) Marshal(version int) []byte {
+	if !p.Data {
+		return []byte{byte(p.Indicator&0x0F) << 1}
+	}
+	hasFields := len(p.Fields) > 0
+	b0 := byte(0x40) | byte(p.DataType&0x0F)<<1
+	if hasFields {
+		b0 |= 0x80
+	}
+	out := []byte{b0}
+	if !hasFields {
+		return out
+	}
+	out = appendLength(out, len(p.Fields))
+	for _, f := range p.Fields {
+		hdr := byte(0)
+		if len(f.Data) > 0 {
+			hdr = 0x80
+		}
+		if version == 0 {
+			hdr |= byte(f.Type&0x07) << 4
+		} else {
+			hdr |= byte(f.Type&0x07) << 3
+		}
+		out = append(out, hdr)
+		if len(f.Data) > 0 {
+			n := len(f.Data) - 1
+			out = append(out, byte(n>>8), byte(n))
+			out = append(out, f.Data...)
+		}
+	}
+	return out
+}
+
+// ParseIFP decodes an IFP packet (see Marshal for version).
+func ParseIFP(b []byte, version int) (IFP, error) {
+	if len(b) == 0 {
+		return IFP{}, ErrMalformed
+	}
+	if b[0]&0x40 == 0 {
+		if b[0]&0x20 != 0 {
+			// Extension indicators (V.34 / V.33 / V.8) are not supported.
+			return IFP{}, ErrMalformed
+		}
+		return IFP{Indicator: int(b[0]>>1) & 0x0F}, nil
+	}
+	p := IFP{Data: true, DataType: int(b[0]>>1) & 0x0F}
+	if b[0]&0x80 == 0 {
+		return p, nil
+	}
+	n, off, err := readLength(b, 1)
+	if err != nil {
+		return IFP{}, err
+	}
+	for i := 0; i < n; i++ {
+		if off >= len(b) {
+			return IFP{}, ErrMalformed
+		}
+		hdr := b[off]
+		off++
+		f := Field{}
+		if version == 0 {
+			f.Type = int(hdr>>4) & 0x07
+		} else {
+			f.Type = int(hdr>>3) & 0x07
+		}
+		if hdr&0x80 != 0 {
+			if off+2 > len(b) {
+				return IFP{}, ErrMalformed
+			}
+			l := int(b[off])<<8 | int(b[off+1]) + 1
+			off += 2
+			if off+l > len(b) {
+				return IFP{}, ErrMalformed
+			}
+			f.Data = b[off : off+l]
+			off += l
+		}
+		p.Fields = append(p.Fields, f)
+	}
+	return p, nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package udptl
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultRedundancy is how many earlier IFPs ride along as secondaries.
+const DefaultRedundancy = 3
+
+// Session is one UDPTL leg over its own UDP socket.
+type Session struct {
+	conn *net.UDPConn
+
+	mu         sync.Mutex
+	remote     *net.UDPAddr
+	redundancy int
+	txSeq      uint16
+	history    [][]byte // last sent primaries, newest first
+
+	rxStarted  bool
+	rxExpected uint16
+	rxBuf      []byte
+}
+
+// NewSession binds a UDPTL socket on port (0 = ephemeral).
+func NewSession(port int) (*Session, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero, Port: port})
+	if err != nil {
+		return nil, err
+	}
+	return &Session{conn: conn, redundancy: DefaultRedundancy, rxBuf: make([]byte, 2048)}, nil
+}
+
+// LocalAddr is the bound socket address.
+func (s *Session) LocalAddr() *net.UDPAddr {
+	return s.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// SetRemoteAddr sets the SDP-advertised peer. The first datagram
+// received re-latches it (symmetric UDPTL behind NAT).
+func (s *Session) SetRemoteAddr(addr *net.UDPAddr) {
+	s.mu.Lock()
+	s.remote = addr
+	s.mu.Unlock()
+}
+
+// SetRedundancy sets the number of secondary IFPs per packet (0 = none).
+func (s *Session) SetRedundancy(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.mu.Lock()
+	s.redundancy = n
+	if len(s.history) > n {
+		s.history = s.history[:n]
+	}
+	s.mu.Unlock()
+}
+
+// Send transmits one IFP as the primary of the next UDPTL packet.
+func (s *Session) Send(ifp []byte) error {
+	s.mu.Lock()
+	remote := s.remote
+	if remote == nil {
+		s.mu.Unlock()
+		return errors.New("udptl: no remote address")
+	}
+	pkt := Packet{Seq: s.txSeq, Primary: ifp, Secondary: s.history}
+	b, err := pkt.Marshal()
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.txSeq++
+	if s.redundancy > 0 {
+		cp := append([]byte(nil), ifp...)
+		s.history = append([][]byte{cp}, s.history...)
+		if len(s.history) > s.redundancy {
+			s.history = s.history[:s.redundancy]
+		}
+	}
+	s.mu.Unlock()
+	_, err = s.conn.WriteToUDP(b, remote)
+	return err
+}
+
+// Receive waits up to timeout for the next datagram and returns the
+// IFPs it carries in sequence order: recovered secondaries for any
+// gap, then the primary. Duplicates and malformed datagrams are
+// skipped. It returns (nil, nil) on timeout.
+func (s *Session) Receive(timeout time.Duration) ([][]byte, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := s.conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, from, err := s.conn.ReadFromUDP(s.rxBuf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		pkt, err := Unmarshal(s.rxBuf[:n])
+		if err != nil {
+			continue
+		}
+		s.mu.Lock()
+		if s.remote == nil || !s.remote.IP.Equal(from.IP) || s.remote.Port != from.Port {
+			s.remote = from
+		}
+		out := s.accept(pkt)
+		s.mu.Unlock()
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+}
+
+// accept runs the in-order / recovery logic; s.mu held.
+func (s *Session) accept(pkt *Packet) [][]byte {
+	if !s.rxStarted {
+		s.rxStarted = true
+		s.rxExpected = pkt.Seq
+	}
+	gap := int16(pkt.Seq - s.rxExpected)
+	if gap < 0 {
+		return nil
+	}
+	var out [][]byte
+	for seq := s.rxExpected; seq != pkt.Seq; seq++ {
+		idx := int(pkt.Seq - seq - 1)
+		if idx < len(pkt.Secondary) {
+			out = append(out, append([]byte(nil), pkt.Secondary[idx]...))
+		}
+	}
+	out = append(out, append([]byte(nil), pkt.Primary...))
+	s.rxExpected = pkt.Seq + 1
+	return out
+}
+
+// Close releases the socket; a blocked Receive returns an error.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package udptl
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrMalformed is returned for UDPTL / IFP bytes that do not decode.
+var ErrMalformed = errors.New("udptl: malformed packet")
+
+// Error-recovery choice tags.
+const (
+	recoverySecondary = 0x00
+	recoveryFEC       = 0x80
+)
+
+// maxOpenType is the largest open type we encode without X.691
+// fragmentation (two-byte length determinant).
+const maxOpenType = 16383
+
+// Packet is one UDPTL datagram.
+type Packet struct {
+	Seq     uint16
+	Primary []byte
+	// Secondary holds redundant copies of earlier IFPs, newest first:
+	// Secondary[0] is the primary of Seq-1.
+	Secondary [][]byte
+}
+
+// Marshal encodes p with redundancy error recovery.
+func (p *Packet) Marshal() ([]byte, error) {
+	out := make([]byte, 2, 8+len(p.Primary))
+	binary.BigEndian.PutUint16(out, p.Seq)
+	var err error
+	if out, err = appendOpenType(out, p.Primary); err != nil {
+		return nil, err
+	}
+	out = append(out, recoverySecondary)
+	out = appendLength(out, len(p.Secondary))
+	for _, s := range p.Secondary {
+		if out, err = appendOpenType(out, s); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Unmarshal decodes a UDPTL datagram. FEC error recovery is accepted
+// but its content is dropped (Secondary stays empty).
+func Unmarshal(b []byte) (*Packet, error) {
+	if len(b) < 3 {
+		return nil, ErrMalformed
+	}
+	p := &Packet{Seq: binary.BigEndian.Uint16(b)}
+	var off int
+	var err error
+	if p.Primary, off, err = readOpenType(b, 2); err != nil {
+		return nil, err
+	}
+	if off >= len(b) {
+		// Some stacks omit error recovery entirely on the first packet.
+		return p, nil
+	}
+	if b[off]&0x80 == recoveryFEC {
+		return p, nil
+	}
+	off++
+	n, off, err := readLength(b, off)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		var s []byte
+		if s, off, err = readOpenType(b, off); err != nil {
+			return nil, err
+		}
+		p.Secondary = append(p.Secondary, s)
+	}
+	return p, nil
+}
+
+func appendLength(out []byte, n int) []byte {
+	if n < 0x80 {
+		return append(out, byte(n))
+	}
+	return append(out, 0x80|byte(n>>8), byte(n))
+}
+
+func appendOpenType(out, v []byte) ([]byte, error) {
+	if len(v) > maxOpenType {
+		return nil, fmt.Errorf("udptl: open type of %d bytes needs fragmentation", len(v))
+	}
+	out = appendLength(out, len(v))
+	return append(out, v...), nil
+}
+
+func readLength(b []byte, off int) (n, next int, err error) {
+	if off >= len(b) {
+		return 0, 0, ErrMalformed
+	}
+	switch {
+	case b[off]&0x80 == 0:
+		return int(b[off]), off + 1, nil
+	case b[off]&0xC0 == 0x80 && off+1 < len(b):
+		return int(b[off]&0x3F)<<8 | int(b[off+1]), off + 2, nil
+	default:
+		// 0xC0: fragmented — never produced for fax-sized IFPs.
+		return 0, 0, ErrMalformed
+	}
+}
+
+func readOpenType(b []byte, off int) (v []byte, next int, err error) {
+	n, off, err := readLength(b, off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if off+n > len(b) {
+		return nil, 0, ErrMalformed
+	}
+	return b[off : off+n], off + n, nil
+}
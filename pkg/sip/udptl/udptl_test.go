@@ -0,0 +1,110 @@
+package udptl
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPacket_RoundTrip(t *testing.T) {
+	big := bytes.Repeat([]byte{0xAB}, 300)
+	p := Packet{Seq: 513, Primary: big, Secondary: [][]byte{{1, 2}, {3}}}
+	b, err := p.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Seq != 513 || !bytes.Equal(got.Primary, big) || len(got.Secondary) != 2 || got.Secondary[1][0] != 3 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestUnmarshal_FECAndTruncated(t *testing.T) {
+	got, err := Unmarshal([]byte{0, 7, 1, 0x06, 0x80, 0x01, 0x02})
+	if err != nil || got.Seq != 7 || !bytes.Equal(got.Primary, []byte{0x06}) || got.Secondary != nil {
+		t.Fatalf("FEC packet: %+v %v", got, err)
+	}
+	if _, err := Unmarshal([]byte{0, 1, 5, 1}); err == nil {
+		t.Fatal("truncated primary accepted")
+	}
+}
+
+func TestIFP_Encoding(t *testing.T) {
+	// Byte patterns as produced by spandsp for T38FaxVersion 0.
+	if b := Indicator(IndCED).Marshal(0); !bytes.Equal(b, []byte{0x04}) {
+		t.Fatalf("CED = % x", b)
+	}
+	hdlc := DataIFP(DataV21, Field{Type: FieldHDLCData, Data: []byte{0xFF, 0x13, 0x80}}, Field{Type: FieldHDLCFCSOKSigEnd})
+	want := []byte{0xC0, 0x02, 0x80, 0x00, 0x02, 0xFF, 0x13, 0x80, 0x40}
+	if b := hdlc.Marshal(0); !bytes.Equal(b, want) {
+		t.Fatalf("hdlc = % x, want % x", b, want)
+	}
+	for _, version := range []int{0, 3} {
+		got, err := ParseIFP(hdlc.Marshal(version), version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Data || got.DataType != DataV21 || len(got.Fields) != 2 ||
+			got.Fields[1].Type != FieldHDLCFCSOKSigEnd || !bytes.Equal(got.Fields[0].Data, []byte{0xFF, 0x13, 0x80}) {
+			t.Fatalf("v%d: %+v", version, got)
+		}
+	}
+	ind, err := ParseIFP([]byte{0x06}, 0)
+	if err != nil || ind.Data || ind.Indicator != IndV21Preamble {
+		t.Fatalf("indicator: %+v %v", ind, err)
+	}
+}
+
+func TestSession_RecoversLossFromRedundancy(t *testing.T) {
+	a, err := NewSession(0)
+	if err != nil {
+		t.Skip("udp unavailable:", err)
+	}
+	defer a.Close()
+	b, err := NewSession(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	to := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: b.LocalAddr().Port}
+
+	// Build packets by hand so #1 and #2 can be "lost".
+	var sent [][]byte
+	for i := 0; i < 4; i++ {
+		sent = append(sent, []byte{byte(0x10 + i)})
+	}
+	send := func(seq int) {
+		sec := [][]byte{}
+		for j := seq - 1; j >= 0 && j >= seq-DefaultRedundancy; j-- {
+			sec = append(sec, sent[j])
+		}
+		pkt := Packet{Seq: uint16(seq), Primary: sent[seq], Secondary: sec}
+		raw, _ := pkt.Marshal()
+		if _, err := a.conn.WriteToUDP(raw, to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(0)
+	send(3)
+	send(3) // duplicate
+	var got []byte
+	for len(got) < 4 {
+		ifps, err := b.Receive(time.Second)
+		if err != nil || ifps == nil {
+			t.Fatalf("receive: %v (got % x)", err, got)
+		}
+		for _, p := range ifps {
+			got = append(got, p...)
+		}
+	}
+	if !bytes.Equal(got, []byte{0x10, 0x11, 0x12, 0x13}) {
+		t.Fatalf("got % x", got)
+	}
+	if ifps, _ := b.Receive(50 * time.Millisecond); ifps != nil {
+		t.Fatalf("duplicate delivered: %v", ifps)
+	}
+}
@@ -1,3 +0,0 @@
-// Package vad provides lightweight RMS-based voice activity hints for sip1 barge-in
-// (interrupt TTS / local WAV toward the callee while synthetic audio is playing).
-package vad
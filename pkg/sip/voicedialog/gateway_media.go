@@ -14,7 +14,6 @@ import (
 	"github.com/LinByte/VoiceServer/pkg/recognizer"
 	"github.com/LinByte/VoiceServer/pkg/sip/conversation"
 	sipdtmf "github.com/LinByte/VoiceServer/pkg/sip/dtmf"
-	sipvad "github.com/LinByte/VoiceServer/pkg/sip/vad"
 	"github.com/LinByte/VoiceServer/pkg/synthesizer"
 	sipasr "github.com/LinByte/VoiceServer/pkg/voice/asr"
 	voiceMetrics "github.com/LinByte/VoiceServer/pkg/voice/metrics"
@@ -199,14 +198,11 @@ func attachGatewayMedia(sess *dialogSession) error {
 	var ttsPlaying atomic.Bool
 	var ttsStartedAtNS atomic.Int64
 
-	var vadDet *sipvad.Detector
-	if config.GlobalConfig.SIP.SIPVADBargeIn {
-		vadDet = sipvad.NewDetector()
-		vadDet.SetLogger(zlg)
-		vadDet.SetThreshold(config.GlobalConfig.SIP.SIPVADThreshold)
-		vadDet.SetConsecutiveFrames(config.GlobalConfig.SIP.SIPVADConsecFrames)
-		logger.Info("voicedialog gateway RMS barge-in enabled",
+	vadDet := conversation.NewSIPBargeInDetector(asrInRate, zlg)
+	if vadDet != nil {
+		logger.Info("voicedialog gateway VAD barge-in enabled",
 			zap.String(KeyCallID, sess.meta.CallID),
+			zap.String("engine", config.GlobalConfig.SIP.SIPVADEngine),
 			zap.Float64("threshold", config.GlobalConfig.SIP.SIPVADThreshold),
 			zap.Int("consecutive_frames", config.GlobalConfig.SIP.SIPVADConsecFrames),
 		)
@@ -222,7 +218,7 @@ func attachGatewayMedia(sess *dialogSession) error {
 		if trimmed == "" {
 			return
 		}
-		// ASR-driven barge-in. The RMS-VAD path above only fires for
+		// ASR-driven barge-in. The RMS VAD engine above only fires for
 		// loud speech (configurable threshold, 3200 by default) — soft
 		// callers / phones with AGC normalisation can drop below that
 		// while still producing a perfectly clear ASR transcript. If
@@ -285,6 +281,17 @@ func attachGatewayMedia(sess *dialogSession) error {
 		}))
 	})
 
+	onDTMF := func(_ context.Context, digit string) {
+		if digit == "" {
+			return
+		}
+		sess.emitGateway(event(EvDTMF, callID, map[string]any{
+			KeyDigit: digit,
+		}))
+	}
+	inbandDTMF := conversation.NewInbandDTMFDetector(sess.cs)
+	faxWatch := conversation.NewFaxWatch(sess.cs)
+
 	proc := media.NewPacketProcessor("voice-gateway-asr-feed", media.PriorityHigh,
 		func(c context.Context, _ *media.MediaSession, packet media.MediaPacket) error {
 			ap, ok := packet.(*media.AudioPacket)
@@ -297,7 +304,10 @@ func attachGatewayMedia(sess *dialogSession) error {
 			if conversation.IsTransferInProgress(callID) {
 				return nil
 			}
-			pcm16 := ap.Payload
+			if faxWatch.Drop(ap.Payload) {
+				return nil
+			}
+			pcm16 := inbandDTMF.Filter(c, ap.Payload, onDTMF)
 			pcmASR := pcm16
 			if asrOutRate != asrInRate {
 				out, err := media.ResamplePCM(pcm16, asrInRate, asrOutRate)
@@ -341,14 +351,7 @@ func attachGatewayMedia(sess *dialogSession) error {
 		})
 	ms.RegisterProcessor(proc)
 
-	sipdtmf.AttachProcessor(ms, "voice-gateway-dtmf", func(_ context.Context, digit string) {
-		if digit == "" {
-			return
-		}
-		sess.emitGateway(event(EvDTMF, callID, map[string]any{
-			KeyDigit: digit,
-		}))
-	})
+	sipdtmf.AttachProcessor(ms, "voice-gateway-dtmf", onDTMF)
 
 	sess.attachTTSLifecycle(&ttsPlaying, &ttsStartedAtNS, ms)
 
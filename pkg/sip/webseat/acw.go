@@ -0,0 +1,103 @@
+package webseat
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// After-call work (ACW) on agent pages.
+//
+// When a seat that talked to the customer is torn down, Config.StartAfterCallWork
+// decides whether the agent enters ACW. The owner then pushes
+//
+//	{"type":"acw_start","call_id":…,"acd_target_id":…,"acw_seconds":…,"expires_at":…,"dispositions":[…]}
+//
+// and the page submits {"type":"wrap_up","call_id":…,"disposition_code":…,"notes":…}
+// on the same WebSocket (answered with {"type":"wrap_up_result",…}) or through
+// the tenant HTTP API. {"type":"acw_end",…} closes the panel on wrap-up, expiry
+// or when the seat's work state is changed elsewhere.
+
+// Disposition is one selectable wrap-up code.
+type Disposition struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+// ACWNotice describes an ACW period that just started.
+type ACWNotice struct {
+	CallID       string        `json:"call_id"`
+	ACDTargetID  uint          `json:"acd_target_id,string"`
+	ACWSeconds   int           `json:"acw_seconds"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+	Dispositions []Disposition `json:"dispositions"`
+	// Required is true when the tenant configured codes and one must be picked.
+	Required bool `json:"required"`
+}
+
+// NotifyACWStart tells agent pages that a seat entered after-call work.
+func NotifyACWStart(n ACWNotice) {
+	if defaultHub == nil {
+		return
+	}
+	if n.Dispositions == nil {
+		n.Dispositions = []Disposition{}
+	}
+	defaultHub.broadcastJSON(struct {
+		Type string `json:"type"`
+		ACWNotice
+		TS string `json:"ts"`
+	}{Type: "acw_start", ACWNotice: n, TS: time.Now().UTC().Format(time.RFC3339Nano)})
+}
+
+// NotifyACWEnd tells agent pages that a seat's ACW is over (reason: wrapped_up | expired | cancelled).
+func NotifyACWEnd(callID string, acdTargetID uint, reason, code string) {
+	if defaultHub == nil {
+		return
+	}
+	defaultHub.broadcastJSON(struct {
+		Type            string `json:"type"`
+		CallID          string `json:"call_id"`
+		ACDTargetID     uint   `json:"acd_target_id,string"`
+		Reason          string `json:"reason"`
+		DispositionCode string `json:"disposition_code,omitempty"`
+		TS              string `json:"ts"`
+	}{"acw_end", callID, acdTargetID, reason, code, time.Now().UTC().Format(time.RFC3339Nano)})
+}
+
+// acdReleaseAfterCall clears the seat binding of a finished call. When the agent
+// talked to the customer and Config.StartAfterCallWork takes the seat into ACW
+// the row stays out of "available"; otherwise it is released at once.
+func (h *Hub) acdReleaseAfterCall(callID string, talked bool) {
+	if !talked || h.cfg.StartAfterCallWork == nil {
+		h.acdReleaseBindingToAvailable(callID)
+		return
+	}
+	v, ok := h.acdBinding.Load(callID)
+	if !ok {
+		return
+	}
+	if id, _ := v.(uint); id != 0 && h.cfg.StartAfterCallWork(callID, id) {
+		h.acdBinding.Delete(callID)
+		return
+	}
+	h.acdReleaseBindingToAvailable(callID)
+}
+
+// handleWrapUp answers a page's {"type":"wrap_up",…} message.
+func (h *Hub) handleWrapUp(c *websocket.Conn, callID string, m wsMessage) {
+	reply := map[string]any{"type": "wrap_up_result", "call_id": callID, "ok": true}
+	if h.cfg.WrapUp == nil {
+		reply["ok"] = false
+		reply["error"] = "wrap-up not available"
+	} else if err := h.cfg.WrapUp(context.Background(), callID, m.DispositionCode, m.Notes); err != nil {
+		reply["ok"] = false
+		reply["error"] = err.Error()
+	}
+	reply["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+	if msg, err := json.Marshal(reply); err == nil {
+		h.wsWrite(c, msg)
+	}
+}
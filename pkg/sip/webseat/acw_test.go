@@ -0,0 +1,48 @@
+package webseat
+
+import (
+	"context"
+	"testing"
+)
+
+func TestACDReleaseAfterCall(t *testing.T) {
+	old := defaultHub
+	defer func() { defaultHub = old }()
+
+	var states []string
+	var acw []uint
+	inACW := true
+	InitDefault(Config{
+		SetACDWebSeatWorkState: func(_ context.Context, _ uint, ws string) error {
+			states = append(states, ws)
+			return nil
+		},
+		StartAfterCallWork: func(_ string, id uint) bool {
+			acw = append(acw, id)
+			return inACW
+		},
+	})
+	h := defaultHub
+
+	h.acdBinding.Store("talked", uint(7))
+	h.acdReleaseAfterCall("talked", true)
+	if len(acw) != 1 || acw[0] != 7 || len(states) != 0 {
+		t.Fatalf("acw=%v states=%v", acw, states)
+	}
+	if _, ok := h.acdBinding.Load("talked"); ok {
+		t.Fatal("binding kept after ACW started")
+	}
+
+	h.acdBinding.Store("missed", uint(8))
+	h.acdReleaseAfterCall("missed", false)
+	if len(acw) != 1 || len(states) != 1 || states[0] != "available" {
+		t.Fatalf("unanswered: acw=%v states=%v", acw, states)
+	}
+
+	inACW = false
+	h.acdBinding.Store("no-acw", uint(9))
+	h.acdReleaseAfterCall("no-acw", true)
+	if len(acw) != 2 || len(states) != 2 || states[1] != "available" {
+		t.Fatalf("acw disabled: acw=%v states=%v", acw, states)
+	}
+}
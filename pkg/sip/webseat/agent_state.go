@@ -0,0 +1,68 @@
+package webseat
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// AgentState is one seat's BLF / work-state snapshot pushed to agent
+// pages as {"type":"agent_state", ...} (supervisor lamps on web seats).
+type AgentState struct {
+	ACDTargetID uint   `json:"acd_target_id,string"`
+	TenantID    uint   `json:"tenant_id"`
+	Name        string `json:"name"`
+	TargetValue string `json:"target_value"`
+	RouteType   string `json:"route_type"`
+	WorkState   string `json:"work_state"`
+	// DialogState is the RFC 4235 lamp state: early | confirmed | terminated.
+	DialogState string `json:"dialog_state"`
+	CallID      string `json:"call_id,omitempty"`
+	Remote      string `json:"remote,omitempty"`
+}
+
+// BroadcastAgentState notifies all agent WS clients of one seat's state.
+func BroadcastAgentState(st AgentState) {
+	if defaultHub == nil {
+		return
+	}
+	defaultHub.broadcastJSON(struct {
+		Type string `json:"type"`
+		AgentState
+		TS string `json:"ts"`
+	}{Type: "agent_state", AgentState: st, TS: time.Now().UTC().Format(time.RFC3339Nano)})
+}
+
+// InboundCallForWebACD returns the inbound Call-ID bound to a web ACD row (reverse of BindInboundCallToWebACD).
+func InboundCallForWebACD(acdTargetID uint) (string, bool) {
+	if defaultHub == nil || acdTargetID == 0 {
+		return "", false
+	}
+	var found string
+	defaultHub.acdBinding.Range(func(k, v any) bool {
+		if id, ok := v.(uint); ok && id == acdTargetID {
+			found, _ = k.(string)
+			return false
+		}
+		return true
+	})
+	return strings.TrimSpace(found), found != ""
+}
+
+func (h *Hub) broadcastJSON(v any) {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	h.wsMu.Lock()
+	list := make([]*websocket.Conn, 0, len(h.wsConns))
+	for c := range h.wsConns {
+		list = append(list, c)
+	}
+	h.wsMu.Unlock()
+	for _, c := range list {
+		h.wsWrite(c, msg)
+	}
+}
@@ -64,6 +64,16 @@ type Config struct {
 	StopTransferRinging func(inboundCallID string)
 	// AbortTransferOnAgentReject clears transfer retry/ring state before BYEing the customer on agent reject.
 	AbortTransferOnAgentReject func(inboundCallID string)
+	// RecordWebSeatQuality stores the browser leg's connection quality on the call record
+	// (callID is the inbound Call-ID or the outbound seat key); runs once per seat at teardown,
+	// before OutboundHooks.OnEnded, and must not block.
+	RecordWebSeatQuality func(ctx context.Context, callID string, q QualitySummary)
+	// StartAfterCallWork runs when a seat that talked to the customer is torn down
+	// (callID as for RecordWebSeatQuality). Returning true means the seat entered
+	// after-call work and its owner returns it to available; false releases it now.
+	StartAfterCallWork func(callID string, acdTargetID uint) bool
+	// WrapUp ends after-call work for callID from a page's {"type":"wrap_up",…} message.
+	WrapUp func(ctx context.Context, callID, dispositionCode, notes string) error
 }
 
 // Hub tracks pending joins and active bridges.
@@ -79,12 +89,16 @@ type Hub struct {
 
 	wsMu    sync.Mutex
 	wsConns map[*websocket.Conn]struct{}
+	// wsWriteMu serializes writes: gorilla connections allow one concurrent writer.
+	wsWriteMu sync.Mutex
 }
 
 type awaitEntry struct {
-	cs *sipSession.CallSession
-	lg *zap.Logger
-	at time.Time
+	cs  *sipSession.CallSession
+	lg  *zap.Logger
+	at  time.Time
+	out *OutboundHooks            // agent-first outbound (cs is nil until the customer answers)
+	ice []webrtc.ICECandidateInit // browser candidates trickled before join
 }
 
 type activeBridge struct {
@@ -92,6 +106,11 @@ type activeBridge struct {
 	inbound *sipSession.CallSession
 	br      *bridge.TwoLegPCMBridge
 	pc      *webrtc.PeerConnection
+	out     *OutboundHooks
+	web     *Transport // browser leg, kept for AttachOutboundPeer
+	early   bool       // br carries early media (replaced on answer)
+	trickle bool       // browser trickles candidates over the WebSocket
+	quality *qualityTracker
 }
 
 var defaultHub *Hub
@@ -296,6 +315,18 @@ func (h *Hub) wsAdd(c *websocket.Conn) {
 	h.broadcastPresence()
 }
 
+// wsWrite sends one text frame, dropping the client on error.
+func (h *Hub) wsWrite(c *websocket.Conn, msg []byte) {
+	h.wsWriteMu.Lock()
+	_ = c.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	err := c.WriteMessage(websocket.TextMessage, msg)
+	h.wsWriteMu.Unlock()
+	if err != nil {
+		_ = c.Close()
+		h.wsRemove(c)
+	}
+}
+
 func (h *Hub) wsRemove(c *websocket.Conn) {
 	if h == nil || c == nil {
 		return
@@ -325,11 +356,7 @@ func (h *Hub) broadcastIncoming(callID string) {
 	}
 	h.wsMu.Unlock()
 	for _, c := range list {
-		_ = c.SetWriteDeadline(time.Now().Add(5 * time.Second))
-		if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-			_ = c.Close()
-			h.wsRemove(c)
-		}
+		h.wsWrite(c, msg)
 	}
 }
 
@@ -354,11 +381,7 @@ func (h *Hub) broadcastIncomingEnd(callID, reason string) {
 	}
 	h.wsMu.Unlock()
 	for _, c := range list {
-		_ = c.SetWriteDeadline(time.Now().Add(5 * time.Second))
-		if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-			_ = c.Close()
-			h.wsRemove(c)
-		}
+		h.wsWrite(c, msg)
 	}
 }
 
@@ -384,11 +407,7 @@ func (h *Hub) broadcastPresence() {
 		return
 	}
 	for _, c := range list {
-		_ = c.SetWriteDeadline(time.Now().Add(5 * time.Second))
-		if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-			_ = c.Close()
-			h.wsRemove(c)
-		}
+		h.wsWrite(c, msg)
 	}
 }
 
@@ -414,9 +433,11 @@ func (h *Hub) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			h.broadcastPresence()
 		}()
 		for {
-			if _, _, err := c.ReadMessage(); err != nil {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
 				return
 			}
+			h.handleWSMessage(c, msg)
 		}
 	}(conn)
 }
@@ -436,6 +457,12 @@ func (h *Hub) awaitWatchdog(callID string) {
 		if e.lg != nil {
 			e.lg.Warn("webseat: join timeout, releasing slot", zap.String("call_id", callID))
 		}
+		if e.out != nil {
+			h.acdReleaseBindingToAvailable(callID)
+			h.broadcastIncomingEnd(callID, "join_timeout")
+			logger.SafeGo("webseat-outbound-ended", func() { h.endOutbound(callID, e.out, false, "join_timeout") })
+			return
+		}
 		var acdID uint
 		if v, ok := h.acdBinding.Load(callID); ok {
 			if id, ok := v.(uint); ok {
@@ -478,6 +505,24 @@ func IsActive(callID string) bool {
 	return a
 }
 
+// ActiveCallSession returns the inbound call session parked for or bridged to a web seat
+// (the SIP server's call store no longer holds it once handed off).
+func ActiveCallSession(callID string) *sipSession.CallSession {
+	if defaultHub == nil || callID == "" {
+		return nil
+	}
+	h := defaultHub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ab := h.active[callID]; ab != nil {
+		return ab.inbound
+	}
+	if aw := h.awaiting[callID]; aw != nil {
+		return aw.cs
+	}
+	return nil
+}
+
 // HangupIfCustomerBye tears down Web seat when the PSTN side sends BYE. Returns true if handled.
 func HangupIfCustomerBye(callID string) bool {
 	return teardownWebSeat(callID, false)
@@ -563,6 +608,15 @@ func teardownWebSeat(callID string, sendByeToCustomer bool) bool {
 	ab, activeOK := h.active[callID]
 	if !activeOK {
 		entry, waiting := h.awaiting[callID]
+		if waiting && entry != nil && entry.out != nil {
+			delete(h.awaiting, callID)
+			h.mu.Unlock()
+			h.broadcastIncomingEnd(callID, "ended")
+			h.acdReleaseBindingToAvailable(callID)
+			h.endOutbound(callID, entry.out, sendByeToCustomer, teardownReason(sendByeToCustomer))
+			lg.Info("webseat: outbound seat torn down", zap.String("call_id", callID), zap.String("phase", "awaiting"))
+			return true
+		}
 		if waiting {
 			if sendByeToCustomer && h.cfg.SendUASBye != nil {
 				if err := h.cfg.SendUASBye(callID); err != nil {
@@ -604,6 +658,28 @@ func teardownWebSeat(callID string, sendByeToCustomer bool) bool {
 	}
 	h.mu.Unlock()
 
+	if ab.out != nil {
+		h.mu.Lock()
+		if cur, still := h.active[callID]; !still || cur != ab {
+			h.mu.Unlock()
+			return false
+		}
+		delete(h.active, callID)
+		h.mu.Unlock()
+		h.broadcastIncomingEnd(callID, "ended")
+		h.finishQuality(callID, ab)
+		if ab.br != nil {
+			ab.br.Stop()
+		}
+		if ab.pc != nil {
+			_ = ab.pc.Close()
+		}
+		h.acdReleaseAfterCall(callID, ab.br != nil && !ab.early)
+		h.endOutbound(callID, ab.out, sendByeToCustomer, teardownReason(sendByeToCustomer))
+		lg.Info("webseat: outbound seat torn down", zap.String("call_id", callID), zap.Bool("hangup_peer", sendByeToCustomer))
+		return true
+	}
+
 	if sendByeToCustomer && h.cfg.SendUASBye != nil {
 		if err := h.cfg.SendUASBye(callID); err != nil {
 			lg.Warn("webseat: SendUASBye failed (active bridge path)", zap.String("call_id", callID), zap.Error(err))
@@ -626,6 +702,7 @@ func teardownWebSeat(callID string, sendByeToCustomer bool) bool {
 		initiator = "local"
 	}
 	h.emitFinalizePersist(callID, initiator, ab.inbound)
+	h.finishQuality(callID, ab)
 
 	if ab.br != nil {
 		ab.br.Stop()
@@ -642,7 +719,7 @@ func teardownWebSeat(callID string, sendByeToCustomer bool) bool {
 	if h.cfg.ForgetUASDialog != nil {
 		h.cfg.ForgetUASDialog(callID)
 	}
-	h.acdReleaseBindingToAvailable(callID)
+	h.acdReleaseAfterCall(callID, ab.br != nil)
 	if h.cfg.ReleaseTransferDedupe != nil {
 		h.cfg.ReleaseTransferDedupe(callID)
 	}
@@ -650,11 +727,21 @@ func teardownWebSeat(callID string, sendByeToCustomer bool) bool {
 	return true
 }
 
+func teardownReason(local bool) string {
+	if local {
+		return "local"
+	}
+	return "remote"
+}
+
 type joinBody struct {
 	CallID     string                    `json:"call_id"`
 	SDP        string                    `json:"sdp"`
 	Type       string                    `json:"type"`
 	Candidates []webrtc.ICECandidateInit `json:"candidates"`
+	// Trickle answers without waiting for ICE gathering; candidates then flow
+	// both ways over the webseat WebSocket as {"type":"ice_candidate",…}.
+	Trickle bool `json:"trickle"`
 }
 
 func (h *Hub) handleJoin(w http.ResponseWriter, r *http.Request) {
@@ -688,6 +775,7 @@ func (h *Hub) handleJoin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body.Candidates = append(body.Candidates, entry.ice...)
 	lg := entry.lg
 	if lg == nil && logger.Lg != nil {
 		lg = logger.Lg
@@ -696,7 +784,15 @@ func (h *Hub) handleJoin(w http.ResponseWriter, r *http.Request) {
 		lg = zap.NewNop()
 	}
 
-	answer, err := h.completeJoin(r.Context(), callID, entry.cs, body, lg)
+	answer, err := h.completeJoin(r.Context(), callID, entry.cs, entry.out, body, lg)
+	if err != nil && entry.out != nil {
+		h.broadcastIncomingEnd(callID, "ended")
+		h.acdReleaseBindingToAvailable(callID)
+		h.endOutbound(callID, entry.out, false, "join_failed")
+		lg.Warn("webseat: outbound join failed", zap.String("call_id", callID), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	if err != nil {
 		h.cleanupInboundAfterJoinFailure(callID, entry.cs, lg)
 		h.acdReleaseBindingToAvailable(callID)
@@ -777,12 +873,10 @@ type joinAnswer struct {
 	SDP  string `json:"sdp"`
 }
 
-func (h *Hub) completeJoin(ctx context.Context, callID string, inbound *sipSession.CallSession, body joinBody, lg *zap.Logger) (*joinAnswer, error) {
+func (h *Hub) completeJoin(ctx context.Context, callID string, inbound *sipSession.CallSession, out *OutboundHooks, body joinBody, lg *zap.Logger) (*joinAnswer, error) {
 	m := newMediaEngine()
 	api := webrtc.NewAPI(webrtc.WithMediaEngine(m))
-	pc, err := api.NewPeerConnection(webrtc.Configuration{
-		ICEServers: defaultICEServers(),
-	})
+	pc, err := api.NewPeerConnection(CurrentICEConfig("webseat", time.Now()).peerConfiguration())
 	if err != nil {
 		return nil, err
 	}
@@ -796,11 +890,7 @@ func (h *Hub) completeJoin(ctx context.Context, callID string, inbound *sipSessi
 		}
 	})
 
-	pc.OnConnectionStateChange(func(s webrtc.PeerConnectionState) {
-		if s == webrtc.PeerConnectionStateFailed || s == webrtc.PeerConnectionStateClosed {
-			_ = teardownWebSeat(callID, true)
-		}
-	})
+	h.watchICE(callID, pc, body.Trickle)
 
 	offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: body.SDP}
 	if err := pc.SetRemoteDescription(offer); err != nil {
@@ -823,7 +913,8 @@ func (h *Hub) completeJoin(ctx context.Context, callID string, inbound *sipSessi
 		_ = pc.Close()
 		return nil, err
 	}
-	if _, err := pc.AddTrack(txLocal); err != nil {
+	txSender, err := pc.AddTrack(txLocal)
+	if err != nil {
 		_ = pc.Close()
 		return nil, err
 	}
@@ -838,27 +929,35 @@ func (h *Hub) completeJoin(ctx context.Context, callID string, inbound *sipSessi
 		return nil, err
 	}
 
-	gatherComplete := webrtc.GatheringCompletePromise(pc)
-	select {
-	case <-gatherComplete:
-	case <-time.After(15 * time.Second):
-		_ = pc.Close()
-		return nil, errors.New("ICE gather timeout")
-	case <-ctx.Done():
-		_ = pc.Close()
-		return nil, ctx.Err()
+	if !body.Trickle {
+		gatherComplete := webrtc.GatheringCompletePromise(pc)
+		select {
+		case <-gatherComplete:
+		case <-time.After(15 * time.Second):
+			_ = pc.Close()
+			return nil, errors.New("ICE gather timeout")
+		case <-ctx.Done():
+			_ = pc.Close()
+			return nil, ctx.Err()
+		}
 	}
 
-	h.mu.Lock()
-	h.active[callID] = &activeBridge{
+	ab := &activeBridge{
 		callID:  callID,
 		inbound: inbound,
 		br:      nil,
 		pc:      pc,
+		out:     out,
+		trickle: body.Trickle,
+		quality: &qualityTracker{},
 	}
+	h.mu.Lock()
+	h.active[callID] = ab
 	h.mu.Unlock()
+	logger.SafeGo("webseat-rtcp", func() { h.readSenderRTCP(ab, txSender, int(txCap.ClockRate)) })
+	logger.SafeGo("webseat-quality", func() { h.sampleQuality(callID, ab) })
 
-	if h.cfg.OnWebSeatBridgeEstablished != nil {
+	if out == nil && h.cfg.OnWebSeatBridgeEstablished != nil {
 		h.cfg.OnWebSeatBridgeEstablished(callID)
 	}
 
@@ -868,7 +967,7 @@ func (h *Hub) completeJoin(ctx context.Context, callID string, inbound *sipSessi
 	})
 
 	ld := pc.LocalDescription()
-	lg.Info("webseat: answer sent, waiting for browser RTP / OnTrack", zap.String("call_id", callID))
+	lg.Info("webseat: answer sent, waiting for browser RTP / OnTrack", zap.String("call_id", callID), zap.Bool("trickle", body.Trickle))
 	return &joinAnswer{Type: ld.Type.String(), SDP: ld.SDP}, nil
 }
 
@@ -918,6 +1017,27 @@ func (h *Hub) waitRemoteTrackAndBridge(
 	webRxCodec := mediaFromRemoteTrack(remoteTrack)
 	wt := NewTransport(remoteTrack, txLocal, webRxCodec, webTxCodec)
 
+	if ab.out != nil {
+		// Agent-first outbound: nothing to bridge yet — park the browser
+		// leg and let the caller dial the customer.
+		h.mu.Lock()
+		if cur := h.active[callID]; cur != ab {
+			h.mu.Unlock()
+			_ = pc.Close()
+			return
+		}
+		ab.web = wt
+		h.mu.Unlock()
+		h.acdSetStateForCall(callID, "busy")
+		if lg != nil {
+			lg.Info("webseat: outbound agent ready", zap.String("call_id", callID))
+		}
+		if ab.out.OnAgentReady != nil {
+			ab.out.OnAgentReady(callID)
+		}
+		return
+	}
+
 	if h.cfg.PlayTransferAgentBrief != nil {
 		playCtx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 		played, err := h.cfg.PlayTransferAgentBrief(playCtx, callID, wt)
@@ -1041,17 +1161,6 @@ func newMediaEngine() *webrtc.MediaEngine {
 	return me
 }
 
-func defaultICEServers() []webrtc.ICEServer {
-	raw := utils.GetEnv("SIP_WEBSEAT_ICE_SERVERS")
-	if raw != "" {
-		var servers []webrtc.ICEServer
-		if err := json.Unmarshal([]byte(raw), &servers); err == nil && len(servers) > 0 {
-			return servers
-		}
-	}
-	return []webrtc.ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}}
-}
-
 func mediaFromRemoteTrack(tr *webrtc.TrackRemote) media.CodecConfig {
 	c := tr.Codec()
 	mime := strings.ToLower(c.MimeType)
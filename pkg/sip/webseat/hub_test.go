@@ -57,3 +57,63 @@ func TestEnvConstant(t *testing.T) {
 		t.Fatal(EnvWSToken)
 	}
 }
+
+func TestOfferOutboundTeardown(t *testing.T) {
+	old := defaultHub
+	defer func() { defaultHub = old }()
+
+	defaultHub = nil
+	if err := OfferOutbound("k", 0, "", OutboundHooks{}); err == nil {
+		t.Fatal("nil hub")
+	}
+	InitDefault(Config{})
+	var hung, ended string
+	hooks := OutboundHooks{
+		HangupPeer: func(key string) { hung = key },
+		OnEnded:    func(key, reason string) { ended = key + ":" + reason },
+	}
+	if err := OfferOutbound("c2c-1", 0, "13800138000", hooks); err != nil {
+		t.Fatal(err)
+	}
+	if err := OfferOutbound("c2c-1", 0, "", hooks); err == nil {
+		t.Fatal("duplicate offer")
+	}
+	if !IsPendingOrActive("c2c-1") || ActiveCallSession("c2c-1") != nil {
+		t.Fatal("pending state")
+	}
+	if AttachOutboundPeer("c2c-1", nil) == nil {
+		t.Fatal("attach without session")
+	}
+	if !HangupFull("c2c-1") || hung != "c2c-1" || ended != "c2c-1:local" {
+		t.Fatalf("hung=%q ended=%q", hung, ended)
+	}
+	if IsPendingOrActive("c2c-1") {
+		t.Fatal("still pending")
+	}
+}
+
+func TestRegisterOutboundRemoteEnd(t *testing.T) {
+	old := defaultHub
+	defer func() { defaultHub = old }()
+
+	InitDefault(Config{})
+	var hung bool
+	var ended string
+	hooks := OutboundHooks{
+		HangupPeer: func(string) { hung = true },
+		OnEnded:    func(_, reason string) { ended = reason },
+	}
+	if err := RegisterOutbound("ws-1", 0, hooks); err != nil {
+		t.Fatal(err)
+	}
+	if !IsPendingOrActive("ws-1") {
+		t.Fatal("not pending")
+	}
+	if AttachOutboundEarlyMedia("ws-1", nil) == nil {
+		t.Fatal("early media without session")
+	}
+	NotifyOutboundProgress("ws-1", "provisional", 180) // no consoles: must not block
+	if !HangupIfCustomerBye("ws-1") || hung || ended != "remote" {
+		t.Fatalf("hung=%v ended=%q", hung, ended)
+	}
+}
@@ -0,0 +1,329 @@
+package webseat
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"github.com/gorilla/websocket"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// EnvICEServers is a JSON array of static RTCIceServer entries
+	// (e.g. [{"urls":["stun:stun.example.com:3478"]}]).
+	EnvICEServers = "SIP_WEBSEAT_ICE_SERVERS"
+	// EnvTURNURLs lists TURN URLs (comma separated) that get time-limited
+	// credentials from EnvTURNSecret (TURN REST API, coturn use-auth-secret).
+	EnvTURNURLs = "SIP_WEBSEAT_TURN_URLS"
+	// EnvTURNSecret is the shared secret configured as static-auth-secret on the TURN server.
+	EnvTURNSecret = "SIP_WEBSEAT_TURN_SECRET"
+	// EnvTURNTTL is the lifetime of issued TURN credentials (default 1h).
+	EnvTURNTTL = "SIP_WEBSEAT_TURN_TTL"
+	// EnvICETransportPolicy is "all" (default) or "relay" (force TURN for every seat).
+	EnvICETransportPolicy = "SIP_WEBSEAT_ICE_TRANSPORT_POLICY"
+	// EnvICERestartGrace is how long a failed seat may stay up waiting for an
+	// ICE restart from the browser before the call is torn down (default 15s; 0 disables).
+	EnvICERestartGrace = "SIP_WEBSEAT_ICE_RESTART_GRACE"
+
+	defaultTURNTTL         = time.Hour
+	defaultICERestartGrace = 15 * time.Second
+	maxBufferedCandidates  = 64
+)
+
+// ICEConfig is the ICE setup handed to browsers (GET ice-servers) and used for the server-side PeerConnection.
+type ICEConfig struct {
+	ICEServers         []webrtc.ICEServer `json:"ice_servers"`
+	ICETransportPolicy string             `json:"ice_transport_policy"`
+	// TTLSeconds is the lifetime of TURN credentials in ICEServers (0 = no TURN REST credentials).
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+}
+
+// turnRESTCredential builds a TURN REST API credential pair: username is
+// "<unix expiry>:<user>" and the password is base64(HMAC-SHA1(secret, username)).
+func turnRESTCredential(secret, user string, expires time.Time) (username, credential string) {
+	username = strconv.FormatInt(expires.Unix(), 10)
+	if user = strings.TrimSpace(user); user != "" {
+		username += ":" + user
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func turnTTL() time.Duration {
+	if v := strings.TrimSpace(utils.GetEnv(EnvTURNTTL)); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultTURNTTL
+}
+
+// CurrentICEConfig returns the configured STUN/TURN servers with fresh TURN
+// credentials for user (any label; it only ends up in TURN server logs).
+func CurrentICEConfig(user string, now time.Time) ICEConfig {
+	cfg := ICEConfig{ICETransportPolicy: webrtc.ICETransportPolicyAll.String()}
+	if strings.EqualFold(strings.TrimSpace(utils.GetEnv(EnvICETransportPolicy)), "relay") {
+		cfg.ICETransportPolicy = webrtc.ICETransportPolicyRelay.String()
+	}
+	if raw := utils.GetEnv(EnvICEServers); raw != "" {
+		var servers []webrtc.ICEServer
+		if err := json.Unmarshal([]byte(raw), &servers); err == nil {
+			cfg.ICEServers = append(cfg.ICEServers, servers...)
+		} else if logger.Lg != nil {
+			logger.Lg.Warn("webseat: invalid "+EnvICEServers, zap.Error(err))
+		}
+	}
+	var turnURLs []string
+	for _, u := range strings.Split(utils.GetEnv(EnvTURNURLs), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			turnURLs = append(turnURLs, u)
+		}
+	}
+	if secret := utils.GetEnv(EnvTURNSecret); secret != "" && len(turnURLs) > 0 {
+		ttl := turnTTL()
+		username, credential := turnRESTCredential(secret, user, now.Add(ttl))
+		cfg.ICEServers = append(cfg.ICEServers, webrtc.ICEServer{
+			URLs:           turnURLs,
+			Username:       username,
+			Credential:     credential,
+			CredentialType: webrtc.ICECredentialTypePassword,
+		})
+		cfg.TTLSeconds = int(ttl / time.Second)
+	}
+	if len(cfg.ICEServers) == 0 {
+		cfg.ICEServers = []webrtc.ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}}
+	}
+	return cfg
+}
+
+func (c ICEConfig) peerConfiguration() webrtc.Configuration {
+	out := webrtc.Configuration{ICEServers: c.ICEServers}
+	if c.ICETransportPolicy == webrtc.ICETransportPolicyRelay.String() {
+		out.ICETransportPolicy = webrtc.ICETransportPolicyRelay
+	}
+	return out
+}
+
+func iceRestartGrace() time.Duration {
+	if v := strings.TrimSpace(utils.GetEnv(EnvICERestartGrace)); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultICERestartGrace
+}
+
+// ICEServersHTTP serves GET ice-servers: the RTCConfiguration the agent page
+// should use, with TURN credentials valid for ttl_seconds (?user= labels them).
+func ICEServersHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method", http.StatusMethodNotAllowed)
+		return
+	}
+	if !webseatTokenOK(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	user := strings.TrimSpace(r.URL.Query().Get("user"))
+	if user == "" {
+		user = "webseat"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(CurrentICEConfig(user, time.Now()))
+}
+
+// wsMessage is a browser → server message on the webseat WebSocket.
+//
+//   - {"type":"ice_candidate","call_id":"…","candidate":{…}} trickles a browser candidate
+//   - {"type":"ice_restart","call_id":"…","sdp":"…"} carries an iceRestart offer;
+//     the answer comes back on the same socket as {"type":"ice_restart_answer",…}
+//   - {"type":"wrap_up","call_id":"…","disposition_code":"…","notes":"…"} ends after-call work (acw.go)
+type wsMessage struct {
+	Type      string                   `json:"type"`
+	CallID    string                   `json:"call_id"`
+	Candidate *webrtc.ICECandidateInit `json:"candidate,omitempty"`
+	SDP       string                   `json:"sdp,omitempty"`
+
+	DispositionCode string `json:"disposition_code,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+}
+
+func (h *Hub) handleWSMessage(c *websocket.Conn, raw []byte) {
+	var m wsMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return
+	}
+	callID := strings.TrimSpace(m.CallID)
+	if callID == "" {
+		return
+	}
+	switch m.Type {
+	case "ice_candidate":
+		if m.Candidate != nil {
+			h.addRemoteCandidate(callID, *m.Candidate)
+		}
+	case "ice_restart":
+		reply := map[string]any{"type": "ice_restart_answer", "call_id": callID}
+		if ans, err := h.restartICE(callID, m.SDP); err != nil {
+			reply["error"] = err.Error()
+		} else {
+			reply["sdp"] = ans.SDP
+		}
+		reply["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+		if msg, err := json.Marshal(reply); err == nil {
+			h.wsWrite(c, msg)
+		}
+	case "wrap_up":
+		h.handleWrapUp(c, callID, m)
+	}
+}
+
+// addRemoteCandidate applies a trickled browser candidate; candidates sent
+// before the join completes are held on the awaiting entry.
+func (h *Hub) addRemoteCandidate(callID string, cand webrtc.ICECandidateInit) {
+	h.mu.Lock()
+	if e := h.awaiting[callID]; e != nil {
+		if len(e.ice) < maxBufferedCandidates {
+			e.ice = append(e.ice, cand)
+		}
+		h.mu.Unlock()
+		return
+	}
+	var pc *webrtc.PeerConnection
+	if ab := h.active[callID]; ab != nil {
+		pc = ab.pc
+	}
+	h.mu.Unlock()
+	if pc == nil {
+		return
+	}
+	if err := pc.AddICECandidate(cand); err != nil && logger.Lg != nil {
+		logger.Lg.Debug("webseat: add trickled candidate failed", zap.String("call_id", callID), zap.Error(err))
+	}
+}
+
+// restartICE answers an iceRestart offer for an active seat. Without trickle
+// the answer waits for gathering so it carries the new candidates.
+func (h *Hub) restartICE(callID, sdp string) (*webrtc.SessionDescription, error) {
+	if strings.TrimSpace(sdp) == "" {
+		return nil, errors.New("sdp required")
+	}
+	h.mu.Lock()
+	ab := h.active[callID]
+	h.mu.Unlock()
+	if ab == nil || ab.pc == nil {
+		return nil, fmt.Errorf("unknown call_id %q", callID)
+	}
+	pc := ab.pc
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp}); err != nil {
+		return nil, fmt.Errorf("SetRemoteDescription: %w", err)
+	}
+	ans, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return nil, err
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(ans); err != nil {
+		return nil, err
+	}
+	if !ab.trickle {
+		select {
+		case <-gathered:
+		case <-time.After(10 * time.Second):
+			return nil, errors.New("ICE gather timeout")
+		}
+	}
+	if logger.Lg != nil {
+		logger.Lg.Info("webseat: ICE restart answered", zap.String("call_id", callID), zap.Bool("trickle", ab.trickle))
+	}
+	return pc.LocalDescription(), nil
+}
+
+// watchICE wires trickle candidates, restart hints and the failure grace
+// period on a seat's PeerConnection.
+func (h *Hub) watchICE(callID string, pc *webrtc.PeerConnection, trickle bool) {
+	if trickle {
+		pc.OnICECandidate(func(c *webrtc.ICECandidate) {
+			if c == nil {
+				h.broadcastJSON(map[string]any{
+					"type":    "end_of_candidates",
+					"call_id": callID,
+					"ts":      time.Now().UTC().Format(time.RFC3339Nano),
+				})
+				return
+			}
+			h.broadcastJSON(map[string]any{
+				"type":      "ice_candidate",
+				"call_id":   callID,
+				"candidate": c.ToJSON(),
+				"ts":        time.Now().UTC().Format(time.RFC3339Nano),
+			})
+		})
+	}
+
+	grace := iceRestartGrace()
+	// pion runs state handlers on their own goroutines.
+	var failMu sync.Mutex
+	var failTimer *time.Timer
+	pc.OnConnectionStateChange(func(s webrtc.PeerConnectionState) {
+		switch s {
+		case webrtc.PeerConnectionStateConnected:
+			failMu.Lock()
+			if failTimer != nil {
+				failTimer.Stop()
+				failTimer = nil
+			}
+			failMu.Unlock()
+		case webrtc.PeerConnectionStateDisconnected:
+			h.broadcastRestartNeeded(callID, s)
+		case webrtc.PeerConnectionStateFailed:
+			if grace <= 0 {
+				_ = teardownWebSeat(callID, true)
+				return
+			}
+			h.broadcastRestartNeeded(callID, s)
+			failMu.Lock()
+			defer failMu.Unlock()
+			if failTimer == nil {
+				failTimer = time.AfterFunc(grace, func() {
+					if pc.ConnectionState() != webrtc.PeerConnectionStateConnected {
+						if logger.Lg != nil {
+							logger.Lg.Warn("webseat: no ICE recovery within grace, tearing down",
+								zap.String("call_id", callID), zap.Duration("grace", grace))
+						}
+						_ = teardownWebSeat(callID, true)
+					}
+				})
+			}
+		case webrtc.PeerConnectionStateClosed:
+			_ = teardownWebSeat(callID, true)
+		}
+	})
+}
+
+// broadcastRestartNeeded asks the agent page to renegotiate with iceRestart (network change, NAT rebinding).
+func (h *Hub) broadcastRestartNeeded(callID string, s webrtc.PeerConnectionState) {
+	if logger.Lg != nil {
+		logger.Lg.Info("webseat: ICE connectivity lost, requesting restart", zap.String("call_id", callID), zap.String("state", s.String()))
+	}
+	h.broadcastJSON(map[string]any{
+		"type":    "ice_restart_needed",
+		"call_id": callID,
+		"state":   s.String(),
+		"ts":      time.Now().UTC().Format(time.RFC3339Nano),
+	})
+}
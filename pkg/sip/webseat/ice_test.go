@@ -0,0 +1,102 @@
+package webseat
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+)
+
+func TestTURNRESTCredential(t *testing.T) {
+	exp := time.Unix(1700000000, 0)
+	user, pass := turnRESTCredential("s3cret", "agent7", exp)
+	if user != "1700000000:agent7" {
+		t.Fatalf("username = %q", user)
+	}
+	mac := hmac.New(sha1.New, []byte("s3cret"))
+	mac.Write([]byte(user))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); pass != want {
+		t.Fatalf("credential = %q, want %q", pass, want)
+	}
+	if user, _ := turnRESTCredential("s3cret", " ", exp); user != "1700000000" {
+		t.Fatalf("username without label = %q", user)
+	}
+}
+
+func TestCurrentICEConfig(t *testing.T) {
+	t.Setenv(EnvICEServers, `[{"urls":["stun:stun.example.com:3478"]}]`)
+	t.Setenv(EnvTURNURLs, "turn:turn.example.com:3478?transport=udp, turns:turn.example.com:5349")
+	t.Setenv(EnvTURNSecret, "s3cret")
+	t.Setenv(EnvTURNTTL, "10m")
+	t.Setenv(EnvICETransportPolicy, "relay")
+
+	now := time.Unix(1700000000, 0)
+	cfg := CurrentICEConfig("webseat", now)
+	if len(cfg.ICEServers) != 2 {
+		t.Fatalf("servers = %+v", cfg.ICEServers)
+	}
+	turn := cfg.ICEServers[1]
+	if len(turn.URLs) != 2 || !strings.HasPrefix(turn.Username, "1700000600:") || turn.Credential == nil {
+		t.Fatalf("turn = %+v", turn)
+	}
+	if cfg.TTLSeconds != 600 || cfg.ICETransportPolicy != "relay" {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	if cfg.peerConfiguration().ICETransportPolicy != webrtc.ICETransportPolicyRelay {
+		t.Fatal("relay policy not applied")
+	}
+}
+
+func TestCurrentICEConfigDefault(t *testing.T) {
+	t.Setenv(EnvICEServers, "")
+	t.Setenv(EnvTURNURLs, "")
+	t.Setenv(EnvTURNSecret, "")
+	t.Setenv(EnvICETransportPolicy, "")
+	cfg := CurrentICEConfig("", time.Now())
+	if len(cfg.ICEServers) != 1 || cfg.TTLSeconds != 0 || cfg.ICETransportPolicy != "all" {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestAddRemoteCandidateBuffersWhileAwaiting(t *testing.T) {
+	old := defaultHub
+	defer func() { defaultHub = old }()
+	InitDefault(Config{})
+	h := defaultHub
+	h.awaiting["c1"] = &awaitEntry{at: time.Now()}
+	h.addRemoteCandidate("c1", webrtc.ICECandidateInit{Candidate: "candidate:1 1 udp 1 10.0.0.1 5000 typ host"})
+	h.addRemoteCandidate("unknown", webrtc.ICECandidateInit{Candidate: "candidate:2"})
+	if got := len(h.awaiting["c1"].ice); got != 1 {
+		t.Fatalf("buffered = %d", got)
+	}
+	if _, err := h.restartICE("unknown", "v=0"); err == nil {
+		t.Fatal("restart on unknown call")
+	}
+}
+
+func TestQualityTrackerSummary(t *testing.T) {
+	q := &qualityTracker{}
+	if s := q.summary(); s.Samples != 0 {
+		t.Fatalf("empty summary = %+v", s)
+	}
+	q.addRTT(40, "host")
+	q.addRTT(80, "relay")
+	q.addRTT(0, "")
+	q.addReceptionReport(rtcp.ReceptionReport{FractionLost: 64, Jitter: 80}, 8000)
+	q.addReceptionReport(rtcp.ReceptionReport{FractionLost: 0, Jitter: 160}, 8000)
+	s := q.summary()
+	if s.AvgRTTMs != 60 || s.MaxRTTMs != 80 || s.CandidateType != "relay" {
+		t.Fatalf("rtt summary = %+v", s)
+	}
+	if s.AvgLossPct != 12.5 || s.MaxLossPct != 25 || s.AvgJitterMs != 15 || s.Samples != 4 {
+		t.Fatalf("loss summary = %+v", s)
+	}
+	if last := q.snapshot(); last.LossPct != 0 || last.JitterMs != 20 || last.RTTMs != 80 {
+		t.Fatalf("snapshot = %+v", last)
+	}
+}
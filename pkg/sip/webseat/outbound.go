@@ -0,0 +1,182 @@
+package webseat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/media"
+	"github.com/LinByte/VoiceServer/pkg/sip/bridge"
+	siprtp "github.com/LinByte/VoiceServer/pkg/sip/rtp"
+	sipSession "github.com/LinByte/VoiceServer/pkg/sip/session"
+	"go.uber.org/zap"
+)
+
+// OutboundHooks drive an agent-first outbound web seat (click-to-call):
+// the browser joins before any SIP leg exists, the caller dials the
+// customer from OnAgentReady and hands the answered leg to AttachOutboundPeer.
+type OutboundHooks struct {
+	// OnAgentReady runs once the browser's audio track is up.
+	OnAgentReady func(key string)
+	// HangupPeer ends the customer leg (CANCEL / BYE) on agent hangup or reject.
+	HangupPeer func(key string)
+	// OnEnded runs once when the seat is torn down; reason is join_timeout,
+	// join_failed, remote (customer BYE) or local.
+	OnEnded func(key, reason string)
+}
+
+// OfferOutbound rings web seats for an agent-first outbound call keyed by key
+// (used as call_id on the agent page). acdTargetID binds the web ACD row like
+// BindInboundCallToWebACD; remote is shown on the card.
+func OfferOutbound(key string, acdTargetID uint, remote string, hooks OutboundHooks) error {
+	h, key, err := addOutbound(key, acdTargetID, hooks)
+	if err != nil {
+		return err
+	}
+	logger.SafeGo("webseat-broadcast-outbound", func() {
+		h.broadcastJSON(map[string]any{
+			"type":      "incoming",
+			"call_id":   key,
+			"direction": "outbound",
+			"remote":    strings.TrimSpace(remote),
+			"ts":        time.Now().UTC().Format(time.RFC3339Nano),
+		})
+	})
+	return nil
+}
+
+// RegisterOutbound is OfferOutbound for a call the agent placed from the
+// browser: the page already holds key (the dial API returned it) and joins
+// right away, so no incoming card is broadcast.
+func RegisterOutbound(key string, acdTargetID uint, hooks OutboundHooks) error {
+	_, _, err := addOutbound(key, acdTargetID, hooks)
+	return err
+}
+
+func addOutbound(key string, acdTargetID uint, hooks OutboundHooks) (*Hub, string, error) {
+	if defaultHub == nil {
+		return nil, "", errors.New("webseat: InitDefault not called")
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, "", errors.New("webseat: invalid key")
+	}
+	h := defaultHub
+	h.mu.Lock()
+	if h.active[key] != nil || h.awaiting[key] != nil {
+		h.mu.Unlock()
+		return nil, "", fmt.Errorf("webseat: call %q already offered", key)
+	}
+	h.awaiting[key] = &awaitEntry{lg: logger.Lg, at: time.Now(), out: &hooks}
+	h.mu.Unlock()
+	if acdTargetID > 0 {
+		h.acdBinding.Store(key, acdTargetID)
+	}
+	logger.SafeGo("webseat-await-watchdog", func() { h.awaitWatchdog(key) })
+	return h, key, nil
+}
+
+// NotifyOutboundProgress tells the agent page how the customer leg of key is
+// doing (state is an outbound dial event: invited, provisional, established …).
+func NotifyOutboundProgress(key, state string, statusCode int) {
+	if defaultHub == nil || strings.TrimSpace(key) == "" {
+		return
+	}
+	defaultHub.broadcastJSON(map[string]any{
+		"type":        "outbound_progress",
+		"call_id":     strings.TrimSpace(key),
+		"state":       state,
+		"status_code": statusCode,
+		"ts":          time.Now().UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// AttachOutboundEarlyMedia bridges the customer leg's early media (18x SDP)
+// to the browser that joined key until AttachOutboundPeer replaces it.
+func AttachOutboundEarlyMedia(key string, cs *sipSession.CallSession) error {
+	return attachOutbound(key, cs, true)
+}
+
+// AttachOutboundPeer bridges the answered customer leg to the browser that
+// joined key, replacing any early-media bridge. The caller keeps owning the
+// SIP leg (RTP, BYE, persistence).
+func AttachOutboundPeer(key string, cs *sipSession.CallSession) error {
+	return attachOutbound(key, cs, false)
+}
+
+func attachOutbound(key string, cs *sipSession.CallSession, early bool) error {
+	if defaultHub == nil {
+		return errors.New("webseat: InitDefault not called")
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || cs == nil || cs.RTPSession() == nil {
+		return errors.New("webseat: invalid call or session")
+	}
+	h := defaultHub
+	h.mu.Lock()
+	ab := h.active[key]
+	if ab == nil || ab.out == nil || ab.web == nil || (ab.br != nil && !ab.early) {
+		h.mu.Unlock()
+		return fmt.Errorf("webseat: no agent ready for %q", key)
+	}
+	if early && ab.br != nil {
+		h.mu.Unlock()
+		return nil // first 18x SDP wins
+	}
+	wt := ab.web
+	prev := ab.br
+	ab.br = nil
+	h.mu.Unlock()
+	// Both bridges read the same sockets: stop the early one first.
+	prev.Stop()
+
+	// The leg was started with MediaProfileNone: stop its media session so
+	// the bridge is the only RTP reader.
+	cs.StopMediaPreserveRTP()
+	cc := cs.SourceCodec()
+	peerRx := siprtp.NewSIPRTPTransport(cs.RTPSession(), cc, media.DirectionInput, cs.DTMFPayloadType())
+	peerTx := siprtp.NewSIPRTPTransport(cs.RTPSession(), cc, media.DirectionOutput, 0)
+	br, err := bridge.NewTwoLegPCMBridge(peerRx, peerTx, wt, wt)
+	if err != nil {
+		return fmt.Errorf("webseat: pcm bridge: %w", err)
+	}
+
+	h.mu.Lock()
+	if cur := h.active[key]; cur != ab || ab.br != nil {
+		h.mu.Unlock()
+		br.Stop()
+		return fmt.Errorf("webseat: agent left %q", key)
+	}
+	ab.inbound = cs
+	ab.br = br
+	ab.early = early
+	h.mu.Unlock()
+
+	br.Start()
+	if logger.Lg != nil {
+		logger.Lg.Info("webseat: outbound bridge started",
+			zap.String("key", key),
+			zap.Bool("early_media", early),
+			zap.String("peer_codec", cc.Codec),
+			zap.Int("mid_sr", br.MidSampleRate()),
+		)
+	}
+	return nil
+}
+
+// endOutbound finishes an agent-first seat: optionally hangs up the customer
+// leg, then reports the end reason exactly once (callers already removed key
+// from awaiting / active).
+func (h *Hub) endOutbound(key string, out *OutboundHooks, hangupPeer bool, reason string) {
+	if out == nil {
+		return
+	}
+	if hangupPeer && out.HangupPeer != nil {
+		out.HangupPeer(key)
+	}
+	if out.OnEnded != nil {
+		out.OnEnded(key, reason)
+	}
+}
@@ -0,0 +1,208 @@
+package webseat
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/zap"
+)
+
+// EnvStatsInterval is how often seat connection quality is sampled and pushed to agent pages (default 5s).
+const EnvStatsInterval = "SIP_WEBSEAT_STATS_INTERVAL"
+
+// ConnQuality is the browser leg's connection quality. RTT comes from ICE
+// connectivity checks on the selected candidate pair; loss and jitter come
+// from the browser's RTCP receiver reports about our downlink.
+type ConnQuality struct {
+	RTTMs    float64 `json:"rtt_ms"`
+	LossPct  float64 `json:"loss_pct"`
+	JitterMs float64 `json:"jitter_ms"`
+	// CandidateType is the local type of the selected pair (host, srflx, relay …).
+	CandidateType string `json:"candidate_type,omitempty"`
+}
+
+// QualitySummary aggregates a seat's samples for the call record.
+type QualitySummary struct {
+	AvgRTTMs      float64
+	MaxRTTMs      float64
+	AvgLossPct    float64
+	MaxLossPct    float64
+	AvgJitterMs   float64
+	CandidateType string
+	Samples       int
+}
+
+// qualityTracker accumulates RTT samples and RTCP receiver reports for one seat.
+type qualityTracker struct {
+	mu      sync.Mutex
+	last    ConnQuality
+	rttN    int
+	rttSum  float64
+	rttMax  float64
+	rrN     int
+	lossSum float64
+	lossMax float64
+	jitSum  float64
+}
+
+func (q *qualityTracker) addRTT(rttMs float64, candidateType string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if candidateType != "" {
+		q.last.CandidateType = candidateType
+	}
+	if rttMs <= 0 {
+		return
+	}
+	q.last.RTTMs = rttMs
+	q.rttN++
+	q.rttSum += rttMs
+	if rttMs > q.rttMax {
+		q.rttMax = rttMs
+	}
+}
+
+// addReceptionReport folds one RTCP report block (clockRate is the downlink RTP clock).
+func (q *qualityTracker) addReceptionReport(r rtcp.ReceptionReport, clockRate int) {
+	if clockRate <= 0 {
+		clockRate = 8000
+	}
+	loss := float64(r.FractionLost) * 100 / 256
+	jit := float64(r.Jitter) * 1000 / float64(clockRate)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.last.LossPct = loss
+	q.last.JitterMs = jit
+	q.rrN++
+	q.lossSum += loss
+	q.jitSum += jit
+	if loss > q.lossMax {
+		q.lossMax = loss
+	}
+}
+
+func (q *qualityTracker) snapshot() ConnQuality {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.last
+}
+
+func (q *qualityTracker) summary() QualitySummary {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := QualitySummary{MaxRTTMs: q.rttMax, MaxLossPct: q.lossMax, CandidateType: q.last.CandidateType}
+	if q.rttN > 0 {
+		s.AvgRTTMs = q.rttSum / float64(q.rttN)
+	}
+	if q.rrN > 0 {
+		s.AvgLossPct = q.lossSum / float64(q.rrN)
+		s.AvgJitterMs = q.jitSum / float64(q.rrN)
+	}
+	s.Samples = q.rttN + q.rrN
+	return s
+}
+
+// selectedPairRTT reads the nominated candidate pair's RTT (ms) and local candidate type from a stats report.
+func selectedPairRTT(report webrtc.StatsReport) (rttMs float64, candidateType string) {
+	for _, st := range report {
+		pair, ok := st.(webrtc.ICECandidatePairStats)
+		if !ok || !pair.Nominated || pair.State != webrtc.StatsICECandidatePairStateSucceeded {
+			continue
+		}
+		rttMs = pair.CurrentRoundTripTime * 1000
+		if rttMs == 0 && pair.ResponsesReceived > 0 {
+			rttMs = pair.TotalRoundTripTime * 1000 / float64(pair.ResponsesReceived)
+		}
+		if local, ok := report[pair.LocalCandidateID].(webrtc.ICECandidateStats); ok {
+			candidateType = local.CandidateType.String()
+		}
+		return rttMs, candidateType
+	}
+	return 0, ""
+}
+
+func statsInterval() time.Duration {
+	if v := strings.TrimSpace(utils.GetEnv(EnvStatsInterval)); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 5 * time.Second
+}
+
+// readSenderRTCP drains RTCP for the downlink track (pion needs it read) and
+// feeds the browser's receiver reports into ab.quality until the PC closes.
+func (h *Hub) readSenderRTCP(ab *activeBridge, sender *webrtc.RTPSender, clockRate int) {
+	for {
+		pkts, _, err := sender.ReadRTCP()
+		if err != nil {
+			return
+		}
+		for _, p := range pkts {
+			var reports []rtcp.ReceptionReport
+			switch pkt := p.(type) {
+			case *rtcp.ReceiverReport:
+				reports = pkt.Reports
+			case *rtcp.SenderReport:
+				reports = pkt.Reports
+			}
+			for _, r := range reports {
+				ab.quality.addReceptionReport(r, clockRate)
+			}
+		}
+	}
+}
+
+// sampleQuality pushes {"type":"quality",…} for a seat every statsInterval
+// while it stays active.
+func (h *Hub) sampleQuality(callID string, ab *activeBridge) {
+	t := time.NewTicker(statsInterval())
+	defer t.Stop()
+	for range t.C {
+		h.mu.Lock()
+		cur := h.active[callID]
+		h.mu.Unlock()
+		if cur != ab {
+			return
+		}
+		rtt, typ := selectedPairRTT(ab.pc.GetStats())
+		ab.quality.addRTT(rtt, typ)
+		q := ab.quality.snapshot()
+		h.broadcastJSON(struct {
+			Type   string `json:"type"`
+			CallID string `json:"call_id"`
+			ConnQuality
+			TS string `json:"ts"`
+		}{Type: "quality", CallID: callID, ConnQuality: q, TS: time.Now().UTC().Format(time.RFC3339Nano)})
+	}
+}
+
+// finishQuality hands the seat's summary to Config.RecordWebSeatQuality once at teardown.
+func (h *Hub) finishQuality(callID string, ab *activeBridge) {
+	if ab == nil || ab.quality == nil {
+		return
+	}
+	s := ab.quality.summary()
+	if s.Samples == 0 {
+		return
+	}
+	if logger.Lg != nil {
+		logger.Lg.Info("webseat: connection quality",
+			zap.String("call_id", callID),
+			zap.Float64("avg_rtt_ms", s.AvgRTTMs),
+			zap.Float64("max_rtt_ms", s.MaxRTTMs),
+			zap.Float64("avg_loss_pct", s.AvgLossPct),
+			zap.Float64("avg_jitter_ms", s.AvgJitterMs),
+			zap.String("candidate_type", s.CandidateType),
+		)
+	}
+	if h.cfg.RecordWebSeatQuality != nil {
+		h.cfg.RecordWebSeatQuality(context.Background(), callID, s)
+	}
+}
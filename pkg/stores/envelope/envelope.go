@@ -0,0 +1,194 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package envelope encrypts call recordings at rest with envelope
+// encryption.
+//
+// Every object written through Wrap gets its own random 256-bit data key
+// (DEK). The audio is sealed with AES-256-GCM in fixed-size segments so
+// multi-hour recordings stream through without buffering, and the DEK is
+// wrapped by the tenant's key-encryption key (KEK) held by a pluggable
+// KMS. Only the wrapped DEK is persisted (KeyStore, keyed by object key),
+// never next to the ciphertext, which gives two properties:
+//
+//   - Rotation re-wraps DEKs under a new KEK version without touching
+//     the (possibly large, possibly remote) audio objects.
+//   - Crypto-shredding: destroying a tenant's KEKs makes every recording
+//     of that tenant unreadable, wherever copies of the objects live.
+//
+// Objects without a key row are read back as plaintext, so recordings
+// written before encryption was switched on keep playing.
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/stores"
+	"github.com/LinByte/VoiceServer/pkg/utils"
+)
+
+// Env keys.
+const (
+	EnvEnabled = "RECORDING_ENCRYPTION_ENABLED" // "true" encrypts new recordings
+	EnvKMSDir  = "RECORDING_KMS_DIR"            // FileKMS key directory; default ./data/kms
+)
+
+const defaultKMSDir = "./data/kms"
+
+// dekSize is the AES-256 data key length.
+const dekSize = 32
+
+var (
+	ErrKeyNotFound  = errors.New("envelope: key not found")
+	ErrKeyDestroyed = errors.New("envelope: tenant key destroyed")
+)
+
+// DataKey is the persisted envelope of one encrypted object: its DEK
+// wrapped by the tenant KEK identified by KeyID.
+type DataKey struct {
+	ObjectKey string
+	TenantID  uint
+	KeyID     string
+	Wrapped   []byte
+	CreatedAt time.Time
+}
+
+// KeyStore persists wrapped data keys (the sip app wires a DB table).
+type KeyStore interface {
+	PutDataKey(ctx context.Context, k DataKey) error
+	// GetDataKey returns ok=false when objectKey was stored in plaintext.
+	GetDataKey(ctx context.Context, objectKey string) (k DataKey, ok bool, err error)
+	// ListStaleDataKeys returns up to limit keys of tenantID wrapped by
+	// any KEK other than keyID.
+	ListStaleDataKeys(ctx context.Context, tenantID uint, keyID string, limit int) ([]DataKey, error)
+	UpdateDataKeyWrap(ctx context.Context, objectKey, keyID string, wrapped []byte) error
+	DeleteDataKey(ctx context.Context, objectKey string) error
+	DeleteTenantDataKeys(ctx context.Context, tenantID uint) (int64, error)
+}
+
+var (
+	mu   sync.RWMutex
+	kms  KMS
+	keys KeyStore
+)
+
+// Configure installs the KMS and key store. Passing a nil KMS disables
+// encryption of new objects; reads of already-encrypted objects then fail
+// rather than returning ciphertext.
+func Configure(k KMS, ks KeyStore) {
+	mu.Lock()
+	kms, keys = k, ks
+	mu.Unlock()
+}
+
+// ConfigureFromEnv enables encryption with a FileKMS under
+// RECORDING_KMS_DIR when RECORDING_ENCRYPTION_ENABLED is set. The key
+// store is installed either way so existing encrypted recordings are
+// still recognised (and refused) with encryption switched off.
+func ConfigureFromEnv(ks KeyStore) bool {
+	if !utils.GetBoolEnv(EnvEnabled) {
+		Configure(nil, ks)
+		return false
+	}
+	dir := strings.TrimSpace(utils.GetEnv(EnvKMSDir))
+	if dir == "" {
+		dir = defaultKMSDir
+	}
+	Configure(NewFileKMS(dir), ks)
+	return true
+}
+
+func current() (KMS, KeyStore) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return kms, keys
+}
+
+// Enabled reports whether new objects written through Wrap are encrypted.
+func Enabled() bool {
+	k, ks := current()
+	return k != nil && ks != nil
+}
+
+// Wrap returns a Store that encrypts writes for tenantID and decrypts
+// reads of any encrypted object. When only a key store is configured the
+// wrapper still guards reads; with neither, inner is returned unchanged.
+// A nil inner stays nil so callers keep their "no storage" handling.
+func Wrap(inner stores.Store, tenantID uint) stores.Store {
+	if inner == nil {
+		return nil
+	}
+	if _, ks := current(); ks == nil {
+		return inner
+	}
+	return &Store{inner: inner, tenantID: tenantID}
+}
+
+// RotateTenant creates a new KEK version for tenantID and re-wraps every
+// data key still wrapped by an older version. Audio objects are not
+// touched. Returns the number of re-wrapped keys.
+func RotateTenant(ctx context.Context, tenantID uint) (int, error) {
+	k, ks := current()
+	if k == nil || ks == nil {
+		return 0, errors.New("envelope: encryption not configured")
+	}
+	keyID, err := k.RotateKey(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	const batch = 200
+	n := 0
+	for {
+		stale, err := ks.ListStaleDataKeys(ctx, tenantID, keyID, batch)
+		if err != nil {
+			return n, err
+		}
+		if len(stale) == 0 {
+			return n, nil
+		}
+		for _, dk := range stale {
+			dek, err := k.UnwrapKey(ctx, tenantID, dk.KeyID, dk.Wrapped)
+			if err != nil {
+				return n, fmt.Errorf("envelope: unwrap %s: %w", dk.ObjectKey, err)
+			}
+			wrapped, err := k.WrapKeyWith(ctx, tenantID, keyID, dek)
+			if err != nil {
+				return n, err
+			}
+			if err := ks.UpdateDataKeyWrap(ctx, dk.ObjectKey, keyID, wrapped); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+}
+
+// ShredTenant destroys every KEK of tenantID and drops its data keys,
+// rendering all of the tenant's encrypted recordings permanently
+// unreadable. Irreversible.
+func ShredTenant(ctx context.Context, tenantID uint) (int64, error) {
+	k, ks := current()
+	if k != nil {
+		if err := k.DestroyTenant(ctx, tenantID); err != nil {
+			return 0, err
+		}
+	}
+	if ks == nil {
+		return 0, nil
+	}
+	return ks.DeleteTenantDataKeys(ctx, tenantID)
+}
+
+func newDEK() ([]byte, error) {
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	return dek, nil
+}
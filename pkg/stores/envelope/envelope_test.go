@@ -0,0 +1,218 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+type memObjects struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (s *memObjects) Write(key string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = b
+	return nil
+}
+
+func (s *memObjects) Read(key string) (io.ReadCloser, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.m[key]
+	if !ok {
+		return nil, 0, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(b)), int64(len(b)), nil
+}
+
+func (s *memObjects) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, key)
+	return nil
+}
+
+func (s *memObjects) Exists(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.m[key]
+	return ok, nil
+}
+
+func (s *memObjects) PublicURL(key string) string { return "/" + key }
+
+type memKeys struct {
+	mu sync.Mutex
+	m  map[string]DataKey
+}
+
+func (k *memKeys) PutDataKey(_ context.Context, d DataKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.m[d.ObjectKey] = d
+	return nil
+}
+
+func (k *memKeys) GetDataKey(_ context.Context, key string) (DataKey, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	d, ok := k.m[key]
+	return d, ok, nil
+}
+
+func (k *memKeys) ListStaleDataKeys(_ context.Context, tenantID uint, keyID string, limit int) ([]DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var out []DataKey
+	for _, d := range k.m {
+		if d.TenantID == tenantID && d.KeyID != keyID && len(out) < limit {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (k *memKeys) UpdateDataKeyWrap(_ context.Context, key, keyID string, wrapped []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	d := k.m[key]
+	d.KeyID, d.Wrapped = keyID, wrapped
+	k.m[key] = d
+	return nil
+}
+
+func (k *memKeys) DeleteDataKey(_ context.Context, key string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.m, key)
+	return nil
+}
+
+func (k *memKeys) DeleteTenantDataKeys(_ context.Context, tenantID uint) (int64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var n int64
+	for key, d := range k.m {
+		if d.TenantID == tenantID {
+			delete(k.m, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestStream_RoundTripAndSize(t *testing.T) {
+	aead, err := newGCM(randomBytes(t, dekSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, segmentSize - 1, segmentSize, 2*segmentSize + 5} {
+		plain := randomBytes(t, n)
+		er, err := newEncryptReader(bytes.NewReader(plain), aead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, err := io.ReadAll(er)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := PlaintextSize(int64(len(ct))); got != int64(n) {
+			t.Errorf("n=%d: PlaintextSize=%d", n, got)
+		}
+		dr, err := newDecryptReader(io.NopCloser(bytes.NewReader(ct)), aead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(dr)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("n=%d: round trip mismatch (err=%v)", n, err)
+		}
+		if n > segmentSize {
+			// Dropping the final segment must not decrypt as a shorter file.
+			cut := ct[:headerSize+segmentSize+tagSize]
+			dr, _ := newDecryptReader(io.NopCloser(bytes.NewReader(cut)), aead)
+			if _, err := io.ReadAll(dr); err == nil {
+				t.Fatalf("n=%d: truncated ciphertext decrypted without error", n)
+			}
+		}
+	}
+}
+
+func TestStore_EncryptRotateShred(t *testing.T) {
+	objs := &memObjects{m: map[string][]byte{}}
+	ks := &memKeys{m: map[string]DataKey{}}
+	Configure(NewFileKMS(t.TempDir()), ks)
+	defer Configure(nil, nil)
+	ctx := context.Background()
+
+	objs.m["sip/recordings/old.wav"] = []byte("RIFF-plain")
+	st := Wrap(objs, 7)
+	plain := randomBytes(t, 3*segmentSize)
+	if err := st.Write("sip/recordings/a.wav", bytes.NewReader(plain)); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(objs.m["sip/recordings/a.wav"], plain[:64]) {
+		t.Fatal("object stored in plaintext")
+	}
+	read := func(key string) ([]byte, error) {
+		rc, size, err := Wrap(objs, 0).Read(key)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err == nil && size != int64(len(b)) {
+			t.Fatalf("%s: size %d, read %d", key, size, len(b))
+		}
+		return b, err
+	}
+	if b, err := read("sip/recordings/a.wav"); err != nil || !bytes.Equal(b, plain) {
+		t.Fatalf("decrypt: err=%v", err)
+	}
+	if b, err := read("sip/recordings/old.wav"); err != nil || string(b) != "RIFF-plain" {
+		t.Fatalf("legacy plaintext: %q err=%v", b, err)
+	}
+
+	before := append([]byte(nil), objs.m["sip/recordings/a.wav"]...)
+	n, err := RotateTenant(ctx, 7)
+	if err != nil || n != 1 {
+		t.Fatalf("rotate: n=%d err=%v", n, err)
+	}
+	if ks.m["sip/recordings/a.wav"].KeyID != "v2" || !bytes.Equal(before, objs.m["sip/recordings/a.wav"]) {
+		t.Fatal("rotation must re-wrap the key without rewriting the object")
+	}
+	if b, err := read("sip/recordings/a.wav"); err != nil || !bytes.Equal(b, plain) {
+		t.Fatalf("decrypt after rotation: err=%v", err)
+	}
+
+	if _, err := ShredTenant(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	ks.m["sip/recordings/a.wav"] = DataKey{ObjectKey: "sip/recordings/a.wav", TenantID: 7, KeyID: "v2", Wrapped: []byte("stale backup row")}
+	if _, err := read("sip/recordings/a.wav"); !errors.Is(err, ErrKeyDestroyed) {
+		t.Fatalf("after shred: want ErrKeyDestroyed, got %v", err)
+	}
+}
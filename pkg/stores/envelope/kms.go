@@ -0,0 +1,233 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// KMS holds tenant key-encryption keys. Implementations must never
+// return KEK material; they only wrap and unwrap data keys. A cloud KMS
+// adapter implements the same interface with KeyID as the key version ARN.
+type KMS interface {
+	// WrapKey wraps dek with the tenant's current KEK (created on first use).
+	WrapKey(ctx context.Context, tenantID uint, dek []byte) (keyID string, wrapped []byte, err error)
+	// WrapKeyWith wraps dek with a specific KEK version (rotation).
+	WrapKeyWith(ctx context.Context, tenantID uint, keyID string, dek []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, tenantID uint, keyID string, wrapped []byte) ([]byte, error)
+	// RotateKey creates a new KEK version, makes it current and returns its id.
+	RotateKey(ctx context.Context, tenantID uint) (string, error)
+	// DestroyTenant deletes every KEK version of tenantID (crypto-shredding).
+	DestroyTenant(ctx context.Context, tenantID uint) error
+}
+
+// FileKMS keeps KEKs as 0600 files on local disk:
+//
+//	<dir>/tenant-<id>/v<n>.kek   32 random bytes per version
+//	<dir>/tenant-<id>/current    id of the active version ("v3")
+//
+// Meant for single-node deployments and development. The directory must
+// live outside the recording storage (and its backups) or shredding and
+// at-rest protection are void.
+type FileKMS struct {
+	dir string
+
+	mu    sync.Mutex
+	cache map[string][]byte // "<tenant>/<keyID>" → KEK
+}
+
+// NewFileKMS returns a FileKMS rooted at dir (created on first write).
+func NewFileKMS(dir string) *FileKMS {
+	return &FileKMS{dir: dir, cache: map[string][]byte{}}
+}
+
+func (f *FileKMS) tenantDir(tenantID uint) string {
+	return filepath.Join(f.dir, "tenant-"+strconv.FormatUint(uint64(tenantID), 10))
+}
+
+func validKeyID(keyID string) bool {
+	if len(keyID) < 2 || keyID[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(keyID[1:])
+	return err == nil
+}
+
+// currentLocked returns the active version, creating v1 when the tenant
+// has no key yet.
+func (f *FileKMS) currentLocked(tenantID uint) (string, error) {
+	b, err := os.ReadFile(filepath.Join(f.tenantDir(tenantID), "current"))
+	if err == nil {
+		if id := strings.TrimSpace(string(b)); validKeyID(id) {
+			return id, nil
+		}
+		return "", fmt.Errorf("envelope: corrupt current key pointer for tenant %d", tenantID)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return f.createLocked(tenantID, "v1")
+}
+
+func (f *FileKMS) createLocked(tenantID uint, keyID string) (string, error) {
+	dir := f.tenantDir(tenantID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	kek := make([]byte, dekSize)
+	if _, err := rand.Read(kek); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, keyID+".kek"), kek, 0o600); err != nil {
+		return "", err
+	}
+	tmp := filepath.Join(dir, "current.tmp")
+	if err := os.WriteFile(tmp, []byte(keyID), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "current")); err != nil {
+		return "", err
+	}
+	f.cache[strconv.FormatUint(uint64(tenantID), 10)+"/"+keyID] = kek
+	return keyID, nil
+}
+
+func (f *FileKMS) kekLocked(tenantID uint, keyID string) ([]byte, error) {
+	if !validKeyID(keyID) {
+		return nil, ErrKeyNotFound
+	}
+	ck := strconv.FormatUint(uint64(tenantID), 10) + "/" + keyID
+	if kek, ok := f.cache[ck]; ok {
+		return kek, nil
+	}
+	kek, err := os.ReadFile(filepath.Join(f.tenantDir(tenantID), keyID+".kek"))
+	if errors.Is(err, os.ErrNotExist) {
+		if _, derr := os.Stat(f.tenantDir(tenantID)); errors.Is(derr, os.ErrNotExist) {
+			return nil, ErrKeyDestroyed
+		}
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(kek) != dekSize {
+		return nil, fmt.Errorf("envelope: bad KEK length for tenant %d %s", tenantID, keyID)
+	}
+	f.cache[ck] = kek
+	return kek, nil
+}
+
+// WrapKey implements KMS.
+func (f *FileKMS) WrapKey(ctx context.Context, tenantID uint, dek []byte) (string, []byte, error) {
+	f.mu.Lock()
+	keyID, err := f.currentLocked(tenantID)
+	f.mu.Unlock()
+	if err != nil {
+		return "", nil, err
+	}
+	wrapped, err := f.WrapKeyWith(ctx, tenantID, keyID, dek)
+	return keyID, wrapped, err
+}
+
+// WrapKeyWith implements KMS.
+func (f *FileKMS) WrapKeyWith(_ context.Context, tenantID uint, keyID string, dek []byte) ([]byte, error) {
+	f.mu.Lock()
+	kek, err := f.kekLocked(tenantID, keyID)
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return sealKey(kek, dek, wrapAAD(tenantID, keyID))
+}
+
+// UnwrapKey implements KMS.
+func (f *FileKMS) UnwrapKey(_ context.Context, tenantID uint, keyID string, wrapped []byte) ([]byte, error) {
+	f.mu.Lock()
+	kek, err := f.kekLocked(tenantID, keyID)
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return openKey(kek, wrapped, wrapAAD(tenantID, keyID))
+}
+
+// RotateKey implements KMS.
+func (f *FileKMS) RotateKey(_ context.Context, tenantID uint) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cur, err := f.currentLocked(tenantID)
+	if err != nil {
+		return "", err
+	}
+	n, _ := strconv.Atoi(cur[1:])
+	return f.createLocked(tenantID, "v"+strconv.Itoa(n+1))
+}
+
+// DestroyTenant implements KMS. Key files are overwritten with zeros
+// before removal so a plain undelete on the filesystem does not bring
+// them back.
+func (f *FileKMS) DestroyTenant(_ context.Context, tenantID uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefix := strconv.FormatUint(uint64(tenantID), 10) + "/"
+	for k := range f.cache {
+		if strings.HasPrefix(k, prefix) {
+			delete(f.cache, k)
+		}
+	}
+	dir := f.tenantDir(tenantID)
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.kek"))
+	for _, p := range matches {
+		_ = os.WriteFile(p, make([]byte, dekSize), 0o600)
+	}
+	return os.RemoveAll(dir)
+}
+
+// wrapAAD binds a wrapped DEK to its tenant and KEK version, so a key
+// row copied to another tenant does not unwrap.
+func wrapAAD(tenantID uint, keyID string) []byte {
+	return []byte("vs-dek|" + strconv.FormatUint(uint64(tenantID), 10) + "|" + keyID)
+}
+
+func sealKey(kek, dek, aad []byte) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dek, aad), nil
+}
+
+func openKey(kek, wrapped, aad []byte) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("envelope: wrapped key too short")
+	}
+	ns := aead.NonceSize()
+	return aead.Open(nil, wrapped[:ns], wrapped[ns:], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
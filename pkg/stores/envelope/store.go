@@ -0,0 +1,128 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package envelope
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/LinByte/VoiceServer/pkg/stores"
+)
+
+// Store decorates a stores.Store with envelope encryption (see Wrap).
+type Store struct {
+	inner    stores.Store
+	tenantID uint
+}
+
+var _ stores.Store = (*Store)(nil)
+
+// Write implements stores.Store: fresh DEK, wrapped key row first, then
+// the ciphertext upload. Without a KMS configured the object is written
+// as plaintext.
+func (s *Store) Write(key string, r io.Reader) error {
+	k, ks := current()
+	if k == nil || ks == nil {
+		return s.inner.Write(key, r)
+	}
+	ctx := context.Background()
+	key = normalizeKey(key)
+	dek, err := newDEK()
+	if err != nil {
+		return err
+	}
+	keyID, wrapped, err := k.WrapKey(ctx, s.tenantID, dek)
+	if err != nil {
+		return fmt.Errorf("envelope: wrap data key: %w", err)
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return err
+	}
+	er, err := newEncryptReader(r, aead)
+	if err != nil {
+		return err
+	}
+	if err := ks.PutDataKey(ctx, DataKey{
+		ObjectKey: key,
+		TenantID:  s.tenantID,
+		KeyID:     keyID,
+		Wrapped:   wrapped,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("envelope: store data key: %w", err)
+	}
+	if err := s.inner.Write(key, er); err != nil {
+		_ = ks.DeleteDataKey(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// Read implements stores.Store, decrypting transparently. Objects with
+// no key row are returned as stored (pre-encryption recordings).
+func (s *Store) Read(key string) (io.ReadCloser, int64, error) {
+	_, ks := current()
+	if ks == nil {
+		return s.inner.Read(key)
+	}
+	ctx := context.Background()
+	dk, ok, err := ks.GetDataKey(ctx, normalizeKey(key))
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return s.inner.Read(key)
+	}
+	k, _ := current()
+	if k == nil {
+		return nil, 0, fmt.Errorf("envelope: %s is encrypted but no KMS is configured", key)
+	}
+	dek, err := k.UnwrapKey(ctx, dk.TenantID, dk.KeyID, dk.Wrapped)
+	if err != nil {
+		return nil, 0, fmt.Errorf("envelope: unwrap data key: %w", err)
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, 0, err
+	}
+	rc, size, err := s.inner.Read(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	dr, err := newDecryptReader(rc, aead)
+	if err != nil {
+		_ = rc.Close()
+		return nil, 0, err
+	}
+	if size > 0 {
+		size = PlaintextSize(size)
+	}
+	return dr, size, nil
+}
+
+// Delete implements stores.Store and drops the key row with the object.
+func (s *Store) Delete(key string) error {
+	if err := s.inner.Delete(key); err != nil {
+		return err
+	}
+	if _, ks := current(); ks != nil {
+		return ks.DeleteDataKey(context.Background(), normalizeKey(key))
+	}
+	return nil
+}
+
+// Exists implements stores.Store.
+func (s *Store) Exists(key string) (bool, error) { return s.inner.Exists(key) }
+
+// PublicURL implements stores.Store. The URL serves ciphertext for
+// encrypted objects; players must go through a decrypting endpoint.
+func (s *Store) PublicURL(key string) string { return s.inner.PublicURL(key) }
+
+func normalizeKey(key string) string {
+	return strings.TrimPrefix(strings.TrimSpace(key), "/")
+}
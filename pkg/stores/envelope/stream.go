@@ -0,0 +1,207 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Ciphertext layout:
+//
+//	"VSE1" | nonce prefix (8 bytes) | segment 0 | segment 1 | …
+//
+// Each segment seals up to segmentSize plaintext bytes with AES-GCM
+// (nonce = prefix ‖ big-endian segment counter). The additional data is
+// one byte, 1 on the last segment and 0 otherwise, so truncating the
+// object at a segment boundary fails authentication instead of yielding
+// a silently shortened recording.
+const (
+	segmentSize = 64 << 10
+	prefixSize  = 8
+	headerSize  = len(magic) + prefixSize
+	tagSize     = 16
+)
+
+const magic = "VSE1"
+
+var errTruncated = errors.New("envelope: ciphertext truncated")
+
+func segmentNonce(prefix []byte, n uint32) []byte {
+	nonce := make([]byte, prefixSize+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[prefixSize:], n)
+	return nonce
+}
+
+func finalAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// PlaintextSize returns the decrypted length of a ciphertext of n bytes,
+// or -1 when n cannot be a valid ciphertext.
+func PlaintextSize(n int64) int64 {
+	body := n - int64(headerSize)
+	if body < tagSize {
+		return -1
+	}
+	full := int64(segmentSize + tagSize)
+	segs := (body + full - 1) / full
+	if rem := body % full; rem != 0 && rem < tagSize {
+		return -1
+	}
+	return body - segs*tagSize
+}
+
+// encryptReader streams the ciphertext of src.
+type encryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	seq    uint32
+	out    bytes.Buffer
+	plain  []byte
+	done   bool
+	err    error
+}
+
+func newEncryptReader(src io.Reader, aead cipher.AEAD) (*encryptReader, error) {
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	r := &encryptReader{
+		src:    bufio.NewReaderSize(src, segmentSize),
+		aead:   aead,
+		prefix: prefix,
+		plain:  make([]byte, segmentSize),
+	}
+	r.out.WriteString(magic)
+	r.out.Write(prefix)
+	return r, nil
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.fill()
+	}
+	return r.out.Read(p)
+}
+
+// fill seals the next segment into out. A segment is final when the
+// source ends inside it or exactly at its end (peek finds EOF).
+func (r *encryptReader) fill() {
+	n, err := io.ReadFull(r.src, r.plain)
+	final := false
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		final = true
+	case err != nil:
+		r.err = err
+		return
+	default:
+		if _, perr := r.src.Peek(1); perr == io.EOF {
+			final = true
+		} else if perr != nil {
+			r.err = perr
+			return
+		}
+	}
+	r.out.Write(r.aead.Seal(nil, segmentNonce(r.prefix, r.seq), r.plain[:n], finalAAD(final)))
+	r.seq++
+	r.done = final
+}
+
+// decryptReader streams the plaintext of a ciphertext produced by
+// encryptReader, authenticating each segment before releasing it.
+type decryptReader struct {
+	src    *bufio.Reader
+	closer io.Closer
+	aead   cipher.AEAD
+	prefix []byte
+	seq    uint32
+	seg    []byte
+	out    []byte
+	done   bool
+	err    error
+}
+
+func newDecryptReader(src io.ReadCloser, aead cipher.AEAD) (*decryptReader, error) {
+	br := bufio.NewReaderSize(src, segmentSize+tagSize)
+	hdr := make([]byte, headerSize)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, errTruncated
+	}
+	if string(hdr[:len(magic)]) != magic {
+		return nil, errors.New("envelope: not an encrypted object")
+	}
+	return &decryptReader{
+		src:    br,
+		closer: src,
+		aead:   aead,
+		prefix: append([]byte(nil), hdr[len(magic):]...),
+		seg:    make([]byte, segmentSize+tagSize),
+	}, nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.next()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *decryptReader) next() {
+	n, err := io.ReadFull(r.src, r.seg)
+	final := false
+	switch {
+	case err == io.EOF:
+		r.err = errTruncated
+		return
+	case err == io.ErrUnexpectedEOF:
+		final = true
+	case err != nil:
+		r.err = err
+		return
+	default:
+		if _, perr := r.src.Peek(1); perr == io.EOF {
+			final = true
+		} else if perr != nil {
+			r.err = perr
+			return
+		}
+	}
+	plain, oerr := r.aead.Open(r.seg[:0], segmentNonce(r.prefix, r.seq), r.seg[:n], finalAAD(final))
+	if oerr != nil {
+		r.err = oerr
+		return
+	}
+	r.seq++
+	r.out = plain
+	r.done = final
+}
+
+func (r *decryptReader) Close() error { return r.closer.Close() }
@@ -131,6 +131,14 @@ func (p *Pipeline) SetErrorCallback(cb func(err error, fatal bool)) {
 	p.onErr = cb
 }
 
+// Close stops the recognizer connection; the pipeline is unusable afterwards.
+func (p *Pipeline) Close() error {
+	if p == nil || p.asr == nil {
+		return nil
+	}
+	return p.asr.StopConn()
+}
+
 // GetMetrics returns a snapshot of pipeline metrics. The returned value
 // is a field-by-field copy so the caller never observes a torn read and
 // the embedded sync.RWMutex is not copied (go vet -copylocks).
@@ -0,0 +1,106 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cdr
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EnvDir names the CDR spool directory; unset = "trace" (see
+// Config.applyDefaults).
+const EnvDir = "LINGECHOX_CDR_DIR"
+
+// DirFromEnv returns the spool directory the SIP app writes CDRs to.
+func DirFromEnv() string {
+	if d := strings.TrimSpace(os.Getenv(EnvDir)); d != "" {
+		return d
+	}
+	return "trace"
+}
+
+// PurgeResult summarises one PurgeRotated pass.
+type PurgeResult struct {
+	Removed []string // file names deleted
+	Held    []string // expired files kept because they contain a held call
+	Failed  int
+}
+
+// PurgeRotated deletes rotated `<baseName>-<stamp>.jsonl` files in dir
+// whose last write is before cutoff. The file being written
+// (`<baseName>.current.jsonl`) is never touched. A file holding a line
+// for any Call-ID in held (legal hold) is kept whole: CDR files are an
+// append-only shipping format and are not rewritten in place.
+func PurgeRotated(dir, baseName string, cutoff time.Time, held map[string]struct{}) (PurgeResult, error) {
+	var res PurgeResult
+	if baseName == "" {
+		baseName = "cdr"
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return res, err
+	}
+	prefix := baseName + "-"
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".jsonl")
+		if _, err := time.Parse("20060102T150405Z", stamp); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if len(held) > 0 {
+			hit, err := fileHasCallID(path, held)
+			if err != nil {
+				res.Failed++
+				continue
+			}
+			if hit {
+				res.Held = append(res.Held, name)
+				continue
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			res.Failed++
+			continue
+		}
+		res.Removed = append(res.Removed, name)
+	}
+	return res, nil
+}
+
+func fileHasCallID(path string, ids map[string]struct{}) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 4<<20)
+	for sc.Scan() {
+		var rec struct {
+			CallID string `json:"call_id"`
+		}
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		if _, ok := ids[rec.CallID]; ok {
+			return true, nil
+		}
+	}
+	return false, sc.Err()
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package cdr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPurgeRotated_KeepsHeldAndCurrent(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(name, body string, mtime time.Time) {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cdr-20260101T000000Z.jsonl", `{"call_id":"a"}`+"\n", old)
+	write("cdr-20260101T010000Z.jsonl", `{"call_id":"b"}`+"\n"+`{"call_id":"held-1"}`+"\n", old)
+	write("cdr-20260101T020000Z.jsonl", `{"call_id":"c"}`+"\n", time.Now())
+	write("cdr.current.jsonl", `{"call_id":"d"}`+"\n", old)
+	write("other.jsonl", "", old)
+
+	res, err := PurgeRotated(dir, "cdr", time.Now().Add(-24*time.Hour), map[string]struct{}{"held-1": {}})
+	if err != nil {
+		t.Fatalf("PurgeRotated: %v", err)
+	}
+	if len(res.Removed) != 1 || res.Removed[0] != "cdr-20260101T000000Z.jsonl" {
+		t.Fatalf("removed = %v", res.Removed)
+	}
+	if len(res.Held) != 1 || res.Held[0] != "cdr-20260101T010000Z.jsonl" {
+		t.Fatalf("held = %v", res.Held)
+	}
+	for _, keep := range []string{"cdr-20260101T010000Z.jsonl", "cdr-20260101T020000Z.jsonl", "cdr.current.jsonl", "other.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, keep)); err != nil {
+			t.Errorf("%s should survive: %v", keep, err)
+		}
+	}
+
+	if res, err := PurgeRotated(filepath.Join(dir, "missing"), "cdr", time.Now(), nil); err != nil || len(res.Removed) != 0 {
+		t.Fatalf("missing dir: %+v %v", res, err)
+	}
+}
@@ -76,6 +76,21 @@ type CallRecord struct {
 	E2EFirstByteP95 int64 `json:"e2e_first_byte_ms_p95,omitempty"`
 	BargeInCount    int   `json:"barge_in_count,omitempty"`
 
+	// Recording pauses (PCI card capture): how many times and for how
+	// long the call recording was deliberately paused.
+	RecordingPauses   int   `json:"recording_pauses,omitempty"`
+	RecordingPausedMs int64 `json:"recording_paused_ms,omitempty"`
+
+	// Fax calls (CNG / CED detected or peer switched to T.38). Empty on
+	// voice calls. FaxResult: ok / partial / failed / relayed.
+	FaxMode     string `json:"fax_mode,omitempty"` // t38 / g711_passthrough
+	FaxResult   string `json:"fax_result,omitempty"`
+	FaxReason   string `json:"fax_reason,omitempty"`
+	FaxPages    int    `json:"fax_pages,omitempty"`
+	FaxRemoteID string `json:"fax_remote_id,omitempty"` // sender TSI
+	FaxBitRate  int    `json:"fax_bit_rate,omitempty"`
+	FaxDocument string `json:"fax_document,omitempty"` // stored PDF / TIFF URL
+
 	// Free-form structured tail for things we don't want to elevate
 	// to first-class columns yet. Keep keys short; values must be
 	// JSON-encodable (string / number / bool / nested map).
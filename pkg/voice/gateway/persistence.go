@@ -67,6 +67,17 @@ type RecordingInfo struct {
 	DurationMs int64
 	Hash       string
 	Note       string
+	// Gaps lists intervals deliberately left out of the recording
+	// (capture paused for PCI card entry). The audio is zero-padded.
+	Gaps []RecordingGap
+}
+
+// RecordingGap is one paused interval. OffsetMs is where the gap starts
+// on the recording timeline; Start/End are wall-clock.
+type RecordingGap struct {
+	Start    time.Time
+	End      time.Time
+	OffsetMs int64
 }
 
 // TurnEvent is delivered after each TTS Speak completes. It pairs with
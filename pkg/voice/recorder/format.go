@@ -0,0 +1,206 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/LinByte/VoiceServer/pkg/utils"
+)
+
+// Format is the container/codec of the final recording object. Rolling
+// chunk parts are always raw PCM + WAV regardless of Format: they are
+// crash-recovery breadcrumbs merged (and deleted) by Flush.
+type Format string
+
+const (
+	FormatWAV  Format = "wav" // PCM16 stereo, ~115 MB/h at 16 kHz
+	FormatOpus Format = "ogg" // Opus in OGG (RFC 7845), ~15 MB/h at 32 kbit/s
+	FormatMP3  Format = "mp3" // MP3 via ffmpeg/libmp3lame, ~21 MB/h at 48 kbit/s
+)
+
+// Env keys for the process default format and encoder bitrates.
+const (
+	EnvFormat      = "RECORDING_FORMAT"       // wav (default) / opus / mp3
+	EnvOpusBitrate = "RECORDING_OPUS_BITRATE" // bit/s, default 32000
+	EnvMP3Bitrate  = "RECORDING_MP3_BITRATE"  // kbit/s, default 48
+	EnvFFmpegPath  = "RECORDING_FFMPEG"       // ffmpeg binary, default "ffmpeg" on PATH
+)
+
+const (
+	defaultOpusBitrate = 32000
+	defaultMP3Bitrate  = 48
+)
+
+// ParseFormat normalises a config string. Empty input yields "" (inherit
+// the default); unknown values are an error.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return "", nil
+	case "wav":
+		return FormatWAV, nil
+	case "ogg", "opus":
+		return FormatOpus, nil
+	case "mp3":
+		return FormatMP3, nil
+	default:
+		return "", fmt.Errorf("recorder: unknown recording format %q", s)
+	}
+}
+
+// DefaultFormat returns the RECORDING_FORMAT env default (WAV when unset
+// or invalid).
+func DefaultFormat() Format {
+	if f, err := ParseFormat(utils.GetEnv(EnvFormat)); err == nil && f != "" {
+		return f
+	}
+	return FormatWAV
+}
+
+// Ext returns the object key extension including the dot.
+func (f Format) Ext() string {
+	switch f {
+	case FormatOpus:
+		return ".ogg"
+	case FormatMP3:
+		return ".mp3"
+	default:
+		return ".wav"
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	return ContentTypeForKey(f.Ext())
+}
+
+// ContentTypeForKey maps a recording object key / file name to its MIME
+// type by extension (Go's builtin table lacks .ogg on minimal images).
+func ContentTypeForKey(key string) string {
+	k := strings.ToLower(key)
+	switch {
+	case strings.HasSuffix(k, ".ogg"), strings.HasSuffix(k, ".opus"):
+		return "audio/ogg"
+	case strings.HasSuffix(k, ".mp3"):
+		return "audio/mpeg"
+	case strings.HasSuffix(k, ".wav"):
+		return "audio/wav"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// FormatLoader returns the stored recording format of one tenant ("" =
+// not set).
+type FormatLoader func(ctx context.Context, tenantID uint) string
+
+var (
+	formatMu     sync.RWMutex
+	formatLoader FormatLoader
+)
+
+// SetFormatLoader installs the DB-backed tenant format loader. nil clears it.
+func SetFormatLoader(fn FormatLoader) {
+	formatMu.Lock()
+	formatLoader = fn
+	formatMu.Unlock()
+}
+
+// FormatForTenant resolves the recording format for tenantID: the tenant
+// setting when present and valid, otherwise DefaultFormat(). Consulted
+// once per call when the recorder is attached.
+func FormatForTenant(ctx context.Context, tenantID uint) Format {
+	formatMu.RLock()
+	fn := formatLoader
+	formatMu.RUnlock()
+	if fn != nil && tenantID > 0 {
+		if f, err := ParseFormat(fn(ctx, tenantID)); err == nil && f != "" {
+			return f
+		}
+	}
+	return DefaultFormat()
+}
+
+// opusRates are the input rates libopus accepts without resampling.
+var opusRates = map[int]bool{8000: true, 12000: true, 16000: true, 24000: true, 48000: true}
+
+// usableFormat downgrades f to WAV when it cannot be produced for this
+// call (Opus at an unsupported bridge rate, no ffmpeg binary for MP3), so
+// a misconfiguration never costs the recording itself.
+func usableFormat(f Format, rate int) (Format, string) {
+	switch f {
+	case FormatOpus:
+		if !opusRates[rate] {
+			return FormatWAV, fmt.Sprintf("opus does not support %d Hz", rate)
+		}
+	case FormatMP3:
+		if _, err := exec.LookPath(ffmpegPath()); err != nil {
+			return FormatWAV, "ffmpeg not found: " + err.Error()
+		}
+	case FormatWAV:
+	default:
+		return FormatWAV, "unknown format " + string(f)
+	}
+	return f, ""
+}
+
+func ffmpegPath() string {
+	if p := strings.TrimSpace(utils.GetEnv(EnvFFmpegPath)); p != "" {
+		return p
+	}
+	return "ffmpeg"
+}
+
+// mp3Writer pipes interleaved stereo PCM16 (as produced by
+// streamInterleave) through an ffmpeg child process (libmp3lame). The
+// encoder streams: ffmpeg reads stdin and writes MP3 frames to out as it
+// goes, so memory stays bounded for arbitrarily long calls.
+type mp3Writer struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+}
+
+func newMP3Writer(out io.Writer, rate int, comment string) (*mp3Writer, error) {
+	kbps := utils.GetIntEnvWithDefault(EnvMP3Bitrate, defaultMP3Bitrate)
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-f", "s16le", "-ar", strconv.Itoa(rate), "-ac", "2", "-i", "pipe:0",
+		"-codec:a", "libmp3lame", "-b:a", strconv.Itoa(kbps) + "k",
+	}
+	if comment != "" {
+		args = append(args, "-metadata", "comment="+comment)
+	}
+	args = append(args, "-f", "mp3", "pipe:1")
+	w := &mp3Writer{cmd: exec.Command(ffmpegPath(), args...)}
+	w.cmd.Stdout = out
+	w.cmd.Stderr = &w.stderr
+	stdin, err := w.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	w.stdin = stdin
+	if err := w.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("recorder: start ffmpeg: %w", err)
+	}
+	return w, nil
+}
+
+func (w *mp3Writer) Write(p []byte) (int, error) { return w.stdin.Write(p) }
+
+func (w *mp3Writer) Close() error {
+	_ = w.stdin.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return fmt.Errorf("recorder: ffmpeg mp3: %w: %s", err, strings.TrimSpace(w.stderr.String()))
+	}
+	return nil
+}
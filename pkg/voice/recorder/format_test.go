@@ -0,0 +1,104 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestParseFormatAndTenantResolve(t *testing.T) {
+	for in, want := range map[string]Format{"": "", "WAV": FormatWAV, "opus": FormatOpus, "ogg": FormatOpus, " mp3 ": FormatMP3} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("flac"); err == nil {
+		t.Fatal("unknown format must be rejected")
+	}
+
+	t.Setenv(EnvFormat, "mp3")
+	defer SetFormatLoader(nil)
+	SetFormatLoader(func(_ context.Context, id uint) string {
+		if id == 7 {
+			return "opus"
+		}
+		return ""
+	})
+	if f := FormatForTenant(context.Background(), 7); f != FormatOpus {
+		t.Fatalf("tenant 7: %q", f)
+	}
+	if f := FormatForTenant(context.Background(), 8); f != FormatMP3 {
+		t.Fatalf("tenant without setting should inherit env: %q", f)
+	}
+	if f, why := usableFormat(FormatOpus, 11025); f != FormatWAV || why == "" {
+		t.Fatalf("opus at 11025 Hz must fall back: %q %q", f, why)
+	}
+}
+
+func TestRecorder_MP3WithoutFFmpegFallsBackToWAV(t *testing.T) {
+	t.Setenv(EnvFFmpegPath, "/nonexistent/ffmpeg")
+	store := newMemStore()
+	r := New(Config{CallID: "call-mp3", SampleRate: 8000, Store: store, Format: FormatMP3})
+	r.WriteCaller(make([]byte, 320))
+	info, ok := r.Flush(context.Background())
+	if !ok || info.Format != "wav" || !strings.HasSuffix(info.Key, ".wav") {
+		t.Fatalf("info=%+v ok=%v", info, ok)
+	}
+}
+
+func TestOggPagesAndOpusDuration(t *testing.T) {
+	var buf bytes.Buffer
+	s := oggStream{w: &buf, serial: 42}
+	if err := s.writePage(oggHeaderBOS, 0, [][]byte{opusHead(2, 16000)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.writePage(0, 0, [][]byte{opusTags("COMMENT=x")}); err != nil {
+		t.Fatal(err)
+	}
+	big := bytes.Repeat([]byte{1}, 300) // laced as 255 + 45
+	if err := s.writePage(0, opusPreSkip+960, [][]byte{big, {2}}); err != nil {
+		t.Fatal(err)
+	}
+	// 2.5 s of audio at 48 kHz after pre-skip.
+	if err := s.writePage(oggHeaderEOS, opusPreSkip+120000, [][]byte{{3}}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	// Walk pages: sequence numbers, CRCs and lacing must be consistent.
+	var seq uint32
+	for i := 0; i < len(b); seq++ {
+		if string(b[i:i+4]) != "OggS" {
+			t.Fatalf("page %d: bad capture pattern", seq)
+		}
+		if got := binary.LittleEndian.Uint32(b[i+18:]); got != seq {
+			t.Fatalf("page seq %d, want %d", got, seq)
+		}
+		nseg := int(b[i+26])
+		size := 0
+		for _, l := range b[i+27 : i+27+nseg] {
+			size += int(l)
+		}
+		page := append([]byte(nil), b[i:i+27+nseg+size]...)
+		want := binary.LittleEndian.Uint32(page[22:26])
+		binary.LittleEndian.PutUint32(page[22:26], 0)
+		if got := oggCRC(0, page); got != want {
+			t.Fatalf("page %d crc %08x, want %08x", seq, got, want)
+		}
+		i += len(page)
+	}
+	if seq != 4 {
+		t.Fatalf("pages = %d", seq)
+	}
+	if ms := OggOpusDurationMs(b); ms != 2500 {
+		t.Fatalf("duration = %d ms", ms)
+	}
+	if OggOpusDurationMs([]byte("RIFF....WAVE")) != 0 {
+		t.Fatal("non-ogg input must yield 0")
+	}
+}
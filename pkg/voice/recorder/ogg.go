@@ -0,0 +1,293 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package recorder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/LinByte/VoiceServer/pkg/utils"
+	"github.com/hraban/opus"
+)
+
+// Ogg page framing (RFC 3533) and the Opus mapping (RFC 7845). Granule
+// positions are always in 48 kHz samples regardless of the input rate;
+// the last page's granule minus pre-skip is the exact playback length,
+// which is what players (and OggOpusDurationMs) report as duration.
+
+const (
+	oggHeaderContinued = 0x01
+	oggHeaderBOS       = 0x02
+	oggHeaderEOS       = 0x04
+
+	// opusPreSkip is libopus' encoder lookahead at 48 kHz (6.5 ms) for
+	// the VoIP application; decoders drop this many leading samples.
+	opusPreSkip = 312
+	// opusFrameMs is the encoder frame size. 20 ms is the libopus sweet
+	// spot for speech.
+	opusFrameMs = 20
+	// oggMaxPacketsPerPage bounds page latency / size (~1 s of audio);
+	// one page per packet would add ~11 kbit/s of framing overhead.
+	oggMaxPacketsPerPage = 50
+)
+
+var oggCRCTable = func() (t [256]uint32) {
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+func oggCRC(crc uint32, b []byte) uint32 {
+	for _, v := range b {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^v]
+	}
+	return crc
+}
+
+// oggStream writes the pages of one logical bitstream.
+type oggStream struct {
+	w      io.Writer
+	serial uint32
+	seq    uint32
+}
+
+// writePage emits packets as one page. Each packet is laced with 255-byte
+// segments; callers keep the total under 255 segments.
+func (s *oggStream) writePage(flags byte, granule int64, packets [][]byte) error {
+	var lacing []byte
+	size := 0
+	for _, p := range packets {
+		n := len(p)
+		for n >= 255 {
+			lacing = append(lacing, 255)
+			n -= 255
+		}
+		lacing = append(lacing, byte(n))
+		size += len(p)
+	}
+	if len(lacing) > 255 {
+		return errors.New("recorder: ogg page exceeds 255 segments")
+	}
+	page := make([]byte, 27+len(lacing), 27+len(lacing)+size)
+	copy(page[0:4], "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:18], s.serial)
+	binary.LittleEndian.PutUint32(page[18:22], s.seq)
+	page[26] = byte(len(lacing))
+	copy(page[27:], lacing)
+	for _, p := range packets {
+		page = append(page, p...)
+	}
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(0, page))
+	s.seq++
+	_, err := s.w.Write(page)
+	return err
+}
+
+// opusHead builds the RFC 7845 identification header.
+func opusHead(channels, inputRate int) []byte {
+	b := make([]byte, 19)
+	copy(b, "OpusHead")
+	b[8] = 1 // version
+	b[9] = byte(channels)
+	binary.LittleEndian.PutUint16(b[10:12], opusPreSkip)
+	binary.LittleEndian.PutUint32(b[12:16], uint32(inputRate))
+	// output gain 0, channel mapping family 0 (mono / stereo)
+	return b
+}
+
+// opusTags builds the comment header; comments are "KEY=value" strings.
+func opusTags(comments ...string) []byte {
+	const vendor = "VoiceServer recorder"
+	b := append([]byte("OpusTags"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[8:], uint32(len(vendor)))
+	b = append(b, vendor...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(comments)))
+	for _, c := range comments {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(c)))
+		b = append(b, c...)
+	}
+	return b
+}
+
+// oggOpusWriter encodes interleaved stereo PCM16 LE (as produced by
+// streamInterleave) to Opus and muxes it into an Ogg stream. legSamples
+// is known up-front, so the EOS page carries the exact end granule and
+// the zero padding of the last frame is trimmed on playback.
+type oggOpusWriter struct {
+	ogg        oggStream
+	enc        *opus.Encoder
+	rate       int
+	legSamples int64
+	frame      []int16 // interleaved samples of one frame
+	fill       int
+	odd        []byte // dangling byte of a split sample
+	out        []byte
+	pending    [][]byte
+	segs       int
+	frames     int64
+}
+
+func newOggOpusWriter(w io.Writer, rate int, legSamples int64, comment string) (*oggOpusWriter, error) {
+	enc, err := opus.NewEncoder(rate, 2, opus.AppVoIP)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: opus encoder: %w", err)
+	}
+	if err := enc.SetBitrate(utils.GetIntEnvWithDefault(EnvOpusBitrate, defaultOpusBitrate)); err != nil {
+		return nil, fmt.Errorf("recorder: opus bitrate: %w", err)
+	}
+	ow := &oggOpusWriter{
+		ogg:        oggStream{w: w, serial: rand.Uint32()},
+		enc:        enc,
+		rate:       rate,
+		legSamples: legSamples,
+		frame:      make([]int16, rate*opusFrameMs/1000*2),
+		out:        make([]byte, 4000),
+	}
+	if err := ow.ogg.writePage(oggHeaderBOS, 0, [][]byte{opusHead(2, rate)}); err != nil {
+		return nil, err
+	}
+	var tags []string
+	if comment != "" {
+		tags = append(tags, "COMMENT="+comment)
+	}
+	if err := ow.ogg.writePage(0, 0, [][]byte{opusTags(tags...)}); err != nil {
+		return nil, err
+	}
+	return ow, nil
+}
+
+func (ow *oggOpusWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(ow.odd) > 0 && len(p) > 0 {
+		if err := ow.push(int16(binary.LittleEndian.Uint16([]byte{ow.odd[0], p[0]}))); err != nil {
+			return 0, err
+		}
+		ow.odd = ow.odd[:0]
+		p = p[1:]
+	}
+	for len(p) >= 2 {
+		if err := ow.push(int16(binary.LittleEndian.Uint16(p))); err != nil {
+			return 0, err
+		}
+		p = p[2:]
+	}
+	if len(p) == 1 {
+		ow.odd = append(ow.odd[:0], p[0])
+	}
+	return n, nil
+}
+
+func (ow *oggOpusWriter) push(v int16) error {
+	ow.frame[ow.fill] = v
+	ow.fill++
+	if ow.fill < len(ow.frame) {
+		return nil
+	}
+	return ow.encodeFrame()
+}
+
+func (ow *oggOpusWriter) encodeFrame() error {
+	n, err := ow.enc.Encode(ow.frame, ow.out)
+	if err != nil {
+		return fmt.Errorf("recorder: opus encode: %w", err)
+	}
+	ow.fill = 0
+	ow.frames++
+	pkt := append([]byte(nil), ow.out[:n]...)
+	segs := n/255 + 1
+	if ow.segs+segs > 255 || len(ow.pending) >= oggMaxPacketsPerPage {
+		if err := ow.flushPage(0); err != nil {
+			return err
+		}
+	}
+	ow.pending = append(ow.pending, pkt)
+	ow.segs += segs
+	return nil
+}
+
+// granule returns the 48 kHz end position of the packets encoded so far.
+func (ow *oggOpusWriter) granule() int64 {
+	return opusPreSkip + ow.frames*int64(48*opusFrameMs)
+}
+
+func (ow *oggOpusWriter) flushPage(flags byte) error {
+	if len(ow.pending) == 0 && flags&oggHeaderEOS == 0 {
+		return nil
+	}
+	g := ow.granule()
+	if flags&oggHeaderEOS != 0 {
+		// End trimming: the last page may declare fewer samples than
+		// its packets decode to (RFC 7845 §4.4).
+		if end := opusPreSkip + ow.legSamples*48000/int64(ow.rate); end < g {
+			g = end
+		}
+	}
+	err := ow.ogg.writePage(flags, g, ow.pending)
+	ow.pending = ow.pending[:0]
+	ow.segs = 0
+	return err
+}
+
+// Close pads and encodes the last partial frame and writes the EOS page.
+func (ow *oggOpusWriter) Close() error {
+	if ow.fill > 0 {
+		for i := ow.fill; i < len(ow.frame); i++ {
+			ow.frame[i] = 0
+		}
+		if err := ow.encodeFrame(); err != nil {
+			return err
+		}
+	}
+	return ow.flushPage(oggHeaderEOS)
+}
+
+// OggOpusDurationMs returns the playback length of an Ogg Opus stream
+// from its last page granule and the OpusHead pre-skip. 0 when b is not
+// Ogg Opus or is truncated before any audio page.
+func OggOpusDurationMs(b []byte) int64 {
+	if len(b) < 27+19 || string(b[0:4]) != "OggS" {
+		return 0
+	}
+	hdrStart := 27 + int(b[26])
+	if hdrStart+19 > len(b) || string(b[hdrStart:hdrStart+8]) != "OpusHead" {
+		return 0
+	}
+	preSkip := int64(binary.LittleEndian.Uint16(b[hdrStart+10 : hdrStart+12]))
+	var last int64 = -1
+	for i := 0; i+27 <= len(b); {
+		if string(b[i:i+4]) != "OggS" {
+			break
+		}
+		nseg := int(b[i+26])
+		if i+27+nseg > len(b) {
+			break
+		}
+		size := 0
+		for _, l := range b[i+27 : i+27+nseg] {
+			size += int(l)
+		}
+		if g := int64(binary.LittleEndian.Uint64(b[i+6 : i+14])); g >= 0 {
+			last = g
+		}
+		i += 27 + nseg + size
+	}
+	if last <= preSkip {
+		return 0
+	}
+	return (last - preSkip) * 1000 / 48000
+}
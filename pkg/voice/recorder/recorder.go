@@ -13,6 +13,10 @@
 //     listener hears events in the order they actually happened, even
 //     when frames arrive in bursts due to scheduler / network latency.
 //
+// The final object is WAV unless Config.Format selects Opus-in-OGG or
+// MP3; compressed formats are encoded from the same streamed timeline,
+// so peak memory does not grow with call length either way.
+//
 // Wall-clock alignment is the trick borrowed from LingEchoX's
 // `placeWallPCMTrack`: each frame is captured with `time.Now().UnixNano()`
 // at write time. At flush time we pick `base = min(wallNs)` across both
@@ -39,6 +43,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"github.com/LinByte/VoiceServer/pkg/stores"
 	"github.com/LinByte/VoiceServer/pkg/utils"
 	"github.com/LinByte/VoiceServer/pkg/voice/gateway"
@@ -72,6 +77,19 @@ type Config struct {
 	// Typical setting: 30s. Smaller = more S3 PUTs but better
 	// recovery granularity; larger = cheaper but more lost on crash.
 	ChunkInterval time.Duration
+
+	// RedactMode renders ranges registered through Redact in the final
+	// WAV: redact.AudioBeep overlays a 1 kHz tone, anything else zeroes
+	// the samples. Rolling chunk parts are blanked the same way for the
+	// ranges (and secure windows of CallID, pkg/redact) known when the
+	// part is uploaded; ranges registered later only reach the final
+	// WAV, so Flush deletes the parts whether or not it succeeds.
+	RedactMode redact.AudioMode
+
+	// Format of the final recording object: FormatWAV, FormatOpus or
+	// FormatMP3. Empty = DefaultFormat() (RECORDING_FORMAT env). Formats
+	// that cannot be produced for this call fall back to WAV.
+	Format Format
 }
 
 // legPlacer tracks one leg's session-wide PCM placement state. Each
@@ -154,6 +172,17 @@ type Recorder struct {
 	outFirstNs, outLastNs int64
 	outBytes              int64
 	outRateWarned         bool
+
+	// redactions are wall-clock ranges (PII matched on ASR word
+	// timestamps, secure script steps) blanked on both legs at Flush.
+	redactions []redact.TimeRange
+
+	// Pause state (PCI card capture). While pausedAtNs != 0 append drops
+	// every frame on both legs, so the paused audio never reaches memory,
+	// chunk parts or the final WAV; the timeline gap is zero-padded like
+	// any other silence. gaps records closed pauses for WAV metadata.
+	pausedAtNs int64
+	gaps       []gateway.RecordingGap
 }
 
 // 采样率运行时校验阈值。
@@ -261,6 +290,7 @@ func (r *Recorder) uploadNextChunk() {
 	baseNs := r.sessionBaseNs
 	rate := r.cfg.SampleRate
 	seq := r.partSeq + 1
+	redactions := append([]redact.TimeRange(nil), r.redactions...)
 	r.mu.Unlock()
 
 	snapNs := utils.RecordingJitterSnapNs()
@@ -271,6 +301,22 @@ func (r *Recorder) uploadNextChunk() {
 	if len(lBytes) == 0 && len(rBytes) == 0 {
 		return
 	}
+	// Blank what is already known to be sensitive before anything
+	// leaves the process; Flush re-applies every range to the final WAV.
+	// A still-open secure window covers everything placed so far.
+	penEnd := inP.pen
+	if outP.pen > penEnd {
+		penEnd = outP.pen
+	}
+	placedEnd := time.Unix(0, baseNs+penEnd*1_000_000_000/int64(rate))
+	for _, w := range redact.SecureWindowsSoFar(r.cfg.CallID, placedEnd) {
+		redactions = append(redactions, redact.TimeRange{Start: w.Start, End: w.End})
+	}
+	if blanks := redact.ToSampleRanges(redactions, time.Unix(0, baseNs), rate); len(blanks) > 0 {
+		mode := r.redactMode()
+		redact.MaskPCM16(lBytes, inPenBefore, rate, blanks, mode)
+		redact.MaskPCM16(rBytes, outPenBefore, rate, blanks, mode)
+	}
 
 	store := r.cfg.Store
 	if store == nil {
@@ -423,7 +469,7 @@ func (r *Recorder) append(caller bool, pcm []byte) {
 	buf := append([]byte(nil), pcm...)
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if r.flushed {
+	if r.flushed || r.pausedAtNs != 0 {
 		return
 	}
 	f := frame{wallNs: time.Now().UnixNano(), pcm: buf}
@@ -445,6 +491,80 @@ func (r *Recorder) append(caller bool, pcm []byte) {
 	}
 }
 
+// Pause stops capturing both legs until Resume. Returns false when
+// already paused, flushed or nil.
+func (r *Recorder) Pause() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.flushed || r.pausedAtNs != 0 {
+		return false
+	}
+	r.pausedAtNs = time.Now().UnixNano()
+	return true
+}
+
+// Resume restarts capture after Pause and returns the closed gap.
+// Returns false when not paused.
+func (r *Recorder) Resume() (gateway.RecordingGap, bool) {
+	if r == nil {
+		return gateway.RecordingGap{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pausedAtNs == 0 {
+		return gateway.RecordingGap{}, false
+	}
+	return r.closeGapLocked(time.Now().UnixNano()), true
+}
+
+// Paused reports whether capture is currently paused.
+func (r *Recorder) Paused() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pausedAtNs != 0
+}
+
+// closeGapLocked ends the open pause at nowNs. The wall-clock gap also
+// starves the sample-rate check, so its observation windows restart
+// from the next frame instead of reporting a bogus low implied rate.
+func (r *Recorder) closeGapLocked(nowNs int64) gateway.RecordingGap {
+	g := gateway.RecordingGap{
+		Start: time.Unix(0, r.pausedAtNs),
+		End:   time.Unix(0, nowNs),
+	}
+	r.gaps = append(r.gaps, g)
+	r.pausedAtNs = 0
+	r.inFirstNs, r.inLastNs, r.inBytes = 0, 0, 0
+	r.outFirstNs, r.outLastNs, r.outBytes = 0, 0, 0
+	return g
+}
+
+// Redact registers wall-clock ranges to blank on both legs of the final
+// recording. Ranges may arrive in any order and may overlap; they are
+// merged at Flush. nil-safe; ignored after Flush.
+func (r *Recorder) Redact(ranges ...redact.TimeRange) {
+	if r == nil || len(ranges) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.flushed {
+		return
+	}
+	for _, tr := range ranges {
+		if tr.Start.IsZero() || !tr.End.After(tr.Start) {
+			continue
+		}
+		r.redactions = append(r.redactions, tr)
+	}
+}
+
 // updateRateStats 在锁内被 append 调用，累计单路的字节数与时间跨度，并在
 // 累计窗口 ≥ rateCheckMinSeconds 时计算 implied rate（PCM16 = 2 bytes/sample）
 // 与 cfg.SampleRate 的相对偏差。超出 rateCheckTolerance 立即 WARN 一次。
@@ -516,7 +636,8 @@ func (r *Recorder) updateRateStats(caller bool, wallNs, byteCount int64) {
 // Flush stitches every uploaded chunk-part PCM together with the tail
 // (frames that arrived after the last chunker tick), streams the
 // combined stereo WAV to the store via io.Pipe (so peak memory is
-// O(buffer size), not O(call duration)), then deletes the part files.
+// O(buffer size), not O(call duration)), then deletes the part files —
+// also when the upload fails, since parts may predate late redactions.
 //
 // Returns ok=false when there is nothing to record or when the upload
 // failed; the error is logged either way.
@@ -532,6 +653,10 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 		return gateway.RecordingInfo{}, false
 	}
 	r.flushed = true
+	if r.pausedAtNs != 0 {
+		r.closeGapLocked(time.Now().UnixNano())
+	}
+	gaps := r.gaps
 	// Stop the chunker BEFORE taking the snapshot. A chunker tick that
 	// already passed its `flushed` check just races to upload+commit;
 	// our `flushed=true` makes its commit branch into the orphan-delete
@@ -550,6 +675,8 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 	outP := r.outPlacer
 	baseNs := r.sessionBaseNs
 	sessionSet := r.sessionBaseSet
+	redactions := r.redactions
+	r.redactions = nil
 	r.mu.Unlock()
 
 	rate := r.cfg.SampleRate
@@ -581,12 +708,6 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 	if rs := totalRBytes / 2; rs > legSamples {
 		legSamples = rs
 	}
-	if legSamples == 0 {
-		r.log.Info("recorder: zero-length recording, skip",
-			zap.String("call_id", r.cfg.CallID))
-		return gateway.RecordingInfo{}, false
-	}
-
 	store := r.cfg.Store
 	if store == nil {
 		store = stores.Default()
@@ -596,9 +717,40 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 			zap.String("call_id", r.cfg.CallID))
 		return gateway.RecordingInfo{}, false
 	}
+	// Parts may hold audio redacted after they were uploaded, so they
+	// never outlive Flush — not even when the final write fails.
+	defer deletePartObjects(store, parts, r.log, r.cfg.CallID)
+
+	if legSamples == 0 {
+		r.log.Info("recorder: zero-length recording, skip",
+			zap.String("call_id", r.cfg.CallID))
+		return gateway.RecordingInfo{}, false
+	}
+
+	format := r.cfg.Format
+	if format == "" {
+		format = DefaultFormat()
+	}
+	if f, why := usableFormat(format, rate); why != "" {
+		r.log.Warn("recorder: format unavailable, writing wav",
+			zap.String("call_id", r.cfg.CallID),
+			zap.String("format", string(format)),
+			zap.String("reason", why))
+		format = f
+	}
 
 	ts := time.Now().Unix()
-	key := fmt.Sprintf("%s-%d.wav", sanitizeFilename(r.cfg.CallID), ts)
+	key := fmt.Sprintf("%s-%d%s", sanitizeFilename(r.cfg.CallID), ts, format.Ext())
+
+	blanks := redact.ToSampleRanges(redactions, time.Unix(0, baseNs), rate)
+	for i := range gaps {
+		if off := gaps[i].Start.UnixNano() - baseNs; off > 0 {
+			gaps[i].OffsetMs = off / 1_000_000
+		}
+	}
+	comment := gapComment(gaps)
+	trailer := wavInfoTrailer(comment)
+	redactMode := r.redactMode()
 
 	// Streaming pipeline:
 	//
@@ -623,12 +775,31 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 		rChain := newPartReaderChain(store, parts, false /*R*/, tailR)
 		defer lChain.Close()
 		defer rChain.Close()
-		if err := writeWAVHeaderTo(mw, rate, 2, legSamples); err != nil {
+		var lr, rr io.Reader = lChain, rChain
+		if len(blanks) > 0 {
+			lr = newRedactingReader(lChain, blanks, redactMode, rate)
+			rr = newRedactingReader(rChain, blanks, redactMode, rate)
+		}
+		if format != FormatWAV {
+			if err := encodeCompressed(format, mw, lr, rr, rate, legSamples, comment); err != nil {
+				streamErr = err
+				_ = pw.CloseWithError(err)
+				return
+			}
+			_ = pw.Close()
+			return
+		}
+		if err := writeWAVHeaderWithTrailerTo(mw, rate, 2, legSamples, len(trailer)); err != nil {
 			streamErr = err
 			_ = pw.CloseWithError(err)
 			return
 		}
-		if err := streamInterleave(mw, lChain, rChain, legSamples); err != nil {
+		if err := streamInterleave(mw, lr, rr, legSamples); err != nil {
+			streamErr = err
+			_ = pw.CloseWithError(err)
+			return
+		}
+		if _, err := mw.Write(trailer); err != nil {
 			streamErr = err
 			_ = pw.CloseWithError(err)
 			return
@@ -652,11 +823,6 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 		return gateway.RecordingInfo{}, false
 	}
 
-	// Reclaim every part object (3 keys per chunk) now that the
-	// canonical final WAV is durable. Best-effort: delete failures are
-	// logged but never demote the recording's success.
-	deletePartObjects(store, parts, r.log, r.cfg.CallID)
-
 	durationMs := legSamples * 1000 / int64(rate)
 	hash := "sha256:" + hex.EncodeToString(hasher.Sum(nil))
 	url := strings.TrimSpace(stores.PublicObjectURL(store, key))
@@ -664,29 +830,42 @@ func (r *Recorder) Flush(ctx context.Context) (gateway.RecordingInfo, bool) {
 		url = key
 	}
 
-	r.log.Info("recorder: wav written (streamed)",
+	r.log.Info("recorder: recording written (streamed)",
 		zap.String("call_id", r.cfg.CallID),
+		zap.String("format", string(format)),
 		zap.String("transport", r.cfg.Transport),
 		zap.String("codec", r.cfg.Codec),
 		zap.Int("rate", rate),
 		zap.Int64("bytes", totalBytes),
 		zap.Int64("samples", legSamples),
 		zap.Int("parts", len(parts)),
+		zap.Int("redacted_ranges", len(blanks)),
+		zap.Int("paused_gaps", len(gaps)),
 		zap.String("key", key))
 
 	return gateway.RecordingInfo{
 		Key:        key,
 		URL:        url,
-		Format:     "wav",
+		Format:     string(format),
 		Layout:     "stereo-l-r",
 		SampleRate: rate,
 		Channels:   2,
 		Bytes:      int(totalBytes),
 		DurationMs: durationMs,
 		Hash:       hash,
+		Gaps:       gaps,
 	}, true
 }
 
+// redactMode is how redacted ranges are rendered: beep when configured,
+// silence otherwise.
+func (r *Recorder) redactMode() redact.AudioMode {
+	if r.cfg.RedactMode == redact.AudioBeep {
+		return redact.AudioBeep
+	}
+	return redact.AudioSilence
+}
+
 // counterWriter tallies bytes written through it. Used to populate
 // RecordingInfo.Bytes during the streaming Flush since the final WAV
 // never lives in one buffer we could len() on.
@@ -788,6 +967,53 @@ func (c *partReaderChain) Close() error {
 	return nil
 }
 
+// redactingReader overwrites the samples of one leg's PCM16 stream that
+// fall inside blanks. It tracks the absolute byte offset so ranges are
+// honoured across arbitrary Read boundaries (including odd splits).
+type redactingReader struct {
+	r      io.Reader
+	off    int64 // bytes consumed so far on this leg
+	blanks []redact.SampleRange
+	mode   redact.AudioMode
+	rate   int
+}
+
+func newRedactingReader(r io.Reader, blanks []redact.SampleRange, mode redact.AudioMode, rate int) *redactingReader {
+	return &redactingReader{r: r, blanks: blanks, mode: mode, rate: rate}
+}
+
+func (rr *redactingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if n > 0 {
+		start := rr.off
+		rr.off += int64(n)
+		for len(rr.blanks) > 0 && rr.blanks[0].End*2 <= start {
+			rr.blanks = rr.blanks[1:]
+		}
+		for _, b := range rr.blanks {
+			from, to := b.Start*2, b.End*2
+			if from >= rr.off {
+				break
+			}
+			if from < start {
+				from = start
+			}
+			if to > rr.off {
+				to = rr.off
+			}
+			for i := from; i < to; i++ {
+				v := uint16(rr.mode.Sample(i/2, rr.rate))
+				if i%2 == 0 {
+					p[i-start] = byte(v)
+				} else {
+					p[i-start] = byte(v >> 8)
+				}
+			}
+		}
+	}
+	return n, err
+}
+
 // streamInterleave reads up to legSamples L+R PCM16 sample pairs from
 // lr / rr and writes 4-byte interleaved stereo samples to w. EOF on
 // either input is treated as zero samples (the corresponding leg ran
@@ -834,10 +1060,43 @@ func streamInterleave(w io.Writer, lr, rr io.Reader, legSamples int64) error {
 	return nil
 }
 
+// encodeCompressed streams the interleaved stereo timeline through the
+// Opus/OGG or MP3 encoder into w. comment carries the paused-gap note
+// (Vorbis comment / ID3 comment) that WAV puts in its ICMT trailer.
+func encodeCompressed(format Format, w io.Writer, lr, rr io.Reader, rate int, legSamples int64, comment string) error {
+	var (
+		enc io.WriteCloser
+		err error
+	)
+	switch format {
+	case FormatOpus:
+		enc, err = newOggOpusWriter(w, rate, legSamples, comment)
+	case FormatMP3:
+		enc, err = newMP3Writer(w, rate, comment)
+	default:
+		err = fmt.Errorf("recorder: no encoder for format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	if err := streamInterleave(enc, lr, rr, legSamples); err != nil {
+		_ = enc.Close()
+		return err
+	}
+	return enc.Close()
+}
+
 // writeWAVHeaderTo emits the canonical 44-byte RIFF/WAVE header for
 // PCM16 with a known data-chunk size. The caller is responsible for
 // streaming exactly `legSamples * channels * 2` bytes of PCM after.
 func writeWAVHeaderTo(w io.Writer, sampleRate, channels int, legSamples int64) error {
+	return writeWAVHeaderWithTrailerTo(w, sampleRate, channels, legSamples, 0)
+}
+
+// writeWAVHeaderWithTrailerTo is writeWAVHeaderTo for a file that carries
+// trailerBytes of extra RIFF chunks after the data chunk (see
+// wavInfoTrailer); the RIFF size covers them so players skip cleanly.
+func writeWAVHeaderWithTrailerTo(w io.Writer, sampleRate, channels int, legSamples int64, trailerBytes int) error {
 	const (
 		bitsPerSample = 16
 		fmtChunkSize  = 16
@@ -847,7 +1106,7 @@ func writeWAVHeaderTo(w io.Writer, sampleRate, channels int, legSamples int64) e
 		channels = 1
 	}
 	dataSize := uint32(legSamples) * uint32(channels) * uint32(bitsPerSample/8)
-	totalSize := uint32(4) + 8 + uint32(fmtChunkSize) + 8 + dataSize
+	totalSize := uint32(4) + 8 + uint32(fmtChunkSize) + 8 + dataSize + uint32(trailerBytes)
 	byteRate := uint32(sampleRate * channels * bitsPerSample / 8)
 	blockAlign := uint16(channels * bitsPerSample / 8)
 
@@ -869,6 +1128,40 @@ func writeWAVHeaderTo(w io.Writer, sampleRate, channels int, legSamples int64) e
 	return err
 }
 
+// gapComment renders paused gaps as "recording paused: <offset>s+<dur>s, …"
+// for the WAV ICMT tag. Empty when there are none.
+func gapComment(gaps []gateway.RecordingGap) string {
+	if len(gaps) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(gaps))
+	for _, g := range gaps {
+		parts = append(parts, fmt.Sprintf("%.3fs+%.3fs",
+			float64(g.OffsetMs)/1000, g.End.Sub(g.Start).Seconds()))
+	}
+	return "recording paused: " + strings.Join(parts, ", ")
+}
+
+// wavInfoTrailer builds a LIST/INFO chunk holding comment as ICMT, to be
+// appended after the data chunk. Returns nil for an empty comment.
+func wavInfoTrailer(comment string) []byte {
+	if comment == "" {
+		return nil
+	}
+	text := append([]byte(comment), 0) // ZSTR
+	if len(text)%2 != 0 {
+		text = append(text, 0) // RIFF word alignment
+	}
+	var b bytes.Buffer
+	b.WriteString("LIST")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+8+len(text)))
+	b.WriteString("INFO")
+	b.WriteString("ICMT")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(text)))
+	b.Write(text)
+	return b.Bytes()
+}
+
 // deletePartObjects best-effort removes every key referenced by the
 // manifest. Failures are logged but don't propagate — by this point
 // the canonical final WAV is durable; orphaned parts are wasted
@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/LinByte/VoiceServer/pkg/redact"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 )
@@ -668,3 +669,146 @@ func TestRecorder_StreamMergeFromExistingParts(t *testing.T) {
 	check(180, 0x00, 0x22) // L exhausted, R continues with chunk 2
 	check(219, 0x00, 0x22)
 }
+
+func TestRecorder_RedactBlanksRange(t *testing.T) {
+	store := newMemStore()
+	r := New(Config{CallID: "call-redact", SampleRate: 16000, Store: store})
+	start := time.Now()
+	caller := make([]byte, 3200) // 100 ms
+	for i := 0; i < len(caller); i += 2 {
+		binary.LittleEndian.PutUint16(caller[i:], 1000)
+	}
+	r.WriteCaller(caller)
+	// Cover the whole caller burst plus slop on both sides.
+	r.Redact(redact.TimeRange{Start: start.Add(-time.Second), End: start.Add(time.Second)})
+	r.Redact(redact.TimeRange{}) // zero range ignored
+
+	info, ok := r.Flush(context.Background())
+	if !ok {
+		t.Fatal("Flush returned ok=false")
+	}
+	data := store.snapshot()[info.Key][44:]
+	for i := 0; i+1 < len(data); i += 4 {
+		if data[i] != 0 || data[i+1] != 0 {
+			t.Fatalf("left sample at byte %d not blanked: %02x%02x", i, data[i], data[i+1])
+		}
+	}
+	r.Redact(redact.TimeRange{Start: start, End: start.Add(time.Second)}) // after Flush: no-op, no panic
+}
+
+func TestRecorder_PauseDropsFramesAndTagsWAV(t *testing.T) {
+	store := newMemStore()
+	r := New(Config{CallID: "call-pause", SampleRate: 8000, Store: store})
+	loud := make([]byte, 160)
+	for i := 0; i < len(loud); i += 2 {
+		binary.LittleEndian.PutUint16(loud[i:], 1000)
+	}
+	r.WriteCaller(make([]byte, 160))
+	if !r.Pause() || r.Pause() || !r.Paused() {
+		t.Fatal("Pause must succeed once and report Paused")
+	}
+	r.WriteCaller(loud) // dropped
+	r.WriteAI(loud)     // dropped
+	time.Sleep(20 * time.Millisecond)
+	if g, ok := r.Resume(); !ok || !g.End.After(g.Start) {
+		t.Fatalf("Resume: gap=%+v ok=%v", g, ok)
+	}
+	if _, ok := r.Resume(); ok {
+		t.Fatal("second Resume must report not paused")
+	}
+	r.Pause() // left open: Flush closes it
+
+	info, ok := r.Flush(context.Background())
+	if !ok {
+		t.Fatal("Flush returned ok=false")
+	}
+	if len(info.Gaps) != 2 {
+		t.Fatalf("gaps: %+v", info.Gaps)
+	}
+	wav := store.snapshot()[info.Key]
+	if riff := binary.LittleEndian.Uint32(wav[4:8]); int(riff) != len(wav)-8 {
+		t.Fatalf("RIFF size %d, file %d", riff, len(wav))
+	}
+	dataSize := int(binary.LittleEndian.Uint32(wav[40:44]))
+	for i := 44; i < 44+dataSize; i += 2 {
+		if wav[i] != 0 || wav[i+1] != 0 {
+			t.Fatalf("paused audio leaked at byte %d", i)
+		}
+	}
+	trailer := wav[44+dataSize:]
+	if !bytes.HasPrefix(trailer, []byte("LIST")) || !bytes.Contains(trailer, []byte("ICMT")) || !bytes.Contains(trailer, []byte("recording paused: ")) {
+		t.Fatalf("missing LIST/ICMT trailer: %q", trailer)
+	}
+}
+
+// failFinalStore fails writes of the final recording object only.
+type failFinalStore struct{ *memStore }
+
+func (s failFinalStore) Write(key string, body io.Reader) error {
+	if !bytes.Contains([]byte(key), []byte("-part-")) {
+		_, _ = io.Copy(io.Discard, body)
+		return errors.New("store down")
+	}
+	return s.memStore.Write(key, body)
+}
+
+func loudPCM(n int) []byte {
+	pcm := make([]byte, n)
+	for i := 0; i < len(pcm); i += 2 {
+		binary.LittleEndian.PutUint16(pcm[i:], 1000)
+	}
+	return pcm
+}
+
+func waitForParts(t *testing.T, store *memStore) map[string][]byte {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		snap := store.snapshot()
+		for k := range snap {
+			if bytes.Contains([]byte(k), []byte("-part-")) {
+				return snap
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no chunk part uploaded")
+	return nil
+}
+
+func TestRecorder_ChunkPartsBlankSecureWindow(t *testing.T) {
+	const callID = "call-secure-part"
+	store := newMemStore()
+	r := New(Config{CallID: callID, SampleRate: 8000, Store: store, ChunkInterval: 30 * time.Millisecond})
+	redact.BeginSecure(callID)
+	defer redact.ForgetSecure(callID)
+	r.WriteCaller(loudPCM(1600))
+	r.WriteAI(loudPCM(1600))
+
+	snap := waitForParts(t, store)
+	for k, b := range snap {
+		if !bytes.HasSuffix([]byte(k), []byte(".pcm")) {
+			continue
+		}
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] != 0 || b[i+1] != 0 {
+				t.Fatalf("secure audio in part %s at byte %d", k, i)
+			}
+		}
+	}
+	r.Flush(context.Background())
+}
+
+func TestRecorder_FlushFailureDeletesParts(t *testing.T) {
+	mem := newMemStore()
+	r := New(Config{CallID: "call-flush-fail", SampleRate: 8000, Store: failFinalStore{mem}, ChunkInterval: 30 * time.Millisecond})
+	r.WriteCaller(loudPCM(1600))
+	waitForParts(t, mem)
+
+	if _, ok := r.Flush(context.Background()); ok {
+		t.Fatal("Flush must fail when the final write fails")
+	}
+	if left := mem.snapshot(); len(left) != 0 {
+		t.Fatalf("parts left after failed Flush: %v", keysOf(left))
+	}
+}
@@ -1,20 +1,34 @@
 // Copyright (c) 2026 LinByte. All rights reserved.
 // SPDX-License-Identifier: AGPL-3.0
 
-// Package vad is an energy-based (RMS) voice activity detector tuned for
-// barge-in: detecting that a human has started to talk while the AI's
-// TTS is still playing, so the gateway can interrupt synthesis and let
-// the user take the floor.
+// Package vad detects voice activity for barge-in — a human starting to
+// talk while the AI's TTS is still playing, so the gateway can interrupt
+// synthesis and let the user take the floor — and, on the native
+// cascaded path, for end-of-turn timing.
 //
-// The detector is transport-agnostic — it consumes PCM16 LE mono frames
-// and emits a boolean "user is speaking now". All three transports in
-// VoiceServer (SIP / xiaozhi / WebRTC) funnel decoded PCM through the
-// shared ASR pipeline; Detector plugs in at that same junction so a
+// The detectors are transport-agnostic — they consume PCM16 LE mono
+// frames and emit a boolean "user is speaking now". All three transports
+// in VoiceServer (SIP / xiaozhi / WebRTC) funnel decoded PCM through the
+// shared ASR pipeline; the detectors plug in at that same junction so a
 // single implementation handles barge-in for every transport.
 //
-// Defaults are calibrated for 20 ms @ 16 kHz frames (320 bytes) from a
-// typical VoIP mic: threshold 1500 RMS, adaptive noise floor tracked
+// The default engine is an energy (RMS) gate, Detector. Its defaults
+// are calibrated for 20 ms @ 16 kHz frames (320 bytes) from a typical
+// VoIP mic: threshold 1500 RMS, adaptive noise floor tracked
 // over 20 quiet frames, one over-threshold frame fires. Tune via the
 // Set* methods when running on headset, conference-room, or WebRTC
 // browser inputs — browser auto-gain tends to need a lower threshold.
+//
+// Besides RMS the package ships probability engines behind the same
+// BargeInDetector interface, selected with New(Config{Engine: ...}):
+//
+//   - spectral: SNR over an adaptive noise floor, pitch-range voicing
+//     and spectral flatness. Model-free; ignores stationary noise that
+//     trips the RMS gate.
+//   - neural: a small GRU over 16 log-mel bands plus voicing and
+//     flatness (GRUModel, weights loaded from JSON), run in pure Go on
+//     the CPU. A missing or invalid model falls back to spectral.
+//
+// Both feed a ProbDetector, whose onset / offset hysteresis also
+// yields a per-frame speech state (IsSpeech) for end-of-turn timing.
 package vad
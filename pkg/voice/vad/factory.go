@@ -0,0 +1,114 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package vad
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/LinByte/VoiceServer/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Engine names accepted by Config.Engine (SIP_VAD_ENGINE).
+const (
+	EngineRMS      = "rms"
+	EngineSpectral = "spectral"
+	EngineNeural   = "neural"
+)
+
+// Config selects and tunes a detector.
+type Config struct {
+	// Engine is rms (default), spectral or neural. Unknown names log a
+	// warning and use rms.
+	Engine string
+	// SampleRate of the PCM the detector is fed; 0 = 16000.
+	SampleRate int
+	// Threshold is the RMS ceiling (rms engine only).
+	Threshold float64
+	// ConsecutiveFrames is the number of speech frames before a
+	// barge-in fires (all engines).
+	ConsecutiveFrames int
+	// ModelPath is the GRUModel JSON file (neural engine only); the
+	// repo ships one at models/vad_gru.json.
+	ModelPath string
+	// Logger receives barge-in events and configuration warnings;
+	// nil uses the process logger for the warnings.
+	Logger *zap.Logger
+}
+
+// New builds the barge-in detector cfg asks for. A neural engine
+// whose model cannot be loaded falls back to spectral with a warning
+// so a bad deployment degrades instead of losing barge-in.
+func New(cfg Config) BargeInDetector {
+	if cfg.engine() == EngineRMS {
+		d := NewDetector()
+		d.SetLogger(cfg.Logger)
+		if cfg.Threshold > 0 {
+			d.SetThreshold(cfg.Threshold)
+		}
+		if cfg.ConsecutiveFrames > 0 {
+			d.SetConsecutiveFrames(cfg.ConsecutiveFrames)
+		}
+		return d
+	}
+	return NewSpeech(cfg)
+}
+
+// NewSpeech builds a probability detector for cfg. The rms engine has
+// no per-frame speech state worth trusting for endpointing, so it maps
+// to spectral here.
+func NewSpeech(cfg Config) *ProbDetector {
+	var d *ProbDetector
+	if cfg.engine() == EngineNeural {
+		m, err := LoadGRUModel(cfg.ModelPath)
+		if err == nil {
+			d = NewNeuralDetector(m, cfg.SampleRate)
+		} else {
+			cfg.warnOnce("model:"+cfg.ModelPath, "vad: neural model unavailable; using spectral engine",
+				zap.String("model_path", cfg.ModelPath), zap.Error(err))
+		}
+	}
+	if d == nil {
+		d = NewSpectralDetector(cfg.SampleRate)
+	}
+	d.SetLogger(cfg.Logger)
+	if cfg.ConsecutiveFrames > 0 {
+		d.SetConsecutiveFrames(cfg.ConsecutiveFrames)
+	}
+	return d
+}
+
+// engine normalises cfg.Engine; an unknown name is a deployment
+// mistake, so it is logged (once per name) before rms takes over.
+func (cfg Config) engine() string {
+	switch e := strings.ToLower(strings.TrimSpace(cfg.Engine)); e {
+	case EngineRMS, EngineSpectral, EngineNeural:
+		return e
+	case "":
+		return EngineRMS
+	default:
+		cfg.warnOnce("engine:"+e, "vad: unknown engine; using rms",
+			zap.String("engine", cfg.Engine))
+		return EngineRMS
+	}
+}
+
+// configWarned keeps per-call detector construction from repeating
+// the same configuration warning on every call.
+var configWarned sync.Map
+
+func (cfg Config) warnOnce(key, msg string, fields ...zap.Field) {
+	lg := cfg.Logger
+	if lg == nil {
+		lg = logger.Lg
+	}
+	if lg == nil {
+		return
+	}
+	if _, seen := configWarned.LoadOrStore(key, struct{}{}); seen {
+		return
+	}
+	lg.Warn(msg, fields...)
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package vad
+
+import (
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+)
+
+// Frame analysis shared by the spectral and neural scorers.
+//
+// Every frame is 20 ms of mono PCM. Band energies only cover
+// 60 Hz – 4 kHz so narrowband (8 kHz) and wideband (16 kHz) calls
+// produce the same feature vector and one model serves both.
+
+const (
+	// FrameDuration is the analysis hop of the probability scorers.
+	FrameDuration = 20 // ms
+
+	// MelBands is the number of log-mel band energies per frame.
+	MelBands = 16
+
+	// FeatureSize is the length of the vector fed to a GRUModel:
+	// MelBands log energies, then voicing and spectral flatness.
+	FeatureSize = MelBands + 2
+
+	melLowHz  = 60.0
+	melHighHz = 4000.0
+
+	pitchMinHz = 70.0
+	pitchMaxHz = 400.0
+)
+
+// frameFeatures is the per-frame analysis result.
+type frameFeatures struct {
+	logMel    [MelBands]float64
+	energyDB  float64 // 20·log10(RMS) in int16 units
+	voicing   float64 // peak normalised autocorrelation in the pitch range, 0..1
+	flatness  float64 // spectral flatness over melLowHz..melHighHz, 0..1
+	vector    [FeatureSize]float64
+	hasSignal bool
+}
+
+// melFilter is one triangular filter over FFT bins.
+type melFilter struct {
+	start   int
+	weights []float64
+}
+
+// featureExtractor holds the per-rate FFT setup. Not safe for
+// concurrent use; each detector owns one.
+type featureExtractor struct {
+	rate     int
+	frameLen int
+	nfft     int
+	window   []float64
+	filters  []melFilter
+	lowBin   int
+	highBin  int
+	minLag   int
+	maxLag   int
+	spec     []complex128
+	power    []float64
+}
+
+func newFeatureExtractor(rate int) *featureExtractor {
+	if rate <= 0 {
+		rate = 16000
+	}
+	frameLen := rate * FrameDuration / 1000
+	nfft := 1
+	for nfft < frameLen {
+		nfft <<= 1
+	}
+	fx := &featureExtractor{
+		rate:     rate,
+		frameLen: frameLen,
+		nfft:     nfft,
+		window:   make([]float64, frameLen),
+		spec:     make([]complex128, nfft),
+		power:    make([]float64, nfft/2+1),
+	}
+	for i := range fx.window {
+		fx.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(frameLen-1))
+	}
+	binHz := float64(rate) / float64(nfft)
+	high := math.Min(melHighHz, float64(rate)/2)
+	fx.lowBin = int(math.Ceil(melLowHz / binHz))
+	fx.highBin = int(math.Floor(high / binHz))
+	if fx.highBin > nfft/2 {
+		fx.highBin = nfft / 2
+	}
+	melLow, melHigh := hzToMel(melLowHz), hzToMel(high)
+	edges := make([]float64, MelBands+2)
+	for i := range edges {
+		edges[i] = melToHz(melLow+(melHigh-melLow)*float64(i)/float64(MelBands+1)) / binHz
+	}
+	for b := 0; b < MelBands; b++ {
+		lo, mid, hi := edges[b], edges[b+1], edges[b+2]
+		start := int(math.Ceil(lo))
+		f := melFilter{start: start}
+		for k := start; float64(k) <= hi && k <= nfft/2; k++ {
+			var w float64
+			if float64(k) <= mid {
+				w = (float64(k) - lo) / math.Max(mid-lo, 1e-9)
+			} else {
+				w = (hi - float64(k)) / math.Max(hi-mid, 1e-9)
+			}
+			f.weights = append(f.weights, math.Max(w, 0))
+		}
+		if len(f.weights) == 0 {
+			// Narrow low bands at 8 kHz can fall between bins; use
+			// the nearest one so every band carries energy.
+			f.start = int(math.Round(mid))
+			f.weights = []float64{1}
+		}
+		fx.filters = append(fx.filters, f)
+	}
+	fx.minLag = rate / int(pitchMaxHz)
+	fx.maxLag = rate / int(pitchMinHz)
+	if fx.maxLag >= frameLen {
+		fx.maxLag = frameLen - 1
+	}
+	return fx
+}
+
+func hzToMel(hz float64) float64  { return 2595 * math.Log10(1+hz/700) }
+func melToHz(mel float64) float64 { return 700 * (math.Pow(10, mel/2595) - 1) }
+
+// analyze computes the features of one frame of exactly frameLen
+// samples (int16 scale).
+func (fx *featureExtractor) analyze(frame []float64) frameFeatures {
+	var ff frameFeatures
+	var sumSq float64
+	for _, s := range frame {
+		sumSq += s * s
+	}
+	rms := math.Sqrt(sumSq / float64(len(frame)))
+	ff.energyDB = 20 * math.Log10(rms+1)
+	ff.hasSignal = rms >= 1
+
+	for i := range fx.spec {
+		fx.spec[i] = 0
+	}
+	for i, s := range frame {
+		fx.spec[i] = complex(s*fx.window[i], 0)
+	}
+	fft(fx.spec)
+	norm := 1 / float64(fx.frameLen)
+	for k := range fx.power {
+		a := cmplx.Abs(fx.spec[k]) * norm
+		fx.power[k] = a * a
+	}
+
+	for b, f := range fx.filters {
+		var e float64
+		for j, w := range f.weights {
+			if k := f.start + j; k < len(fx.power) {
+				e += w * fx.power[k]
+			}
+		}
+		ff.logMel[b] = math.Log10(e + 1e-6)
+	}
+
+	var logSum, linSum float64
+	n := 0
+	for k := fx.lowBin; k <= fx.highBin; k++ {
+		p := fx.power[k] + 1e-6
+		logSum += math.Log(p)
+		linSum += p
+		n++
+	}
+	if n > 0 && linSum > 0 {
+		ff.flatness = math.Exp(logSum/float64(n)) / (linSum / float64(n))
+	}
+
+	if ff.hasSignal {
+		ff.voicing = fx.voicing(frame)
+	}
+
+	copy(ff.vector[:MelBands], ff.logMel[:])
+	ff.vector[MelBands] = ff.voicing
+	ff.vector[MelBands+1] = ff.flatness
+	return ff
+}
+
+// Features returns the GRUModel input vector of every whole 20 ms frame
+// of mono PCM16 LE at sampleRate. cmd/vad-train uses it so a model is
+// trained on exactly the features it is served.
+func Features(pcm []byte, sampleRate int) [][FeatureSize]float64 {
+	fx := newFeatureExtractor(sampleRate)
+	frame := make([]float64, fx.frameLen)
+	var out [][FeatureSize]float64
+	for off := 0; off+2*fx.frameLen <= len(pcm); off += 2 * fx.frameLen {
+		for i := range frame {
+			frame[i] = float64(int16(binary.LittleEndian.Uint16(pcm[off+2*i:])))
+		}
+		ff := fx.analyze(frame)
+		out = append(out, ff.vector)
+	}
+	return out
+}
+
+// voicing returns the peak normalised autocorrelation over pitch
+// lags: near 1 for a periodic (voiced) frame, low for noise.
+func (fx *featureExtractor) voicing(frame []float64) float64 {
+	var mean float64
+	for _, s := range frame {
+		mean += s
+	}
+	mean /= float64(len(frame))
+	best := 0.0
+	for lag := fx.minLag; lag <= fx.maxLag; lag++ {
+		var num, e0, e1 float64
+		for i := 0; i+lag < len(frame); i++ {
+			a, b := frame[i]-mean, frame[i+lag]-mean
+			num += a * b
+			e0 += a * a
+			e1 += b * b
+		}
+		if e0 <= 0 || e1 <= 0 {
+			continue
+		}
+		if r := num / math.Sqrt(e0*e1); r > best {
+			best = r
+		}
+	}
+	return best
+}
+
+// fft is an in-place iterative radix-2 transform; len(x) must be a
+// power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * w
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
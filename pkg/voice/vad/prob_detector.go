@@ -0,0 +1,226 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package vad
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// BargeInDetector is what transports call on every uplink frame while
+// the AI is speaking. Implemented by *Detector (RMS) and *ProbDetector
+// (spectral / neural); build one with New.
+type BargeInDetector interface {
+	CheckBargeIn(pcmData []byte, synthPlaying bool) bool
+}
+
+// SpeechDetector additionally reports per-frame speech state, which
+// end-of-turn detection needs regardless of TTS playback.
+type SpeechDetector interface {
+	BargeInDetector
+	IsSpeech(pcmData []byte) bool
+}
+
+// Hysteresis defaults for ProbDetector.
+const (
+	DefaultOnset           = 0.6
+	DefaultOffset          = 0.35
+	DefaultMinSpeechFrames = 3 // 60 ms
+	DefaultHangoverFrames  = 3 // 60 ms
+)
+
+// ProbDetector turns per-frame speech probabilities into a speech /
+// silence state with hysteresis: speech starts after MinSpeechFrames
+// consecutive frames ≥ onset and ends after hangover frames < offset.
+// It accepts PCM chunks of any length and analyses them in 20 ms
+// frames, carrying the remainder over to the next call.
+type ProbDetector struct {
+	mu       sync.Mutex
+	sc       scorer
+	fx       *featureExtractor
+	pending  []float64
+	frame    []float64
+	enabled  bool
+	onset    float64
+	offset   float64
+	minOn    int
+	hangover int
+	above    int
+	below    int
+	speaking bool
+	fired    bool
+	lastProb float64
+	logger   *zap.Logger
+	engine   string
+}
+
+func newProbDetector(engine string, sc scorer, sampleRate int) *ProbDetector {
+	fx := newFeatureExtractor(sampleRate)
+	return &ProbDetector{
+		sc:       sc,
+		fx:       fx,
+		frame:    make([]float64, fx.frameLen),
+		enabled:  true,
+		onset:    DefaultOnset,
+		offset:   DefaultOffset,
+		minOn:    DefaultMinSpeechFrames,
+		hangover: DefaultHangoverFrames,
+		engine:   engine,
+	}
+}
+
+// NewSpectralDetector builds a model-free probability detector for
+// mono PCM16 LE at sampleRate.
+func NewSpectralDetector(sampleRate int) *ProbDetector {
+	return newProbDetector(EngineSpectral, newSpectralScorer(), sampleRate)
+}
+
+// NewNeuralDetector builds a detector running m with fresh per-call
+// recurrent state.
+func NewNeuralDetector(m *GRUModel, sampleRate int) *ProbDetector {
+	return newProbDetector(EngineNeural, newGRUScorer(m), sampleRate)
+}
+
+// SetLogger attaches an optional zap logger (info on barge-in fires).
+func (d *ProbDetector) SetLogger(logger *zap.Logger) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logger = logger
+}
+
+// SetEnabled toggles detection; a disabled detector reports silence.
+func (d *ProbDetector) SetEnabled(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.enabled = enabled
+}
+
+// Enabled reports whether detection is on.
+func (d *ProbDetector) Enabled() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.enabled
+}
+
+// SetThresholds sets the onset / offset probabilities. Values outside
+// 0..1, or offset above onset, are ignored.
+func (d *ProbDetector) SetThresholds(onset, offset float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if onset <= 0 || onset >= 1 || offset <= 0 || offset > onset {
+		return
+	}
+	d.onset, d.offset = onset, offset
+}
+
+// SetConsecutiveFrames sets how many 20 ms frames above onset start
+// speech. Minimum 1.
+func (d *ProbDetector) SetConsecutiveFrames(frames int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if frames < 1 {
+		frames = 1
+	}
+	d.minOn = frames
+}
+
+// Probability returns the last frame's speech probability.
+func (d *ProbDetector) Probability() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastProb
+}
+
+// IsSpeech feeds pcmData and reports whether the caller is speaking
+// at the end of it.
+func (d *ProbDetector) IsSpeech(pcmData []byte) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.feedLocked(pcmData)
+}
+
+// CheckBargeIn implements BargeInDetector. Unlike the RMS detector the
+// scorer keeps learning from every frame, so callers should feed it
+// with synthPlaying=false too. True is edge-triggered: once per
+// speech onset while synthPlaying.
+func (d *ProbDetector) CheckBargeIn(pcmData []byte, synthPlaying bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	speaking := d.feedLocked(pcmData)
+	if !speaking {
+		d.fired = false
+		return false
+	}
+	if !synthPlaying || d.fired {
+		return false
+	}
+	d.fired = true
+	if d.logger != nil {
+		d.logger.Info("vad: barge-in",
+			zap.String("engine", d.engine),
+			zap.Float64("probability", d.lastProb))
+	}
+	return true
+}
+
+func (d *ProbDetector) feedLocked(pcmData []byte) bool {
+	if !d.enabled {
+		d.speaking = false
+		d.above, d.below = 0, 0
+		return false
+	}
+	for i := 0; i+1 < len(pcmData); i += 2 {
+		d.pending = append(d.pending, float64(int16(pcmData[i])|int16(pcmData[i+1])<<8))
+	}
+	n := d.fx.frameLen
+	consumed := 0
+	for len(d.pending)-consumed >= n {
+		copy(d.frame, d.pending[consumed:consumed+n])
+		consumed += n
+		ff := d.fx.analyze(d.frame)
+		p := d.sc.score(&ff)
+		d.lastProb = p
+		d.step(p)
+	}
+	if consumed > 0 {
+		d.pending = append(d.pending[:0], d.pending[consumed:]...)
+	}
+	return d.speaking
+}
+
+func (d *ProbDetector) step(p float64) {
+	if d.speaking {
+		if p < d.offset {
+			d.below++
+			if d.below >= d.hangover {
+				d.speaking = false
+				d.above, d.below = 0, 0
+			}
+		} else {
+			d.below = 0
+		}
+		return
+	}
+	if p >= d.onset {
+		d.above++
+		if d.above >= d.minOn {
+			d.speaking = true
+			d.above, d.below = 0, 0
+		}
+	} else {
+		d.above = 0
+	}
+}
+
+// Reset clears speech state and the scorer's adaptive state.
+func (d *ProbDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sc.reset()
+	d.pending = d.pending[:0]
+	d.speaking, d.fired = false, false
+	d.above, d.below = 0, 0
+	d.lastProb = 0
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package vad
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testRate = 16000
+
+// voicedPCM synthesises ms of a 150 Hz vowel-like tone (five
+// harmonics) at peak amplitude amp, starting at sample offset off.
+func voicedPCM(ms int, amp float64, off int) []byte {
+	n := testRate * ms / 1000
+	buf := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		t := float64(i+off) / testRate
+		var v float64
+		for h := 1; h <= 5; h++ {
+			v += math.Sin(2*math.Pi*150*float64(h)*t) / float64(h)
+		}
+		binary.LittleEndian.PutUint16(buf[2*i:], uint16(int16(v*amp/2)))
+	}
+	return buf
+}
+
+func noisePCM(ms int, amp float64, rng *rand.Rand) []byte {
+	n := testRate * ms / 1000
+	buf := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint16(buf[2*i:], uint16(int16(rng.NormFloat64()*amp)))
+	}
+	return buf
+}
+
+func mix(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := 0; i+1 < len(a); i += 2 {
+		v := int32(int16(binary.LittleEndian.Uint16(a[i:]))) + int32(int16(binary.LittleEndian.Uint16(b[i:])))
+		if v > math.MaxInt16 {
+			v = math.MaxInt16
+		} else if v < math.MinInt16 {
+			v = math.MinInt16
+		}
+		binary.LittleEndian.PutUint16(out[i:], uint16(int16(v)))
+	}
+	return out
+}
+
+// feed pushes pcm in 20 ms chunks and returns how many chunks ended
+// in speech.
+func feed(d *ProbDetector, pcm []byte) int {
+	speech := 0
+	for i := 0; i < len(pcm); i += 640 {
+		end := i + 640
+		if end > len(pcm) {
+			end = len(pcm)
+		}
+		if d.IsSpeech(pcm[i:end]) {
+			speech++
+		}
+	}
+	return speech
+}
+
+func TestSpectralDetector_VoicedSpeechVsNoise(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	d := NewSpectralDetector(testRate)
+	if n := feed(d, noisePCM(500, 60, rng)); n != 0 {
+		t.Fatalf("room tone scored as speech in %d frames", n)
+	}
+	if n := feed(d, voicedPCM(500, 3000, 0)); n < 20 {
+		t.Fatalf("voiced speech detected in only %d/25 frames", n)
+	}
+	// Loud stationary noise is absorbed into the floor.
+	feed(d, noisePCM(1000, 2000, rng))
+	if n := feed(d, noisePCM(500, 2000, rng)); n != 0 {
+		t.Fatalf("stationary noise still speech in %d frames", n)
+	}
+	// Speech over that noise is still speech.
+	if n := feed(d, mix(voicedPCM(500, 6000, 0), noisePCM(500, 2000, rng))); n < 15 {
+		t.Fatalf("speech over noise detected in only %d/25 frames", n)
+	}
+}
+
+func TestSpectralDetector_NarrowbandAndOddChunks(t *testing.T) {
+	d := NewSpectralDetector(8000)
+	// 8 kHz: resample the 16 kHz tone by dropping every other sample,
+	// fed in 7 ms chunks to exercise the frame carry-over.
+	src := voicedPCM(600, 3000, 0)
+	pcm := make([]byte, 0, len(src)/2)
+	for i := 0; i+3 < len(src); i += 4 {
+		pcm = append(pcm, src[i], src[i+1])
+	}
+	speaking := false
+	for i := 0; i < len(pcm); i += 112 {
+		end := i + 112
+		if end > len(pcm) {
+			end = len(pcm)
+		}
+		speaking = d.IsSpeech(pcm[i:end])
+	}
+	if !speaking || d.Probability() < 0.9 {
+		t.Fatalf("speaking=%v p=%.2f", speaking, d.Probability())
+	}
+}
+
+func TestProbDetector_BargeInEdgeTriggered(t *testing.T) {
+	d := NewSpectralDetector(testRate)
+	frame := voicedPCM(20, 3000, 0)
+	fired := 0
+	for i := 0; i < 20; i++ {
+		if d.CheckBargeIn(frame, true) {
+			fired++
+		}
+	}
+	if fired != 1 {
+		t.Fatalf("fired %d times during one utterance", fired)
+	}
+	silence := make([]byte, 640)
+	for i := 0; i < 10; i++ {
+		d.CheckBargeIn(silence, true)
+	}
+	fired = 0
+	for i := 0; i < 10; i++ {
+		if d.CheckBargeIn(frame, true) {
+			fired++
+		}
+	}
+	if fired != 1 {
+		t.Fatalf("second utterance fired %d times", fired)
+	}
+	// Not playing: state still tracks, nothing fires.
+	d.Reset()
+	for i := 0; i < 10; i++ {
+		if d.CheckBargeIn(frame, false) {
+			t.Fatal("fired while TTS idle")
+		}
+	}
+	d.SetEnabled(false)
+	if d.IsSpeech(frame) || d.Enabled() {
+		t.Fatal("disabled detector must report silence")
+	}
+}
+
+// voicingModel is a one-unit GRU that copies the voicing feature:
+// z=1 (always take the candidate), h' = tanh(4·(voicing−0.5)).
+func voicingModel() *GRUModel {
+	row := func(idx int, w float64) [][]float64 {
+		r := make([]float64, FeatureSize)
+		if idx >= 0 {
+			r[idx] = w
+		}
+		return [][]float64{r}
+	}
+	return &GRUModel{
+		Version: 1,
+		Hidden:  1,
+		Wz:      row(-1, 0), Uz: [][]float64{{0}}, Bz: []float64{20},
+		Wr: row(-1, 0), Ur: [][]float64{{0}}, Br: []float64{0},
+		Wh: row(MelBands, 4), Uh: [][]float64{{0}}, Bh: []float64{-2},
+		Wo: []float64{6}, Bo: 0,
+	}
+}
+
+func TestNeuralDetector_ModelFileAndInference(t *testing.T) {
+	data, err := json.Marshal(voicingModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "vad.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d := NewSpeech(Config{Engine: "neural", ModelPath: path, SampleRate: testRate})
+	if d.engine != EngineNeural {
+		t.Fatalf("engine=%s", d.engine)
+	}
+	rng := rand.New(rand.NewSource(2))
+	if n := feed(d, noisePCM(300, 2000, rng)); n != 0 {
+		t.Fatalf("noise scored as speech in %d frames", n)
+	}
+	if n := feed(d, voicedPCM(300, 3000, 0)); n < 10 {
+		t.Fatalf("voiced detected in %d/15 frames", n)
+	}
+	if m, _ := LoadGRUModel(path); m == nil || m.Hidden != 1 {
+		t.Fatal("model not cached")
+	}
+}
+
+func TestParseGRUModel_Validation(t *testing.T) {
+	m := voicingModel()
+	m.Wh = [][]float64{{1, 2}}
+	data, _ := json.Marshal(m)
+	if _, err := ParseGRUModel(data); err == nil {
+		t.Fatal("wrong column count accepted")
+	}
+	if _, err := ParseGRUModel([]byte(`{"hidden":0}`)); err == nil {
+		t.Fatal("zero hidden accepted")
+	}
+}
+
+func TestNew_EngineSelection(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	lg := zap.New(core)
+	if _, ok := New(Config{Logger: lg}).(*Detector); !ok {
+		t.Fatal("default engine must stay rms")
+	}
+	if d, ok := New(Config{Engine: " Spectral ", Logger: lg}).(*ProbDetector); !ok || d.engine != EngineSpectral {
+		t.Fatal("spectral engine not selected")
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("known engines warned: %v", logs.All())
+	}
+	// Missing model degrades to spectral instead of failing.
+	d := NewSpeech(Config{Engine: EngineNeural, ModelPath: filepath.Join(t.TempDir(), "missing.json"), Logger: lg})
+	if d.engine != EngineSpectral {
+		t.Fatalf("fallback engine=%s", d.engine)
+	}
+	if logs.FilterMessage("vad: neural model unavailable; using spectral engine").Len() != 1 {
+		t.Fatalf("model fallback not logged: %v", logs.All())
+	}
+	if d := NewSpeech(Config{Engine: EngineRMS}); d.engine != EngineSpectral {
+		t.Fatal("rms must map to spectral for speech state")
+	}
+}
+
+func TestNew_UnknownEngineWarnsOnce(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	lg := zap.New(core)
+	for range 3 {
+		if _, ok := New(Config{Engine: "silero", Logger: lg}).(*Detector); !ok {
+			t.Fatal("unknown engine must use rms")
+		}
+	}
+	warned := logs.FilterMessage("vad: unknown engine; using rms")
+	if warned.Len() != 1 || warned.All()[0].ContextMap()["engine"] != "silero" {
+		t.Fatalf("unknown engine warnings: %v", logs.All())
+	}
+}
+
+// readWAV returns the PCM16 mono data chunk of a WAV file.
+func readWAV(t *testing.T, path string) (pcm []byte, rate int) {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for off := 12; off+8 <= len(raw); {
+		id, size := string(raw[off:off+4]), int(binary.LittleEndian.Uint32(raw[off+4:]))
+		body := raw[off+8 : min(off+8+size, len(raw))]
+		switch id {
+		case "fmt ":
+			if binary.LittleEndian.Uint16(body[2:]) != 1 || binary.LittleEndian.Uint16(body[14:]) != 16 {
+				t.Fatalf("%s: want 16-bit mono", path)
+			}
+			rate = int(binary.LittleEndian.Uint32(body[4:]))
+		case "data":
+			return body, rate
+		}
+		off += 8 + size + size%2
+	}
+	t.Fatalf("%s: no data chunk", path)
+	return nil, 0
+}
+
+// The shipped model on speech it was not trained on (not_bind.wav is
+// the trainer's holdout) and on ringback it must ignore.
+func TestNeuralDetector_ShippedModel(t *testing.T) {
+	const model = "../../../models/vad_gru.json"
+	speech, rate := readWAV(t, "../../../scripts/not_bind.wav")
+	d := NewSpeech(Config{Engine: EngineNeural, ModelPath: model, SampleRate: rate})
+	if d.engine != EngineNeural {
+		t.Fatalf("engine=%s", d.engine)
+	}
+	rng := rand.New(rand.NewSource(3))
+	noisy := mix(speech, noisePCM(len(speech)*1000/(2*rate)+FrameDuration, 150, rng))
+	chunk := rate * 2 * FrameDuration / 1000
+	speechFrames, frames := 0, 0
+	for i := 0; i+chunk <= len(noisy); i += chunk {
+		if d.IsSpeech(noisy[i : i+chunk]) {
+			speechFrames++
+		}
+		frames++
+	}
+	if speechFrames < frames/2 {
+		t.Fatalf("speech detected in %d/%d frames", speechFrames, frames)
+	}
+
+	ring, rate := readWAV(t, "../../../scripts/ringing.wav")
+	d = NewSpeech(Config{Engine: EngineNeural, ModelPath: model, SampleRate: rate})
+	chunk = rate * 2 * FrameDuration / 1000
+	fired := 0
+	for i := 0; i+chunk <= len(ring) && i < 10*rate*2; i += chunk {
+		if d.CheckBargeIn(ring[i:i+chunk], true) {
+			fired++
+		}
+	}
+	if fired > 0 {
+		t.Fatalf("ringback fired barge-in %d times", fired)
+	}
+}
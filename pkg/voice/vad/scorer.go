@@ -0,0 +1,234 @@
+// Copyright (c) 2026 LinByte. All rights reserved.
+// SPDX-License-Identifier: AGPL-3.0
+
+package vad
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+// scorer maps one analysed frame to a speech probability in 0..1.
+// Implementations carry per-call state (noise floor, recurrent
+// state) and are owned by exactly one ProbDetector.
+type scorer interface {
+	score(ff *frameFeatures) float64
+	reset()
+}
+
+// spectralScorer is the model-free engine: SNR over a tracked noise
+// floor, periodicity (voicing) and spectral flatness combined by a
+// fixed logistic. Unlike plain RMS it rejects loud stationary noise
+// (fans, line hum, comfort noise) and tracks quiet speech on loud
+// lines; it is also what the neural engine falls back to.
+type spectralScorer struct {
+	noiseDB float64
+}
+
+const (
+	spectralInitNoiseDB = 40 // ≈ 100 RMS room tone
+	spectralMinNoiseDB  = 30
+)
+
+func newSpectralScorer() *spectralScorer {
+	return &spectralScorer{noiseDB: spectralInitNoiseDB}
+}
+
+func (s *spectralScorer) reset() {
+	s.noiseDB = spectralInitNoiseDB
+}
+
+func (s *spectralScorer) score(ff *frameFeatures) float64 {
+	snr := ff.energyDB - s.noiseDB
+	z := 0.3*(snr-10) + 8*(ff.voicing-0.45) - 6*(ff.flatness-0.3)
+	p := sigmoid(z)
+	// Noise floor: fall immediately, rise quickly on aperiodic frames
+	// so a constant loud background is absorbed within ~0.5 s, and
+	// barely move on voiced frames so a long utterance keeps scoring.
+	switch {
+	case ff.energyDB < s.noiseDB:
+		s.noiseDB = ff.energyDB
+	case p < 0.5 || ff.voicing < 0.4:
+		s.noiseDB += 0.05 * (ff.energyDB - s.noiseDB)
+	default:
+		s.noiseDB += 0.002 * (ff.energyDB - s.noiseDB)
+	}
+	if s.noiseDB < spectralMinNoiseDB {
+		s.noiseDB = spectralMinNoiseDB
+	}
+	return p
+}
+
+// GRUModel is a small recurrent speech classifier in the spirit of
+// Silero VAD, evaluated in pure Go on the CPU:
+//
+//	x  = (features − Mean) / Std            (FeatureSize inputs)
+//	z  = σ(Wz·x + Uz·h + Bz)
+//	r  = σ(Wr·x + Ur·h + Br)
+//	h' = tanh(Wh·x + Uh·(r⊙h) + Bh)
+//	h  = (1−z)⊙h + z⊙h'
+//	p  = σ(Wo·h + Bo)
+//
+// Weights are trained offline by cmd/vad-train and shipped as JSON
+// (models/vad_gru.json, SIP_VAD_MODEL_PATH); the model is read-only
+// and shared by every call.
+type GRUModel struct {
+	Version int       `json:"version"`
+	Hidden  int       `json:"hidden"`
+	Mean    []float64 `json:"mean"`
+	Std     []float64 `json:"std"`
+
+	Wz [][]float64 `json:"wz"`
+	Uz [][]float64 `json:"uz"`
+	Bz []float64   `json:"bz"`
+	Wr [][]float64 `json:"wr"`
+	Ur [][]float64 `json:"ur"`
+	Br []float64   `json:"br"`
+	Wh [][]float64 `json:"wh"`
+	Uh [][]float64 `json:"uh"`
+	Bh []float64   `json:"bh"`
+
+	Wo []float64 `json:"wo"`
+	Bo float64   `json:"bo"`
+}
+
+// ParseGRUModel decodes and validates a model file.
+func ParseGRUModel(data []byte) (*GRUModel, error) {
+	var m GRUModel
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("vad: parse model: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *GRUModel) validate() error {
+	h := m.Hidden
+	if h <= 0 || h > 256 {
+		return fmt.Errorf("vad: model hidden size %d out of range 1..256", h)
+	}
+	if m.Mean != nil && len(m.Mean) != FeatureSize {
+		return fmt.Errorf("vad: model mean has %d entries, want %d", len(m.Mean), FeatureSize)
+	}
+	if m.Std != nil && len(m.Std) != FeatureSize {
+		return fmt.Errorf("vad: model std has %d entries, want %d", len(m.Std), FeatureSize)
+	}
+	mats := []struct {
+		name       string
+		m          [][]float64
+		rows, cols int
+	}{
+		{"wz", m.Wz, h, FeatureSize}, {"wr", m.Wr, h, FeatureSize}, {"wh", m.Wh, h, FeatureSize},
+		{"uz", m.Uz, h, h}, {"ur", m.Ur, h, h}, {"uh", m.Uh, h, h},
+	}
+	for _, x := range mats {
+		if len(x.m) != x.rows {
+			return fmt.Errorf("vad: model %s has %d rows, want %d", x.name, len(x.m), x.rows)
+		}
+		for _, row := range x.m {
+			if len(row) != x.cols {
+				return fmt.Errorf("vad: model %s row has %d columns, want %d", x.name, len(row), x.cols)
+			}
+		}
+	}
+	for name, b := range map[string][]float64{"bz": m.Bz, "br": m.Br, "bh": m.Bh, "wo": m.Wo} {
+		if len(b) != h {
+			return fmt.Errorf("vad: model %s has %d entries, want %d", name, len(b), h)
+		}
+	}
+	return nil
+}
+
+var (
+	modelCacheMu sync.Mutex
+	modelCache   = map[string]*GRUModel{}
+)
+
+// LoadGRUModel reads a model file once per path; later calls return
+// the cached model.
+func LoadGRUModel(path string) (*GRUModel, error) {
+	modelCacheMu.Lock()
+	defer modelCacheMu.Unlock()
+	if m, ok := modelCache[path]; ok {
+		return m, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("vad: read model: %w", err)
+	}
+	m, err := ParseGRUModel(data)
+	if err != nil {
+		return nil, err
+	}
+	modelCache[path] = m
+	return m, nil
+}
+
+// gruScorer runs a GRUModel with per-call recurrent state.
+type gruScorer struct {
+	m       *GRUModel
+	h, x, r []float64
+	cand    []float64
+	rh      []float64
+}
+
+func newGRUScorer(m *GRUModel) *gruScorer {
+	return &gruScorer{
+		m:    m,
+		h:    make([]float64, m.Hidden),
+		x:    make([]float64, FeatureSize),
+		r:    make([]float64, m.Hidden),
+		cand: make([]float64, m.Hidden),
+		rh:   make([]float64, m.Hidden),
+	}
+}
+
+func (g *gruScorer) reset() {
+	for i := range g.h {
+		g.h[i] = 0
+	}
+}
+
+func (g *gruScorer) score(ff *frameFeatures) float64 {
+	m := g.m
+	for i, v := range ff.vector {
+		if m.Mean != nil {
+			v -= m.Mean[i]
+		}
+		if m.Std != nil && m.Std[i] != 0 {
+			v /= m.Std[i]
+		}
+		g.x[i] = v
+	}
+	for j := 0; j < m.Hidden; j++ {
+		g.r[j] = sigmoid(dot(m.Wr[j], g.x) + dot(m.Ur[j], g.h) + m.Br[j])
+		g.rh[j] = g.r[j] * g.h[j]
+	}
+	for j := 0; j < m.Hidden; j++ {
+		g.cand[j] = math.Tanh(dot(m.Wh[j], g.x) + dot(m.Uh[j], g.rh) + m.Bh[j])
+	}
+	// z is computed per unit after the candidate so the update reads
+	// the previous h for every gate.
+	next := g.r // reuse: r is no longer needed
+	for j := 0; j < m.Hidden; j++ {
+		z := sigmoid(dot(m.Wz[j], g.x) + dot(m.Uz[j], g.h) + m.Bz[j])
+		next[j] = (1-z)*g.h[j] + z*g.cand[j]
+	}
+	copy(g.h, next)
+	return sigmoid(dot(m.Wo, g.h) + m.Bo)
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }